@@ -0,0 +1,116 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/xataio/pgroll/pkg/backfill"
+)
+
+func backfillCmd() *cobra.Command {
+	backfillCmd := &cobra.Command{
+		Use:   "backfill",
+		Short: "Manage the backfills of the active migration",
+	}
+
+	backfillCmd.AddCommand(backfillResumeCmd())
+
+	return backfillCmd
+}
+
+func backfillResumeCmd() *cobra.Command {
+	var complete bool
+	var bf backfillFlags
+
+	resumeCmd := &cobra.Command{
+		Use:     "resume",
+		Short:   "Resume the interrupted backfills of the active migration from their last checkpoint",
+		Example: "backfill resume --complete",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx := cmd.Context()
+
+			// Create a roll instance and check if pgroll is initialized
+			m, err := NewRollWithInitCheck(ctx)
+			if err != nil {
+				return err
+			}
+			defer m.Close()
+
+			c := bf.config()
+
+			sp, _ := pterm.DefaultSpinner.WithText("Resuming backfill...").Start()
+			c.AddCallback(backfillProgressCallback(sp))
+
+			if err := m.ResumeBackfill(ctx, c); err != nil {
+				sp.Fail(fmt.Sprintf("Failed to resume backfill: %s", err))
+				return err
+			}
+
+			if complete {
+				if err := m.Complete(ctx); err != nil {
+					sp.Fail(fmt.Sprintf("Failed to complete migration: %s", err))
+					return err
+				}
+			}
+
+			sp.Success("Backfill complete")
+			return nil
+		},
+	}
+
+	bf.addFlags(resumeCmd.Flags())
+	resumeCmd.Flags().BoolVarP(&complete, "complete", "c", false, "Complete the migration once the backfill has finished")
+	addWaitForClientsFlags(resumeCmd)
+
+	return resumeCmd
+}
+
+// addResumableBackfillFlag adds the --resumable-backfill flag to a command
+// that can start a backfill
+func addResumableBackfillFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool("resumable-backfill", false, "Leave the migration in progress if a backfill fails so that it can be resumed with `pgroll backfill resume`")
+	bindFlagOnRun(cmd, "RESUMABLE_BACKFILL", "resumable-backfill")
+}
+
+// backfillFlags holds the flags that configure how backfills are run, shared
+// by all commands that can start a backfill.
+type backfillFlags struct {
+	batchSize           int
+	batchDelay          time.Duration
+	concurrency         int
+	maxReplicationLag   time.Duration
+	throttleProbe       string
+	throttleThreshold   float64
+	targetBatchDuration time.Duration
+}
+
+func (f *backfillFlags) addFlags(fs *pflag.FlagSet) {
+	fs.IntVar(&f.batchSize, "backfill-batch-size", backfill.DefaultBatchSize, "Number of rows backfilled in each batch")
+	fs.DurationVar(&f.batchDelay, "backfill-batch-delay", backfill.DefaultDelay, "Duration of delay between batch backfills (eg. 1s, 1000ms)")
+	fs.IntVar(&f.concurrency, "backfill-concurrency", backfill.DefaultConcurrency, "Number of batches backfilled in parallel")
+	fs.DurationVar(&f.maxReplicationLag, "backfill-max-replication-lag", 0, "Pause the backfill while the replay lag of any replica exceeds this duration (eg. 10s)")
+	fs.StringVar(&f.throttleProbe, "backfill-throttle-probe", "", "SQL query returning a single number; the backfill is paused while it exceeds --backfill-throttle-threshold")
+	fs.Float64Var(&f.throttleThreshold, "backfill-throttle-threshold", 0, "Threshold above which --backfill-throttle-probe pauses the backfill")
+	fs.DurationVar(&f.targetBatchDuration, "backfill-target-batch-duration", 0, "Adapt the batch size so that each batch takes approximately this duration (eg. 500ms)")
+}
+
+func (f *backfillFlags) config() *backfill.Config {
+	opts := []backfill.OptionFn{
+		backfill.WithBatchSize(f.batchSize),
+		backfill.WithBatchDelay(f.batchDelay),
+		backfill.WithConcurrency(f.concurrency),
+		backfill.WithMaxReplicationLag(f.maxReplicationLag),
+		backfill.WithTargetBatchDuration(f.targetBatchDuration),
+	}
+	if f.throttleProbe != "" {
+		opts = append(opts, backfill.WithThrottleProbe(f.throttleProbe, f.throttleThreshold))
+	}
+	return backfill.NewConfig(opts...)
+}
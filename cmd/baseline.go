@@ -11,11 +11,13 @@ import (
 	"github.com/pterm/pterm"
 	"github.com/spf13/cobra"
 	"github.com/xataio/pgroll/pkg/migrations"
+	"github.com/xataio/pgroll/pkg/sql2pgroll"
 )
 
 func baselineCmd() *cobra.Command {
 	var useJSON bool
 	var yes bool
+	var generate bool
 
 	baselineCmd := &cobra.Command{
 		Use:       "baseline <version> <target directory>",
@@ -49,21 +51,33 @@ func baselineCmd() *cobra.Command {
 				}
 			}
 
-			// Create a placeholder baseline migration
+			// Create a placeholder baseline migration, or one whose operations
+			// recreate the current schema
 			ops := migrations.Operations{&migrations.OpRawSQL{Up: ""}}
+			if generate {
+				s, err := m.State().ReadSchema(ctx, m.Schema())
+				if err != nil {
+					return fmt.Errorf("failed to read schema: %w", err)
+				}
+				ops, err = sql2pgroll.ConvertSchema(s)
+				if err != nil {
+					return fmt.Errorf("failed to generate operations: %w", err)
+				}
+			}
 			opsJSON, err := json.Marshal(ops)
 			if err != nil {
 				return fmt.Errorf("failed to marshal operations: %w", err)
 			}
 			mig := &migrations.RawMigration{
 				Name:       version,
+				Baseline:   generate,
 				Operations: opsJSON,
 			}
 
-			// Write the placeholder migration to disk
+			// Write the baseline migration to disk
 			filePath, err := writeMigrationToFile(mig, targetDir, "", useJSON)
 			if err != nil {
-				return fmt.Errorf("failed to write placeholder baseline migration: %w", err)
+				return fmt.Errorf("failed to write baseline migration: %w", err)
 			}
 
 			sp, _ := pterm.DefaultSpinner.WithText(fmt.Sprintf("Creating baseline migration %q...", version)).Start()
@@ -76,13 +90,18 @@ func baselineCmd() *cobra.Command {
 				return err
 			}
 
-			sp.Success(fmt.Sprintf("Baseline created successfully. Placeholder migration %q written", filePath))
+			if generate {
+				sp.Success(fmt.Sprintf("Baseline created successfully. Baseline migration %q written", filePath))
+			} else {
+				sp.Success(fmt.Sprintf("Baseline created successfully. Placeholder migration %q written", filePath))
+			}
 			return nil
 		},
 	}
 
 	baselineCmd.Flags().BoolVarP(&useJSON, "json", "j", false, "output in JSON format instead of YAML")
 	baselineCmd.Flags().BoolVarP(&yes, "yes", "y", false, "skip confirmation prompt")
+	baselineCmd.Flags().BoolVar(&generate, "generate-operations", false, "generate operations that recreate the current schema instead of a placeholder migration")
 
 	return baselineCmd
 }
@@ -4,30 +4,51 @@ package cmd
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/pterm/pterm"
 	"github.com/spf13/cobra"
 )
 
-var completeCmd = &cobra.Command{
-	Use:   "complete <file>",
-	Short: "Complete an ongoing migration with the operations present in the given file",
-	RunE: func(cmd *cobra.Command, args []string) error {
-		// Create a roll instance and check if pgroll is initialized
-		m, err := NewRollWithInitCheck(cmd.Context())
-		if err != nil {
-			return err
-		}
-		defer m.Close()
-
-		sp, _ := pterm.DefaultSpinner.WithText("Completing migration...").Start()
-		err = m.Complete(cmd.Context())
-		if err != nil {
-			sp.Fail(fmt.Sprintf("Failed to complete migration: %s", err))
-			return err
-		}
-
-		sp.Success("Migration successful!")
-		return nil
-	},
+func completeCmd() *cobra.Command {
+	completeCmd := &cobra.Command{
+		Use:   "complete <file>",
+		Short: "Complete an ongoing migration with the operations present in the given file",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Create a roll instance and check if pgroll is initialized
+			m, err := NewRollWithInitCheck(cmd.Context())
+			if err != nil {
+				return err
+			}
+			defer m.Close()
+
+			sp, _ := pterm.DefaultSpinner.WithText("Completing migration...").Start()
+			err = m.Complete(cmd.Context())
+			if err != nil {
+				sp.Fail(fmt.Sprintf("Failed to complete migration: %s", err))
+				return err
+			}
+
+			sp.Success("Migration successful!")
+			return nil
+		},
+	}
+
+	addWaitForClientsFlags(completeCmd)
+
+	return completeCmd
+}
+
+// addWaitForClientsFlags adds the flags that control waiting for clients of the
+// previous version schema to a command that can complete a migration
+func addWaitForClientsFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("wait-for-clients", false, "Wait until no session is using the previous version schema before dropping it")
+	cmd.Flags().Duration("wait-for-clients-timeout", 5*time.Minute, "How long to wait for sessions to stop using the previous version schema before aborting")
+	cmd.Flags().Duration("wait-for-clients-grace-period", 30*time.Second, "How long no session must have used the previous version schema before it is dropped")
+	cmd.Flags().Bool("wait-for-clients-check-locks", true, "Also treat sessions holding locks on objects in the previous version schema as using it")
+
+	bindFlagOnRun(cmd, "WAIT_FOR_CLIENTS", "wait-for-clients")
+	bindFlagOnRun(cmd, "WAIT_FOR_CLIENTS_TIMEOUT", "wait-for-clients-timeout")
+	bindFlagOnRun(cmd, "WAIT_FOR_CLIENTS_GRACE_PERIOD", "wait-for-clients-grace-period")
+	bindFlagOnRun(cmd, "WAIT_FOR_CLIENTS_CHECK_LOCKS", "wait-for-clients-check-locks")
 }
@@ -0,0 +1,90 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/xataio/pgroll/pkg/migrations"
+	"github.com/xataio/pgroll/pkg/schema"
+	"github.com/xataio/pgroll/pkg/sql2pgroll"
+)
+
+func diffCmd() *cobra.Command {
+	var useJSON bool
+
+	diffCmd := &cobra.Command{
+		Use:       "diff <path to schema file>",
+		Short:     "Generate a migration that changes the database schema to match a desired-state SQL schema file",
+		Long:      "Generate a migration that changes the database schema to match a desired-state SQL schema file. The command can read the schema from stdin or a file",
+		Example:   "diff schema.sql > migrations/02_update_schema.yaml",
+		Args:      cobra.MaximumNArgs(1),
+		ValidArgs: []string{"schema-file"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			reader, err := openSQLReader(args)
+			if err != nil {
+				return fmt.Errorf("open schema file: %w", err)
+			}
+			defer reader.Close()
+
+			sql, err := io.ReadAll(reader)
+			if err != nil {
+				return fmt.Errorf("read schema file: %w", err)
+			}
+
+			// Create a roll instance and check if pgroll is initialized
+			m, err := NewRollWithInitCheck(ctx)
+			if err != nil {
+				return err
+			}
+			defer m.Close()
+
+			// The live schema includes the changes made by an active migration
+			active, err := m.State().IsActiveMigrationPeriod(ctx, m.Schema())
+			if err != nil {
+				return fmt.Errorf("unable to determine active migration period: %w", err)
+			}
+			if active {
+				return fmt.Errorf("a migration is active in schema %q; complete or roll it back first", m.Schema())
+			}
+
+			current, err := m.State().ReadSchema(ctx, m.Schema())
+			if err != nil {
+				return fmt.Errorf("unable to read schema: %w", err)
+			}
+
+			desired, err := sql2pgroll.ParseSchema(m.Schema(), string(sql))
+			if err != nil {
+				return err
+			}
+
+			diff := schema.Diff(current, desired)
+			if diff.Empty() {
+				fmt.Fprintf(os.Stderr, "Schema %q is up to date\n", m.Schema())
+				return nil
+			}
+
+			ops, err := sql2pgroll.ConvertSchemaDiff(diff)
+			if err != nil {
+				return err
+			}
+
+			migration := &migrations.Migration{Operations: ops}
+			err = migrations.NewWriter(os.Stdout, migrations.NewMigrationFormat(useJSON)).Write(migration)
+			if err != nil {
+				return fmt.Errorf("failed to write migration to stdout: %w", err)
+			}
+			return nil
+		},
+	}
+
+	diffCmd.Flags().BoolVarP(&useJSON, "json", "j", false, "Output migration file in JSON format instead of YAML")
+
+	return diffCmd
+}
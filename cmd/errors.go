@@ -5,3 +5,5 @@ package cmd
 import "errors"
 
 var errPGRollNotInitialized = errors.New("pgroll is not initialized, run 'pgroll init' to initialize")
+
+var errPGRollUpgradeRequired = errors.New("pgroll state schema has pending upgrades, run 'pgroll init --upgrade' to apply them")
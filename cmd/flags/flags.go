@@ -3,6 +3,8 @@
 package flags
 
 import (
+	"time"
+
 	"github.com/spf13/viper"
 )
 
@@ -22,8 +24,24 @@ func LockTimeout() int {
 	return viper.GetInt("LOCK_TIMEOUT")
 }
 
+func LockWaitTimeout() time.Duration {
+	return viper.GetDuration("LOCK_WAIT_TIMEOUT")
+}
+
 func SkipValidation() bool { return viper.GetBool("SKIP_VALIDATION") }
 
+func ResumableBackfill() bool { return viper.GetBool("RESUMABLE_BACKFILL") }
+
+func WaitForClients() bool { return viper.GetBool("WAIT_FOR_CLIENTS") }
+
+func WaitForClientsTimeout() time.Duration { return viper.GetDuration("WAIT_FOR_CLIENTS_TIMEOUT") }
+
+func WaitForClientsGracePeriod() time.Duration {
+	return viper.GetDuration("WAIT_FOR_CLIENTS_GRACE_PERIOD")
+}
+
+func WaitForClientsCheckLocks() bool { return viper.GetBool("WAIT_FOR_CLIENTS_CHECK_LOCKS") }
+
 func Role() string {
 	return viper.GetString("ROLE")
 }
@@ -33,3 +51,17 @@ func Verbose() bool { return viper.GetBool("VERBOSE") }
 func UseVersionSchema() bool {
 	return viper.GetBool("USE_VERSION_SCHEMA")
 }
+
+func VersionedFunctions() bool {
+	return viper.GetBool("VERSIONED_FUNCTIONS")
+}
+
+func CopyPrivileges() bool {
+	return viper.GetBool("COPY_PRIVILEGES")
+}
+
+func Operator() string { return viper.GetString("OPERATOR") }
+
+func MetricsAddr() string { return viper.GetString("METRICS_ADDR") }
+
+func OTLPTraces() bool { return viper.GetBool("OTLP_TRACES") }
@@ -0,0 +1,116 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/xataio/pgroll/cmd/flags"
+	"github.com/xataio/pgroll/pkg/state"
+)
+
+func historyCmd() *cobra.Command {
+	var useJSON bool
+	var migration string
+
+	historyCmd := &cobra.Command{
+		Use:     "history",
+		Short:   "Show the audit trail of migration events in the schema",
+		Example: "history --migration 03_add_column",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx := cmd.Context()
+
+			m, err := NewRollWithInitCheck(ctx)
+			if err != nil {
+				return err
+			}
+			defer m.Close()
+
+			events, err := m.State().Events(ctx, flags.Schema(), migration)
+			if err != nil {
+				return err
+			}
+
+			if useJSON {
+				if events == nil {
+					events = []state.Event{}
+				}
+				eventsJSON, err := json.MarshalIndent(events, "", "  ")
+				if err != nil {
+					return err
+				}
+				fmt.Println(string(eventsJSON))
+				return nil
+			}
+
+			return writeHistory(os.Stdout, events)
+		},
+	}
+
+	historyCmd.Flags().BoolVarP(&useJSON, "json", "j", false, "Output the events in JSON format instead of a table")
+	historyCmd.Flags().StringVar(&migration, "migration", "", "Only show the events of this migration")
+
+	return historyCmd
+}
+
+// writeHistory writes the events as a table, one event per row
+func writeHistory(w io.Writer, events []state.Event) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "TIME\tMIGRATION\tEVENT\tDURATION\tOPERATOR\tHOST\tROLE\tVERSION\tDETAILS")
+	for _, e := range events {
+		duration := "-"
+		if e.Type != state.EventBackfillStart {
+			duration = (time.Duration(e.DurationMs) * time.Millisecond).String()
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			e.CreatedAt.Format(time.RFC3339),
+			e.Migration,
+			e.Type,
+			duration,
+			orDash(e.Operator),
+			orDash(e.Hostname),
+			e.Role,
+			orDash(e.PgrollVersion),
+			eventDetails(e))
+	}
+
+	return tw.Flush()
+}
+
+// eventDetails summarizes the table, failed phase, error and rollback reason
+// of an event on a single line
+func eventDetails(e state.Event) string {
+	var details []string
+	if e.Table != "" {
+		details = append(details, "table: "+e.Table)
+	}
+	if e.Phase != "" {
+		details = append(details, "phase: "+e.Phase)
+	}
+	if e.Reason != "" {
+		details = append(details, "reason: "+e.Reason)
+	}
+	if e.Error != "" {
+		details = append(details, "error: "+e.Error)
+	}
+
+	return orDash(strings.ReplaceAll(strings.Join(details, ", "), "\n", "; "))
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
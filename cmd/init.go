@@ -9,24 +9,69 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var initCmd = &cobra.Command{
-	Use:   "init <file>",
-	Short: "Initialize pgroll in the target database",
-	RunE: func(cmd *cobra.Command, args []string) error {
-		m, err := NewRoll(cmd.Context())
-		if err != nil {
-			return err
-		}
-		defer m.Close()
-
-		sp, _ := pterm.DefaultSpinner.WithText("Initializing pgroll...").Start()
-		err = m.Init(cmd.Context())
-		if err != nil {
-			sp.Fail(fmt.Sprintf("Failed to initialize pgroll: %s", err))
-			return err
-		}
-
-		sp.Success("Initialization complete")
-		return nil
-	},
+func initCmd() *cobra.Command {
+	var upgrade bool
+
+	initCmd := &cobra.Command{
+		Use:   "init <file>",
+		Short: "Initialize pgroll in the target database",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			m, err := NewRoll(ctx)
+			if err != nil {
+				return err
+			}
+			defer m.Close()
+
+			initialized, err := m.State().IsInitialized(ctx)
+			if err != nil {
+				return err
+			}
+
+			// Upgrading an existing pgroll state schema must be asked for explicitly
+			if initialized {
+				pending, err := m.State().PendingUpgrades(ctx)
+				if err != nil {
+					return err
+				}
+				if len(pending) > 0 && !upgrade {
+					return errPGRollUpgradeRequired
+				}
+			}
+
+			if initialized && upgrade {
+				sp, _ := pterm.DefaultSpinner.WithText("Upgrading pgroll...").Start()
+				applied, err := m.State().Upgrade(ctx)
+				if err != nil {
+					sp.Fail(fmt.Sprintf("Failed to upgrade pgroll: %s", err))
+					return err
+				}
+
+				if len(applied) == 0 {
+					sp.Success("pgroll is already up to date")
+					return nil
+				}
+				for _, u := range applied {
+					pterm.Info.Printfln("Applied state upgrade %d: %s", u.Version, u.Name)
+				}
+				sp.Success("Upgrade complete")
+				return nil
+			}
+
+			sp, _ := pterm.DefaultSpinner.WithText("Initializing pgroll...").Start()
+			err = m.Init(ctx)
+			if err != nil {
+				sp.Fail(fmt.Sprintf("Failed to initialize pgroll: %s", err))
+				return err
+			}
+
+			sp.Success("Initialization complete")
+			return nil
+		},
+	}
+
+	initCmd.Flags().BoolVar(&upgrade, "upgrade", false, "Apply any pending upgrades to an existing pgroll state schema")
+
+	return initCmd
 }
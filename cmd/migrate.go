@@ -6,18 +6,15 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"time"
 
 	"github.com/spf13/cobra"
 
-	"github.com/xataio/pgroll/pkg/backfill"
 	"github.com/xataio/pgroll/pkg/migrations"
 )
 
 func migrateCmd() *cobra.Command {
-	var complete, expectOne bool
-	var batchSize int
-	var batchDelay time.Duration
+	var complete, expectOne, allowDrift bool
+	var bf backfillFlags
 
 	migrateCmd := &cobra.Command{
 		Use:       "migrate <directory>",
@@ -36,6 +33,14 @@ func migrateCmd() *cobra.Command {
 			}
 			defer m.Close()
 
+			// Hold the advisory lock while the outstanding migrations are
+			// determined and applied so that concurrent `migrate` runs do not
+			// try to apply the same migrations
+			if err := m.Lock(ctx); err != nil {
+				return err
+			}
+			defer m.Unlock(ctx)
+
 			latestMigration, err := m.State().LatestMigration(ctx, m.Schema())
 			if err != nil {
 				return fmt.Errorf("unable to determine latest version: %w", err)
@@ -68,6 +73,17 @@ func migrateCmd() *cobra.Command {
 				return nil
 			}
 
+			// Refuse to apply migrations on top of applied migrations whose
+			// files have changed since they were applied
+			drifted, err := m.DriftedMigrations(ctx, os.DirFS(migrationsDir))
+			if err != nil {
+				return fmt.Errorf("failed to check applied migrations: %w", err)
+			}
+			writeDrift(os.Stderr, drifted)
+			if err := checkDrift(drifted, allowDrift); err != nil {
+				return err
+			}
+
 			rawMigs, err := m.UnappliedMigrations(ctx, os.DirFS(migrationsDir))
 			if err != nil {
 				return fmt.Errorf("failed to get migrations to apply: %w", err)
@@ -89,10 +105,7 @@ func migrateCmd() *cobra.Command {
 				return fmt.Errorf("failed to run migrate: %w", err)
 			}
 
-			backfillConfig := backfill.NewConfig(
-				backfill.WithBatchSize(batchSize),
-				backfill.WithBatchDelay(batchDelay),
-			)
+			backfillConfig := bf.config()
 
 			// Run all migrations after the latest version up to the final migration,
 			// completing each one.
@@ -107,10 +120,12 @@ func migrateCmd() *cobra.Command {
 		},
 	}
 
-	migrateCmd.Flags().IntVar(&batchSize, "backfill-batch-size", backfill.DefaultBatchSize, "Number of rows backfilled in each batch")
-	migrateCmd.Flags().DurationVar(&batchDelay, "backfill-batch-delay", backfill.DefaultDelay, "Duration of delay between batch backfills (eg. 1s, 1000ms)")
+	bf.addFlags(migrateCmd.Flags())
+	addResumableBackfillFlag(migrateCmd)
+	addWaitForClientsFlags(migrateCmd)
 	migrateCmd.Flags().BoolVar(&expectOne, "expect-one", false, "Abort if there is more than one migration to be applied")
 	migrateCmd.Flags().BoolVarP(&complete, "complete", "c", false, "complete the final migration rather than leaving it active")
+	migrateCmd.Flags().BoolVar(&allowDrift, "allow-drift", false, "Apply outstanding migrations even if applied migrations have changed since they were applied")
 
 	return migrateCmd
 }
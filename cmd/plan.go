@@ -0,0 +1,93 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/xataio/pgroll/pkg/migrations"
+	"github.com/xataio/pgroll/pkg/roll"
+)
+
+func planCmd() *cobra.Command {
+	var useJSON bool
+
+	planCmd := &cobra.Command{
+		Use:       "plan <file>",
+		Short:     "Print the SQL statements a migration would execute without running them",
+		Example:   "plan migrations/03_my_migration.yaml",
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: []string{"file"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			fileName := args[0]
+
+			m, err := NewRollWithInitCheck(ctx)
+			if err != nil {
+				return err
+			}
+			defer m.Close()
+
+			migration, err := migrations.ReadMigration(os.DirFS(filepath.Dir(fileName)), filepath.Base(fileName))
+			if err != nil {
+				return err
+			}
+
+			plan, err := m.Plan(ctx, migration)
+			if err != nil {
+				return err
+			}
+
+			if useJSON {
+				planJSON, err := json.MarshalIndent(plan, "", "  ")
+				if err != nil {
+					return err
+				}
+				fmt.Println(string(planJSON))
+				return nil
+			}
+
+			return writePlan(os.Stdout, plan)
+		},
+	}
+
+	planCmd.Flags().BoolVarP(&useJSON, "json", "j", false, "Output the plan in JSON format instead of SQL")
+
+	return planCmd
+}
+
+// writePlan writes the plan as a SQL script, with each phase and action
+// introduced by a comment.
+func writePlan(w io.Writer, plan *roll.Plan) error {
+	for i, phase := range plan.Phases {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "-- %s\n", phase.Phase)
+
+		for _, step := range phase.Steps {
+			fmt.Fprintf(w, "\n-- [%s]\n", step.ID)
+			for _, lock := range step.Locks {
+				fmt.Fprintf(w, "-- lock: %s\n", lock)
+			}
+			for _, stmt := range step.Statements {
+				if _, err := fmt.Fprintf(w, "%s;\n", stmt); err != nil {
+					return err
+				}
+			}
+		}
+
+		if phase.Phase == roll.PlanPhaseStart && len(plan.Backfills) > 0 {
+			fmt.Fprintln(w)
+			for _, table := range plan.Backfills {
+				fmt.Fprintf(w, "-- backfill %s\n", table)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/xataio/pgroll/pkg/migrations"
+)
+
+func revertCmd() *cobra.Command {
+	var complete, printOnly, useJSON bool
+	var name string
+	var bf backfillFlags
+
+	revertCmd := &cobra.Command{
+		Use:       "revert <migration>",
+		Short:     "Start a new migration that undoes a completed migration",
+		Example:   "revert 02_add_users_email --complete",
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: []string{"migration"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			migrationName := args[0]
+
+			// Create a roll instance and check if pgroll is initialized
+			m, err := NewRollWithInitCheck(ctx)
+			if err != nil {
+				return err
+			}
+			defer m.Close()
+
+			if name == "" {
+				name = "revert_" + migrationName
+			}
+
+			migration, err := m.RevertMigration(ctx, migrationName, name)
+			if err != nil {
+				return err
+			}
+
+			if printOnly {
+				err := migrations.NewWriter(os.Stdout, migrations.NewMigrationFormat(useJSON)).Write(migration)
+				if err != nil {
+					return fmt.Errorf("failed to write migration to stdout: %w", err)
+				}
+				return nil
+			}
+
+			return runMigration(ctx, m, migration, complete, bf.config())
+		},
+	}
+
+	bf.addFlags(revertCmd.Flags())
+	revertCmd.Flags().BoolVarP(&complete, "complete", "c", false, "Mark the migration as complete")
+	revertCmd.Flags().StringVarP(&name, "name", "n", "", "Name of the new migration (default \"revert_<migration>\")")
+	revertCmd.Flags().BoolVarP(&printOnly, "print", "p", false, "Print the new migration instead of starting it")
+	revertCmd.Flags().BoolVarP(&useJSON, "json", "j", false, "Print the migration in JSON format instead of YAML")
+
+	return revertCmd
+}
@@ -9,25 +9,33 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var rollbackCmd = &cobra.Command{
-	Use:   "rollback",
-	Short: "Roll back an ongoing migration",
-	RunE: func(cmd *cobra.Command, args []string) error {
-		// Create a roll instance and check if pgroll is initialized
-		m, err := NewRollWithInitCheck(cmd.Context())
-		if err != nil {
-			return err
-		}
-		defer m.Close()
-
-		sp, _ := pterm.DefaultSpinner.WithText("Rolling back migration...").Start()
-		err = m.Rollback(cmd.Context())
-		if err != nil {
-			sp.Fail(fmt.Sprintf("Failed to roll back migration: %s", err))
-			return err
-		}
-
-		sp.Success("Migration rolled back. Changes made since the last version have been reverted")
-		return nil
-	},
+func rollbackCmd() *cobra.Command {
+	var reason string
+
+	rollbackCmd := &cobra.Command{
+		Use:   "rollback",
+		Short: "Roll back an ongoing migration",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Create a roll instance and check if pgroll is initialized
+			m, err := NewRollWithInitCheck(cmd.Context())
+			if err != nil {
+				return err
+			}
+			defer m.Close()
+
+			sp, _ := pterm.DefaultSpinner.WithText("Rolling back migration...").Start()
+			err = m.RollbackWithReason(cmd.Context(), reason)
+			if err != nil {
+				sp.Fail(fmt.Sprintf("Failed to roll back migration: %s", err))
+				return err
+			}
+
+			sp.Success("Migration rolled back. Changes made since the last version have been reverted")
+			return nil
+		},
+	}
+
+	rollbackCmd.Flags().StringVar(&reason, "reason", "", "Reason for the rollback, recorded in the migration history")
+
+	return rollbackCmd
 }
@@ -4,6 +4,7 @@ package cmd
 
 import (
 	"context"
+	"errors"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -21,28 +22,51 @@ func NewRoll(ctx context.Context) (*roll.Roll, error) {
 	schema := flags.Schema()
 	stateSchema := flags.StateSchema()
 	lockTimeout := flags.LockTimeout()
+	lockWaitTimeout := flags.LockWaitTimeout()
 	role := flags.Role()
 	skipValidation := flags.SkipValidation()
 	verbose := flags.Verbose()
 	useVersionSchema := flags.UseVersionSchema()
+	versionedFunctions := flags.VersionedFunctions()
+	copyPrivileges := flags.CopyPrivileges()
+	resumableBackfill := flags.ResumableBackfill()
+	waitForClients := flags.WaitForClients()
+
+	stateOpts := []state.StateOpt{state.WithPgrollVersion(Version)}
+	if operator := flags.Operator(); operator != "" {
+		stateOpts = append(stateOpts, state.WithOperator(operator))
+	}
 
-	state, err := state.New(ctx, pgURL, stateSchema, state.WithPgrollVersion(Version))
+	state, err := state.New(ctx, pgURL, stateSchema, stateOpts...)
 	if err != nil {
 		return nil, err
 	}
 
-	return roll.New(
-		ctx, pgURL, schema, state,
+	opts := []roll.Option{
 		roll.WithLockTimeoutMs(lockTimeout),
+		roll.WithLockWaitTimeout(lockWaitTimeout),
 		roll.WithRole(role),
 		roll.WithSkipValidation(skipValidation),
 		roll.WithLogging(verbose),
 		roll.WithVersionSchema(useVersionSchema),
-	)
+		roll.WithVersionedFunctions(versionedFunctions),
+		roll.WithCopyPrivileges(copyPrivileges),
+		roll.WithResumableBackfills(resumableBackfill),
+	}
+	if waitForClients {
+		opts = append(opts, roll.WithWaitForClients(roll.WaitForClients{
+			Timeout:     flags.WaitForClientsTimeout(),
+			GracePeriod: flags.WaitForClientsGracePeriod(),
+			CheckLocks:  flags.WaitForClientsCheckLocks(),
+		}))
+	}
+
+	return roll.New(ctx, pgURL, schema, state, opts...)
 }
 
-// EnsureInitialized checks if the pgroll state schema is initialized.
-// Returns an error if the check fails or if pgroll is not initialized.
+// EnsureInitialized checks if the pgroll state schema is initialized and up to
+// date. Returns an error if the check fails, if pgroll is not initialized or if
+// the state schema has pending upgrades.
 func EnsureInitialized(ctx context.Context, state *state.State) error {
 	ok, err := state.IsInitialized(ctx)
 	if err != nil {
@@ -51,6 +75,14 @@ func EnsureInitialized(ctx context.Context, state *state.State) error {
 	if !ok {
 		return errPGRollNotInitialized
 	}
+
+	pending, err := state.PendingUpgrades(ctx)
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		return errPGRollUpgradeRequired
+	}
 	return nil
 }
 
@@ -72,6 +104,19 @@ func NewRollWithInitCheck(ctx context.Context) (*roll.Roll, error) {
 	return m, nil
 }
 
+// bindFlagOnRun binds the viper key `key` to the flag `flag` of `cmd` when
+// `cmd` runs. A viper key can only be bound to a single flag, so flags that are
+// defined by more than one command are bound by the command that is run.
+func bindFlagOnRun(cmd *cobra.Command, key, flag string) {
+	preRun := cmd.PreRun
+	cmd.PreRun = func(c *cobra.Command, args []string) {
+		if preRun != nil {
+			preRun(c, args)
+		}
+		viper.BindPFlag(key, c.Flags().Lookup(flag))
+	}
+}
+
 func Prepare() *cobra.Command {
 	rootCmd := &cobra.Command{
 		Use:          "pgroll",
@@ -80,6 +125,15 @@ func Prepare() *cobra.Command {
 		Long:         "For more information, visit http://pgroll.com/docs",
 	}
 
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, _ []string) error {
+		shutdown, err := setupTelemetry(cmd.Context())
+		if err != nil {
+			return err
+		}
+		telemetryShutdown = shutdown
+		return nil
+	}
+
 	viper.SetEnvPrefix("PGROLL")
 	viper.AutomaticEnv()
 
@@ -87,24 +141,36 @@ func Prepare() *cobra.Command {
 	rootCmd.PersistentFlags().String("schema", "public", "Postgres schema to use for the migration")
 	rootCmd.PersistentFlags().String("pgroll-schema", "pgroll", "Postgres schema to use for pgroll internal state")
 	rootCmd.PersistentFlags().Int("lock-timeout", 500, "Postgres lock timeout in milliseconds for pgroll DDL operations")
+	rootCmd.PersistentFlags().Duration("lock-wait-timeout", 0, "How long to wait for another pgroll process operating on the same schema to finish")
 	rootCmd.PersistentFlags().String("role", "", "Optional postgres role to set when executing migrations")
 	rootCmd.PersistentFlags().Bool("use-version-schema", true, "Create version schemas for each migration")
+	rootCmd.PersistentFlags().Bool("versioned-functions", false, "Copy the schema's functions into each version schema")
+	rootCmd.PersistentFlags().Bool("copy-privileges", false, "Copy the schema's privileges and default privileges onto each version schema and its views")
 	rootCmd.PersistentFlags().Bool("verbose", false, "Enable verbose logging")
+	rootCmd.PersistentFlags().String("operator", "", "Name of the operator recorded in the migration history (default: the operating system user)")
+	rootCmd.PersistentFlags().String("metrics-addr", "", "Serve Prometheus metrics on /metrics at this address, for example :9090")
+	rootCmd.PersistentFlags().Bool("otlp-traces", false, "Export OpenTelemetry traces over OTLP/HTTP, configured with the standard OTEL_EXPORTER_OTLP_* environment variables")
 
 	viper.BindPFlag("PG_URL", rootCmd.PersistentFlags().Lookup("postgres-url"))
 	viper.BindPFlag("SCHEMA", rootCmd.PersistentFlags().Lookup("schema"))
 	viper.BindPFlag("STATE_SCHEMA", rootCmd.PersistentFlags().Lookup("pgroll-schema"))
 	viper.BindPFlag("LOCK_TIMEOUT", rootCmd.PersistentFlags().Lookup("lock-timeout"))
+	viper.BindPFlag("LOCK_WAIT_TIMEOUT", rootCmd.PersistentFlags().Lookup("lock-wait-timeout"))
 	viper.BindPFlag("ROLE", rootCmd.PersistentFlags().Lookup("role"))
 	viper.BindPFlag("USE_VERSION_SCHEMA", rootCmd.PersistentFlags().Lookup("use-version-schema"))
+	viper.BindPFlag("VERSIONED_FUNCTIONS", rootCmd.PersistentFlags().Lookup("versioned-functions"))
+	viper.BindPFlag("COPY_PRIVILEGES", rootCmd.PersistentFlags().Lookup("copy-privileges"))
 	viper.BindPFlag("VERBOSE", rootCmd.PersistentFlags().Lookup("verbose"))
+	viper.BindPFlag("OPERATOR", rootCmd.PersistentFlags().Lookup("operator"))
+	viper.BindPFlag("METRICS_ADDR", rootCmd.PersistentFlags().Lookup("metrics-addr"))
+	viper.BindPFlag("OTLP_TRACES", rootCmd.PersistentFlags().Lookup("otlp-traces"))
 
 	// register subcommands
 	rootCmd.AddCommand(startCmd())
-	rootCmd.AddCommand(completeCmd)
-	rootCmd.AddCommand(rollbackCmd)
+	rootCmd.AddCommand(completeCmd())
+	rootCmd.AddCommand(rollbackCmd())
 	rootCmd.AddCommand(analyzeCmd)
-	rootCmd.AddCommand(initCmd)
+	rootCmd.AddCommand(initCmd())
 	rootCmd.AddCommand(statusCmd)
 	rootCmd.AddCommand(updateCmd())
 	rootCmd.AddCommand(createCmd())
@@ -113,13 +179,28 @@ func Prepare() *cobra.Command {
 	rootCmd.AddCommand(latestCmd())
 	rootCmd.AddCommand(convertCmd())
 	rootCmd.AddCommand(baselineCmd())
+	rootCmd.AddCommand(squashCmd())
 	rootCmd.AddCommand(validateCmd)
+	rootCmd.AddCommand(planCmd())
+	rootCmd.AddCommand(backfillCmd())
+	rootCmd.AddCommand(diffCmd())
+	rootCmd.AddCommand(revertCmd())
+	rootCmd.AddCommand(historyCmd())
+	rootCmd.AddCommand(verifyCmd())
 
 	return rootCmd
 }
 
+// telemetryShutdown flushes telemetry once the command has run
+var telemetryShutdown func(context.Context) error
+
 // Execute executes the root command.
 func Execute() error {
 	cmd := Prepare()
-	return cmd.Execute()
+	err := cmd.Execute()
+
+	if telemetryShutdown != nil {
+		err = errors.Join(err, telemetryShutdown(context.Background()))
+	}
+	return err
 }
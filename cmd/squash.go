@@ -0,0 +1,122 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+	"github.com/xataio/pgroll/pkg/migrations"
+)
+
+func squashCmd() *cobra.Command {
+	var upTo, name string
+	var useJSON bool
+	var yes bool
+	var allowIncomplete bool
+
+	squashCmd := &cobra.Command{
+		Use:       "squash <target directory>",
+		Short:     "Squash applied migrations into a single baseline migration",
+		Example:   "squash ./migrations --up-to 0400_add_orders_index",
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: []string{"directory"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			targetDir := args[0]
+
+			ctx := cmd.Context()
+
+			// Create a roll instance
+			m, err := NewRollWithInitCheck(ctx)
+			if err != nil {
+				return err
+			}
+			defer m.Close()
+
+			if name == "" {
+				name = upTo + "_squashed"
+			}
+
+			mig, omitted, err := m.Squash(ctx, upTo, name)
+			if err != nil {
+				return err
+			}
+
+			// Objects the baseline does not recreate must be added to it by hand
+			if len(omitted) > 0 {
+				fmt.Fprintln(os.Stderr, "The baseline migration does not recreate:")
+				for _, obj := range omitted {
+					fmt.Fprintf(os.Stderr, "  - %s\n", obj)
+				}
+				if !allowIncomplete {
+					return fmt.Errorf("the baseline migration would not recreate %d objects in the schema; rerun with --allow-incomplete to write it anyway and add them to it by hand", len(omitted))
+				}
+			}
+
+			// The baseline can only be recorded in the target database if no
+			// migrations have been applied after the squashed migrations
+			latest, err := m.State().LatestMigration(ctx, m.Schema())
+			if err != nil {
+				return err
+			}
+			recordBaseline := latest != nil && *latest == upTo
+
+			// Prompt for confirmation unless --yes flag is set
+			if !yes && recordBaseline {
+				fmt.Println("Squashing migrations will restart the migration history.")
+				ok, _ := pterm.DefaultInteractiveConfirm.Show()
+				if !ok {
+					return nil
+				}
+			}
+
+			opsJSON, err := json.Marshal(mig.Operations)
+			if err != nil {
+				return fmt.Errorf("failed to marshal operations: %w", err)
+			}
+			raw := &migrations.RawMigration{
+				Name:       mig.Name,
+				Baseline:   true,
+				Operations: opsJSON,
+			}
+
+			// Write the baseline migration to disk
+			filePath, err := writeMigrationToFile(raw, targetDir, "", useJSON)
+			if err != nil {
+				return fmt.Errorf("failed to write baseline migration: %w", err)
+			}
+
+			if !recordBaseline {
+				pterm.Warning.Printfln("Migrations have been applied after %q, so the baseline is not recorded in the target database", upTo)
+				pterm.Success.Printfln("Baseline migration %q written", filePath)
+				return nil
+			}
+
+			sp, _ := pterm.DefaultSpinner.WithText(fmt.Sprintf("Creating baseline migration %q...", name)).Start()
+
+			// Create the baseline in the target database
+			err = m.CreateBaseline(ctx, name)
+			if err != nil {
+				sp.Fail(fmt.Sprintf("Failed to create baseline: %s", err))
+				err = errors.Join(err, os.Remove(filePath))
+				return err
+			}
+
+			sp.Success(fmt.Sprintf("Baseline created successfully. Baseline migration %q written", filePath))
+			return nil
+		},
+	}
+
+	squashCmd.Flags().StringVar(&upTo, "up-to", "", "Name of the last applied migration to squash into the baseline")
+	squashCmd.Flags().StringVar(&name, "name", "", "Name of the baseline migration (default: the --up-to migration with a _squashed suffix)")
+	squashCmd.Flags().BoolVarP(&useJSON, "json", "j", false, "output in JSON format instead of YAML")
+	squashCmd.Flags().BoolVarP(&yes, "yes", "y", false, "skip confirmation prompt")
+	squashCmd.Flags().BoolVar(&allowIncomplete, "allow-incomplete", false, "Write the baseline migration even if it does not recreate every object in the schema")
+	squashCmd.MarkFlagRequired("up-to")
+
+	return squashCmd
+}
@@ -8,7 +8,6 @@ import (
 	"math"
 	"os"
 	"path/filepath"
-	"time"
 
 	"github.com/pterm/pterm"
 	"github.com/spf13/cobra"
@@ -22,8 +21,7 @@ import (
 
 func startCmd() *cobra.Command {
 	var complete bool
-	var batchSize int
-	var batchDelay time.Duration
+	var bf backfillFlags
 
 	startCmd := &cobra.Command{
 		Use:       "start <file>",
@@ -51,19 +49,17 @@ func startCmd() *cobra.Command {
 				return nil
 			}
 
-			c := backfill.NewConfig(
-				backfill.WithBatchSize(batchSize),
-				backfill.WithBatchDelay(batchDelay),
-			)
+			c := bf.config()
 
 			return runMigrationFromFile(ctx, m, fileName, complete, c)
 		},
 	}
 
-	startCmd.Flags().IntVar(&batchSize, "backfill-batch-size", backfill.DefaultBatchSize, "Number of rows backfilled in each batch")
-	startCmd.Flags().DurationVar(&batchDelay, "backfill-batch-delay", backfill.DefaultDelay, "Duration of delay between batch backfills (eg. 1s, 1000ms)")
+	bf.addFlags(startCmd.Flags())
 	startCmd.Flags().BoolVarP(&complete, "complete", "c", false, "Mark the migration as complete")
 	startCmd.Flags().BoolP("skip-validation", "s", false, "skip migration validation")
+	addResumableBackfillFlag(startCmd)
+	addWaitForClientsFlags(startCmd)
 
 	viper.BindPFlag("SKIP_VALIDATION", startCmd.Flags().Lookup("skip-validation"))
 
@@ -81,16 +77,7 @@ func runMigrationFromFile(ctx context.Context, m *roll.Roll, fileName string, co
 
 func runMigration(ctx context.Context, m *roll.Roll, migration *migrations.Migration, complete bool, c *backfill.Config) error {
 	sp, _ := pterm.DefaultSpinner.WithText("Starting migration...").Start()
-	c.AddCallback(func(n int64, total int64) {
-		if total > 0 {
-			percent := float64(n) / float64(total) * 100
-			// Percent can be > 100 if we're on the last batch in which case we still want to display 100.
-			percent = math.Min(percent, 100)
-			sp.UpdateText(fmt.Sprintf("%d records complete... (%.2f%%)", n, percent))
-		} else {
-			sp.UpdateText(fmt.Sprintf("%d records complete...", n))
-		}
-	})
+	c.AddCallback(backfillProgressCallback(sp))
 
 	err := m.Start(ctx, migration, c)
 	if err != nil {
@@ -117,3 +104,18 @@ func runMigration(ctx context.Context, m *roll.Roll, migration *migrations.Migra
 
 	return nil
 }
+
+// backfillProgressCallback returns a backfill callback that reports progress
+// on the spinner.
+func backfillProgressCallback(sp *pterm.SpinnerPrinter) backfill.CallbackFn {
+	return func(n int64, total int64) {
+		if total > 0 {
+			percent := float64(n) / float64(total) * 100
+			// Percent can be > 100 if we're on the last batch in which case we still want to display 100.
+			percent = math.Min(percent, 100)
+			sp.UpdateText(fmt.Sprintf("%d records complete... (%.2f%%)", n, percent))
+		} else {
+			sp.UpdateText(fmt.Sprintf("%d records complete...", n))
+		}
+	}
+}
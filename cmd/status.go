@@ -5,16 +5,29 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
+	"os"
 
 	"github.com/xataio/pgroll/cmd/flags"
+	"github.com/xataio/pgroll/pkg/roll"
 
 	"github.com/spf13/cobra"
 )
 
+// statusWithDrift is the status of a schema together with the names of the
+// applied migrations whose migration files have changed since they were
+// applied
+type statusWithDrift struct {
+	*roll.Status
+
+	DriftedMigrations []string `json:"drifted_migrations"`
+}
+
 var statusCmd = &cobra.Command{
-	Use:   "status",
-	Short: "Show pgroll status",
-	RunE: func(cmd *cobra.Command, _ []string) error {
+	Use:       "status [directory]",
+	Short:     "Show pgroll status",
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: []string{"directory"},
+	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx := cmd.Context()
 
 		m, err := NewRollWithInitCheck(ctx)
@@ -28,7 +41,24 @@ var statusCmd = &cobra.Command{
 			return err
 		}
 
-		statusJSON, err := json.MarshalIndent(status, "", "  ")
+		var output any = status
+
+		// Report applied migrations that have changed if a migrations
+		// directory is given
+		if len(args) == 1 {
+			drifted, err := m.DriftedMigrations(ctx, os.DirFS(args[0]))
+			if err != nil {
+				return err
+			}
+
+			names := make([]string, len(drifted))
+			for i, d := range drifted {
+				names[i] = d.Name
+			}
+			output = statusWithDrift{Status: status, DriftedMigrations: names}
+		}
+
+		statusJSON, err := json.MarshalIndent(output, "", "  ")
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,73 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	otelprometheus "go.opentelemetry.io/otel/exporters/prometheus"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"github.com/xataio/pgroll/cmd/flags"
+)
+
+// setupTelemetry installs the OpenTelemetry providers requested by the
+// telemetry flags. It returns a function that flushes any buffered telemetry
+// and stops the metrics endpoint.
+func setupTelemetry(ctx context.Context) (func(context.Context) error, error) {
+	var shutdowns []func(context.Context) error
+	shutdown := func(ctx context.Context) error {
+		var errs error
+		for _, f := range shutdowns {
+			errs = errors.Join(errs, f(ctx))
+		}
+		return errs
+	}
+
+	// Export spans over OTLP/HTTP. The endpoint, headers and service name are
+	// configured with the standard OTEL_* environment variables.
+	if flags.OTLPTraces() {
+		exporter, err := otlptracehttp.New(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create OTLP trace exporter: %w", err)
+		}
+		tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter))
+		otel.SetTracerProvider(tp)
+		shutdowns = append(shutdowns, tp.Shutdown)
+	}
+
+	// Serve metrics in the Prometheus exposition format on /metrics
+	if addr := flags.MetricsAddr(); addr != "" {
+		exporter, err := otelprometheus.New()
+		if err != nil {
+			return nil, fmt.Errorf("unable to create Prometheus exporter: %w", err)
+		}
+		mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(exporter))
+		otel.SetMeterProvider(mp)
+		shutdowns = append(shutdowns, mp.Shutdown)
+
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			return nil, errors.Join(
+				fmt.Errorf("unable to listen on metrics address %q: %w", addr, err),
+				shutdown(ctx),
+			)
+		}
+
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.Handler())
+		server := &http.Server{Handler: mux}
+		go server.Serve(listener)
+		shutdowns = append(shutdowns, server.Shutdown)
+	}
+
+	return shutdown, nil
+}
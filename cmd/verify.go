@@ -0,0 +1,85 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/xataio/pgroll/pkg/roll"
+)
+
+func verifyCmd() *cobra.Command {
+	var useJSON, allowDrift bool
+
+	verifyCmd := &cobra.Command{
+		Use:       "verify <directory>",
+		Short:     "Check that applied migrations have not changed since they were applied",
+		Example:   "verify ./migrations",
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: []string{"directory"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			migrationsDir := args[0]
+
+			m, err := NewRollWithInitCheck(ctx)
+			if err != nil {
+				return err
+			}
+			defer m.Close()
+
+			drifted, err := m.DriftedMigrations(ctx, os.DirFS(migrationsDir))
+			if err != nil {
+				return err
+			}
+
+			if useJSON {
+				if drifted == nil {
+					drifted = []roll.DriftedMigration{}
+				}
+				driftJSON, err := json.MarshalIndent(drifted, "", "  ")
+				if err != nil {
+					return err
+				}
+				fmt.Println(string(driftJSON))
+			} else if len(drifted) == 0 {
+				fmt.Println("All applied migrations match their migration files")
+			} else {
+				writeDrift(os.Stdout, drifted)
+			}
+
+			return checkDrift(drifted, allowDrift)
+		},
+	}
+
+	verifyCmd.Flags().BoolVarP(&useJSON, "json", "j", false, "Output the changed migrations in JSON format")
+	verifyCmd.Flags().BoolVar(&allowDrift, "allow-drift", false, "Exit successfully even if applied migrations have changed")
+
+	return verifyCmd
+}
+
+// writeDrift writes a unified diff for each of the `drifted` migrations
+func writeDrift(w io.Writer, drifted []roll.DriftedMigration) {
+	for _, d := range drifted {
+		fmt.Fprintf(w, "Migration %q has changed since it was applied:\n%s\n", d.Name, d.Diff)
+	}
+}
+
+// checkDrift returns an error naming the `drifted` migrations, if there are
+// any and drift is not allowed
+func checkDrift(drifted []roll.DriftedMigration, allowDrift bool) error {
+	if len(drifted) == 0 || allowDrift {
+		return nil
+	}
+
+	names := make([]string, len(drifted))
+	for i, d := range drifted {
+		names[i] = d.Name
+	}
+	return fmt.Errorf("%w: %s (use --allow-drift to ignore)", roll.ErrMigrationDrift, strings.Join(names, ", "))
+}
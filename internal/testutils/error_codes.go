@@ -3,12 +3,13 @@
 package testutils
 
 const (
-	CheckViolationErrorCode         string = "check_violation"
-	ExclusionViolationErrorCode     string = "exclusion_violation"
-	FKViolationErrorCode            string = "foreign_key_violation"
-	NotNullViolationErrorCode       string = "not_null_violation"
-	UndefinedColumnErrorCode        string = "undefined_column"
-	UndefinedTableErrorCode         string = "undefined_table"
-	UniqueViolationErrorCode        string = "unique_violation"
-	NumericValueOutOfRangeErrorCode string = "numeric_value_out_of_range"
+	CheckViolationErrorCode            string = "check_violation"
+	ExclusionViolationErrorCode        string = "exclusion_violation"
+	FKViolationErrorCode               string = "foreign_key_violation"
+	NotNullViolationErrorCode          string = "not_null_violation"
+	UndefinedColumnErrorCode           string = "undefined_column"
+	UndefinedTableErrorCode            string = "undefined_table"
+	UniqueViolationErrorCode           string = "unique_violation"
+	NumericValueOutOfRangeErrorCode    string = "numeric_value_out_of_range"
+	InvalidTextRepresentationErrorCode string = "invalid_text_representation"
 )
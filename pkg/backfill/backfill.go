@@ -7,13 +7,16 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/lib/pq"
 	"github.com/xataio/pgroll/pkg/backfill/templates"
 	"github.com/xataio/pgroll/pkg/db"
 	"github.com/xataio/pgroll/pkg/schema"
+	"github.com/xataio/pgroll/pkg/telemetry"
 )
 
 // CNeedsBackfillColumn is the name of the internal column created
@@ -36,7 +39,10 @@ type Job struct {
 }
 
 type Backfill struct {
-	conn db.DB
+	conn        db.DB
+	checkpoints CheckpointStore
+	progress    *progress
+	workers     chan struct{}
 	*Config
 }
 
@@ -122,13 +128,23 @@ func findColumnName(columns map[string]*schema.Column, columnName string) string
 // not started until `Start` is invoked.
 func New(conn db.DB, c *Config) *Backfill {
 	b := &Backfill{
-		conn:   conn,
-		Config: c,
+		conn:     conn,
+		progress: newProgress(),
+		workers:  make(chan struct{}, max(c.concurrency, 1)),
+		Config:   c,
 	}
 
 	return b
 }
 
+// WithCheckpointStore sets the store used to persist the progress of each
+// table backfill. When set, `Start` resumes the backfill of a table from its
+// last checkpoint and skips tables whose backfill has already completed.
+func (bf *Backfill) WithCheckpointStore(store CheckpointStore) *Backfill {
+	bf.checkpoints = store
+	return bf
+}
+
 // CreateTriggers creates the triggers for the tables before starting the backfill.
 func (bf *Backfill) CreateTriggers(ctx context.Context, j *Job) error {
 	for _, trigger := range j.triggers {
@@ -149,43 +165,187 @@ func (bf *Backfill) CreateTriggers(ctx context.Context, j *Job) error {
 // 2. Get the first batch of rows from the table, ordered by the primary key.
 // 3. Update each row in the batch, setting the value of the primary key column to itself.
 // 4. Repeat steps 2 and 3 until no more rows are returned.
-func (bf *Backfill) Start(ctx context.Context, table *schema.Table) error {
-	// Create a batcher for the table.
-	var b batcher
-	if identityColumns := getIdentityColumns(table); identityColumns != nil {
-		b = &pkBatcher{
+//
+// If the backfill concurrency is greater than one, the table is split into
+// ranges of primary key values which are backfilled in parallel.
+//
+// If a checkpoint store is set, a checkpoint is saved after each batch and the
+// backfill starts from the last saved checkpoint for the table.
+func (bf *Backfill) Start(ctx context.Context, table *schema.Table) (err error) {
+	ctx, span := telemetry.StartSpan(ctx, "pgroll.backfill", telemetry.TableKey.String(table.Name))
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	cp, err := bf.loadCheckpoint(ctx, table.Name)
+	if err != nil {
+		return fmt.Errorf("load checkpoint for %q: %w", table.Name, err)
+	}
+	if cp.Done {
+		return nil
+	}
+
+	total, err := getRowCount(ctx, bf.conn, table.Name)
+	if err != nil {
+		return fmt.Errorf("get row count for %q: %w", table.Name, err)
+	}
+
+	// Create the batchers for the table.
+	batchers, err := bf.batchers(ctx, table, cp, total)
+	if err != nil {
+		return fmt.Errorf("split %q into ranges: %w", table.Name, err)
+	}
+
+	bf.progress.start(table.Name, cp.RowsDone, total)
+	defer bf.progress.finish(table.Name)
+
+	tcp := &tableCheckpoint{Checkpoint: cp, batchers: batchers}
+	if err := bf.runBatchers(ctx, table.Name, tcp, batchers); err != nil {
+		return err
+	}
+
+	tcp.Done = true
+	if err := bf.saveCheckpoint(ctx, table.Name, tcp.Checkpoint); err != nil {
+		return fmt.Errorf("save checkpoint for %q: %w", table.Name, err)
+	}
+
+	return nil
+}
+
+// batchers returns the batchers used to backfill `table`. More than one
+// batcher is returned if the table is split into key ranges.
+func (bf *Backfill) batchers(ctx context.Context, table *schema.Table, cp *Checkpoint, total int64) ([]batcher, error) {
+	identityColumns := getIdentityColumns(table)
+	if identityColumns == nil {
+		return []batcher{&needsBackfillColumnBatcher{
+			table:               table.Name,
+			batchSize:           bf.batchSize,
+			needsBackfillColumn: CNeedsBackfillColumn,
+		}}, nil
+	}
+
+	newBatcher := func(lower, upper []string) batcher {
+		return &pkBatcher{
 			BatchConfig: templates.BatchConfig{
 				TableName:           table.Name,
 				PrimaryKey:          identityColumns,
+				LastValue:           lower,
+				UpperValue:          upper,
 				BatchSize:           bf.batchSize,
 				NeedsBackfillColumn: CNeedsBackfillColumn,
 			},
 		}
-	} else {
-		b = &needsBackfillColumnBatcher{
-			table:               table.Name,
-			batchSize:           bf.batchSize,
-			needsBackfillColumn: CNeedsBackfillColumn,
+	}
+
+	// A table that was being backfilled in ranges is resumed from the last
+	// checkpoint of each range.
+	if len(cp.Ranges) > 0 {
+		batchers := make([]batcher, 0, len(cp.Ranges))
+		for _, r := range cp.Ranges {
+			start := r.Lower
+			if r.LastValue != nil {
+				start = r.LastValue
+			}
+			batchers = append(batchers, newBatcher(start, r.Upper))
 		}
+		return batchers, nil
 	}
 
-	total, err := getRowCount(ctx, bf.conn, table.Name)
+	// A table that was being backfilled sequentially is resumed sequentially
+	// from its last checkpoint.
+	ranges := bf.rangeCount(total)
+	if ranges <= 1 || cp.LastValue != nil {
+		return []batcher{newBatcher(cp.LastValue, nil)}, nil
+	}
+
+	bounds, err := getRangeBounds(ctx, bf.conn, table.Name, identityColumns, total/int64(ranges), ranges-1)
 	if err != nil {
-		return fmt.Errorf("get row count for %q: %w", table.Name, err)
+		return nil, err
+	}
+
+	batchers := make([]batcher, 0, len(bounds)+1)
+	var lower []string
+	for _, upper := range append(bounds, nil) {
+		batchers = append(batchers, newBatcher(lower, upper))
+		cp.Ranges = append(cp.Ranges, KeyRange{Lower: lower, Upper: upper})
+		lower = upper
+	}
+
+	return batchers, nil
+}
+
+// rangeCount returns the number of key ranges to split a table with
+// approximately `total` rows into.
+func (bf *Backfill) rangeCount(total int64) int {
+	if bf.concurrency <= 1 || bf.batchSize <= 0 {
+		return 1
 	}
+	batches := (total + int64(bf.batchSize) - 1) / int64(bf.batchSize)
+	return int(min(int64(bf.concurrency), batches))
+}
+
+// runBatchers runs each batcher until its range of the table is backfilled.
+// If any batcher fails, the others are stopped and the first error is
+// returned.
+func (bf *Backfill) runBatchers(ctx context.Context, table string, cp *tableCheckpoint, batchers []batcher) error {
+	if len(batchers) == 1 {
+		return bf.runBatcher(ctx, table, cp, batchers[0])
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	var wg sync.WaitGroup
+	for _, b := range batchers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := bf.runBatcher(ctx, table, cp, b); err != nil {
+				cancel(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return context.Cause(ctx)
+}
+
+// runBatcher updates batches of rows until the batcher runs out of rows,
+// invoking callbacks and saving a checkpoint after each batch.
+func (bf *Backfill) runBatcher(ctx context.Context, table string, cp *tableCheckpoint, b batcher) error {
+	// Limit the number of batchers running at once across all tables
+	select {
+	case bf.workers <- struct{}{}:
+		defer func() { <-bf.workers }()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	size := bf.batchSize
+	for {
+		bf.progress.report(bf.callbacks)
 
-	// Update each batch of rows, invoking callbacks for each one.
-	for batch := 0; ; batch++ {
-		for _, cb := range bf.callbacks {
-			cb(int64(batch*bf.batchSize), total)
+		// Wait until replicas have caught up and the database is not overloaded
+		if err := bf.throttle.wait(ctx, bf.conn); err != nil {
+			return err
 		}
 
-		if err := b.updateBatch(ctx, bf.conn); err != nil {
+		b.setBatchSize(size)
+		start := time.Now()
+		updated, err := b.updateBatch(ctx, bf.conn)
+		if err != nil {
 			if errors.Is(err, sql.ErrNoRows) {
-				break
+				return nil
 			}
 			return err
 		}
+		elapsed := time.Since(start)
+		telemetry.RecordBackfillBatch(ctx, table, int(updated), elapsed)
+
+		bf.progress.add(table, updated)
+		if err := bf.recordBatch(ctx, table, cp, b, updated); err != nil {
+			return fmt.Errorf("save checkpoint for %q: %w", table, err)
+		}
+
+		size = bf.throttle.nextBatchSize(size, elapsed)
 
 		select {
 		case <-ctx.Done():
@@ -193,8 +353,45 @@ func (bf *Backfill) Start(ctx context.Context, table *schema.Table) error {
 		case <-time.After(bf.batchDelay):
 		}
 	}
+}
 
-	return nil
+// recordBatch updates the checkpoint for `table` after a batch of `updated`
+// rows has been backfilled by `b` and saves it.
+func (bf *Backfill) recordBatch(ctx context.Context, table string, cp *tableCheckpoint, b batcher, updated int64) error {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	cp.RowsDone += updated
+	if i := slices.Index(cp.batchers, b); len(cp.Ranges) > 0 && i != -1 {
+		cp.Ranges[i].LastValue = b.lastValue()
+	} else {
+		cp.LastValue = b.lastValue()
+	}
+	return bf.saveCheckpoint(ctx, table, cp.Checkpoint)
+}
+
+// loadCheckpoint returns the last checkpoint for `table`, or an empty
+// checkpoint if there is no checkpoint store or no checkpoint for the table.
+func (bf *Backfill) loadCheckpoint(ctx context.Context, table string) (*Checkpoint, error) {
+	if bf.checkpoints == nil {
+		return &Checkpoint{}, nil
+	}
+
+	cp, err := bf.checkpoints.LoadCheckpoint(ctx, table)
+	if err != nil {
+		return nil, err
+	}
+	if cp == nil {
+		return &Checkpoint{}, nil
+	}
+	return cp, nil
+}
+
+func (bf *Backfill) saveCheckpoint(ctx context.Context, table string, cp *Checkpoint) error {
+	if bf.checkpoints == nil {
+		return nil
+	}
+	return bf.checkpoints.SaveCheckpoint(ctx, table, cp)
 }
 
 // getRowCount will attempt to get the row count for the given table. It first attempts to get an
@@ -263,7 +460,16 @@ func getIdentityColumns(table *schema.Table) []string {
 
 // A batcher is responsible for updating a batch of rows in a table.
 type batcher interface {
-	updateBatch(context.Context, db.DB) error
+	// updateBatch updates the next batch of rows and returns the number of rows
+	// updated. It returns sql.ErrNoRows once there are no rows left to update.
+	updateBatch(context.Context, db.DB) (int64, error)
+
+	// lastValue returns the key of the last row updated, if the batcher
+	// iterates over the table by key.
+	lastValue() []string
+
+	// setBatchSize sets the number of rows updated by the next batch.
+	setBatchSize(int)
 }
 
 // pkBatcher is responsible for updating a batch of rows in a table.
@@ -273,8 +479,9 @@ type pkBatcher struct {
 	templates.BatchConfig
 }
 
-func (b *pkBatcher) updateBatch(ctx context.Context, conn db.DB) error {
-	return conn.WithRetryableTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+func (b *pkBatcher) updateBatch(ctx context.Context, conn db.DB) (int64, error) {
+	var updated int64
+	err := conn.WithRetryableTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
 		// Build the query to update the next batch of rows
 		sql, err := templates.BuildSQL(b.BatchConfig)
 		if err != nil {
@@ -286,19 +493,22 @@ func (b *pkBatcher) updateBatch(ctx context.Context, conn db.DB) error {
 		if b.LastValue == nil {
 			b.LastValue = make([]string, len(b.PrimaryKey))
 		}
-		wrapper := make([]any, len(b.LastValue))
+		wrapper := make([]any, len(b.LastValue)+1)
 		for i := range b.LastValue {
 			wrapper[i] = &b.LastValue[i]
 		}
-		err = tx.QueryRowContext(ctx, sql).Scan(wrapper...)
-		if err != nil {
-			return err
-		}
-
-		return nil
+		wrapper[len(b.LastValue)] = &updated
+		return tx.QueryRowContext(ctx, sql).Scan(wrapper...)
 	})
+	return updated, err
 }
 
+func (b *pkBatcher) lastValue() []string {
+	return slices.Clone(b.LastValue)
+}
+
+func (b *pkBatcher) setBatchSize(size int) { b.BatchSize = size }
+
 // needsBackfillColumnBatcher is responsible for updating a batch of rows in a table
 // if the table does not have a PK or a unique column.
 type needsBackfillColumnBatcher struct {
@@ -307,8 +517,9 @@ type needsBackfillColumnBatcher struct {
 	needsBackfillColumn string
 }
 
-func (b *needsBackfillColumnBatcher) updateBatch(ctx context.Context, conn db.DB) error {
-	return conn.WithRetryableTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+func (b *needsBackfillColumnBatcher) updateBatch(ctx context.Context, conn db.DB) (int64, error) {
+	var updated int64
+	err := conn.WithRetryableTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
 		//nolint:gosec // tablenames are column names are checked
 		stmt := fmt.Sprintf("UPDATE %s SET %s = true WHERE ctid IN (SELECT ctid FROM %s WHERE %s = true LIMIT %d)",
 			pq.QuoteIdentifier(b.table),
@@ -320,9 +531,16 @@ func (b *needsBackfillColumnBatcher) updateBatch(ctx context.Context, conn db.DB
 		if err != nil {
 			return err
 		}
-		if count, err := res.RowsAffected(); err != nil || count == 0 {
+		if updated, err = res.RowsAffected(); err != nil || updated == 0 {
 			return sql.ErrNoRows
 		}
 		return nil
 	})
+	return updated, err
 }
+
+// The batcher needs no checkpoint as backfilled rows are no longer marked as
+// needing a backfill.
+func (b *needsBackfillColumnBatcher) lastValue() []string { return nil }
+
+func (b *needsBackfillColumnBatcher) setBatchSize(size int) { b.batchSize = size }
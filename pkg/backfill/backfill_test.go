@@ -14,6 +14,7 @@ import (
 	"github.com/xataio/pgroll/internal/testutils"
 	"github.com/xataio/pgroll/pkg/backfill"
 	"github.com/xataio/pgroll/pkg/db"
+	"github.com/xataio/pgroll/pkg/schema"
 )
 
 func TestMain(m *testing.M) {
@@ -48,3 +49,262 @@ func TestGetRowCountDoesNotLeakConnections(t *testing.T) {
 		}
 	})
 }
+
+func TestBackfillResumesFromCheckpoint(t *testing.T) {
+	t.Parallel()
+
+	testutils.WithConnectionToContainer(t, func(conn *sql.DB, connStr string) {
+		ctx := context.Background()
+
+		// Create a table whose rows record whether they have been backfilled
+		_, err := conn.ExecContext(ctx, `
+			CREATE TABLE test_resume (
+				id INT PRIMARY KEY,
+				backfilled BOOLEAN NOT NULL DEFAULT false,
+				_pgroll_needs_backfill BOOLEAN NOT NULL DEFAULT true
+			);
+			INSERT INTO test_resume (id) SELECT generate_series(1, 10);
+			CREATE FUNCTION mark_backfilled() RETURNS trigger AS $$
+			BEGIN
+				NEW.backfilled := true;
+				RETURN NEW;
+			END $$ LANGUAGE plpgsql;
+			CREATE TRIGGER mark_backfilled BEFORE UPDATE ON test_resume
+				FOR EACH ROW EXECUTE FUNCTION mark_backfilled();
+		`)
+		require.NoError(t, err)
+
+		// Start from a checkpoint saved after the first 5 rows were backfilled
+		store := &memoryCheckpointStore{checkpoints: map[string]*backfill.Checkpoint{
+			"test_resume": {LastValue: []string{"5"}, RowsDone: 5},
+		}}
+
+		bf := backfill.New(&db.RDB{DB: conn}, backfill.NewConfig(backfill.WithBatchSize(2))).
+			WithCheckpointStore(store)
+
+		table := &schema.Table{
+			Name:       "test_resume",
+			PrimaryKey: []string{"id"},
+		}
+		err = bf.Start(ctx, table)
+		require.NoError(t, err)
+
+		// Only the rows after the checkpoint were backfilled
+		rows, err := conn.QueryContext(ctx, "SELECT id FROM test_resume WHERE backfilled ORDER BY id")
+		require.NoError(t, err)
+		defer rows.Close()
+
+		var ids []int
+		for rows.Next() {
+			var id int
+			require.NoError(t, rows.Scan(&id))
+			ids = append(ids, id)
+		}
+		require.NoError(t, rows.Err())
+		require.Equal(t, []int{6, 7, 8, 9, 10}, ids)
+
+		// The checkpoint records that the backfill has completed
+		cp := store.checkpoints["test_resume"]
+		require.True(t, cp.Done)
+		require.Equal(t, []string{"10"}, cp.LastValue)
+		require.Equal(t, int64(10), cp.RowsDone)
+
+		// Starting the backfill of a completed table again does nothing
+		_, err = conn.ExecContext(ctx, `
+			ALTER TABLE test_resume DISABLE TRIGGER mark_backfilled;
+			UPDATE test_resume SET backfilled = false;
+			ALTER TABLE test_resume ENABLE TRIGGER mark_backfilled;
+		`)
+		require.NoError(t, err)
+
+		err = bf.Start(ctx, table)
+		require.NoError(t, err)
+
+		var count int
+		err = conn.QueryRowContext(ctx, "SELECT count(*) FROM test_resume WHERE backfilled").Scan(&count)
+		require.NoError(t, err)
+		require.Zero(t, count)
+	})
+}
+
+type memoryCheckpointStore struct {
+	checkpoints map[string]*backfill.Checkpoint
+}
+
+func (s *memoryCheckpointStore) LoadCheckpoint(_ context.Context, table string) (*backfill.Checkpoint, error) {
+	return s.checkpoints[table], nil
+}
+
+func (s *memoryCheckpointStore) SaveCheckpoint(_ context.Context, table string, cp *backfill.Checkpoint) error {
+	saved := *cp
+	s.checkpoints[table] = &saved
+	return nil
+}
+
+func TestBackfillSplitsTableIntoRanges(t *testing.T) {
+	t.Parallel()
+
+	testutils.WithConnectionToContainer(t, func(conn *sql.DB, connStr string) {
+		ctx := context.Background()
+
+		// Create a table whose rows count how many times they have been backfilled
+		_, err := conn.ExecContext(ctx, `
+			CREATE TABLE test_parallel (
+				id INT PRIMARY KEY,
+				backfills INT NOT NULL DEFAULT 0,
+				_pgroll_needs_backfill BOOLEAN NOT NULL DEFAULT true
+			);
+			INSERT INTO test_parallel (id) SELECT generate_series(1, 1000);
+			ANALYZE test_parallel;
+			CREATE FUNCTION count_backfills() RETURNS trigger AS $$
+			BEGIN
+				NEW.backfills := OLD.backfills + 1;
+				RETURN NEW;
+			END $$ LANGUAGE plpgsql;
+			CREATE TRIGGER count_backfills BEFORE UPDATE ON test_parallel
+				FOR EACH ROW EXECUTE FUNCTION count_backfills();
+		`)
+		require.NoError(t, err)
+
+		store := &memoryCheckpointStore{checkpoints: map[string]*backfill.Checkpoint{}}
+		cfg := backfill.NewConfig(backfill.WithBatchSize(50), backfill.WithConcurrency(4))
+
+		var maxDone, lastTotal int64
+		cfg.AddCallback(func(done, total int64) {
+			maxDone, lastTotal = max(maxDone, done), total
+		})
+
+		bf := backfill.New(&db.RDB{DB: conn}, cfg).WithCheckpointStore(store)
+		err = bf.Start(ctx, &schema.Table{
+			Name:       "test_parallel",
+			PrimaryKey: []string{"id"},
+		})
+		require.NoError(t, err)
+
+		// Every row was backfilled exactly once
+		var count int
+		err = conn.QueryRowContext(ctx, "SELECT count(*) FROM test_parallel WHERE backfills <> 1").Scan(&count)
+		require.NoError(t, err)
+		require.Zero(t, count)
+
+		// Progress was aggregated across all ranges
+		require.Equal(t, int64(1000), lastTotal)
+		require.Equal(t, int64(1000), maxDone)
+
+		// The checkpoint records that the backfill has completed
+		cp := store.checkpoints["test_parallel"]
+		require.True(t, cp.Done)
+		require.Equal(t, int64(1000), cp.RowsDone)
+	})
+}
+
+func TestBackfillResumesRangesFromCheckpoint(t *testing.T) {
+	t.Parallel()
+
+	testutils.WithConnectionToContainer(t, func(conn *sql.DB, connStr string) {
+		ctx := context.Background()
+
+		// Create a table whose rows count how many times they have been
+		// backfilled. The first half of each range has already been backfilled.
+		_, err := conn.ExecContext(ctx, `
+			CREATE TABLE test_resume_ranges (
+				id INT PRIMARY KEY,
+				backfills INT NOT NULL DEFAULT 0,
+				_pgroll_needs_backfill BOOLEAN NOT NULL DEFAULT true
+			);
+			INSERT INTO test_resume_ranges (id) SELECT generate_series(1, 100);
+			UPDATE test_resume_ranges SET backfills = 1, _pgroll_needs_backfill = false
+				WHERE id <= 25 OR id BETWEEN 51 AND 75;
+			CREATE FUNCTION count_range_backfills() RETURNS trigger AS $$
+			BEGIN
+				NEW.backfills := OLD.backfills + 1;
+				RETURN NEW;
+			END $$ LANGUAGE plpgsql;
+			CREATE TRIGGER count_range_backfills BEFORE UPDATE ON test_resume_ranges
+				FOR EACH ROW EXECUTE FUNCTION count_range_backfills();
+		`)
+		require.NoError(t, err)
+
+		store := &memoryCheckpointStore{checkpoints: map[string]*backfill.Checkpoint{
+			"test_resume_ranges": {
+				Ranges: []backfill.KeyRange{
+					{Upper: []string{"50"}, LastValue: []string{"25"}},
+					{Lower: []string{"50"}, LastValue: []string{"75"}},
+				},
+				RowsDone: 50,
+			},
+		}}
+
+		cfg := backfill.NewConfig(backfill.WithBatchSize(10), backfill.WithConcurrency(2))
+		bf := backfill.New(&db.RDB{DB: conn}, cfg).WithCheckpointStore(store)
+		err = bf.Start(ctx, &schema.Table{
+			Name:       "test_resume_ranges",
+			PrimaryKey: []string{"id"},
+		})
+		require.NoError(t, err)
+
+		// Every row was backfilled exactly once
+		var count int
+		err = conn.QueryRowContext(ctx, "SELECT count(*) FROM test_resume_ranges WHERE backfills <> 1").Scan(&count)
+		require.NoError(t, err)
+		require.Zero(t, count)
+
+		// The checkpoint records the progress of each range
+		cp := store.checkpoints["test_resume_ranges"]
+		require.True(t, cp.Done)
+		require.Equal(t, int64(100), cp.RowsDone)
+		require.Equal(t, []string{"50"}, cp.Ranges[0].LastValue)
+		require.Equal(t, []string{"100"}, cp.Ranges[1].LastValue)
+	})
+}
+
+func TestBackfillPausesWhileProbeExceedsThreshold(t *testing.T) {
+	t.Parallel()
+
+	testutils.WithConnectionToContainer(t, func(conn *sql.DB, connStr string) {
+		ctx := context.Background()
+
+		_, err := conn.ExecContext(ctx, `
+			CREATE TABLE test_throttle (
+				id INT PRIMARY KEY,
+				_pgroll_needs_backfill BOOLEAN NOT NULL DEFAULT true
+			);
+			INSERT INTO test_throttle (id) SELECT generate_series(1, 100);
+			CREATE TABLE test_load (value INT NOT NULL);
+			INSERT INTO test_load VALUES (10);
+		`)
+		require.NoError(t, err)
+
+		cfg := backfill.NewConfig(
+			backfill.WithBatchSize(10),
+			backfill.WithThrottleProbe("SELECT value FROM test_load", 5),
+			backfill.WithThrottlePollInterval(10*time.Millisecond),
+		)
+
+		done := make(chan error, 1)
+		go func() {
+			done <- backfill.New(&db.RDB{DB: conn}, cfg).Start(ctx, &schema.Table{
+				Name:       "test_throttle",
+				PrimaryKey: []string{"id"},
+			})
+		}()
+
+		// The backfill does not make progress while the probe exceeds the threshold
+		time.Sleep(200 * time.Millisecond)
+		var remaining int
+		err = conn.QueryRowContext(ctx, "SELECT count(*) FROM test_throttle WHERE _pgroll_needs_backfill").Scan(&remaining)
+		require.NoError(t, err)
+		require.Equal(t, 100, remaining)
+
+		// Once the load drops the backfill completes
+		_, err = conn.ExecContext(ctx, "UPDATE test_load SET value = 0")
+		require.NoError(t, err)
+
+		select {
+		case err := <-done:
+			require.NoError(t, err)
+		case <-time.After(10 * time.Second):
+			t.Fatal("backfill did not resume after the probe dropped below the threshold")
+		}
+	})
+}
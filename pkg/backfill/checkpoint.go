@@ -0,0 +1,55 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package backfill
+
+import (
+	"context"
+	"time"
+)
+
+// Checkpoint records the progress of the backfill of a single table.
+type Checkpoint struct {
+	// The primary key of the last row that was backfilled. Nil if no rows have
+	// been backfilled or the table is backfilled without using its primary key.
+	LastValue []string
+
+	// The progress of each range of primary key values when the table is split
+	// into ranges that are backfilled in parallel. Nil if the table is
+	// backfilled as a single range.
+	Ranges []KeyRange
+
+	// The number of rows backfilled so far
+	RowsDone int64
+
+	// When the backfill of the table was started
+	StartedAt time.Time
+
+	// Whether the backfill of the table has completed
+	Done bool
+}
+
+// KeyRange records the progress of the backfill of a range of primary key
+// values of a table.
+type KeyRange struct {
+	// The exclusive lower bound of the range, or nil if the range starts at
+	// the beginning of the table
+	Lower []string `json:"lower"`
+
+	// The inclusive upper bound of the range, or nil if the range extends to
+	// the end of the table
+	Upper []string `json:"upper"`
+
+	// The primary key of the last row in the range that was backfilled, or nil
+	// if no rows in the range have been backfilled
+	LastValue []string `json:"last_value"`
+}
+
+// CheckpointStore persists backfill checkpoints so that an interrupted
+// backfill can be resumed from the last completed batch.
+type CheckpointStore interface {
+	// LoadCheckpoint returns the checkpoint for `table`, or nil if there is none.
+	LoadCheckpoint(ctx context.Context, table string) (*Checkpoint, error)
+
+	// SaveCheckpoint stores the checkpoint for `table`.
+	SaveCheckpoint(ctx context.Context, table string, cp *Checkpoint) error
+}
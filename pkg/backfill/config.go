@@ -7,28 +7,45 @@ import (
 )
 
 type Config struct {
-	batchSize  int
-	batchDelay time.Duration
-	callbacks  []CallbackFn
+	batchSize   int
+	batchDelay  time.Duration
+	concurrency int
+	throttle    throttle
+	callbacks   []CallbackFn
 }
 
 const (
-	DefaultBatchSize int           = 1000
-	DefaultDelay     time.Duration = 0
+	DefaultBatchSize   int           = 1000
+	DefaultDelay       time.Duration = 0
+	DefaultConcurrency int           = 1
 )
 
 type OptionFn func(*Config)
 
 func NewConfig(opts ...OptionFn) *Config {
 	c := &Config{
-		batchSize:  DefaultBatchSize,
-		batchDelay: DefaultDelay,
-		callbacks:  make([]CallbackFn, 0),
+		batchSize:   DefaultBatchSize,
+		batchDelay:  DefaultDelay,
+		concurrency: DefaultConcurrency,
+		throttle: throttle{
+			pollInterval: DefaultThrottlePollInterval,
+		},
+		callbacks: make([]CallbackFn, 0),
 	}
 
 	for _, opt := range opts {
 		opt(c)
 	}
+
+	// Unless set explicitly, allow the batch size to be adapted to between a
+	// tenth of and ten times the configured batch size.
+	if c.throttle.minBatchSize <= 0 {
+		c.throttle.minBatchSize = max(c.batchSize/10, 1)
+	}
+	if c.throttle.maxBatchSize <= 0 {
+		c.throttle.maxBatchSize = c.batchSize * 10
+	}
+
 	return c
 }
 
@@ -46,8 +63,71 @@ func WithBatchDelay(delay time.Duration) OptionFn {
 	}
 }
 
+// WithConcurrency sets the maximum number of batches that are backfilled at
+// the same time. When greater than one, tables are backfilled in parallel and
+// large tables are split into ranges of primary key values that are
+// backfilled in parallel.
+func WithConcurrency(concurrency int) OptionFn {
+	return func(o *Config) {
+		o.concurrency = concurrency
+	}
+}
+
+// WithMaxReplicationLag pauses the backfill before each batch while the replay
+// lag of any replica, as reported by `pg_stat_replication`, is greater than
+// `lag`.
+func WithMaxReplicationLag(lag time.Duration) OptionFn {
+	return func(o *Config) {
+		o.throttle.maxReplicationLag = lag
+	}
+}
+
+// WithThrottleProbe pauses the backfill before each batch while the query
+// `sql`, which must return a single numeric value, returns a value greater
+// than `threshold`.
+func WithThrottleProbe(sql string, threshold float64) OptionFn {
+	return func(o *Config) {
+		o.throttle.probeSQL = sql
+		o.throttle.probeThreshold = threshold
+	}
+}
+
+// WithThrottlePollInterval sets how long a paused backfill waits before
+// checking again whether it can continue.
+func WithThrottlePollInterval(interval time.Duration) OptionFn {
+	return func(o *Config) {
+		o.throttle.pollInterval = interval
+	}
+}
+
+// WithTargetBatchDuration adapts the batch size after each batch so that
+// batches take approximately `d` to backfill. The batch size set with
+// `WithBatchSize` is used for the first batch.
+func WithTargetBatchDuration(d time.Duration) OptionFn {
+	return func(o *Config) {
+		o.throttle.targetBatchDuration = d
+	}
+}
+
+// WithBatchSizeLimits sets the range within which the batch size is adapted
+// when a target batch duration is set.
+func WithBatchSizeLimits(minSize, maxSize int) OptionFn {
+	return func(o *Config) {
+		o.throttle.minBatchSize = minSize
+		o.throttle.maxBatchSize = maxSize
+	}
+}
+
+// Concurrency returns the maximum number of batches that are backfilled at the
+// same time.
+func (c *Config) Concurrency() int {
+	return c.concurrency
+}
+
 // AddCallback adds a callback to the backfill operation.
-// Callbacks are invoked after each batch is processed.
+// Callbacks are invoked after each batch is processed. When batches are
+// backfilled in parallel, callbacks receive the progress aggregated across all
+// tables that are being backfilled and are never invoked concurrently.
 func (c *Config) AddCallback(fn CallbackFn) {
 	c.callbacks = append(c.callbacks, fn)
 }
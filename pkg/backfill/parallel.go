@@ -0,0 +1,148 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package backfill
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/lib/pq"
+
+	"github.com/xataio/pgroll/pkg/db"
+)
+
+// tableCheckpoint is the checkpoint of a table that may be shared by several
+// batchers.
+type tableCheckpoint struct {
+	*Checkpoint
+
+	mu sync.Mutex
+
+	// The batchers backfilling the table. When the table is split into key
+	// ranges, the i'th batcher backfills the i'th range of the checkpoint.
+	batchers []batcher
+}
+
+// progress aggregates the progress of all tables that are being backfilled.
+type progress struct {
+	mu    sync.Mutex
+	done  map[string]int64
+	total map[string]int64
+}
+
+func newProgress() *progress {
+	return &progress{
+		done:  make(map[string]int64),
+		total: make(map[string]int64),
+	}
+}
+
+// start begins tracking the progress of `table`.
+func (p *progress) start(table string, done, total int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.done[table] = done
+	p.total[table] = total
+}
+
+// finish stops tracking the progress of `table`.
+func (p *progress) finish(table string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.done, table)
+	delete(p.total, table)
+}
+
+// add records that `n` more rows of `table` have been backfilled.
+func (p *progress) add(table string, n int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.done[table] += n
+}
+
+// report invokes `callbacks` with the progress of all tracked tables.
+func (p *progress) report(callbacks []CallbackFn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var done, total int64
+	for table, n := range p.done {
+		done += n
+		total += p.total[table]
+	}
+
+	for _, cb := range callbacks {
+		cb(done, total)
+	}
+}
+
+// rangeSampleRows is the number of rows sampled for each key range when
+// splitting a table into ranges
+const rangeSampleRows = 100
+
+// getRangeBounds splits the rows of `table` into ranges of approximately
+// `rowsPerRange` rows each, ordered by `key`, and returns the upper bound of
+// at most `n` ranges. The rows after the last bound form a final range.
+//
+// The bounds are taken from a sample of the table's blocks rather than from
+// all of its rows, so that the table is not scanned and sorted in full. Fewer
+// bounds are returned if the sample is too small to split the table further.
+func getRangeBounds(ctx context.Context, conn db.DB, table string, key []string, rowsPerRange int64, n int) ([][]string, error) {
+	if rowsPerRange <= 0 || n <= 0 {
+		return nil, nil
+	}
+
+	quoted := make([]string, len(key))
+	for i, k := range key {
+		quoted[i] = pq.QuoteIdentifier(k)
+	}
+	cols := strings.Join(quoted, ", ")
+	percent := min(100, 100*float64(rangeSampleRows)/float64(rowsPerRange))
+
+	//nolint:gosec // table and column names are quoted
+	query := fmt.Sprintf("SELECT %[1]s FROM %[2]s TABLESAMPLE SYSTEM (%[3]s) ORDER BY %[1]s",
+		cols, pq.QuoteIdentifier(table), strconv.FormatFloat(percent, 'f', -1, 64))
+
+	rows, err := conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sample [][]string
+	for rows.Next() {
+		values := make([]string, len(key))
+		dest := make([]any, len(values))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		sample = append(sample, values)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Split the sample into n+1 equally sized parts; the sampled rows are
+	// unique and ordered, so the bounds are strictly increasing
+	var bounds [][]string
+	last := 0
+	for i := 1; i <= n; i++ {
+		idx := len(sample) * i / (n + 1)
+		if idx == last {
+			continue
+		}
+		bounds = append(bounds, sample[idx])
+		last = idx
+	}
+
+	return bounds, nil
+}
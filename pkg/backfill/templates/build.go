@@ -14,6 +14,7 @@ type BatchConfig struct {
 	TableName           string
 	PrimaryKey          []string
 	LastValue           []string
+	UpperValue          []string
 	BatchSize           int
 	NeedsBackfillColumn string
 }
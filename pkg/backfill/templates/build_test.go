@@ -51,6 +51,27 @@ func TestBatchStatementBuilder(t *testing.T) {
 			},
 			expected: multipleIDColumnsWithLastValue,
 		},
+		"single identity column with last value and upper value": {
+			config: BatchConfig{
+				TableName:           "table_name",
+				PrimaryKey:          []string{"id"},
+				NeedsBackfillColumn: "_pgroll_needs_backfill",
+				LastValue:           []string{"1"},
+				UpperValue:          []string{"100"},
+				BatchSize:           10,
+			},
+			expected: singleIDColumnWithLastValueAndUpperValue,
+		},
+		"multiple identity columns with upper value": {
+			config: BatchConfig{
+				TableName:           "table_name",
+				PrimaryKey:          []string{"id", "zip"},
+				NeedsBackfillColumn: "_pgroll_needs_backfill",
+				UpperValue:          []string{"100", "1234"},
+				BatchSize:           10,
+			},
+			expected: multipleIDColumnsWithUpperValue,
+		},
 	}
 
 	for name, test := range tests {
@@ -80,7 +101,7 @@ update AS
   WHERE "table_name"."id" = batch."id"
   RETURNING "table_name"."id"
 )
-SELECT LAST_VALUE("id") OVER()
+SELECT LAST_VALUE("id") OVER(), COUNT(*) OVER()
 FROM update
 `
 
@@ -101,7 +122,7 @@ update AS
   WHERE "table_name"."id" = batch."id" AND "table_name"."zip" = batch."zip"
   RETURNING "table_name"."id", "table_name"."zip"
 )
-SELECT LAST_VALUE("id") OVER(), LAST_VALUE("zip") OVER()
+SELECT LAST_VALUE("id") OVER(), LAST_VALUE("zip") OVER(), COUNT(*) OVER()
 FROM update
 `
 
@@ -123,7 +144,7 @@ update AS
   WHERE "table_name"."id" = batch."id"
   RETURNING "table_name"."id"
 )
-SELECT LAST_VALUE("id") OVER()
+SELECT LAST_VALUE("id") OVER(), COUNT(*) OVER()
 FROM update
 `
 
@@ -145,6 +166,51 @@ update AS
   WHERE "table_name"."id" = batch."id" AND "table_name"."zip" = batch."zip"
   RETURNING "table_name"."id", "table_name"."zip"
 )
-SELECT LAST_VALUE("id") OVER(), LAST_VALUE("zip") OVER()
+SELECT LAST_VALUE("id") OVER(), LAST_VALUE("zip") OVER(), COUNT(*) OVER()
+FROM update
+`
+
+const singleIDColumnWithLastValueAndUpperValue = `WITH batch AS
+(
+  SELECT "id"
+  FROM "table_name"
+  WHERE "_pgroll_needs_backfill" = true
+  AND ("id") > ('1')
+  AND ("id") <= ('100')
+  ORDER BY "id"
+  LIMIT 10
+  FOR NO KEY UPDATE
+),
+update AS
+(
+  UPDATE "table_name"
+  SET "id" = "table_name"."id"
+  FROM batch
+  WHERE "table_name"."id" = batch."id"
+  RETURNING "table_name"."id"
+)
+SELECT LAST_VALUE("id") OVER(), COUNT(*) OVER()
+FROM update
+`
+
+const multipleIDColumnsWithUpperValue = `WITH batch AS
+(
+  SELECT "id", "zip"
+  FROM "table_name"
+  WHERE "_pgroll_needs_backfill" = true
+  AND ("id", "zip") <= ('100', '1234')
+  ORDER BY "id", "zip"
+  LIMIT 10
+  FOR NO KEY UPDATE
+),
+update AS
+(
+  UPDATE "table_name"
+  SET "id" = "table_name"."id", "zip" = "table_name"."zip"
+  FROM batch
+  WHERE "table_name"."id" = batch."id" AND "table_name"."zip" = batch."zip"
+  RETURNING "table_name"."id", "table_name"."zip"
+)
+SELECT LAST_VALUE("id") OVER(), LAST_VALUE("zip") OVER(), COUNT(*) OVER()
 FROM update
 `
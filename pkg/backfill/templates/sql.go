@@ -10,6 +10,9 @@ const SQL = `WITH batch AS
   {{ if .LastValue -}}
   AND ({{ commaSeparate (quoteIdentifiers .PrimaryKey) }}) > ({{ commaSeparate (quoteLiterals .LastValue) }})
   {{ end -}}
+  {{ if .UpperValue -}}
+  AND ({{ commaSeparate (quoteIdentifiers .PrimaryKey) }}) <= ({{ commaSeparate (quoteLiterals .UpperValue) }})
+  {{ end -}}
   ORDER BY {{ commaSeparate (quoteIdentifiers .PrimaryKey) }}
   LIMIT {{ .BatchSize }}
   FOR NO KEY UPDATE
@@ -22,6 +25,6 @@ update AS
   WHERE {{ updateWhereClause .TableName .PrimaryKey }}
   RETURNING {{ updateReturnClause .TableName .PrimaryKey }}
 )
-SELECT {{ selectLastValue .PrimaryKey }}
+SELECT {{ selectLastValue .PrimaryKey }}, COUNT(*) OVER()
 FROM update
 `
@@ -0,0 +1,136 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package backfill
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/xataio/pgroll/pkg/db"
+)
+
+// DefaultThrottlePollInterval is how long a throttled backfill waits before
+// checking again whether it can continue.
+const DefaultThrottlePollInterval = time.Second
+
+// throttle pauses a backfill while replicas are lagging or a user-supplied
+// probe reports that the database is under load, and adapts the batch size to
+// hit a target batch duration.
+type throttle struct {
+	// The maximum replay lag of any replica before the backfill is paused.
+	// Zero disables the check.
+	maxReplicationLag time.Duration
+
+	// A query returning a single numeric value. The backfill is paused while
+	// the value is greater than `probeThreshold`. Empty disables the check.
+	probeSQL       string
+	probeThreshold float64
+
+	// How long to wait before checking again whether a paused backfill can
+	// continue
+	pollInterval time.Duration
+
+	// The duration each batch should take. Zero disables batch size adaptation.
+	targetBatchDuration time.Duration
+
+	// The range within which the batch size is adapted
+	minBatchSize int
+	maxBatchSize int
+}
+
+func (t *throttle) enabled() bool {
+	return t.maxReplicationLag > 0 || t.probeSQL != ""
+}
+
+// wait blocks until no throttling threshold is exceeded or the context is
+// cancelled.
+func (t *throttle) wait(ctx context.Context, conn db.DB) error {
+	if !t.enabled() {
+		return nil
+	}
+
+	for {
+		throttled, err := t.throttled(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if !throttled {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(t.pollInterval):
+		}
+	}
+}
+
+// throttled returns true if any throttling threshold is exceeded.
+func (t *throttle) throttled(ctx context.Context, conn db.DB) (bool, error) {
+	if t.maxReplicationLag > 0 {
+		lag, err := getReplicationLag(ctx, conn)
+		if err != nil {
+			return false, fmt.Errorf("get replication lag: %w", err)
+		}
+		if lag > t.maxReplicationLag {
+			return true, nil
+		}
+	}
+
+	if t.probeSQL != "" {
+		var value sql.NullFloat64
+		if err := queryFirstValue(ctx, conn, t.probeSQL, &value); err != nil {
+			return false, fmt.Errorf("run throttle probe: %w", err)
+		}
+		if value.Valid && value.Float64 > t.probeThreshold {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// nextBatchSize returns the batch size to use after a batch of `size` rows
+// took `elapsed` to backfill. The batch size is scaled towards the target
+// batch duration, changing by at most a factor of two each batch.
+func (t *throttle) nextBatchSize(size int, elapsed time.Duration) int {
+	if t.targetBatchDuration <= 0 || elapsed <= 0 {
+		return size
+	}
+
+	ratio := float64(t.targetBatchDuration) / float64(elapsed)
+	ratio = min(max(ratio, 0.5), 2)
+
+	next := int(float64(size) * ratio)
+	return min(max(next, t.minBatchSize, 1), max(t.maxBatchSize, 1))
+}
+
+// getReplicationLag returns the largest replay lag of any replica, or zero if
+// there are no replicas.
+func getReplicationLag(ctx context.Context, conn db.DB) (time.Duration, error) {
+	var seconds sql.NullFloat64
+	err := queryFirstValue(ctx, conn,
+		"SELECT EXTRACT(EPOCH FROM max(replay_lag)) FROM pg_stat_replication",
+		&seconds)
+	if err != nil {
+		return 0, err
+	}
+	if !seconds.Valid {
+		return 0, nil
+	}
+
+	return time.Duration(seconds.Float64 * float64(time.Second)), nil
+}
+
+func queryFirstValue[T any](ctx context.Context, conn db.DB, query string, dest *T) error {
+	rows, err := conn.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	return db.ScanFirstValue(rows, dest)
+}
@@ -0,0 +1,70 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package backfill
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextBatchSize(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		throttle throttle
+		size     int
+		elapsed  time.Duration
+		want     int
+	}{
+		"no target duration keeps the batch size": {
+			throttle: throttle{minBatchSize: 1, maxBatchSize: 10000},
+			size:     1000,
+			elapsed:  time.Second,
+			want:     1000,
+		},
+		"slow batches shrink the batch size": {
+			throttle: throttle{targetBatchDuration: 300 * time.Millisecond, minBatchSize: 1, maxBatchSize: 10000},
+			size:     1000,
+			elapsed:  400 * time.Millisecond,
+			want:     750,
+		},
+		"fast batches grow the batch size": {
+			throttle: throttle{targetBatchDuration: 500 * time.Millisecond, minBatchSize: 1, maxBatchSize: 10000},
+			size:     1000,
+			elapsed:  400 * time.Millisecond,
+			want:     1250,
+		},
+		"the batch size at most halves": {
+			throttle: throttle{targetBatchDuration: 100 * time.Millisecond, minBatchSize: 1, maxBatchSize: 10000},
+			size:     1000,
+			elapsed:  10 * time.Second,
+			want:     500,
+		},
+		"the batch size at most doubles": {
+			throttle: throttle{targetBatchDuration: 10 * time.Second, minBatchSize: 1, maxBatchSize: 10000},
+			size:     1000,
+			elapsed:  100 * time.Millisecond,
+			want:     2000,
+		},
+		"the batch size does not exceed the maximum": {
+			throttle: throttle{targetBatchDuration: time.Second, minBatchSize: 1, maxBatchSize: 1500},
+			size:     1000,
+			elapsed:  100 * time.Millisecond,
+			want:     1500,
+		},
+		"the batch size does not fall below the minimum": {
+			throttle: throttle{targetBatchDuration: 100 * time.Millisecond, minBatchSize: 800, maxBatchSize: 10000},
+			size:     1000,
+			elapsed:  time.Second,
+			want:     800,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.want, tc.throttle.nextBatchSize(tc.size, tc.elapsed))
+		})
+	}
+}
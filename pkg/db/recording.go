@@ -0,0 +1,53 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+)
+
+// RecordingDB is a fake implementation of `DB` that records every statement
+// passed to `ExecContext` instead of executing it. Queries are answered in the
+// same way as `FakeDB`.
+type RecordingDB struct {
+	FakeDB
+
+	mu         sync.Mutex
+	statements []string
+}
+
+// ExecContext records the statement and returns without executing it.
+func (db *RecordingDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.statements = append(db.statements, query)
+	return nil, nil
+}
+
+// WithRetryableTransaction invokes `f` once with a nil transaction. Statements
+// issued through the `RecordingDB` from within `f` are recorded; `f` must not
+// use the transaction directly.
+func (db *RecordingDB) WithRetryableTransaction(ctx context.Context, f func(context.Context, *sql.Tx) error) error {
+	return f(ctx, nil)
+}
+
+// Statements returns the statements recorded since the last call to `Reset`.
+func (db *RecordingDB) Statements() []string {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	stmts := make([]string, len(db.statements))
+	copy(stmts, db.statements)
+	return stmts
+}
+
+// Reset discards all recorded statements.
+func (db *RecordingDB) Reset() {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.statements = nil
+}
@@ -0,0 +1,105 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Checksum returns the SHA-256 checksum of the canonical JSON representation
+// of the migration. The checksum does not depend on the name of the migration
+// nor on the formatting or format of the file it was read from.
+func (m *Migration) Checksum() (string, error) {
+	canonical, err := m.CanonicalJSON()
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// CanonicalJSON returns the JSON representation of the migration with object
+// keys sorted and null and zero values removed. Removing zero values keeps the
+// representation, and so the checksum, of existing migrations the same when a
+// field is added to an operation; the field only appears once it is set. A
+// field set to its zero value is not distinguished from an unset field.
+func (m *Migration) CanonicalJSON() ([]byte, error) {
+	raw, err := json.Marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal migration: %w", err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("unable to decode migration: %w", err)
+	}
+
+	return json.Marshal(withoutZeroValues(v))
+}
+
+// CanonicalYAML returns the canonical representation of the migration in
+// YAML, for display
+func (m *Migration) CanonicalYAML() (string, error) {
+	canonical, err := m.CanonicalJSON()
+	if err != nil {
+		return "", err
+	}
+
+	out, err := yaml.JSONToYAML(canonical)
+	if err != nil {
+		return "", fmt.Errorf("unable to convert migration to YAML: %w", err)
+	}
+	return string(out), nil
+}
+
+// withoutZeroValues returns `v` with null, false, zero, empty string, empty
+// array and empty object values removed from all objects. Elements of arrays
+// are kept, so that their positions do not change.
+func withoutZeroValues(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, elem := range v {
+			elem = withoutZeroValues(elem)
+			if isZeroValue(elem) {
+				delete(v, k)
+				continue
+			}
+			v[k] = elem
+		}
+	case []any:
+		for i, elem := range v {
+			v[i] = withoutZeroValues(elem)
+		}
+	}
+	return v
+}
+
+// isZeroValue returns whether `v`, as decoded from JSON, is a zero value
+func isZeroValue(v any) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case bool:
+		return !v
+	case string:
+		return v == ""
+	case json.Number:
+		f, err := v.Float64()
+		return err == nil && f == 0
+	case map[string]any:
+		return len(v) == 0
+	case []any:
+		return len(v) == 0
+	}
+	return false
+}
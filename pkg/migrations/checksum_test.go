@@ -0,0 +1,95 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xataio/pgroll/pkg/migrations"
+)
+
+func TestMigrationChecksum(t *testing.T) {
+	t.Parallel()
+
+	dir := fstest.MapFS{
+		"01_create_table.yaml": &fstest.MapFile{Data: []byte(`
+operations:
+  - create_table:
+      name: users
+      columns:
+        - name: id
+          type: serial
+          pk: true
+`)},
+		"02_create_table.json": &fstest.MapFile{Data: []byte(`{
+  "operations": [
+    {"create_table": {"columns": [{"pk": true, "type": "serial", "name": "id"}], "name": "users"}}
+  ]
+}`)},
+		"03_create_table.yaml": &fstest.MapFile{Data: []byte(`
+operations:
+  - create_table:
+      name: users
+      columns:
+        - name: id
+          type: bigserial
+          pk: true
+`)},
+		"04_create_table.yaml": &fstest.MapFile{Data: []byte(`
+operations:
+  - create_table:
+      name: users
+      comment: ""
+      columns:
+        - name: id
+          type: serial
+          pk: true
+          nullable: false
+          unique: false
+      constraints: []
+`)},
+	}
+
+	checksum := func(file string) string {
+		mig, err := migrations.ReadMigration(dir, file)
+		require.NoError(t, err)
+
+		sum, err := mig.Checksum()
+		require.NoError(t, err)
+		return sum
+	}
+
+	// The checksum depends only on the content of the migration
+	assert.Equal(t, checksum("01_create_table.yaml"), checksum("02_create_table.json"))
+
+	// Fields set to their zero value do not change the checksum
+	assert.Equal(t, checksum("01_create_table.yaml"), checksum("04_create_table.yaml"))
+	assert.NotEqual(t, checksum("01_create_table.yaml"), checksum("03_create_table.yaml"))
+	assert.Len(t, checksum("01_create_table.yaml"), 64)
+}
+
+func TestMigrationCanonicalYAML(t *testing.T) {
+	t.Parallel()
+
+	mig := migrations.Migration{
+		Name:          "02_rename_column",
+		VersionSchema: "v2",
+		Operations: migrations.Operations{
+			&migrations.OpRenameColumn{Table: "users", From: "name", To: "username"},
+		},
+	}
+
+	out, err := mig.CanonicalYAML()
+	require.NoError(t, err)
+	assert.Equal(t, `operations:
+- rename_column:
+    from: name
+    table: users
+    to: username
+version_schema: v2
+`, out)
+}
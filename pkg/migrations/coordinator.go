@@ -6,6 +6,8 @@ import (
 	"context"
 	"fmt"
 	"slices"
+
+	"github.com/xataio/pgroll/pkg/telemetry"
 )
 
 // Coordinator is responsible for executing a series of database actions in a specific orderedActions.
@@ -33,6 +35,16 @@ func NewCoordinator(actions []DBAction) *Coordinator {
 	}
 }
 
+// Actions returns the deduplicated actions in the order in which they will be
+// executed.
+func (c *Coordinator) Actions() []DBAction {
+	actions := make([]DBAction, 0, len(c.orderedActions))
+	for _, id := range c.orderedActions {
+		actions = append(actions, c.actions[id])
+	}
+	return actions
+}
+
 // Execute runs all actions in the order they were added to the coordinator.
 func (c *Coordinator) Execute(ctx context.Context) error {
 	for _, id := range c.orderedActions {
@@ -40,9 +52,17 @@ func (c *Coordinator) Execute(ctx context.Context) error {
 		if !exists {
 			return fmt.Errorf("action %s not found", id)
 		}
-		if err := action.Execute(ctx); err != nil {
+		if err := executeAction(ctx, action); err != nil {
 			return fmt.Errorf("failed to execute action %s: %w", id, err)
 		}
 	}
 	return nil
 }
+
+// executeAction executes `action`, recording a span for it
+func executeAction(ctx context.Context, action DBAction) (err error) {
+	ctx, span := telemetry.StartSpan(ctx, "pgroll.action", telemetry.ActionKey.String(action.ID()))
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	return action.Execute(ctx)
+}
@@ -4,6 +4,7 @@ package migrations
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"strings"
 	"time"
@@ -19,6 +20,8 @@ import (
 type DBAction interface {
 	ID() string
 	Execute(context.Context) error
+	// Locks returns the locks the action takes while it runs.
+	Locks() []LockImpact
 }
 
 type addColumnAction struct {
@@ -40,6 +43,19 @@ func NewAddColumnAction(conn db.DB, table string, c Column, withPK bool) *addCol
 
 func (a *addColumnAction) ID() string { return a.id }
 
+// Adding a column rewrites the table if the column is a stored generated
+// column or an identity column; both are described by `Generated`. Adding a
+// column with a volatile default also rewrites the table, but pgroll only adds
+// columns with defaults that can be added without a rewrite, and backfills
+// volatile defaults instead. Any other column is only added to the catalog.
+func (a *addColumnAction) Locks() []LockImpact {
+	generated := a.column.Generated
+	lock := accessExclusiveLock(a.table)
+	lock.RewritesTable = generated != nil && (generated.Expression != "" || generated.Identity != nil)
+	lock.MetadataOnly = !lock.RewritesTable
+	return []LockImpact{lock}
+}
+
 func (a *addColumnAction) Execute(ctx context.Context) error {
 	colSQL, err := ColumnSQLWriter{WithPK: a.withPK}.Write(a.column)
 	if err != nil {
@@ -73,6 +89,10 @@ func NewDropColumnAction(conn db.DB, table string, columns ...string) *dropColum
 
 func (a *dropColumnAction) ID() string { return a.id }
 
+func (a *dropColumnAction) Locks() []LockImpact {
+	return []LockImpact{accessExclusiveLock(a.table)}
+}
+
 func (a *dropColumnAction) Execute(ctx context.Context) error {
 	_, err := a.conn.ExecContext(ctx, fmt.Sprintf("ALTER TABLE IF EXISTS %s %s",
 		pq.QuoteIdentifier(a.table),
@@ -107,6 +127,10 @@ func NewRenameTableAction(conn db.DB, from, to string) *renameTableAction {
 
 func (a *renameTableAction) ID() string { return a.id }
 
+func (a *renameTableAction) Locks() []LockImpact {
+	return []LockImpact{accessExclusiveLock(a.from)}
+}
+
 func (a *renameTableAction) Execute(ctx context.Context) error {
 	_, err := a.conn.ExecContext(ctx, fmt.Sprintf("ALTER TABLE IF EXISTS %s RENAME TO %s",
 		pq.QuoteIdentifier(a.from),
@@ -114,6 +138,133 @@ func (a *renameTableAction) Execute(ctx context.Context) error {
 	return err
 }
 
+// createSchemaAction is a DBAction that creates a schema if it does not
+// already exist.
+type createSchemaAction struct {
+	conn db.DB
+	id   string
+	name string
+}
+
+func NewCreateSchemaAction(conn db.DB, name string) *createSchemaAction {
+	return &createSchemaAction{
+		conn: conn,
+		id:   fmt.Sprintf("create_schema_%s", name),
+		name: name,
+	}
+}
+
+func (a *createSchemaAction) ID() string { return a.id }
+
+func (a *createSchemaAction) Locks() []LockImpact { return nil }
+
+func (a *createSchemaAction) Execute(ctx context.Context) error {
+	_, err := a.conn.ExecContext(ctx, fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s",
+		pq.QuoteIdentifier(a.name)))
+	return err
+}
+
+// moveTableAction is a DBAction that moves a table to another schema.
+type moveTableAction struct {
+	conn  db.DB
+	id    string
+	table string
+	from  string
+	to    string
+}
+
+func NewMoveTableAction(conn db.DB, table, from, to string) *moveTableAction {
+	return &moveTableAction{
+		conn:  conn,
+		id:    fmt.Sprintf("move_table_%s_from_%s_to_%s", table, from, to),
+		table: table,
+		from:  from,
+		to:    to,
+	}
+}
+
+func (a *moveTableAction) ID() string { return a.id }
+
+func (a *moveTableAction) Locks() []LockImpact {
+	return []LockImpact{accessExclusiveLock(a.table)}
+}
+
+func (a *moveTableAction) Execute(ctx context.Context) error {
+	_, err := a.conn.ExecContext(ctx, fmt.Sprintf("ALTER TABLE IF EXISTS %s.%s SET SCHEMA %s",
+		pq.QuoteIdentifier(a.from),
+		pq.QuoteIdentifier(a.table),
+		pq.QuoteIdentifier(a.to)))
+	return err
+}
+
+// grantAction is a DBAction that grants privileges on a table to a role.
+type grantAction struct {
+	conn            db.DB
+	id              string
+	table           string
+	role            string
+	privileges      []TablePrivilege
+	withGrantOption bool
+}
+
+func NewGrantAction(conn db.DB, table, role string, privileges []TablePrivilege, withGrantOption bool) *grantAction {
+	return &grantAction{
+		conn:            conn,
+		id:              fmt.Sprintf("grant_%s_%s", table, role),
+		table:           table,
+		role:            role,
+		privileges:      privileges,
+		withGrantOption: withGrantOption,
+	}
+}
+
+func (a *grantAction) ID() string { return a.id }
+
+func (a *grantAction) Locks() []LockImpact { return nil }
+
+func (a *grantAction) Execute(ctx context.Context) error {
+	stmt := fmt.Sprintf("GRANT %s ON TABLE %s TO %s",
+		privilegesSQL(a.privileges),
+		pq.QuoteIdentifier(a.table),
+		quoteRole(a.role))
+	if a.withGrantOption {
+		stmt += " WITH GRANT OPTION"
+	}
+	_, err := a.conn.ExecContext(ctx, stmt)
+	return err
+}
+
+// revokeAction is a DBAction that revokes privileges on a table from a role.
+type revokeAction struct {
+	conn       db.DB
+	id         string
+	table      string
+	role       string
+	privileges []TablePrivilege
+}
+
+func NewRevokeAction(conn db.DB, table, role string, privileges []TablePrivilege) *revokeAction {
+	return &revokeAction{
+		conn:       conn,
+		id:         fmt.Sprintf("revoke_%s_%s", table, role),
+		table:      table,
+		role:       role,
+		privileges: privileges,
+	}
+}
+
+func (a *revokeAction) ID() string { return a.id }
+
+func (a *revokeAction) Locks() []LockImpact { return nil }
+
+func (a *revokeAction) Execute(ctx context.Context) error {
+	_, err := a.conn.ExecContext(ctx, fmt.Sprintf("REVOKE %s ON TABLE %s FROM %s",
+		privilegesSQL(a.privileges),
+		pq.QuoteIdentifier(a.table),
+		quoteRole(a.role)))
+	return err
+}
+
 // renameColumnAction is a DBAction that renames a column in a table.
 type renameColumnAction struct {
 	conn  db.DB
@@ -135,6 +286,10 @@ func NewRenameColumnAction(conn db.DB, table, from, to string) *renameColumnActi
 
 func (a *renameColumnAction) ID() string { return a.id }
 
+func (a *renameColumnAction) Locks() []LockImpact {
+	return []LockImpact{accessExclusiveLock(a.table)}
+}
+
 func (a *renameColumnAction) Execute(ctx context.Context) error {
 	_, err := a.conn.ExecContext(ctx, fmt.Sprintf("ALTER TABLE IF EXISTS %s RENAME COLUMN %s TO %s",
 		pq.QuoteIdentifier(a.table),
@@ -164,6 +319,10 @@ func NewRenameConstraintAction(conn db.DB, table, from, to string) *renameConstr
 
 func (a *renameConstraintAction) ID() string { return a.id }
 
+func (a *renameConstraintAction) Locks() []LockImpact {
+	return []LockImpact{accessExclusiveLock(a.table)}
+}
+
 func (a *renameConstraintAction) Execute(ctx context.Context) error {
 	_, err := a.conn.ExecContext(ctx, fmt.Sprintf("ALTER TABLE IF EXISTS %s RENAME CONSTRAINT %s TO %s",
 		pq.QuoteIdentifier(a.table),
@@ -192,6 +351,10 @@ func NewAddConstraintUsingUniqueIndex(conn db.DB, table, constraint, indexName s
 
 func (a *addConstraintUsingUniqueIndexAction) ID() string { return a.id }
 
+func (a *addConstraintUsingUniqueIndexAction) Locks() []LockImpact {
+	return []LockImpact{accessExclusiveLock(a.table)}
+}
+
 func (a *addConstraintUsingUniqueIndexAction) Execute(ctx context.Context) error {
 	_, err := a.conn.ExecContext(ctx, fmt.Sprintf("ALTER TABLE IF EXISTS %s ADD CONSTRAINT %s UNIQUE USING INDEX %s",
 		pq.QuoteIdentifier(a.table),
@@ -218,6 +381,14 @@ func NewAddPrimaryKeyAction(conn db.DB, table, indexName string) *addPrimaryKeyA
 
 func (a *addPrimaryKeyAction) ID() string { return a.id }
 
+// Adding a primary key using an existing unique index scans the table to check
+// that the key columns are not null, unless they are already `NOT NULL`.
+func (a *addPrimaryKeyAction) Locks() []LockImpact {
+	lock := accessExclusiveLock(a.table)
+	lock.ScansTable = true
+	return []LockImpact{lock}
+}
+
 func (a *addPrimaryKeyAction) Execute(ctx context.Context) error {
 	_, err := a.conn.ExecContext(ctx, fmt.Sprintf(
 		"ALTER TABLE %s ADD PRIMARY KEY USING INDEX %s",
@@ -244,6 +415,9 @@ func NewDropFunctionAction(conn db.DB, functions ...string) *dropFunctionAction
 
 func (a *dropFunctionAction) ID() string { return a.id }
 
+// Dropping a function does not lock any relation.
+func (a *dropFunctionAction) Locks() []LockImpact { return nil }
+
 func (a *dropFunctionAction) Execute(ctx context.Context) error {
 	functions := make([]string, len(a.functions))
 	for idx, fn := range a.functions {
@@ -282,6 +456,14 @@ func NewCreateIndexConcurrentlyAction(conn db.DB, table, name, method string, un
 
 func (a *createIndexConcurrentlyAction) ID() string { return a.id }
 
+func (a *createIndexConcurrentlyAction) Locks() []LockImpact {
+	return []LockImpact{{
+		Level:      LockLevelShareUpdateExclusive,
+		Relation:   a.table,
+		ScansTable: true,
+	}}
+}
+
 func (a *createIndexConcurrentlyAction) Execute(ctx context.Context) error {
 	stmtFmt := "CREATE INDEX CONCURRENTLY %s ON %s"
 	if a.unique {
@@ -353,6 +535,10 @@ func NewCommentColumnAction(conn db.DB, table, column string, comment *string) *
 
 func (a *commentColumnAction) ID() string { return a.id }
 
+func (a *commentColumnAction) Locks() []LockImpact {
+	return []LockImpact{{Level: LockLevelShareUpdateExclusive, Relation: a.table}}
+}
+
 func (a *commentColumnAction) Execute(ctx context.Context) error {
 	commentSQL := fmt.Sprintf("COMMENT ON COLUMN %s.%s IS %s",
 		pq.QuoteIdentifier(a.table),
@@ -382,6 +568,10 @@ func NewCommentTableAction(conn db.DB, table string, comment *string) *commentTa
 
 func (a *commentTableAction) ID() string { return a.id }
 
+func (a *commentTableAction) Locks() []LockImpact {
+	return []LockImpact{{Level: LockLevelShareUpdateExclusive, Relation: a.table}}
+}
+
 func (a *commentTableAction) Execute(ctx context.Context) error {
 	commentSQL := fmt.Sprintf("COMMENT ON TABLE %s IS %s",
 		pq.QuoteIdentifier(a.table),
@@ -420,6 +610,14 @@ func NewCreateUniqueIndexConcurrentlyAction(conn db.DB, schemaName, indexName, t
 
 func (a *createUniqueIndexConcurrentlyAction) ID() string { return a.id }
 
+func (a *createUniqueIndexConcurrentlyAction) Locks() []LockImpact {
+	return []LockImpact{{
+		Level:      LockLevelShareUpdateExclusive,
+		Relation:   a.tableName,
+		ScansTable: true,
+	}}
+}
+
 func (a *createUniqueIndexConcurrentlyAction) Execute(ctx context.Context) error {
 	quotedQualifiedIndexName := pq.QuoteIdentifier(a.indexName)
 	if a.schemaName != "" {
@@ -556,6 +754,11 @@ func NewCreateTableAction(conn db.DB, table, columns, constraints string) *creat
 
 func (a *createTableAction) ID() string { return a.id }
 
+// The lock on the new table can not conflict with any existing sessions.
+func (a *createTableAction) Locks() []LockImpact {
+	return []LockImpact{accessExclusiveLock(a.table)}
+}
+
 func (a *createTableAction) Execute(ctx context.Context) error {
 	_, err := a.conn.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s (%s %s)",
 		pq.QuoteIdentifier(a.table),
@@ -581,6 +784,10 @@ func NewDropIndexAction(conn db.DB, name string) *dropIndexAction {
 
 func (a *dropIndexAction) ID() string { return a.id }
 
+func (a *dropIndexAction) Locks() []LockImpact {
+	return []LockImpact{{Level: LockLevelShareUpdateExclusive, Relation: a.name}}
+}
+
 func (a *dropIndexAction) Execute(ctx context.Context) error {
 	_, err := a.conn.ExecContext(ctx, fmt.Sprintf("DROP INDEX CONCURRENTLY IF EXISTS %s",
 		pq.QuoteIdentifier(a.name)))
@@ -604,6 +811,10 @@ func NewDropTableAction(conn db.DB, table string) *DropTableAction {
 
 func (a *DropTableAction) ID() string { return a.id }
 
+func (a *DropTableAction) Locks() []LockImpact {
+	return []LockImpact{accessExclusiveLock(a.table)}
+}
+
 func (a *DropTableAction) Execute(ctx context.Context) error {
 	_, err := a.conn.ExecContext(ctx, fmt.Sprintf("DROP TABLE IF EXISTS %s",
 		pq.QuoteIdentifier(a.table)))
@@ -629,6 +840,14 @@ func NewValidateConstraintAction(conn db.DB, table, constraint string) *validate
 
 func (a *validateConstraintAction) ID() string { return a.id }
 
+func (a *validateConstraintAction) Locks() []LockImpact {
+	return []LockImpact{{
+		Level:      LockLevelShareUpdateExclusive,
+		Relation:   a.table,
+		ScansTable: true,
+	}}
+}
+
 func (a *validateConstraintAction) Execute(ctx context.Context) error {
 	_, err := a.conn.ExecContext(ctx, fmt.Sprintf("ALTER TABLE IF EXISTS %s VALIDATE CONSTRAINT %s",
 		pq.QuoteIdentifier(a.table),
@@ -663,6 +882,12 @@ func NewCreateCheckConstraintAction(conn db.DB, table, constraint, check string,
 
 func (a *CreateCheckConstraintAction) ID() string { return a.id }
 
+func (a *CreateCheckConstraintAction) Locks() []LockImpact {
+	lock := accessExclusiveLock(a.table)
+	lock.ScansTable = !a.skipValidation
+	return []LockImpact{lock}
+}
+
 func (a *CreateCheckConstraintAction) Execute(ctx context.Context) error {
 	sql := fmt.Sprintf("ALTER TABLE %s ADD ", pq.QuoteIdentifier(a.table))
 
@@ -716,6 +941,14 @@ func NewCreateFKConstraintAction(conn db.DB, table, constraint string, columns [
 
 func (a *createFKConstraintAction) ID() string { return a.id }
 
+// Adding a foreign key locks both the table and the referenced table.
+func (a *createFKConstraintAction) Locks() []LockImpact {
+	return []LockImpact{
+		{Level: LockLevelShareRowExclusive, Relation: a.table, ScansTable: !a.skipValidation},
+		{Level: LockLevelShareRowExclusive, Relation: a.reference.Table},
+	}
+}
+
 func (a *createFKConstraintAction) Execute(ctx context.Context) error {
 	sql := fmt.Sprintf("ALTER TABLE %s ADD ", pq.QuoteIdentifier(a.table))
 	writer := &ConstraintSQLWriter{
@@ -758,11 +991,22 @@ func NewAlterSequenceOwnerAction(conn db.DB, table, from, to string) *alterSeque
 
 func (a *alterSequenceOwnerAction) ID() string { return a.id }
 
+func (a *alterSequenceOwnerAction) Locks() []LockImpact {
+	return []LockImpact{{Level: LockLevelShareRowExclusive, Relation: a.table}}
+}
+
 func (a *alterSequenceOwnerAction) Execute(ctx context.Context) error {
 	sequence := getSequenceNameForColumn(ctx, a.conn, a.table, a.from)
 	if sequence == "" {
 		return nil
 	}
+
+	// The sequence of an identity column can not change owner, so make the new
+	// column an identity column continuing from the old sequence instead
+	if identity := getIdentityForColumn(ctx, a.conn, a.table, a.from); identity != "" {
+		return a.transferIdentity(ctx, sequence, identity)
+	}
+
 	_, err := a.conn.ExecContext(ctx, fmt.Sprintf(
 		"ALTER SEQUENCE IF EXISTS %s OWNED BY %s.%s",
 		sequence,
@@ -773,13 +1017,70 @@ func (a *alterSequenceOwnerAction) Execute(ctx context.Context) error {
 	return err
 }
 
+func (a *alterSequenceOwnerAction) transferIdentity(ctx context.Context, sequence, identity string) error {
+	return a.conn.WithRetryableTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, fmt.Sprintf("LOCK TABLE %s IN ACCESS EXCLUSIVE MODE", pq.QuoteIdentifier(a.table)))
+		if err != nil {
+			return err
+		}
+
+		var start int64
+		if err := tx.QueryRowContext(ctx, "SELECT nextval($1::regclass)", sequence).Scan(&start); err != nil {
+			return fmt.Errorf("failed to read sequence %q: %w", sequence, err)
+		}
+
+		// Dropping the identity drops its sequence
+		_, err = tx.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP IDENTITY",
+			pq.QuoteIdentifier(a.table),
+			pq.QuoteIdentifier(a.from)))
+		if err != nil {
+			return err
+		}
+
+		// Identity columns must be NOT NULL. The duplicated column only has an
+		// unchecked NOT NULL constraint at this point.
+		_, err = tx.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET NOT NULL",
+			pq.QuoteIdentifier(a.table),
+			pq.QuoteIdentifier(a.to)))
+		if err != nil {
+			return err
+		}
+
+		return addIdentity(ctx, tx, a.table, a.to, identity, start)
+	})
+}
+
+// getIdentityForColumn returns how values are generated for the identity
+// column `columnName`: ALWAYS or BY DEFAULT, or an empty string if the column
+// is not an identity column.
+func getIdentityForColumn(ctx context.Context, conn db.DB, tableName, columnName string) string {
+	var identity string
+	rows, err := conn.QueryContext(ctx, `
+		SELECT CASE attidentity WHEN 'a' THEN 'ALWAYS' WHEN 'd' THEN 'BY DEFAULT' ELSE '' END
+		FROM pg_attribute
+		WHERE attrelid = $1::regclass AND attname = $2
+	`, pq.QuoteIdentifier(tableName), columnName)
+	if err != nil || rows == nil {
+		// if rows == nil && err == nil, then it means we have queried a fake db.
+		return ""
+	}
+	defer rows.Close()
+
+	if err := db.ScanFirstValue(rows, &identity); err != nil {
+		return ""
+	}
+
+	return identity
+}
+
 func getSequenceNameForColumn(ctx context.Context, conn db.DB, tableName, columnName string) string {
 	var sequenceName string
 	query := fmt.Sprintf(`
 		SELECT pg_get_serial_sequence('%s', '%s')
 	`, pq.QuoteIdentifier(tableName), columnName)
 	rows, err := conn.QueryContext(ctx, query)
-	if err != nil {
+	if err != nil || rows == nil {
+		// if rows == nil && err == nil, then it means we have queried a fake db.
 		return ""
 	}
 	defer rows.Close()
@@ -809,6 +1110,10 @@ func NewDropConstraintAction(conn db.DB, table, constraint string) *dropConstrai
 
 func (a *dropConstraintAction) ID() string { return a.id }
 
+func (a *dropConstraintAction) Locks() []LockImpact {
+	return []LockImpact{accessExclusiveLock(a.table)}
+}
+
 func (a *dropConstraintAction) Execute(ctx context.Context) error {
 	_, err := a.conn.ExecContext(ctx, fmt.Sprintf("ALTER TABLE IF EXISTS %s DROP CONSTRAINT IF EXISTS %s",
 		pq.QuoteIdentifier(a.table),
@@ -834,6 +1139,15 @@ func NewSetNotNullAction(conn db.DB, table, column string) *setNotNullAction {
 
 func (a *setNotNullAction) ID() string { return a.id }
 
+// pgroll only sets `NOT NULL` on a column once a validated `CHECK (column IS
+// NOT NULL)` constraint exists, so Postgres skips the full table scan and the
+// lock is only held while the catalog is updated.
+func (a *setNotNullAction) Locks() []LockImpact {
+	lock := accessExclusiveLock(a.table)
+	lock.MetadataOnly = true
+	return []LockImpact{lock}
+}
+
 func (a *setNotNullAction) Execute(ctx context.Context) error {
 	_, err := a.conn.ExecContext(ctx, fmt.Sprintf("ALTER TABLE IF EXISTS %s ALTER COLUMN %s SET NOT NULL",
 		pq.QuoteIdentifier(a.table),
@@ -861,6 +1175,10 @@ func NewSetDefaultValueAction(conn db.DB, table, column, defaultValue string) *s
 
 func (a *setDefaultAction) ID() string { return a.id }
 
+func (a *setDefaultAction) Locks() []LockImpact {
+	return []LockImpact{accessExclusiveLock(a.table)}
+}
+
 func (a *setDefaultAction) Execute(ctx context.Context) error {
 	_, err := a.conn.ExecContext(ctx, fmt.Sprintf("ALTER TABLE IF EXISTS %s ALTER COLUMN %s SET DEFAULT %s",
 		pq.QuoteIdentifier(a.table),
@@ -887,6 +1205,10 @@ func NewDropDefaultValueAction(conn db.DB, table, column string) *dropDefaultAct
 
 func (a *dropDefaultAction) ID() string { return a.id }
 
+func (a *dropDefaultAction) Locks() []LockImpact {
+	return []LockImpact{accessExclusiveLock(a.table)}
+}
+
 func (a *dropDefaultAction) Execute(ctx context.Context) error {
 	_, err := a.conn.ExecContext(ctx, fmt.Sprintf("ALTER TABLE IF EXISTS %s ALTER COLUMN %s DROP DEFAULT",
 		pq.QuoteIdentifier(a.table),
@@ -910,6 +1232,10 @@ func NewRawSQLAction(conn db.DB, sql string) *rawSQLAction {
 
 func (a *rawSQLAction) ID() string { return a.id }
 
+func (a *rawSQLAction) Locks() []LockImpact {
+	return analyzeRawSQLLocks(a.sql)
+}
+
 func (a *rawSQLAction) Execute(ctx context.Context) error {
 	_, err := a.conn.ExecContext(ctx, a.sql)
 	return err
@@ -936,6 +1262,10 @@ func NewSetReplicaIdentityAction(conn db.DB, table string, identityType, index s
 
 func (a *setReplicaIdentityAction) ID() string { return a.id }
 
+func (a *setReplicaIdentityAction) Locks() []LockImpact {
+	return []LockImpact{accessExclusiveLock(a.table)}
+}
+
 func (a *setReplicaIdentityAction) Execute(ctx context.Context) error {
 	// build the correct form of the `SET REPLICA IDENTITY` statement based on the`identity type
 	identitySQL := a.identity
@@ -949,3 +1279,1040 @@ func (a *setReplicaIdentityAction) Execute(ctx context.Context) error {
 		identitySQL))
 	return err
 }
+
+type createEnumAction struct {
+	conn   db.DB
+	id     string
+	name   string
+	values []string
+}
+
+func NewCreateEnumAction(conn db.DB, name string, values []string) *createEnumAction {
+	return &createEnumAction{
+		conn:   conn,
+		id:     fmt.Sprintf("create_enum_%s", name),
+		name:   name,
+		values: values,
+	}
+}
+
+func (a *createEnumAction) ID() string { return a.id }
+
+// Creating a type does not lock any relation.
+func (a *createEnumAction) Locks() []LockImpact { return nil }
+
+func (a *createEnumAction) Execute(ctx context.Context) error {
+	values := make([]string, len(a.values))
+	for i, v := range a.values {
+		values[i] = pq.QuoteLiteral(v)
+	}
+	_, err := a.conn.ExecContext(ctx, fmt.Sprintf("CREATE TYPE %s AS ENUM (%s)",
+		pq.QuoteIdentifier(a.name),
+		strings.Join(values, ", ")))
+	return err
+}
+
+type dropEnumAction struct {
+	conn db.DB
+	id   string
+	name string
+}
+
+func NewDropEnumAction(conn db.DB, name string) *dropEnumAction {
+	return &dropEnumAction{
+		conn: conn,
+		id:   fmt.Sprintf("drop_enum_%s", name),
+		name: name,
+	}
+}
+
+func (a *dropEnumAction) ID() string { return a.id }
+
+// Dropping a type does not lock any relation; it fails if a column still uses
+// the type.
+func (a *dropEnumAction) Locks() []LockImpact { return nil }
+
+func (a *dropEnumAction) Execute(ctx context.Context) error {
+	_, err := a.conn.ExecContext(ctx, fmt.Sprintf("DROP TYPE IF EXISTS %s",
+		pq.QuoteIdentifier(a.name)))
+	return err
+}
+
+type renameEnumAction struct {
+	conn db.DB
+	id   string
+	from string
+	to   string
+}
+
+func NewRenameEnumAction(conn db.DB, from, to string) *renameEnumAction {
+	return &renameEnumAction{
+		conn: conn,
+		id:   fmt.Sprintf("rename_enum_%s_to_%s", from, to),
+		from: from,
+		to:   to,
+	}
+}
+
+func (a *renameEnumAction) ID() string { return a.id }
+
+func (a *renameEnumAction) Locks() []LockImpact { return nil }
+
+func (a *renameEnumAction) Execute(ctx context.Context) error {
+	_, err := a.conn.ExecContext(ctx, fmt.Sprintf("ALTER TYPE %s RENAME TO %s",
+		pq.QuoteIdentifier(a.from),
+		pq.QuoteIdentifier(a.to)))
+	return err
+}
+
+type addEnumValueAction struct {
+	conn   db.DB
+	id     string
+	enum   string
+	value  string
+	before string
+	after  string
+}
+
+func NewAddEnumValueAction(conn db.DB, enum, value, before, after string) *addEnumValueAction {
+	return &addEnumValueAction{
+		conn:   conn,
+		id:     fmt.Sprintf("add_enum_value_%s_%s", enum, value),
+		enum:   enum,
+		value:  value,
+		before: before,
+		after:  after,
+	}
+}
+
+func (a *addEnumValueAction) ID() string { return a.id }
+
+// Adding a value to an enum does not lock or rewrite the tables that use it.
+func (a *addEnumValueAction) Locks() []LockImpact { return nil }
+
+func (a *addEnumValueAction) Execute(ctx context.Context) error {
+	sql := fmt.Sprintf("ALTER TYPE %s ADD VALUE IF NOT EXISTS %s",
+		pq.QuoteIdentifier(a.enum),
+		pq.QuoteLiteral(a.value))
+	switch {
+	case a.before != "":
+		sql += " BEFORE " + pq.QuoteLiteral(a.before)
+	case a.after != "":
+		sql += " AFTER " + pq.QuoteLiteral(a.after)
+	}
+	_, err := a.conn.ExecContext(ctx, sql)
+	return err
+}
+
+type renameEnumValueAction struct {
+	conn db.DB
+	id   string
+	enum string
+	from string
+	to   string
+}
+
+func NewRenameEnumValueAction(conn db.DB, enum, from, to string) *renameEnumValueAction {
+	return &renameEnumValueAction{
+		conn: conn,
+		id:   fmt.Sprintf("rename_enum_value_%s_%s_to_%s", enum, from, to),
+		enum: enum,
+		from: from,
+		to:   to,
+	}
+}
+
+func (a *renameEnumValueAction) ID() string { return a.id }
+
+// Renaming an enum value only updates the catalog; stored values refer to the
+// label by OID so no table is rewritten.
+func (a *renameEnumValueAction) Locks() []LockImpact { return nil }
+
+func (a *renameEnumValueAction) Execute(ctx context.Context) error {
+	_, err := a.conn.ExecContext(ctx, fmt.Sprintf("ALTER TYPE %s RENAME VALUE %s TO %s",
+		pq.QuoteIdentifier(a.enum),
+		pq.QuoteLiteral(a.from),
+		pq.QuoteLiteral(a.to)))
+	return err
+}
+
+// createViewAction is a DBAction that creates a view, replacing any existing
+// view of the same name.
+type createViewAction struct {
+	conn       db.DB
+	id         string
+	name       string
+	definition string
+}
+
+func NewCreateViewAction(conn db.DB, name, definition string) *createViewAction {
+	return &createViewAction{
+		conn:       conn,
+		id:         fmt.Sprintf("create_view_%s", name),
+		name:       name,
+		definition: definition,
+	}
+}
+
+func (a *createViewAction) ID() string { return a.id }
+
+func (a *createViewAction) Locks() []LockImpact {
+	return []LockImpact{accessExclusiveLock(a.name)}
+}
+
+// Execute drops and recreates the view rather than using CREATE OR REPLACE
+// VIEW, which can not rename or remove columns of the view. Both statements
+// are sent together so that they run in a single implicit transaction.
+func (a *createViewAction) Execute(ctx context.Context) error {
+	_, err := a.conn.ExecContext(ctx, fmt.Sprintf("DROP VIEW IF EXISTS %s; CREATE VIEW %s AS %s",
+		pq.QuoteIdentifier(a.name),
+		pq.QuoteIdentifier(a.name),
+		a.definition))
+	return err
+}
+
+type dropViewAction struct {
+	conn db.DB
+	id   string
+	name string
+}
+
+func NewDropViewAction(conn db.DB, name string) *dropViewAction {
+	return &dropViewAction{
+		conn: conn,
+		id:   fmt.Sprintf("drop_view_%s", name),
+		name: name,
+	}
+}
+
+func (a *dropViewAction) ID() string { return a.id }
+
+func (a *dropViewAction) Locks() []LockImpact {
+	return []LockImpact{accessExclusiveLock(a.name)}
+}
+
+func (a *dropViewAction) Execute(ctx context.Context) error {
+	_, err := a.conn.ExecContext(ctx, fmt.Sprintf("DROP VIEW IF EXISTS %s",
+		pq.QuoteIdentifier(a.name)))
+	return err
+}
+
+// createPartitionAction is a DBAction that creates a partition of a
+// partitioned table.
+type createPartitionAction struct {
+	conn  db.DB
+	id    string
+	table string
+	name  string
+	bound string
+}
+
+func NewCreatePartitionAction(conn db.DB, table, name, bound string) *createPartitionAction {
+	return &createPartitionAction{
+		conn:  conn,
+		id:    fmt.Sprintf("create_partition_%s_%s", table, name),
+		table: table,
+		name:  name,
+		bound: bound,
+	}
+}
+
+func (a *createPartitionAction) ID() string { return a.id }
+
+func (a *createPartitionAction) Locks() []LockImpact {
+	return []LockImpact{accessExclusiveLock(a.table)}
+}
+
+func (a *createPartitionAction) Execute(ctx context.Context) error {
+	_, err := a.conn.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s PARTITION OF %s %s",
+		pq.QuoteIdentifier(a.name),
+		pq.QuoteIdentifier(a.table),
+		a.bound))
+	return err
+}
+
+// createSyncTriggerAction is a DBAction that creates a trigger that copies
+// every row written to one table to another table with the same columns. Rows
+// are matched on the primary key, so that updates and deletes are copied too.
+//
+// While a row is being copied, the OID of the table it is copied to is stored
+// in the `pgroll.sync_target` setting, so that the trigger copying rows the
+// other way skips it. Row triggers on a partitioned table run on the partition
+// the row is written to, so the setting is compared with the root of the
+// partition tree. Rows written by other triggers, including foreign key
+// actions, are still copied.
+type createSyncTriggerAction struct {
+	conn       db.DB
+	id         string
+	name       string
+	from       string
+	to         string
+	columns    []string
+	primaryKey []string
+}
+
+// NewCreateSyncTriggerAction creates an action that copies rows written to
+// `from` to `to`. The trigger function runs with the search path of the
+// client, so `to` must be qualified with the name of its schema.
+func NewCreateSyncTriggerAction(conn db.DB, name, from, to string, columns, primaryKey []string) *createSyncTriggerAction {
+	return &createSyncTriggerAction{
+		conn:       conn,
+		id:         fmt.Sprintf("create_sync_trigger_%s", name),
+		name:       name,
+		from:       from,
+		to:         to,
+		columns:    columns,
+		primaryKey: primaryKey,
+	}
+}
+
+func (a *createSyncTriggerAction) ID() string { return a.id }
+
+func (a *createSyncTriggerAction) Locks() []LockImpact {
+	return []LockImpact{{Level: LockLevelShareRowExclusive, Relation: a.from}}
+}
+
+func (a *createSyncTriggerAction) Execute(ctx context.Context) error {
+	const cSyncTriggerSQL = `CREATE OR REPLACE FUNCTION %[1]s()
+    RETURNS TRIGGER
+    LANGUAGE PLPGSQL
+    AS $$
+    DECLARE
+      syncing text := current_setting('pgroll.sync_target', true);
+    BEGIN
+      -- Rows written by the trigger copying rows the other way are already
+      -- in sync
+      IF syncing = coalesce(pg_partition_root(TG_RELID)::oid, TG_RELID)::text THEN
+        RETURN NULL;
+      END IF;
+
+      PERFORM set_config('pgroll.sync_target', %[8]s::regclass::oid::text, true);
+
+      IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        DELETE FROM %[2]s WHERE (%[3]s) = (%[4]s);
+      END IF;
+
+      IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        INSERT INTO %[2]s (%[5]s) VALUES (%[6]s);
+      END IF;
+
+      PERFORM set_config('pgroll.sync_target', coalesce(syncing, ''), true);
+
+      RETURN NULL;
+    END; $$;
+
+CREATE OR REPLACE TRIGGER %[1]s
+    AFTER INSERT OR UPDATE OR DELETE
+    ON %[7]s
+    FOR EACH ROW
+    EXECUTE PROCEDURE %[1]s();`
+
+	_, err := a.conn.ExecContext(ctx, fmt.Sprintf(cSyncTriggerSQL,
+		pq.QuoteIdentifier(a.name),
+		a.to,
+		strings.Join(quoteColumnNames(a.primaryKey), ", "),
+		strings.Join(prefixColumnNames("OLD", a.primaryKey), ", "),
+		strings.Join(quoteColumnNames(a.columns), ", "),
+		strings.Join(prefixColumnNames("NEW", a.columns), ", "),
+		pq.QuoteIdentifier(a.from),
+		pq.QuoteLiteral(a.to)))
+	return err
+}
+
+// prefixColumnNames returns the quoted names of `columns` prefixed with
+// `record`, eg NEW."id"
+func prefixColumnNames(record string, columns []string) []string {
+	prefixed := make([]string, len(columns))
+	for i, column := range columns {
+		prefixed[i] = record + "." + pq.QuoteIdentifier(column)
+	}
+	return prefixed
+}
+
+// renameIndexAction is a DBAction that renames an index. Renaming the index
+// backing a constraint also renames the constraint.
+type renameIndexAction struct {
+	conn db.DB
+	id   string
+	from string
+	to   string
+}
+
+func NewRenameIndexAction(conn db.DB, from, to string) *renameIndexAction {
+	return &renameIndexAction{
+		conn: conn,
+		id:   fmt.Sprintf("rename_index_%s_to_%s", from, to),
+		from: from,
+		to:   to,
+	}
+}
+
+func (a *renameIndexAction) ID() string { return a.id }
+
+func (a *renameIndexAction) Locks() []LockImpact {
+	return []LockImpact{{Level: LockLevelShareUpdateExclusive, Relation: a.from}}
+}
+
+func (a *renameIndexAction) Execute(ctx context.Context) error {
+	_, err := a.conn.ExecContext(ctx, fmt.Sprintf("ALTER INDEX IF EXISTS %s RENAME TO %s",
+		pq.QuoteIdentifier(a.from),
+		pq.QuoteIdentifier(a.to)))
+	return err
+}
+
+// moveSequencesAction is a DBAction that makes the sequences owned by columns
+// of one table owned by the columns of the same name in another table, so that
+// they are not dropped with the first table.
+type moveSequencesAction struct {
+	conn    db.DB
+	id      string
+	from    string
+	to      string
+	columns []string
+}
+
+func NewMoveSequencesAction(conn db.DB, from, to string, columns ...string) *moveSequencesAction {
+	return &moveSequencesAction{
+		conn:    conn,
+		id:      fmt.Sprintf("move_sequences_%s_to_%s", from, to),
+		from:    from,
+		to:      to,
+		columns: columns,
+	}
+}
+
+func (a *moveSequencesAction) ID() string { return a.id }
+
+func (a *moveSequencesAction) Locks() []LockImpact {
+	return []LockImpact{
+		{Level: LockLevelShareRowExclusive, Relation: a.from},
+		{Level: LockLevelShareRowExclusive, Relation: a.to},
+	}
+}
+
+func (a *moveSequencesAction) Execute(ctx context.Context) error {
+	for _, column := range a.columns {
+		sequence := getSequenceNameForColumn(ctx, a.conn, a.from, column)
+		if sequence == "" {
+			continue
+		}
+		_, err := a.conn.ExecContext(ctx, fmt.Sprintf("ALTER SEQUENCE IF EXISTS %s OWNED BY %s.%s",
+			sequence,
+			pq.QuoteIdentifier(a.to),
+			pq.QuoteIdentifier(column)))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// attachPartitionAction is a DBAction that attaches a table as a partition of
+// a partitioned table.
+type attachPartitionAction struct {
+	conn      db.DB
+	id        string
+	table     string
+	name      string
+	bound     string
+	validated bool
+}
+
+// NewAttachPartitionAction creates an action that attaches table `name` to
+// partitioned table `table`. `validated` is true when a valid check
+// constraint on `name` implies the partition bound, so that Postgres does not
+// scan the table to check the bound.
+func NewAttachPartitionAction(conn db.DB, table, name, bound string, validated bool) *attachPartitionAction {
+	return &attachPartitionAction{
+		conn:      conn,
+		id:        fmt.Sprintf("attach_partition_%s_%s", table, name),
+		table:     table,
+		name:      name,
+		bound:     bound,
+		validated: validated,
+	}
+}
+
+func (a *attachPartitionAction) ID() string { return a.id }
+
+func (a *attachPartitionAction) Locks() []LockImpact {
+	lock := accessExclusiveLock(a.name)
+	lock.ScansTable = !a.validated
+	return []LockImpact{
+		{Level: LockLevelShareUpdateExclusive, Relation: a.table},
+		lock,
+	}
+}
+
+func (a *attachPartitionAction) Execute(ctx context.Context) error {
+	_, err := a.conn.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ATTACH PARTITION %s %s",
+		pq.QuoteIdentifier(a.table),
+		pq.QuoteIdentifier(a.name),
+		a.bound))
+	return err
+}
+
+// detachPartitionAction is a DBAction that detaches a partition from a
+// partitioned table.
+type detachPartitionAction struct {
+	conn         db.DB
+	id           string
+	table        string
+	name         string
+	concurrently bool
+}
+
+// NewDetachPartitionAction creates an action that detaches partition `name`
+// from partitioned table `table`. When `concurrently` is true and the server
+// supports it (Postgres 14 and later), the partition is detached without
+// blocking queries on the partitioned table.
+func NewDetachPartitionAction(conn db.DB, table, name string, concurrently bool) *detachPartitionAction {
+	return &detachPartitionAction{
+		conn:         conn,
+		id:           fmt.Sprintf("detach_partition_%s_%s", table, name),
+		table:        table,
+		name:         name,
+		concurrently: concurrently,
+	}
+}
+
+func (a *detachPartitionAction) ID() string { return a.id }
+
+func (a *detachPartitionAction) Locks() []LockImpact {
+	if a.concurrently {
+		return []LockImpact{
+			{Level: LockLevelShareUpdateExclusive, Relation: a.table},
+			{Level: LockLevelShareUpdateExclusive, Relation: a.name},
+		}
+	}
+	return []LockImpact{accessExclusiveLock(a.table), accessExclusiveLock(a.name)}
+}
+
+func (a *detachPartitionAction) Execute(ctx context.Context) error {
+	sql := fmt.Sprintf("ALTER TABLE %s DETACH PARTITION %s",
+		pq.QuoteIdentifier(a.table),
+		pq.QuoteIdentifier(a.name))
+	if a.concurrently && serverVersionNum(ctx, a.conn) >= 140000 {
+		sql += " CONCURRENTLY"
+	}
+
+	_, err := a.conn.ExecContext(ctx, sql)
+	return err
+}
+
+// serverVersionNum returns the version of the Postgres server as an integer,
+// eg 170002 for 17.2, or 0 if the version can not be determined.
+func serverVersionNum(ctx context.Context, conn db.DB) int {
+	rows, err := conn.QueryContext(ctx, "SELECT current_setting('server_version_num')::integer")
+	if err != nil || rows == nil {
+		// if rows == nil && err == nil, then it means we have queried a fake db.
+		return 0
+	}
+	defer rows.Close()
+
+	var version int
+	if err := db.ScanFirstValue(rows, &version); err != nil {
+		return 0
+	}
+	return version
+}
+
+// enableRLSAction is a DBAction that enables row level security on a table.
+type enableRLSAction struct {
+	conn  db.DB
+	id    string
+	table string
+	force bool
+}
+
+// NewEnableRLSAction creates an action that enables row level security on
+// `table`. When `force` is true, row level security also applies to the owner
+// of the table.
+func NewEnableRLSAction(conn db.DB, table string, force bool) *enableRLSAction {
+	return &enableRLSAction{
+		conn:  conn,
+		id:    fmt.Sprintf("enable_rls_%s", table),
+		table: table,
+		force: force,
+	}
+}
+
+func (a *enableRLSAction) ID() string { return a.id }
+
+func (a *enableRLSAction) Locks() []LockImpact {
+	return []LockImpact{accessExclusiveLock(a.table)}
+}
+
+func (a *enableRLSAction) Execute(ctx context.Context) error {
+	sql := fmt.Sprintf("ALTER TABLE %s ENABLE ROW LEVEL SECURITY", pq.QuoteIdentifier(a.table))
+	if a.force {
+		sql += ", FORCE ROW LEVEL SECURITY"
+	}
+	_, err := a.conn.ExecContext(ctx, sql)
+	return err
+}
+
+// createPolicyAction is a DBAction that creates a row level security policy.
+type createPolicyAction struct {
+	conn      db.DB
+	id        string
+	table     string
+	name      string
+	as        string
+	command   string
+	roles     []string
+	using     string
+	withCheck string
+}
+
+func NewCreatePolicyAction(conn db.DB, table, name, as, command string, roles []string, using, withCheck string) *createPolicyAction {
+	return &createPolicyAction{
+		conn:      conn,
+		id:        fmt.Sprintf("create_policy_%s_%s", table, name),
+		table:     table,
+		name:      name,
+		as:        as,
+		command:   command,
+		roles:     roles,
+		using:     using,
+		withCheck: withCheck,
+	}
+}
+
+func (a *createPolicyAction) ID() string { return a.id }
+
+func (a *createPolicyAction) Locks() []LockImpact {
+	return []LockImpact{accessExclusiveLock(a.table)}
+}
+
+func (a *createPolicyAction) Execute(ctx context.Context) error {
+	sql := fmt.Sprintf("CREATE POLICY %s ON %s",
+		pq.QuoteIdentifier(a.name),
+		pq.QuoteIdentifier(a.table))
+	if a.as != "" {
+		sql += " AS " + a.as
+	}
+	if a.command != "" {
+		sql += " FOR " + a.command
+	}
+	if len(a.roles) > 0 {
+		sql += " TO " + formatPolicyRoles(a.roles)
+	}
+	if a.using != "" {
+		sql += fmt.Sprintf(" USING (%s)", a.using)
+	}
+	if a.withCheck != "" {
+		sql += fmt.Sprintf(" WITH CHECK (%s)", a.withCheck)
+	}
+
+	_, err := a.conn.ExecContext(ctx, sql)
+	return err
+}
+
+// alterPolicyAction is a DBAction that changes the roles or expressions of a
+// row level security policy.
+type alterPolicyAction struct {
+	conn      db.DB
+	id        string
+	table     string
+	name      string
+	roles     []string
+	using     *string
+	withCheck *string
+}
+
+// NewAlterPolicyAction creates an action that alters policy `name` on `table`.
+// Only the roles and expressions that are set are changed.
+func NewAlterPolicyAction(conn db.DB, table, name string, roles []string, using, withCheck *string) *alterPolicyAction {
+	return &alterPolicyAction{
+		conn:      conn,
+		id:        fmt.Sprintf("alter_policy_%s_%s", table, name),
+		table:     table,
+		name:      name,
+		roles:     roles,
+		using:     using,
+		withCheck: withCheck,
+	}
+}
+
+func (a *alterPolicyAction) ID() string { return a.id }
+
+func (a *alterPolicyAction) Locks() []LockImpact {
+	return []LockImpact{accessExclusiveLock(a.table)}
+}
+
+func (a *alterPolicyAction) Execute(ctx context.Context) error {
+	sql := fmt.Sprintf("ALTER POLICY %s ON %s",
+		pq.QuoteIdentifier(a.name),
+		pq.QuoteIdentifier(a.table))
+	if len(a.roles) > 0 {
+		sql += " TO " + formatPolicyRoles(a.roles)
+	}
+	if a.using != nil {
+		sql += fmt.Sprintf(" USING (%s)", *a.using)
+	}
+	if a.withCheck != nil {
+		sql += fmt.Sprintf(" WITH CHECK (%s)", *a.withCheck)
+	}
+
+	_, err := a.conn.ExecContext(ctx, sql)
+	return err
+}
+
+// dropPolicyAction is a DBAction that drops a row level security policy.
+type dropPolicyAction struct {
+	conn  db.DB
+	id    string
+	table string
+	name  string
+}
+
+func NewDropPolicyAction(conn db.DB, table, name string) *dropPolicyAction {
+	return &dropPolicyAction{
+		conn:  conn,
+		id:    fmt.Sprintf("drop_policy_%s_%s", table, name),
+		table: table,
+		name:  name,
+	}
+}
+
+func (a *dropPolicyAction) ID() string { return a.id }
+
+func (a *dropPolicyAction) Locks() []LockImpact {
+	return []LockImpact{accessExclusiveLock(a.table)}
+}
+
+func (a *dropPolicyAction) Execute(ctx context.Context) error {
+	_, err := a.conn.ExecContext(ctx, fmt.Sprintf("DROP POLICY IF EXISTS %s ON %s",
+		pq.QuoteIdentifier(a.name),
+		pq.QuoteIdentifier(a.table)))
+	return err
+}
+
+// createFunctionAction is a DBAction that creates a function, or replaces the
+// definition of an existing function.
+type createFunctionAction struct {
+	conn       db.DB
+	id         string
+	name       string
+	arguments  string
+	returns    string
+	language   string
+	volatility string
+	definition string
+	replace    bool
+}
+
+func NewCreateFunctionAction(conn db.DB, name, arguments, returns, language, volatility, definition string) *createFunctionAction {
+	return &createFunctionAction{
+		conn:       conn,
+		id:         fmt.Sprintf("create_function_%s", name),
+		name:       name,
+		arguments:  arguments,
+		returns:    returns,
+		language:   language,
+		volatility: volatility,
+		definition: definition,
+	}
+}
+
+// OrReplace makes the action replace the definition of an existing function
+// with the same name and arguments.
+func (a *createFunctionAction) OrReplace() *createFunctionAction {
+	a.replace = true
+	a.id = fmt.Sprintf("replace_function_%s", a.name)
+	return a
+}
+
+func (a *createFunctionAction) ID() string { return a.id }
+
+// Creating a function does not lock any relation.
+func (a *createFunctionAction) Locks() []LockImpact { return nil }
+
+func (a *createFunctionAction) Execute(ctx context.Context) error {
+	_, err := a.conn.ExecContext(ctx, createFunctionSQL(pq.QuoteIdentifier(a.name),
+		a.arguments, a.returns, a.language, a.volatility, a.definition, a.replace))
+	return err
+}
+
+// createFunctionSQL returns a CREATE FUNCTION statement for the function
+// `name`, which must already be quoted.
+func createFunctionSQL(name, arguments, returns, language, volatility, definition string, replace bool) string {
+	var sb strings.Builder
+	sb.WriteString("CREATE ")
+	if replace {
+		sb.WriteString("OR REPLACE ")
+	}
+	fmt.Fprintf(&sb, "FUNCTION %s(%s) RETURNS %s LANGUAGE %s", name, arguments, returns, pq.QuoteIdentifier(language))
+	if volatility != "" {
+		fmt.Fprintf(&sb, " %s", volatility)
+	}
+	fmt.Fprintf(&sb, " AS %s", pq.QuoteLiteral(definition))
+	return sb.String()
+}
+
+type createTriggerAction struct {
+	conn     db.DB
+	id       string
+	table    string
+	name     string
+	function string
+	timing   string
+	events   []string
+	forEach  string
+	when     string
+}
+
+func NewCreateTriggerAction(conn db.DB, table, name, function, timing string, events []string, forEach, when string) *createTriggerAction {
+	return &createTriggerAction{
+		conn:     conn,
+		id:       fmt.Sprintf("create_trigger_%s_%s", table, name),
+		table:    table,
+		name:     name,
+		function: function,
+		timing:   timing,
+		events:   events,
+		forEach:  forEach,
+		when:     when,
+	}
+}
+
+func (a *createTriggerAction) ID() string { return a.id }
+
+func (a *createTriggerAction) Locks() []LockImpact {
+	return []LockImpact{{Level: LockLevelShareRowExclusive, Relation: a.table}}
+}
+
+func (a *createTriggerAction) Execute(ctx context.Context) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "CREATE TRIGGER %s %s %s ON %s FOR EACH %s",
+		pq.QuoteIdentifier(a.name),
+		a.timing,
+		strings.Join(a.events, " OR "),
+		pq.QuoteIdentifier(a.table),
+		a.forEach)
+	if a.when != "" {
+		fmt.Fprintf(&sb, " WHEN (%s)", a.when)
+	}
+	fmt.Fprintf(&sb, " EXECUTE FUNCTION %s()", pq.QuoteIdentifier(a.function))
+
+	_, err := a.conn.ExecContext(ctx, sb.String())
+	return err
+}
+
+type dropTriggerAction struct {
+	conn  db.DB
+	id    string
+	table string
+	name  string
+}
+
+func NewDropTriggerAction(conn db.DB, table, name string) *dropTriggerAction {
+	return &dropTriggerAction{
+		conn:  conn,
+		id:    fmt.Sprintf("drop_trigger_%s_%s", table, name),
+		table: table,
+		name:  name,
+	}
+}
+
+func (a *dropTriggerAction) ID() string { return a.id }
+
+func (a *dropTriggerAction) Locks() []LockImpact {
+	return []LockImpact{accessExclusiveLock(a.table)}
+}
+
+func (a *dropTriggerAction) Execute(ctx context.Context) error {
+	_, err := a.conn.ExecContext(ctx, fmt.Sprintf("DROP TRIGGER IF EXISTS %s ON %s",
+		pq.QuoteIdentifier(a.name),
+		pq.QuoteIdentifier(a.table)))
+	return err
+}
+
+type createSequenceAction struct {
+	conn     db.DB
+	id       string
+	name     string
+	dataType string
+	options  sequenceOptions
+	cycle    bool
+	table    string
+	column   string
+}
+
+func NewCreateSequenceAction(conn db.DB, name, dataType string, options sequenceOptions) *createSequenceAction {
+	return &createSequenceAction{
+		conn:     conn,
+		id:       fmt.Sprintf("create_sequence_%s", name),
+		name:     name,
+		dataType: dataType,
+		options:  options,
+	}
+}
+
+// OwnedBy makes the sequence owned by `column` of `table`, so that it is
+// dropped with the column.
+func (a *createSequenceAction) OwnedBy(table, column string) *createSequenceAction {
+	a.table = table
+	a.column = column
+	return a
+}
+
+func (a *createSequenceAction) ID() string { return a.id }
+
+func (a *createSequenceAction) Locks() []LockImpact {
+	if a.table == "" {
+		return nil
+	}
+	return []LockImpact{{Level: LockLevelAccessShare, Relation: a.table}}
+}
+
+func (a *createSequenceAction) Execute(ctx context.Context) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "CREATE SEQUENCE %s AS %s", pq.QuoteIdentifier(a.name), a.dataType)
+	if opts := a.options.sql(); opts != "" {
+		fmt.Fprintf(&sb, " %s", opts)
+	}
+	if a.table != "" {
+		fmt.Fprintf(&sb, " OWNED BY %s", qualifiedSequenceOwner(a.table, a.column))
+	}
+
+	_, err := a.conn.ExecContext(ctx, sb.String())
+	return err
+}
+
+type alterSequenceAction struct {
+	conn    db.DB
+	id      string
+	name    string
+	options sequenceOptions
+}
+
+func NewAlterSequenceAction(conn db.DB, name string, options sequenceOptions) *alterSequenceAction {
+	return &alterSequenceAction{
+		conn:    conn,
+		id:      fmt.Sprintf("alter_sequence_%s", name),
+		name:    name,
+		options: options,
+	}
+}
+
+func (a *alterSequenceAction) ID() string { return a.id }
+
+// Altering a sequence does not lock any table.
+func (a *alterSequenceAction) Locks() []LockImpact { return nil }
+
+func (a *alterSequenceAction) Execute(ctx context.Context) error {
+	_, err := a.conn.ExecContext(ctx, fmt.Sprintf("ALTER SEQUENCE IF EXISTS %s %s",
+		pq.QuoteIdentifier(a.name),
+		a.options.sql()))
+	return err
+}
+
+type dropSequenceAction struct {
+	conn db.DB
+	id   string
+	name string
+}
+
+func NewDropSequenceAction(conn db.DB, name string) *dropSequenceAction {
+	return &dropSequenceAction{
+		conn: conn,
+		id:   fmt.Sprintf("drop_sequence_%s", name),
+		name: name,
+	}
+}
+
+func (a *dropSequenceAction) ID() string { return a.id }
+
+// Dropping a sequence does not lock any table.
+func (a *dropSequenceAction) Locks() []LockImpact { return nil }
+
+func (a *dropSequenceAction) Execute(ctx context.Context) error {
+	_, err := a.conn.ExecContext(ctx, fmt.Sprintf("DROP SEQUENCE IF EXISTS %s", pq.QuoteIdentifier(a.name)))
+	return err
+}
+
+// convertToIdentityAction is a DBAction that converts a column taking its
+// default from a sequence into an identity column. The identity sequence
+// continues from the next value of the old sequence, which is then dropped.
+type convertToIdentityAction struct {
+	conn      db.DB
+	id        string
+	table     string
+	column    string
+	sequence  string
+	generated string
+}
+
+// NewConvertToIdentityAction creates an action that converts `column` of
+// `table` into an identity column. `sequence` is the sequence the column takes
+// its default from, as returned by defaultSequence.
+func NewConvertToIdentityAction(conn db.DB, table, column, sequence, generated string) *convertToIdentityAction {
+	return &convertToIdentityAction{
+		conn:      conn,
+		id:        fmt.Sprintf("convert_to_identity_%s_%s", table, column),
+		table:     table,
+		column:    column,
+		sequence:  sequence,
+		generated: generated,
+	}
+}
+
+func (a *convertToIdentityAction) ID() string { return a.id }
+
+func (a *convertToIdentityAction) Locks() []LockImpact {
+	return []LockImpact{accessExclusiveLock(a.table)}
+}
+
+func (a *convertToIdentityAction) Execute(ctx context.Context) error {
+	table := pq.QuoteIdentifier(a.table)
+	column := pq.QuoteIdentifier(a.column)
+
+	// Lock the table so that no values are taken from the old sequence after
+	// the identity sequence is set to continue from it. The identity sequence
+	// is found by name, as it is only created by the preceding statement.
+	stmts := []string{
+		fmt.Sprintf("LOCK TABLE %s IN ACCESS EXCLUSIVE MODE", table),
+		fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP DEFAULT", table, column),
+		fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s ADD GENERATED %s AS IDENTITY", table, column, a.generated),
+		fmt.Sprintf("SELECT setval(pg_get_serial_sequence(%s, %s), nextval(%s), false)",
+			pq.QuoteLiteral(table), pq.QuoteLiteral(a.column), regclassLiteral(a.sequence)),
+		fmt.Sprintf("DROP SEQUENCE IF EXISTS %s", strings.ReplaceAll(a.sequence, "''", "'")),
+	}
+
+	return a.conn.WithRetryableTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		// There is no transaction when the statements are only being recorded
+		var conn interface {
+			ExecContext(context.Context, string, ...any) (sql.Result, error)
+		} = a.conn
+		if tx != nil {
+			conn = tx
+		}
+
+		for _, stmt := range stmts {
+			if _, err := conn.ExecContext(ctx, stmt); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// addIdentity makes `column` of `table` an identity column starting at
+// `start`, dropping the default of the column.
+func addIdentity(ctx context.Context, tx *sql.Tx, table, column, generated string, start int64) error {
+	_, err := tx.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP DEFAULT",
+		pq.QuoteIdentifier(table),
+		pq.QuoteIdentifier(column)))
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s ADD GENERATED %s AS IDENTITY (START WITH %d)",
+		pq.QuoteIdentifier(table),
+		pq.QuoteIdentifier(column),
+		generated,
+		start))
+	return err
+}
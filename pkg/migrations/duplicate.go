@@ -6,6 +6,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"slices"
 	"strings"
 
@@ -67,6 +68,13 @@ func NewColumnDuplicator(conn db.DB, table *schema.Table, columns ...*schema.Col
 
 func (d *duplicator) ID() string { return d.id }
 
+// Duplicated check and foreign key constraints are added `NOT VALID` and
+// duplicated indexes are created concurrently, so duplicating a column does not
+// scan the table while holding the lock.
+func (d *duplicator) Locks() []LockImpact {
+	return []LockImpact{accessExclusiveLock(d.stmtBuilder.table.Name)}
+}
+
 // WithType sets the type of the new column.
 func (d *duplicator) WithType(columnName, t string) *duplicator {
 	d.columns[columnName].withType = t
@@ -322,6 +330,17 @@ func (d *duplicatorStmtBuilder) duplicateColumn(
 }
 
 func (d *duplicatorStmtBuilder) duplicateDefault(column *schema.Column, asName string) string {
+	// The duplicate of an identity column takes its values from the identity
+	// sequence of the original column until the migration is completed, when
+	// the duplicate becomes an identity column itself
+	if column.Default == nil && column.Identity != "" {
+		return fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET DEFAULT nextval(pg_get_serial_sequence(%s, %s))",
+			pq.QuoteIdentifier(d.table.Name),
+			pq.QuoteIdentifier(asName),
+			pq.QuoteLiteral(pq.QuoteIdentifier(d.table.Name)),
+			pq.QuoteLiteral(column.Name))
+	}
+
 	if column.Default == nil {
 		return ""
 	}
@@ -350,6 +369,144 @@ func (d *duplicatorStmtBuilder) duplicateComment(column *schema.Column, asName s
 	)
 }
 
+// tableDuplicator duplicates a table as a new table, including all columns,
+// defaults, comments, constraints and indexes.
+//
+// Constraints and indexes whose names must be unique within the schema are
+// given duplicated names, see DuplicationName.
+type tableDuplicator struct {
+	id           string
+	conn         db.DB
+	table        *schema.Table
+	asName       string
+	partitionKey *schema.PartitionKey
+}
+
+// NewTableDuplicator creates a new duplicator for a table.
+func NewTableDuplicator(conn db.DB, table *schema.Table, asName string) *tableDuplicator {
+	return &tableDuplicator{
+		id:     fmt.Sprintf("duplicate_table_%s_as_%s", table.Name, asName),
+		conn:   conn,
+		table:  table,
+		asName: asName,
+	}
+}
+
+func (d *tableDuplicator) ID() string { return d.id }
+
+// The new table is empty, so adding constraints and indexes to it is fast.
+// Adding foreign keys locks the referenced tables.
+func (d *tableDuplicator) Locks() []LockImpact {
+	locks := []LockImpact{accessExclusiveLock(d.asName)}
+	for _, name := range slices.Sorted(maps.Keys(d.table.ForeignKeys)) {
+		locks = append(locks, LockImpact{
+			Level:    LockLevelShareRowExclusive,
+			Relation: d.table.ForeignKeys[name].ReferencedTable,
+		})
+	}
+	return locks
+}
+
+// WithPartitionKey creates the new table as a partitioned table with the
+// given partition key.
+func (d *tableDuplicator) WithPartitionKey(key *schema.PartitionKey) *tableDuplicator {
+	d.partitionKey = key
+	return d
+}
+
+// Execute creates the new table.
+func (d *tableDuplicator) Execute(ctx context.Context) error {
+	for _, sql := range d.statements() {
+		if _, err := d.conn.ExecContext(ctx, sql); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *tableDuplicator) statements() []string {
+	table := pq.QuoteIdentifier(d.asName)
+
+	// Columns, defaults, check and not null constraints and comments on columns
+	// are copied by `LIKE`
+	create := fmt.Sprintf("CREATE TABLE %s (LIKE %s INCLUDING DEFAULTS INCLUDING CONSTRAINTS INCLUDING GENERATED INCLUDING COMMENTS)",
+		table,
+		pq.QuoteIdentifier(d.table.Name))
+	if d.partitionKey != nil {
+		create += fmt.Sprintf(" PARTITION BY %s (%s)",
+			strings.ToUpper(d.partitionKey.Strategy),
+			strings.Join(quoteColumnNames(d.partitionKey.Columns), ", "))
+	}
+	stmts := []string{create}
+
+	if d.table.Comment != "" {
+		stmts = append(stmts, fmt.Sprintf("COMMENT ON TABLE %s IS %s", table, pq.QuoteLiteral(d.table.Comment)))
+	}
+
+	if len(d.table.PrimaryKey) > 0 {
+		name := primaryKeyName(d.table.Name)
+		if idx := d.table.PrimaryKeyIndex(); idx != nil {
+			name = idx.Name
+		}
+		writer := ConstraintSQLWriter{Name: DuplicationName(name), Columns: d.table.PrimaryKey}
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ADD %s", table, writer.WritePrimaryKey()))
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(d.table.UniqueConstraints)) {
+		uc := d.table.UniqueConstraints[name]
+		if IsDuplicatedName(uc.Name) {
+			continue
+		}
+		writer := ConstraintSQLWriter{Name: DuplicationName(uc.Name), Columns: uc.Columns}
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ADD %s", table, writer.WriteUnique(false)))
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(d.table.ExcludeConstraints)) {
+		xc := d.table.ExcludeConstraints[name]
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s %s",
+			table,
+			pq.QuoteIdentifier(DuplicationName(xc.Name)),
+			xc.Definition))
+	}
+
+	// Foreign key names only need to be unique within a table, so foreign keys
+	// keep their names
+	for _, name := range slices.Sorted(maps.Keys(d.table.ForeignKeys)) {
+		fk := d.table.ForeignKeys[name]
+		writer := ConstraintSQLWriter{Name: fk.Name, Columns: fk.Columns}
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ADD %s", table, writer.WriteForeignKey(
+			fk.ReferencedTable,
+			fk.ReferencedColumns,
+			ForeignKeyAction(fk.OnDelete),
+			ForeignKeyAction(fk.OnUpdate),
+			fk.OnDeleteSetColumns,
+			ForeignKeyMatchType(fk.MatchType),
+		)))
+	}
+
+	// Recreate the remaining indexes from their definitions, replacing the name
+	// of the index and of the table
+	indexes := d.table.StandaloneIndexes()
+	for _, name := range slices.Sorted(maps.Keys(indexes)) {
+		idx := indexes[name]
+		using := strings.Index(idx.Definition, " USING ")
+		if IsDuplicatedName(idx.Name) || using == -1 {
+			continue
+		}
+		stmt := "CREATE INDEX"
+		if idx.Unique {
+			stmt = "CREATE UNIQUE INDEX"
+		}
+		stmts = append(stmts, fmt.Sprintf("%s %s ON %s%s",
+			stmt,
+			pq.QuoteIdentifier(DuplicationName(idx.Name)),
+			table,
+			idx.Definition[using:]))
+	}
+
+	return stmts
+}
+
 // DuplicationName returns the name of a duplicated column.
 func DuplicationName(name string) string {
 	return "_pgroll_dup_" + name
@@ -0,0 +1,307 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/lib/pq"
+
+	"github.com/xataio/pgroll/pkg/backfill"
+	"github.com/xataio/pgroll/pkg/db"
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+// enumChange changes the values of an enum type so that the old and new
+// versions of the schema can be used side by side.
+//
+// Postgres can not remove or rename an enum value in a way that keeps the old
+// value usable, so instead a new enum type with the changed values is created
+// under a temporary name. Every column using the enum is duplicated with the
+// new type, and up and down triggers translate values between the old and new
+// columns. On completion the old columns and type are dropped and the new type
+// takes the name of the old one.
+//
+// Only one backfill task can be run per operation, so all columns using the
+// enum must belong to the same table.
+type enumChange struct {
+	// Name of the enum type
+	enum string
+
+	// Values of the new enum type, in sort order
+	values []string
+
+	// Translation of old values to new values. A nil value translates to NULL.
+	// Values not in the map are unchanged.
+	up map[string]*string
+
+	// Translation of new values to old values. Values not in the map are
+	// unchanged.
+	down map[string]string
+}
+
+func (c *enumChange) start(ctx context.Context, conn db.DB, s *schema.Schema) (*StartResult, error) {
+	// Create the new enum type under a temporary name
+	tmpEnum := TemporaryName(c.enum)
+	if err := NewCreateEnumAction(conn, tmpEnum, c.values).Execute(ctx); err != nil {
+		return nil, fmt.Errorf("failed to create enum type %q: %w", tmpEnum, err)
+	}
+
+	// The new values are visible in the virtual schema under the enum's name
+	if enum := s.GetEnum(c.enum); enum != nil {
+		enum.Values = slices.Clone(c.values)
+	}
+	s.AddEnum(tmpEnum, &schema.Enum{Name: tmpEnum, Values: slices.Clone(c.values)})
+
+	tableName, columnNames := c.columns(s, c.enum)
+	if tableName == "" {
+		return &StartResult{}, nil
+	}
+	table := s.GetTable(tableName)
+
+	columns := make([]*schema.Column, 0, len(columnNames))
+	for _, name := range columnNames {
+		columns = append(columns, table.GetColumn(name))
+	}
+
+	// Duplicate the columns on the underlying table with the new type
+	d := NewColumnDuplicator(conn, table, columns...)
+	for i, name := range columnNames {
+		d = d.WithName(columns[i].Name, TemporaryName(name)).WithType(columns[i].Name, pq.QuoteIdentifier(tmpEnum))
+	}
+	if err := d.Execute(ctx); err != nil {
+		return nil, fmt.Errorf("failed to duplicate columns: %w", err)
+	}
+
+	// Copy the columns from table columns, so the up triggers read the old
+	// physical columns
+	upColumns := maps.Clone(table.Columns)
+
+	// Add triggers to copy values from the old columns to the new, translating
+	// values to the new type.
+	triggers := make([]backfill.OperationTrigger, 0, 2*len(columnNames))
+	for _, name := range columnNames {
+		triggers = append(triggers, backfill.OperationTrigger{
+			Name:           backfill.TriggerName(tableName, name),
+			Direction:      backfill.TriggerDirectionUp,
+			TableName:      table.Name,
+			Columns:        upColumns,
+			PhysicalColumn: TemporaryName(name),
+			SQL:            translateEnumSQL(name, c.up, qualifiedName(s, tmpEnum)),
+		})
+	}
+
+	// Add the new columns to the internal schema representation, saving the
+	// old physical column names for use in the down triggers
+	var actions []DBAction
+	oldPhysicalColumns := make([]string, len(columns))
+	for i, name := range columnNames {
+		oldPhysicalColumns[i] = columns[i].Name
+
+		newColumn := &schema.Column{
+			Name:         TemporaryName(name),
+			Type:         tmpEnum,
+			Nullable:     columns[i].Nullable,
+			Comment:      columns[i].Comment,
+			EnumValues:   slices.Clone(c.values),
+			PostgresType: "enum",
+		}
+
+		// The default of the old column can not be duplicated as it has the
+		// old type; translate it to the new type instead
+		if def := c.translateDefault(columns[i].Default, tmpEnum); def != nil {
+			newColumn.Default = def
+			actions = append(actions, NewSetDefaultValueAction(conn, table.Name, TemporaryName(name), *def))
+		}
+
+		table.AddColumn(name, newColumn)
+	}
+
+	// Add triggers to copy values from the new columns to the old
+	for i, name := range columnNames {
+		triggers = append(triggers, backfill.OperationTrigger{
+			Name:           backfill.TriggerName(tableName, TemporaryName(name)),
+			Direction:      backfill.TriggerDirectionDown,
+			TableName:      table.Name,
+			Columns:        table.Columns,
+			PhysicalColumn: oldPhysicalColumns[i],
+			SQL:            translateEnumSQL(name, downMapping(c.down), qualifiedName(s, c.enum)),
+		})
+	}
+
+	return &StartResult{Actions: actions, BackfillTask: backfill.NewTask(table, triggers...)}, nil
+}
+
+func (c *enumChange) complete(conn db.DB, s *schema.Schema) []DBAction {
+	var actions []DBAction
+
+	// The schema is read from the database, so the duplicated columns have
+	// their temporary names and the new type
+	tableName, tmpColumns := c.columns(s, TemporaryName(c.enum))
+	if tableName != "" {
+		table := s.GetTable(tableName)
+
+		columns := make([]string, len(tmpColumns))
+		functions := make([]string, 0, 2*len(tmpColumns))
+		for i, tmp := range tmpColumns {
+			columns[i] = strings.TrimPrefix(tmp, temporaryPrefix)
+			functions = append(functions,
+				backfill.TriggerFunctionName(tableName, columns[i]),
+				backfill.TriggerFunctionName(tableName, tmp))
+		}
+
+		actions = append(actions,
+			NewRewritePoliciesAction(conn, table, columns...),
+			NewDropColumnAction(conn, table.Name, columns...),
+			NewDropFunctionAction(conn, functions...),
+			NewDropColumnAction(conn, table.Name, backfill.CNeedsBackfillColumn),
+		)
+		for _, column := range columns {
+			actions = append(actions, NewRenameDuplicatedColumnAction(conn, table, column))
+		}
+	}
+
+	return append(actions,
+		NewDropEnumAction(conn, c.enum),
+		NewRenameEnumAction(conn, TemporaryName(c.enum), c.enum),
+	)
+}
+
+func (c *enumChange) rollback(conn db.DB, s *schema.Schema) []DBAction {
+	var actions []DBAction
+
+	// The schema is the virtual schema, in which the duplicated columns have
+	// their original names and the new type
+	tableName, columnNames := c.columns(s, TemporaryName(c.enum))
+	if tableName != "" {
+		table := s.GetTable(tableName)
+
+		columns := make([]string, len(columnNames))
+		functions := make([]string, 0, 2*len(columnNames))
+		for i, name := range columnNames {
+			columns[i] = table.GetColumn(name).Name
+			functions = append(functions,
+				backfill.TriggerFunctionName(tableName, name),
+				backfill.TriggerFunctionName(tableName, TemporaryName(name)))
+		}
+
+		actions = append(actions,
+			NewDropColumnAction(conn, table.Name, columns...),
+			NewDropFunctionAction(conn, functions...),
+			NewDropColumnAction(conn, table.Name, backfill.CNeedsBackfillColumn),
+		)
+	}
+
+	return append(actions, NewDropEnumAction(conn, TemporaryName(c.enum)))
+}
+
+func (c *enumChange) validate(s *schema.Schema) error {
+	columns := s.EnumColumns(c.enum)
+	if len(columns) > 1 {
+		return EnumUsedByMultipleTablesError{Name: c.enum}
+	}
+
+	// Values translated to NULL can not be written to NOT NULL columns
+	if !c.translatesToNull() {
+		return nil
+	}
+	for table, names := range columns {
+		for _, name := range names {
+			if !s.GetTable(table).GetColumn(name).Nullable {
+				return ColumnIsNotNullableError{Table: table, Name: name}
+			}
+		}
+	}
+
+	return nil
+}
+
+// translatesToNull returns true if any old value is translated to NULL
+func (c *enumChange) translatesToNull() bool {
+	for _, v := range c.up {
+		if v == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// columns returns the table and the names of the columns in it that use the
+// enum type `enum`
+func (c *enumChange) columns(s *schema.Schema, enum string) (string, []string) {
+	for table, columns := range s.EnumColumns(enum) {
+		return table, columns
+	}
+	return "", nil
+}
+
+// literalDefaultRegex matches a default that is a string literal cast to a
+// type, as reported by postgres for enum column defaults, eg 'value'::mood
+var literalDefaultRegex = regexp.MustCompile(`^'((?:[^']|'')*)'::`)
+
+// translateDefault returns the default `def` of an old column translated to
+// the new enum type, or nil if the default can not be translated.
+func (c *enumChange) translateDefault(def *string, typ string) *string {
+	if def == nil {
+		return nil
+	}
+	match := literalDefaultRegex.FindStringSubmatch(*def)
+	if match == nil {
+		return nil
+	}
+
+	value := strings.ReplaceAll(match[1], "''", "'")
+	if v, ok := c.up[value]; ok {
+		if v == nil {
+			return nil
+		}
+		value = *v
+	}
+
+	translated := fmt.Sprintf("%s::%s", pq.QuoteLiteral(value), pq.QuoteIdentifier(typ))
+	return &translated
+}
+
+// translateEnumSQL returns an expression that converts the value of `column`
+// to the enum type `typ`, translating values according to `mapping`
+func translateEnumSQL(column string, mapping map[string]*string, typ string) string {
+	value := fmt.Sprintf("%s::text", pq.QuoteIdentifier(column))
+	if len(mapping) == 0 {
+		return fmt.Sprintf("%s::%s", value, typ)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "CASE %s", value)
+	for _, from := range slices.Sorted(maps.Keys(mapping)) {
+		to := "NULL"
+		if mapping[from] != nil {
+			to = pq.QuoteLiteral(*mapping[from])
+		}
+		fmt.Fprintf(&sb, " WHEN %s THEN %s", pq.QuoteLiteral(from), to)
+	}
+	fmt.Fprintf(&sb, " ELSE %s END::%s", value, typ)
+	return sb.String()
+}
+
+func downMapping(down map[string]string) map[string]*string {
+	mapping := make(map[string]*string, len(down))
+	for from, to := range down {
+		mapping[from] = &to
+	}
+	return mapping
+}
+
+// qualifiedName returns the quoted name of the type or table `name` qualified
+// with the name of the schema. Trigger functions run with the search path of
+// the version schema the application is using, so names must be qualified.
+func qualifiedName(s *schema.Schema, name string) string {
+	if s.Name == "" {
+		return pq.QuoteIdentifier(name)
+	}
+	return pq.QuoteIdentifier(s.Name) + "." + pq.QuoteIdentifier(name)
+}
@@ -0,0 +1,62 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranslateEnumSQL(t *testing.T) {
+	t.Parallel()
+
+	ok := "ok"
+	tests := map[string]struct {
+		mapping map[string]*string
+		want    string
+	}{
+		"no translation": {
+			want: `"mood"::text::"public"."mood"`,
+		},
+		"translate to value": {
+			mapping: map[string]*string{"sad": &ok},
+			want:    `CASE "mood"::text WHEN 'sad' THEN 'ok' ELSE "mood"::text END::"public"."mood"`,
+		},
+		"translate to NULL": {
+			mapping: map[string]*string{"sad": nil, "angry": nil},
+			want:    `CASE "mood"::text WHEN 'angry' THEN NULL WHEN 'sad' THEN NULL ELSE "mood"::text END::"public"."mood"`,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, translateEnumSQL("mood", tt.mapping, `"public"."mood"`))
+		})
+	}
+}
+
+func TestEnumChangeTranslateDefault(t *testing.T) {
+	t.Parallel()
+
+	ok := "ok"
+	c := &enumChange{enum: "mood", up: map[string]*string{"sad": &ok, "angry": nil}}
+
+	tests := map[string]struct {
+		def  *string
+		want *string
+	}{
+		"no default":             {},
+		"unchanged value":        {def: ptr("'happy'::mood"), want: ptr(`'happy'::"_pgroll_new_mood"`)},
+		"translated value":       {def: ptr("'sad'::mood"), want: ptr(`'ok'::"_pgroll_new_mood"`)},
+		"value dropped":          {def: ptr("'angry'::mood"), want: nil},
+		"value with quotes":      {def: ptr("'it''s'::mood"), want: ptr(`'it''s'::"_pgroll_new_mood"`)},
+		"non-literal expression": {def: ptr("default_mood()"), want: nil},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, c.translateDefault(tt.def, "_pgroll_new_mood"))
+		})
+	}
+}
@@ -300,3 +300,409 @@ type UpSQLMustBeColumnDefaultError struct {
 func (e UpSQLMustBeColumnDefaultError) Error() string {
 	return fmt.Sprintf(`volatile default expression for column %q; "up" must be equal to "default"`, e.Column)
 }
+
+type OperationNotInvertibleError struct {
+	Operation OpName
+	Reason    string
+}
+
+func (e OperationNotInvertibleError) Error() string {
+	return fmt.Sprintf("%s operation can not be reverted automatically: %s", e.Operation, e.Reason)
+}
+
+type EnumAlreadyExistsError struct {
+	Name string
+}
+
+func (e EnumAlreadyExistsError) Error() string {
+	return fmt.Sprintf("enum type %q already exists", e.Name)
+}
+
+type EnumDoesNotExistError struct {
+	Name string
+}
+
+func (e EnumDoesNotExistError) Error() string {
+	return fmt.Sprintf("enum type %q does not exist", e.Name)
+}
+
+type EnumValueAlreadyExistsError struct {
+	Enum  string
+	Value string
+}
+
+func (e EnumValueAlreadyExistsError) Error() string {
+	return fmt.Sprintf("value %q already exists in enum type %q", e.Value, e.Enum)
+}
+
+type EnumValueDoesNotExistError struct {
+	Enum  string
+	Value string
+}
+
+func (e EnumValueDoesNotExistError) Error() string {
+	return fmt.Sprintf("value %q does not exist in enum type %q", e.Value, e.Enum)
+}
+
+type EnumInUseError struct {
+	Name   string
+	Table  string
+	Column string
+}
+
+func (e EnumInUseError) Error() string {
+	return fmt.Sprintf("enum type %q is used by column %q on table %q", e.Name, e.Column, e.Table)
+}
+
+type EnumUsedByMultipleTablesError struct {
+	Name string
+}
+
+func (e EnumUsedByMultipleTablesError) Error() string {
+	return fmt.Sprintf("enum type %q is used by columns in more than one table; values can only be changed in enums used by a single table", e.Name)
+}
+
+type ViewAlreadyExistsError struct {
+	Name string
+}
+
+func (e ViewAlreadyExistsError) Error() string {
+	return fmt.Sprintf("view %q already exists", e.Name)
+}
+
+type ViewDoesNotExistError struct {
+	Name string
+}
+
+func (e ViewDoesNotExistError) Error() string {
+	return fmt.Sprintf("view %q does not exist", e.Name)
+}
+
+type TableAlreadyPartitionedError struct {
+	Name string
+}
+
+func (e TableAlreadyPartitionedError) Error() string {
+	return fmt.Sprintf("table %q is already partitioned", e.Name)
+}
+
+type TableHasNoPrimaryKeyError struct {
+	Table string
+}
+
+func (e TableHasNoPrimaryKeyError) Error() string {
+	return fmt.Sprintf("table %q has no primary key", e.Table)
+}
+
+type PartitionKeyNotInConstraintError struct {
+	Table      string
+	Constraint string
+	Column     string
+}
+
+func (e PartitionKeyNotInConstraintError) Error() string {
+	return fmt.Sprintf("constraint %q on table %q does not include partition key column %q", e.Constraint, e.Table, e.Column)
+}
+
+type TableReferencedByForeignKeyError struct {
+	Table            string
+	ReferencingTable string
+	Constraint       string
+}
+
+func (e TableReferencedByForeignKeyError) Error() string {
+	return fmt.Sprintf("table %q is referenced by foreign key %q on table %q", e.Table, e.Constraint, e.ReferencingTable)
+}
+
+type TableReferencedByViewError struct {
+	Table string
+	View  string
+}
+
+func (e TableReferencedByViewError) Error() string {
+	return fmt.Sprintf("table %q is referenced by view %q", e.Table, e.View)
+}
+
+type TableNotPartitionedError struct {
+	Name string
+}
+
+func (e TableNotPartitionedError) Error() string {
+	return fmt.Sprintf("table %q is not partitioned", e.Name)
+}
+
+type PartitionAlreadyExistsError struct {
+	Table string
+	Name  string
+}
+
+func (e PartitionAlreadyExistsError) Error() string {
+	return fmt.Sprintf("partition %q of table %q already exists", e.Name, e.Table)
+}
+
+type PartitionDoesNotExistError struct {
+	Table string
+	Name  string
+}
+
+func (e PartitionDoesNotExistError) Error() string {
+	return fmt.Sprintf("partition %q of table %q does not exist", e.Name, e.Table)
+}
+
+type InvalidPartitionBoundError struct {
+	Name  string
+	Bound string
+}
+
+func (e InvalidPartitionBoundError) Error() string {
+	return fmt.Sprintf("bound %q of partition %q is not a valid partition bound", e.Bound, e.Name)
+}
+
+type PartitionColumnMismatchError struct {
+	Table     string
+	Partition string
+	Column    string
+}
+
+func (e PartitionColumnMismatchError) Error() string {
+	return fmt.Sprintf("column %q does not match between table %q and partitioned table %q", e.Column, e.Partition, e.Table)
+}
+
+type RowLevelSecurityAlreadyEnabledError struct {
+	Table string
+}
+
+func (e RowLevelSecurityAlreadyEnabledError) Error() string {
+	return fmt.Sprintf("row level security is already enabled on table %q", e.Table)
+}
+
+type PolicyAlreadyExistsError struct {
+	Table string
+	Name  string
+}
+
+func (e PolicyAlreadyExistsError) Error() string {
+	return fmt.Sprintf("policy %q on table %q already exists", e.Name, e.Table)
+}
+
+type PolicyDoesNotExistError struct {
+	Table string
+	Name  string
+}
+
+func (e PolicyDoesNotExistError) Error() string {
+	return fmt.Sprintf("policy %q on table %q does not exist", e.Name, e.Table)
+}
+
+type InvalidPolicyCommandError struct {
+	Name    string
+	Command string
+}
+
+func (e InvalidPolicyCommandError) Error() string {
+	return fmt.Sprintf("policy %q has invalid command %q", e.Name, e.Command)
+}
+
+type InvalidPolicyExpressionError struct {
+	Name    string
+	Command string
+	Field   string
+}
+
+func (e InvalidPolicyExpressionError) Error() string {
+	return fmt.Sprintf("policy %q for %s can not have a %s expression", e.Name, e.Command, e.Field)
+}
+
+type AlterPolicyNoChangesError struct {
+	Table string
+	Name  string
+}
+
+func (e AlterPolicyNoChangesError) Error() string {
+	return fmt.Sprintf("alter policy %q on table %q requires at least one change", e.Name, e.Table)
+}
+
+type FunctionAlreadyExistsError struct {
+	Name string
+}
+
+func (e FunctionAlreadyExistsError) Error() string {
+	return fmt.Sprintf("function %q already exists", e.Name)
+}
+
+type FunctionDoesNotExistError struct {
+	Name string
+}
+
+func (e FunctionDoesNotExistError) Error() string {
+	return fmt.Sprintf("function %q does not exist", e.Name)
+}
+
+type FunctionUsedByTriggerError struct {
+	Name    string
+	Table   string
+	Trigger string
+}
+
+func (e FunctionUsedByTriggerError) Error() string {
+	return fmt.Sprintf("function %q is used by trigger %q on table %q", e.Name, e.Trigger, e.Table)
+}
+
+type FunctionNotTriggerFunctionError struct {
+	Name string
+}
+
+func (e FunctionNotTriggerFunctionError) Error() string {
+	return fmt.Sprintf("function %q does not return trigger", e.Name)
+}
+
+type InvalidFunctionVolatilityError struct {
+	Name       string
+	Volatility string
+}
+
+func (e InvalidFunctionVolatilityError) Error() string {
+	return fmt.Sprintf("function %q has invalid volatility %q", e.Name, e.Volatility)
+}
+
+type TriggerAlreadyExistsError struct {
+	Table string
+	Name  string
+}
+
+func (e TriggerAlreadyExistsError) Error() string {
+	return fmt.Sprintf("trigger %q on table %q already exists", e.Name, e.Table)
+}
+
+type TriggerDoesNotExistError struct {
+	Table string
+	Name  string
+}
+
+func (e TriggerDoesNotExistError) Error() string {
+	return fmt.Sprintf("trigger %q on table %q does not exist", e.Name, e.Table)
+}
+
+type InvalidTriggerTimingError struct {
+	Name   string
+	Timing string
+}
+
+func (e InvalidTriggerTimingError) Error() string {
+	return fmt.Sprintf("trigger %q has invalid timing %q", e.Name, e.Timing)
+}
+
+type InvalidTriggerEventError struct {
+	Name  string
+	Event string
+}
+
+func (e InvalidTriggerEventError) Error() string {
+	return fmt.Sprintf("trigger %q has invalid event %q", e.Name, e.Event)
+}
+
+type SequenceAlreadyExistsError struct {
+	Name string
+}
+
+func (e SequenceAlreadyExistsError) Error() string {
+	return fmt.Sprintf("sequence %q already exists", e.Name)
+}
+
+type SequenceDoesNotExistError struct {
+	Name string
+}
+
+func (e SequenceDoesNotExistError) Error() string {
+	return fmt.Sprintf("sequence %q does not exist", e.Name)
+}
+
+type SequenceUsedByColumnError struct {
+	Name   string
+	Table  string
+	Column string
+}
+
+func (e SequenceUsedByColumnError) Error() string {
+	return fmt.Sprintf("sequence %q is used by the default of column %q on table %q", e.Name, e.Column, e.Table)
+}
+
+type InvalidSequenceDataTypeError struct {
+	Name     string
+	DataType string
+}
+
+func (e InvalidSequenceDataTypeError) Error() string {
+	return fmt.Sprintf("sequence %q has invalid data type %q", e.Name, e.DataType)
+}
+
+type InvalidSequenceOptionsError struct {
+	Name   string
+	Reason string
+}
+
+func (e InvalidSequenceOptionsError) Error() string {
+	return fmt.Sprintf("sequence %q has invalid options: %s", e.Name, e.Reason)
+}
+
+type InvalidSequenceOwnerError struct {
+	Name    string
+	OwnedBy string
+}
+
+func (e InvalidSequenceOwnerError) Error() string {
+	return fmt.Sprintf("sequence %q has invalid owner %q, expected \"table.column\"", e.Name, e.OwnedBy)
+}
+
+type AlterSequenceNoChangesError struct {
+	Name string
+}
+
+func (e AlterSequenceNoChangesError) Error() string {
+	return fmt.Sprintf("alter sequence %q requires at least one change", e.Name)
+}
+
+type ColumnNotSerialError struct {
+	Table string
+	Name  string
+}
+
+func (e ColumnNotSerialError) Error() string {
+	return fmt.Sprintf("column %q on table %q does not take its default from a sequence", e.Name, e.Table)
+}
+
+type ColumnIsIdentityError struct {
+	Table string
+	Name  string
+}
+
+func (e ColumnIsIdentityError) Error() string {
+	return fmt.Sprintf("column %q on table %q is already an identity column", e.Name, e.Table)
+}
+
+type InvalidIdentityGenerationError struct {
+	Table     string
+	Name      string
+	Generated string
+}
+
+func (e InvalidIdentityGenerationError) Error() string {
+	return fmt.Sprintf("column %q on table %q has invalid identity generation %q, expected ALWAYS or BY DEFAULT", e.Name, e.Table, e.Generated)
+}
+
+type TableAlreadyInSchemaError struct {
+	Table  string
+	Schema string
+}
+
+func (e TableAlreadyInSchemaError) Error() string {
+	return fmt.Sprintf("table %q is already in schema %q", e.Table, e.Schema)
+}
+
+type InvalidPrivilegeError struct {
+	Privilege string
+}
+
+func (e InvalidPrivilegeError) Error() string {
+	return fmt.Sprintf("invalid table privilege %q", e.Privilege)
+}
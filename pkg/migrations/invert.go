@@ -0,0 +1,774 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/oapi-codegen/nullable"
+
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+// Invert returns the operations that undo the operations in `m`. `before` is
+// the schema as it was before `m` was applied; it is used to restore the
+// definitions of the columns, indexes and constraints that `m` dropped or
+// altered.
+//
+// The `up` and `down` expressions of the original operations are swapped in
+// the inverse operations. Operations whose effects can not be undone without
+// data that is no longer available, such as dropping a table, return an
+// OperationNotInvertibleError.
+func Invert(m *Migration, before *schema.Schema) (Operations, error) {
+	inv := inverter{before: before}
+
+	var ops Operations
+	for _, op := range slices.Backward(m.Operations) {
+		inverse, err := inv.invert(op)
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, inverse...)
+	}
+
+	return ops, nil
+}
+
+type inverter struct {
+	before *schema.Schema
+}
+
+func (inv inverter) invert(op Operation) (Operations, error) {
+	switch op := op.(type) {
+	case *OpCreateTable:
+		return Operations{&OpDropTable{Name: op.Name}}, nil
+
+	case *OpDropTable:
+		return nil, OperationNotInvertibleError{
+			Operation: OpNameDropTable,
+			Reason:    fmt.Sprintf("the data in table %q has been dropped", op.Name),
+		}
+
+	case *OpRenameTable:
+		return Operations{&OpRenameTable{From: op.To, To: op.From}}, nil
+
+	case *OpAddColumn:
+		if !op.Column.Nullable && op.Column.Default == nil && !op.Column.Pk {
+			return nil, OperationNotInvertibleError{
+				Operation: OpNameAddColumn,
+				Reason: fmt.Sprintf("column %q on table %q is NOT NULL without a default, so a down expression is needed to drop it",
+					op.Column.Name, op.Table),
+			}
+		}
+		return Operations{&OpDropColumn{Table: op.Table, Column: op.Column.Name}}, nil
+
+	case *OpDropColumn:
+		return inv.invertDropColumn(op)
+
+	case *OpRenameColumn:
+		return Operations{&OpRenameColumn{Table: op.Table, From: op.To, To: op.From}}, nil
+
+	case *OpAlterColumn:
+		return inv.invertAlterColumn(op)
+
+	case *OpCreateIndex:
+		return Operations{&OpDropIndex{Name: op.Name}}, nil
+
+	case *OpDropIndex:
+		return inv.invertDropIndex(op)
+
+	case *OpRenameConstraint:
+		return Operations{&OpRenameConstraint{Table: op.Table, From: op.To, To: op.From}}, nil
+
+	case *OpCreateConstraint:
+		return Operations{&OpDropMultiColumnConstraint{
+			Table: op.Table,
+			Name:  op.Name,
+			Up:    MultiColumnUpSQL(op.Down),
+			Down:  MultiColumnDownSQL(op.Up),
+		}}, nil
+
+	case *OpDropConstraint:
+		table, err := inv.beforeTable(OpNameDropConstraint, op.Table)
+		if err != nil {
+			return nil, err
+		}
+		up, down := MultiColumnUpSQL{}, MultiColumnDownSQL{}
+		for _, col := range table.GetConstraintColumns(op.Name) {
+			up[col], down[col] = op.Down, op.Up
+		}
+		return inv.recreateConstraint(OpNameDropConstraint, table, op.Table, op.Name, up, down)
+
+	case *OpDropMultiColumnConstraint:
+		table, err := inv.beforeTable(OpNameDropMultiColumnConstraint, op.Table)
+		if err != nil {
+			return nil, err
+		}
+		return inv.recreateConstraint(OpNameDropMultiColumnConstraint, table, op.Table, op.Name,
+			MultiColumnUpSQL(op.Down), MultiColumnDownSQL(op.Up))
+
+	case *OpRawSQL:
+		if op.Down == "" {
+			return nil, OperationNotInvertibleError{
+				Operation: OpRawSQLName,
+				Reason:    "the operation has no down SQL",
+			}
+		}
+		return Operations{&OpRawSQL{Up: op.Down, Down: op.Up, OnComplete: op.OnComplete}}, nil
+
+	case *OpSetReplicaIdentity:
+		return nil, OperationNotInvertibleError{
+			Operation: OpNameSetReplicaIdentity,
+			Reason:    fmt.Sprintf("the previous replica identity of table %q is not recorded", op.Table),
+		}
+
+	case *OpCreateEnum:
+		return Operations{&OpDropEnum{Name: op.Name}}, nil
+
+	case *OpDropEnum:
+		var enum *schema.Enum
+		if inv.before != nil {
+			enum = inv.before.GetEnum(op.Name)
+		}
+		if enum == nil {
+			return nil, OperationNotInvertibleError{
+				Operation: OpNameDropEnum,
+				Reason:    fmt.Sprintf("enum type %q is not recorded in the schema history", op.Name),
+			}
+		}
+		return Operations{&OpCreateEnum{Name: op.Name, Values: slices.Clone(enum.Values)}}, nil
+
+	case *OpAddEnumValue:
+		return Operations{&OpDropEnumValue{Enum: op.Enum, Value: op.Value}}, nil
+
+	case *OpRenameEnumValue:
+		return Operations{&OpRenameEnumValue{Enum: op.Enum, From: op.To, To: op.From}}, nil
+
+	case *OpDropEnumValue:
+		return nil, OperationNotInvertibleError{
+			Operation: OpNameDropEnumValue,
+			Reason:    fmt.Sprintf("rows holding value %q of enum type %q have been changed", op.Value, op.Enum),
+		}
+
+	case *OpSplitColumn:
+		return inv.invertSplitColumn(op)
+
+	case *OpMergeColumns:
+		return inv.invertMergeColumns(op)
+
+	case *OpPartitionTable:
+		return nil, OperationNotInvertibleError{
+			Operation: OpNamePartitionTable,
+			Reason:    fmt.Sprintf("table %q can not be converted back to a table without partitions", op.Table),
+		}
+
+	case *OpCreatePartition:
+		return nil, OperationNotInvertibleError{
+			Operation: OpNameCreatePartition,
+			Reason:    fmt.Sprintf("dropping partition %q would delete the rows written to it", op.Name),
+		}
+
+	case *OpAttachPartition:
+		return Operations{&OpDetachPartition{Table: op.Table, Name: op.Name}}, nil
+
+	case *OpDetachPartition:
+		return inv.invertDetachPartition(op)
+
+	case *OpEnableRLS:
+		return nil, OperationNotInvertibleError{
+			Operation: OpNameEnableRLS,
+			Reason:    fmt.Sprintf("row level security can not be disabled on table %q", op.Table),
+		}
+
+	case *OpCreatePolicy:
+		return Operations{&OpDropPolicy{Table: op.Table, Name: op.Name}}, nil
+
+	case *OpAlterPolicy:
+		return inv.invertAlterPolicy(op)
+
+	case *OpDropPolicy:
+		policy, err := inv.beforePolicy(OpNameDropPolicy, op.Table, op.Name)
+		if err != nil {
+			return nil, err
+		}
+		return Operations{policyFromSchema(op.Table, policy)}, nil
+
+	case *OpCreateFunction:
+		return Operations{&OpDropFunction{Name: op.Name}}, nil
+
+	case *OpReplaceFunction:
+		fn, err := inv.beforeFunction(OpNameReplaceFunction, op.Name)
+		if err != nil {
+			return nil, err
+		}
+		return Operations{&OpReplaceFunction{
+			Name:       op.Name,
+			Definition: fn.Definition,
+			Language:   fn.Language,
+			Volatility: FunctionVolatility(fn.Volatility),
+		}}, nil
+
+	case *OpDropFunction:
+		fn, err := inv.beforeFunction(OpNameDropFunction, op.Name)
+		if err != nil {
+			return nil, err
+		}
+		return Operations{&OpCreateFunction{
+			Name:       fn.Name,
+			Arguments:  fn.Arguments,
+			Returns:    fn.Returns,
+			Language:   fn.Language,
+			Volatility: FunctionVolatility(fn.Volatility),
+			Definition: fn.Definition,
+		}}, nil
+
+	case *OpCreateTrigger:
+		return Operations{&OpDropTrigger{Table: op.Table, Name: op.Name}}, nil
+
+	case *OpDropTrigger:
+		trigger, err := inv.beforeTrigger(OpNameDropTrigger, op.Table, op.Name)
+		if err != nil {
+			return nil, err
+		}
+		return Operations{triggerFromSchema(op.Table, trigger)}, nil
+
+	case *OpCreateSequence:
+		return Operations{&OpDropSequence{Name: op.Name}}, nil
+
+	case *OpAlterSequence:
+		return inv.invertAlterSequence(op)
+
+	case *OpDropSequence:
+		return nil, OperationNotInvertibleError{
+			Operation: OpNameDropSequence,
+			Reason:    fmt.Sprintf("the current value of sequence %q has been dropped", op.Name),
+		}
+
+	case *OpConvertToIdentity:
+		return nil, OperationNotInvertibleError{
+			Operation: OpNameConvertToIdentity,
+			Reason:    fmt.Sprintf("column %q on table %q can not be converted back from an identity column", op.Column, op.Table),
+		}
+
+	case *OpMoveTable:
+		return nil, OperationNotInvertibleError{
+			Operation: OpNameMoveTable,
+			Reason:    fmt.Sprintf("table %q has been moved to schema %q and can only be moved back by a migration on that schema", op.Table, op.Schema),
+		}
+
+	case *OpGrant:
+		return inv.invertGrant(op), nil
+
+	case *OpRevoke:
+		return inv.invertRevoke(op)
+
+	case *OpCreateView:
+		return Operations{&OpDropView{Name: op.Name}}, nil
+
+	case *OpReplaceView:
+		view, err := inv.beforeView(OpNameReplaceView, op.Name)
+		if err != nil {
+			return nil, err
+		}
+		return Operations{&OpReplaceView{Name: op.Name, Definition: view.Definition}}, nil
+
+	case *OpDropView:
+		view, err := inv.beforeView(OpNameDropView, op.Name)
+		if err != nil {
+			return nil, err
+		}
+		return Operations{&OpCreateView{Name: op.Name, Definition: view.Definition}}, nil
+	}
+
+	return nil, OperationNotInvertibleError{
+		Operation: OperationName(op),
+		Reason:    "the operation is not supported",
+	}
+}
+
+func (inv inverter) invertDropColumn(op *OpDropColumn) (Operations, error) {
+	if op.Down == "" {
+		return nil, OperationNotInvertibleError{
+			Operation: OpNameDropColumn,
+			Reason: fmt.Sprintf("the data in column %q on table %q has been dropped and the operation has no down expression to restore it",
+				op.Column, op.Table),
+		}
+	}
+
+	column, err := inv.beforeColumn(OpNameDropColumn, op.Table, op.Column)
+	if err != nil {
+		return nil, err
+	}
+
+	return Operations{&OpAddColumn{Table: op.Table, Column: columnFromSchema(column), Up: op.Down}}, nil
+}
+
+func (inv inverter) invertSplitColumn(op *OpSplitColumn) (Operations, error) {
+	column, err := inv.beforeColumn(OpNameSplitColumn, op.Table, op.Column)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(op.Columns))
+	for i, col := range op.Columns {
+		names[i] = col.Name
+	}
+
+	return Operations{&OpMergeColumns{
+		Table:   op.Table,
+		Columns: names,
+		Column:  columnFromSchema(column),
+		Up:      op.Down,
+		Down:    MultiColumnDownSQL(op.Up),
+	}}, nil
+}
+
+func (inv inverter) invertMergeColumns(op *OpMergeColumns) (Operations, error) {
+	columns := make([]Column, len(op.Columns))
+	for i, name := range op.Columns {
+		column, err := inv.beforeColumn(OpNameMergeColumns, op.Table, name)
+		if err != nil {
+			return nil, err
+		}
+		columns[i] = columnFromSchema(column)
+	}
+
+	return Operations{&OpSplitColumn{
+		Table:   op.Table,
+		Column:  op.Column.Name,
+		Columns: columns,
+		Up:      MultiColumnUpSQL(op.Down),
+		Down:    op.Up,
+	}}, nil
+}
+
+// columnFromSchema returns the definition of a column that recreates `column`
+func columnFromSchema(column *schema.Column) Column {
+	col := Column{
+		Name:     column.Name,
+		Type:     column.Type,
+		Nullable: column.Nullable,
+		Default:  column.Default,
+	}
+	if column.Comment != "" {
+		col.Comment = &column.Comment
+	}
+	return col
+}
+
+func (inv inverter) invertDetachPartition(op *OpDetachPartition) (Operations, error) {
+	table, err := inv.beforeTable(OpNameDetachPartition, op.Table)
+	if err != nil {
+		return nil, err
+	}
+	partition, ok := table.Partitions[op.Name]
+	if !ok {
+		return nil, OperationNotInvertibleError{
+			Operation: OpNameDetachPartition,
+			Reason:    fmt.Sprintf("partition %q of table %q is not recorded in the schema history", op.Name, op.Table),
+		}
+	}
+
+	return Operations{&OpAttachPartition{Table: op.Table, Name: op.Name, Bound: partition.Bound}}, nil
+}
+
+func (inv inverter) invertAlterPolicy(op *OpAlterPolicy) (Operations, error) {
+	policy, err := inv.beforePolicy(OpNameAlterPolicy, op.Table, op.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	// An expression added to a policy can not be removed by altering it, only
+	// changed
+	if (op.Using != nil && policy.Using == "") || (op.WithCheck != nil && policy.WithCheck == "") {
+		return nil, OperationNotInvertibleError{
+			Operation: OpNameAlterPolicy,
+			Reason:    fmt.Sprintf("an expression added to policy %q on table %q can not be removed", op.Name, op.Table),
+		}
+	}
+
+	inverse := &OpAlterPolicy{Table: op.Table, Name: op.Name}
+	if len(op.Roles) > 0 {
+		inverse.Roles = slices.Clone(policy.Roles)
+	}
+	if op.Using != nil {
+		inverse.Using = &policy.Using
+	}
+	if op.WithCheck != nil {
+		inverse.WithCheck = &policy.WithCheck
+	}
+	return Operations{inverse}, nil
+}
+
+// invertGrant revokes the granted privileges that the role did not already
+// hold before the migration
+func (inv inverter) invertGrant(op *OpGrant) Operations {
+	var held []schema.Privilege
+	if inv.before != nil {
+		if table := inv.before.GetTable(op.Table); table != nil {
+			held = rolePrivileges(table, op.Role)
+		}
+	}
+
+	var revoke []TablePrivilege
+	for _, p := range expandPrivileges(op.Privileges) {
+		if !slices.ContainsFunc(held, func(h schema.Privilege) bool { return h.Privilege == string(p) }) {
+			revoke = append(revoke, p)
+		}
+	}
+	if len(revoke) == 0 {
+		return Operations{}
+	}
+
+	return Operations{&OpRevoke{Table: op.Table, Role: op.Role, Privileges: revoke}}
+}
+
+// invertRevoke grants back the revoked privileges that the role held before
+// the migration
+func (inv inverter) invertRevoke(op *OpRevoke) (Operations, error) {
+	table, err := inv.beforeTable(OpNameRevoke, op.Table)
+	if err != nil {
+		return nil, err
+	}
+
+	var grant, grantWithOption []TablePrivilege
+	for _, p := range rolePrivileges(table, op.Role) {
+		privilege := TablePrivilege(p.Privilege)
+		if !slices.Contains(expandPrivileges(op.Privileges), privilege) {
+			continue
+		}
+		if p.Grantable {
+			grantWithOption = append(grantWithOption, privilege)
+		} else {
+			grant = append(grant, privilege)
+		}
+	}
+
+	ops := Operations{}
+	if len(grant) > 0 {
+		ops = append(ops, &OpGrant{Table: op.Table, Role: op.Role, Privileges: grant})
+	}
+	if len(grantWithOption) > 0 {
+		ops = append(ops, &OpGrant{Table: op.Table, Role: op.Role, Privileges: grantWithOption, WithGrantOption: true})
+	}
+	return ops, nil
+}
+
+func (inv inverter) invertAlterSequence(op *OpAlterSequence) (Operations, error) {
+	if op.Restart != nil {
+		return nil, OperationNotInvertibleError{
+			Operation: OpNameAlterSequence,
+			Reason:    fmt.Sprintf("the value of sequence %q before it was restarted is not recorded", op.Name),
+		}
+	}
+
+	seq, err := inv.beforeSequence(OpNameAlterSequence, op.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	increment, minValue, maxValue, cache := int(seq.Increment), int(seq.MinValue), int(seq.MaxValue), int(seq.Cache)
+
+	inverse := &OpAlterSequence{Name: op.Name}
+	if op.Increment != nil {
+		inverse.Increment = &increment
+	}
+	if op.MinValue != nil {
+		inverse.MinValue = &minValue
+	}
+	if op.MaxValue != nil {
+		inverse.MaxValue = &maxValue
+	}
+	if op.Cache != nil {
+		inverse.Cache = &cache
+	}
+	if op.Cycle != nil {
+		inverse.Cycle = &seq.Cycle
+	}
+	return Operations{inverse}, nil
+}
+
+// policyFromSchema returns the operation that recreates `policy` on `table`
+func policyFromSchema(table string, policy *schema.Policy) *OpCreatePolicy {
+	as := OpCreatePolicyAsPERMISSIVE
+	if !policy.Permissive {
+		as = OpCreatePolicyAsRESTRICTIVE
+	}
+	return &OpCreatePolicy{
+		Table:     table,
+		Name:      policy.Name,
+		As:        as,
+		Command:   OpCreatePolicyCommand(policy.Command),
+		Roles:     slices.Clone(policy.Roles),
+		Using:     policy.Using,
+		WithCheck: policy.WithCheck,
+	}
+}
+
+func (inv inverter) invertAlterColumn(op *OpAlterColumn) (Operations, error) {
+	var ops Operations
+
+	// Constraints added by the operation are dropped, migrating data in the
+	// opposite direction
+	for _, name := range alterColumnConstraintNames(op) {
+		ops = append(ops, &OpDropMultiColumnConstraint{
+			Table: op.Table,
+			Name:  name,
+			Up:    MultiColumnUpSQL{op.Column: op.Down},
+			Down:  MultiColumnDownSQL{op.Column: op.Up},
+		})
+	}
+
+	if op.Type == nil && op.Nullable == nil && op.Default == nil && op.Comment == nil {
+		return ops, nil
+	}
+
+	column, err := inv.beforeColumn(OpNameAlterColumn, op.Table, op.Column)
+	if err != nil {
+		return nil, err
+	}
+
+	inverse := &OpAlterColumn{
+		Table:  op.Table,
+		Column: op.Column,
+		Up:     op.Down,
+		Down:   op.Up,
+	}
+	if op.Type != nil {
+		inverse.Type = &column.Type
+	}
+	if op.Nullable != nil {
+		inverse.Nullable = &column.Nullable
+	}
+	if op.Default != nil {
+		if column.Default == nil {
+			inverse.Default = nullable.NewNullNullable[string]()
+		} else {
+			inverse.Default = nullable.NewNullableWithValue(*column.Default)
+		}
+	}
+	if op.Comment != nil {
+		if column.Comment == "" {
+			inverse.Comment = nullable.NewNullNullable[string]()
+		} else {
+			inverse.Comment = nullable.NewNullableWithValue(column.Comment)
+		}
+	}
+
+	return append(ops, inverse), nil
+}
+
+// alterColumnConstraintNames returns the names of the constraints added by an
+// alter column operation.
+func alterColumnConstraintNames(op *OpAlterColumn) []string {
+	var names []string
+	if op.Check != nil {
+		names = append(names, op.Check.Name)
+	}
+	if op.References != nil {
+		names = append(names, op.References.Name)
+	}
+	if op.Unique != nil {
+		names = append(names, op.Unique.Name)
+	}
+	return names
+}
+
+func (inv inverter) invertDropIndex(op *OpDropIndex) (Operations, error) {
+	if inv.before != nil {
+		for _, tableName := range sortedTableNames(inv.before) {
+			table := inv.before.GetTable(tableName)
+			if table == nil {
+				continue
+			}
+			idx, ok := table.Indexes[op.Name]
+			if !ok {
+				continue
+			}
+
+			create := &OpCreateIndex{
+				Name:   idx.Name,
+				Table:  tableName,
+				Unique: idx.Unique,
+				Method: OpCreateIndexMethod(idx.Method),
+			}
+			if idx.Predicate != nil {
+				create.Predicate = *idx.Predicate
+			}
+			for _, col := range idx.Columns {
+				create.Columns = append(create.Columns, IndexField{Column: col})
+			}
+			return Operations{create}, nil
+		}
+	}
+
+	return nil, OperationNotInvertibleError{
+		Operation: OpNameDropIndex,
+		Reason:    fmt.Sprintf("the definition of index %q is not recorded in the schema history", op.Name),
+	}
+}
+
+// recreateConstraint returns an operation that recreates the constraint
+// `name` on `tableName` as it was defined in the schema before the migration.
+func (inv inverter) recreateConstraint(opName OpName, table *schema.Table, tableName, name string, up MultiColumnUpSQL, down MultiColumnDownSQL) (Operations, error) {
+	create := &OpCreateConstraint{
+		Table: tableName,
+		Name:  name,
+		Up:    up,
+		Down:  down,
+	}
+
+	switch {
+	case table.CheckConstraints[name] != nil:
+		cc := table.CheckConstraints[name]
+		create.Type = OpCreateConstraintTypeCheck
+		create.Columns = cc.Columns
+		check := checkExpression(cc.Definition)
+		create.Check = &check
+		create.NoInherit = cc.NoInherit
+
+	case table.UniqueConstraints[name] != nil:
+		create.Type = OpCreateConstraintTypeUnique
+		create.Columns = table.UniqueConstraints[name].Columns
+
+	case table.ForeignKeys[name] != nil:
+		fk := table.ForeignKeys[name]
+		create.Type = OpCreateConstraintTypeForeignKey
+		create.Columns = fk.Columns
+		create.References = &TableForeignKeyReference{
+			Table:              fk.ReferencedTable,
+			Columns:            fk.ReferencedColumns,
+			MatchType:          ForeignKeyMatchType(fk.MatchType),
+			OnDelete:           ForeignKeyAction(fk.OnDelete),
+			OnDeleteSetColumns: fk.OnDeleteSetColumns,
+			OnUpdate:           ForeignKeyAction(fk.OnUpdate),
+		}
+
+	default:
+		return nil, OperationNotInvertibleError{
+			Operation: opName,
+			Reason:    fmt.Sprintf("the definition of constraint %q on table %q is not recorded in the schema history", name, tableName),
+		}
+	}
+
+	return Operations{create}, nil
+}
+
+// checkExpression extracts the expression from a check constraint definition
+// of the form `CHECK ((expr))`.
+func checkExpression(definition string) string {
+	expr := strings.TrimSuffix(strings.TrimSpace(definition), " NOT VALID")
+	expr = strings.TrimPrefix(expr, "CHECK ")
+	for strings.HasPrefix(expr, "(") && strings.HasSuffix(expr, ")") {
+		expr = expr[1 : len(expr)-1]
+	}
+	return expr
+}
+
+func (inv inverter) beforeTable(opName OpName, tableName string) (*schema.Table, error) {
+	var table *schema.Table
+	if inv.before != nil {
+		table = inv.before.GetTable(tableName)
+	}
+	if table == nil {
+		return nil, OperationNotInvertibleError{
+			Operation: opName,
+			Reason:    fmt.Sprintf("table %q is not recorded in the schema history", tableName),
+		}
+	}
+	return table, nil
+}
+
+func (inv inverter) beforeView(opName OpName, viewName string) (*schema.View, error) {
+	var view *schema.View
+	if inv.before != nil {
+		view = inv.before.GetView(viewName)
+	}
+	if view == nil {
+		return nil, OperationNotInvertibleError{
+			Operation: opName,
+			Reason:    fmt.Sprintf("view %q is not recorded in the schema history", viewName),
+		}
+	}
+	return view, nil
+}
+
+func (inv inverter) beforeColumn(opName OpName, tableName, columnName string) (*schema.Column, error) {
+	table, err := inv.beforeTable(opName, tableName)
+	if err != nil {
+		return nil, err
+	}
+	column := table.GetColumn(columnName)
+	if column == nil {
+		return nil, OperationNotInvertibleError{
+			Operation: opName,
+			Reason:    fmt.Sprintf("column %q on table %q is not recorded in the schema history", columnName, tableName),
+		}
+	}
+	return column, nil
+}
+
+func (inv inverter) beforePolicy(opName OpName, tableName, policyName string) (*schema.Policy, error) {
+	table, err := inv.beforeTable(opName, tableName)
+	if err != nil {
+		return nil, err
+	}
+	policy, ok := table.Policies[policyName]
+	if !ok {
+		return nil, OperationNotInvertibleError{
+			Operation: opName,
+			Reason:    fmt.Sprintf("policy %q on table %q is not recorded in the schema history", policyName, tableName),
+		}
+	}
+	return policy, nil
+}
+
+func (inv inverter) beforeFunction(opName OpName, functionName string) (*schema.Function, error) {
+	var fn *schema.Function
+	if inv.before != nil {
+		fn = inv.before.GetFunction(functionName)
+	}
+	if fn == nil {
+		return nil, OperationNotInvertibleError{
+			Operation: opName,
+			Reason:    fmt.Sprintf("function %q is not recorded in the schema history", functionName),
+		}
+	}
+	return fn, nil
+}
+
+func (inv inverter) beforeSequence(opName OpName, sequenceName string) (*schema.Sequence, error) {
+	var seq *schema.Sequence
+	if inv.before != nil {
+		seq = inv.before.GetSequence(sequenceName)
+	}
+	if seq == nil {
+		return nil, OperationNotInvertibleError{
+			Operation: opName,
+			Reason:    fmt.Sprintf("sequence %q is not recorded in the schema history", sequenceName),
+		}
+	}
+	return seq, nil
+}
+
+func (inv inverter) beforeTrigger(opName OpName, tableName, triggerName string) (*schema.Trigger, error) {
+	table, err := inv.beforeTable(opName, tableName)
+	if err != nil {
+		return nil, err
+	}
+	trigger, ok := table.Triggers[triggerName]
+	if !ok {
+		return nil, OperationNotInvertibleError{
+			Operation: opName,
+			Reason:    fmt.Sprintf("trigger %q on table %q is not recorded in the schema history", triggerName, tableName),
+		}
+	}
+	return trigger, nil
+}
+
+func sortedTableNames(s *schema.Schema) []string {
+	return slices.Sorted(maps.Keys(s.Tables))
+}
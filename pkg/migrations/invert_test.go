@@ -0,0 +1,518 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations_test
+
+import (
+	"testing"
+
+	"github.com/oapi-codegen/nullable"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xataio/pgroll/pkg/migrations"
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+func TestInvert(t *testing.T) {
+	t.Parallel()
+
+	before := &schema.Schema{
+		Tables: map[string]*schema.Table{
+			"users": {
+				Name: "users",
+				Columns: map[string]*schema.Column{
+					"id":    {Name: "id", Type: "integer"},
+					"email": {Name: "email", Type: "text", Nullable: true, Comment: "contact address"},
+					"age":   {Name: "age", Type: "integer", Nullable: true, Default: ptr("0")},
+				},
+				Indexes: map[string]*schema.Index{
+					"idx_email": {Name: "idx_email", Unique: true, Columns: []string{"email"}, Method: "btree"},
+				},
+				CheckConstraints: map[string]*schema.CheckConstraint{
+					"age_positive": {Name: "age_positive", Columns: []string{"age"}, Definition: "CHECK ((age > 0))"},
+				},
+			},
+		},
+	}
+
+	m := &migrations.Migration{
+		Name: "02_changes",
+		Operations: migrations.Operations{
+			&migrations.OpCreateTable{Name: "posts", Columns: []migrations.Column{{Name: "id", Type: "integer", Pk: true}}},
+			&migrations.OpDropColumn{Table: "users", Column: "email", Down: "'unknown'"},
+			&migrations.OpAlterColumn{Table: "users", Column: "age", Type: ptr("bigint"), Default: nullable.NewNullNullable[string](), Up: "age::bigint", Down: "age::integer"},
+			&migrations.OpDropIndex{Name: "idx_email"},
+			&migrations.OpDropMultiColumnConstraint{
+				Table: "users",
+				Name:  "age_positive",
+				Up:    migrations.MultiColumnUpSQL{"age": "age"},
+				Down:  migrations.MultiColumnDownSQL{"age": "GREATEST(age, 1)"},
+			},
+			&migrations.OpRenameTable{From: "users", To: "people"},
+		},
+	}
+
+	ops, err := migrations.Invert(m, before)
+	require.NoError(t, err)
+
+	assert.Equal(t, migrations.Operations{
+		&migrations.OpRenameTable{From: "people", To: "users"},
+		&migrations.OpCreateConstraint{
+			Table:   "users",
+			Name:    "age_positive",
+			Type:    migrations.OpCreateConstraintTypeCheck,
+			Columns: []string{"age"},
+			Check:   ptr("age > 0"),
+			Up:      migrations.MultiColumnUpSQL{"age": "GREATEST(age, 1)"},
+			Down:    migrations.MultiColumnDownSQL{"age": "age"},
+		},
+		&migrations.OpCreateIndex{
+			Name:    "idx_email",
+			Table:   "users",
+			Unique:  true,
+			Method:  migrations.OpCreateIndexMethodBtree,
+			Columns: []migrations.IndexField{{Column: "email"}},
+		},
+		&migrations.OpAlterColumn{
+			Table:   "users",
+			Column:  "age",
+			Type:    ptr("integer"),
+			Default: nullable.NewNullableWithValue("0"),
+			Up:      "age::integer",
+			Down:    "age::bigint",
+		},
+		&migrations.OpAddColumn{
+			Table:  "users",
+			Column: migrations.Column{Name: "email", Type: "text", Nullable: true, Comment: ptr("contact address")},
+			Up:     "'unknown'",
+		},
+		&migrations.OpDropTable{Name: "posts"},
+	}, ops)
+}
+
+func TestInvertEnumOperations(t *testing.T) {
+	t.Parallel()
+
+	before := &schema.Schema{
+		Enums: map[string]*schema.Enum{
+			"mood": {Name: "mood", Values: []string{"happy", "sad"}},
+		},
+	}
+
+	m := &migrations.Migration{
+		Name: "02_enums",
+		Operations: migrations.Operations{
+			&migrations.OpCreateEnum{Name: "color", Values: []string{"red", "green"}},
+			&migrations.OpAddEnumValue{Enum: "color", Value: "blue"},
+			&migrations.OpRenameEnumValue{Enum: "color", From: "red", To: "crimson"},
+			&migrations.OpDropEnum{Name: "mood"},
+		},
+	}
+
+	ops, err := migrations.Invert(m, before)
+	require.NoError(t, err)
+
+	assert.Equal(t, migrations.Operations{
+		&migrations.OpCreateEnum{Name: "mood", Values: []string{"happy", "sad"}},
+		&migrations.OpRenameEnumValue{Enum: "color", From: "crimson", To: "red"},
+		&migrations.OpDropEnumValue{Enum: "color", Value: "blue"},
+		&migrations.OpDropEnum{Name: "color"},
+	}, ops)
+}
+
+func TestInvertSplitAndMergeColumns(t *testing.T) {
+	t.Parallel()
+
+	before := &schema.Schema{
+		Tables: map[string]*schema.Table{
+			"users": {
+				Name: "users",
+				Columns: map[string]*schema.Column{
+					"name":   {Name: "name", Type: "text", Nullable: true},
+					"street": {Name: "street", Type: "text"},
+					"city":   {Name: "city", Type: "text"},
+				},
+			},
+		},
+	}
+
+	m := &migrations.Migration{
+		Name: "02_reshape",
+		Operations: migrations.Operations{
+			&migrations.OpSplitColumn{
+				Table:  "users",
+				Column: "name",
+				Columns: []migrations.Column{
+					{Name: "first_name", Type: "text"},
+					{Name: "last_name", Type: "text"},
+				},
+				Up: migrations.MultiColumnUpSQL{
+					"first_name": "split_part(name, ' ', 1)",
+					"last_name":  "split_part(name, ' ', 2)",
+				},
+				Down: "first_name || ' ' || last_name",
+			},
+			&migrations.OpMergeColumns{
+				Table:   "users",
+				Columns: []string{"street", "city"},
+				Column:  migrations.Column{Name: "address", Type: "text"},
+				Up:      "street || ', ' || city",
+				Down: migrations.MultiColumnDownSQL{
+					"street": "split_part(address, ', ', 1)",
+					"city":   "split_part(address, ', ', 2)",
+				},
+			},
+		},
+	}
+
+	ops, err := migrations.Invert(m, before)
+	require.NoError(t, err)
+
+	assert.Equal(t, migrations.Operations{
+		&migrations.OpSplitColumn{
+			Table:  "users",
+			Column: "address",
+			Columns: []migrations.Column{
+				{Name: "street", Type: "text"},
+				{Name: "city", Type: "text"},
+			},
+			Up: migrations.MultiColumnUpSQL{
+				"street": "split_part(address, ', ', 1)",
+				"city":   "split_part(address, ', ', 2)",
+			},
+			Down: "street || ', ' || city",
+		},
+		&migrations.OpMergeColumns{
+			Table:   "users",
+			Columns: []string{"first_name", "last_name"},
+			Column:  migrations.Column{Name: "name", Type: "text", Nullable: true},
+			Up:      "first_name || ' ' || last_name",
+			Down: migrations.MultiColumnDownSQL{
+				"first_name": "split_part(name, ' ', 1)",
+				"last_name":  "split_part(name, ' ', 2)",
+			},
+		},
+	}, ops)
+}
+
+func TestInvertViewOperations(t *testing.T) {
+	t.Parallel()
+
+	before := &schema.Schema{
+		Views: map[string]*schema.View{
+			"user_names": {Name: "user_names", Definition: "SELECT id, name FROM users"},
+			"user_ids":   {Name: "user_ids", Definition: "SELECT id FROM users"},
+		},
+	}
+
+	m := &migrations.Migration{
+		Name: "02_views",
+		Operations: migrations.Operations{
+			&migrations.OpCreateView{Name: "user_emails", Definition: "SELECT id, email FROM users"},
+			&migrations.OpReplaceView{Name: "user_names", Definition: "SELECT id, username FROM users"},
+			&migrations.OpDropView{Name: "user_ids"},
+		},
+	}
+
+	ops, err := migrations.Invert(m, before)
+	require.NoError(t, err)
+
+	assert.Equal(t, migrations.Operations{
+		&migrations.OpCreateView{Name: "user_ids", Definition: "SELECT id FROM users"},
+		&migrations.OpReplaceView{Name: "user_names", Definition: "SELECT id, name FROM users"},
+		&migrations.OpDropView{Name: "user_emails"},
+	}, ops)
+}
+
+func TestInvertPartitionOperations(t *testing.T) {
+	t.Parallel()
+
+	before := &schema.Schema{
+		Tables: map[string]*schema.Table{
+			"events": {
+				Name:         "events",
+				PartitionKey: &schema.PartitionKey{Strategy: "range", Columns: []string{"created_at"}},
+				Partitions: map[string]*schema.Partition{
+					"events_2024": {Name: "events_2024", Bound: "FOR VALUES FROM ('2024-01-01') TO ('2025-01-01')"},
+				},
+			},
+		},
+	}
+
+	m := &migrations.Migration{
+		Name: "02_rotate_partitions",
+		Operations: migrations.Operations{
+			&migrations.OpAttachPartition{Table: "events", Name: "events_2025", Bound: "FOR VALUES FROM ('2025-01-01') TO ('2026-01-01')"},
+			&migrations.OpDetachPartition{Table: "events", Name: "events_2024"},
+		},
+	}
+
+	ops, err := migrations.Invert(m, before)
+	require.NoError(t, err)
+
+	assert.Equal(t, migrations.Operations{
+		&migrations.OpAttachPartition{Table: "events", Name: "events_2024", Bound: "FOR VALUES FROM ('2024-01-01') TO ('2025-01-01')"},
+		&migrations.OpDetachPartition{Table: "events", Name: "events_2025"},
+	}, ops)
+}
+
+func TestInvertPolicyOperations(t *testing.T) {
+	t.Parallel()
+
+	before := &schema.Schema{
+		Tables: map[string]*schema.Table{
+			"documents": {
+				Name:       "documents",
+				RLSEnabled: true,
+				Policies: map[string]*schema.Policy{
+					"owner_read": {
+						Name:       "owner_read",
+						Command:    "SELECT",
+						Permissive: true,
+						Roles:      []string{"public"},
+						Using:      "(owner = CURRENT_USER)",
+						Columns:    []string{"owner"},
+					},
+					"owner_write": {
+						Name:       "owner_write",
+						Command:    "INSERT",
+						Permissive: false,
+						Roles:      []string{"app"},
+						WithCheck:  "(owner = CURRENT_USER)",
+						Columns:    []string{"owner"},
+					},
+				},
+			},
+		},
+	}
+
+	using := "owner = current_user OR shared"
+	m := &migrations.Migration{
+		Name: "02_change_policies",
+		Operations: migrations.Operations{
+			&migrations.OpCreatePolicy{Table: "documents", Name: "admin_all", Roles: []string{"admin"}, Using: "true"},
+			&migrations.OpAlterPolicy{Table: "documents", Name: "owner_read", Using: &using},
+			&migrations.OpDropPolicy{Table: "documents", Name: "owner_write"},
+		},
+	}
+
+	ops, err := migrations.Invert(m, before)
+	require.NoError(t, err)
+
+	beforeUsing := "(owner = CURRENT_USER)"
+	assert.Equal(t, migrations.Operations{
+		&migrations.OpCreatePolicy{
+			Table:     "documents",
+			Name:      "owner_write",
+			As:        migrations.OpCreatePolicyAsRESTRICTIVE,
+			Command:   migrations.OpCreatePolicyCommandINSERT,
+			Roles:     []string{"app"},
+			WithCheck: "(owner = CURRENT_USER)",
+		},
+		&migrations.OpAlterPolicy{Table: "documents", Name: "owner_read", Using: &beforeUsing},
+		&migrations.OpDropPolicy{Table: "documents", Name: "admin_all"},
+	}, ops)
+}
+
+func TestInvertFunctionAndTriggerOperations(t *testing.T) {
+	t.Parallel()
+
+	before := &schema.Schema{
+		Functions: map[string]*schema.Function{
+			"touch": {
+				Name:       "touch",
+				Returns:    "trigger",
+				Language:   "plpgsql",
+				Volatility: "VOLATILE",
+				Definition: "BEGIN NEW.updated_at := now(); RETURN NEW; END",
+			},
+			"full_name": {
+				Name:       "full_name",
+				Arguments:  "u users",
+				Returns:    "text",
+				Language:   "sql",
+				Volatility: "STABLE",
+				Definition: "SELECT u.first_name || ' ' || u.last_name",
+			},
+		},
+		Tables: map[string]*schema.Table{
+			"users": {
+				Name: "users",
+				Triggers: map[string]*schema.Trigger{
+					"users_touch": {
+						Name:       "users_touch",
+						Function:   "touch",
+						Timing:     "BEFORE",
+						Events:     []string{"INSERT", "UPDATE"},
+						ForEach:    "ROW",
+						Definition: "CREATE TRIGGER users_touch BEFORE INSERT OR UPDATE ON public.users FOR EACH ROW WHEN ((new.name IS NOT NULL)) EXECUTE FUNCTION touch()",
+					},
+				},
+			},
+		},
+	}
+
+	m := &migrations.Migration{
+		Name: "02_change_functions",
+		Operations: migrations.Operations{
+			&migrations.OpCreateFunction{Name: "audit", Returns: "trigger", Definition: "BEGIN RETURN NEW; END"},
+			&migrations.OpCreateTrigger{
+				Table:    "users",
+				Name:     "users_audit",
+				Function: "audit",
+				Timing:   migrations.OpCreateTriggerTimingAFTER,
+				Events:   []migrations.OpCreateTriggerEventsElem{migrations.OpCreateTriggerEventsElemINSERT},
+			},
+			&migrations.OpDropTrigger{Table: "users", Name: "users_touch"},
+			&migrations.OpReplaceFunction{Name: "full_name", Definition: "SELECT u.given_name || ' ' || u.family_name"},
+			&migrations.OpDropFunction{Name: "touch"},
+		},
+	}
+
+	ops, err := migrations.Invert(m, before)
+	require.NoError(t, err)
+
+	assert.Equal(t, migrations.Operations{
+		&migrations.OpCreateFunction{
+			Name:       "touch",
+			Returns:    "trigger",
+			Language:   "plpgsql",
+			Volatility: migrations.FunctionVolatilityVOLATILE,
+			Definition: "BEGIN NEW.updated_at := now(); RETURN NEW; END",
+		},
+		&migrations.OpReplaceFunction{
+			Name:       "full_name",
+			Language:   "sql",
+			Volatility: migrations.FunctionVolatilitySTABLE,
+			Definition: "SELECT u.first_name || ' ' || u.last_name",
+		},
+		&migrations.OpCreateTrigger{
+			Table:    "users",
+			Name:     "users_touch",
+			Function: "touch",
+			Timing:   migrations.OpCreateTriggerTimingBEFORE,
+			Events:   []migrations.OpCreateTriggerEventsElem{migrations.OpCreateTriggerEventsElemINSERT, migrations.OpCreateTriggerEventsElemUPDATE},
+			ForEach:  migrations.OpCreateTriggerForEachROW,
+			When:     "new.name IS NOT NULL",
+		},
+		&migrations.OpDropTrigger{Table: "users", Name: "users_audit"},
+		&migrations.OpDropFunction{Name: "audit"},
+	}, ops)
+}
+
+func TestInvertSequenceOperations(t *testing.T) {
+	t.Parallel()
+
+	before := &schema.Schema{
+		Sequences: map[string]*schema.Sequence{
+			"invoice_number": {
+				Name:      "invoice_number",
+				DataType:  "bigint",
+				Start:     1000,
+				Increment: 1,
+				MinValue:  1,
+				MaxValue:  9223372036854775807,
+				Cache:     1,
+			},
+		},
+	}
+
+	increment, cache := 10, 20
+	cycle := true
+
+	m := &migrations.Migration{
+		Name: "02_change_sequences",
+		Operations: migrations.Operations{
+			&migrations.OpCreateSequence{Name: "order_number"},
+			&migrations.OpAlterSequence{Name: "invoice_number", Increment: &increment, Cache: &cache, Cycle: &cycle},
+		},
+	}
+
+	ops, err := migrations.Invert(m, before)
+	require.NoError(t, err)
+
+	beforeIncrement, beforeCache := 1, 1
+	beforeCycle := false
+
+	assert.Equal(t, migrations.Operations{
+		&migrations.OpAlterSequence{Name: "invoice_number", Increment: &beforeIncrement, Cache: &beforeCache, Cycle: &beforeCycle},
+		&migrations.OpDropSequence{Name: "order_number"},
+	}, ops)
+}
+
+func TestInvertPrivilegeOperations(t *testing.T) {
+	t.Parallel()
+
+	before := &schema.Schema{
+		Tables: map[string]*schema.Table{
+			"orders": {
+				Name: "orders",
+				Privileges: []schema.Privilege{
+					{Grantee: "reader", Privilege: "SELECT"},
+					{Grantee: "writer", Privilege: "INSERT", Grantable: true},
+					{Grantee: "writer", Privilege: "SELECT"},
+					{Grantee: "writer", Privilege: "UPDATE"},
+				},
+			},
+		},
+	}
+
+	m := &migrations.Migration{
+		Name: "02_change_privileges",
+		Operations: migrations.Operations{
+			&migrations.OpGrant{Table: "orders", Role: "reader", Privileges: []migrations.TablePrivilege{"SELECT", "INSERT"}},
+			&migrations.OpRevoke{Table: "orders", Role: "writer", Privileges: []migrations.TablePrivilege{"ALL"}},
+		},
+	}
+
+	ops, err := migrations.Invert(m, before)
+	require.NoError(t, err)
+
+	assert.Equal(t, migrations.Operations{
+		&migrations.OpGrant{Table: "orders", Role: "writer", Privileges: []migrations.TablePrivilege{"SELECT", "UPDATE"}},
+		&migrations.OpGrant{Table: "orders", Role: "writer", Privileges: []migrations.TablePrivilege{"INSERT"}, WithGrantOption: true},
+		&migrations.OpRevoke{Table: "orders", Role: "reader", Privileges: []migrations.TablePrivilege{"INSERT"}},
+	}, ops)
+}
+
+func TestInvertRefusesIrreversibleOperations(t *testing.T) {
+	t.Parallel()
+
+	cycle, restart := true, 1
+
+	tests := map[string]migrations.Operation{
+		"drop table":                       &migrations.OpDropTable{Name: "users"},
+		"drop column without down":         &migrations.OpDropColumn{Table: "users", Column: "email"},
+		"raw SQL without down":             &migrations.OpRawSQL{Up: "CREATE TABLE foo (id int)"},
+		"add NOT NULL column":              &migrations.OpAddColumn{Table: "users", Column: migrations.Column{Name: "a", Type: "int"}, Up: "1"},
+		"set replica identity":             &migrations.OpSetReplicaIdentity{Table: "users", Identity: migrations.ReplicaIdentity{Type: "full"}},
+		"drop index with no definition":    &migrations.OpDropIndex{Name: "missing"},
+		"drop enum with no definition":     &migrations.OpDropEnum{Name: "missing"},
+		"drop enum value":                  &migrations.OpDropEnumValue{Enum: "mood", Value: "sad"},
+		"drop view with no definition":     &migrations.OpDropView{Name: "missing"},
+		"replace view with no definition":  &migrations.OpReplaceView{Name: "missing", Definition: "SELECT 1"},
+		"split column with no definition":  &migrations.OpSplitColumn{Table: "users", Column: "name"},
+		"merge columns with no definition": &migrations.OpMergeColumns{Table: "users", Columns: []string{"first_name", "last_name"}},
+		"partition table":                  &migrations.OpPartitionTable{Table: "users"},
+		"create partition":                 &migrations.OpCreatePartition{Table: "events", Name: "events_2025"},
+		"detach unrecorded partition":      &migrations.OpDetachPartition{Table: "events", Name: "events_2024"},
+		"enable row level security":        &migrations.OpEnableRLS{Table: "documents"},
+		"drop unrecorded policy":           &migrations.OpDropPolicy{Table: "documents", Name: "owner_read"},
+		"drop unrecorded function":         &migrations.OpDropFunction{Name: "touch"},
+		"replace unrecorded function":      &migrations.OpReplaceFunction{Name: "touch", Definition: "BEGIN RETURN NEW; END"},
+		"drop unrecorded trigger":          &migrations.OpDropTrigger{Table: "users", Name: "users_touch"},
+		"drop sequence":                    &migrations.OpDropSequence{Name: "order_number"},
+		"alter unrecorded sequence":        &migrations.OpAlterSequence{Name: "order_number", Cycle: &cycle},
+		"restart sequence":                 &migrations.OpAlterSequence{Name: "order_number", Restart: &restart},
+		"convert to identity":              &migrations.OpConvertToIdentity{Table: "orders", Column: "id"},
+		"move table":                       &migrations.OpMoveTable{Table: "orders", Schema: "sales"},
+		"revoke on unrecorded table":       &migrations.OpRevoke{Table: "orders", Role: "reader", Privileges: []migrations.TablePrivilege{"SELECT"}},
+	}
+
+	for name, op := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := migrations.Invert(&migrations.Migration{Operations: migrations.Operations{op}}, schema.New())
+			assert.ErrorAs(t, err, &migrations.OperationNotInvertibleError{})
+		})
+	}
+}
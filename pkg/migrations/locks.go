@@ -0,0 +1,249 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"fmt"
+	"strings"
+
+	pgq "github.com/xataio/pg_query_go/v6"
+)
+
+// LockLevel is a Postgres table-level lock mode.
+type LockLevel string
+
+const (
+	LockLevelAccessExclusive      LockLevel = "ACCESS EXCLUSIVE"
+	LockLevelExclusive            LockLevel = "EXCLUSIVE"
+	LockLevelShareRowExclusive    LockLevel = "SHARE ROW EXCLUSIVE"
+	LockLevelShare                LockLevel = "SHARE"
+	LockLevelShareUpdateExclusive LockLevel = "SHARE UPDATE EXCLUSIVE"
+	LockLevelRowExclusive         LockLevel = "ROW EXCLUSIVE"
+	LockLevelRowShare             LockLevel = "ROW SHARE"
+	LockLevelAccessShare          LockLevel = "ACCESS SHARE"
+
+	// LockLevelUnknown is used when the lock taken by a statement can not be
+	// determined, for example for raw SQL that could not be analyzed.
+	LockLevelUnknown LockLevel = "UNKNOWN"
+)
+
+// BlocksWrites returns true if the lock level conflicts with the ROW EXCLUSIVE
+// lock taken by INSERT, UPDATE and DELETE statements.
+func (l LockLevel) BlocksWrites() bool {
+	switch l {
+	case LockLevelShareUpdateExclusive, LockLevelRowExclusive, LockLevelRowShare, LockLevelAccessShare:
+		return false
+	}
+	return true
+}
+
+// LockImpact describes a lock that a DBAction takes on a relation while it
+// runs.
+type LockImpact struct {
+	// The lock mode taken on the relation
+	Level LockLevel `json:"level"`
+
+	// The name of the relation that is locked. Empty if the relation is unknown.
+	Relation string `json:"relation,omitempty"`
+
+	// Whether the statement can rewrite the whole table while holding the lock
+	RewritesTable bool `json:"rewrites_table,omitempty"`
+
+	// Whether the statement can scan the whole table while holding the lock
+	ScansTable bool `json:"scans_table,omitempty"`
+
+	// Whether the statement only changes the system catalogs, so that the lock
+	// is only held briefly
+	MetadataOnly bool `json:"metadata_only,omitempty"`
+}
+
+func (l LockImpact) String() string {
+	var sb strings.Builder
+	sb.WriteString(string(l.Level))
+	if l.Relation != "" {
+		fmt.Fprintf(&sb, " on %s", l.Relation)
+	}
+	switch {
+	case l.RewritesTable:
+		sb.WriteString(" (rewrites table)")
+	case l.ScansTable:
+		sb.WriteString(" (scans table)")
+	case l.MetadataOnly:
+		sb.WriteString(" (metadata only)")
+	}
+	return sb.String()
+}
+
+func accessExclusiveLock(relation string) LockImpact {
+	return LockImpact{Level: LockLevelAccessExclusive, Relation: relation}
+}
+
+// analyzeRawSQLLocks returns the locks taken by the statements in `sql`. Only
+// the most common DDL statements are recognized; for any other statement a
+// lock of unknown level is reported.
+func analyzeRawSQLLocks(sql string) []LockImpact {
+	tree, err := pgq.Parse(sql)
+	if err != nil {
+		return []LockImpact{{Level: LockLevelUnknown}}
+	}
+
+	var locks []LockImpact
+	for _, stmt := range tree.GetStmts() {
+		locks = append(locks, statementLocks(stmt.GetStmt())...)
+	}
+	return locks
+}
+
+func statementLocks(node *pgq.Node) []LockImpact {
+	switch stmt := node.GetNode().(type) {
+	case *pgq.Node_CreateStmt:
+		return []LockImpact{accessExclusiveLock(rangeVarName(stmt.CreateStmt.GetRelation()))}
+
+	case *pgq.Node_AlterTableStmt:
+		return alterTableLocks(stmt.AlterTableStmt)
+
+	case *pgq.Node_IndexStmt:
+		level := LockLevelShare
+		if stmt.IndexStmt.GetConcurrent() {
+			level = LockLevelShareUpdateExclusive
+		}
+		return []LockImpact{{
+			Level:      level,
+			Relation:   rangeVarName(stmt.IndexStmt.GetRelation()),
+			ScansTable: true,
+		}}
+
+	case *pgq.Node_RenameStmt:
+		return []LockImpact{accessExclusiveLock(rangeVarName(stmt.RenameStmt.GetRelation()))}
+
+	case *pgq.Node_DropStmt:
+		return dropLocks(stmt.DropStmt)
+
+	case *pgq.Node_CommentStmt:
+		return []LockImpact{{Level: LockLevelShareUpdateExclusive}}
+
+	case *pgq.Node_InsertStmt:
+		return []LockImpact{{Level: LockLevelRowExclusive, Relation: rangeVarName(stmt.InsertStmt.GetRelation())}}
+
+	case *pgq.Node_UpdateStmt:
+		return []LockImpact{{Level: LockLevelRowExclusive, Relation: rangeVarName(stmt.UpdateStmt.GetRelation()), ScansTable: true}}
+
+	case *pgq.Node_DeleteStmt:
+		return []LockImpact{{Level: LockLevelRowExclusive, Relation: rangeVarName(stmt.DeleteStmt.GetRelation()), ScansTable: true}}
+	}
+
+	return []LockImpact{{Level: LockLevelUnknown}}
+}
+
+func alterTableLocks(stmt *pgq.AlterTableStmt) []LockImpact {
+	lock := LockImpact{
+		Level:    LockLevelShareUpdateExclusive,
+		Relation: rangeVarName(stmt.GetRelation()),
+	}
+
+	for _, cmd := range stmt.GetCmds() {
+		cmd := cmd.GetAlterTableCmd()
+		if cmd == nil {
+			continue
+		}
+
+		level := LockLevelAccessExclusive
+		switch cmd.GetSubtype() {
+		case pgq.AlterTableType_AT_AlterColumnType:
+			lock.RewritesTable = true
+
+		case pgq.AlterTableType_AT_SetNotNull:
+			lock.ScansTable = true
+
+		case pgq.AlterTableType_AT_AddColumn:
+			for _, c := range cmd.GetDef().GetColumnDef().GetConstraints() {
+				switch c.GetConstraint().GetContype() {
+				case pgq.ConstrType_CONSTR_GENERATED, pgq.ConstrType_CONSTR_IDENTITY:
+					lock.RewritesTable = true
+				}
+			}
+
+		case pgq.AlterTableType_AT_AddConstraint:
+			c := cmd.GetDef().GetConstraint()
+			if c.GetContype() == pgq.ConstrType_CONSTR_FOREIGN {
+				level = LockLevelShareRowExclusive
+			}
+			if !c.GetSkipValidation() {
+				lock.ScansTable = true
+			}
+
+		case pgq.AlterTableType_AT_ValidateConstraint:
+			level = LockLevelShareUpdateExclusive
+			lock.ScansTable = true
+		}
+
+		if lockStrength(level) > lockStrength(lock.Level) {
+			lock.Level = level
+		}
+	}
+
+	return []LockImpact{lock}
+}
+
+func dropLocks(stmt *pgq.DropStmt) []LockImpact {
+	var locks []LockImpact
+	switch stmt.GetRemoveType() {
+	case pgq.ObjectType_OBJECT_TABLE:
+		for _, obj := range stmt.GetObjects() {
+			locks = append(locks, accessExclusiveLock(objectName(obj)))
+		}
+	case pgq.ObjectType_OBJECT_INDEX:
+		level := LockLevelAccessExclusive
+		if stmt.GetConcurrent() {
+			level = LockLevelShareUpdateExclusive
+		}
+		for _, obj := range stmt.GetObjects() {
+			locks = append(locks, LockImpact{Level: level, Relation: objectName(obj)})
+		}
+	default:
+		locks = append(locks, LockImpact{Level: LockLevelUnknown})
+	}
+	return locks
+}
+
+// lockStrength orders lock levels from weakest to strongest.
+func lockStrength(l LockLevel) int {
+	switch l {
+	case LockLevelAccessShare:
+		return 1
+	case LockLevelRowShare:
+		return 2
+	case LockLevelRowExclusive:
+		return 3
+	case LockLevelShareUpdateExclusive:
+		return 4
+	case LockLevelShare:
+		return 5
+	case LockLevelShareRowExclusive:
+		return 6
+	case LockLevelExclusive:
+		return 7
+	case LockLevelAccessExclusive:
+		return 8
+	}
+	return 0
+}
+
+func rangeVarName(rv *pgq.RangeVar) string {
+	if rv == nil {
+		return ""
+	}
+	if rv.GetSchemaname() != "" {
+		return rv.GetSchemaname() + "." + rv.GetRelname()
+	}
+	return rv.GetRelname()
+}
+
+func objectName(node *pgq.Node) string {
+	items := node.GetList().GetItems()
+	parts := make([]string, 0, len(items))
+	for _, item := range items {
+		parts = append(parts, item.GetString_().GetSval())
+	}
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,129 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRawSQLLocks(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		sql      string
+		expected []LockImpact
+	}{
+		"change column type rewrites the table": {
+			sql: "ALTER TABLE users ALTER COLUMN age TYPE bigint",
+			expected: []LockImpact{
+				{Level: LockLevelAccessExclusive, Relation: "users", RewritesTable: true},
+			},
+		},
+		"set not null scans the table": {
+			sql: "ALTER TABLE users ALTER COLUMN name SET NOT NULL",
+			expected: []LockImpact{
+				{Level: LockLevelAccessExclusive, Relation: "users", ScansTable: true},
+			},
+		},
+		"add not valid foreign key": {
+			sql: "ALTER TABLE public.orders ADD CONSTRAINT fk FOREIGN KEY (user_id) REFERENCES users(id) NOT VALID",
+			expected: []LockImpact{
+				{Level: LockLevelShareRowExclusive, Relation: "public.orders"},
+			},
+		},
+		"validate constraint": {
+			sql: "ALTER TABLE users VALIDATE CONSTRAINT c",
+			expected: []LockImpact{
+				{Level: LockLevelShareUpdateExclusive, Relation: "users", ScansTable: true},
+			},
+		},
+		"create index concurrently": {
+			sql: "CREATE INDEX CONCURRENTLY idx ON users(name)",
+			expected: []LockImpact{
+				{Level: LockLevelShareUpdateExclusive, Relation: "users", ScansTable: true},
+			},
+		},
+		"create index": {
+			sql: "CREATE INDEX idx ON users(name)",
+			expected: []LockImpact{
+				{Level: LockLevelShare, Relation: "users", ScansTable: true},
+			},
+		},
+		"multiple statements": {
+			sql: "DROP TABLE a, b; UPDATE c SET x = 1",
+			expected: []LockImpact{
+				{Level: LockLevelAccessExclusive, Relation: "a"},
+				{Level: LockLevelAccessExclusive, Relation: "b"},
+				{Level: LockLevelRowExclusive, Relation: "c", ScansTable: true},
+			},
+		},
+		"unrecognized statement": {
+			sql: "CREATE EXTENSION pg_trgm",
+			expected: []LockImpact{
+				{Level: LockLevelUnknown},
+			},
+		},
+		"invalid SQL": {
+			sql: "NOT SQL",
+			expected: []LockImpact{
+				{Level: LockLevelUnknown},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, analyzeRawSQLLocks(tc.sql))
+		})
+	}
+}
+
+func TestActionLocks(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		action   DBAction
+		expected []LockImpact
+	}{
+		"set not null only changes the catalog": {
+			action: NewSetNotNullAction(nil, "users", "name"),
+			expected: []LockImpact{
+				{Level: LockLevelAccessExclusive, Relation: "users", MetadataOnly: true},
+			},
+		},
+		"add column only changes the catalog": {
+			action: NewAddColumnAction(nil, "users", Column{Name: "age", Type: "integer"}, false),
+			expected: []LockImpact{
+				{Level: LockLevelAccessExclusive, Relation: "users", MetadataOnly: true},
+			},
+		},
+		"add identity column rewrites the table": {
+			action: NewAddColumnAction(nil, "users", Column{
+				Name:      "id",
+				Type:      "bigint",
+				Generated: &ColumnGenerated{Identity: &ColumnGeneratedIdentity{}},
+			}, false),
+			expected: []LockImpact{
+				{Level: LockLevelAccessExclusive, Relation: "users", RewritesTable: true},
+			},
+		},
+		"add stored generated column rewrites the table": {
+			action: NewAddColumnAction(nil, "users", Column{
+				Name:      "full_name",
+				Type:      "text",
+				Generated: &ColumnGenerated{Expression: "first_name || ' ' || last_name"},
+			}, false),
+			expected: []LockImpact{
+				{Level: LockLevelAccessExclusive, Relation: "users", RewritesTable: true},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.action.Locks())
+		})
+	}
+}
@@ -254,6 +254,186 @@ func (l migrationLogger) extractOpArgs(op Operation) []any {
 			"table", o.Table,
 			"unique", true,
 		}
+	case *OpCreateEnum:
+		return []any{
+			"operation", OpNameCreateEnum,
+			"name", o.Name,
+			"values", o.Values,
+		}
+	case *OpAddEnumValue:
+		return []any{
+			"operation", OpNameAddEnumValue,
+			"enum", o.Enum,
+			"value", o.Value,
+		}
+	case *OpRenameEnumValue:
+		return []any{
+			"operation", OpNameRenameEnumValue,
+			"enum", o.Enum,
+			"from", o.From,
+			"to", o.To,
+		}
+	case *OpDropEnumValue:
+		return []any{
+			"operation", OpNameDropEnumValue,
+			"enum", o.Enum,
+			"value", o.Value,
+		}
+	case *OpDropEnum:
+		return []any{
+			"operation", OpNameDropEnum,
+			"name", o.Name,
+		}
+	case *OpCreateView:
+		return []any{
+			"operation", OpNameCreateView,
+			"name", o.Name,
+		}
+	case *OpReplaceView:
+		return []any{
+			"operation", OpNameReplaceView,
+			"name", o.Name,
+		}
+	case *OpDropView:
+		return []any{
+			"operation", OpNameDropView,
+			"name", o.Name,
+		}
+	case *OpSplitColumn:
+		return []any{
+			"operation", OpNameSplitColumn,
+			"table", o.Table,
+			"column", o.Column,
+			"columns", getColumnNames(o.Columns),
+		}
+	case *OpMergeColumns:
+		return []any{
+			"operation", OpNameMergeColumns,
+			"table", o.Table,
+			"columns", o.Columns,
+			"column", o.Column.Name,
+		}
+	case *OpPartitionTable:
+		return []any{
+			"operation", OpNamePartitionTable,
+			"table", o.Table,
+			"strategy", o.PartitionBy.Strategy,
+			"columns", o.PartitionBy.Columns,
+		}
+	case *OpCreatePartition:
+		return []any{
+			"operation", OpNameCreatePartition,
+			"table", o.Table,
+			"name", o.Name,
+			"bound", o.Bound,
+		}
+	case *OpAttachPartition:
+		return []any{
+			"operation", OpNameAttachPartition,
+			"table", o.Table,
+			"name", o.Name,
+			"bound", o.Bound,
+		}
+	case *OpDetachPartition:
+		return []any{
+			"operation", OpNameDetachPartition,
+			"table", o.Table,
+			"name", o.Name,
+		}
+	case *OpEnableRLS:
+		return []any{
+			"operation", OpNameEnableRLS,
+			"table", o.Table,
+			"force", o.Force,
+		}
+	case *OpCreatePolicy:
+		return []any{
+			"operation", OpNameCreatePolicy,
+			"table", o.Table,
+			"name", o.Name,
+			"command", o.Command,
+		}
+	case *OpAlterPolicy:
+		return []any{
+			"operation", OpNameAlterPolicy,
+			"table", o.Table,
+			"name", o.Name,
+		}
+	case *OpDropPolicy:
+		return []any{
+			"operation", OpNameDropPolicy,
+			"table", o.Table,
+			"name", o.Name,
+		}
+	case *OpCreateFunction:
+		return []any{
+			"operation", OpNameCreateFunction,
+			"name", o.Name,
+		}
+	case *OpReplaceFunction:
+		return []any{
+			"operation", OpNameReplaceFunction,
+			"name", o.Name,
+		}
+	case *OpDropFunction:
+		return []any{
+			"operation", OpNameDropFunction,
+			"name", o.Name,
+		}
+	case *OpCreateTrigger:
+		return []any{
+			"operation", OpNameCreateTrigger,
+			"table", o.Table,
+			"name", o.Name,
+			"function", o.Function,
+		}
+	case *OpDropTrigger:
+		return []any{
+			"operation", OpNameDropTrigger,
+			"table", o.Table,
+			"name", o.Name,
+		}
+	case *OpCreateSequence:
+		return []any{
+			"operation", OpNameCreateSequence,
+			"name", o.Name,
+		}
+	case *OpAlterSequence:
+		return []any{
+			"operation", OpNameAlterSequence,
+			"name", o.Name,
+		}
+	case *OpDropSequence:
+		return []any{
+			"operation", OpNameDropSequence,
+			"name", o.Name,
+		}
+	case *OpConvertToIdentity:
+		return []any{
+			"operation", OpNameConvertToIdentity,
+			"table", o.Table,
+			"column", o.Column,
+		}
+	case *OpMoveTable:
+		return []any{
+			"operation", OpNameMoveTable,
+			"table", o.Table,
+			"schema", o.Schema,
+		}
+	case *OpGrant:
+		return []any{
+			"operation", OpNameGrant,
+			"table", o.Table,
+			"role", o.Role,
+			"privileges", o.Privileges,
+		}
+	case *OpRevoke:
+		return []any{
+			"operation", OpNameRevoke,
+			"table", o.Table,
+			"role", o.Role,
+			"privileges", o.Privileges,
+		}
 	default:
 		return []any{}
 	}
@@ -6,6 +6,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
 
 	_ "github.com/lib/pq"
 
@@ -66,16 +67,26 @@ type RequiresSchemaRefreshOperation interface {
 	RequiresSchemaRefresh()
 }
 
+// CrossSchemaOperation is an operation that changes schemas other than the
+// one the migration is applied to.
+type CrossSchemaOperation interface {
+	// TargetSchemas returns the names of the other schemas changed by the
+	// operation.
+	TargetSchemas() []string
+}
+
 type (
 	Operations []Operation
 	Migration  struct {
 		Name          string     `json:"-"`
 		VersionSchema string     `json:"version_schema,omitempty"`
+		Baseline      bool       `json:"baseline,omitempty"`
 		Operations    Operations `json:"operations"`
 	}
 	RawMigration struct {
 		Name          string          `json:"-"`
 		VersionSchema string          `json:"version_schema,omitempty"`
+		Baseline      bool            `json:"baseline,omitempty"`
 		Operations    json.RawMessage `json:"operations"`
 	}
 
@@ -94,6 +105,25 @@ func (m *Migration) VersionSchemaName() string {
 	return m.Name
 }
 
+// TargetSchemas returns the names of the schemas, other than `schemaName`,
+// that are changed by the migration's operations, in the order in which they
+// are first referenced.
+func (m *Migration) TargetSchemas(schemaName string) []string {
+	var schemas []string
+	for _, op := range m.Operations {
+		crossSchemaOp, ok := op.(CrossSchemaOperation)
+		if !ok {
+			continue
+		}
+		for _, target := range crossSchemaOp.TargetSchemas() {
+			if target != schemaName && !slices.Contains(schemas, target) {
+				schemas = append(schemas, target)
+			}
+		}
+	}
+	return schemas
+}
+
 // Validate will check that the migration can be applied to the given schema
 // returns a descriptive error if the migration is invalid
 func (m *Migration) Validate(ctx context.Context, s *schema.Schema) error {
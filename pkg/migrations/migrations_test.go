@@ -64,6 +64,24 @@ func TestOnCompleteSQLMigrationsAreNotIsolated(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestMigrationTargetSchemas(t *testing.T) {
+	t.Parallel()
+
+	migration := migrations.Migration{
+		Name: "move_tables",
+		Operations: migrations.Operations{
+			&migrations.OpMoveTable{Table: "orders", Schema: "sales"},
+			&migrations.OpCreateTable{Name: "foo"},
+			&migrations.OpMoveTable{Table: "products", Schema: "inventory"},
+			&migrations.OpMoveTable{Table: "invoices", Schema: "sales"},
+		},
+	}
+
+	assert.Equal(t, []string{"sales", "inventory"}, migration.TargetSchemas("public"))
+	assert.Equal(t, []string{"inventory"}, migration.TargetSchemas("sales"))
+	assert.Empty(t, (&migrations.Migration{Name: "foo"}).TargetSchemas("public"))
+}
+
 func TestCollectFilesFromDir(t *testing.T) {
 	t.Parallel()
 
@@ -133,6 +133,9 @@ func toSchemaColumn(c Column) *schema.Column {
 	if c.Comment != nil {
 		tmpColumn.Comment = *c.Comment
 	}
+	if c.Generated != nil && c.Generated.Identity != nil {
+		tmpColumn.Identity = string(c.Generated.Identity.UserSpecifiedValues)
+	}
 	return tmpColumn
 }
 
@@ -0,0 +1,73 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"context"
+	"slices"
+
+	"github.com/xataio/pgroll/pkg/db"
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+var (
+	_ Operation  = (*OpAddEnumValue)(nil)
+	_ Createable = (*OpAddEnumValue)(nil)
+)
+
+func (o *OpAddEnumValue) Start(ctx context.Context, l Logger, conn db.DB, s *schema.Schema) (*StartResult, error) {
+	l.LogOperationStart(o)
+
+	// Add the value to the in-memory schema representation
+	if enum := s.GetEnum(o.Enum); enum != nil && !slices.Contains(enum.Values, o.Value) {
+		idx := len(enum.Values)
+		switch {
+		case o.Before != "":
+			idx = max(slices.Index(enum.Values, o.Before), 0)
+		case o.After != "":
+			idx = slices.Index(enum.Values, o.After) + 1
+		}
+		enum.Values = slices.Insert(slices.Clone(enum.Values), idx, o.Value)
+	}
+
+	return &StartResult{Actions: []DBAction{
+		NewAddEnumValueAction(conn, o.Enum, o.Value, o.Before, o.After),
+	}}, nil
+}
+
+func (o *OpAddEnumValue) Complete(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationComplete(o)
+
+	// No-op
+	return nil, nil
+}
+
+// Rollback leaves the value in the enum: Postgres can not remove a value
+// from an enum type. The old version of the schema does not write the value.
+func (o *OpAddEnumValue) Rollback(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationRollback(o)
+
+	// No-op
+	return nil, nil
+}
+
+func (o *OpAddEnumValue) Validate(ctx context.Context, s *schema.Schema) error {
+	enum := s.GetEnum(o.Enum)
+	if enum == nil {
+		return EnumDoesNotExistError{Name: o.Enum}
+	}
+
+	if o.Before != "" && o.After != "" {
+		return InvalidMigrationError{Reason: "only one of before and after can be set"}
+	}
+
+	// The value may already exist if the migration was rolled back before; the
+	// value is only added if it does not exist.
+	for _, neighbour := range []string{o.Before, o.After} {
+		if neighbour != "" && !slices.Contains(enum.Values, neighbour) {
+			return EnumValueDoesNotExistError{Enum: o.Enum, Value: neighbour}
+		}
+	}
+
+	return nil
+}
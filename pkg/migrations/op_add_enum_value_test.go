@@ -0,0 +1,141 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/xataio/pgroll/pkg/migrations"
+)
+
+func TestAddEnumValue(t *testing.T) {
+	t.Parallel()
+
+	createEnumMigration := migrations.Migration{
+		Name:          "01_create_enum",
+		VersionSchema: "create_enum",
+		Operations: migrations.Operations{
+			&migrations.OpCreateEnum{
+				Name:   "mood",
+				Values: []string{"happy", "sad"},
+			},
+			&migrations.OpCreateTable{
+				Name: "people",
+				Columns: []migrations.Column{
+					{Name: "id", Type: "serial", Pk: true},
+					{Name: "mood", Type: "mood", Nullable: true},
+				},
+			},
+		},
+	}
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "add enum value",
+			migrations: []migrations.Migration{
+				createEnumMigration,
+				{
+					Name:          "02_add_enum_value",
+					VersionSchema: "add_enum_value",
+					Operations: migrations.Operations{
+						&migrations.OpAddEnumValue{
+							Enum:  "mood",
+							Value: "ok",
+							After: "happy",
+						},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB, schema string) {
+				// The value has been added in the requested position
+				EnumMustHaveValues(t, db, schema, "mood", []string{"happy", "ok", "sad"})
+
+				// The new value can be written through the new version of the schema
+				MustInsert(t, db, schema, "add_enum_value", "people", map[string]string{
+					"mood": "ok",
+				})
+			},
+			afterRollback: func(t *testing.T, db *sql.DB, schema string) {
+				// The value can not be removed from the enum, so it remains
+				EnumMustHaveValues(t, db, schema, "mood", []string{"happy", "ok", "sad"})
+			},
+			afterComplete: func(t *testing.T, db *sql.DB, schema string) {
+				EnumMustHaveValues(t, db, schema, "mood", []string{"happy", "ok", "sad"})
+
+				MustInsert(t, db, schema, "add_enum_value", "people", map[string]string{
+					"mood": "ok",
+				})
+			},
+		},
+		{
+			name: "add enum value that already exists",
+			migrations: []migrations.Migration{
+				createEnumMigration,
+				{
+					Name:          "02_add_enum_value",
+					VersionSchema: "add_enum_value",
+					Operations: migrations.Operations{
+						&migrations.OpAddEnumValue{
+							Enum:  "mood",
+							Value: "sad",
+						},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB, schema string) {
+				// The operation is a no-op
+				EnumMustHaveValues(t, db, schema, "mood", []string{"happy", "sad"})
+			},
+			afterRollback: func(t *testing.T, db *sql.DB, schema string) {
+				EnumMustHaveValues(t, db, schema, "mood", []string{"happy", "sad"})
+			},
+			afterComplete: func(t *testing.T, db *sql.DB, schema string) {
+				EnumMustHaveValues(t, db, schema, "mood", []string{"happy", "sad"})
+			},
+		},
+	})
+}
+
+func TestAddEnumValueValidation(t *testing.T) {
+	t.Parallel()
+
+	createEnumMigration := migrations.Migration{
+		Name: "01_create_enum",
+		Operations: migrations.Operations{
+			&migrations.OpCreateEnum{
+				Name:   "mood",
+				Values: []string{"happy", "sad"},
+			},
+		},
+	}
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "enum must exist",
+			migrations: []migrations.Migration{
+				createEnumMigration,
+				{
+					Name: "02_add_enum_value",
+					Operations: migrations.Operations{
+						&migrations.OpAddEnumValue{Enum: "color", Value: "red"},
+					},
+				},
+			},
+			wantStartErr: migrations.EnumDoesNotExistError{Name: "color"},
+		},
+		{
+			name: "neighbouring value must exist",
+			migrations: []migrations.Migration{
+				createEnumMigration,
+				{
+					Name: "02_add_enum_value",
+					Operations: migrations.Operations{
+						&migrations.OpAddEnumValue{Enum: "mood", Value: "ok", Before: "angry"},
+					},
+				},
+			},
+			wantStartErr: migrations.EnumValueDoesNotExistError{Enum: "mood", Value: "angry"},
+		},
+	})
+}
@@ -691,6 +691,54 @@ func TestAlterPrimaryKeyColumns(t *testing.T) {
 				})
 			},
 		},
+		{
+			name: "alter an identity primary key column",
+			migrations: []migrations.Migration{
+				{
+					Name: "01_create_table",
+					Operations: migrations.Operations{
+						&migrations.OpRawSQL{
+							Up: "CREATE TABLE people (id integer GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY, name text)",
+						},
+					},
+				},
+				{
+					Name: "02_alter_column",
+					Operations: migrations.Operations{
+						&migrations.OpAlterColumn{
+							Table:  "people",
+							Column: "id",
+							Type:   ptr("bigint"),
+							Up:     "CAST(id AS bigint)",
+							Down:   "CAST(id AS integer)",
+						},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB, schema string) {
+				// Both versions of the table generate ids
+				MustInsert(t, db, schema, "01_create_table", "people", map[string]string{
+					"name": "alice",
+				})
+				MustInsert(t, db, schema, "02_alter_column", "people", map[string]string{
+					"name": "bob",
+				})
+				assert.Len(t, MustSelect(t, db, schema, "02_alter_column", "people"), 2)
+			},
+			afterRollback: func(t *testing.T, db *sql.DB, schema string) {
+				ColumnMustBeIdentity(t, db, schema, "people", "id", "BY DEFAULT")
+			},
+			afterComplete: func(t *testing.T, db *sql.DB, schema string) {
+				// The new column has become the identity column
+				ColumnMustBeIdentity(t, db, schema, "people", "id", "BY DEFAULT")
+				ColumnMustBePK(t, db, schema, "people", "id")
+
+				MustInsert(t, db, schema, "02_alter_column", "people", map[string]string{
+					"name": "carol",
+				})
+				assert.Len(t, MustSelect(t, db, schema, "02_alter_column", "people"), 3)
+			},
+		},
 		{
 			name: "alter a composite primary key: one column changes type, the other is unchanged",
 			migrations: []migrations.Migration{
@@ -0,0 +1,96 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"context"
+	"maps"
+	"slices"
+
+	"github.com/xataio/pgroll/pkg/db"
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+var (
+	_ Operation  = (*OpAlterPolicy)(nil)
+	_ Createable = (*OpAlterPolicy)(nil)
+)
+
+// Start changes the policy in the virtual schema only. The policy applies to
+// both versions of the schema, so it is altered on completion.
+func (o *OpAlterPolicy) Start(ctx context.Context, l Logger, conn db.DB, s *schema.Schema) (*StartResult, error) {
+	l.LogOperationStart(o)
+
+	table := s.GetTable(o.Table)
+	if table == nil {
+		return nil, TableDoesNotExistError{Name: o.Table}
+	}
+	o.updatePolicy(table)
+
+	return &StartResult{}, nil
+}
+
+func (o *OpAlterPolicy) Complete(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationComplete(o)
+
+	// Keep the policy up to date, so that later operations rewriting the
+	// policy see the changes
+	if table := s.GetTable(o.Table); table != nil {
+		o.updatePolicy(table)
+	}
+
+	return []DBAction{
+		NewAlterPolicyAction(conn, o.Table, o.Name, o.Roles, o.Using, o.WithCheck),
+	}, nil
+}
+
+func (o *OpAlterPolicy) Rollback(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationRollback(o)
+
+	// No-op
+	return nil, nil
+}
+
+func (o *OpAlterPolicy) Validate(ctx context.Context, s *schema.Schema) error {
+	table := s.GetTable(o.Table)
+	if table == nil {
+		return TableDoesNotExistError{Name: o.Table}
+	}
+
+	policy, ok := table.Policies[o.Name]
+	if !ok {
+		return PolicyDoesNotExistError{Table: o.Table, Name: o.Name}
+	}
+
+	if len(o.Roles) == 0 && o.Using == nil && o.WithCheck == nil {
+		return AlterPolicyNoChangesError{Table: o.Table, Name: o.Name}
+	}
+
+	command := OpCreatePolicyCommand(policy.Command)
+	if err := validatePolicyExpressions(o.Name, command, o.Using != nil, o.WithCheck != nil); err != nil {
+		return err
+	}
+
+	o.updatePolicy(table)
+	return nil
+}
+
+// updatePolicy applies the changes to the policy in the schema representation
+// of `table`
+func (o *OpAlterPolicy) updatePolicy(table *schema.Table) {
+	policy, ok := table.Policies[o.Name]
+	if !ok {
+		return
+	}
+
+	if len(o.Roles) > 0 {
+		policy.Roles = slices.Clone(o.Roles)
+	}
+	if o.Using != nil {
+		policy.Using = *o.Using
+	}
+	if o.WithCheck != nil {
+		policy.WithCheck = *o.WithCheck
+	}
+	policy.Columns = referencedColumns(slices.Collect(maps.Keys(table.Columns)), policy.Using, policy.WithCheck)
+}
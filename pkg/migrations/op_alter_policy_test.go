@@ -0,0 +1,99 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/xataio/pgroll/pkg/migrations"
+)
+
+func TestAlterPolicy(t *testing.T) {
+	t.Parallel()
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "alter policy expression",
+			migrations: []migrations.Migration{
+				createDocumentsTableMigration,
+				{
+					Name: "02_create_policy",
+					Operations: migrations.Operations{
+						&migrations.OpCreatePolicy{
+							Table: "documents",
+							Name:  "owner_all",
+							Using: "owner = current_user",
+						},
+					},
+				},
+				{
+					Name: "03_alter_policy",
+					Operations: migrations.Operations{
+						&migrations.OpAlterPolicy{
+							Table: "documents",
+							Name:  "owner_all",
+							Roles: []string{"public"},
+							Using: ptr("owner = current_user OR is_public"),
+						},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB, schema string) {
+				// The policy is altered on completion
+				PolicyMustReferenceColumns(t, db, schema, "documents", "owner_all", "owner")
+			},
+			afterRollback: func(t *testing.T, db *sql.DB, schema string) {
+				PolicyMustReferenceColumns(t, db, schema, "documents", "owner_all", "owner")
+			},
+			afterComplete: func(t *testing.T, db *sql.DB, schema string) {
+				PolicyMustReferenceColumns(t, db, schema, "documents", "owner_all", "is_public", "owner")
+			},
+		},
+	})
+}
+
+func TestAlterPolicyValidation(t *testing.T) {
+	t.Parallel()
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "policy must exist",
+			migrations: []migrations.Migration{
+				createDocumentsTableMigration,
+				{
+					Name: "02_alter_policy",
+					Operations: migrations.Operations{
+						&migrations.OpAlterPolicy{
+							Table: "documents",
+							Name:  "doesntexist",
+							Using: ptr("true"),
+						},
+					},
+				},
+			},
+			wantStartErr: migrations.PolicyDoesNotExistError{Table: "documents", Name: "doesntexist"},
+		},
+		{
+			name: "at least one change is required",
+			migrations: []migrations.Migration{
+				createDocumentsTableMigration,
+				{
+					Name: "02_alter_policy",
+					Operations: migrations.Operations{
+						&migrations.OpCreatePolicy{
+							Table: "documents",
+							Name:  "owner_all",
+							Using: "owner = current_user",
+						},
+						&migrations.OpAlterPolicy{
+							Table: "documents",
+							Name:  "owner_all",
+						},
+					},
+				},
+			},
+			wantStartErr: migrations.AlterPolicyNoChangesError{Table: "documents", Name: "owner_all"},
+		},
+	})
+}
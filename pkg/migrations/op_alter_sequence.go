@@ -0,0 +1,77 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"context"
+
+	"github.com/xataio/pgroll/pkg/db"
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+var (
+	_ Operation  = (*OpAlterSequence)(nil)
+	_ Createable = (*OpAlterSequence)(nil)
+)
+
+func (o *OpAlterSequence) Start(ctx context.Context, l Logger, conn db.DB, s *schema.Schema) (*StartResult, error) {
+	l.LogOperationStart(o)
+
+	// The sequence is shared by both versions of the schema, so it is altered
+	// on completion. Until then only the in-memory schema representation is
+	// updated.
+	seq := s.GetSequence(o.Name)
+	if seq == nil {
+		return nil, SequenceDoesNotExistError{Name: o.Name}
+	}
+	o.options().apply(seq)
+
+	return &StartResult{}, nil
+}
+
+func (o *OpAlterSequence) Complete(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationComplete(o)
+
+	return []DBAction{NewAlterSequenceAction(conn, o.Name, o.options())}, nil
+}
+
+func (o *OpAlterSequence) Rollback(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationRollback(o)
+
+	// No-op
+	return nil, nil
+}
+
+func (o *OpAlterSequence) Validate(ctx context.Context, s *schema.Schema) error {
+	seq := s.GetSequence(o.Name)
+	if seq == nil {
+		return SequenceDoesNotExistError{Name: o.Name}
+	}
+
+	opts := o.options()
+	if opts.isEmpty() {
+		return AlterSequenceNoChangesError{Name: o.Name}
+	}
+
+	// Validate the options against a copy of the sequence with the options
+	// applied, then update the sequence for subsequent operations
+	altered := *seq
+	opts.apply(&altered)
+	if err := opts.validate(&altered); err != nil {
+		return err
+	}
+	*seq = altered
+
+	return nil
+}
+
+func (o *OpAlterSequence) options() sequenceOptions {
+	return sequenceOptions{
+		increment: o.Increment,
+		minValue:  o.MinValue,
+		maxValue:  o.MaxValue,
+		cache:     o.Cache,
+		cycle:     o.Cycle,
+		restart:   o.Restart,
+	}
+}
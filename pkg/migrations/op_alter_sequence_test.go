@@ -0,0 +1,125 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xataio/pgroll/pkg/migrations"
+)
+
+func TestAlterSequence(t *testing.T) {
+	t.Parallel()
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "alter sequence",
+			migrations: []migrations.Migration{
+				createInvoicesTableMigration,
+				{
+					Name: "02_alter_sequence",
+					Operations: migrations.Operations{
+						&migrations.OpAlterSequence{
+							Name:      "invoices_id_seq",
+							Increment: ptr(100),
+							Restart:   ptr(1000),
+						},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB, schema string) {
+				// The sequence is unchanged until the migration is completed
+				MustInsert(t, db, schema, "01_create_table", "invoices", map[string]string{
+					"total": "100",
+				})
+				MustInsert(t, db, schema, "02_alter_sequence", "invoices", map[string]string{
+					"total": "200",
+				})
+
+				rows := MustSelect(t, db, schema, "02_alter_sequence", "invoices")
+				assert.ElementsMatch(t, []map[string]any{
+					{"id": 1, "total": 100},
+					{"id": 2, "total": 200},
+				}, rows)
+			},
+			afterRollback: func(t *testing.T, db *sql.DB, schema string) {
+				MustInsert(t, db, schema, "01_create_table", "invoices", map[string]string{
+					"total": "300",
+				})
+
+				rows := MustSelect(t, db, schema, "01_create_table", "invoices")
+				assert.ElementsMatch(t, []map[string]any{
+					{"id": 1, "total": 100},
+					{"id": 2, "total": 200},
+					{"id": 3, "total": 300},
+				}, rows)
+			},
+			afterComplete: func(t *testing.T, db *sql.DB, schema string) {
+				MustInsert(t, db, schema, "02_alter_sequence", "invoices", map[string]string{
+					"total": "400",
+				})
+				MustInsert(t, db, schema, "02_alter_sequence", "invoices", map[string]string{
+					"total": "500",
+				})
+
+				rows := MustSelect(t, db, schema, "02_alter_sequence", "invoices")
+				assert.ElementsMatch(t, []map[string]any{
+					{"id": 1, "total": 100},
+					{"id": 2, "total": 200},
+					{"id": 3, "total": 300},
+					{"id": 1000, "total": 400},
+					{"id": 1100, "total": 500},
+				}, rows)
+			},
+		},
+	})
+}
+
+func TestAlterSequenceValidation(t *testing.T) {
+	t.Parallel()
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "sequence must exist",
+			migrations: []migrations.Migration{
+				createInvoicesTableMigration,
+				{
+					Name: "02_alter_sequence",
+					Operations: migrations.Operations{
+						&migrations.OpAlterSequence{Name: "doesntexist", Increment: ptr(2)},
+					},
+				},
+			},
+			wantStartErr: migrations.SequenceDoesNotExistError{Name: "doesntexist"},
+		},
+		{
+			name: "at least one change is required",
+			migrations: []migrations.Migration{
+				createInvoicesTableMigration,
+				{
+					Name: "02_alter_sequence",
+					Operations: migrations.Operations{
+						&migrations.OpAlterSequence{Name: "invoices_id_seq"},
+					},
+				},
+			},
+			wantStartErr: migrations.AlterSequenceNoChangesError{Name: "invoices_id_seq"},
+		},
+		{
+			name: "restart must be within the range of the sequence",
+			migrations: []migrations.Migration{
+				createInvoicesTableMigration,
+				{
+					Name: "02_alter_sequence",
+					Operations: migrations.Operations{
+						&migrations.OpAlterSequence{Name: "invoices_id_seq", Restart: ptr(0)},
+					},
+				},
+			},
+			wantStartErr: migrations.InvalidSequenceOptionsError{Name: "invoices_id_seq", Reason: "restart must be between min_value and max_value"},
+		},
+	})
+}
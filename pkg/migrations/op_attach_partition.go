@@ -0,0 +1,118 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"context"
+	"maps"
+	"slices"
+
+	"github.com/xataio/pgroll/pkg/db"
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+var (
+	_ Operation  = (*OpAttachPartition)(nil)
+	_ Createable = (*OpAttachPartition)(nil)
+)
+
+// Start adds a check constraint matching the partition bound to the table
+// being attached and validates it, which does not block writes to the table.
+// The table is attached on completion, when Postgres can rely on the
+// constraint instead of scanning the table while holding a lock on it.
+func (o *OpAttachPartition) Start(ctx context.Context, l Logger, conn db.DB, s *schema.Schema) (*StartResult, error) {
+	l.LogOperationStart(o)
+
+	table := s.GetTable(o.Table)
+	if table == nil {
+		return nil, TableDoesNotExistError{Name: o.Table}
+	}
+	partition := s.GetTable(o.Name)
+	if partition == nil {
+		return nil, TableDoesNotExistError{Name: o.Name}
+	}
+
+	var dbActions []DBAction
+	if check, ok := partitionBoundCheck(table.PartitionKey, o.Bound); ok {
+		dbActions = append(dbActions,
+			NewCreateCheckConstraintAction(conn, partition.Name, partitionBoundConstraintName, check, nil, false, true),
+			NewValidateConstraintAction(conn, partition.Name, partitionBoundConstraintName),
+		)
+	}
+
+	// The new version of the schema sees the table as a partition, so it is no
+	// longer visible on its own
+	s.RemoveTable(o.Name)
+	table.AddPartition(&schema.Partition{Name: o.Name, Bound: o.Bound})
+
+	return &StartResult{Actions: dbActions}, nil
+}
+
+func (o *OpAttachPartition) Complete(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationComplete(o)
+
+	table := s.GetTable(o.Table)
+	if table == nil {
+		return nil, TableDoesNotExistError{Name: o.Table}
+	}
+	_, validated := partitionBoundCheck(table.PartitionKey, o.Bound)
+
+	// The check constraint is redundant once the table is a partition
+	return []DBAction{
+		NewAttachPartitionAction(conn, table.Name, o.Name, o.Bound, validated),
+		NewDropConstraintAction(conn, o.Name, partitionBoundConstraintName),
+	}, nil
+}
+
+func (o *OpAttachPartition) Rollback(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationRollback(o)
+
+	// Make the table visible again to preceding rollbacks in the same migration
+	s.UnRemoveTable(o.Name)
+	if table := s.GetTable(o.Table); table != nil {
+		table.RemovePartition(o.Name)
+	}
+
+	return []DBAction{
+		NewDropConstraintAction(conn, o.Name, partitionBoundConstraintName),
+	}, nil
+}
+
+func (o *OpAttachPartition) Validate(ctx context.Context, s *schema.Schema) error {
+	table := s.GetTable(o.Table)
+	if table == nil {
+		return TableDoesNotExistError{Name: o.Table}
+	}
+	if !table.IsPartitioned() {
+		return TableNotPartitionedError{Name: o.Table}
+	}
+
+	if o.Name == "" {
+		return FieldRequiredError{Name: "name"}
+	}
+	partition := s.GetTable(o.Name)
+	if partition == nil {
+		return TableDoesNotExistError{Name: o.Name}
+	}
+
+	// The table must have exactly the columns of the partitioned table
+	for _, name := range slices.Sorted(maps.Keys(table.Columns)) {
+		col := partition.GetColumn(name)
+		if col == nil || col.Type != table.GetColumn(name).Type {
+			return PartitionColumnMismatchError{Table: o.Table, Partition: o.Name, Column: name}
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(partition.Columns)) {
+		if table.GetColumn(name) == nil {
+			return PartitionColumnMismatchError{Table: o.Table, Partition: o.Name, Column: name}
+		}
+	}
+
+	if err := validatePartitionBound(o.Name, o.Bound); err != nil {
+		return err
+	}
+
+	s.RemoveTable(o.Name)
+	table.AddPartition(&schema.Partition{Name: o.Name, Bound: o.Bound})
+	return nil
+}
@@ -0,0 +1,201 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xataio/pgroll/pkg/migrations"
+)
+
+func TestAttachPartition(t *testing.T) {
+	t.Parallel()
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "attach partition",
+			migrations: []migrations.Migration{
+				createPartitionedTableMigration,
+				{
+					Name: "02_create_table",
+					Operations: migrations.Operations{
+						&migrations.OpRawSQL{
+							Up: `CREATE TABLE events_2025 (id integer, created_at date, PRIMARY KEY (id, created_at));
+								INSERT INTO events_2025 VALUES (1, '2025-03-01')`,
+						},
+					},
+				},
+				{
+					Name: "03_attach_partition",
+					Operations: migrations.Operations{
+						&migrations.OpAttachPartition{
+							Table: "events",
+							Name:  "events_2025",
+							Bound: "FOR VALUES FROM ('2025-01-01') TO ('2026-01-01')",
+						},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB, schema string) {
+				// The table is not attached yet, but has a validated check constraint
+				// matching the partition bound
+				PartitionMustNotExist(t, db, schema, "events", "events_2025")
+				CheckConstraintMustExist(t, db, schema, "events_2025", migrations.TemporaryName("partition_bound"))
+
+				// The table is only visible on its own in the old version schema
+				ViewMustExist(t, db, schema, "02_create_table", "events_2025")
+				ViewMustNotExist(t, db, schema, "03_attach_partition", "events_2025")
+			},
+			afterRollback: func(t *testing.T, db *sql.DB, schema string) {
+				PartitionMustNotExist(t, db, schema, "events", "events_2025")
+				CheckConstraintMustNotExist(t, db, schema, "events_2025", migrations.TemporaryName("partition_bound"))
+			},
+			afterComplete: func(t *testing.T, db *sql.DB, schema string) {
+				PartitionMustExist(t, db, schema, "events", "events_2025")
+				CheckConstraintMustNotExist(t, db, schema, "events_2025", migrations.TemporaryName("partition_bound"))
+
+				// The rows of the attached table are visible through the partitioned
+				// table
+				assert.Len(t, MustSelect(t, db, schema, "03_attach_partition", "events"), 1)
+			},
+		},
+		{
+			name: "attach hash partition",
+			migrations: []migrations.Migration{
+				{
+					Name: "01_create_table",
+					Operations: migrations.Operations{
+						&migrations.OpRawSQL{
+							Up: `CREATE TABLE events (id integer PRIMARY KEY) PARTITION BY HASH (id);
+								CREATE TABLE events_0 PARTITION OF events FOR VALUES WITH (MODULUS 2, REMAINDER 0);
+								CREATE TABLE events_1 (id integer PRIMARY KEY)`,
+						},
+					},
+				},
+				{
+					Name: "02_attach_partition",
+					Operations: migrations.Operations{
+						&migrations.OpAttachPartition{
+							Table: "events",
+							Name:  "events_1",
+							Bound: "FOR VALUES WITH (MODULUS 2, REMAINDER 1)",
+						},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB, schema string) {
+				// No check constraint can be created for a hash partition
+				CheckConstraintMustNotExist(t, db, schema, "events_1", migrations.TemporaryName("partition_bound"))
+			},
+			afterRollback: func(t *testing.T, db *sql.DB, schema string) {
+				PartitionMustNotExist(t, db, schema, "events", "events_1")
+			},
+			afterComplete: func(t *testing.T, db *sql.DB, schema string) {
+				PartitionMustExist(t, db, schema, "events", "events_1")
+			},
+		},
+	})
+}
+
+func TestAttachPartitionValidation(t *testing.T) {
+	t.Parallel()
+
+	createTableMigration := migrations.Migration{
+		Name: "02_create_table",
+		Operations: migrations.Operations{
+			&migrations.OpRawSQL{
+				Up: "CREATE TABLE events_2025 (id integer, created_at date, PRIMARY KEY (id, created_at))",
+			},
+		},
+	}
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "table to attach must exist",
+			migrations: []migrations.Migration{
+				createPartitionedTableMigration,
+				{
+					Name: "02_attach_partition",
+					Operations: migrations.Operations{
+						&migrations.OpAttachPartition{
+							Table: "events",
+							Name:  "events_2025",
+							Bound: "FOR VALUES FROM ('2025-01-01') TO ('2026-01-01')",
+						},
+					},
+				},
+			},
+			wantStartErr: migrations.TableDoesNotExistError{Name: "events_2025"},
+		},
+		{
+			name: "table must be partitioned",
+			migrations: []migrations.Migration{
+				{
+					Name: "01_create_table",
+					Operations: migrations.Operations{
+						&migrations.OpRawSQL{
+							Up: "CREATE TABLE events (id integer, created_at date, PRIMARY KEY (id, created_at))",
+						},
+					},
+				},
+				createTableMigration,
+				{
+					Name: "03_attach_partition",
+					Operations: migrations.Operations{
+						&migrations.OpAttachPartition{
+							Table: "events",
+							Name:  "events_2025",
+							Bound: "FOR VALUES FROM ('2025-01-01') TO ('2026-01-01')",
+						},
+					},
+				},
+			},
+			wantStartErr: migrations.TableNotPartitionedError{Name: "events"},
+		},
+		{
+			name: "columns must match",
+			migrations: []migrations.Migration{
+				createPartitionedTableMigration,
+				{
+					Name: "02_create_table",
+					Operations: migrations.Operations{
+						&migrations.OpRawSQL{
+							Up: "CREATE TABLE events_2025 (id bigint, created_at date, PRIMARY KEY (id, created_at))",
+						},
+					},
+				},
+				{
+					Name: "03_attach_partition",
+					Operations: migrations.Operations{
+						&migrations.OpAttachPartition{
+							Table: "events",
+							Name:  "events_2025",
+							Bound: "FOR VALUES FROM ('2025-01-01') TO ('2026-01-01')",
+						},
+					},
+				},
+			},
+			wantStartErr: migrations.PartitionColumnMismatchError{Table: "events", Partition: "events_2025", Column: "id"},
+		},
+		{
+			name: "partition bound is required",
+			migrations: []migrations.Migration{
+				createPartitionedTableMigration,
+				createTableMigration,
+				{
+					Name: "03_attach_partition",
+					Operations: migrations.Operations{
+						&migrations.OpAttachPartition{
+							Table: "events",
+							Name:  "events_2025",
+						},
+					},
+				},
+			},
+			wantStartErr: migrations.FieldRequiredError{Name: "bound"},
+		},
+	})
+}
@@ -34,6 +34,36 @@ const (
 	OpNameDropMultiColumnConstraint OpName = "drop_multicolumn_constraint"
 	OpRawSQLName                    OpName = "sql"
 	OpCreateConstraintName          OpName = "create_constraint"
+	OpNameCreateEnum                OpName = "create_enum"
+	OpNameAddEnumValue              OpName = "add_enum_value"
+	OpNameRenameEnumValue           OpName = "rename_enum_value"
+	OpNameDropEnumValue             OpName = "drop_enum_value"
+	OpNameDropEnum                  OpName = "drop_enum"
+	OpNameCreateView                OpName = "create_view"
+	OpNameReplaceView               OpName = "replace_view"
+	OpNameDropView                  OpName = "drop_view"
+	OpNameSplitColumn               OpName = "split_column"
+	OpNameMergeColumns              OpName = "merge_columns"
+	OpNamePartitionTable            OpName = "partition_table"
+	OpNameCreatePartition           OpName = "create_partition"
+	OpNameAttachPartition           OpName = "attach_partition"
+	OpNameDetachPartition           OpName = "detach_partition"
+	OpNameEnableRLS                 OpName = "enable_rls"
+	OpNameCreatePolicy              OpName = "create_policy"
+	OpNameAlterPolicy               OpName = "alter_policy"
+	OpNameDropPolicy                OpName = "drop_policy"
+	OpNameCreateFunction            OpName = "create_function"
+	OpNameReplaceFunction           OpName = "replace_function"
+	OpNameDropFunction              OpName = "drop_function"
+	OpNameCreateTrigger             OpName = "create_trigger"
+	OpNameDropTrigger               OpName = "drop_trigger"
+	OpNameCreateSequence            OpName = "create_sequence"
+	OpNameAlterSequence             OpName = "alter_sequence"
+	OpNameDropSequence              OpName = "drop_sequence"
+	OpNameConvertToIdentity         OpName = "convert_to_identity"
+	OpNameMoveTable                 OpName = "move_table"
+	OpNameGrant                     OpName = "grant"
+	OpNameRevoke                    OpName = "revoke"
 )
 
 // AllNonDeprecatedOperations contains the list of operations
@@ -53,6 +83,36 @@ var AllNonDeprecatedOperations = []string{
 	string(OpNameDropMultiColumnConstraint),
 	string(OpRawSQLName),
 	string(OpCreateConstraintName),
+	string(OpNameCreateEnum),
+	string(OpNameAddEnumValue),
+	string(OpNameRenameEnumValue),
+	string(OpNameDropEnumValue),
+	string(OpNameDropEnum),
+	string(OpNameCreateView),
+	string(OpNameReplaceView),
+	string(OpNameDropView),
+	string(OpNameSplitColumn),
+	string(OpNameMergeColumns),
+	string(OpNamePartitionTable),
+	string(OpNameCreatePartition),
+	string(OpNameAttachPartition),
+	string(OpNameDetachPartition),
+	string(OpNameEnableRLS),
+	string(OpNameCreatePolicy),
+	string(OpNameAlterPolicy),
+	string(OpNameDropPolicy),
+	string(OpNameCreateFunction),
+	string(OpNameReplaceFunction),
+	string(OpNameDropFunction),
+	string(OpNameCreateTrigger),
+	string(OpNameDropTrigger),
+	string(OpNameCreateSequence),
+	string(OpNameAlterSequence),
+	string(OpNameDropSequence),
+	string(OpNameConvertToIdentity),
+	string(OpNameMoveTable),
+	string(OpNameGrant),
+	string(OpNameRevoke),
 }
 
 const (
@@ -134,6 +194,7 @@ func ParseMigration(raw *RawMigration) (*Migration, error) {
 	return &Migration{
 		Name:          raw.Name,
 		VersionSchema: raw.VersionSchema,
+		Baseline:      raw.Baseline,
 		Operations:    ops,
 	}, nil
 }
@@ -266,6 +327,96 @@ func OperationName(op Operation) OpName {
 	case *OpDropMultiColumnConstraint:
 		return OpNameDropMultiColumnConstraint
 
+	case *OpCreateEnum:
+		return OpNameCreateEnum
+
+	case *OpAddEnumValue:
+		return OpNameAddEnumValue
+
+	case *OpRenameEnumValue:
+		return OpNameRenameEnumValue
+
+	case *OpDropEnumValue:
+		return OpNameDropEnumValue
+
+	case *OpDropEnum:
+		return OpNameDropEnum
+
+	case *OpCreateView:
+		return OpNameCreateView
+
+	case *OpReplaceView:
+		return OpNameReplaceView
+
+	case *OpDropView:
+		return OpNameDropView
+
+	case *OpSplitColumn:
+		return OpNameSplitColumn
+
+	case *OpMergeColumns:
+		return OpNameMergeColumns
+
+	case *OpPartitionTable:
+		return OpNamePartitionTable
+
+	case *OpCreatePartition:
+		return OpNameCreatePartition
+
+	case *OpAttachPartition:
+		return OpNameAttachPartition
+
+	case *OpDetachPartition:
+		return OpNameDetachPartition
+
+	case *OpEnableRLS:
+		return OpNameEnableRLS
+
+	case *OpCreatePolicy:
+		return OpNameCreatePolicy
+
+	case *OpAlterPolicy:
+		return OpNameAlterPolicy
+
+	case *OpDropPolicy:
+		return OpNameDropPolicy
+
+	case *OpCreateFunction:
+		return OpNameCreateFunction
+
+	case *OpReplaceFunction:
+		return OpNameReplaceFunction
+
+	case *OpDropFunction:
+		return OpNameDropFunction
+
+	case *OpCreateTrigger:
+		return OpNameCreateTrigger
+
+	case *OpDropTrigger:
+		return OpNameDropTrigger
+
+	case *OpCreateSequence:
+		return OpNameCreateSequence
+
+	case *OpAlterSequence:
+		return OpNameAlterSequence
+
+	case *OpDropSequence:
+		return OpNameDropSequence
+
+	case *OpConvertToIdentity:
+		return OpNameConvertToIdentity
+
+	case *OpMoveTable:
+		return OpNameMoveTable
+
+	case *OpGrant:
+		return OpNameGrant
+
+	case *OpRevoke:
+		return OpNameRevoke
+
 	}
 
 	panic(fmt.Errorf("unknown operation for %T", op))
@@ -318,6 +469,96 @@ func OperationFromName(name OpName) (Operation, error) {
 	case OpNameDropMultiColumnConstraint:
 		return &OpDropMultiColumnConstraint{}, nil
 
+	case OpNameCreateEnum:
+		return &OpCreateEnum{}, nil
+
+	case OpNameAddEnumValue:
+		return &OpAddEnumValue{}, nil
+
+	case OpNameRenameEnumValue:
+		return &OpRenameEnumValue{}, nil
+
+	case OpNameDropEnumValue:
+		return &OpDropEnumValue{}, nil
+
+	case OpNameDropEnum:
+		return &OpDropEnum{}, nil
+
+	case OpNameCreateView:
+		return &OpCreateView{}, nil
+
+	case OpNameReplaceView:
+		return &OpReplaceView{}, nil
+
+	case OpNameDropView:
+		return &OpDropView{}, nil
+
+	case OpNameSplitColumn:
+		return &OpSplitColumn{}, nil
+
+	case OpNameMergeColumns:
+		return &OpMergeColumns{}, nil
+
+	case OpNamePartitionTable:
+		return &OpPartitionTable{}, nil
+
+	case OpNameCreatePartition:
+		return &OpCreatePartition{}, nil
+
+	case OpNameAttachPartition:
+		return &OpAttachPartition{}, nil
+
+	case OpNameDetachPartition:
+		return &OpDetachPartition{}, nil
+
+	case OpNameEnableRLS:
+		return &OpEnableRLS{}, nil
+
+	case OpNameCreatePolicy:
+		return &OpCreatePolicy{}, nil
+
+	case OpNameAlterPolicy:
+		return &OpAlterPolicy{}, nil
+
+	case OpNameDropPolicy:
+		return &OpDropPolicy{}, nil
+
+	case OpNameCreateFunction:
+		return &OpCreateFunction{}, nil
+
+	case OpNameReplaceFunction:
+		return &OpReplaceFunction{}, nil
+
+	case OpNameDropFunction:
+		return &OpDropFunction{}, nil
+
+	case OpNameCreateTrigger:
+		return &OpCreateTrigger{}, nil
+
+	case OpNameDropTrigger:
+		return &OpDropTrigger{}, nil
+
+	case OpNameCreateSequence:
+		return &OpCreateSequence{}, nil
+
+	case OpNameAlterSequence:
+		return &OpAlterSequence{}, nil
+
+	case OpNameDropSequence:
+		return &OpDropSequence{}, nil
+
+	case OpNameConvertToIdentity:
+		return &OpConvertToIdentity{}, nil
+
+	case OpNameMoveTable:
+		return &OpMoveTable{}, nil
+
+	case OpNameGrant:
+		return &OpGrant{}, nil
+
+	case OpNameRevoke:
+		return &OpRevoke{}, nil
+
 	}
 	return nil, fmt.Errorf("unknown migration type: %v", name)
 }
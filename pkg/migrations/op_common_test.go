@@ -141,6 +141,20 @@ func ViewMustNotExist(t *testing.T, db *sql.DB, schema, version, view string) {
 	}
 }
 
+func BaseViewMustExist(t *testing.T, db *sql.DB, schema, view string) {
+	t.Helper()
+	if !baseViewExists(t, db, schema, view) {
+		t.Fatalf("Expected view %q to exist", view)
+	}
+}
+
+func BaseViewMustNotExist(t *testing.T, db *sql.DB, schema, view string) {
+	t.Helper()
+	if baseViewExists(t, db, schema, view) {
+		t.Fatalf("Expected view %q to not exist", view)
+	}
+}
+
 func TableMustExist(t *testing.T, db *sql.DB, schema, table string) {
 	t.Helper()
 	if !tableExists(t, db, schema, table) {
@@ -155,6 +169,70 @@ func TableMustNotExist(t *testing.T, db *sql.DB, schema, table string) {
 	}
 }
 
+func TableMustBePartitioned(t *testing.T, db *sql.DB, schema, table string) {
+	t.Helper()
+	if !tableIsPartitioned(t, db, schema, table) {
+		t.Fatalf("Expected table %q to be partitioned", table)
+	}
+}
+
+func TableMustNotBePartitioned(t *testing.T, db *sql.DB, schema, table string) {
+	t.Helper()
+	if tableIsPartitioned(t, db, schema, table) {
+		t.Fatalf("Expected table %q to not be partitioned", table)
+	}
+}
+
+func PartitionMustExist(t *testing.T, db *sql.DB, schema, table, partition string) {
+	t.Helper()
+	if !partitionExists(t, db, schema, table, partition) {
+		t.Fatalf("Expected %q to be a partition of table %q", partition, table)
+	}
+}
+
+func PartitionMustNotExist(t *testing.T, db *sql.DB, schema, table, partition string) {
+	t.Helper()
+	if partitionExists(t, db, schema, table, partition) {
+		t.Fatalf("Expected %q to not be a partition of table %q", partition, table)
+	}
+}
+
+func RowLevelSecurityMustBeEnabled(t *testing.T, db *sql.DB, schema, table string, forced bool) {
+	t.Helper()
+	enabled, isForced := rowLevelSecurity(t, db, schema, table)
+	if !enabled || isForced != forced {
+		t.Fatalf("Expected row level security to be enabled (forced: %t) on table %q", forced, table)
+	}
+}
+
+func RowLevelSecurityMustNotBeEnabled(t *testing.T, db *sql.DB, schema, table string) {
+	t.Helper()
+	if enabled, _ := rowLevelSecurity(t, db, schema, table); enabled {
+		t.Fatalf("Expected row level security to not be enabled on table %q", table)
+	}
+}
+
+func PolicyMustExist(t *testing.T, db *sql.DB, schema, table, policy string) {
+	t.Helper()
+	if !policyExists(t, db, schema, table, policy) {
+		t.Fatalf("Expected policy %q on table %q to exist", policy, table)
+	}
+}
+
+func PolicyMustNotExist(t *testing.T, db *sql.DB, schema, table, policy string) {
+	t.Helper()
+	if policyExists(t, db, schema, table, policy) {
+		t.Fatalf("Expected policy %q on table %q to not exist", policy, table)
+	}
+}
+
+func PolicyMustReferenceColumns(t *testing.T, db *sql.DB, schema, table, policy string, columns ...string) {
+	t.Helper()
+	if got := policyColumns(t, db, schema, table, policy); !slices.Equal(got, columns) {
+		t.Fatalf("Expected policy %q on table %q to reference columns %v, got %v", policy, table, columns, got)
+	}
+}
+
 func ColumnMustExist(t *testing.T, db *sql.DB, schema, table, column string) {
 	t.Helper()
 	if !columnExists(t, db, schema, table, column) {
@@ -218,6 +296,13 @@ func TableMustHaveColumnCount(t *testing.T, db *sql.DB, schema, table string, n
 	}
 }
 
+func FunctionMustExist(t *testing.T, db *sql.DB, schema, function string) {
+	t.Helper()
+	if !functionExists(t, db, schema, function) {
+		t.Fatalf("Expected function %q to exist", function)
+	}
+}
+
 func FunctionMustNotExist(t *testing.T, db *sql.DB, schema, function string) {
 	t.Helper()
 	if functionExists(t, db, schema, function) {
@@ -225,6 +310,29 @@ func FunctionMustNotExist(t *testing.T, db *sql.DB, schema, function string) {
 	}
 }
 
+func EnumMustHaveValues(t *testing.T, db *sql.DB, schema, enum string, expectedValues []string) {
+	t.Helper()
+	values, exists := enumValues(t, db, schema, enum)
+	if !exists {
+		t.Fatalf("Expected enum type %q to exist", enum)
+	}
+	assert.Equal(t, expectedValues, values)
+}
+
+func EnumMustNotExist(t *testing.T, db *sql.DB, schema, enum string) {
+	t.Helper()
+	if _, exists := enumValues(t, db, schema, enum); exists {
+		t.Fatalf("Expected enum type %q to not exist", enum)
+	}
+}
+
+func TriggerMustExist(t *testing.T, db *sql.DB, schema, table, trigger string) {
+	t.Helper()
+	if !triggerExists(t, db, schema, table, trigger) {
+		t.Fatalf("Expected trigger %q to exist", trigger)
+	}
+}
+
 func TriggerMustNotExist(t *testing.T, db *sql.DB, schema, table, trigger string) {
 	t.Helper()
 	if triggerExists(t, db, schema, table, trigger) {
@@ -232,6 +340,41 @@ func TriggerMustNotExist(t *testing.T, db *sql.DB, schema, table, trigger string
 	}
 }
 
+func SequenceMustExist(t *testing.T, db *sql.DB, schema, sequence string) {
+	t.Helper()
+	if !sequenceExists(t, db, schema, sequence) {
+		t.Fatalf("Expected sequence %q to exist", sequence)
+	}
+}
+
+func SequenceMustNotExist(t *testing.T, db *sql.DB, schema, sequence string) {
+	t.Helper()
+	if sequenceExists(t, db, schema, sequence) {
+		t.Fatalf("Expected sequence %q to not exist", sequence)
+	}
+}
+
+func ColumnMustBeIdentity(t *testing.T, db *sql.DB, schema, table, column, generated string) {
+	t.Helper()
+	if got := columnIdentity(t, db, schema, table, column); got != generated {
+		t.Fatalf("Expected column %q to be a %q identity column, got %q", column, generated, got)
+	}
+}
+
+func TablePrivilegeMustExist(t *testing.T, db *sql.DB, schema, table, role, privilege string) {
+	t.Helper()
+	if !tablePrivilegeExists(t, db, schema, table, role, privilege) {
+		t.Fatalf("Expected role %q to have privilege %q on table %q", role, privilege, table)
+	}
+}
+
+func TablePrivilegeMustNotExist(t *testing.T, db *sql.DB, schema, table, role, privilege string) {
+	t.Helper()
+	if tablePrivilegeExists(t, db, schema, table, role, privilege) {
+		t.Fatalf("Expected role %q to not have privilege %q on table %q", role, privilege, table)
+	}
+}
+
 func CheckConstraintMustNotExist(t *testing.T, db *sql.DB, schema, table, constraint string) {
 	t.Helper()
 	if checkConstraintExists(t, db, schema, table, constraint, false) {
@@ -583,6 +726,64 @@ func triggerExists(t *testing.T, db *sql.DB, schema, table, trigger string) bool
 	return exists
 }
 
+func sequenceExists(t *testing.T, db *sql.DB, schema, sequence string) bool {
+	t.Helper()
+
+	var exists bool
+	err := db.QueryRow(`
+    SELECT EXISTS (
+      SELECT 1
+      FROM pg_catalog.pg_class
+      WHERE relname = $1
+      AND relnamespace = $2::regnamespace
+      AND relkind = 'S'
+    )`,
+		sequence, schema).Scan(&exists)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return exists
+}
+
+func tablePrivilegeExists(t *testing.T, db *sql.DB, schema, table, role, privilege string) bool {
+	t.Helper()
+
+	var exists bool
+	err := db.QueryRow(`
+    SELECT EXISTS (
+      SELECT 1
+      FROM pg_catalog.pg_class AS c, aclexplode(c.relacl) AS acl
+      WHERE c.relname = $1
+      AND c.relnamespace = $2::regnamespace
+      AND acl.grantee = $3::regrole
+      AND acl.privilege_type = $4
+    )`,
+		table, schema, role, privilege).Scan(&exists)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return exists
+}
+
+func columnIdentity(t *testing.T, db *sql.DB, schema, table, column string) string {
+	t.Helper()
+
+	var identity string
+	err := db.QueryRow(`
+    SELECT CASE attidentity WHEN 'a' THEN 'ALWAYS' WHEN 'd' THEN 'BY DEFAULT' ELSE '' END
+    FROM pg_catalog.pg_attribute
+    WHERE attrelid = $1::regclass
+    AND attname = $2`,
+		fmt.Sprintf("%s.%s", pq.QuoteIdentifier(schema), pq.QuoteIdentifier(table)), column).Scan(&identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return identity
+}
+
 func functionExists(t *testing.T, db *sql.DB, schema, functionName string) bool {
 	t.Helper()
 
@@ -602,6 +803,29 @@ func functionExists(t *testing.T, db *sql.DB, schema, functionName string) bool
 	return exists
 }
 
+func enumValues(t *testing.T, db *sql.DB, schema, enum string) ([]string, bool) {
+	t.Helper()
+
+	var exists bool
+	var values []string
+	err := db.QueryRow(`
+    SELECT EXISTS (
+      SELECT 1 FROM pg_catalog.pg_type
+      WHERE typname = $1 AND typnamespace = $2::regnamespace AND typtype = 'e'
+    ), COALESCE((
+      SELECT array_agg(e.enumlabel ORDER BY e.enumsortorder)
+      FROM pg_catalog.pg_enum e
+      JOIN pg_catalog.pg_type tp ON tp.oid = e.enumtypid
+      WHERE tp.typname = $1 AND tp.typnamespace = $2::regnamespace
+    ), '{}')`,
+		enum, schema).Scan(&exists, pq.Array(&values))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return values, exists
+}
+
 func tableExists(t *testing.T, db *sql.DB, schema, table string) bool {
 	t.Helper()
 
@@ -621,6 +845,120 @@ func tableExists(t *testing.T, db *sql.DB, schema, table string) bool {
 	return exists
 }
 
+func tableIsPartitioned(t *testing.T, db *sql.DB, schema, table string) bool {
+	t.Helper()
+
+	var partitioned bool
+	err := db.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1
+			FROM pg_catalog.pg_partitioned_table
+			WHERE partrelid = $1::regclass
+		)`,
+		fmt.Sprintf("%s.%s", pq.QuoteIdentifier(schema), pq.QuoteIdentifier(table))).Scan(&partitioned)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return partitioned
+}
+
+func partitionExists(t *testing.T, db *sql.DB, schema, table, partition string) bool {
+	t.Helper()
+
+	var exists bool
+	err := db.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1
+			FROM pg_catalog.pg_inherits AS i
+			JOIN pg_catalog.pg_class AS c ON c.oid = i.inhrelid
+			JOIN pg_catalog.pg_namespace AS n ON n.oid = c.relnamespace
+			WHERE i.inhparent = $1::regclass
+			AND n.nspname = $2
+			AND c.relname = $3
+		)`,
+		fmt.Sprintf("%s.%s", pq.QuoteIdentifier(schema), pq.QuoteIdentifier(table)), schema, partition).Scan(&exists)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return exists
+}
+
+func rowLevelSecurity(t *testing.T, db *sql.DB, schema, table string) (bool, bool) {
+	t.Helper()
+
+	var enabled, forced bool
+	err := db.QueryRow(`
+		SELECT relrowsecurity, relforcerowsecurity
+		FROM pg_catalog.pg_class
+		WHERE oid = $1::regclass`,
+		fmt.Sprintf("%s.%s", pq.QuoteIdentifier(schema), pq.QuoteIdentifier(table))).Scan(&enabled, &forced)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return enabled, forced
+}
+
+func policyExists(t *testing.T, db *sql.DB, schema, table, policy string) bool {
+	t.Helper()
+
+	var exists bool
+	err := db.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1
+			FROM pg_catalog.pg_policies
+			WHERE schemaname = $1
+			AND tablename = $2
+			AND policyname = $3
+		)`,
+		schema, table, policy).Scan(&exists)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return exists
+}
+
+func policyColumns(t *testing.T, db *sql.DB, schema, table, policy string) []string {
+	t.Helper()
+
+	rows, err := db.Query(`
+		SELECT DISTINCT a.attname
+		FROM pg_catalog.pg_policy AS p
+		JOIN pg_catalog.pg_depend AS d
+			ON d.classid = 'pg_catalog.pg_policy'::regclass
+			AND d.objid = p.oid
+			AND d.refclassid = 'pg_catalog.pg_class'::regclass
+			AND d.refobjid = p.polrelid
+		JOIN pg_catalog.pg_attribute AS a
+			ON a.attrelid = d.refobjid
+			AND a.attnum = d.refobjsubid
+		WHERE p.polrelid = $1::regclass
+		AND p.polname = $2
+		ORDER BY a.attname`,
+		fmt.Sprintf("%s.%s", pq.QuoteIdentifier(schema), pq.QuoteIdentifier(table)), policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			t.Fatal(err)
+		}
+		columns = append(columns, column)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	return columns
+}
+
 func tableMustHaveColumnCount(t *testing.T, db *sql.DB, schema, table string, n int) bool {
 	t.Helper()
 
@@ -656,6 +994,23 @@ func viewExists(t *testing.T, db *sql.DB, schema, version, view string) bool {
 	return exists
 }
 
+func baseViewExists(t *testing.T, db *sql.DB, schema, view string) bool {
+	t.Helper()
+	var exists bool
+	err := db.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1
+			FROM pg_catalog.pg_views
+			WHERE schemaname = $1
+			AND viewname = $2
+		)`,
+		schema, view).Scan(&exists)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return exists
+}
+
 func columnExists(t *testing.T, db *sql.DB, schema, table, column string) bool {
 	t.Helper()
 
@@ -991,6 +1346,32 @@ func TableMustBeCleanedUp(t *testing.T, db *sql.DB, schema, table string, column
 	}
 }
 
+// MustCallFunction calls the set-returning function `function` in the
+// version schema for `version` and returns the single column of each row.
+func MustCallFunction(t *testing.T, db *sql.DB, schema, version, function string) []any {
+	t.Helper()
+	versionSchema := roll.VersionedSchemaName(schema, version)
+
+	rows, err := db.Query(fmt.Sprintf("SELECT * FROM %s.%s()", pq.QuoteIdentifier(versionSchema), pq.QuoteIdentifier(function)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	res := make([]any, 0)
+	for rows.Next() {
+		var value any
+		if err := rows.Scan(&value); err != nil {
+			t.Fatal(err)
+		}
+		res = append(res, value)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
 func mustSetSearchPath(t *testing.T, db *sql.DB, schema string) {
 	t.Helper()
 
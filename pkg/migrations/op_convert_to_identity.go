@@ -0,0 +1,111 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"context"
+
+	"github.com/xataio/pgroll/pkg/db"
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+var (
+	_ Operation  = (*OpConvertToIdentity)(nil)
+	_ Createable = (*OpConvertToIdentity)(nil)
+)
+
+func (o *OpConvertToIdentity) Start(ctx context.Context, l Logger, conn db.DB, s *schema.Schema) (*StartResult, error) {
+	l.LogOperationStart(o)
+
+	table := s.GetTable(o.Table)
+	if table == nil {
+		return nil, TableDoesNotExistError{Name: o.Table}
+	}
+	column := table.GetColumn(o.Column)
+	if column == nil {
+		return nil, ColumnDoesNotExistError{Table: o.Table, Name: o.Column}
+	}
+
+	// Both versions of the schema insert into the same column, so the column is
+	// converted on completion. Until then new values keep coming from the old
+	// sequence, so the column keeps its default in the in-memory schema
+	// representation for `Complete` to find the sequence.
+	if seq, ok := defaultSequenceName(column.Default); ok {
+		s.RemoveSequence(seq)
+	}
+	column.Identity = string(o.generated())
+
+	return &StartResult{}, nil
+}
+
+func (o *OpConvertToIdentity) Complete(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationComplete(o)
+
+	table := s.GetTable(o.Table)
+	if table == nil {
+		return nil, TableDoesNotExistError{Name: o.Table}
+	}
+	column := table.GetColumn(o.Column)
+	if column == nil {
+		return nil, ColumnDoesNotExistError{Table: o.Table, Name: o.Column}
+	}
+	seq, ok := defaultSequence(column.Default)
+	if !ok {
+		return nil, ColumnNotSerialError{Table: o.Table, Name: o.Column}
+	}
+
+	return []DBAction{
+		NewConvertToIdentityAction(conn, table.Name, column.Name, seq, string(o.generated())),
+	}, nil
+}
+
+func (o *OpConvertToIdentity) Rollback(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationRollback(o)
+
+	// No-op
+	return nil, nil
+}
+
+func (o *OpConvertToIdentity) Validate(ctx context.Context, s *schema.Schema) error {
+	table := s.GetTable(o.Table)
+	if table == nil {
+		return TableDoesNotExistError{Name: o.Table}
+	}
+	column := table.GetColumn(o.Column)
+	if column == nil {
+		return ColumnDoesNotExistError{Table: o.Table, Name: o.Column}
+	}
+
+	if column.Identity != "" {
+		return ColumnIsIdentityError{Table: o.Table, Name: o.Column}
+	}
+	if _, ok := defaultSequence(column.Default); !ok {
+		return ColumnNotSerialError{Table: o.Table, Name: o.Column}
+	}
+
+	// Identity columns must be NOT NULL
+	if column.Nullable {
+		return ColumnIsNullableError{Table: o.Table, Name: o.Column}
+	}
+
+	switch o.Generated {
+	case "", OpConvertToIdentityGeneratedALWAYS, OpConvertToIdentityGeneratedBYDEFAULT:
+	default:
+		return InvalidIdentityGenerationError{Table: o.Table, Name: o.Column, Generated: string(o.Generated)}
+	}
+
+	if seq, ok := defaultSequenceName(column.Default); ok {
+		s.RemoveSequence(seq)
+	}
+	column.Default = nil
+	column.Identity = string(o.generated())
+
+	return nil
+}
+
+func (o *OpConvertToIdentity) generated() OpConvertToIdentityGenerated {
+	if o.Generated == "" {
+		return OpConvertToIdentityGeneratedBYDEFAULT
+	}
+	return o.Generated
+}
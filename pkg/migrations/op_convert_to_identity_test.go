@@ -0,0 +1,133 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xataio/pgroll/pkg/migrations"
+)
+
+func TestConvertToIdentity(t *testing.T) {
+	t.Parallel()
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "convert serial column to identity column",
+			migrations: []migrations.Migration{
+				createInvoicesTableMigration,
+				{
+					Name: "02_convert_to_identity",
+					Operations: migrations.Operations{
+						&migrations.OpConvertToIdentity{
+							Table:     "invoices",
+							Column:    "id",
+							Generated: migrations.OpConvertToIdentityGeneratedALWAYS,
+						},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB, schema string) {
+				// Both versions take values from the serial sequence until completion
+				ColumnMustBeIdentity(t, db, schema, "invoices", "id", "")
+				SequenceMustExist(t, db, schema, "invoices_id_seq")
+
+				MustInsert(t, db, schema, "01_create_table", "invoices", map[string]string{
+					"total": "100",
+				})
+				MustInsert(t, db, schema, "02_convert_to_identity", "invoices", map[string]string{
+					"total": "200",
+				})
+			},
+			afterRollback: func(t *testing.T, db *sql.DB, schema string) {
+				ColumnMustBeIdentity(t, db, schema, "invoices", "id", "")
+				SequenceMustExist(t, db, schema, "invoices_id_seq")
+			},
+			afterComplete: func(t *testing.T, db *sql.DB, schema string) {
+				ColumnMustBeIdentity(t, db, schema, "invoices", "id", "ALWAYS")
+				SequenceMustNotExist(t, db, schema, "invoices_id_seq")
+
+				// The identity column continues from the serial sequence
+				MustInsert(t, db, schema, "02_convert_to_identity", "invoices", map[string]string{
+					"total": "300",
+				})
+
+				rows := MustSelect(t, db, schema, "02_convert_to_identity", "invoices")
+				assert.ElementsMatch(t, []map[string]any{
+					{"id": 1, "total": 100},
+					{"id": 2, "total": 200},
+					{"id": 3, "total": 300},
+				}, rows)
+			},
+		},
+	})
+}
+
+func TestConvertToIdentityValidation(t *testing.T) {
+	t.Parallel()
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "column must exist",
+			migrations: []migrations.Migration{
+				createInvoicesTableMigration,
+				{
+					Name: "02_convert_to_identity",
+					Operations: migrations.Operations{
+						&migrations.OpConvertToIdentity{Table: "invoices", Column: "doesntexist"},
+					},
+				},
+			},
+			wantStartErr: migrations.ColumnDoesNotExistError{Table: "invoices", Name: "doesntexist"},
+		},
+		{
+			name: "column must take its default from a sequence",
+			migrations: []migrations.Migration{
+				createInvoicesTableMigration,
+				{
+					Name: "02_convert_to_identity",
+					Operations: migrations.Operations{
+						&migrations.OpConvertToIdentity{Table: "invoices", Column: "total"},
+					},
+				},
+			},
+			wantStartErr: migrations.ColumnNotSerialError{Table: "invoices", Name: "total"},
+		},
+		{
+			name: "column must not already be an identity column",
+			migrations: []migrations.Migration{
+				{
+					Name: "01_create_table",
+					Operations: migrations.Operations{
+						&migrations.OpRawSQL{
+							Up: "CREATE TABLE invoices (id integer GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY)",
+						},
+					},
+				},
+				{
+					Name: "02_convert_to_identity",
+					Operations: migrations.Operations{
+						&migrations.OpConvertToIdentity{Table: "invoices", Column: "id"},
+					},
+				},
+			},
+			wantStartErr: migrations.ColumnIsIdentityError{Table: "invoices", Name: "id"},
+		},
+		{
+			name: "generation must be valid",
+			migrations: []migrations.Migration{
+				createInvoicesTableMigration,
+				{
+					Name: "02_convert_to_identity",
+					Operations: migrations.Operations{
+						&migrations.OpConvertToIdentity{Table: "invoices", Column: "id", Generated: "SOMETIMES"},
+					},
+				},
+			},
+			wantStartErr: migrations.InvalidIdentityGenerationError{Table: "invoices", Name: "id", Generated: "SOMETIMES"},
+		},
+	})
+}
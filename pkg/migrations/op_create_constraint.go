@@ -159,14 +159,18 @@ func (o *OpCreateConstraint) Complete(l Logger, conn db.DB, s *schema.Schema) ([
 		dbActions = append(dbActions, NewAlterSequenceOwnerAction(conn, o.Table, col, TemporaryName(col)))
 	}
 
-	dbActions = append(dbActions, NewDropColumnAction(conn, o.Table, o.Columns...))
-
-	// rename new columns to old name
 	table := s.GetTable(o.Table)
 	if table == nil {
 		return nil, TableDoesNotExistError{Name: o.Table}
 	}
 	table.Name = o.Table
+
+	dbActions = append(dbActions,
+		NewRewritePoliciesAction(conn, table, o.Columns...),
+		NewDropColumnAction(conn, o.Table, o.Columns...),
+	)
+
+	// rename new columns to old name
 	for _, col := range o.Columns {
 		column := table.GetColumn(col)
 		if column == nil {
@@ -0,0 +1,62 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"context"
+	"slices"
+
+	"github.com/xataio/pgroll/pkg/db"
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+var (
+	_ Operation  = (*OpCreateEnum)(nil)
+	_ Createable = (*OpCreateEnum)(nil)
+)
+
+func (o *OpCreateEnum) Start(ctx context.Context, l Logger, conn db.DB, s *schema.Schema) (*StartResult, error) {
+	l.LogOperationStart(o)
+
+	// Update the in-memory schema representation with the new enum
+	s.AddEnum(o.Name, &schema.Enum{Name: o.Name, Values: slices.Clone(o.Values)})
+
+	return &StartResult{Actions: []DBAction{NewCreateEnumAction(conn, o.Name, o.Values)}}, nil
+}
+
+func (o *OpCreateEnum) Complete(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationComplete(o)
+
+	// No-op
+	return nil, nil
+}
+
+func (o *OpCreateEnum) Rollback(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationRollback(o)
+
+	return []DBAction{NewDropEnumAction(conn, o.Name)}, nil
+}
+
+func (o *OpCreateEnum) Validate(ctx context.Context, s *schema.Schema) error {
+	if err := ValidateIdentifierLength(o.Name); err != nil {
+		return err
+	}
+
+	if s.GetEnum(o.Name) != nil {
+		return EnumAlreadyExistsError{Name: o.Name}
+	}
+
+	if len(o.Values) == 0 {
+		return FieldRequiredError{Name: "values"}
+	}
+
+	for i, value := range o.Values {
+		if slices.Contains(o.Values[:i], value) {
+			return EnumValueAlreadyExistsError{Enum: o.Name, Value: value}
+		}
+	}
+
+	s.AddEnum(o.Name, &schema.Enum{Name: o.Name, Values: slices.Clone(o.Values)})
+
+	return nil
+}
@@ -0,0 +1,178 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/xataio/pgroll/internal/testutils"
+	"github.com/xataio/pgroll/pkg/migrations"
+)
+
+func TestCreateEnum(t *testing.T) {
+	t.Parallel()
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "create enum and use it in a new table",
+			migrations: []migrations.Migration{
+				{
+					Name:          "01_create_enum",
+					VersionSchema: "create_enum",
+					Operations: migrations.Operations{
+						&migrations.OpCreateEnum{
+							Name:   "mood",
+							Values: []string{"happy", "sad"},
+						},
+						&migrations.OpCreateTable{
+							Name: "people",
+							Columns: []migrations.Column{
+								{Name: "id", Type: "serial", Pk: true},
+								{Name: "mood", Type: "mood", Nullable: true},
+							},
+						},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB, schema string) {
+				// The enum type exists
+				EnumMustHaveValues(t, db, schema, "mood", []string{"happy", "sad"})
+
+				// Inserting a valid value works
+				MustInsert(t, db, schema, "create_enum", "people", map[string]string{
+					"mood": "happy",
+				})
+
+				// Inserting a value not in the enum fails
+				MustNotInsert(t, db, schema, "create_enum", "people", map[string]string{
+					"mood": "angry",
+				}, testutils.InvalidTextRepresentationErrorCode)
+			},
+			afterRollback: func(t *testing.T, db *sql.DB, schema string) {
+				// The enum type has been dropped
+				EnumMustNotExist(t, db, schema, "mood")
+			},
+			afterComplete: func(t *testing.T, db *sql.DB, schema string) {
+				// The enum type exists
+				EnumMustHaveValues(t, db, schema, "mood", []string{"happy", "sad"})
+
+				// Inserting a valid value works
+				MustInsert(t, db, schema, "create_enum", "people", map[string]string{
+					"mood": "sad",
+				})
+			},
+		},
+		{
+			name: "create enum and add a value to it in the same migration",
+			migrations: []migrations.Migration{
+				{
+					Name:          "01_create_enum",
+					VersionSchema: "create_enum",
+					Operations: migrations.Operations{
+						&migrations.OpCreateEnum{
+							Name:   "mood",
+							Values: []string{"happy", "sad"},
+						},
+						&migrations.OpAddEnumValue{
+							Enum:  "mood",
+							Value: "neutral",
+							After: "happy",
+						},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB, schema string) {
+				// The enum type exists with the added value
+				EnumMustHaveValues(t, db, schema, "mood", []string{"happy", "neutral", "sad"})
+			},
+			afterRollback: func(t *testing.T, db *sql.DB, schema string) {
+				// The enum type has been dropped
+				EnumMustNotExist(t, db, schema, "mood")
+			},
+			afterComplete: func(t *testing.T, db *sql.DB, schema string) {
+				// The enum type exists with the added value
+				EnumMustHaveValues(t, db, schema, "mood", []string{"happy", "neutral", "sad"})
+			},
+		},
+	})
+}
+
+func TestCreateEnumValidation(t *testing.T) {
+	t.Parallel()
+
+	createEnumMigration := migrations.Migration{
+		Name: "01_create_enum",
+		Operations: migrations.Operations{
+			&migrations.OpCreateEnum{
+				Name:   "mood",
+				Values: []string{"happy", "sad"},
+			},
+		},
+	}
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "enum already exists",
+			migrations: []migrations.Migration{
+				createEnumMigration,
+				{
+					Name: "02_create_enum",
+					Operations: migrations.Operations{
+						&migrations.OpCreateEnum{
+							Name:   "mood",
+							Values: []string{"angry"},
+						},
+					},
+				},
+			},
+			wantStartErr: migrations.EnumAlreadyExistsError{Name: "mood"},
+		},
+		{
+			name: "enum already created in the same migration",
+			migrations: []migrations.Migration{
+				{
+					Name: "01_create_enum",
+					Operations: migrations.Operations{
+						&migrations.OpCreateEnum{
+							Name:   "mood",
+							Values: []string{"happy", "sad"},
+						},
+						&migrations.OpCreateEnum{
+							Name:   "mood",
+							Values: []string{"angry"},
+						},
+					},
+				},
+			},
+			wantStartErr: migrations.EnumAlreadyExistsError{Name: "mood"},
+		},
+		{
+			name: "values are required",
+			migrations: []migrations.Migration{
+				{
+					Name: "01_create_enum",
+					Operations: migrations.Operations{
+						&migrations.OpCreateEnum{Name: "mood"},
+					},
+				},
+			},
+			wantStartErr: migrations.FieldRequiredError{Name: "values"},
+		},
+		{
+			name: "values must be unique",
+			migrations: []migrations.Migration{
+				{
+					Name: "01_create_enum",
+					Operations: migrations.Operations{
+						&migrations.OpCreateEnum{
+							Name:   "mood",
+							Values: []string{"happy", "happy"},
+						},
+					},
+				},
+			},
+			wantStartErr: migrations.EnumValueAlreadyExistsError{Enum: "mood", Value: "happy"},
+		},
+	})
+}
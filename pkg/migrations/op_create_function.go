@@ -0,0 +1,108 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"context"
+
+	"github.com/xataio/pgroll/pkg/db"
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+var (
+	_ Operation  = (*OpCreateFunction)(nil)
+	_ Createable = (*OpCreateFunction)(nil)
+)
+
+// defaultFunctionLanguage is the language of functions created without an
+// explicit language
+const defaultFunctionLanguage = "plpgsql"
+
+func (o *OpCreateFunction) Start(ctx context.Context, l Logger, conn db.DB, s *schema.Schema) (*StartResult, error) {
+	l.LogOperationStart(o)
+
+	// The function is created in the underlying schema straight away so that
+	// triggers created in the same migration can execute it. It has a new name,
+	// so the old version of the schema is unaffected.
+	s.AddFunction(o.Name, o.function())
+
+	return &StartResult{Actions: []DBAction{
+		NewCreateFunctionAction(conn, o.Name, o.Arguments, o.Returns, o.language(), string(o.Volatility), o.Definition),
+	}}, nil
+}
+
+func (o *OpCreateFunction) Complete(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationComplete(o)
+
+	// No-op
+	return nil, nil
+}
+
+func (o *OpCreateFunction) Rollback(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationRollback(o)
+
+	return []DBAction{NewDropFunctionAction(conn, o.Name)}, nil
+}
+
+func (o *OpCreateFunction) Validate(ctx context.Context, s *schema.Schema) error {
+	if o.Name == "" {
+		return FieldRequiredError{Name: "name"}
+	}
+
+	if err := ValidateIdentifierLength(o.Name); err != nil {
+		return err
+	}
+
+	if s.GetFunction(o.Name) != nil {
+		return FunctionAlreadyExistsError{Name: o.Name}
+	}
+
+	if o.Returns == "" {
+		return FieldRequiredError{Name: "returns"}
+	}
+
+	if o.Definition == "" {
+		return FieldRequiredError{Name: "definition"}
+	}
+
+	if err := validateFunctionVolatility(o.Name, o.Volatility); err != nil {
+		return err
+	}
+
+	s.AddFunction(o.Name, o.function())
+
+	return nil
+}
+
+func (o *OpCreateFunction) function() *schema.Function {
+	volatility := string(o.Volatility)
+	if volatility == "" {
+		volatility = string(FunctionVolatilityVOLATILE)
+	}
+
+	return &schema.Function{
+		Name:       o.Name,
+		Arguments:  o.Arguments,
+		Returns:    o.Returns,
+		Language:   o.language(),
+		Volatility: volatility,
+		Definition: o.Definition,
+	}
+}
+
+func (o *OpCreateFunction) language() string {
+	if o.Language == "" {
+		return defaultFunctionLanguage
+	}
+	return o.Language
+}
+
+// validateFunctionVolatility returns an error if `volatility` is not a valid
+// volatility for function `name`. An empty volatility is valid.
+func validateFunctionVolatility(name string, volatility FunctionVolatility) error {
+	switch volatility {
+	case "", FunctionVolatilityIMMUTABLE, FunctionVolatilitySTABLE, FunctionVolatilityVOLATILE:
+		return nil
+	}
+	return InvalidFunctionVolatilityError{Name: name, Volatility: string(volatility)}
+}
@@ -0,0 +1,252 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xataio/pgroll/pkg/migrations"
+	"github.com/xataio/pgroll/pkg/roll"
+)
+
+// createUsersTableMigration creates a `users` table used by the function and
+// trigger tests
+var createUsersTableMigration = migrations.Migration{
+	Name: "01_create_table",
+	Operations: migrations.Operations{
+		&migrations.OpCreateTable{
+			Name: "users",
+			Columns: []migrations.Column{
+				{Name: "id", Type: "serial", Pk: true},
+				{Name: "name", Type: "text", Nullable: true},
+				{Name: "name_upper", Type: "text", Nullable: true},
+			},
+		},
+	},
+}
+
+func TestCreateFunction(t *testing.T) {
+	t.Parallel()
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "create function",
+			migrations: []migrations.Migration{
+				createUsersTableMigration,
+				{
+					Name: "02_create_function",
+					Operations: migrations.Operations{
+						&migrations.OpCreateFunction{
+							Name:       "user_count",
+							Returns:    "bigint",
+							Language:   "sql",
+							Volatility: migrations.FunctionVolatilitySTABLE,
+							Definition: "SELECT count(*) FROM users",
+						},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB, schema string) {
+				FunctionMustExist(t, db, schema, "user_count")
+			},
+			afterRollback: func(t *testing.T, db *sql.DB, schema string) {
+				FunctionMustNotExist(t, db, schema, "user_count")
+			},
+			afterComplete: func(t *testing.T, db *sql.DB, schema string) {
+				FunctionMustExist(t, db, schema, "user_count")
+			},
+		},
+		{
+			name: "create a trigger function and a trigger executing it",
+			migrations: []migrations.Migration{
+				createUsersTableMigration,
+				{
+					Name: "02_create_trigger",
+					Operations: migrations.Operations{
+						&migrations.OpCreateFunction{
+							Name:       "set_name_upper",
+							Returns:    "trigger",
+							Definition: "BEGIN NEW.name_upper := upper(NEW.name); RETURN NEW; END",
+						},
+						&migrations.OpCreateTrigger{
+							Name:     "users_set_name_upper",
+							Table:    "users",
+							Function: "set_name_upper",
+							Timing:   migrations.OpCreateTriggerTimingBEFORE,
+							Events: []migrations.OpCreateTriggerEventsElem{
+								migrations.OpCreateTriggerEventsElemINSERT,
+								migrations.OpCreateTriggerEventsElemUPDATE,
+							},
+						},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB, schema string) {
+				FunctionMustExist(t, db, schema, "set_name_upper")
+				TriggerMustExist(t, db, schema, "users", "users_set_name_upper")
+
+				// The trigger fires for writes made through the new version
+				MustInsert(t, db, schema, "02_create_trigger", "users", map[string]string{
+					"name": "alice",
+				})
+				// The trigger fires for writes made through the old version
+				MustInsert(t, db, schema, "01_create_table", "users", map[string]string{
+					"name": "bob",
+				})
+
+				assert.Equal(t, []map[string]any{
+					{"id": 1, "name": "alice", "name_upper": "ALICE"},
+					{"id": 2, "name": "bob", "name_upper": "BOB"},
+				}, MustSelect(t, db, schema, "02_create_trigger", "users"))
+			},
+			afterRollback: func(t *testing.T, db *sql.DB, schema string) {
+				TriggerMustNotExist(t, db, schema, "users", "users_set_name_upper")
+				FunctionMustNotExist(t, db, schema, "set_name_upper")
+			},
+			afterComplete: func(t *testing.T, db *sql.DB, schema string) {
+				FunctionMustExist(t, db, schema, "set_name_upper")
+				TriggerMustExist(t, db, schema, "users", "users_set_name_upper")
+
+				MustInsert(t, db, schema, "02_create_trigger", "users", map[string]string{
+					"name": "carol",
+				})
+
+				assert.Equal(t, []map[string]any{
+					{"id": 1, "name": "alice", "name_upper": "ALICE"},
+					{"id": 2, "name": "bob", "name_upper": "BOB"},
+					{"id": 3, "name": "carol", "name_upper": "CAROL"},
+				}, MustSelect(t, db, schema, "02_create_trigger", "users"))
+			},
+		},
+	})
+}
+
+func TestVersionedFunctions(t *testing.T) {
+	t.Parallel()
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "functions in version schemas read the column names of their version",
+			migrations: []migrations.Migration{
+				{
+					Name: "01_create_table",
+					Operations: migrations.Operations{
+						&migrations.OpCreateTable{
+							Name: "users",
+							Columns: []migrations.Column{
+								{Name: "id", Type: "serial", Pk: true},
+								{Name: "name", Type: "text", Nullable: true},
+							},
+						},
+						&migrations.OpCreateFunction{
+							Name:       "user_names",
+							Returns:    "SETOF text",
+							Language:   "sql",
+							Volatility: migrations.FunctionVolatilitySTABLE,
+							Definition: "SELECT name FROM users ORDER BY id",
+						},
+						&migrations.OpRawSQL{
+							Up:         "INSERT INTO users (name) VALUES ('alice')",
+							OnComplete: true,
+						},
+					},
+				},
+				{
+					Name: "02_rename_column",
+					Operations: migrations.Operations{
+						&migrations.OpRenameColumn{
+							Table: "users",
+							From:  "name",
+							To:    "full_name",
+						},
+						&migrations.OpReplaceFunction{
+							Name:       "user_names",
+							Definition: "SELECT full_name FROM users ORDER BY id",
+						},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB, schema string) {
+				// Each version schema has a copy of the function reading from the
+				// views of that version
+				assert.Equal(t, []any{"alice"}, MustCallFunction(t, db, schema, "01_create_table", "user_names"))
+				assert.Equal(t, []any{"alice"}, MustCallFunction(t, db, schema, "02_rename_column", "user_names"))
+			},
+			afterRollback: func(t *testing.T, db *sql.DB, schema string) {
+				assert.Equal(t, []any{"alice"}, MustCallFunction(t, db, schema, "01_create_table", "user_names"))
+			},
+			afterComplete: func(t *testing.T, db *sql.DB, schema string) {
+				assert.Equal(t, []any{"alice"}, MustCallFunction(t, db, schema, "02_rename_column", "user_names"))
+			},
+		},
+	}, roll.WithVersionedFunctions(true))
+}
+
+func TestCreateFunctionValidation(t *testing.T) {
+	t.Parallel()
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "function must not already exist",
+			migrations: []migrations.Migration{
+				createUsersTableMigration,
+				{
+					Name: "02_create_function",
+					Operations: migrations.Operations{
+						&migrations.OpCreateFunction{
+							Name:       "user_count",
+							Returns:    "bigint",
+							Language:   "sql",
+							Definition: "SELECT count(*) FROM users",
+						},
+						&migrations.OpCreateFunction{
+							Name:       "user_count",
+							Returns:    "bigint",
+							Language:   "sql",
+							Definition: "SELECT count(*) FROM users",
+						},
+					},
+				},
+			},
+			wantStartErr: migrations.FunctionAlreadyExistsError{Name: "user_count"},
+		},
+		{
+			name: "definition is required",
+			migrations: []migrations.Migration{
+				createUsersTableMigration,
+				{
+					Name: "02_create_function",
+					Operations: migrations.Operations{
+						&migrations.OpCreateFunction{
+							Name:    "user_count",
+							Returns: "bigint",
+						},
+					},
+				},
+			},
+			wantStartErr: migrations.FieldRequiredError{Name: "definition"},
+		},
+		{
+			name: "volatility must be valid",
+			migrations: []migrations.Migration{
+				createUsersTableMigration,
+				{
+					Name: "02_create_function",
+					Operations: migrations.Operations{
+						&migrations.OpCreateFunction{
+							Name:       "user_count",
+							Returns:    "bigint",
+							Language:   "sql",
+							Volatility: "SOMETIMES",
+							Definition: "SELECT count(*) FROM users",
+						},
+					},
+				},
+			},
+			wantStartErr: migrations.InvalidFunctionVolatilityError{Name: "user_count", Volatility: "SOMETIMES"},
+		},
+	})
+}
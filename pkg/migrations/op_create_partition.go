@@ -0,0 +1,80 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"context"
+
+	"github.com/xataio/pgroll/pkg/db"
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+var (
+	_ Operation  = (*OpCreatePartition)(nil)
+	_ Createable = (*OpCreatePartition)(nil)
+)
+
+func (o *OpCreatePartition) Start(ctx context.Context, l Logger, conn db.DB, s *schema.Schema) (*StartResult, error) {
+	l.LogOperationStart(o)
+
+	table := s.GetTable(o.Table)
+	if table == nil {
+		return nil, TableDoesNotExistError{Name: o.Table}
+	}
+
+	// Partitions are not visible in version schemas, so the partition can be
+	// created straight away. Rows in its range are visible through the
+	// partitioned table in both versions of the schema.
+	table.AddPartition(&schema.Partition{Name: o.Name, Bound: o.Bound})
+
+	return &StartResult{Actions: []DBAction{
+		NewCreatePartitionAction(conn, table.Name, o.Name, o.Bound),
+	}}, nil
+}
+
+func (o *OpCreatePartition) Complete(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationComplete(o)
+
+	// No-op
+	return nil, nil
+}
+
+func (o *OpCreatePartition) Rollback(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationRollback(o)
+
+	if table := s.GetTable(o.Table); table != nil {
+		table.RemovePartition(o.Name)
+	}
+
+	return []DBAction{NewDropTableAction(conn, o.Name)}, nil
+}
+
+func (o *OpCreatePartition) Validate(ctx context.Context, s *schema.Schema) error {
+	table := s.GetTable(o.Table)
+	if table == nil {
+		return TableDoesNotExistError{Name: o.Table}
+	}
+	if !table.IsPartitioned() {
+		return TableNotPartitionedError{Name: o.Table}
+	}
+
+	if o.Name == "" {
+		return FieldRequiredError{Name: "name"}
+	}
+	if err := ValidateIdentifierLength(o.Name); err != nil {
+		return err
+	}
+	if _, ok := table.Partitions[o.Name]; ok {
+		return PartitionAlreadyExistsError{Table: o.Table, Name: o.Name}
+	}
+	if s.GetTable(o.Name) != nil {
+		return TableAlreadyExistsError{Name: o.Name}
+	}
+
+	if err := validatePartitionBound(o.Name, o.Bound); err != nil {
+		return err
+	}
+
+	table.AddPartition(&schema.Partition{Name: o.Name, Bound: o.Bound})
+	return nil
+}
@@ -0,0 +1,145 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xataio/pgroll/pkg/migrations"
+)
+
+// createPartitionedTableMigration creates the `events` table partitioned by
+// range on its `created_at` column, with a partition for 2024.
+var createPartitionedTableMigration = migrations.Migration{
+	Name: "01_create_table",
+	Operations: migrations.Operations{
+		&migrations.OpRawSQL{
+			Up: `CREATE TABLE events (id integer, created_at date, PRIMARY KEY (id, created_at)) PARTITION BY RANGE (created_at);
+				CREATE TABLE events_2024 PARTITION OF events FOR VALUES FROM ('2024-01-01') TO ('2025-01-01')`,
+		},
+	},
+}
+
+func TestCreatePartition(t *testing.T) {
+	t.Parallel()
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "create partition",
+			migrations: []migrations.Migration{
+				createPartitionedTableMigration,
+				{
+					Name: "02_create_partition",
+					Operations: migrations.Operations{
+						&migrations.OpCreatePartition{
+							Table: "events",
+							Name:  "events_2025",
+							Bound: "FOR VALUES FROM ('2025-01-01') TO ('2026-01-01')",
+						},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB, schema string) {
+				PartitionMustExist(t, db, schema, "events", "events_2025")
+
+				// Rows in the range of the new partition can be inserted through both
+				// versions of the schema
+				MustInsert(t, db, schema, "01_create_table", "events", map[string]string{
+					"id":         "1",
+					"created_at": "2025-03-01",
+				})
+				MustInsert(t, db, schema, "02_create_partition", "events", map[string]string{
+					"id":         "2",
+					"created_at": "2025-04-01",
+				})
+
+				// Partitions are not visible in the version schemas
+				ViewMustNotExist(t, db, schema, "02_create_partition", "events_2025")
+			},
+			afterRollback: func(t *testing.T, db *sql.DB, schema string) {
+				TableMustNotExist(t, db, schema, "events_2025")
+			},
+			afterComplete: func(t *testing.T, db *sql.DB, schema string) {
+				PartitionMustExist(t, db, schema, "events", "events_2025")
+
+				MustInsert(t, db, schema, "02_create_partition", "events", map[string]string{
+					"id":         "3",
+					"created_at": "2025-05-01",
+				})
+				assert.Len(t, MustSelect(t, db, schema, "02_create_partition", "events"), 1)
+			},
+		},
+	})
+}
+
+func TestCreatePartitionValidation(t *testing.T) {
+	t.Parallel()
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "table must be partitioned",
+			migrations: []migrations.Migration{
+				{
+					Name: "01_create_table",
+					Operations: migrations.Operations{
+						&migrations.OpCreateTable{
+							Name: "events",
+							Columns: []migrations.Column{
+								{Name: "id", Type: "serial", Pk: true},
+								{Name: "created_at", Type: "date"},
+							},
+						},
+					},
+				},
+				{
+					Name: "02_create_partition",
+					Operations: migrations.Operations{
+						&migrations.OpCreatePartition{
+							Table: "events",
+							Name:  "events_2025",
+							Bound: "FOR VALUES FROM ('2025-01-01') TO ('2026-01-01')",
+						},
+					},
+				},
+			},
+			wantStartErr: migrations.TableNotPartitionedError{Name: "events"},
+		},
+		{
+			name: "partition must not already exist",
+			migrations: []migrations.Migration{
+				createPartitionedTableMigration,
+				{
+					Name: "02_create_partition",
+					Operations: migrations.Operations{
+						&migrations.OpCreatePartition{
+							Table: "events",
+							Name:  "events_2024",
+							Bound: "FOR VALUES FROM ('2024-01-01') TO ('2025-01-01')",
+						},
+					},
+				},
+			},
+			wantStartErr: migrations.PartitionAlreadyExistsError{Table: "events", Name: "events_2024"},
+		},
+		{
+			name: "partition bound must be valid",
+			migrations: []migrations.Migration{
+				createPartitionedTableMigration,
+				{
+					Name: "02_create_partition",
+					Operations: migrations.Operations{
+						&migrations.OpCreatePartition{
+							Table: "events",
+							Name:  "events_2025",
+							Bound: "FROM 2025 TO 2026",
+						},
+					},
+				},
+			},
+			wantStartErr: migrations.InvalidPartitionBoundError{Name: "events_2025", Bound: "FROM 2025 TO 2026"},
+		},
+	})
+}
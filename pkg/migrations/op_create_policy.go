@@ -0,0 +1,128 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"context"
+	"maps"
+	"slices"
+
+	"github.com/xataio/pgroll/pkg/db"
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+var (
+	_ Operation  = (*OpCreatePolicy)(nil)
+	_ Createable = (*OpCreatePolicy)(nil)
+)
+
+// Start creates the policy straight away. Policies apply to the table rather
+// than to a version of the schema, so the policy applies to both versions of
+// the schema once it is created.
+func (o *OpCreatePolicy) Start(ctx context.Context, l Logger, conn db.DB, s *schema.Schema) (*StartResult, error) {
+	l.LogOperationStart(o)
+
+	table := s.GetTable(o.Table)
+	if table == nil {
+		return nil, TableDoesNotExistError{Name: o.Table}
+	}
+
+	// The expressions refer to columns by their names in the new version of
+	// the schema, which may differ from the names of the underlying columns
+	using := physicalExpression(table, o.Using)
+	withCheck := physicalExpression(table, o.WithCheck)
+
+	table.AddPolicy(o.policy(table))
+
+	return &StartResult{Actions: []DBAction{
+		NewCreatePolicyAction(conn, table.Name, o.Name, string(o.As), string(o.Command), o.Roles, using, withCheck),
+	}}, nil
+}
+
+func (o *OpCreatePolicy) Complete(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationComplete(o)
+
+	// No-op
+	return nil, nil
+}
+
+func (o *OpCreatePolicy) Rollback(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationRollback(o)
+
+	table := s.GetTable(o.Table)
+	if table == nil {
+		return nil, TableDoesNotExistError{Name: o.Table}
+	}
+	table.RemovePolicy(o.Name)
+
+	return []DBAction{NewDropPolicyAction(conn, table.Name, o.Name)}, nil
+}
+
+func (o *OpCreatePolicy) Validate(ctx context.Context, s *schema.Schema) error {
+	table := s.GetTable(o.Table)
+	if table == nil {
+		return TableDoesNotExistError{Name: o.Table}
+	}
+
+	if o.Name == "" {
+		return FieldRequiredError{Name: "name"}
+	}
+	if err := ValidateIdentifierLength(o.Name); err != nil {
+		return err
+	}
+	if _, ok := table.Policies[o.Name]; ok {
+		return PolicyAlreadyExistsError{Table: o.Table, Name: o.Name}
+	}
+
+	if err := validatePolicyExpressions(o.Name, o.Command, o.Using != "", o.WithCheck != ""); err != nil {
+		return err
+	}
+
+	table.AddPolicy(o.policy(table))
+	return nil
+}
+
+// policy returns the schema representation of the policy on `table`. Like
+// constraints in the virtual schema, the policy refers to columns by their
+// virtual names.
+func (o *OpCreatePolicy) policy(table *schema.Table) *schema.Policy {
+	command := string(o.Command)
+	if command == "" {
+		command = string(OpCreatePolicyCommandALL)
+	}
+	roles := slices.Clone(o.Roles)
+	if len(roles) == 0 {
+		roles = []string{"public"}
+	}
+
+	return &schema.Policy{
+		Name:       o.Name,
+		Command:    command,
+		Permissive: o.As != OpCreatePolicyAsRESTRICTIVE,
+		Roles:      roles,
+		Using:      o.Using,
+		WithCheck:  o.WithCheck,
+		Columns:    referencedColumns(slices.Collect(maps.Keys(table.Columns)), o.Using, o.WithCheck),
+	}
+}
+
+// validatePolicyExpressions returns an error if policy `name` for `command`
+// has an expression that Postgres does not allow for the command: INSERT
+// policies can only have a WITH CHECK expression and SELECT and DELETE
+// policies can only have a USING expression.
+func validatePolicyExpressions(name string, command OpCreatePolicyCommand, hasUsing, hasWithCheck bool) error {
+	switch command {
+	case "", OpCreatePolicyCommandALL, OpCreatePolicyCommandUPDATE:
+	case OpCreatePolicyCommandINSERT:
+		if hasUsing {
+			return InvalidPolicyExpressionError{Name: name, Command: string(command), Field: "using"}
+		}
+	case OpCreatePolicyCommandSELECT, OpCreatePolicyCommandDELETE:
+		if hasWithCheck {
+			return InvalidPolicyExpressionError{Name: name, Command: string(command), Field: "with_check"}
+		}
+	default:
+		return InvalidPolicyCommandError{Name: name, Command: string(command)}
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/xataio/pgroll/pkg/migrations"
+)
+
+// createDocumentsTableMigration creates a table with row level security
+// enabled for the policy tests
+var createDocumentsTableMigration = migrations.Migration{
+	Name: "01_create_table",
+	Operations: migrations.Operations{
+		&migrations.OpCreateTable{
+			Name: "documents",
+			Columns: []migrations.Column{
+				{Name: "id", Type: "serial", Pk: true},
+				{Name: "owner", Type: "text", Nullable: true},
+				{Name: "is_public", Type: "boolean", Default: ptr("false")},
+			},
+		},
+		&migrations.OpEnableRLS{Table: "documents"},
+	},
+}
+
+func TestCreatePolicy(t *testing.T) {
+	t.Parallel()
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "create policy",
+			migrations: []migrations.Migration{
+				createDocumentsTableMigration,
+				{
+					Name: "02_create_policy",
+					Operations: migrations.Operations{
+						&migrations.OpCreatePolicy{
+							Table:   "documents",
+							Name:    "owner_read",
+							Command: migrations.OpCreatePolicyCommandSELECT,
+							Using:   "owner = current_user OR is_public",
+						},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB, schema string) {
+				// The policy is created on migration start
+				PolicyMustExist(t, db, schema, "documents", "owner_read")
+				PolicyMustReferenceColumns(t, db, schema, "documents", "owner_read", "is_public", "owner")
+			},
+			afterRollback: func(t *testing.T, db *sql.DB, schema string) {
+				// The policy is dropped on rollback
+				PolicyMustNotExist(t, db, schema, "documents", "owner_read")
+			},
+			afterComplete: func(t *testing.T, db *sql.DB, schema string) {
+				PolicyMustExist(t, db, schema, "documents", "owner_read")
+				RowLevelSecurityMustBeEnabled(t, db, schema, "documents", false)
+			},
+		},
+		{
+			name: "create policy referencing a column renamed in the same migration",
+			migrations: []migrations.Migration{
+				createDocumentsTableMigration,
+				{
+					Name: "02_create_policy",
+					Operations: migrations.Operations{
+						&migrations.OpRenameColumn{
+							Table: "documents",
+							From:  "owner",
+							To:    "author",
+						},
+						&migrations.OpCreatePolicy{
+							Table:     "documents",
+							Name:      "author_insert",
+							Command:   migrations.OpCreatePolicyCommandINSERT,
+							Roles:     []string{"public"},
+							WithCheck: "author = current_user",
+						},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB, schema string) {
+				// The policy references the column by its old physical name
+				PolicyMustExist(t, db, schema, "documents", "author_insert")
+				PolicyMustReferenceColumns(t, db, schema, "documents", "author_insert", "owner")
+			},
+			afterRollback: func(t *testing.T, db *sql.DB, schema string) {
+				PolicyMustNotExist(t, db, schema, "documents", "author_insert")
+			},
+			afterComplete: func(t *testing.T, db *sql.DB, schema string) {
+				// The policy follows the column when it is renamed
+				PolicyMustReferenceColumns(t, db, schema, "documents", "author_insert", "author")
+			},
+		},
+	})
+}
+
+func TestCreatePolicyValidation(t *testing.T) {
+	t.Parallel()
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "table must exist",
+			migrations: []migrations.Migration{
+				createDocumentsTableMigration,
+				{
+					Name: "02_create_policy",
+					Operations: migrations.Operations{
+						&migrations.OpCreatePolicy{
+							Table: "doesntexist",
+							Name:  "owner_all",
+							Using: "true",
+						},
+					},
+				},
+			},
+			wantStartErr: migrations.TableDoesNotExistError{Name: "doesntexist"},
+		},
+		{
+			name: "policy must not already exist",
+			migrations: []migrations.Migration{
+				createDocumentsTableMigration,
+				{
+					Name: "02_create_policy",
+					Operations: migrations.Operations{
+						&migrations.OpCreatePolicy{
+							Table: "documents",
+							Name:  "owner_all",
+							Using: "owner = current_user",
+						},
+						&migrations.OpCreatePolicy{
+							Table: "documents",
+							Name:  "owner_all",
+							Using: "true",
+						},
+					},
+				},
+			},
+			wantStartErr: migrations.PolicyAlreadyExistsError{Table: "documents", Name: "owner_all"},
+		},
+		{
+			name: "insert policies can not have a using expression",
+			migrations: []migrations.Migration{
+				createDocumentsTableMigration,
+				{
+					Name: "02_create_policy",
+					Operations: migrations.Operations{
+						&migrations.OpCreatePolicy{
+							Table:   "documents",
+							Name:    "owner_insert",
+							Command: migrations.OpCreatePolicyCommandINSERT,
+							Using:   "owner = current_user",
+						},
+					},
+				},
+			},
+			wantStartErr: migrations.InvalidPolicyExpressionError{Name: "owner_insert", Command: "INSERT", Field: "using"},
+		},
+	})
+}
+
+func TestPolicyColumnChanges(t *testing.T) {
+	t.Parallel()
+
+	createPolicyMigration := migrations.Migration{
+		Name: "02_create_policy",
+		Operations: migrations.Operations{
+			&migrations.OpCreatePolicy{
+				Table: "documents",
+				Name:  "owner_all",
+				Using: "owner = current_user",
+			},
+		},
+	}
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "policy is rewritten when a column it references is duplicated",
+			migrations: []migrations.Migration{
+				createDocumentsTableMigration,
+				createPolicyMigration,
+				{
+					Name: "03_set_not_null",
+					Operations: migrations.Operations{
+						&migrations.OpAlterColumn{
+							Table:    "documents",
+							Column:   "owner",
+							Nullable: ptr(false),
+							Up:       "COALESCE(owner, 'nobody')",
+							Down:     "owner",
+						},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB, schema string) {
+				// The policy references the old column during the migration
+				PolicyMustReferenceColumns(t, db, schema, "documents", "owner_all", "owner")
+			},
+			afterRollback: func(t *testing.T, db *sql.DB, schema string) {
+				PolicyMustReferenceColumns(t, db, schema, "documents", "owner_all", "owner")
+			},
+			afterComplete: func(t *testing.T, db *sql.DB, schema string) {
+				// The old column has been dropped and the policy references the
+				// new column that took its name
+				PolicyMustExist(t, db, schema, "documents", "owner_all")
+				PolicyMustReferenceColumns(t, db, schema, "documents", "owner_all", "owner")
+				TableMustBeCleanedUp(t, db, schema, "documents", "owner")
+			},
+		},
+		{
+			name: "policy follows a renamed column",
+			migrations: []migrations.Migration{
+				createDocumentsTableMigration,
+				createPolicyMigration,
+				{
+					Name: "03_rename_column",
+					Operations: migrations.Operations{
+						&migrations.OpRenameColumn{
+							Table: "documents",
+							From:  "owner",
+							To:    "author",
+						},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB, schema string) {
+				PolicyMustReferenceColumns(t, db, schema, "documents", "owner_all", "owner")
+			},
+			afterRollback: func(t *testing.T, db *sql.DB, schema string) {
+				PolicyMustReferenceColumns(t, db, schema, "documents", "owner_all", "owner")
+			},
+			afterComplete: func(t *testing.T, db *sql.DB, schema string) {
+				PolicyMustReferenceColumns(t, db, schema, "documents", "owner_all", "author")
+			},
+		},
+	})
+}
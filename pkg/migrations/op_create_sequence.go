@@ -0,0 +1,146 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"context"
+
+	"github.com/xataio/pgroll/pkg/db"
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+var (
+	_ Operation  = (*OpCreateSequence)(nil)
+	_ Createable = (*OpCreateSequence)(nil)
+)
+
+func (o *OpCreateSequence) Start(ctx context.Context, l Logger, conn db.DB, s *schema.Schema) (*StartResult, error) {
+	l.LogOperationStart(o)
+
+	// The sequence is created in the underlying schema straight away so that
+	// columns added in the same migration can take their default from it. It
+	// has a new name, so the old version of the schema is unaffected.
+	action := NewCreateSequenceAction(conn, o.Name, o.dataType(), o.options())
+	if o.OwnedBy != "" {
+		tableName, columnName, _ := parseSequenceOwner(o.OwnedBy)
+		table := s.GetTable(tableName)
+		if table == nil {
+			return nil, TableDoesNotExistError{Name: tableName}
+		}
+		column := table.GetColumn(columnName)
+		if column == nil {
+			return nil, ColumnDoesNotExistError{Table: tableName, Name: columnName}
+		}
+		action = action.OwnedBy(table.Name, column.Name)
+	}
+
+	s.AddSequence(o.Name, o.sequence())
+
+	return &StartResult{Actions: []DBAction{action}}, nil
+}
+
+func (o *OpCreateSequence) Complete(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationComplete(o)
+
+	// No-op
+	return nil, nil
+}
+
+func (o *OpCreateSequence) Rollback(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationRollback(o)
+
+	return []DBAction{NewDropSequenceAction(conn, o.Name)}, nil
+}
+
+func (o *OpCreateSequence) Validate(ctx context.Context, s *schema.Schema) error {
+	if o.Name == "" {
+		return FieldRequiredError{Name: "name"}
+	}
+
+	if err := ValidateIdentifierLength(o.Name); err != nil {
+		return err
+	}
+
+	if s.GetSequence(o.Name) != nil {
+		return SequenceAlreadyExistsError{Name: o.Name}
+	}
+
+	if _, _, ok := sequenceDataTypeRange(o.dataType()); !ok {
+		return InvalidSequenceDataTypeError{Name: o.Name, DataType: string(o.As)}
+	}
+
+	if err := o.options().validate(o.sequence()); err != nil {
+		return err
+	}
+
+	if o.OwnedBy != "" {
+		tableName, columnName, ok := parseSequenceOwner(o.OwnedBy)
+		if !ok {
+			return InvalidSequenceOwnerError{Name: o.Name, OwnedBy: o.OwnedBy}
+		}
+		table := s.GetTable(tableName)
+		if table == nil {
+			return TableDoesNotExistError{Name: tableName}
+		}
+		if table.GetColumn(columnName) == nil {
+			return ColumnDoesNotExistError{Table: tableName, Name: columnName}
+		}
+	}
+
+	s.AddSequence(o.Name, o.sequence())
+
+	return nil
+}
+
+func (o *OpCreateSequence) dataType() string {
+	if o.As == "" {
+		return defaultSequenceDataType
+	}
+	return string(o.As)
+}
+
+func (o *OpCreateSequence) options() sequenceOptions {
+	opts := sequenceOptions{
+		start:     o.StartWith,
+		increment: o.Increment,
+		minValue:  o.MinValue,
+		maxValue:  o.MaxValue,
+		cache:     o.Cache,
+	}
+	if o.Cycle {
+		opts.cycle = &o.Cycle
+	}
+	return opts
+}
+
+// sequence returns the sequence created by the operation, with the defaults
+// postgres uses for options that are not set
+func (o *OpCreateSequence) sequence() *schema.Sequence {
+	typeMin, typeMax, _ := sequenceDataTypeRange(o.dataType())
+
+	seq := &schema.Sequence{
+		Name:      o.Name,
+		DataType:  o.dataType(),
+		Increment: 1,
+		MinValue:  1,
+		MaxValue:  typeMax,
+		Cache:     1,
+		OwnedBy:   o.OwnedBy,
+	}
+	if o.Increment != nil && *o.Increment < 0 {
+		seq.MinValue = typeMin
+		seq.MaxValue = -1
+	}
+	o.options().apply(seq)
+
+	// Ascending sequences start at their minimum value and descending
+	// sequences at their maximum value
+	if o.StartWith == nil {
+		seq.Start = seq.MinValue
+		if seq.Increment < 0 {
+			seq.Start = seq.MaxValue
+		}
+	}
+
+	return seq
+}
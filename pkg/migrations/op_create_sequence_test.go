@@ -0,0 +1,230 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xataio/pgroll/pkg/migrations"
+	"github.com/xataio/pgroll/pkg/roll"
+)
+
+// createInvoicesTableMigration creates an `invoices` table used by the
+// sequence tests
+var createInvoicesTableMigration = migrations.Migration{
+	Name: "01_create_table",
+	Operations: migrations.Operations{
+		&migrations.OpCreateTable{
+			Name: "invoices",
+			Columns: []migrations.Column{
+				{Name: "id", Type: "serial", Pk: true},
+				{Name: "total", Type: "integer"},
+			},
+		},
+	},
+}
+
+func TestCreateSequence(t *testing.T) {
+	t.Parallel()
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "create sequence used by a new column",
+			migrations: []migrations.Migration{
+				createInvoicesTableMigration,
+				{
+					Name: "02_create_sequence",
+					Operations: migrations.Operations{
+						&migrations.OpCreateSequence{
+							Name:      "invoice_number",
+							As:        migrations.OpCreateSequenceAsInteger,
+							StartWith: ptr(1000),
+							Increment: ptr(10),
+						},
+						&migrations.OpAddColumn{
+							Table: "invoices",
+							Column: migrations.Column{
+								Name:    "number",
+								Type:    "integer",
+								Default: ptr("nextval('invoice_number')"),
+							},
+							Up: "nextval('invoice_number')",
+						},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB, schema string) {
+				SequenceMustExist(t, db, schema, "invoice_number")
+
+				// Rows inserted into the new version take their number from the sequence
+				MustInsert(t, db, schema, "02_create_sequence", "invoices", map[string]string{
+					"total": "100",
+				})
+
+				rows := MustSelect(t, db, schema, "02_create_sequence", "invoices")
+				assert.Equal(t, []map[string]any{
+					{"id": 1, "total": 100, "number": 1000},
+				}, rows)
+
+				// The sequence can be used by name from the new version schema
+				mustSetSearchPath(t, db, roll.VersionedSchemaName(schema, "02_create_sequence"))
+				var next int
+				require.NoError(t, db.QueryRow("SELECT nextval('invoice_number')").Scan(&next))
+				assert.Equal(t, 1010, next)
+			},
+			afterRollback: func(t *testing.T, db *sql.DB, schema string) {
+				SequenceMustNotExist(t, db, schema, "invoice_number")
+			},
+			afterComplete: func(t *testing.T, db *sql.DB, schema string) {
+				SequenceMustExist(t, db, schema, "invoice_number")
+
+				MustInsert(t, db, schema, "02_create_sequence", "invoices", map[string]string{
+					"total": "200",
+				})
+
+				rows := MustSelect(t, db, schema, "02_create_sequence", "invoices")
+				assert.ElementsMatch(t, []map[string]any{
+					{"id": 1, "total": 100, "number": 1000},
+					{"id": 2, "total": 200, "number": 1010},
+				}, rows)
+			},
+		},
+		{
+			name: "create sequence owned by a column",
+			migrations: []migrations.Migration{
+				createInvoicesTableMigration,
+				{
+					Name: "02_create_sequence",
+					Operations: migrations.Operations{
+						&migrations.OpCreateSequence{
+							Name:    "invoice_total_seq",
+							OwnedBy: "invoices.total",
+						},
+					},
+				},
+				{
+					Name: "03_drop_column",
+					Operations: migrations.Operations{
+						&migrations.OpDropColumn{
+							Table:  "invoices",
+							Column: "total",
+							Down:   "0",
+						},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB, schema string) {
+				SequenceMustExist(t, db, schema, "invoice_total_seq")
+			},
+			afterRollback: func(t *testing.T, db *sql.DB, schema string) {
+				SequenceMustExist(t, db, schema, "invoice_total_seq")
+			},
+			afterComplete: func(t *testing.T, db *sql.DB, schema string) {
+				// The sequence is dropped with the column owning it
+				SequenceMustNotExist(t, db, schema, "invoice_total_seq")
+			},
+		},
+	})
+}
+
+func TestCreateSequenceValidation(t *testing.T) {
+	t.Parallel()
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "sequence must not already exist",
+			migrations: []migrations.Migration{
+				createInvoicesTableMigration,
+				{
+					Name: "02_create_sequence",
+					Operations: migrations.Operations{
+						&migrations.OpCreateSequence{Name: "invoices_id_seq"},
+					},
+				},
+			},
+			wantStartErr: migrations.SequenceAlreadyExistsError{Name: "invoices_id_seq"},
+		},
+		{
+			name: "data type must be valid",
+			migrations: []migrations.Migration{
+				createInvoicesTableMigration,
+				{
+					Name: "02_create_sequence",
+					Operations: migrations.Operations{
+						&migrations.OpCreateSequence{Name: "invoice_number", As: "numeric"},
+					},
+				},
+			},
+			wantStartErr: migrations.InvalidSequenceDataTypeError{Name: "invoice_number", DataType: "numeric"},
+		},
+		{
+			name: "increment must not be zero",
+			migrations: []migrations.Migration{
+				createInvoicesTableMigration,
+				{
+					Name: "02_create_sequence",
+					Operations: migrations.Operations{
+						&migrations.OpCreateSequence{Name: "invoice_number", Increment: ptr(0)},
+					},
+				},
+			},
+			wantStartErr: migrations.InvalidSequenceOptionsError{Name: "invoice_number", Reason: "increment must not be zero"},
+		},
+		{
+			name: "start must be within the range of the sequence",
+			migrations: []migrations.Migration{
+				createInvoicesTableMigration,
+				{
+					Name: "02_create_sequence",
+					Operations: migrations.Operations{
+						&migrations.OpCreateSequence{Name: "invoice_number", MinValue: ptr(100), StartWith: ptr(1)},
+					},
+				},
+			},
+			wantStartErr: migrations.InvalidSequenceOptionsError{Name: "invoice_number", Reason: "start must be between min_value and max_value"},
+		},
+		{
+			name: "owner must be a table and column",
+			migrations: []migrations.Migration{
+				createInvoicesTableMigration,
+				{
+					Name: "02_create_sequence",
+					Operations: migrations.Operations{
+						&migrations.OpCreateSequence{Name: "invoice_number", OwnedBy: "invoices"},
+					},
+				},
+			},
+			wantStartErr: migrations.InvalidSequenceOwnerError{Name: "invoice_number", OwnedBy: "invoices"},
+		},
+		{
+			name: "owning table must exist",
+			migrations: []migrations.Migration{
+				createInvoicesTableMigration,
+				{
+					Name: "02_create_sequence",
+					Operations: migrations.Operations{
+						&migrations.OpCreateSequence{Name: "invoice_number", OwnedBy: "orders.number"},
+					},
+				},
+			},
+			wantStartErr: migrations.TableDoesNotExistError{Name: "orders"},
+		},
+		{
+			name: "owning column must exist",
+			migrations: []migrations.Migration{
+				createInvoicesTableMigration,
+				{
+					Name: "02_create_sequence",
+					Operations: migrations.Operations{
+						&migrations.OpCreateSequence{Name: "invoice_number", OwnedBy: "invoices.number"},
+					},
+				},
+			},
+			wantStartErr: migrations.ColumnDoesNotExistError{Table: "invoices", Name: "number"},
+		},
+	})
+}
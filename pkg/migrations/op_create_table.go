@@ -212,6 +212,9 @@ func (o *OpCreateTable) updateSchema(s *schema.Schema) *schema.Schema {
 		if col.Comment != nil {
 			columns[col.Name].Comment = *col.Comment
 		}
+		if col.Generated != nil && col.Generated.Identity != nil {
+			columns[col.Name].Identity = string(col.Generated.Identity.UserSpecifiedValues)
+		}
 	}
 
 	uniqueConstraints := make(map[string]*schema.UniqueConstraint, 0)
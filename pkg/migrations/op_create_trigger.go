@@ -0,0 +1,127 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"context"
+
+	"github.com/xataio/pgroll/pkg/db"
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+var (
+	_ Operation  = (*OpCreateTrigger)(nil)
+	_ Createable = (*OpCreateTrigger)(nil)
+)
+
+// Start creates the trigger on the underlying table. The trigger fires for
+// writes made through both the old and the new version of the schema.
+func (o *OpCreateTrigger) Start(ctx context.Context, l Logger, conn db.DB, s *schema.Schema) (*StartResult, error) {
+	l.LogOperationStart(o)
+
+	table := s.GetTable(o.Table)
+	if table == nil {
+		return nil, TableDoesNotExistError{Name: o.Table}
+	}
+
+	// The condition refers to columns by their names in the new version of the
+	// schema, which may differ from their physical names
+	when := physicalExpression(table, o.When)
+	table.AddTrigger(o.trigger())
+
+	return &StartResult{Actions: []DBAction{
+		NewCreateTriggerAction(conn, table.Name, o.Name, o.Function, string(o.Timing), o.events(), o.forEach(), when),
+	}}, nil
+}
+
+func (o *OpCreateTrigger) Complete(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationComplete(o)
+
+	// No-op
+	return nil, nil
+}
+
+func (o *OpCreateTrigger) Rollback(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationRollback(o)
+
+	table := s.GetTable(o.Table)
+	if table == nil {
+		return nil, TableDoesNotExistError{Name: o.Table}
+	}
+	table.RemoveTrigger(o.Name)
+
+	return []DBAction{NewDropTriggerAction(conn, table.Name, o.Name)}, nil
+}
+
+func (o *OpCreateTrigger) Validate(ctx context.Context, s *schema.Schema) error {
+	if o.Name == "" {
+		return FieldRequiredError{Name: "name"}
+	}
+
+	if err := ValidateIdentifierLength(o.Name); err != nil {
+		return err
+	}
+
+	table := s.GetTable(o.Table)
+	if table == nil {
+		return TableDoesNotExistError{Name: o.Table}
+	}
+
+	if _, ok := table.Triggers[o.Name]; ok {
+		return TriggerAlreadyExistsError{Table: o.Table, Name: o.Name}
+	}
+
+	fn := s.GetFunction(o.Function)
+	if fn == nil {
+		return FunctionDoesNotExistError{Name: o.Function}
+	}
+	if !fn.IsTriggerFunction() {
+		return FunctionNotTriggerFunctionError{Name: o.Function}
+	}
+
+	switch o.Timing {
+	case OpCreateTriggerTimingBEFORE, OpCreateTriggerTimingAFTER, OpCreateTriggerTimingINSTEADOF:
+	default:
+		return InvalidTriggerTimingError{Name: o.Name, Timing: string(o.Timing)}
+	}
+
+	if len(o.Events) == 0 {
+		return FieldRequiredError{Name: "events"}
+	}
+	for _, event := range o.Events {
+		switch event {
+		case OpCreateTriggerEventsElemINSERT, OpCreateTriggerEventsElemUPDATE, OpCreateTriggerEventsElemDELETE, OpCreateTriggerEventsElemTRUNCATE:
+		default:
+			return InvalidTriggerEventError{Name: o.Name, Event: string(event)}
+		}
+	}
+
+	table.AddTrigger(o.trigger())
+
+	return nil
+}
+
+func (o *OpCreateTrigger) trigger() *schema.Trigger {
+	return &schema.Trigger{
+		Name:     o.Name,
+		Function: o.Function,
+		Timing:   string(o.Timing),
+		Events:   o.events(),
+		ForEach:  o.forEach(),
+	}
+}
+
+func (o *OpCreateTrigger) events() []string {
+	events := make([]string, len(o.Events))
+	for i, event := range o.Events {
+		events[i] = string(event)
+	}
+	return events
+}
+
+func (o *OpCreateTrigger) forEach() string {
+	if o.ForEach == "" {
+		return string(OpCreateTriggerForEachROW)
+	}
+	return string(o.ForEach)
+}
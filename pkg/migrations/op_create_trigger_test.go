@@ -0,0 +1,235 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations_test
+
+import (
+	"database/sql"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xataio/pgroll/pkg/migrations"
+)
+
+// createTriggerFunctionMigration creates the `users` table and a trigger
+// function setting the `name_upper` column
+var createTriggerFunctionMigration = migrations.Migration{
+	Name: "01_create_table",
+	Operations: append(slices.Clone(createUsersTableMigration.Operations),
+		&migrations.OpCreateFunction{
+			Name:       "set_name_upper",
+			Returns:    "trigger",
+			Definition: "BEGIN NEW.name_upper := upper(NEW.name); RETURN NEW; END",
+		},
+	),
+}
+
+func TestCreateTrigger(t *testing.T) {
+	t.Parallel()
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "create trigger with a condition",
+			migrations: []migrations.Migration{
+				createTriggerFunctionMigration,
+				{
+					Name: "02_create_trigger",
+					Operations: migrations.Operations{
+						&migrations.OpCreateTrigger{
+							Name:     "users_set_name_upper",
+							Table:    "users",
+							Function: "set_name_upper",
+							Timing:   migrations.OpCreateTriggerTimingBEFORE,
+							Events:   []migrations.OpCreateTriggerEventsElem{migrations.OpCreateTriggerEventsElemINSERT},
+							ForEach:  migrations.OpCreateTriggerForEachROW,
+							When:     "NEW.name <> 'root'",
+						},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB, schema string) {
+				TriggerMustExist(t, db, schema, "users", "users_set_name_upper")
+
+				MustInsert(t, db, schema, "02_create_trigger", "users", map[string]string{
+					"name": "alice",
+				})
+				// The trigger does not fire for rows not matching the condition
+				MustInsert(t, db, schema, "02_create_trigger", "users", map[string]string{
+					"name": "root",
+				})
+
+				assert.Equal(t, []map[string]any{
+					{"id": 1, "name": "alice", "name_upper": "ALICE"},
+					{"id": 2, "name": "root", "name_upper": nil},
+				}, MustSelect(t, db, schema, "02_create_trigger", "users"))
+			},
+			afterRollback: func(t *testing.T, db *sql.DB, schema string) {
+				TriggerMustNotExist(t, db, schema, "users", "users_set_name_upper")
+
+				// The function is left in place
+				FunctionMustExist(t, db, schema, "set_name_upper")
+			},
+			afterComplete: func(t *testing.T, db *sql.DB, schema string) {
+				TriggerMustExist(t, db, schema, "users", "users_set_name_upper")
+			},
+		},
+		{
+			name: "trigger condition refers to columns by their names in the new version",
+			migrations: []migrations.Migration{
+				createTriggerFunctionMigration,
+				{
+					Name: "02_create_trigger",
+					Operations: migrations.Operations{
+						&migrations.OpRenameColumn{
+							Table: "users",
+							From:  "id",
+							To:    "user_id",
+						},
+						&migrations.OpCreateTrigger{
+							Name:     "users_set_name_upper",
+							Table:    "users",
+							Function: "set_name_upper",
+							Timing:   migrations.OpCreateTriggerTimingBEFORE,
+							Events:   []migrations.OpCreateTriggerEventsElem{migrations.OpCreateTriggerEventsElemINSERT},
+							When:     "NEW.user_id > 1",
+						},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB, schema string) {
+				MustInsert(t, db, schema, "02_create_trigger", "users", map[string]string{
+					"name": "alice",
+				})
+				MustInsert(t, db, schema, "02_create_trigger", "users", map[string]string{
+					"name": "bob",
+				})
+
+				assert.Equal(t, []map[string]any{
+					{"user_id": 1, "name": "alice", "name_upper": nil},
+					{"user_id": 2, "name": "bob", "name_upper": "BOB"},
+				}, MustSelect(t, db, schema, "02_create_trigger", "users"))
+			},
+			afterRollback: func(t *testing.T, db *sql.DB, schema string) {
+				TriggerMustNotExist(t, db, schema, "users", "users_set_name_upper")
+			},
+			afterComplete: func(t *testing.T, db *sql.DB, schema string) {
+				TriggerMustExist(t, db, schema, "users", "users_set_name_upper")
+			},
+		},
+	})
+}
+
+func TestCreateTriggerValidation(t *testing.T) {
+	t.Parallel()
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "table must exist",
+			migrations: []migrations.Migration{
+				createTriggerFunctionMigration,
+				{
+					Name: "02_create_trigger",
+					Operations: migrations.Operations{
+						&migrations.OpCreateTrigger{
+							Name:     "users_set_name_upper",
+							Table:    "doesntexist",
+							Function: "set_name_upper",
+							Timing:   migrations.OpCreateTriggerTimingBEFORE,
+							Events:   []migrations.OpCreateTriggerEventsElem{migrations.OpCreateTriggerEventsElemINSERT},
+						},
+					},
+				},
+			},
+			wantStartErr: migrations.TableDoesNotExistError{Name: "doesntexist"},
+		},
+		{
+			name: "function must exist",
+			migrations: []migrations.Migration{
+				createTriggerFunctionMigration,
+				{
+					Name: "02_create_trigger",
+					Operations: migrations.Operations{
+						&migrations.OpCreateTrigger{
+							Name:     "users_set_name_upper",
+							Table:    "users",
+							Function: "doesntexist",
+							Timing:   migrations.OpCreateTriggerTimingBEFORE,
+							Events:   []migrations.OpCreateTriggerEventsElem{migrations.OpCreateTriggerEventsElemINSERT},
+						},
+					},
+				},
+			},
+			wantStartErr: migrations.FunctionDoesNotExistError{Name: "doesntexist"},
+		},
+		{
+			name: "function must return trigger",
+			migrations: []migrations.Migration{
+				createTriggerFunctionMigration,
+				{
+					Name: "02_create_trigger",
+					Operations: migrations.Operations{
+						&migrations.OpCreateFunction{
+							Name:       "user_count",
+							Returns:    "bigint",
+							Language:   "sql",
+							Definition: "SELECT count(*) FROM users",
+						},
+						&migrations.OpCreateTrigger{
+							Name:     "users_count",
+							Table:    "users",
+							Function: "user_count",
+							Timing:   migrations.OpCreateTriggerTimingAFTER,
+							Events:   []migrations.OpCreateTriggerEventsElem{migrations.OpCreateTriggerEventsElemINSERT},
+						},
+					},
+				},
+			},
+			wantStartErr: migrations.FunctionNotTriggerFunctionError{Name: "user_count"},
+		},
+		{
+			name: "trigger must not already exist",
+			migrations: []migrations.Migration{
+				createTriggerFunctionMigration,
+				{
+					Name: "02_create_trigger",
+					Operations: migrations.Operations{
+						&migrations.OpCreateTrigger{
+							Name:     "users_set_name_upper",
+							Table:    "users",
+							Function: "set_name_upper",
+							Timing:   migrations.OpCreateTriggerTimingBEFORE,
+							Events:   []migrations.OpCreateTriggerEventsElem{migrations.OpCreateTriggerEventsElemINSERT},
+						},
+						&migrations.OpCreateTrigger{
+							Name:     "users_set_name_upper",
+							Table:    "users",
+							Function: "set_name_upper",
+							Timing:   migrations.OpCreateTriggerTimingBEFORE,
+							Events:   []migrations.OpCreateTriggerEventsElem{migrations.OpCreateTriggerEventsElemUPDATE},
+						},
+					},
+				},
+			},
+			wantStartErr: migrations.TriggerAlreadyExistsError{Table: "users", Name: "users_set_name_upper"},
+		},
+		{
+			name: "events are required",
+			migrations: []migrations.Migration{
+				createTriggerFunctionMigration,
+				{
+					Name: "02_create_trigger",
+					Operations: migrations.Operations{
+						&migrations.OpCreateTrigger{
+							Name:     "users_set_name_upper",
+							Table:    "users",
+							Function: "set_name_upper",
+							Timing:   migrations.OpCreateTriggerTimingBEFORE,
+						},
+					},
+				},
+			},
+			wantStartErr: migrations.FieldRequiredError{Name: "events"},
+		},
+	})
+}
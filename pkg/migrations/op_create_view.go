@@ -0,0 +1,62 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"context"
+
+	"github.com/xataio/pgroll/pkg/db"
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+var (
+	_ Operation  = (*OpCreateView)(nil)
+	_ Createable = (*OpCreateView)(nil)
+)
+
+func (o *OpCreateView) Start(ctx context.Context, l Logger, conn db.DB, s *schema.Schema) (*StartResult, error) {
+	l.LogOperationStart(o)
+
+	// Add the view to the in-memory schema representation. The view is created
+	// in the new version schema along with the views for tables; it is created
+	// in the underlying schema on completion, once the tables it reads from
+	// have their final shape.
+	s.AddView(o.Name, &schema.View{Name: o.Name, Definition: o.Definition})
+
+	return &StartResult{}, nil
+}
+
+func (o *OpCreateView) Complete(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationComplete(o)
+
+	return []DBAction{NewCreateViewAction(conn, o.Name, o.Definition)}, nil
+}
+
+func (o *OpCreateView) Rollback(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationRollback(o)
+
+	// No-op; the view only exists in the new version schema, which is dropped
+	return nil, nil
+}
+
+func (o *OpCreateView) Validate(ctx context.Context, s *schema.Schema) error {
+	if err := ValidateIdentifierLength(o.Name); err != nil {
+		return err
+	}
+
+	if s.GetView(o.Name) != nil {
+		return ViewAlreadyExistsError{Name: o.Name}
+	}
+
+	if s.GetTable(o.Name) != nil {
+		return TableAlreadyExistsError{Name: o.Name}
+	}
+
+	if o.Definition == "" {
+		return FieldRequiredError{Name: "definition"}
+	}
+
+	s.AddView(o.Name, &schema.View{Name: o.Name, Definition: o.Definition})
+
+	return nil
+}
@@ -0,0 +1,246 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xataio/pgroll/pkg/migrations"
+)
+
+func TestCreateView(t *testing.T) {
+	t.Parallel()
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "create view",
+			migrations: []migrations.Migration{
+				{
+					Name: "01_create_table",
+					Operations: migrations.Operations{
+						&migrations.OpCreateTable{
+							Name: "users",
+							Columns: []migrations.Column{
+								{Name: "id", Type: "serial", Pk: true},
+								{Name: "name", Type: "text"},
+							},
+						},
+					},
+				},
+				{
+					Name: "02_create_view",
+					Operations: migrations.Operations{
+						&migrations.OpCreateView{
+							Name:       "user_names",
+							Definition: "SELECT id, name FROM users",
+						},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB, schema string) {
+				// The view exists in the new version schema only
+				ViewMustExist(t, db, schema, "02_create_view", "user_names")
+				ViewMustNotExist(t, db, schema, "01_create_table", "user_names")
+				BaseViewMustNotExist(t, db, schema, "user_names")
+
+				// The view reads from the table
+				MustInsert(t, db, schema, "02_create_view", "users", map[string]string{
+					"name": "alice",
+				})
+				rows := MustSelect(t, db, schema, "02_create_view", "user_names")
+				assert.Equal(t, []map[string]any{
+					{"id": 1, "name": "alice"},
+				}, rows)
+			},
+			afterRollback: func(t *testing.T, db *sql.DB, schema string) {
+				BaseViewMustNotExist(t, db, schema, "user_names")
+			},
+			afterComplete: func(t *testing.T, db *sql.DB, schema string) {
+				// The view is created in the underlying schema on completion
+				BaseViewMustExist(t, db, schema, "user_names")
+				ViewMustExist(t, db, schema, "02_create_view", "user_names")
+			},
+		},
+		{
+			name: "views reading from other views are created in the version schema",
+			migrations: []migrations.Migration{
+				{
+					Name: "01_create_table",
+					Operations: migrations.Operations{
+						&migrations.OpCreateTable{
+							Name: "users",
+							Columns: []migrations.Column{
+								{Name: "id", Type: "serial", Pk: true},
+								{Name: "name", Type: "text"},
+							},
+						},
+					},
+				},
+				{
+					Name: "02_create_views",
+					Operations: migrations.Operations{
+						&migrations.OpCreateView{
+							Name:       "user_names",
+							Definition: "SELECT id, name FROM users",
+						},
+						&migrations.OpCreateView{
+							Name:       "user_ids",
+							Definition: "SELECT id FROM user_names",
+						},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB, schema string) {
+				ViewMustExist(t, db, schema, "02_create_views", "user_names")
+				ViewMustExist(t, db, schema, "02_create_views", "user_ids")
+			},
+			afterRollback: func(t *testing.T, db *sql.DB, schema string) {
+				BaseViewMustNotExist(t, db, schema, "user_names")
+				BaseViewMustNotExist(t, db, schema, "user_ids")
+			},
+			afterComplete: func(t *testing.T, db *sql.DB, schema string) {
+				BaseViewMustExist(t, db, schema, "user_names")
+				BaseViewMustExist(t, db, schema, "user_ids")
+			},
+		},
+		{
+			name: "views are recreated in later version schemas",
+			migrations: []migrations.Migration{
+				{
+					Name: "01_create_table",
+					Operations: migrations.Operations{
+						&migrations.OpCreateTable{
+							Name: "users",
+							Columns: []migrations.Column{
+								{Name: "id", Type: "serial", Pk: true},
+								{Name: "name", Type: "text"},
+							},
+						},
+					},
+				},
+				{
+					Name: "02_create_view",
+					Operations: migrations.Operations{
+						&migrations.OpCreateView{
+							Name:       "user_names",
+							Definition: "SELECT id, name FROM users",
+						},
+					},
+				},
+				{
+					Name: "03_add_column",
+					Operations: migrations.Operations{
+						&migrations.OpAddColumn{
+							Table: "users",
+							Column: migrations.Column{
+								Name:     "email",
+								Type:     "text",
+								Nullable: true,
+							},
+						},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB, schema string) {
+				// The view exists in both version schemas
+				ViewMustExist(t, db, schema, "02_create_view", "user_names")
+				ViewMustExist(t, db, schema, "03_add_column", "user_names")
+
+				// The view in the new version schema reads from the new version of
+				// the table
+				MustInsert(t, db, schema, "03_add_column", "users", map[string]string{
+					"name":  "alice",
+					"email": "alice@example.com",
+				})
+				rows := MustSelect(t, db, schema, "03_add_column", "user_names")
+				assert.Equal(t, []map[string]any{
+					{"id": 1, "name": "alice"},
+				}, rows)
+			},
+			afterRollback: func(t *testing.T, db *sql.DB, schema string) {
+				BaseViewMustExist(t, db, schema, "user_names")
+			},
+			afterComplete: func(t *testing.T, db *sql.DB, schema string) {
+				ViewMustExist(t, db, schema, "03_add_column", "user_names")
+			},
+		},
+	})
+}
+
+func TestCreateViewValidation(t *testing.T) {
+	t.Parallel()
+
+	createTableOp := &migrations.OpCreateTable{
+		Name: "users",
+		Columns: []migrations.Column{
+			{Name: "id", Type: "serial", Pk: true},
+			{Name: "name", Type: "text"},
+		},
+	}
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "view must not already exist",
+			migrations: []migrations.Migration{
+				{
+					Name: "01_create_view",
+					Operations: migrations.Operations{
+						createTableOp,
+						&migrations.OpCreateView{
+							Name:       "user_names",
+							Definition: "SELECT id, name FROM users",
+						},
+					},
+				},
+				{
+					Name: "02_create_view",
+					Operations: migrations.Operations{
+						&migrations.OpCreateView{
+							Name:       "user_names",
+							Definition: "SELECT name FROM users",
+						},
+					},
+				},
+			},
+			wantStartErr: migrations.ViewAlreadyExistsError{Name: "user_names"},
+		},
+		{
+			name: "view name must not be used by a table",
+			migrations: []migrations.Migration{
+				{
+					Name: "01_create_table",
+					Operations: migrations.Operations{
+						createTableOp,
+					},
+				},
+				{
+					Name: "02_create_view",
+					Operations: migrations.Operations{
+						&migrations.OpCreateView{
+							Name:       "users",
+							Definition: "SELECT 1",
+						},
+					},
+				},
+			},
+			wantStartErr: migrations.TableAlreadyExistsError{Name: "users"},
+		},
+		{
+			name: "definition is required",
+			migrations: []migrations.Migration{
+				{
+					Name: "01_create_view",
+					Operations: migrations.Operations{
+						&migrations.OpCreateView{
+							Name: "user_names",
+						},
+					},
+				},
+			},
+			wantStartErr: migrations.FieldRequiredError{Name: "definition"},
+		},
+	})
+}
@@ -0,0 +1,87 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"context"
+
+	"github.com/xataio/pgroll/pkg/db"
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+var (
+	_ Operation                      = (*OpDetachPartition)(nil)
+	_ Createable                     = (*OpDetachPartition)(nil)
+	_ RequiresSchemaRefreshOperation = (*OpDetachPartition)(nil)
+)
+
+func (o *OpDetachPartition) Start(ctx context.Context, l Logger, conn db.DB, s *schema.Schema) (*StartResult, error) {
+	l.LogOperationStart(o)
+
+	table := s.GetTable(o.Table)
+	if table == nil {
+		return nil, TableDoesNotExistError{Name: o.Table}
+	}
+
+	// Both versions of the schema read from the same partitioned table, so the
+	// rows of the partition remain visible in both until the partition is
+	// detached on completion
+	table.RemovePartition(o.Name)
+
+	return &StartResult{}, nil
+}
+
+// Complete detaches the partition. Postgres can only detach a partition
+// without blocking queries on the partitioned table if the table has no
+// default partition.
+func (o *OpDetachPartition) Complete(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationComplete(o)
+
+	table := s.GetTable(o.Table)
+	if table == nil {
+		return nil, TableDoesNotExistError{Name: o.Table}
+	}
+
+	concurrently := true
+	for _, p := range table.Partitions {
+		if isDefaultPartition(p) {
+			concurrently = false
+		}
+	}
+
+	return []DBAction{
+		NewDetachPartitionAction(conn, table.Name, o.Name, concurrently),
+	}, nil
+}
+
+func (o *OpDetachPartition) Rollback(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationRollback(o)
+
+	// No-op; the partition is only detached on completion
+	return nil, nil
+}
+
+func (o *OpDetachPartition) Validate(ctx context.Context, s *schema.Schema) error {
+	table := s.GetTable(o.Table)
+	if table == nil {
+		return TableDoesNotExistError{Name: o.Table}
+	}
+	if !table.IsPartitioned() {
+		return TableNotPartitionedError{Name: o.Table}
+	}
+
+	if o.Name == "" {
+		return FieldRequiredError{Name: "name"}
+	}
+	if _, ok := table.Partitions[o.Name]; !ok {
+		return PartitionDoesNotExistError{Table: o.Table, Name: o.Name}
+	}
+
+	table.RemovePartition(o.Name)
+	return nil
+}
+
+// RequiresSchemaRefresh is implemented so that the version schema is
+// recreated on completion, when the detached partition becomes a table in its
+// own right.
+func (o *OpDetachPartition) RequiresSchemaRefresh() {}
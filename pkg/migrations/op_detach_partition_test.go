@@ -0,0 +1,146 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xataio/pgroll/pkg/migrations"
+)
+
+func TestDetachPartition(t *testing.T) {
+	t.Parallel()
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "detach partition",
+			migrations: []migrations.Migration{
+				{
+					Name: "01_create_table",
+					Operations: migrations.Operations{
+						&migrations.OpRawSQL{
+							Up: `CREATE TABLE events (id integer, created_at date, PRIMARY KEY (id, created_at)) PARTITION BY RANGE (created_at);
+								CREATE TABLE events_2024 PARTITION OF events FOR VALUES FROM ('2024-01-01') TO ('2025-01-01');
+								CREATE TABLE events_2025 PARTITION OF events FOR VALUES FROM ('2025-01-01') TO ('2026-01-01');
+								INSERT INTO events VALUES (1, '2024-03-01'), (2, '2025-03-01')`,
+						},
+					},
+				},
+				{
+					Name: "02_detach_partition",
+					Operations: migrations.Operations{
+						&migrations.OpDetachPartition{
+							Table: "events",
+							Name:  "events_2024",
+						},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB, schema string) {
+				// The partition is only detached on completion
+				PartitionMustExist(t, db, schema, "events", "events_2024")
+				assert.Len(t, MustSelect(t, db, schema, "01_create_table", "events"), 2)
+				assert.Len(t, MustSelect(t, db, schema, "02_detach_partition", "events"), 2)
+			},
+			afterRollback: func(t *testing.T, db *sql.DB, schema string) {
+				PartitionMustExist(t, db, schema, "events", "events_2024")
+			},
+			afterComplete: func(t *testing.T, db *sql.DB, schema string) {
+				PartitionMustNotExist(t, db, schema, "events", "events_2024")
+				TableMustExist(t, db, schema, "events_2024")
+
+				// The rows of the detached partition are no longer visible through the
+				// partitioned table
+				assert.Len(t, MustSelect(t, db, schema, "02_detach_partition", "events"), 1)
+
+				// The detached partition is visible as a table in the new version
+				// schema
+				ViewMustExist(t, db, schema, "02_detach_partition", "events_2024")
+				assert.Len(t, MustSelect(t, db, schema, "02_detach_partition", "events_2024"), 1)
+			},
+		},
+		{
+			name: "detach partition from a table with a default partition",
+			migrations: []migrations.Migration{
+				{
+					Name: "01_create_table",
+					Operations: migrations.Operations{
+						&migrations.OpRawSQL{
+							Up: `CREATE TABLE events (id integer, created_at date, PRIMARY KEY (id, created_at)) PARTITION BY RANGE (created_at);
+								CREATE TABLE events_2024 PARTITION OF events FOR VALUES FROM ('2024-01-01') TO ('2025-01-01');
+								CREATE TABLE events_default PARTITION OF events DEFAULT`,
+						},
+					},
+				},
+				{
+					Name: "02_detach_partition",
+					Operations: migrations.Operations{
+						&migrations.OpDetachPartition{
+							Table: "events",
+							Name:  "events_2024",
+						},
+					},
+				},
+			},
+			afterRollback: func(t *testing.T, db *sql.DB, schema string) {
+				PartitionMustExist(t, db, schema, "events", "events_2024")
+			},
+			afterComplete: func(t *testing.T, db *sql.DB, schema string) {
+				PartitionMustNotExist(t, db, schema, "events", "events_2024")
+				PartitionMustExist(t, db, schema, "events", "events_default")
+			},
+		},
+	})
+}
+
+func TestDetachPartitionValidation(t *testing.T) {
+	t.Parallel()
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "partition must exist",
+			migrations: []migrations.Migration{
+				createPartitionedTableMigration,
+				{
+					Name: "02_detach_partition",
+					Operations: migrations.Operations{
+						&migrations.OpDetachPartition{
+							Table: "events",
+							Name:  "events_2025",
+						},
+					},
+				},
+			},
+			wantStartErr: migrations.PartitionDoesNotExistError{Table: "events", Name: "events_2025"},
+		},
+		{
+			name: "table must be partitioned",
+			migrations: []migrations.Migration{
+				{
+					Name: "01_create_table",
+					Operations: migrations.Operations{
+						&migrations.OpCreateTable{
+							Name: "events",
+							Columns: []migrations.Column{
+								{Name: "id", Type: "serial", Pk: true},
+							},
+						},
+					},
+				},
+				{
+					Name: "02_detach_partition",
+					Operations: migrations.Operations{
+						&migrations.OpDetachPartition{
+							Table: "events",
+							Name:  "events_2024",
+						},
+					},
+				},
+			},
+			wantStartErr: migrations.TableNotPartitionedError{Name: "events"},
+		},
+	})
+}
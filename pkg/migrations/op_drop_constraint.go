@@ -90,6 +90,7 @@ func (o *OpDropConstraint) Complete(l Logger, conn db.DB, s *schema.Schema) ([]D
 			backfill.TriggerFunctionName(o.Table, TemporaryName(column.Name))),
 		NewAlterSequenceOwnerAction(conn, o.Table, column.Name, TemporaryName(column.Name)),
 		NewDropColumnAction(conn, table.Name, backfill.CNeedsBackfillColumn),
+		NewRewritePoliciesAction(conn, table, column.Name),
 		NewDropColumnAction(conn, o.Table, column.Name),
 		NewRenameDuplicatedColumnAction(conn, table, column.Name),
 	}, nil
@@ -0,0 +1,56 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"context"
+	"maps"
+	"slices"
+
+	"github.com/xataio/pgroll/pkg/db"
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+var (
+	_ Operation  = (*OpDropEnum)(nil)
+	_ Createable = (*OpDropEnum)(nil)
+)
+
+func (o *OpDropEnum) Start(ctx context.Context, l Logger, conn db.DB, s *schema.Schema) (*StartResult, error) {
+	l.LogOperationStart(o)
+
+	// Remove the enum from the in-memory schema representation; the type is
+	// dropped on completion
+	s.RemoveEnum(o.Name)
+
+	return &StartResult{}, nil
+}
+
+func (o *OpDropEnum) Complete(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationComplete(o)
+
+	return []DBAction{NewDropEnumAction(conn, o.Name)}, nil
+}
+
+func (o *OpDropEnum) Rollback(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationRollback(o)
+
+	// No-op
+	return nil, nil
+}
+
+func (o *OpDropEnum) Validate(ctx context.Context, s *schema.Schema) error {
+	if s.GetEnum(o.Name) == nil {
+		return EnumDoesNotExistError{Name: o.Name}
+	}
+
+	columns := s.EnumColumns(o.Name)
+	if len(columns) > 0 {
+		table := slices.Min(slices.Collect(maps.Keys(columns)))
+		return EnumInUseError{Name: o.Name, Table: table, Column: columns[table][0]}
+	}
+
+	s.RemoveEnum(o.Name)
+
+	return nil
+}
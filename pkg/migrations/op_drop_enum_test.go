@@ -0,0 +1,94 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/xataio/pgroll/pkg/migrations"
+)
+
+func TestDropEnum(t *testing.T) {
+	t.Parallel()
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "drop enum",
+			migrations: []migrations.Migration{
+				{
+					Name: "01_create_enum",
+					Operations: migrations.Operations{
+						&migrations.OpCreateEnum{
+							Name:   "mood",
+							Values: []string{"happy", "sad"},
+						},
+					},
+				},
+				{
+					Name: "02_drop_enum",
+					Operations: migrations.Operations{
+						&migrations.OpDropEnum{Name: "mood"},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB, schema string) {
+				// The enum type is only dropped on completion
+				EnumMustHaveValues(t, db, schema, "mood", []string{"happy", "sad"})
+			},
+			afterRollback: func(t *testing.T, db *sql.DB, schema string) {
+				EnumMustHaveValues(t, db, schema, "mood", []string{"happy", "sad"})
+			},
+			afterComplete: func(t *testing.T, db *sql.DB, schema string) {
+				EnumMustNotExist(t, db, schema, "mood")
+			},
+		},
+	})
+}
+
+func TestDropEnumValidation(t *testing.T) {
+	t.Parallel()
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "enum must exist",
+			migrations: []migrations.Migration{
+				{
+					Name: "01_drop_enum",
+					Operations: migrations.Operations{
+						&migrations.OpDropEnum{Name: "mood"},
+					},
+				},
+			},
+			wantStartErr: migrations.EnumDoesNotExistError{Name: "mood"},
+		},
+		{
+			name: "enum must not be in use",
+			migrations: []migrations.Migration{
+				{
+					Name: "01_create_enum",
+					Operations: migrations.Operations{
+						&migrations.OpCreateEnum{
+							Name:   "mood",
+							Values: []string{"happy", "sad"},
+						},
+						&migrations.OpCreateTable{
+							Name: "people",
+							Columns: []migrations.Column{
+								{Name: "id", Type: "serial", Pk: true},
+								{Name: "mood", Type: "mood", Nullable: true},
+							},
+						},
+					},
+				},
+				{
+					Name: "02_drop_enum",
+					Operations: migrations.Operations{
+						&migrations.OpDropEnum{Name: "mood"},
+					},
+				},
+			},
+			wantStartErr: migrations.EnumInUseError{Name: "mood", Table: "people", Column: "mood"},
+		},
+	})
+}
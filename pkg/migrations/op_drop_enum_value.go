@@ -0,0 +1,67 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"context"
+	"slices"
+
+	"github.com/xataio/pgroll/pkg/db"
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+var (
+	_ Operation  = (*OpDropEnumValue)(nil)
+	_ Createable = (*OpDropEnumValue)(nil)
+)
+
+// Postgres can not drop a value from an enum type, so a new enum type without
+// the value is swapped in using expand/contract. Rows holding the dropped
+// value are migrated to the replacement value, or to NULL.
+func (o *OpDropEnumValue) Start(ctx context.Context, l Logger, conn db.DB, s *schema.Schema) (*StartResult, error) {
+	l.LogOperationStart(o)
+
+	return o.enumChange(s).start(ctx, conn, s)
+}
+
+func (o *OpDropEnumValue) Complete(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationComplete(o)
+
+	return o.enumChange(s).complete(conn, s), nil
+}
+
+func (o *OpDropEnumValue) Rollback(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationRollback(o)
+
+	return o.enumChange(s).rollback(conn, s), nil
+}
+
+func (o *OpDropEnumValue) Validate(ctx context.Context, s *schema.Schema) error {
+	enum := s.GetEnum(o.Enum)
+	if enum == nil {
+		return EnumDoesNotExistError{Name: o.Enum}
+	}
+
+	if !slices.Contains(enum.Values, o.Value) {
+		return EnumValueDoesNotExistError{Enum: o.Enum, Value: o.Value}
+	}
+
+	if o.Replacement != nil && (*o.Replacement == o.Value || !slices.Contains(enum.Values, *o.Replacement)) {
+		return EnumValueDoesNotExistError{Enum: o.Enum, Value: *o.Replacement}
+	}
+
+	return o.enumChange(s).validate(s)
+}
+
+func (o *OpDropEnumValue) enumChange(s *schema.Schema) *enumChange {
+	var values []string
+	if enum := s.GetEnum(o.Enum); enum != nil {
+		values = slices.DeleteFunc(slices.Clone(enum.Values), func(v string) bool { return v == o.Value })
+	}
+
+	return &enumChange{
+		enum:   o.Enum,
+		values: values,
+		up:     map[string]*string{o.Value: o.Replacement},
+	}
+}
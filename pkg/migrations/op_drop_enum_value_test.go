@@ -0,0 +1,282 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xataio/pgroll/internal/testutils"
+	"github.com/xataio/pgroll/pkg/migrations"
+)
+
+func TestDropEnumValue(t *testing.T) {
+	t.Parallel()
+
+	createEnumMigration := migrations.Migration{
+		Name:          "01_create_enum",
+		VersionSchema: "create_enum",
+		Operations: migrations.Operations{
+			&migrations.OpCreateEnum{
+				Name:   "mood",
+				Values: []string{"happy", "ok", "sad"},
+			},
+			&migrations.OpCreateTable{
+				Name: "people",
+				Columns: []migrations.Column{
+					{Name: "id", Type: "serial", Pk: true},
+					{Name: "name", Type: "text"},
+					{Name: "mood", Type: "mood", Nullable: true, Default: ptr("'ok'")},
+				},
+			},
+		},
+	}
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "drop enum value with a replacement",
+			migrations: []migrations.Migration{
+				createEnumMigration,
+				{
+					Name:          "02_drop_enum_value",
+					VersionSchema: "drop_enum_value",
+					Operations: migrations.Operations{
+						&migrations.OpDropEnumValue{
+							Enum:        "mood",
+							Value:       "sad",
+							Replacement: ptr("ok"),
+						},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB, schema string) {
+				// The new enum type and the new column exist
+				EnumMustHaveValues(t, db, schema, migrations.TemporaryName("mood"), []string{"happy", "ok"})
+				ColumnMustExist(t, db, schema, "people", migrations.TemporaryName("mood"))
+
+				// The old version of the schema can still write the dropped value
+				MustInsert(t, db, schema, "create_enum", "people", map[string]string{
+					"name": "alice",
+					"mood": "sad",
+				})
+
+				// The new version of the schema can not write the dropped value
+				MustNotInsert(t, db, schema, "drop_enum_value", "people", map[string]string{
+					"name": "bob",
+					"mood": "sad",
+				}, testutils.InvalidTextRepresentationErrorCode)
+
+				// Values written through the new version are copied to the old column,
+				// using the translated default if no value is given
+				MustInsert(t, db, schema, "drop_enum_value", "people", map[string]string{
+					"name": "carl",
+				})
+
+				// The dropped value has been replaced in the new version of the schema
+				rows := MustSelect(t, db, schema, "drop_enum_value", "people")
+				assert.Equal(t, []map[string]any{
+					{"id": 1, "name": "alice", "mood": []byte("ok")},
+					{"id": 2, "name": "carl", "mood": []byte("ok")},
+				}, rows)
+
+				// The old version of the schema is unchanged
+				rows = MustSelect(t, db, schema, "create_enum", "people")
+				assert.Equal(t, []map[string]any{
+					{"id": 1, "name": "alice", "mood": []byte("sad")},
+					{"id": 2, "name": "carl", "mood": []byte("ok")},
+				}, rows)
+			},
+			afterRollback: func(t *testing.T, db *sql.DB, schema string) {
+				// The table is cleaned up and the new enum type has been dropped
+				TableMustBeCleanedUp(t, db, schema, "people", "mood")
+				EnumMustNotExist(t, db, schema, migrations.TemporaryName("mood"))
+				EnumMustHaveValues(t, db, schema, "mood", []string{"happy", "ok", "sad"})
+			},
+			afterComplete: func(t *testing.T, db *sql.DB, schema string) {
+				// The table is cleaned up and the new enum type has replaced the old
+				TableMustBeCleanedUp(t, db, schema, "people", "mood")
+				EnumMustNotExist(t, db, schema, migrations.TemporaryName("mood"))
+				EnumMustHaveValues(t, db, schema, "mood", []string{"happy", "ok"})
+
+				// The dropped value can not be written
+				MustNotInsert(t, db, schema, "drop_enum_value", "people", map[string]string{
+					"name": "dana",
+					"mood": "sad",
+				}, testutils.InvalidTextRepresentationErrorCode)
+
+				// The default has been preserved
+				MustInsert(t, db, schema, "drop_enum_value", "people", map[string]string{
+					"name": "erin",
+				})
+
+				rows := MustSelect(t, db, schema, "drop_enum_value", "people")
+				assert.Equal(t, []map[string]any{
+					{"id": 1, "name": "alice", "mood": []byte("ok")},
+					{"id": 2, "name": "carl", "mood": []byte("ok")},
+					{"id": 3, "name": "erin", "mood": []byte("ok")},
+				}, rows)
+			},
+		},
+		{
+			name: "drop enum value without a replacement",
+			migrations: []migrations.Migration{
+				createEnumMigration,
+				{
+					Name:          "02_drop_enum_value",
+					VersionSchema: "drop_enum_value",
+					Operations: migrations.Operations{
+						&migrations.OpDropEnumValue{
+							Enum:  "mood",
+							Value: "sad",
+						},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB, schema string) {
+				MustInsert(t, db, schema, "create_enum", "people", map[string]string{
+					"name": "alice",
+					"mood": "sad",
+				})
+
+				// Rows holding the dropped value are set to NULL in the new version
+				rows := MustSelect(t, db, schema, "drop_enum_value", "people")
+				assert.Equal(t, []map[string]any{
+					{"id": 1, "name": "alice", "mood": nil},
+				}, rows)
+			},
+			afterRollback: func(t *testing.T, db *sql.DB, schema string) {
+				TableMustBeCleanedUp(t, db, schema, "people", "mood")
+				EnumMustNotExist(t, db, schema, migrations.TemporaryName("mood"))
+			},
+			afterComplete: func(t *testing.T, db *sql.DB, schema string) {
+				TableMustBeCleanedUp(t, db, schema, "people", "mood")
+				EnumMustHaveValues(t, db, schema, "mood", []string{"happy", "ok"})
+
+				rows := MustSelect(t, db, schema, "drop_enum_value", "people")
+				assert.Equal(t, []map[string]any{
+					{"id": 1, "name": "alice", "mood": nil},
+				}, rows)
+			},
+		},
+		{
+			name: "drop value from an enum that is not used by any column",
+			migrations: []migrations.Migration{
+				{
+					Name: "01_create_enum",
+					Operations: migrations.Operations{
+						&migrations.OpCreateEnum{
+							Name:   "mood",
+							Values: []string{"happy", "sad"},
+						},
+					},
+				},
+				{
+					Name: "02_drop_enum_value",
+					Operations: migrations.Operations{
+						&migrations.OpDropEnumValue{Enum: "mood", Value: "sad"},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB, schema string) {
+				EnumMustHaveValues(t, db, schema, "mood", []string{"happy", "sad"})
+			},
+			afterRollback: func(t *testing.T, db *sql.DB, schema string) {
+				EnumMustNotExist(t, db, schema, migrations.TemporaryName("mood"))
+			},
+			afterComplete: func(t *testing.T, db *sql.DB, schema string) {
+				EnumMustHaveValues(t, db, schema, "mood", []string{"happy"})
+				EnumMustNotExist(t, db, schema, migrations.TemporaryName("mood"))
+			},
+		},
+	})
+}
+
+func TestDropEnumValueValidation(t *testing.T) {
+	t.Parallel()
+
+	createEnumMigration := migrations.Migration{
+		Name: "01_create_enum",
+		Operations: migrations.Operations{
+			&migrations.OpCreateEnum{
+				Name:   "mood",
+				Values: []string{"happy", "sad"},
+			},
+			&migrations.OpCreateTable{
+				Name: "people",
+				Columns: []migrations.Column{
+					{Name: "id", Type: "serial", Pk: true},
+					{Name: "mood", Type: "mood"},
+				},
+			},
+		},
+	}
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "value must exist",
+			migrations: []migrations.Migration{
+				createEnumMigration,
+				{
+					Name: "02_drop_enum_value",
+					Operations: migrations.Operations{
+						&migrations.OpDropEnumValue{Enum: "mood", Value: "angry", Replacement: ptr("happy")},
+					},
+				},
+			},
+			wantStartErr: migrations.EnumValueDoesNotExistError{Enum: "mood", Value: "angry"},
+		},
+		{
+			name: "replacement must exist",
+			migrations: []migrations.Migration{
+				createEnumMigration,
+				{
+					Name: "02_drop_enum_value",
+					Operations: migrations.Operations{
+						&migrations.OpDropEnumValue{Enum: "mood", Value: "sad", Replacement: ptr("angry")},
+					},
+				},
+			},
+			wantStartErr: migrations.EnumValueDoesNotExistError{Enum: "mood", Value: "angry"},
+		},
+		{
+			name: "replacement is required for NOT NULL columns",
+			migrations: []migrations.Migration{
+				createEnumMigration,
+				{
+					Name: "02_drop_enum_value",
+					Operations: migrations.Operations{
+						&migrations.OpDropEnumValue{Enum: "mood", Value: "sad"},
+					},
+				},
+			},
+			wantStartErr: migrations.ColumnIsNotNullableError{Table: "people", Name: "mood"},
+		},
+		{
+			name: "enum must be used by a single table",
+			migrations: []migrations.Migration{
+				createEnumMigration,
+				{
+					Name: "02_create_table",
+					Operations: migrations.Operations{
+						&migrations.OpCreateTable{
+							Name: "pets",
+							Columns: []migrations.Column{
+								{Name: "id", Type: "serial", Pk: true},
+								{Name: "mood", Type: "mood", Nullable: true},
+							},
+						},
+					},
+				},
+				{
+					Name: "03_drop_enum_value",
+					Operations: migrations.Operations{
+						&migrations.OpDropEnumValue{Enum: "mood", Value: "sad", Replacement: ptr("happy")},
+					},
+				},
+			},
+			wantStartErr: migrations.EnumUsedByMultipleTablesError{Name: "mood"},
+		},
+	})
+}
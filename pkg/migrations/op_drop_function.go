@@ -0,0 +1,65 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"context"
+	"maps"
+	"slices"
+
+	"github.com/xataio/pgroll/pkg/db"
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+var (
+	_ Operation  = (*OpDropFunction)(nil)
+	_ Createable = (*OpDropFunction)(nil)
+)
+
+func (o *OpDropFunction) Start(ctx context.Context, l Logger, conn db.DB, s *schema.Schema) (*StartResult, error) {
+	l.LogOperationStart(o)
+
+	// Remove the function from the in-memory schema representation so that it
+	// is not copied into the new version schema. The function in the underlying
+	// schema is used by the old version until completion.
+	s.RemoveFunction(o.Name)
+
+	return &StartResult{}, nil
+}
+
+func (o *OpDropFunction) Complete(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationComplete(o)
+
+	return []DBAction{NewDropFunctionAction(conn, o.Name)}, nil
+}
+
+func (o *OpDropFunction) Rollback(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationRollback(o)
+
+	// No-op
+	return nil, nil
+}
+
+func (o *OpDropFunction) Validate(ctx context.Context, s *schema.Schema) error {
+	if s.GetFunction(o.Name) == nil {
+		return FunctionDoesNotExistError{Name: o.Name}
+	}
+
+	// Functions are dropped with CASCADE, which would silently drop the
+	// triggers executing the function
+	for _, name := range slices.Sorted(maps.Keys(s.Tables)) {
+		table := s.GetTable(name)
+		if table == nil {
+			continue
+		}
+		for _, trigger := range slices.Sorted(maps.Keys(table.Triggers)) {
+			if table.Triggers[trigger].Function == o.Name {
+				return FunctionUsedByTriggerError{Name: o.Name, Table: name, Trigger: trigger}
+			}
+		}
+	}
+
+	s.RemoveFunction(o.Name)
+
+	return nil
+}
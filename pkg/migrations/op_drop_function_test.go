@@ -0,0 +1,76 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/xataio/pgroll/pkg/migrations"
+)
+
+func TestDropFunction(t *testing.T) {
+	t.Parallel()
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "drop function",
+			migrations: []migrations.Migration{
+				createTriggerFunctionMigration,
+				{
+					Name: "02_drop_function",
+					Operations: migrations.Operations{
+						&migrations.OpDropFunction{Name: "set_name_upper"},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB, schema string) {
+				// The function is dropped on completion
+				FunctionMustExist(t, db, schema, "set_name_upper")
+			},
+			afterRollback: func(t *testing.T, db *sql.DB, schema string) {
+				FunctionMustExist(t, db, schema, "set_name_upper")
+			},
+			afterComplete: func(t *testing.T, db *sql.DB, schema string) {
+				FunctionMustNotExist(t, db, schema, "set_name_upper")
+			},
+		},
+		{
+			name: "function must exist",
+			migrations: []migrations.Migration{
+				createTriggerFunctionMigration,
+				{
+					Name: "02_drop_function",
+					Operations: migrations.Operations{
+						&migrations.OpDropFunction{Name: "doesntexist"},
+					},
+				},
+			},
+			wantStartErr: migrations.FunctionDoesNotExistError{Name: "doesntexist"},
+		},
+		{
+			name: "function must not be used by a trigger",
+			migrations: []migrations.Migration{
+				createTriggerFunctionMigration,
+				{
+					Name: "02_drop_function",
+					Operations: migrations.Operations{
+						&migrations.OpCreateTrigger{
+							Name:     "users_set_name_upper",
+							Table:    "users",
+							Function: "set_name_upper",
+							Timing:   migrations.OpCreateTriggerTimingBEFORE,
+							Events:   []migrations.OpCreateTriggerEventsElem{migrations.OpCreateTriggerEventsElemINSERT},
+						},
+						&migrations.OpDropFunction{Name: "set_name_upper"},
+					},
+				},
+			},
+			wantStartErr: migrations.FunctionUsedByTriggerError{
+				Name:    "set_name_upper",
+				Table:   "users",
+				Trigger: "users_set_name_upper",
+			},
+		},
+	})
+}
@@ -110,6 +110,7 @@ func (o *OpDropMultiColumnConstraint) Complete(l Logger, conn db.DB, s *schema.S
 				backfill.TriggerFunctionName(o.Table, TemporaryName(columnName))),
 			NewAlterSequenceOwnerAction(conn, o.Table, columnName, TemporaryName(columnName)),
 			NewDropColumnAction(conn, o.Table, backfill.CNeedsBackfillColumn),
+			NewRewritePoliciesAction(conn, table, columnName),
 			NewDropColumnAction(conn, o.Table, columnName),
 			NewRenameDuplicatedColumnAction(conn, table, columnName),
 		)
@@ -0,0 +1,61 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"context"
+
+	"github.com/xataio/pgroll/pkg/db"
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+var (
+	_ Operation  = (*OpDropPolicy)(nil)
+	_ Createable = (*OpDropPolicy)(nil)
+)
+
+// Start removes the policy from the virtual schema only. The policy applies to
+// both versions of the schema, so it is dropped on completion.
+func (o *OpDropPolicy) Start(ctx context.Context, l Logger, conn db.DB, s *schema.Schema) (*StartResult, error) {
+	l.LogOperationStart(o)
+
+	table := s.GetTable(o.Table)
+	if table == nil {
+		return nil, TableDoesNotExistError{Name: o.Table}
+	}
+	table.RemovePolicy(o.Name)
+
+	return &StartResult{}, nil
+}
+
+func (o *OpDropPolicy) Complete(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationComplete(o)
+
+	// Remove the policy, so that later operations don't try to rewrite it
+	if table := s.GetTable(o.Table); table != nil {
+		table.RemovePolicy(o.Name)
+	}
+
+	return []DBAction{NewDropPolicyAction(conn, o.Table, o.Name)}, nil
+}
+
+func (o *OpDropPolicy) Rollback(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationRollback(o)
+
+	// No-op
+	return nil, nil
+}
+
+func (o *OpDropPolicy) Validate(ctx context.Context, s *schema.Schema) error {
+	table := s.GetTable(o.Table)
+	if table == nil {
+		return TableDoesNotExistError{Name: o.Table}
+	}
+
+	if _, ok := table.Policies[o.Name]; !ok {
+		return PolicyDoesNotExistError{Table: o.Table, Name: o.Name}
+	}
+
+	table.RemovePolicy(o.Name)
+	return nil
+}
@@ -0,0 +1,75 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/xataio/pgroll/pkg/migrations"
+)
+
+func TestDropPolicy(t *testing.T) {
+	t.Parallel()
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "drop policy",
+			migrations: []migrations.Migration{
+				createDocumentsTableMigration,
+				{
+					Name: "02_create_policy",
+					Operations: migrations.Operations{
+						&migrations.OpCreatePolicy{
+							Table: "documents",
+							Name:  "owner_all",
+							Using: "owner = current_user",
+						},
+					},
+				},
+				{
+					Name: "03_drop_policy",
+					Operations: migrations.Operations{
+						&migrations.OpDropPolicy{
+							Table: "documents",
+							Name:  "owner_all",
+						},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB, schema string) {
+				// The policy is dropped on completion
+				PolicyMustExist(t, db, schema, "documents", "owner_all")
+			},
+			afterRollback: func(t *testing.T, db *sql.DB, schema string) {
+				PolicyMustExist(t, db, schema, "documents", "owner_all")
+			},
+			afterComplete: func(t *testing.T, db *sql.DB, schema string) {
+				PolicyMustNotExist(t, db, schema, "documents", "owner_all")
+			},
+		},
+	})
+}
+
+func TestDropPolicyValidation(t *testing.T) {
+	t.Parallel()
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "policy must exist",
+			migrations: []migrations.Migration{
+				createDocumentsTableMigration,
+				{
+					Name: "02_drop_policy",
+					Operations: migrations.Operations{
+						&migrations.OpDropPolicy{
+							Table: "documents",
+							Name:  "doesntexist",
+						},
+					},
+				},
+			},
+			wantStartErr: migrations.PolicyDoesNotExistError{Table: "documents", Name: "doesntexist"},
+		},
+	})
+}
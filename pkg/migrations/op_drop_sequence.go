@@ -0,0 +1,69 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"context"
+	"maps"
+	"slices"
+
+	"github.com/xataio/pgroll/pkg/db"
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+var (
+	_ Operation  = (*OpDropSequence)(nil)
+	_ Createable = (*OpDropSequence)(nil)
+)
+
+func (o *OpDropSequence) Start(ctx context.Context, l Logger, conn db.DB, s *schema.Schema) (*StartResult, error) {
+	l.LogOperationStart(o)
+
+	// Remove the sequence from the in-memory schema representation only. The
+	// sequence in the underlying schema is used by the old version until
+	// completion.
+	s.RemoveSequence(o.Name)
+
+	return &StartResult{}, nil
+}
+
+func (o *OpDropSequence) Complete(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationComplete(o)
+
+	return []DBAction{NewDropSequenceAction(conn, o.Name)}, nil
+}
+
+func (o *OpDropSequence) Rollback(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationRollback(o)
+
+	// No-op
+	return nil, nil
+}
+
+func (o *OpDropSequence) Validate(ctx context.Context, s *schema.Schema) error {
+	if s.GetSequence(o.Name) == nil {
+		return SequenceDoesNotExistError{Name: o.Name}
+	}
+
+	// A sequence used by a column default can not be dropped without dropping
+	// the default
+	for _, name := range slices.Sorted(maps.Keys(s.Tables)) {
+		table := s.GetTable(name)
+		if table == nil {
+			continue
+		}
+		for _, column := range slices.Sorted(maps.Keys(table.Columns)) {
+			col := table.GetColumn(column)
+			if col == nil {
+				continue
+			}
+			if seq, ok := defaultSequenceName(col.Default); ok && seq == o.Name {
+				return SequenceUsedByColumnError{Name: o.Name, Table: name, Column: column}
+			}
+		}
+	}
+
+	s.RemoveSequence(o.Name)
+
+	return nil
+}
@@ -0,0 +1,77 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/xataio/pgroll/pkg/migrations"
+)
+
+func TestDropSequence(t *testing.T) {
+	t.Parallel()
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "drop sequence",
+			migrations: []migrations.Migration{
+				{
+					Name: "01_create_sequence",
+					Operations: migrations.Operations{
+						&migrations.OpCreateSequence{Name: "invoice_number"},
+					},
+				},
+				{
+					Name: "02_drop_sequence",
+					Operations: migrations.Operations{
+						&migrations.OpDropSequence{Name: "invoice_number"},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB, schema string) {
+				// The sequence is used by the old version until completion
+				SequenceMustExist(t, db, schema, "invoice_number")
+			},
+			afterRollback: func(t *testing.T, db *sql.DB, schema string) {
+				SequenceMustExist(t, db, schema, "invoice_number")
+			},
+			afterComplete: func(t *testing.T, db *sql.DB, schema string) {
+				SequenceMustNotExist(t, db, schema, "invoice_number")
+			},
+		},
+	})
+}
+
+func TestDropSequenceValidation(t *testing.T) {
+	t.Parallel()
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "sequence must exist",
+			migrations: []migrations.Migration{
+				createInvoicesTableMigration,
+				{
+					Name: "02_drop_sequence",
+					Operations: migrations.Operations{
+						&migrations.OpDropSequence{Name: "doesntexist"},
+					},
+				},
+			},
+			wantStartErr: migrations.SequenceDoesNotExistError{Name: "doesntexist"},
+		},
+		{
+			name: "sequence must not be used by a column default",
+			migrations: []migrations.Migration{
+				createInvoicesTableMigration,
+				{
+					Name: "02_drop_sequence",
+					Operations: migrations.Operations{
+						&migrations.OpDropSequence{Name: "invoices_id_seq"},
+					},
+				},
+			},
+			wantStartErr: migrations.SequenceUsedByColumnError{Name: "invoices_id_seq", Table: "invoices", Column: "id"},
+		},
+	})
+}
@@ -0,0 +1,64 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"context"
+
+	"github.com/xataio/pgroll/pkg/db"
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+var (
+	_ Operation  = (*OpDropTrigger)(nil)
+	_ Createable = (*OpDropTrigger)(nil)
+)
+
+func (o *OpDropTrigger) Start(ctx context.Context, l Logger, conn db.DB, s *schema.Schema) (*StartResult, error) {
+	l.LogOperationStart(o)
+
+	// Remove the trigger from the in-memory schema representation. The trigger
+	// keeps firing until completion, as the old version of the schema is still
+	// in use.
+	table := s.GetTable(o.Table)
+	if table == nil {
+		return nil, TableDoesNotExistError{Name: o.Table}
+	}
+	table.RemoveTrigger(o.Name)
+
+	return &StartResult{}, nil
+}
+
+func (o *OpDropTrigger) Complete(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationComplete(o)
+
+	table := s.GetTable(o.Table)
+	if table == nil {
+		return nil, TableDoesNotExistError{Name: o.Table}
+	}
+	table.RemoveTrigger(o.Name)
+
+	return []DBAction{NewDropTriggerAction(conn, table.Name, o.Name)}, nil
+}
+
+func (o *OpDropTrigger) Rollback(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationRollback(o)
+
+	// No-op
+	return nil, nil
+}
+
+func (o *OpDropTrigger) Validate(ctx context.Context, s *schema.Schema) error {
+	table := s.GetTable(o.Table)
+	if table == nil {
+		return TableDoesNotExistError{Name: o.Table}
+	}
+
+	if _, ok := table.Triggers[o.Name]; !ok {
+		return TriggerDoesNotExistError{Table: o.Table, Name: o.Name}
+	}
+
+	table.RemoveTrigger(o.Name)
+
+	return nil
+}
@@ -0,0 +1,83 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xataio/pgroll/pkg/migrations"
+)
+
+func TestDropTrigger(t *testing.T) {
+	t.Parallel()
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "drop trigger",
+			migrations: []migrations.Migration{
+				createTriggerFunctionMigration,
+				{
+					Name: "02_create_trigger",
+					Operations: migrations.Operations{
+						&migrations.OpCreateTrigger{
+							Name:     "users_set_name_upper",
+							Table:    "users",
+							Function: "set_name_upper",
+							Timing:   migrations.OpCreateTriggerTimingBEFORE,
+							Events:   []migrations.OpCreateTriggerEventsElem{migrations.OpCreateTriggerEventsElemINSERT},
+						},
+					},
+				},
+				{
+					Name: "03_drop_trigger",
+					Operations: migrations.Operations{
+						&migrations.OpDropTrigger{
+							Table: "users",
+							Name:  "users_set_name_upper",
+						},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB, schema string) {
+				// The trigger keeps firing until completion
+				TriggerMustExist(t, db, schema, "users", "users_set_name_upper")
+				MustInsert(t, db, schema, "03_drop_trigger", "users", map[string]string{
+					"name": "alice",
+				})
+			},
+			afterRollback: func(t *testing.T, db *sql.DB, schema string) {
+				TriggerMustExist(t, db, schema, "users", "users_set_name_upper")
+			},
+			afterComplete: func(t *testing.T, db *sql.DB, schema string) {
+				TriggerMustNotExist(t, db, schema, "users", "users_set_name_upper")
+				MustInsert(t, db, schema, "03_drop_trigger", "users", map[string]string{
+					"name": "bob",
+				})
+
+				assert.Equal(t, []map[string]any{
+					{"id": 1, "name": "alice", "name_upper": "ALICE"},
+					{"id": 2, "name": "bob", "name_upper": nil},
+				}, MustSelect(t, db, schema, "03_drop_trigger", "users"))
+			},
+		},
+		{
+			name: "trigger must exist",
+			migrations: []migrations.Migration{
+				createTriggerFunctionMigration,
+				{
+					Name: "02_drop_trigger",
+					Operations: migrations.Operations{
+						&migrations.OpDropTrigger{
+							Table: "users",
+							Name:  "doesntexist",
+						},
+					},
+				},
+			},
+			wantStartErr: migrations.TriggerDoesNotExistError{Table: "users", Name: "doesntexist"},
+		},
+	})
+}
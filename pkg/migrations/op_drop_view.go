@@ -0,0 +1,49 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"context"
+
+	"github.com/xataio/pgroll/pkg/db"
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+var (
+	_ Operation  = (*OpDropView)(nil)
+	_ Createable = (*OpDropView)(nil)
+)
+
+func (o *OpDropView) Start(ctx context.Context, l Logger, conn db.DB, s *schema.Schema) (*StartResult, error) {
+	l.LogOperationStart(o)
+
+	// Remove the view from the in-memory schema representation so that it is
+	// not created in the new version schema. The view is dropped from the
+	// underlying schema on completion.
+	s.RemoveView(o.Name)
+
+	return &StartResult{}, nil
+}
+
+func (o *OpDropView) Complete(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationComplete(o)
+
+	return []DBAction{NewDropViewAction(conn, o.Name)}, nil
+}
+
+func (o *OpDropView) Rollback(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationRollback(o)
+
+	// No-op
+	return nil, nil
+}
+
+func (o *OpDropView) Validate(ctx context.Context, s *schema.Schema) error {
+	if s.GetView(o.Name) == nil {
+		return ViewDoesNotExistError{Name: o.Name}
+	}
+
+	s.RemoveView(o.Name)
+
+	return nil
+}
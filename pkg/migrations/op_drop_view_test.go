@@ -0,0 +1,76 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/xataio/pgroll/pkg/migrations"
+)
+
+func TestDropView(t *testing.T) {
+	t.Parallel()
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "drop view",
+			migrations: []migrations.Migration{
+				{
+					Name: "01_create_view",
+					Operations: migrations.Operations{
+						&migrations.OpCreateTable{
+							Name: "users",
+							Columns: []migrations.Column{
+								{Name: "id", Type: "serial", Pk: true},
+								{Name: "name", Type: "text"},
+							},
+						},
+						&migrations.OpCreateView{
+							Name:       "user_names",
+							Definition: "SELECT id, name FROM users",
+						},
+					},
+				},
+				{
+					Name: "02_drop_view",
+					Operations: migrations.Operations{
+						&migrations.OpDropView{Name: "user_names"},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB, schema string) {
+				// The view is not in the new version schema, but remains in the old
+				// version schema and the underlying schema
+				ViewMustNotExist(t, db, schema, "02_drop_view", "user_names")
+				ViewMustExist(t, db, schema, "01_create_view", "user_names")
+				BaseViewMustExist(t, db, schema, "user_names")
+			},
+			afterRollback: func(t *testing.T, db *sql.DB, schema string) {
+				BaseViewMustExist(t, db, schema, "user_names")
+			},
+			afterComplete: func(t *testing.T, db *sql.DB, schema string) {
+				BaseViewMustNotExist(t, db, schema, "user_names")
+			},
+		},
+	})
+}
+
+func TestDropViewValidation(t *testing.T) {
+	t.Parallel()
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "view must exist",
+			migrations: []migrations.Migration{
+				{
+					Name: "01_drop_view",
+					Operations: migrations.Operations{
+						&migrations.OpDropView{Name: "user_names"},
+					},
+				},
+			},
+			wantStartErr: migrations.ViewDoesNotExistError{Name: "user_names"},
+		},
+	})
+}
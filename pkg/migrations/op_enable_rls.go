@@ -0,0 +1,60 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"context"
+
+	"github.com/xataio/pgroll/pkg/db"
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+var (
+	_ Operation  = (*OpEnableRLS)(nil)
+	_ Createable = (*OpEnableRLS)(nil)
+)
+
+func (o *OpEnableRLS) Start(ctx context.Context, l Logger, conn db.DB, s *schema.Schema) (*StartResult, error) {
+	l.LogOperationStart(o)
+
+	table := s.GetTable(o.Table)
+	if table == nil {
+		return nil, TableDoesNotExistError{Name: o.Table}
+	}
+
+	// Row level security applies to both versions of the schema, so it is only
+	// enabled on completion. This gives policies created by the migration time
+	// to be created before rows are hidden by row level security.
+	table.RLSEnabled = true
+	table.RLSForced = table.RLSForced || o.Force
+
+	return &StartResult{}, nil
+}
+
+func (o *OpEnableRLS) Complete(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationComplete(o)
+
+	return []DBAction{NewEnableRLSAction(conn, o.Table, o.Force)}, nil
+}
+
+func (o *OpEnableRLS) Rollback(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationRollback(o)
+
+	// No-op
+	return nil, nil
+}
+
+func (o *OpEnableRLS) Validate(ctx context.Context, s *schema.Schema) error {
+	table := s.GetTable(o.Table)
+	if table == nil {
+		return TableDoesNotExistError{Name: o.Table}
+	}
+
+	if table.RLSEnabled && (table.RLSForced || !o.Force) {
+		return RowLevelSecurityAlreadyEnabledError{Table: o.Table}
+	}
+
+	table.RLSEnabled = true
+	table.RLSForced = table.RLSForced || o.Force
+	return nil
+}
@@ -0,0 +1,69 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"context"
+
+	"github.com/xataio/pgroll/pkg/db"
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+var (
+	_ Operation  = (*OpGrant)(nil)
+	_ Createable = (*OpGrant)(nil)
+)
+
+func (o *OpGrant) Start(ctx context.Context, l Logger, conn db.DB, s *schema.Schema) (*StartResult, error) {
+	l.LogOperationStart(o)
+
+	table := s.GetTable(o.Table)
+	if table == nil {
+		return nil, TableDoesNotExistError{Name: o.Table}
+	}
+
+	grantPrivileges(table, o.Role, o.Privileges, o.WithGrantOption)
+
+	// Grant the privileges on start so that they are available to the new
+	// version of the schema
+	return &StartResult{Actions: []DBAction{
+		NewGrantAction(conn, table.Name, o.Role, o.Privileges, o.WithGrantOption),
+	}}, nil
+}
+
+func (o *OpGrant) Complete(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationComplete(o)
+
+	return nil, nil
+}
+
+func (o *OpGrant) Rollback(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationRollback(o)
+
+	table := s.GetTable(o.Table)
+	if table == nil {
+		return nil, TableDoesNotExistError{Name: o.Table}
+	}
+
+	revokePrivileges(table, o.Role, o.Privileges)
+
+	return []DBAction{
+		NewRevokeAction(conn, table.Name, o.Role, o.Privileges),
+	}, nil
+}
+
+func (o *OpGrant) Validate(ctx context.Context, s *schema.Schema) error {
+	table := s.GetTable(o.Table)
+	if table == nil {
+		return TableDoesNotExistError{Name: o.Table}
+	}
+	if o.Role == "" {
+		return FieldRequiredError{Name: "role"}
+	}
+	if err := validatePrivileges(o.Privileges); err != nil {
+		return err
+	}
+
+	grantPrivileges(table, o.Role, o.Privileges, o.WithGrantOption)
+	return nil
+}
@@ -0,0 +1,135 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/xataio/pgroll/pkg/migrations"
+)
+
+func TestGrant(t *testing.T) {
+	t.Parallel()
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "grant privileges on a table",
+			migrations: []migrations.Migration{
+				createInvoicesTableMigration,
+				{
+					Name: "02_grant",
+					Operations: migrations.Operations{
+						&migrations.OpGrant{
+							Table:      "invoices",
+							Role:       "pgroll",
+							Privileges: []migrations.TablePrivilege{"SELECT", "INSERT"},
+						},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB, schema string) {
+				// The privileges are granted when the migration is started
+				TablePrivilegeMustExist(t, db, schema, "invoices", "pgroll", "SELECT")
+				TablePrivilegeMustExist(t, db, schema, "invoices", "pgroll", "INSERT")
+				TablePrivilegeMustNotExist(t, db, schema, "invoices", "pgroll", "UPDATE")
+			},
+			afterRollback: func(t *testing.T, db *sql.DB, schema string) {
+				TablePrivilegeMustNotExist(t, db, schema, "invoices", "pgroll", "SELECT")
+				TablePrivilegeMustNotExist(t, db, schema, "invoices", "pgroll", "INSERT")
+			},
+			afterComplete: func(t *testing.T, db *sql.DB, schema string) {
+				TablePrivilegeMustExist(t, db, schema, "invoices", "pgroll", "SELECT")
+				TablePrivilegeMustExist(t, db, schema, "invoices", "pgroll", "INSERT")
+			},
+		},
+		{
+			name: "grant all privileges on a table",
+			migrations: []migrations.Migration{
+				createInvoicesTableMigration,
+				{
+					Name: "02_grant",
+					Operations: migrations.Operations{
+						&migrations.OpGrant{
+							Table:           "invoices",
+							Role:            "pgroll",
+							Privileges:      []migrations.TablePrivilege{"ALL"},
+							WithGrantOption: true,
+						},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB, schema string) {
+				TablePrivilegeMustExist(t, db, schema, "invoices", "pgroll", "UPDATE")
+				TablePrivilegeMustExist(t, db, schema, "invoices", "pgroll", "TRUNCATE")
+			},
+			afterRollback: func(t *testing.T, db *sql.DB, schema string) {
+				TablePrivilegeMustNotExist(t, db, schema, "invoices", "pgroll", "UPDATE")
+				TablePrivilegeMustNotExist(t, db, schema, "invoices", "pgroll", "TRUNCATE")
+			},
+			afterComplete: func(t *testing.T, db *sql.DB, schema string) {
+				TablePrivilegeMustExist(t, db, schema, "invoices", "pgroll", "UPDATE")
+				TablePrivilegeMustExist(t, db, schema, "invoices", "pgroll", "TRUNCATE")
+			},
+		},
+	})
+}
+
+func TestGrantValidation(t *testing.T) {
+	t.Parallel()
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "table must exist",
+			migrations: []migrations.Migration{
+				createInvoicesTableMigration,
+				{
+					Name: "02_grant",
+					Operations: migrations.Operations{
+						&migrations.OpGrant{Table: "doesntexist", Role: "pgroll", Privileges: []migrations.TablePrivilege{"SELECT"}},
+					},
+				},
+			},
+			wantStartErr: migrations.TableDoesNotExistError{Name: "doesntexist"},
+		},
+		{
+			name: "role is required",
+			migrations: []migrations.Migration{
+				createInvoicesTableMigration,
+				{
+					Name: "02_grant",
+					Operations: migrations.Operations{
+						&migrations.OpGrant{Table: "invoices", Privileges: []migrations.TablePrivilege{"SELECT"}},
+					},
+				},
+			},
+			wantStartErr: migrations.FieldRequiredError{Name: "role"},
+		},
+		{
+			name: "privileges are required",
+			migrations: []migrations.Migration{
+				createInvoicesTableMigration,
+				{
+					Name: "02_grant",
+					Operations: migrations.Operations{
+						&migrations.OpGrant{Table: "invoices", Role: "pgroll"},
+					},
+				},
+			},
+			wantStartErr: migrations.FieldRequiredError{Name: "privileges"},
+		},
+		{
+			name: "privileges must be valid",
+			migrations: []migrations.Migration{
+				createInvoicesTableMigration,
+				{
+					Name: "02_grant",
+					Operations: migrations.Operations{
+						&migrations.OpGrant{Table: "invoices", Role: "pgroll", Privileges: []migrations.TablePrivilege{"USAGE"}},
+					},
+				},
+			},
+			wantStartErr: migrations.InvalidPrivilegeError{Privilege: "USAGE"},
+		},
+	})
+}
@@ -0,0 +1,87 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"context"
+	"slices"
+
+	"github.com/xataio/pgroll/pkg/db"
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+var (
+	_ Operation  = (*OpMergeColumns)(nil)
+	_ Createable = (*OpMergeColumns)(nil)
+)
+
+func (o *OpMergeColumns) Start(ctx context.Context, l Logger, conn db.DB, s *schema.Schema) (*StartResult, error) {
+	l.LogOperationStart(o)
+
+	return startColumnReshape(ctx, l, conn, s, o.addColumnOps(), o.dropColumnOps())
+}
+
+func (o *OpMergeColumns) Complete(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationComplete(o)
+
+	return completeColumnReshape(l, conn, s, o.addColumnOps(), o.dropColumnOps())
+}
+
+func (o *OpMergeColumns) Rollback(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationRollback(o)
+
+	return rollbackColumnReshape(l, conn, s, o.addColumnOps(), o.dropColumnOps())
+}
+
+func (o *OpMergeColumns) Validate(ctx context.Context, s *schema.Schema) error {
+	table := s.GetTable(o.Table)
+	if table == nil {
+		return TableDoesNotExistError{Name: o.Table}
+	}
+
+	if len(o.Columns) == 0 {
+		return FieldRequiredError{Name: "columns"}
+	}
+	if o.Up == "" {
+		return FieldRequiredError{Name: "up"}
+	}
+
+	// Each merged column must exist and have a down expression, and there must
+	// be no down expressions for other columns
+	for _, name := range o.Columns {
+		if table.GetColumn(name) == nil {
+			return ColumnDoesNotExistError{Table: o.Table, Name: name}
+		}
+		if o.Down[name] == "" {
+			return ColumnMigrationMissingError{Table: o.Table, Name: name}
+		}
+	}
+	for name := range o.Down {
+		if !slices.Contains(o.Columns, name) {
+			return ColumnMigrationRedundantError{Table: o.Table, Name: name}
+		}
+	}
+
+	for _, op := range o.addColumnOps() {
+		if err := op.Validate(ctx, s); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addColumnOps returns the operation adding the merged column
+func (o *OpMergeColumns) addColumnOps() []*OpAddColumn {
+	return []*OpAddColumn{{Table: o.Table, Column: o.Column, Up: o.Up}}
+}
+
+// dropColumnOps returns the operations dropping each of the columns being
+// merged
+func (o *OpMergeColumns) dropColumnOps() []*OpDropColumn {
+	ops := make([]*OpDropColumn, len(o.Columns))
+	for i, name := range o.Columns {
+		ops[i] = &OpDropColumn{Table: o.Table, Column: name, Down: o.Down[name]}
+	}
+	return ops
+}
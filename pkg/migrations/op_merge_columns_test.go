@@ -0,0 +1,207 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xataio/pgroll/pkg/migrations"
+)
+
+func TestMergeColumns(t *testing.T) {
+	t.Parallel()
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "merge columns",
+			migrations: []migrations.Migration{
+				{
+					Name: "01_create_table",
+					Operations: migrations.Operations{
+						&migrations.OpCreateTable{
+							Name: "addresses",
+							Columns: []migrations.Column{
+								{Name: "id", Type: "serial", Pk: true},
+								{Name: "street", Type: "text"},
+								{Name: "city", Type: "text"},
+							},
+						},
+						// insert some data into the table to test backfill in the next migration
+						&migrations.OpRawSQL{
+							Up:         "INSERT INTO addresses (street, city) VALUES ('1 High St', 'Leeds')",
+							OnComplete: true,
+						},
+					},
+				},
+				{
+					Name: "02_merge_columns",
+					Operations: migrations.Operations{
+						&migrations.OpMergeColumns{
+							Table:   "addresses",
+							Columns: []string{"street", "city"},
+							Column:  migrations.Column{Name: "address", Type: "text"},
+							Up:      "street || ', ' || city",
+							Down: migrations.MultiColumnDownSQL{
+								"street": "split_part(address, ', ', 1)",
+								"city":   "split_part(address, ', ', 2)",
+							},
+						},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB, schema string) {
+				// Inserting into the old version fills the new column
+				MustInsert(t, db, schema, "01_create_table", "addresses", map[string]string{
+					"street": "2 Low Rd",
+					"city":   "York",
+				})
+
+				// Inserting into the new version fills the old columns
+				MustInsert(t, db, schema, "02_merge_columns", "addresses", map[string]string{
+					"address": "3 Mill Ln, Hull",
+				})
+
+				// The new version has the new column, including for backfilled rows
+				rows := MustSelect(t, db, schema, "02_merge_columns", "addresses")
+				assert.Equal(t, []map[string]any{
+					{"id": 1, "address": "1 High St, Leeds"},
+					{"id": 2, "address": "2 Low Rd, York"},
+					{"id": 3, "address": "3 Mill Ln, Hull"},
+				}, rows)
+
+				// The old version has the old columns
+				rows = MustSelect(t, db, schema, "01_create_table", "addresses")
+				assert.Equal(t, []map[string]any{
+					{"id": 1, "street": "1 High St", "city": "Leeds"},
+					{"id": 2, "street": "2 Low Rd", "city": "York"},
+					{"id": 3, "street": "3 Mill Ln", "city": "Hull"},
+				}, rows)
+			},
+			afterRollback: func(t *testing.T, db *sql.DB, schema string) {
+				TableMustBeCleanedUp(t, db, schema, "addresses", "street", "city", "address")
+			},
+			afterComplete: func(t *testing.T, db *sql.DB, schema string) {
+				TableMustBeCleanedUp(t, db, schema, "addresses", "street", "city", "address")
+
+				// The merged columns have been dropped
+				ColumnMustNotExist(t, db, schema, "addresses", "street")
+				ColumnMustNotExist(t, db, schema, "addresses", "city")
+
+				rows := MustSelect(t, db, schema, "02_merge_columns", "addresses")
+				assert.Equal(t, []map[string]any{
+					{"id": 1, "address": "1 High St, Leeds"},
+					{"id": 2, "address": "2 Low Rd, York"},
+					{"id": 3, "address": "3 Mill Ln, Hull"},
+				}, rows)
+			},
+		},
+	})
+}
+
+func TestMergeColumnsValidation(t *testing.T) {
+	t.Parallel()
+
+	createTableMigration := migrations.Migration{
+		Name: "01_create_table",
+		Operations: migrations.Operations{
+			&migrations.OpCreateTable{
+				Name: "addresses",
+				Columns: []migrations.Column{
+					{Name: "id", Type: "serial", Pk: true},
+					{Name: "street", Type: "text"},
+					{Name: "city", Type: "text"},
+				},
+			},
+		},
+	}
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "merged columns must exist",
+			migrations: []migrations.Migration{
+				createTableMigration,
+				{
+					Name: "02_merge_columns",
+					Operations: migrations.Operations{
+						&migrations.OpMergeColumns{
+							Table:   "addresses",
+							Columns: []string{"street", "town"},
+							Column:  migrations.Column{Name: "address", Type: "text"},
+							Up:      "street || ', ' || town",
+							Down: migrations.MultiColumnDownSQL{
+								"street": "address",
+								"town":   "address",
+							},
+						},
+					},
+				},
+			},
+			wantStartErr: migrations.ColumnDoesNotExistError{Table: "addresses", Name: "town"},
+		},
+		{
+			name: "up SQL is required",
+			migrations: []migrations.Migration{
+				createTableMigration,
+				{
+					Name: "02_merge_columns",
+					Operations: migrations.Operations{
+						&migrations.OpMergeColumns{
+							Table:   "addresses",
+							Columns: []string{"street", "city"},
+							Column:  migrations.Column{Name: "address", Type: "text"},
+							Down: migrations.MultiColumnDownSQL{
+								"street": "address",
+								"city":   "address",
+							},
+						},
+					},
+				},
+			},
+			wantStartErr: migrations.FieldRequiredError{Name: "up"},
+		},
+		{
+			name: "down SQL is required for each merged column",
+			migrations: []migrations.Migration{
+				createTableMigration,
+				{
+					Name: "02_merge_columns",
+					Operations: migrations.Operations{
+						&migrations.OpMergeColumns{
+							Table:   "addresses",
+							Columns: []string{"street", "city"},
+							Column:  migrations.Column{Name: "address", Type: "text"},
+							Up:      "street || ', ' || city",
+							Down:    migrations.MultiColumnDownSQL{"street": "address"},
+						},
+					},
+				},
+			},
+			wantStartErr: migrations.ColumnMigrationMissingError{Table: "addresses", Name: "city"},
+		},
+		{
+			name: "down SQL must only be given for merged columns",
+			migrations: []migrations.Migration{
+				createTableMigration,
+				{
+					Name: "02_merge_columns",
+					Operations: migrations.Operations{
+						&migrations.OpMergeColumns{
+							Table:   "addresses",
+							Columns: []string{"street"},
+							Column:  migrations.Column{Name: "address", Type: "text"},
+							Up:      "street",
+							Down: migrations.MultiColumnDownSQL{
+								"street": "address",
+								"city":   "address",
+							},
+						},
+					},
+				},
+			},
+			wantStartErr: migrations.ColumnMigrationRedundantError{Table: "addresses", Name: "city"},
+		},
+	})
+}
@@ -0,0 +1,77 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"context"
+
+	"github.com/xataio/pgroll/pkg/db"
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+var (
+	_ Operation            = (*OpMoveTable)(nil)
+	_ Createable           = (*OpMoveTable)(nil)
+	_ CrossSchemaOperation = (*OpMoveTable)(nil)
+)
+
+func (o *OpMoveTable) Start(ctx context.Context, l Logger, conn db.DB, s *schema.Schema) (*StartResult, error) {
+	l.LogOperationStart(o)
+
+	table := s.GetTable(o.Table)
+	if table == nil {
+		return nil, TableDoesNotExistError{Name: o.Table}
+	}
+
+	s.RemoveTable(o.Table)
+
+	// Move the table on start. Views in the old version schema refer to the
+	// table by OID, so they keep working once the table has moved.
+	return &StartResult{Actions: []DBAction{
+		NewCreateSchemaAction(conn, o.Schema),
+		NewMoveTableAction(conn, table.Name, s.Name, o.Schema),
+	}}, nil
+}
+
+func (o *OpMoveTable) Complete(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationComplete(o)
+
+	return nil, nil
+}
+
+func (o *OpMoveTable) Rollback(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationRollback(o)
+
+	// Mark the table as no longer moved so that it is visible to preceding
+	// Rollbacks in the same migration
+	s.UnRemoveTable(o.Table)
+
+	table := s.GetTable(o.Table)
+
+	return []DBAction{
+		NewMoveTableAction(conn, table.Name, o.Schema, s.Name),
+	}, nil
+}
+
+func (o *OpMoveTable) Validate(ctx context.Context, s *schema.Schema) error {
+	if s.GetTable(o.Table) == nil {
+		return TableDoesNotExistError{Name: o.Table}
+	}
+	if o.Schema == "" {
+		return FieldRequiredError{Name: "schema"}
+	}
+	if o.Schema == s.Name {
+		return TableAlreadyInSchemaError{Table: o.Table, Schema: o.Schema}
+	}
+	if err := ValidateIdentifierLength(o.Schema); err != nil {
+		return err
+	}
+
+	s.RemoveTable(o.Table)
+	return nil
+}
+
+// TargetSchemas returns the schema the table is moved to
+func (o *OpMoveTable) TargetSchemas() []string {
+	return []string{o.Schema}
+}
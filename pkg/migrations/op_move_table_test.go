@@ -0,0 +1,132 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xataio/pgroll/internal/testutils"
+	"github.com/xataio/pgroll/pkg/migrations"
+)
+
+func TestMoveTable(t *testing.T) {
+	t.Parallel()
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "move table to another schema",
+			migrations: []migrations.Migration{
+				createInvoicesTableMigration,
+				{
+					Name: "02_move_table",
+					Operations: migrations.Operations{
+						&migrations.OpMoveTable{
+							Table:  "invoices",
+							Schema: "billing",
+						},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB, schema string) {
+				// The table has been moved to the new schema
+				TableMustNotExist(t, db, schema, "invoices")
+				TableMustExist(t, db, "billing", "invoices")
+
+				// The table is no longer part of the new version of the source schema
+				ViewMustNotExist(t, db, schema, "02_move_table", "invoices")
+
+				// The old version of the source schema still reads from the moved table
+				MustInsert(t, db, schema, "01_create_table", "invoices", map[string]string{
+					"total": "100",
+				})
+
+				// The new version of the destination schema exposes the moved table
+				MustInsert(t, db, "billing", "02_move_table", "invoices", map[string]string{
+					"total": "200",
+				})
+
+				rows := MustSelect(t, db, schema, "01_create_table", "invoices")
+				assert.ElementsMatch(t, []map[string]any{
+					{"id": 1, "total": 100},
+					{"id": 2, "total": 200},
+				}, rows)
+			},
+			afterRollback: func(t *testing.T, db *sql.DB, schema string) {
+				// The table has been moved back to the source schema
+				TableMustExist(t, db, schema, "invoices")
+				TableMustNotExist(t, db, "billing", "invoices")
+
+				rows := MustSelect(t, db, schema, "01_create_table", "invoices")
+				assert.ElementsMatch(t, []map[string]any{
+					{"id": 1, "total": 100},
+					{"id": 2, "total": 200},
+				}, rows)
+			},
+			afterComplete: func(t *testing.T, db *sql.DB, schema string) {
+				TableMustNotExist(t, db, schema, "invoices")
+				TableMustExist(t, db, "billing", "invoices")
+
+				MustInsert(t, db, "billing", "02_move_table", "invoices", map[string]string{
+					"total": "300",
+				})
+
+				// The sequence owned by the moved table has moved with it
+				rows := MustSelect(t, db, "billing", "02_move_table", "invoices")
+				assert.ElementsMatch(t, []map[string]any{
+					{"id": 1, "total": 100},
+					{"id": 2, "total": 200},
+					{"id": 3, "total": 300},
+				}, rows)
+			},
+		},
+	})
+}
+
+func TestMoveTableValidation(t *testing.T) {
+	t.Parallel()
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "table must exist",
+			migrations: []migrations.Migration{
+				createInvoicesTableMigration,
+				{
+					Name: "02_move_table",
+					Operations: migrations.Operations{
+						&migrations.OpMoveTable{Table: "doesntexist", Schema: "billing"},
+					},
+				},
+			},
+			wantStartErr: migrations.TableDoesNotExistError{Name: "doesntexist"},
+		},
+		{
+			name: "schema is required",
+			migrations: []migrations.Migration{
+				createInvoicesTableMigration,
+				{
+					Name: "02_move_table",
+					Operations: migrations.Operations{
+						&migrations.OpMoveTable{Table: "invoices"},
+					},
+				},
+			},
+			wantStartErr: migrations.FieldRequiredError{Name: "schema"},
+		},
+		{
+			name: "table must not be moved to the schema it is already in",
+			migrations: []migrations.Migration{
+				createInvoicesTableMigration,
+				{
+					Name: "02_move_table",
+					Operations: migrations.Operations{
+						&migrations.OpMoveTable{Table: "invoices", Schema: testutils.TestSchema()},
+					},
+				},
+			},
+			wantStartErr: migrations.TableAlreadyInSchemaError{Table: "invoices", Schema: testutils.TestSchema()},
+		},
+	})
+}
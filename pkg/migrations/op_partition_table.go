@@ -0,0 +1,276 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/xataio/pgroll/pkg/backfill"
+	"github.com/xataio/pgroll/pkg/db"
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+var (
+	_ Operation         = (*OpPartitionTable)(nil)
+	_ Createable        = (*OpPartitionTable)(nil)
+	_ IsolatedOperation = (*OpPartitionTable)(nil)
+)
+
+// Start creates a partitioned copy of the table under a temporary name. Rows
+// are copied to the new table by the backfill and kept in sync in both
+// directions by triggers, so the old version of the schema keeps using the
+// original table while the new version uses the partitioned table.
+func (o *OpPartitionTable) Start(ctx context.Context, l Logger, conn db.DB, s *schema.Schema) (*StartResult, error) {
+	l.LogOperationStart(o)
+
+	table := s.GetTable(o.Table)
+	if table == nil {
+		return nil, TableDoesNotExistError{Name: o.Table}
+	}
+
+	// The backfill runs on the original table, so keep a copy of it before the
+	// in-memory table is pointed at the partitioned table
+	original := *table
+	partitioned := TemporaryName(table.Name)
+
+	key := &schema.PartitionKey{
+		Strategy: string(o.PartitionBy.Strategy),
+		Columns:  table.PhysicalColumnNamesFor(o.PartitionBy.Columns...),
+	}
+
+	dbActions := []DBAction{
+		NewTableDuplicator(conn, &original, partitioned).WithPartitionKey(key),
+	}
+	for _, p := range o.Partitions {
+		dbActions = append(dbActions, NewCreatePartitionAction(conn, partitioned, p.Name, p.Bound))
+	}
+
+	// Copy rows written to either table to the other table. The backfill only
+	// visits rows with the backfill column set, so add it to the original table.
+	columns := physicalColumnNames(table)
+	needsBackfill := "true"
+	dbActions = append(dbActions,
+		NewAddColumnAction(conn, table.Name, Column{
+			Name:     backfill.CNeedsBackfillColumn,
+			Type:     "boolean",
+			Default:  &needsBackfill,
+			Nullable: true,
+		}, false),
+		NewCreateSyncTriggerAction(conn,
+			backfill.TriggerFunctionName(table.Name, partitioned),
+			table.Name,
+			qualifiedName(s, partitioned),
+			columns,
+			table.PrimaryKey),
+		NewCreateSyncTriggerAction(conn,
+			backfill.TriggerFunctionName(partitioned, table.Name),
+			partitioned,
+			qualifiedName(s, table.Name),
+			columns,
+			table.PrimaryKey),
+	)
+
+	// Point the in-memory table at the partitioned table, so that the new
+	// version of the schema reads from it
+	table.Name = partitioned
+	table.PartitionKey = key
+	table.Partitions = nil
+	for _, p := range o.Partitions {
+		table.AddPartition(&schema.Partition{Name: p.Name, Bound: p.Bound})
+	}
+
+	return &StartResult{Actions: dbActions, BackfillTask: backfill.NewTask(&original)}, nil
+}
+
+// Complete drops the original table and gives the partitioned table its name.
+// Views in the new version schema refer to the partitioned table itself rather
+// than to its name, so they are unaffected by the rename.
+func (o *OpPartitionTable) Complete(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationComplete(o)
+
+	table := s.GetTable(o.Table)
+	if table == nil {
+		return nil, TableDoesNotExistError{Name: o.Table}
+	}
+	partitioned := TemporaryName(table.Name)
+
+	dbActions := []DBAction{
+		NewDropFunctionAction(conn,
+			backfill.TriggerFunctionName(table.Name, partitioned),
+			backfill.TriggerFunctionName(partitioned, table.Name)),
+		NewMoveSequencesAction(conn, table.Name, partitioned, physicalColumnNames(table)...),
+		NewDropTableAction(conn, table.Name),
+		NewRenameTableAction(conn, partitioned, table.Name),
+	}
+
+	// Give the constraints and indexes of the partitioned table the names of
+	// those of the original table. Renaming an index also renames the primary
+	// key, unique or exclusion constraint it backs; check and foreign key
+	// constraints have no index and are renamed separately.
+	if pt := s.GetTable(partitioned); pt != nil {
+		for _, name := range slices.Sorted(maps.Keys(pt.Indexes)) {
+			if IsDuplicatedName(name) {
+				dbActions = append(dbActions, NewRenameIndexAction(conn, name, StripDuplicationPrefix(name)))
+			}
+		}
+		constraints := slices.Concat(
+			slices.Collect(maps.Keys(pt.CheckConstraints)),
+			slices.Collect(maps.Keys(pt.ForeignKeys)))
+		slices.Sort(constraints)
+		for _, name := range constraints {
+			if IsDuplicatedName(name) {
+				dbActions = append(dbActions, NewRenameConstraintAction(conn, table.Name, name, StripDuplicationPrefix(name)))
+			}
+		}
+	}
+
+	return dbActions, nil
+}
+
+func (o *OpPartitionTable) Rollback(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationRollback(o)
+
+	// The in-memory table points at the partitioned table
+	table := s.GetTable(o.Table)
+	if table == nil {
+		return nil, TableDoesNotExistError{Name: o.Table}
+	}
+	partitioned := table.Name
+	original := strings.TrimPrefix(partitioned, temporaryPrefix)
+
+	return []DBAction{
+		NewDropFunctionAction(conn,
+			backfill.TriggerFunctionName(original, partitioned),
+			backfill.TriggerFunctionName(partitioned, original)),
+		NewDropColumnAction(conn, original, backfill.CNeedsBackfillColumn),
+		NewDropTableAction(conn, partitioned),
+	}, nil
+}
+
+func (o *OpPartitionTable) Validate(ctx context.Context, s *schema.Schema) error {
+	table := s.GetTable(o.Table)
+	if table == nil {
+		return TableDoesNotExistError{Name: o.Table}
+	}
+	if table.IsPartitioned() {
+		return TableAlreadyPartitionedError{Name: o.Table}
+	}
+
+	if len(o.PartitionBy.Columns) == 0 {
+		return FieldRequiredError{Name: "columns"}
+	}
+	for _, name := range o.PartitionBy.Columns {
+		if table.GetColumn(name) == nil {
+			return ColumnDoesNotExistError{Table: o.Table, Name: name}
+		}
+	}
+
+	// Rows are kept in sync between the two tables by primary key
+	if len(table.PrimaryKey) == 0 {
+		return TableHasNoPrimaryKeyError{Table: o.Table}
+	}
+
+	// Postgres requires unique constraints on a partitioned table to include
+	// all columns of the partition key
+	if err := o.validateUniqueConstraints(table); err != nil {
+		return err
+	}
+
+	// The original table is dropped on completion, so it can not be referenced
+	// by any foreign keys or views
+	for _, name := range slices.Sorted(maps.Keys(s.Tables)) {
+		t := s.GetTable(name)
+		if t == nil {
+			continue
+		}
+		for _, fk := range t.ForeignKeys {
+			if fk.ReferencedTable == table.Name {
+				return TableReferencedByForeignKeyError{Table: o.Table, ReferencingTable: name, Constraint: fk.Name}
+			}
+		}
+	}
+
+	// Dropping the original table would also drop views that read from it
+	for _, name := range slices.Sorted(maps.Keys(s.Views)) {
+		view := s.GetView(name)
+		if view == nil {
+			continue
+		}
+		if slices.Contains(RelationNames(view.Definition), table.Name) {
+			return TableReferencedByViewError{Table: o.Table, View: name}
+		}
+	}
+
+	if len(o.Partitions) == 0 {
+		return FieldRequiredError{Name: "partitions"}
+	}
+	for _, p := range o.Partitions {
+		if p.Name == "" {
+			return FieldRequiredError{Name: "name"}
+		}
+		if err := ValidateIdentifierLength(p.Name); err != nil {
+			return err
+		}
+		if s.GetTable(p.Name) != nil {
+			return TableAlreadyExistsError{Name: p.Name}
+		}
+		if err := validatePartitionBound(p.Name, p.Bound); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// IsIsolated returns true; the table is replaced by a new table, so no other
+// operations can change it in the same migration.
+func (o *OpPartitionTable) IsIsolated() bool {
+	return true
+}
+
+func (o *OpPartitionTable) validateUniqueConstraints(table *schema.Table) error {
+	key := table.PhysicalColumnNamesFor(o.PartitionBy.Columns...)
+
+	constraints := map[string][]string{}
+	if idx := table.PrimaryKeyIndex(); idx != nil {
+		constraints[idx.Name] = table.PrimaryKey
+	} else {
+		constraints[primaryKeyName(table.Name)] = table.PrimaryKey
+	}
+	for name, uc := range table.UniqueConstraints {
+		constraints[name] = uc.Columns
+	}
+	for name, idx := range table.StandaloneIndexes() {
+		if idx.Unique {
+			constraints[name] = idx.Columns
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(constraints)) {
+		for i, column := range key {
+			if !slices.Contains(constraints[name], column) {
+				return PartitionKeyNotInConstraintError{
+					Table:      o.Table,
+					Constraint: name,
+					Column:     o.PartitionBy.Columns[i],
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// physicalColumnNames returns the physical names of the columns of `table`,
+// in name order.
+func physicalColumnNames(table *schema.Table) []string {
+	columns := make([]string, 0, len(table.Columns))
+	for _, name := range slices.Sorted(maps.Keys(table.Columns)) {
+		if col := table.GetColumn(name); col != nil {
+			columns = append(columns, col.Name)
+		}
+	}
+	return columns
+}
@@ -0,0 +1,506 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xataio/pgroll/pkg/backfill"
+	"github.com/xataio/pgroll/pkg/migrations"
+)
+
+func TestPartitionTable(t *testing.T) {
+	t.Parallel()
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "partition table by list",
+			migrations: []migrations.Migration{
+				{
+					Name: "01_create_table",
+					Operations: migrations.Operations{
+						&migrations.OpCreateTable{
+							Name: "measurements",
+							Columns: []migrations.Column{
+								{Name: "id", Type: "serial", Pk: true},
+								{Name: "region", Type: "text", Pk: true},
+								{Name: "value", Type: "integer"},
+							},
+						},
+						// insert some data into the table to test backfill in the next migration
+						&migrations.OpRawSQL{
+							Up:         "INSERT INTO measurements (region, value) VALUES ('eu', 10), ('us', 20)",
+							OnComplete: true,
+						},
+					},
+				},
+				{
+					Name: "02_partition_table",
+					Operations: migrations.Operations{
+						&migrations.OpPartitionTable{
+							Table: "measurements",
+							PartitionBy: migrations.PartitionBy{
+								Strategy: migrations.PartitionByStrategyList,
+								Columns:  []string{"region"},
+							},
+							Partitions: []migrations.Partition{
+								{Name: "measurements_eu", Bound: "FOR VALUES IN ('eu')"},
+								{Name: "measurements_other", Bound: "DEFAULT"},
+							},
+						},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB, schema string) {
+				// The partitioned table exists alongside the original table
+				TableMustNotBePartitioned(t, db, schema, "measurements")
+				TableMustBePartitioned(t, db, schema, migrations.TemporaryName("measurements"))
+				TableMustExist(t, db, schema, "measurements_eu")
+				TableMustExist(t, db, schema, "measurements_other")
+
+				// Rows inserted into the old version are copied to the new version
+				MustInsert(t, db, schema, "01_create_table", "measurements", map[string]string{
+					"region": "eu",
+					"value":  "30",
+				})
+
+				// Rows inserted into the new version are copied to the old version
+				MustInsert(t, db, schema, "02_partition_table", "measurements", map[string]string{
+					"region": "apac",
+					"value":  "40",
+				})
+
+				// Rows updated in the new version are updated in the old version
+				MustUpdate(t, db, schema, "02_partition_table", "measurements", "value", "11", map[string]string{
+					"id": "1",
+				})
+
+				// Rows deleted from the old version are deleted from the new version
+				MustDelete(t, db, schema, "01_create_table", "measurements", map[string]string{
+					"id": "2",
+				})
+
+				want := []map[string]any{
+					{"id": 1, "region": "eu", "value": 11},
+					{"id": 3, "region": "eu", "value": 30},
+					{"id": 4, "region": "apac", "value": 40},
+				}
+				assert.ElementsMatch(t, want, MustSelect(t, db, schema, "01_create_table", "measurements"))
+				assert.ElementsMatch(t, want, MustSelect(t, db, schema, "02_partition_table", "measurements"))
+			},
+			afterRollback: func(t *testing.T, db *sql.DB, schema string) {
+				TableMustBeCleanedUp(t, db, schema, "measurements", "id", "region", "value")
+
+				// The partitioned table and its partitions have been dropped
+				TableMustNotExist(t, db, schema, migrations.TemporaryName("measurements"))
+				TableMustNotExist(t, db, schema, "measurements_eu")
+				TableMustNotExist(t, db, schema, "measurements_other")
+				TableMustNotBePartitioned(t, db, schema, "measurements")
+				FunctionMustNotExist(t, db, schema, backfill.TriggerFunctionName("measurements", migrations.TemporaryName("measurements")))
+				FunctionMustNotExist(t, db, schema, backfill.TriggerFunctionName(migrations.TemporaryName("measurements"), "measurements"))
+			},
+			afterComplete: func(t *testing.T, db *sql.DB, schema string) {
+				TableMustBeCleanedUp(t, db, schema, "measurements", "id", "region", "value")
+
+				// The partitioned table has taken the name of the original table
+				TableMustNotExist(t, db, schema, migrations.TemporaryName("measurements"))
+				TableMustBePartitioned(t, db, schema, "measurements")
+				PrimaryKeyConstraintMustExist(t, db, schema, "measurements", "measurements_pkey")
+				FunctionMustNotExist(t, db, schema, backfill.TriggerFunctionName("measurements", migrations.TemporaryName("measurements")))
+				FunctionMustNotExist(t, db, schema, backfill.TriggerFunctionName(migrations.TemporaryName("measurements"), "measurements"))
+
+				// The sequence of the serial column is still usable
+				MustInsert(t, db, schema, "02_partition_table", "measurements", map[string]string{
+					"region": "us",
+					"value":  "50",
+				})
+
+				// Changes made to the original table before the rollback have been kept
+				assert.ElementsMatch(t, []map[string]any{
+					{"id": 1, "region": "eu", "value": 11},
+					{"id": 3, "region": "eu", "value": 30},
+					{"id": 4, "region": "apac", "value": 40},
+					{"id": 5, "region": "us", "value": 50},
+				}, MustSelect(t, db, schema, "02_partition_table", "measurements"))
+			},
+		},
+		{
+			name: "rows written to the partitioned table are copied to the original table once",
+			migrations: []migrations.Migration{
+				{
+					Name: "01_create_table",
+					Operations: migrations.Operations{
+						&migrations.OpCreateTable{
+							Name: "measurements",
+							Columns: []migrations.Column{
+								{Name: "id", Type: "integer", Pk: true},
+								{Name: "region", Type: "text", Pk: true},
+								{Name: "value", Type: "integer"},
+							},
+						},
+					},
+				},
+				{
+					Name: "02_partition_table",
+					Operations: migrations.Operations{
+						&migrations.OpPartitionTable{
+							Table: "measurements",
+							PartitionBy: migrations.PartitionBy{
+								Strategy: migrations.PartitionByStrategyList,
+								Columns:  []string{"region"},
+							},
+							Partitions: []migrations.Partition{
+								{Name: "measurements_eu", Bound: "FOR VALUES IN ('eu')"},
+								{Name: "measurements_other", Bound: "DEFAULT"},
+							},
+						},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB, schema string) {
+				// Rows inserted into a partition through the new version are
+				// inserted into the original table
+				MustInsert(t, db, schema, "02_partition_table", "measurements", map[string]string{
+					"id":     "1",
+					"region": "eu",
+					"value":  "10",
+				})
+				assert.ElementsMatch(t, []map[string]any{
+					{"id": 1, "region": "eu", "value": 10},
+				}, MustSelect(t, db, schema, "01_create_table", "measurements"))
+
+				// Rows updated through the new version are updated in the original
+				// table
+				MustUpdate(t, db, schema, "02_partition_table", "measurements", "value", "20", map[string]string{
+					"id": "1",
+				})
+				assert.ElementsMatch(t, []map[string]any{
+					{"id": 1, "region": "eu", "value": 20},
+				}, MustSelect(t, db, schema, "01_create_table", "measurements"))
+				assert.ElementsMatch(t, []map[string]any{
+					{"id": 1, "region": "eu", "value": 20},
+				}, MustSelect(t, db, schema, "02_partition_table", "measurements"))
+			},
+			afterRollback: func(t *testing.T, db *sql.DB, schema string) {
+				assert.ElementsMatch(t, []map[string]any{
+					{"id": 1, "region": "eu", "value": 20},
+				}, MustSelect(t, db, schema, "01_create_table", "measurements"))
+			},
+			afterComplete: func(t *testing.T, db *sql.DB, schema string) {
+				assert.ElementsMatch(t, []map[string]any{
+					{"id": 1, "region": "eu", "value": 20},
+				}, MustSelect(t, db, schema, "02_partition_table", "measurements"))
+			},
+		},
+		{
+			name: "rows written by other triggers are kept in sync",
+			migrations: []migrations.Migration{
+				{
+					Name: "01_create_table",
+					Operations: migrations.Operations{
+						&migrations.OpCreateTable{
+							Name: "measurements",
+							Columns: []migrations.Column{
+								{Name: "id", Type: "serial", Pk: true},
+								{Name: "region", Type: "text", Pk: true},
+								{Name: "value", Type: "integer"},
+							},
+						},
+						&migrations.OpCreateTable{
+							Name: "readings",
+							Columns: []migrations.Column{
+								{Name: "id", Type: "serial", Pk: true},
+								{Name: "region", Type: "text"},
+								{Name: "value", Type: "integer"},
+							},
+						},
+						// every reading is recorded as a measurement by a trigger
+						&migrations.OpRawSQL{
+							Up: `CREATE FUNCTION record_reading() RETURNS TRIGGER LANGUAGE plpgsql AS $$
+								BEGIN
+									EXECUTE format('INSERT INTO %I.measurements (region, value) VALUES ($1, $2)', TG_TABLE_SCHEMA)
+										USING NEW.region, NEW.value;
+									RETURN NULL;
+								END; $$;
+								CREATE TRIGGER record_reading AFTER INSERT ON readings FOR EACH ROW EXECUTE PROCEDURE record_reading();`,
+							OnComplete: true,
+						},
+					},
+				},
+				{
+					Name: "02_partition_table",
+					Operations: migrations.Operations{
+						&migrations.OpPartitionTable{
+							Table: "measurements",
+							PartitionBy: migrations.PartitionBy{
+								Strategy: migrations.PartitionByStrategyList,
+								Columns:  []string{"region"},
+							},
+							Partitions: []migrations.Partition{
+								{Name: "measurements_eu", Bound: "FOR VALUES IN ('eu')"},
+								{Name: "measurements_other", Bound: "DEFAULT"},
+							},
+						},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB, schema string) {
+				// The measurement written by the trigger on readings is copied to
+				// the partitioned table
+				MustInsert(t, db, schema, "01_create_table", "readings", map[string]string{
+					"region": "eu",
+					"value":  "10",
+				})
+
+				want := []map[string]any{
+					{"id": 1, "region": "eu", "value": 10},
+				}
+				assert.ElementsMatch(t, want, MustSelect(t, db, schema, "01_create_table", "measurements"))
+				assert.ElementsMatch(t, want, MustSelect(t, db, schema, "02_partition_table", "measurements"))
+			},
+			afterRollback: func(t *testing.T, db *sql.DB, schema string) {
+				TableMustNotExist(t, db, schema, migrations.TemporaryName("measurements"))
+			},
+			afterComplete: func(t *testing.T, db *sql.DB, schema string) {
+				assert.ElementsMatch(t, []map[string]any{
+					{"id": 1, "region": "eu", "value": 10},
+				}, MustSelect(t, db, schema, "02_partition_table", "measurements"))
+			},
+		},
+	})
+}
+
+func TestPartitionTableValidation(t *testing.T) {
+	t.Parallel()
+
+	createTableMigration := migrations.Migration{
+		Name: "01_create_table",
+		Operations: migrations.Operations{
+			&migrations.OpCreateTable{
+				Name: "measurements",
+				Columns: []migrations.Column{
+					{Name: "id", Type: "serial", Pk: true},
+					{Name: "region", Type: "text", Pk: true},
+					{Name: "value", Type: "integer"},
+				},
+			},
+		},
+	}
+
+	partitionBy := migrations.PartitionBy{
+		Strategy: migrations.PartitionByStrategyList,
+		Columns:  []string{"region"},
+	}
+	partitions := []migrations.Partition{
+		{Name: "measurements_other", Bound: "DEFAULT"},
+	}
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "table must exist",
+			migrations: []migrations.Migration{
+				createTableMigration,
+				{
+					Name: "02_partition_table",
+					Operations: migrations.Operations{
+						&migrations.OpPartitionTable{
+							Table:       "doesntexist",
+							PartitionBy: partitionBy,
+							Partitions:  partitions,
+						},
+					},
+				},
+			},
+			wantStartErr: migrations.TableDoesNotExistError{Name: "doesntexist"},
+		},
+		{
+			name: "partition key columns must exist",
+			migrations: []migrations.Migration{
+				createTableMigration,
+				{
+					Name: "02_partition_table",
+					Operations: migrations.Operations{
+						&migrations.OpPartitionTable{
+							Table: "measurements",
+							PartitionBy: migrations.PartitionBy{
+								Strategy: migrations.PartitionByStrategyList,
+								Columns:  []string{"country"},
+							},
+							Partitions: partitions,
+						},
+					},
+				},
+			},
+			wantStartErr: migrations.ColumnDoesNotExistError{Table: "measurements", Name: "country"},
+		},
+		{
+			name: "partition key must be part of the primary key",
+			migrations: []migrations.Migration{
+				createTableMigration,
+				{
+					Name: "02_partition_table",
+					Operations: migrations.Operations{
+						&migrations.OpPartitionTable{
+							Table: "measurements",
+							PartitionBy: migrations.PartitionBy{
+								Strategy: migrations.PartitionByStrategyRange,
+								Columns:  []string{"value"},
+							},
+							Partitions: partitions,
+						},
+					},
+				},
+			},
+			wantStartErr: migrations.PartitionKeyNotInConstraintError{
+				Table:      "measurements",
+				Constraint: "measurements_pkey",
+				Column:     "value",
+			},
+		},
+		{
+			name: "table must have a primary key",
+			migrations: []migrations.Migration{
+				{
+					Name: "01_create_table",
+					Operations: migrations.Operations{
+						&migrations.OpRawSQL{
+							Up: "CREATE TABLE measurements (id integer, region text)",
+						},
+					},
+				},
+				{
+					Name: "02_partition_table",
+					Operations: migrations.Operations{
+						&migrations.OpPartitionTable{
+							Table:       "measurements",
+							PartitionBy: partitionBy,
+							Partitions:  partitions,
+						},
+					},
+				},
+			},
+			wantStartErr: migrations.TableHasNoPrimaryKeyError{Table: "measurements"},
+		},
+		{
+			name: "table must not be referenced by foreign keys",
+			migrations: []migrations.Migration{
+				createTableMigration,
+				{
+					Name: "02_create_referencing_table",
+					Operations: migrations.Operations{
+						&migrations.OpRawSQL{
+							Up: "CREATE TABLE readings (id integer PRIMARY KEY, measurement_id integer, region text, " +
+								"CONSTRAINT fk_measurement FOREIGN KEY (measurement_id, region) REFERENCES measurements (id, region))",
+						},
+					},
+				},
+				{
+					Name: "03_partition_table",
+					Operations: migrations.Operations{
+						&migrations.OpPartitionTable{
+							Table:       "measurements",
+							PartitionBy: partitionBy,
+							Partitions:  partitions,
+						},
+					},
+				},
+			},
+			wantStartErr: migrations.TableReferencedByForeignKeyError{
+				Table:            "measurements",
+				ReferencingTable: "readings",
+				Constraint:       "fk_measurement",
+			},
+		},
+		{
+			name: "table must not be referenced by views",
+			migrations: []migrations.Migration{
+				createTableMigration,
+				{
+					Name: "02_create_view",
+					Operations: migrations.Operations{
+						&migrations.OpCreateView{
+							Name:       "eu_measurements",
+							Definition: "SELECT id, value FROM measurements WHERE region = 'eu'",
+						},
+					},
+				},
+				{
+					Name: "03_partition_table",
+					Operations: migrations.Operations{
+						&migrations.OpPartitionTable{
+							Table:       "measurements",
+							PartitionBy: partitionBy,
+							Partitions:  partitions,
+						},
+					},
+				},
+			},
+			wantStartErr: migrations.TableReferencedByViewError{
+				Table: "measurements",
+				View:  "eu_measurements",
+			},
+		},
+		{
+			name: "partitions are required",
+			migrations: []migrations.Migration{
+				createTableMigration,
+				{
+					Name: "02_partition_table",
+					Operations: migrations.Operations{
+						&migrations.OpPartitionTable{
+							Table:       "measurements",
+							PartitionBy: partitionBy,
+						},
+					},
+				},
+			},
+			wantStartErr: migrations.FieldRequiredError{Name: "partitions"},
+		},
+		{
+			name: "partitions must not clash with existing tables",
+			migrations: []migrations.Migration{
+				createTableMigration,
+				{
+					Name: "02_partition_table",
+					Operations: migrations.Operations{
+						&migrations.OpPartitionTable{
+							Table:       "measurements",
+							PartitionBy: partitionBy,
+							Partitions: []migrations.Partition{
+								{Name: "measurements", Bound: "DEFAULT"},
+							},
+						},
+					},
+				},
+			},
+			wantStartErr: migrations.TableAlreadyExistsError{Name: "measurements"},
+		},
+		{
+			name: "table must not already be partitioned",
+			migrations: []migrations.Migration{
+				{
+					Name: "01_create_table",
+					Operations: migrations.Operations{
+						&migrations.OpRawSQL{
+							Up: "CREATE TABLE measurements (id integer, region text, PRIMARY KEY (id, region)) PARTITION BY LIST (region)",
+						},
+					},
+				},
+				{
+					Name: "02_partition_table",
+					Operations: migrations.Operations{
+						&migrations.OpPartitionTable{
+							Table:       "measurements",
+							PartitionBy: partitionBy,
+							Partitions:  partitions,
+						},
+					},
+				},
+			},
+			wantStartErr: migrations.TableAlreadyPartitionedError{Name: "measurements"},
+		},
+	})
+}
@@ -25,9 +25,10 @@ func (o *OpRenameColumn) Start(ctx context.Context, l Logger, conn db.DB, s *sch
 	}
 	table.RenameColumn(o.From, o.To)
 
-	// Update the name of the column in any constraints that reference the
-	// renamed column.
+	// Update the name of the column in any constraints or policies that
+	// reference the renamed column.
 	table.RenameConstraintColumns(o.From, o.To)
+	renamePolicyColumns(table, o.From, o.To)
 
 	return nil, nil
 }
@@ -38,6 +39,7 @@ func (o *OpRenameColumn) Complete(l Logger, conn db.DB, s *schema.Schema) ([]DBA
 	table := s.GetTable(o.Table)
 	table.RenameColumn(o.From, o.To)
 	table.RenameConstraintColumns(o.From, o.To)
+	renamePolicyColumns(table, o.From, o.To)
 
 	return []DBAction{
 		NewRenameColumnAction(conn, o.Table, o.From, o.To),
@@ -86,6 +88,7 @@ func (o *OpRenameColumn) Validate(ctx context.Context, s *schema.Schema) error {
 	// visible to subsequent operations' validation steps.
 	table.RenameColumn(o.From, o.To)
 	table.RenameConstraintColumns(o.From, o.To)
+	renamePolicyColumns(table, o.From, o.To)
 
 	return nil
 }
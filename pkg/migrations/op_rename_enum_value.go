@@ -0,0 +1,71 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"context"
+	"slices"
+
+	"github.com/xataio/pgroll/pkg/db"
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+var (
+	_ Operation  = (*OpRenameEnumValue)(nil)
+	_ Createable = (*OpRenameEnumValue)(nil)
+)
+
+// Renaming the value in place would break the version of the schema that
+// still uses the old value, so the enum is changed using expand/contract:
+// the old and new versions of the schema each see their own value.
+func (o *OpRenameEnumValue) Start(ctx context.Context, l Logger, conn db.DB, s *schema.Schema) (*StartResult, error) {
+	l.LogOperationStart(o)
+
+	return o.enumChange(s).start(ctx, conn, s)
+}
+
+func (o *OpRenameEnumValue) Complete(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationComplete(o)
+
+	return o.enumChange(s).complete(conn, s), nil
+}
+
+func (o *OpRenameEnumValue) Rollback(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationRollback(o)
+
+	return o.enumChange(s).rollback(conn, s), nil
+}
+
+func (o *OpRenameEnumValue) Validate(ctx context.Context, s *schema.Schema) error {
+	enum := s.GetEnum(o.Enum)
+	if enum == nil {
+		return EnumDoesNotExistError{Name: o.Enum}
+	}
+
+	if !slices.Contains(enum.Values, o.From) {
+		return EnumValueDoesNotExistError{Enum: o.Enum, Value: o.From}
+	}
+
+	if slices.Contains(enum.Values, o.To) {
+		return EnumValueAlreadyExistsError{Enum: o.Enum, Value: o.To}
+	}
+
+	return o.enumChange(s).validate(s)
+}
+
+func (o *OpRenameEnumValue) enumChange(s *schema.Schema) *enumChange {
+	var values []string
+	if enum := s.GetEnum(o.Enum); enum != nil {
+		values = slices.Clone(enum.Values)
+		if idx := slices.Index(values, o.From); idx >= 0 {
+			values[idx] = o.To
+		}
+	}
+
+	return &enumChange{
+		enum:   o.Enum,
+		values: values,
+		up:     map[string]*string{o.From: &o.To},
+		down:   map[string]string{o.To: o.From},
+	}
+}
@@ -0,0 +1,141 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xataio/pgroll/pkg/migrations"
+)
+
+func TestRenameEnumValue(t *testing.T) {
+	t.Parallel()
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "rename enum value",
+			migrations: []migrations.Migration{
+				{
+					Name:          "01_create_enum",
+					VersionSchema: "create_enum",
+					Operations: migrations.Operations{
+						&migrations.OpCreateEnum{
+							Name:   "mood",
+							Values: []string{"happy", "sad"},
+						},
+						&migrations.OpCreateTable{
+							Name: "people",
+							Columns: []migrations.Column{
+								{Name: "id", Type: "serial", Pk: true},
+								{Name: "mood", Type: "mood", Default: ptr("'sad'")},
+							},
+						},
+					},
+				},
+				{
+					Name:          "02_rename_enum_value",
+					VersionSchema: "rename_enum_value",
+					Operations: migrations.Operations{
+						&migrations.OpRenameEnumValue{
+							Enum: "mood",
+							From: "sad",
+							To:   "unhappy",
+						},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB, schema string) {
+				// The old version of the schema writes the old value
+				MustInsert(t, db, schema, "create_enum", "people", map[string]string{
+					"mood": "sad",
+				})
+
+				// The new version of the schema writes the new value
+				MustInsert(t, db, schema, "rename_enum_value", "people", map[string]string{
+					"mood": "unhappy",
+				})
+
+				// Each version of the schema sees its own value
+				rows := MustSelect(t, db, schema, "create_enum", "people")
+				assert.Equal(t, []map[string]any{
+					{"id": 1, "mood": []byte("sad")},
+					{"id": 2, "mood": []byte("sad")},
+				}, rows)
+
+				rows = MustSelect(t, db, schema, "rename_enum_value", "people")
+				assert.Equal(t, []map[string]any{
+					{"id": 1, "mood": []byte("unhappy")},
+					{"id": 2, "mood": []byte("unhappy")},
+				}, rows)
+			},
+			afterRollback: func(t *testing.T, db *sql.DB, schema string) {
+				TableMustBeCleanedUp(t, db, schema, "people", "mood")
+				EnumMustNotExist(t, db, schema, migrations.TemporaryName("mood"))
+				EnumMustHaveValues(t, db, schema, "mood", []string{"happy", "sad"})
+			},
+			afterComplete: func(t *testing.T, db *sql.DB, schema string) {
+				TableMustBeCleanedUp(t, db, schema, "people", "mood")
+				EnumMustNotExist(t, db, schema, migrations.TemporaryName("mood"))
+				EnumMustHaveValues(t, db, schema, "mood", []string{"happy", "unhappy"})
+
+				// The default has been renamed along with the value
+				MustInsert(t, db, schema, "rename_enum_value", "people", map[string]string{
+					"id": "3",
+				})
+
+				rows := MustSelect(t, db, schema, "rename_enum_value", "people")
+				assert.Equal(t, []map[string]any{
+					{"id": 1, "mood": []byte("unhappy")},
+					{"id": 2, "mood": []byte("unhappy")},
+					{"id": 3, "mood": []byte("unhappy")},
+				}, rows)
+			},
+		},
+	})
+}
+
+func TestRenameEnumValueValidation(t *testing.T) {
+	t.Parallel()
+
+	createEnumMigration := migrations.Migration{
+		Name: "01_create_enum",
+		Operations: migrations.Operations{
+			&migrations.OpCreateEnum{
+				Name:   "mood",
+				Values: []string{"happy", "sad"},
+			},
+		},
+	}
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "value must exist",
+			migrations: []migrations.Migration{
+				createEnumMigration,
+				{
+					Name: "02_rename_enum_value",
+					Operations: migrations.Operations{
+						&migrations.OpRenameEnumValue{Enum: "mood", From: "angry", To: "furious"},
+					},
+				},
+			},
+			wantStartErr: migrations.EnumValueDoesNotExistError{Enum: "mood", Value: "angry"},
+		},
+		{
+			name: "new value must not exist",
+			migrations: []migrations.Migration{
+				createEnumMigration,
+				{
+					Name: "02_rename_enum_value",
+					Operations: migrations.Operations{
+						&migrations.OpRenameEnumValue{Enum: "mood", From: "sad", To: "happy"},
+					},
+				},
+			},
+			wantStartErr: migrations.EnumValueAlreadyExistsError{Enum: "mood", Value: "happy"},
+		},
+	})
+}
@@ -0,0 +1,82 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"context"
+
+	"github.com/xataio/pgroll/pkg/db"
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+var (
+	_ Operation  = (*OpReplaceFunction)(nil)
+	_ Createable = (*OpReplaceFunction)(nil)
+)
+
+func (o *OpReplaceFunction) Start(ctx context.Context, l Logger, conn db.DB, s *schema.Schema) (*StartResult, error) {
+	l.LogOperationStart(o)
+
+	// Replace the definition in the in-memory schema representation. The
+	// function in the underlying schema is replaced on completion, once the
+	// columns the new definition refers to have their final names.
+	fn := s.GetFunction(o.Name)
+	if fn == nil {
+		return nil, FunctionDoesNotExistError{Name: o.Name}
+	}
+	o.updateFunction(fn)
+
+	return &StartResult{}, nil
+}
+
+func (o *OpReplaceFunction) Complete(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationComplete(o)
+
+	fn := s.GetFunction(o.Name)
+	if fn == nil {
+		return nil, FunctionDoesNotExistError{Name: o.Name}
+	}
+	o.updateFunction(fn)
+
+	return []DBAction{
+		NewCreateFunctionAction(conn, fn.Name, fn.Arguments, fn.Returns, fn.Language, fn.Volatility, fn.Definition).OrReplace(),
+	}, nil
+}
+
+func (o *OpReplaceFunction) Rollback(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationRollback(o)
+
+	// No-op; the function in the underlying schema has not been replaced
+	return nil, nil
+}
+
+func (o *OpReplaceFunction) Validate(ctx context.Context, s *schema.Schema) error {
+	fn := s.GetFunction(o.Name)
+	if fn == nil {
+		return FunctionDoesNotExistError{Name: o.Name}
+	}
+
+	if o.Definition == "" {
+		return FieldRequiredError{Name: "definition"}
+	}
+
+	if err := validateFunctionVolatility(o.Name, o.Volatility); err != nil {
+		return err
+	}
+
+	o.updateFunction(fn)
+
+	return nil
+}
+
+// updateFunction replaces the definition of `fn`. The language and
+// volatility are only changed if they are set on the operation.
+func (o *OpReplaceFunction) updateFunction(fn *schema.Function) {
+	fn.Definition = o.Definition
+	if o.Language != "" {
+		fn.Language = o.Language
+	}
+	if o.Volatility != "" {
+		fn.Volatility = string(o.Volatility)
+	}
+}
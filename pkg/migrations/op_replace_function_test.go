@@ -0,0 +1,91 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xataio/pgroll/pkg/migrations"
+)
+
+func TestReplaceFunction(t *testing.T) {
+	t.Parallel()
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "replace a trigger function along with a rename of the column it uses",
+			migrations: []migrations.Migration{
+				createTriggerFunctionMigration,
+				{
+					Name: "02_create_trigger",
+					Operations: migrations.Operations{
+						&migrations.OpCreateTrigger{
+							Name:     "users_set_name_upper",
+							Table:    "users",
+							Function: "set_name_upper",
+							Timing:   migrations.OpCreateTriggerTimingBEFORE,
+							Events:   []migrations.OpCreateTriggerEventsElem{migrations.OpCreateTriggerEventsElemINSERT},
+						},
+					},
+				},
+				{
+					Name: "03_rename_column",
+					Operations: migrations.Operations{
+						&migrations.OpRenameColumn{
+							Table: "users",
+							From:  "name",
+							To:    "full_name",
+						},
+						&migrations.OpReplaceFunction{
+							Name:       "set_name_upper",
+							Definition: "BEGIN NEW.name_upper := upper(NEW.full_name); RETURN NEW; END",
+						},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB, schema string) {
+				// The old definition is used until completion, as the column has
+				// not been renamed yet
+				MustInsert(t, db, schema, "03_rename_column", "users", map[string]string{
+					"full_name": "alice",
+				})
+			},
+			afterRollback: func(t *testing.T, db *sql.DB, schema string) {
+				MustInsert(t, db, schema, "02_create_trigger", "users", map[string]string{
+					"name": "bob",
+				})
+			},
+			afterComplete: func(t *testing.T, db *sql.DB, schema string) {
+				// The new definition uses the new column name
+				MustInsert(t, db, schema, "03_rename_column", "users", map[string]string{
+					"full_name": "carol",
+				})
+
+				assert.Equal(t, []map[string]any{
+					{"id": 1, "full_name": "alice", "name_upper": "ALICE"},
+					{"id": 2, "full_name": "bob", "name_upper": "BOB"},
+					{"id": 3, "full_name": "carol", "name_upper": "CAROL"},
+				}, MustSelect(t, db, schema, "03_rename_column", "users"))
+			},
+		},
+		{
+			name: "function must exist",
+			migrations: []migrations.Migration{
+				createUsersTableMigration,
+				{
+					Name: "02_replace_function",
+					Operations: migrations.Operations{
+						&migrations.OpReplaceFunction{
+							Name:       "doesntexist",
+							Definition: "BEGIN RETURN NEW; END",
+						},
+					},
+				},
+			},
+			wantStartErr: migrations.FunctionDoesNotExistError{Name: "doesntexist"},
+		},
+	})
+}
@@ -0,0 +1,53 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"context"
+
+	"github.com/xataio/pgroll/pkg/db"
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+var (
+	_ Operation  = (*OpReplaceView)(nil)
+	_ Createable = (*OpReplaceView)(nil)
+)
+
+func (o *OpReplaceView) Start(ctx context.Context, l Logger, conn db.DB, s *schema.Schema) (*StartResult, error) {
+	l.LogOperationStart(o)
+
+	// Replace the definition in the in-memory schema representation. The new
+	// version schema gets the new definition while the old version schema
+	// keeps the old one; the view in the underlying schema is replaced on
+	// completion.
+	s.AddView(o.Name, &schema.View{Name: o.Name, Definition: o.Definition})
+
+	return &StartResult{}, nil
+}
+
+func (o *OpReplaceView) Complete(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationComplete(o)
+
+	return []DBAction{NewCreateViewAction(conn, o.Name, o.Definition)}, nil
+}
+
+func (o *OpReplaceView) Rollback(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationRollback(o)
+
+	// No-op; the new definition only exists in the new version schema, which
+	// is dropped
+	return nil, nil
+}
+
+func (o *OpReplaceView) Validate(ctx context.Context, s *schema.Schema) error {
+	if s.GetView(o.Name) == nil {
+		return ViewDoesNotExistError{Name: o.Name}
+	}
+
+	if o.Definition == "" {
+		return FieldRequiredError{Name: "definition"}
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xataio/pgroll/pkg/migrations"
+)
+
+func TestReplaceView(t *testing.T) {
+	t.Parallel()
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "replace view to read from a renamed column",
+			migrations: []migrations.Migration{
+				{
+					Name: "01_create_table",
+					Operations: migrations.Operations{
+						&migrations.OpCreateTable{
+							Name: "users",
+							Columns: []migrations.Column{
+								{Name: "id", Type: "serial", Pk: true},
+								{Name: "name", Type: "text"},
+							},
+						},
+						&migrations.OpCreateView{
+							Name:       "user_names",
+							Definition: "SELECT id, name FROM users",
+						},
+					},
+				},
+				{
+					Name: "02_rename_column",
+					Operations: migrations.Operations{
+						&migrations.OpRenameColumn{
+							Table: "users",
+							From:  "name",
+							To:    "username",
+						},
+						&migrations.OpReplaceView{
+							Name:       "user_names",
+							Definition: "SELECT id, username FROM users",
+						},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB, schema string) {
+				MustInsert(t, db, schema, "02_rename_column", "users", map[string]string{
+					"username": "alice",
+				})
+
+				// The old version schema has the old definition of the view
+				rows := MustSelect(t, db, schema, "01_create_table", "user_names")
+				assert.Equal(t, []map[string]any{
+					{"id": 1, "name": "alice"},
+				}, rows)
+
+				// The new version schema has the new definition of the view
+				rows = MustSelect(t, db, schema, "02_rename_column", "user_names")
+				assert.Equal(t, []map[string]any{
+					{"id": 1, "username": "alice"},
+				}, rows)
+			},
+			afterRollback: func(t *testing.T, db *sql.DB, schema string) {
+				BaseViewMustExist(t, db, schema, "user_names")
+			},
+			afterComplete: func(t *testing.T, db *sql.DB, schema string) {
+				BaseViewMustExist(t, db, schema, "user_names")
+
+				rows := MustSelect(t, db, schema, "02_rename_column", "user_names")
+				assert.Equal(t, []map[string]any{
+					{"id": 1, "username": "alice"},
+				}, rows)
+			},
+		},
+	})
+}
+
+func TestReplaceViewValidation(t *testing.T) {
+	t.Parallel()
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "view must exist",
+			migrations: []migrations.Migration{
+				{
+					Name: "01_replace_view",
+					Operations: migrations.Operations{
+						&migrations.OpReplaceView{
+							Name:       "user_names",
+							Definition: "SELECT 1",
+						},
+					},
+				},
+			},
+			wantStartErr: migrations.ViewDoesNotExistError{Name: "user_names"},
+		},
+	})
+}
@@ -0,0 +1,65 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"context"
+
+	"github.com/xataio/pgroll/pkg/db"
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+var (
+	_ Operation  = (*OpRevoke)(nil)
+	_ Createable = (*OpRevoke)(nil)
+)
+
+func (o *OpRevoke) Start(ctx context.Context, l Logger, conn db.DB, s *schema.Schema) (*StartResult, error) {
+	l.LogOperationStart(o)
+
+	table := s.GetTable(o.Table)
+	if table == nil {
+		return nil, TableDoesNotExistError{Name: o.Table}
+	}
+
+	// The privileges are only revoked on completion so that they remain
+	// available to the old version of the schema
+	revokePrivileges(table, o.Role, o.Privileges)
+
+	return &StartResult{}, nil
+}
+
+func (o *OpRevoke) Complete(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationComplete(o)
+
+	table := s.GetTable(o.Table)
+	if table == nil {
+		return nil, TableDoesNotExistError{Name: o.Table}
+	}
+
+	return []DBAction{
+		NewRevokeAction(conn, table.Name, o.Role, o.Privileges),
+	}, nil
+}
+
+func (o *OpRevoke) Rollback(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationRollback(o)
+
+	return nil, nil
+}
+
+func (o *OpRevoke) Validate(ctx context.Context, s *schema.Schema) error {
+	table := s.GetTable(o.Table)
+	if table == nil {
+		return TableDoesNotExistError{Name: o.Table}
+	}
+	if o.Role == "" {
+		return FieldRequiredError{Name: "role"}
+	}
+	if err := validatePrivileges(o.Privileges); err != nil {
+		return err
+	}
+
+	revokePrivileges(table, o.Role, o.Privileges)
+	return nil
+}
@@ -0,0 +1,89 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/xataio/pgroll/pkg/migrations"
+)
+
+func TestRevoke(t *testing.T) {
+	t.Parallel()
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "revoke privileges on a table",
+			migrations: []migrations.Migration{
+				createInvoicesTableMigration,
+				{
+					Name: "02_grant",
+					Operations: migrations.Operations{
+						&migrations.OpGrant{
+							Table:      "invoices",
+							Role:       "pgroll",
+							Privileges: []migrations.TablePrivilege{"SELECT", "INSERT"},
+						},
+					},
+				},
+				{
+					Name: "03_revoke",
+					Operations: migrations.Operations{
+						&migrations.OpRevoke{
+							Table:      "invoices",
+							Role:       "pgroll",
+							Privileges: []migrations.TablePrivilege{"INSERT"},
+						},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB, schema string) {
+				// The privileges remain available to the old version until completion
+				TablePrivilegeMustExist(t, db, schema, "invoices", "pgroll", "SELECT")
+				TablePrivilegeMustExist(t, db, schema, "invoices", "pgroll", "INSERT")
+			},
+			afterRollback: func(t *testing.T, db *sql.DB, schema string) {
+				TablePrivilegeMustExist(t, db, schema, "invoices", "pgroll", "SELECT")
+				TablePrivilegeMustExist(t, db, schema, "invoices", "pgroll", "INSERT")
+			},
+			afterComplete: func(t *testing.T, db *sql.DB, schema string) {
+				TablePrivilegeMustExist(t, db, schema, "invoices", "pgroll", "SELECT")
+				TablePrivilegeMustNotExist(t, db, schema, "invoices", "pgroll", "INSERT")
+			},
+		},
+	})
+}
+
+func TestRevokeValidation(t *testing.T) {
+	t.Parallel()
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "table must exist",
+			migrations: []migrations.Migration{
+				createInvoicesTableMigration,
+				{
+					Name: "02_revoke",
+					Operations: migrations.Operations{
+						&migrations.OpRevoke{Table: "doesntexist", Role: "pgroll", Privileges: []migrations.TablePrivilege{"SELECT"}},
+					},
+				},
+			},
+			wantStartErr: migrations.TableDoesNotExistError{Name: "doesntexist"},
+		},
+		{
+			name: "privileges must be valid",
+			migrations: []migrations.Migration{
+				createInvoicesTableMigration,
+				{
+					Name: "02_revoke",
+					Operations: migrations.Operations{
+						&migrations.OpRevoke{Table: "invoices", Role: "pgroll", Privileges: []migrations.TablePrivilege{"USAGE"}},
+					},
+				},
+			},
+			wantStartErr: migrations.InvalidPrivilegeError{Privilege: "USAGE"},
+		},
+	})
+}
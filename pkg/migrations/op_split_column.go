@@ -0,0 +1,166 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"context"
+	"slices"
+
+	"github.com/xataio/pgroll/pkg/backfill"
+	"github.com/xataio/pgroll/pkg/db"
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+var (
+	_ Operation  = (*OpSplitColumn)(nil)
+	_ Createable = (*OpSplitColumn)(nil)
+)
+
+func (o *OpSplitColumn) Start(ctx context.Context, l Logger, conn db.DB, s *schema.Schema) (*StartResult, error) {
+	l.LogOperationStart(o)
+
+	return startColumnReshape(ctx, l, conn, s, o.addColumnOps(), o.dropColumnOps())
+}
+
+func (o *OpSplitColumn) Complete(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationComplete(o)
+
+	return completeColumnReshape(l, conn, s, o.addColumnOps(), o.dropColumnOps())
+}
+
+func (o *OpSplitColumn) Rollback(l Logger, conn db.DB, s *schema.Schema) ([]DBAction, error) {
+	l.LogOperationRollback(o)
+
+	return rollbackColumnReshape(l, conn, s, o.addColumnOps(), o.dropColumnOps())
+}
+
+func (o *OpSplitColumn) Validate(ctx context.Context, s *schema.Schema) error {
+	table := s.GetTable(o.Table)
+	if table == nil {
+		return TableDoesNotExistError{Name: o.Table}
+	}
+	if table.GetColumn(o.Column) == nil {
+		return ColumnDoesNotExistError{Table: o.Table, Name: o.Column}
+	}
+
+	if len(o.Columns) == 0 {
+		return FieldRequiredError{Name: "columns"}
+	}
+	if o.Down == "" {
+		return FieldRequiredError{Name: "down"}
+	}
+
+	// Each new column must have an up expression, and there must be no up
+	// expressions for other columns
+	names := make([]string, len(o.Columns))
+	for i, col := range o.Columns {
+		names[i] = col.Name
+		if o.Up[col.Name] == "" {
+			return ColumnMigrationMissingError{Table: o.Table, Name: col.Name}
+		}
+	}
+	for name := range o.Up {
+		if !slices.Contains(names, name) {
+			return ColumnMigrationRedundantError{Table: o.Table, Name: name}
+		}
+	}
+
+	for _, op := range o.addColumnOps() {
+		if err := op.Validate(ctx, s); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addColumnOps returns the operations adding each of the new columns
+func (o *OpSplitColumn) addColumnOps() []*OpAddColumn {
+	ops := make([]*OpAddColumn, len(o.Columns))
+	for i, col := range o.Columns {
+		ops[i] = &OpAddColumn{Table: o.Table, Column: col, Up: o.Up[col.Name]}
+	}
+	return ops
+}
+
+// dropColumnOps returns the operation dropping the split column
+func (o *OpSplitColumn) dropColumnOps() []*OpDropColumn {
+	return []*OpDropColumn{{Table: o.Table, Column: o.Column, Down: o.Down}}
+}
+
+// startColumnReshape starts the operations that add and drop columns in a
+// split or merge of columns. The columns are added before any are dropped so
+// that the down triggers of the dropped columns can read the new columns. The
+// triggers of all operations are combined into a single backfill task.
+func startColumnReshape(ctx context.Context, l Logger, conn db.DB, s *schema.Schema, adds []*OpAddColumn, drops []*OpDropColumn) (*StartResult, error) {
+	ops := make([]Operation, 0, len(adds)+len(drops))
+	for _, op := range adds {
+		ops = append(ops, op)
+	}
+	for _, op := range drops {
+		ops = append(ops, op)
+	}
+
+	var task *backfill.Task
+	var dbActions []DBAction
+	for _, op := range ops {
+		startOp, err := op.Start(ctx, l, conn, s)
+		if err != nil {
+			return nil, err
+		}
+		dbActions = append(dbActions, startOp.Actions...)
+
+		switch {
+		case startOp.BackfillTask == nil:
+		case task == nil:
+			task = startOp.BackfillTask
+		default:
+			task.AddTriggers(startOp.BackfillTask)
+		}
+	}
+
+	return &StartResult{Actions: dbActions, BackfillTask: task}, nil
+}
+
+// completeColumnReshape completes the operations that add and drop columns in
+// a split or merge of columns.
+func completeColumnReshape(l Logger, conn db.DB, s *schema.Schema, adds []*OpAddColumn, drops []*OpDropColumn) ([]DBAction, error) {
+	var dbActions []DBAction
+	for _, op := range adds {
+		actions, err := op.Complete(l, conn, s)
+		if err != nil {
+			return nil, err
+		}
+		dbActions = append(dbActions, actions...)
+	}
+	for _, op := range drops {
+		actions, err := op.Complete(l, conn, s)
+		if err != nil {
+			return nil, err
+		}
+		dbActions = append(dbActions, actions...)
+	}
+	return dbActions, nil
+}
+
+// rollbackColumnReshape rolls back the operations that add and drop columns
+// in a split or merge of columns, in the reverse order to which they were
+// started.
+func rollbackColumnReshape(l Logger, conn db.DB, s *schema.Schema, adds []*OpAddColumn, drops []*OpDropColumn) ([]DBAction, error) {
+	var dbActions []DBAction
+	for _, op := range slices.Backward(drops) {
+		actions, err := op.Rollback(l, conn, s)
+		if err != nil {
+			return nil, err
+		}
+		dbActions = append(dbActions, actions...)
+	}
+	for _, op := range slices.Backward(adds) {
+		actions, err := op.Rollback(l, conn, s)
+		if err != nil {
+			return nil, err
+		}
+		dbActions = append(dbActions, actions...)
+	}
+	return dbActions, nil
+}
@@ -0,0 +1,278 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xataio/pgroll/internal/testutils"
+	"github.com/xataio/pgroll/pkg/migrations"
+)
+
+func TestSplitColumn(t *testing.T) {
+	t.Parallel()
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "split column",
+			migrations: []migrations.Migration{
+				{
+					Name: "01_create_table",
+					Operations: migrations.Operations{
+						&migrations.OpCreateTable{
+							Name: "users",
+							Columns: []migrations.Column{
+								{Name: "id", Type: "serial", Pk: true},
+								{Name: "name", Type: "text"},
+							},
+						},
+						// insert some data into the table to test backfill in the next migration
+						&migrations.OpRawSQL{
+							Up:         "INSERT INTO users (name) VALUES ('Carol White')",
+							OnComplete: true,
+						},
+					},
+				},
+				{
+					Name: "02_split_column",
+					Operations: migrations.Operations{
+						&migrations.OpSplitColumn{
+							Table:  "users",
+							Column: "name",
+							Columns: []migrations.Column{
+								{Name: "first_name", Type: "text"},
+								{Name: "last_name", Type: "text"},
+							},
+							Up: migrations.MultiColumnUpSQL{
+								"first_name": "split_part(name, ' ', 1)",
+								"last_name":  "split_part(name, ' ', 2)",
+							},
+							Down: "first_name || ' ' || last_name",
+						},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB, schema string) {
+				// Inserting into the old version fills the new columns
+				MustInsert(t, db, schema, "01_create_table", "users", map[string]string{
+					"name": "Alice Smith",
+				})
+
+				// Inserting into the new version fills the old column
+				MustInsert(t, db, schema, "02_split_column", "users", map[string]string{
+					"first_name": "Bob",
+					"last_name":  "Jones",
+				})
+
+				// The new version has the new columns, including for backfilled rows
+				rows := MustSelect(t, db, schema, "02_split_column", "users")
+				assert.Equal(t, []map[string]any{
+					{"id": 1, "first_name": "Carol", "last_name": "White"},
+					{"id": 2, "first_name": "Alice", "last_name": "Smith"},
+					{"id": 3, "first_name": "Bob", "last_name": "Jones"},
+				}, rows)
+
+				// The old version has the old column
+				rows = MustSelect(t, db, schema, "01_create_table", "users")
+				assert.Equal(t, []map[string]any{
+					{"id": 1, "name": "Carol White"},
+					{"id": 2, "name": "Alice Smith"},
+					{"id": 3, "name": "Bob Jones"},
+				}, rows)
+			},
+			afterRollback: func(t *testing.T, db *sql.DB, schema string) {
+				TableMustBeCleanedUp(t, db, schema, "users", "name", "first_name", "last_name")
+			},
+			afterComplete: func(t *testing.T, db *sql.DB, schema string) {
+				TableMustBeCleanedUp(t, db, schema, "users", "name", "first_name", "last_name")
+
+				// The split column has been dropped
+				ColumnMustNotExist(t, db, schema, "users", "name")
+
+				MustInsert(t, db, schema, "02_split_column", "users", map[string]string{
+					"first_name": "Dan",
+					"last_name":  "Brown",
+				})
+				rows := MustSelect(t, db, schema, "02_split_column", "users")
+				assert.Equal(t, []map[string]any{
+					{"id": 1, "first_name": "Carol", "last_name": "White"},
+					{"id": 2, "first_name": "Alice", "last_name": "Smith"},
+					{"id": 3, "first_name": "Bob", "last_name": "Jones"},
+					{"id": 4, "first_name": "Dan", "last_name": "Brown"},
+				}, rows)
+			},
+		},
+		{
+			name: "split column into NOT NULL columns",
+			migrations: []migrations.Migration{
+				{
+					Name: "01_create_table",
+					Operations: migrations.Operations{
+						&migrations.OpCreateTable{
+							Name: "users",
+							Columns: []migrations.Column{
+								{Name: "id", Type: "serial", Pk: true},
+								{Name: "name", Type: "text"},
+							},
+						},
+					},
+				},
+				{
+					Name: "02_split_column",
+					Operations: migrations.Operations{
+						&migrations.OpSplitColumn{
+							Table:  "users",
+							Column: "name",
+							Columns: []migrations.Column{
+								{Name: "first_name", Type: "text", Nullable: false},
+								{Name: "last_name", Type: "text", Nullable: false},
+							},
+							Up: migrations.MultiColumnUpSQL{
+								"first_name": "split_part(name, ' ', 1)",
+								"last_name":  "split_part(name, ' ', 2)",
+							},
+							Down: "first_name || ' ' || last_name",
+						},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB, schema string) {
+				// The new columns can not be NULL in the new version
+				MustNotInsert(t, db, schema, "02_split_column", "users", map[string]string{
+					"first_name": "Bob",
+				}, testutils.CheckViolationErrorCode)
+			},
+			afterRollback: func(t *testing.T, db *sql.DB, schema string) {
+				TableMustBeCleanedUp(t, db, schema, "users", "name", "first_name", "last_name")
+			},
+			afterComplete: func(t *testing.T, db *sql.DB, schema string) {
+				MustNotInsert(t, db, schema, "02_split_column", "users", map[string]string{
+					"first_name": "Bob",
+				}, testutils.NotNullViolationErrorCode)
+			},
+		},
+	})
+}
+
+func TestSplitColumnValidation(t *testing.T) {
+	t.Parallel()
+
+	createTableMigration := migrations.Migration{
+		Name: "01_create_table",
+		Operations: migrations.Operations{
+			&migrations.OpCreateTable{
+				Name: "users",
+				Columns: []migrations.Column{
+					{Name: "id", Type: "serial", Pk: true},
+					{Name: "name", Type: "text"},
+				},
+			},
+		},
+	}
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "column must exist",
+			migrations: []migrations.Migration{
+				createTableMigration,
+				{
+					Name: "02_split_column",
+					Operations: migrations.Operations{
+						&migrations.OpSplitColumn{
+							Table:   "users",
+							Column:  "full_name",
+							Columns: []migrations.Column{{Name: "first_name", Type: "text"}},
+							Up:      migrations.MultiColumnUpSQL{"first_name": "full_name"},
+							Down:    "first_name",
+						},
+					},
+				},
+			},
+			wantStartErr: migrations.ColumnDoesNotExistError{Table: "users", Name: "full_name"},
+		},
+		{
+			name: "down SQL is required",
+			migrations: []migrations.Migration{
+				createTableMigration,
+				{
+					Name: "02_split_column",
+					Operations: migrations.Operations{
+						&migrations.OpSplitColumn{
+							Table:   "users",
+							Column:  "name",
+							Columns: []migrations.Column{{Name: "first_name", Type: "text"}},
+							Up:      migrations.MultiColumnUpSQL{"first_name": "name"},
+						},
+					},
+				},
+			},
+			wantStartErr: migrations.FieldRequiredError{Name: "down"},
+		},
+		{
+			name: "up SQL is required for each new column",
+			migrations: []migrations.Migration{
+				createTableMigration,
+				{
+					Name: "02_split_column",
+					Operations: migrations.Operations{
+						&migrations.OpSplitColumn{
+							Table:  "users",
+							Column: "name",
+							Columns: []migrations.Column{
+								{Name: "first_name", Type: "text"},
+								{Name: "last_name", Type: "text"},
+							},
+							Up:   migrations.MultiColumnUpSQL{"first_name": "name"},
+							Down: "first_name",
+						},
+					},
+				},
+			},
+			wantStartErr: migrations.ColumnMigrationMissingError{Table: "users", Name: "last_name"},
+		},
+		{
+			name: "up SQL must only be given for new columns",
+			migrations: []migrations.Migration{
+				createTableMigration,
+				{
+					Name: "02_split_column",
+					Operations: migrations.Operations{
+						&migrations.OpSplitColumn{
+							Table:   "users",
+							Column:  "name",
+							Columns: []migrations.Column{{Name: "first_name", Type: "text"}},
+							Up: migrations.MultiColumnUpSQL{
+								"first_name": "name",
+								"nickname":   "name",
+							},
+							Down: "first_name",
+						},
+					},
+				},
+			},
+			wantStartErr: migrations.ColumnMigrationRedundantError{Table: "users", Name: "nickname"},
+		},
+		{
+			name: "new columns must not already exist",
+			migrations: []migrations.Migration{
+				createTableMigration,
+				{
+					Name: "02_split_column",
+					Operations: migrations.Operations{
+						&migrations.OpSplitColumn{
+							Table:   "users",
+							Column:  "name",
+							Columns: []migrations.Column{{Name: "id", Type: "text"}},
+							Up:      migrations.MultiColumnUpSQL{"id": "name"},
+							Down:    "id",
+						},
+					},
+				},
+			},
+			wantStartErr: migrations.ColumnAlreadyExistsError{Table: "users", Name: "id"},
+		},
+	})
+}
@@ -0,0 +1,129 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+	pgq "github.com/xataio/pg_query_go/v6"
+
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+// partitionBoundConstraintName is the name of the check constraint that
+// matches the bound of a table being attached as a partition.
+var partitionBoundConstraintName = TemporaryName("partition_bound")
+
+// parsePartitionBound parses a partition bound specification as used in a
+// CREATE TABLE ... PARTITION OF statement, eg FOR VALUES IN ('a', 'b') or
+// DEFAULT.
+func parsePartitionBound(bound string) (*pgq.PartitionBoundSpec, error) {
+	tree, err := pgq.Parse("CREATE TABLE p PARTITION OF t " + bound)
+	if err != nil {
+		return nil, err
+	}
+
+	stmts := tree.GetStmts()
+	if len(stmts) != 1 {
+		return nil, fmt.Errorf("expected a single partition bound, found %d statements", len(stmts))
+	}
+	spec := stmts[0].GetStmt().GetCreateStmt().GetPartbound()
+	if spec == nil {
+		return nil, fmt.Errorf("no partition bound found")
+	}
+	return spec, nil
+}
+
+// validatePartitionBound returns an error if `bound` is not a valid bound for
+// partition `name`.
+func validatePartitionBound(name, bound string) error {
+	if bound == "" {
+		return FieldRequiredError{Name: "bound"}
+	}
+	if _, err := parsePartitionBound(bound); err != nil {
+		return InvalidPartitionBoundError{Name: name, Bound: bound}
+	}
+	return nil
+}
+
+// partitionBoundCheck returns a check expression that Postgres recognizes as
+// implying the partition constraint of a partition with bound `bound` of a
+// table partitioned by `key`. A table with a valid constraint with this
+// expression can be attached as a partition without scanning it.
+//
+// Only single column list and range partitions are supported; false is
+// returned for default and hash partitions and multi-column range partitions.
+func partitionBoundCheck(key *schema.PartitionKey, bound string) (string, bool) {
+	spec, err := parsePartitionBound(bound)
+	if err != nil || spec.GetIsDefault() || len(key.Columns) != 1 {
+		return "", false
+	}
+	column := pq.QuoteIdentifier(key.Columns[0])
+
+	switch spec.GetStrategy() {
+	case "l":
+		var values []string
+		var hasNull bool
+		for _, datum := range spec.GetListdatums() {
+			if datum.GetAConst().GetIsnull() {
+				hasNull = true
+				continue
+			}
+			value, err := pgq.DeparseExpr(datum)
+			if err != nil {
+				return "", false
+			}
+			values = append(values, value)
+		}
+
+		if len(values) == 0 {
+			return fmt.Sprintf("%s IS NULL", column), true
+		}
+		in := fmt.Sprintf("%s IN (%s)", column, strings.Join(values, ", "))
+		if hasNull {
+			return fmt.Sprintf("%s IS NULL OR %s", column, in), true
+		}
+		return fmt.Sprintf("%s IS NOT NULL AND %s", column, in), true
+
+	case "r":
+		conditions := []string{fmt.Sprintf("%s IS NOT NULL", column)}
+		for _, b := range []struct {
+			datums   []*pgq.Node
+			op       string
+			infinite string
+		}{
+			{spec.GetLowerdatums(), ">=", "minvalue"},
+			{spec.GetUpperdatums(), "<", "maxvalue"},
+		} {
+			if len(b.datums) != 1 {
+				return "", false
+			}
+			if isColumnRef(b.datums[0], b.infinite) {
+				continue
+			}
+			value, err := pgq.DeparseExpr(b.datums[0])
+			if err != nil {
+				return "", false
+			}
+			conditions = append(conditions, fmt.Sprintf("%s %s %s", column, b.op, value))
+		}
+		return strings.Join(conditions, " AND "), true
+	}
+
+	return "", false
+}
+
+// isColumnRef returns true if `node` is a reference to the unqualified column
+// `name`. MINVALUE and MAXVALUE in range bounds are parsed as column references.
+func isColumnRef(node *pgq.Node, name string) bool {
+	fields := node.GetColumnRef().GetFields()
+	return len(fields) == 1 && fields[0].GetString_().GetSval() == name
+}
+
+// isDefaultPartition returns true if `p` is the default partition of its table
+func isDefaultPartition(p *schema.Partition) bool {
+	spec, err := parsePartitionBound(p.Bound)
+	return err == nil && spec.GetIsDefault()
+}
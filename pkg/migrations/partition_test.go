@@ -0,0 +1,97 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+func TestPartitionBoundCheck(t *testing.T) {
+	t.Parallel()
+
+	listKey := &schema.PartitionKey{Strategy: "list", Columns: []string{"region"}}
+	rangeKey := &schema.PartitionKey{Strategy: "range", Columns: []string{"created_at"}}
+	multiColumnKey := &schema.PartitionKey{Strategy: "range", Columns: []string{"year", "month"}}
+
+	tests := map[string]struct {
+		key    *schema.PartitionKey
+		bound  string
+		want   string
+		wantOk bool
+	}{
+		"list": {
+			key:    listKey,
+			bound:  "FOR VALUES IN ('eu', 'us')",
+			want:   `"region" IS NOT NULL AND "region" IN ('eu', 'us')`,
+			wantOk: true,
+		},
+		"list including NULL": {
+			key:    listKey,
+			bound:  "FOR VALUES IN ('eu', NULL)",
+			want:   `"region" IS NULL OR "region" IN ('eu')`,
+			wantOk: true,
+		},
+		"list of only NULL": {
+			key:    listKey,
+			bound:  "FOR VALUES IN (NULL)",
+			want:   `"region" IS NULL`,
+			wantOk: true,
+		},
+		"range": {
+			key:    rangeKey,
+			bound:  "FOR VALUES FROM ('2024-01-01') TO ('2025-01-01')",
+			want:   `"created_at" IS NOT NULL AND "created_at" >= '2024-01-01' AND "created_at" < '2025-01-01'`,
+			wantOk: true,
+		},
+		"range from MINVALUE": {
+			key:    rangeKey,
+			bound:  "FOR VALUES FROM (MINVALUE) TO ('2025-01-01')",
+			want:   `"created_at" IS NOT NULL AND "created_at" < '2025-01-01'`,
+			wantOk: true,
+		},
+		"range to MAXVALUE": {
+			key:    rangeKey,
+			bound:  "FOR VALUES FROM ('2024-01-01') TO (MAXVALUE)",
+			want:   `"created_at" IS NOT NULL AND "created_at" >= '2024-01-01'`,
+			wantOk: true,
+		},
+		"multi-column range": {
+			key:   multiColumnKey,
+			bound: "FOR VALUES FROM (2024, 1) TO (2024, 2)",
+		},
+		"hash": {
+			key:   rangeKey,
+			bound: "FOR VALUES WITH (MODULUS 4, REMAINDER 0)",
+		},
+		"default": {
+			key:   rangeKey,
+			bound: "DEFAULT",
+		},
+		"invalid bound": {
+			key:   rangeKey,
+			bound: "FOR VALUES",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, ok := partitionBoundCheck(tt.key, tt.bound)
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestValidatePartitionBound(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, validatePartitionBound("p", "DEFAULT"))
+	assert.NoError(t, validatePartitionBound("p", "FOR VALUES IN (1, 2)"))
+	assert.Equal(t, FieldRequiredError{Name: "bound"}, validatePartitionBound("p", ""))
+	assert.Equal(t, InvalidPartitionBoundError{Name: "p", Bound: "FOR VALUES IN"}, validatePartitionBound("p", "FOR VALUES IN"))
+	assert.Equal(t, InvalidPartitionBoundError{Name: "p", Bound: "DEFAULT; DROP TABLE t"}, validatePartitionBound("p", "DEFAULT; DROP TABLE t"))
+}
@@ -0,0 +1,191 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/lib/pq"
+
+	"github.com/xataio/pgroll/pkg/db"
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+// expressionTokenRegex matches the parts of a SQL expression that may contain
+// identifiers: string literals, numbers, quoted identifiers and unquoted
+// identifiers. String literals and numbers are matched only so that they can
+// be skipped.
+var expressionTokenRegex = regexp.MustCompile(`'(?:[^']|'')*'|[0-9][0-9A-Za-z_.]*|"(?:[^"]|"")*"|[A-Za-z_][A-Za-z0-9_$]*`)
+
+// identifierToken returns the identifier matched by `token`, or false if the
+// token is a string literal or number.
+func identifierToken(token string) (string, bool) {
+	switch {
+	case strings.HasPrefix(token, "'"):
+		return "", false
+	case strings.HasPrefix(token, `"`):
+		return strings.ReplaceAll(token[1:len(token)-1], `""`, `"`), true
+	case token[0] >= '0' && token[0] <= '9':
+		return "", false
+	default:
+		return strings.ToLower(token), true
+	}
+}
+
+// rewriteColumnReferences rewrites the references to columns in the SQL
+// expression `expr` according to `columns`, a map from old to new column
+// names. Like rewriteCheckExpression, the rewrite is naive: any identifier
+// outside of a string literal matching an old column name is replaced.
+func rewriteColumnReferences(expr string, columns map[string]string) string {
+	if len(columns) == 0 {
+		return expr
+	}
+	return expressionTokenRegex.ReplaceAllStringFunc(expr, func(token string) string {
+		ident, ok := identifierToken(token)
+		if !ok {
+			return token
+		}
+		if to, ok := columns[ident]; ok {
+			return pq.QuoteIdentifier(to)
+		}
+		return token
+	})
+}
+
+// referencedColumns returns the names of the columns in `columns` that are
+// referenced by any of the SQL expressions `exprs`, in name order.
+func referencedColumns(columns []string, exprs ...string) []string {
+	referenced := []string{}
+	for _, expr := range exprs {
+		for _, token := range expressionTokenRegex.FindAllString(expr, -1) {
+			ident, ok := identifierToken(token)
+			if ok && slices.Contains(columns, ident) && !slices.Contains(referenced, ident) {
+				referenced = append(referenced, ident)
+			}
+		}
+	}
+	slices.Sort(referenced)
+	return referenced
+}
+
+// physicalExpression rewrites the references to virtual column names in the
+// policy or trigger condition `expr` to the physical names of the columns of
+// `table`
+func physicalExpression(table *schema.Table, expr string) string {
+	columns := make(map[string]string, len(table.Columns))
+	for name, col := range table.Columns {
+		if name != col.Name {
+			columns[name] = col.Name
+		}
+	}
+	return rewriteColumnReferences(expr, columns)
+}
+
+// renamePolicyColumns renames all references to column `from` in the policies
+// on `table` to `to`.
+func renamePolicyColumns(table *schema.Table, from, to string) {
+	for _, name := range slices.Sorted(maps.Keys(table.Policies)) {
+		policy := table.Policies[name]
+		if !slices.Contains(policy.Columns, from) {
+			continue
+		}
+		mapping := map[string]string{from: to}
+		policy.Using = rewriteColumnReferences(policy.Using, mapping)
+		policy.WithCheck = rewriteColumnReferences(policy.WithCheck, mapping)
+		for i, c := range policy.Columns {
+			if c == from {
+				policy.Columns[i] = to
+			}
+		}
+		slices.Sort(policy.Columns)
+	}
+}
+
+// policyRoleKeywords are the role specifications that are keywords rather
+// than role names
+var policyRoleKeywords = []string{"public", "current_user", "current_role", "session_user"}
+
+// formatPolicyRoles returns `roles` formatted for the TO clause of a CREATE
+// POLICY or ALTER POLICY statement.
+func formatPolicyRoles(roles []string) string {
+	formatted := make([]string, len(roles))
+	for i, role := range roles {
+		if slices.Contains(policyRoleKeywords, strings.ToLower(role)) {
+			formatted[i] = strings.ToUpper(role)
+		} else {
+			formatted[i] = pq.QuoteIdentifier(role)
+		}
+	}
+	return strings.Join(formatted, ", ")
+}
+
+// rewritePoliciesAction is a DBAction that rewrites the policies referencing
+// columns that have been duplicated to reference the duplicated columns
+// instead.
+type rewritePoliciesAction struct {
+	conn    db.DB
+	id      string
+	table   *schema.Table
+	columns []string
+}
+
+// NewRewritePoliciesAction creates an action that rewrites the expressions of
+// the policies on `table` referencing any of `columns` to reference the
+// temporary duplicates of the columns. The old columns can then be dropped,
+// and the policies follow the duplicated columns when they are renamed.
+func NewRewritePoliciesAction(conn db.DB, table *schema.Table, columns ...string) *rewritePoliciesAction {
+	return &rewritePoliciesAction{
+		conn:    conn,
+		id:      fmt.Sprintf("rewrite_policies_%s_%s", table.Name, strings.Join(columns, "_")),
+		table:   table,
+		columns: columns,
+	}
+}
+
+func (a *rewritePoliciesAction) ID() string { return a.id }
+
+func (a *rewritePoliciesAction) Locks() []LockImpact {
+	for _, policy := range a.table.Policies {
+		for _, column := range a.columns {
+			if slices.Contains(policy.Columns, column) {
+				return []LockImpact{accessExclusiveLock(a.table.Name)}
+			}
+		}
+	}
+	return nil
+}
+
+func (a *rewritePoliciesAction) Execute(ctx context.Context) error {
+	mapping := make(map[string]string, len(a.columns))
+	for _, column := range a.columns {
+		mapping[column] = TemporaryName(column)
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(a.table.Policies)) {
+		policy := a.table.Policies[name]
+		if !slices.ContainsFunc(a.columns, func(c string) bool { return slices.Contains(policy.Columns, c) }) {
+			continue
+		}
+
+		var using, withCheck *string
+		if policy.Using != "" {
+			rewritten := rewriteColumnReferences(policy.Using, mapping)
+			using = &rewritten
+		}
+		if policy.WithCheck != "" {
+			rewritten := rewriteColumnReferences(policy.WithCheck, mapping)
+			withCheck = &rewritten
+		}
+
+		err := NewAlterPolicyAction(a.conn, a.table.Name, name, nil, using, withCheck).Execute(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to rewrite policy %q: %w", name, err)
+		}
+	}
+	return nil
+}
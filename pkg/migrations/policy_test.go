@@ -0,0 +1,103 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+func TestRewriteColumnReferences(t *testing.T) {
+	t.Parallel()
+
+	columns := map[string]string{"owner": "_pgroll_new_owner", "Team": "team_id"}
+
+	tests := map[string]struct {
+		expr string
+		want string
+	}{
+		"unquoted reference": {
+			expr: "(owner = CURRENT_USER)",
+			want: `("_pgroll_new_owner" = CURRENT_USER)`,
+		},
+		"unquoted references are case insensitive": {
+			expr: "OWNER = current_user",
+			want: `"_pgroll_new_owner" = current_user`,
+		},
+		"quoted reference": {
+			expr: `"Team" = 1 AND "owner" = 'x'`,
+			want: `"team_id" = 1 AND "_pgroll_new_owner" = 'x'`,
+		},
+		"quoted references are case sensitive": {
+			expr: `"OWNER" = 'x' AND team = 1`,
+			want: `"OWNER" = 'x' AND team = 1`,
+		},
+		"string literals are not rewritten": {
+			expr: "owner <> 'owner''s'",
+			want: `"_pgroll_new_owner" <> 'owner''s'`,
+		},
+		"longer identifiers are not rewritten": {
+			expr: "owner_id = 1 AND co_owner = 2",
+			want: "owner_id = 1 AND co_owner = 2",
+		},
+		"qualified reference": {
+			expr: "documents.owner = CURRENT_USER",
+			want: `documents."_pgroll_new_owner" = CURRENT_USER`,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, rewriteColumnReferences(tt.expr, columns))
+		})
+	}
+}
+
+func TestReferencedColumns(t *testing.T) {
+	t.Parallel()
+
+	columns := []string{"id", "owner", "shared"}
+
+	assert.Equal(t, []string{"owner", "shared"}, referencedColumns(columns, "owner = current_user OR shared", `"owner" IS NULL`))
+	assert.Equal(t, []string{}, referencedColumns(columns, "'owner' = current_user"))
+}
+
+func TestRenamePolicyColumns(t *testing.T) {
+	t.Parallel()
+
+	table := &schema.Table{
+		Name: "documents",
+		Policies: map[string]*schema.Policy{
+			"owner_all": {
+				Name:      "owner_all",
+				Using:     "(owner = CURRENT_USER)",
+				WithCheck: "(owner = CURRENT_USER AND id > 0)",
+				Columns:   []string{"id", "owner"},
+			},
+			"public_read": {
+				Name:    "public_read",
+				Using:   "public",
+				Columns: []string{"public"},
+			},
+		},
+	}
+
+	renamePolicyColumns(table, "owner", "author")
+
+	assert.Equal(t, &schema.Policy{
+		Name:      "owner_all",
+		Using:     `("author" = CURRENT_USER)`,
+		WithCheck: `("author" = CURRENT_USER AND id > 0)`,
+		Columns:   []string{"author", "id"},
+	}, table.Policies["owner_all"])
+	assert.Equal(t, "public", table.Policies["public_read"].Using)
+}
+
+func TestFormatPolicyRoles(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, `PUBLIC, CURRENT_USER, "app", "Admin"`, formatPolicyRoles([]string{"public", "current_user", "app", "Admin"}))
+}
@@ -0,0 +1,116 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/lib/pq"
+
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+// publicRole is the pseudo-role representing all roles
+const publicRole = "PUBLIC"
+
+// tablePrivileges are the privileges that ALL expands to on a table
+var tablePrivileges = []TablePrivilege{
+	TablePrivilegeSELECT,
+	TablePrivilegeINSERT,
+	TablePrivilegeUPDATE,
+	TablePrivilegeDELETE,
+	TablePrivilegeTRUNCATE,
+	TablePrivilegeREFERENCES,
+	TablePrivilegeTRIGGER,
+}
+
+// validatePrivileges checks that `privileges` is a non-empty list of valid
+// table privileges
+func validatePrivileges(privileges []TablePrivilege) error {
+	if len(privileges) == 0 {
+		return FieldRequiredError{Name: "privileges"}
+	}
+	for _, p := range privileges {
+		if p != TablePrivilegeALL && !slices.Contains(tablePrivileges, p) {
+			return InvalidPrivilegeError{Privilege: string(p)}
+		}
+	}
+	return nil
+}
+
+// expandPrivileges returns `privileges` with ALL replaced by the privileges it
+// stands for
+func expandPrivileges(privileges []TablePrivilege) []TablePrivilege {
+	if slices.Contains(privileges, TablePrivilegeALL) {
+		return tablePrivileges
+	}
+	return privileges
+}
+
+// privilegesSQL returns the comma separated list of `privileges` for use in a
+// GRANT or REVOKE statement
+func privilegesSQL(privileges []TablePrivilege) string {
+	sqls := make([]string, len(privileges))
+	for i, p := range privileges {
+		sqls[i] = string(p)
+	}
+	return strings.Join(sqls, ", ")
+}
+
+// granteeName returns the name under which privileges granted to `role` are
+// recorded in the schema
+func granteeName(role string) string {
+	if strings.EqualFold(role, publicRole) {
+		return publicRole
+	}
+	return role
+}
+
+// quoteRole quotes `role` for use in a GRANT or REVOKE statement
+func quoteRole(role string) string {
+	if strings.EqualFold(role, publicRole) {
+		return publicRole
+	}
+	return pq.QuoteIdentifier(role)
+}
+
+// rolePrivileges returns the privileges held by `role` on `table`
+func rolePrivileges(table *schema.Table, role string) []schema.Privilege {
+	var privileges []schema.Privilege
+	for _, p := range table.Privileges {
+		if p.Grantee == granteeName(role) {
+			privileges = append(privileges, p)
+		}
+	}
+	return privileges
+}
+
+// grantPrivileges records the grant of `privileges` on `table` to `role` in
+// the virtual schema
+func grantPrivileges(table *schema.Table, role string, privileges []TablePrivilege, grantable bool) {
+	for _, p := range expandPrivileges(privileges) {
+		i := slices.IndexFunc(table.Privileges, func(tp schema.Privilege) bool {
+			return tp.Grantee == granteeName(role) && tp.Privilege == string(p)
+		})
+		if i >= 0 {
+			table.Privileges[i].Grantable = table.Privileges[i].Grantable || grantable
+			continue
+		}
+		table.Privileges = append(table.Privileges, schema.Privilege{
+			Grantee:   granteeName(role),
+			Privilege: string(p),
+			Grantable: grantable,
+		})
+	}
+}
+
+// revokePrivileges records the revocation of `privileges` on `table` from
+// `role` in the virtual schema
+func revokePrivileges(table *schema.Table, role string, privileges []TablePrivilege) {
+	all := slices.Contains(privileges, TablePrivilegeALL)
+	table.Privileges = slices.DeleteFunc(table.Privileges, func(tp schema.Privilege) bool {
+		return tp.Grantee == granteeName(role) &&
+			(all || slices.Contains(privileges, TablePrivilege(tp.Privilege)))
+	})
+}
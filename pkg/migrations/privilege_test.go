@@ -0,0 +1,57 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+func TestGrantAndRevokePrivileges(t *testing.T) {
+	t.Parallel()
+
+	table := &schema.Table{
+		Privileges: []schema.Privilege{
+			{Grantee: "reader", Privilege: "SELECT"},
+		},
+	}
+
+	grantPrivileges(table, "reader", []TablePrivilege{"SELECT", "INSERT"}, true)
+	grantPrivileges(table, "public", []TablePrivilege{"SELECT"}, false)
+	assert.Equal(t, []schema.Privilege{
+		{Grantee: "reader", Privilege: "SELECT", Grantable: true},
+		{Grantee: "reader", Privilege: "INSERT", Grantable: true},
+		{Grantee: "PUBLIC", Privilege: "SELECT"},
+	}, table.Privileges)
+
+	revokePrivileges(table, "reader", []TablePrivilege{"INSERT"})
+	assert.Equal(t, []schema.Privilege{
+		{Grantee: "reader", Privilege: "SELECT", Grantable: true},
+		{Grantee: "PUBLIC", Privilege: "SELECT"},
+	}, table.Privileges)
+
+	revokePrivileges(table, "PUBLIC", []TablePrivilege{"ALL"})
+	assert.Equal(t, []schema.Privilege{
+		{Grantee: "reader", Privilege: "SELECT", Grantable: true},
+	}, table.Privileges)
+}
+
+func TestValidatePrivileges(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, validatePrivileges([]TablePrivilege{"ALL"}))
+	assert.NoError(t, validatePrivileges([]TablePrivilege{"SELECT", "TRIGGER"}))
+	assert.Equal(t, FieldRequiredError{Name: "privileges"}, validatePrivileges(nil))
+	assert.Equal(t, InvalidPrivilegeError{Privilege: "select"}, validatePrivileges([]TablePrivilege{"select"}))
+}
+
+func TestQuoteRole(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "PUBLIC", quoteRole("public"))
+	assert.Equal(t, `"app_user"`, quoteRole("app_user"))
+	assert.Equal(t, `"App User"`, quoteRole("App User"))
+}
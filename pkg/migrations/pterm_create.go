@@ -261,6 +261,302 @@ func (o *OpRenameTable) Create() {
 	o.To, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("to").Show()
 }
 
+func (o *OpCreateEnum) Create() {
+	o.Name, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("name").Show()
+	values, _ := pterm.DefaultInteractiveTextInput.WithDefaultText("values").Show()
+	o.Values = strings.Split(values, ",")
+}
+
+func (o *OpAddEnumValue) Create() {
+	o.Enum, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("enum").Show()
+	o.Value, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("value").Show()
+	o.Before, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("before").Show()
+	if o.Before == "" {
+		o.After, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("after").Show()
+	}
+}
+
+func (o *OpRenameEnumValue) Create() {
+	o.Enum, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("enum").Show()
+	o.From, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("from").Show()
+	o.To, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("to").Show()
+}
+
+func (o *OpDropEnumValue) Create() {
+	o.Enum, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("enum").Show()
+	o.Value, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("value").Show()
+	replacement, _ := pterm.DefaultInteractiveTextInput.WithDefaultText("replacement").Show()
+	if replacement != "" {
+		o.Replacement = &replacement
+	}
+}
+
+func (o *OpDropEnum) Create() {
+	o.Name, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("name").Show()
+}
+
+func (o *OpCreateView) Create() {
+	o.Name, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("name").Show()
+	o.Definition, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("definition").Show()
+}
+
+func (o *OpReplaceView) Create() {
+	o.Name, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("name").Show()
+	o.Definition, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("definition").Show()
+}
+
+func (o *OpDropView) Create() {
+	o.Name, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("name").Show()
+}
+
+func (o *OpSplitColumn) Create() {
+	o.Table, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("table").Show()
+	o.Column, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("column").Show()
+
+	o.Up = make(MultiColumnUpSQL)
+	addColumns := true
+	for addColumns {
+		column := getColumnFromCLI()
+		o.Columns = append(o.Columns, column)
+		o.Up[column.Name], _ = pterm.DefaultInteractiveTextInput.WithDefaultText(fmt.Sprintf("up migration for %s", column.Name)).Show()
+
+		addColumns, _ = pterm.DefaultInteractiveConfirm.
+			WithDefaultText("Add more columns").
+			Show()
+	}
+	o.Down, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("down").Show()
+}
+
+func (o *OpMergeColumns) Create() {
+	o.Table, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("table").Show()
+	columnsStr, _ := pterm.DefaultInteractiveTextInput.WithDefaultText("columns").Show()
+	o.Columns = strings.Split(columnsStr, ",")
+	o.Column = getColumnFromCLI()
+	o.Up, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("up").Show()
+
+	o.Down = make(MultiColumnDownSQL, len(o.Columns))
+	for _, columnName := range o.Columns {
+		o.Down[columnName], _ = pterm.DefaultInteractiveTextInput.WithDefaultText(fmt.Sprintf("down migration for %s", columnName)).Show()
+	}
+}
+
+func (o *OpPartitionTable) Create() {
+	o.Table, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("table").Show()
+	strategy, _ := pterm.DefaultInteractiveSelect.
+		WithDefaultText("strategy").
+		WithOptions([]string{"range", "list", "hash"}).
+		Show()
+	o.PartitionBy.Strategy = PartitionByStrategy(strategy)
+	columns, _ := pterm.DefaultInteractiveTextInput.WithDefaultText("columns").Show()
+	o.PartitionBy.Columns = strings.Split(columns, ",")
+
+	addPartitions := true
+	for addPartitions {
+		var p Partition
+		p.Name, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("name").Show()
+		p.Bound, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("bound").Show()
+		o.Partitions = append(o.Partitions, p)
+
+		addPartitions, _ = pterm.DefaultInteractiveConfirm.
+			WithDefaultText("Add more partitions").
+			Show()
+	}
+}
+
+func (o *OpCreatePartition) Create() {
+	o.Table, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("table").Show()
+	o.Name, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("name").Show()
+	o.Bound, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("bound").Show()
+}
+
+func (o *OpAttachPartition) Create() {
+	o.Table, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("table").Show()
+	o.Name, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("name").Show()
+	o.Bound, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("bound").Show()
+}
+
+func (o *OpDetachPartition) Create() {
+	o.Table, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("table").Show()
+	o.Name, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("name").Show()
+}
+
+func (o *OpEnableRLS) Create() {
+	o.Table, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("table").Show()
+	o.Force = getBooleanOptionForColumnAttr("force")
+}
+
+func (o *OpCreatePolicy) Create() {
+	o.Table, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("table").Show()
+	o.Name, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("name").Show()
+	as, _ := pterm.DefaultInteractiveSelect.
+		WithDefaultText("as").
+		WithOptions([]string{"PERMISSIVE", "RESTRICTIVE"}).
+		WithDefaultOption("PERMISSIVE").
+		Show()
+	o.As = OpCreatePolicyAs(as)
+	command, _ := pterm.DefaultInteractiveSelect.
+		WithDefaultText("command").
+		WithOptions([]string{"ALL", "SELECT", "INSERT", "UPDATE", "DELETE"}).
+		WithDefaultOption("ALL").
+		Show()
+	o.Command = OpCreatePolicyCommand(command)
+	if roles, _ := pterm.DefaultInteractiveTextInput.WithDefaultText("roles").Show(); roles != "" {
+		o.Roles = strings.Split(roles, ",")
+	}
+	o.Using, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("using").Show()
+	o.WithCheck, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("with_check").Show()
+}
+
+func (o *OpAlterPolicy) Create() {
+	o.Table, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("table").Show()
+	o.Name, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("name").Show()
+	if roles, _ := pterm.DefaultInteractiveTextInput.WithDefaultText("roles").Show(); roles != "" {
+		o.Roles = strings.Split(roles, ",")
+	}
+	if using, _ := pterm.DefaultInteractiveTextInput.WithDefaultText("using").Show(); using != "" {
+		o.Using = &using
+	}
+	if withCheck, _ := pterm.DefaultInteractiveTextInput.WithDefaultText("with_check").Show(); withCheck != "" {
+		o.WithCheck = &withCheck
+	}
+}
+
+func (o *OpDropPolicy) Create() {
+	o.Table, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("table").Show()
+	o.Name, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("name").Show()
+}
+
+func (o *OpCreateFunction) Create() {
+	o.Name, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("name").Show()
+	o.Arguments, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("arguments").Show()
+	o.Returns, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("returns").Show()
+	o.Language, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("language").WithDefaultValue("plpgsql").Show()
+	o.Volatility = getFunctionVolatility()
+	o.Definition, _ = pterm.DefaultInteractiveTextInput.WithMultiLine().WithDefaultText("definition").Show()
+}
+
+func (o *OpReplaceFunction) Create() {
+	o.Name, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("name").Show()
+	o.Language, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("language").Show()
+	o.Volatility = getFunctionVolatility()
+	o.Definition, _ = pterm.DefaultInteractiveTextInput.WithMultiLine().WithDefaultText("definition").Show()
+}
+
+func (o *OpDropFunction) Create() {
+	o.Name, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("name").Show()
+}
+
+func (o *OpCreateTrigger) Create() {
+	o.Table, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("table").Show()
+	o.Name, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("name").Show()
+	o.Function, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("function").Show()
+	timing, _ := pterm.DefaultInteractiveSelect.
+		WithDefaultText("timing").
+		WithOptions([]string{"BEFORE", "AFTER", "INSTEAD OF"}).
+		WithDefaultOption("BEFORE").
+		Show()
+	o.Timing = OpCreateTriggerTiming(timing)
+	events, _ := pterm.DefaultInteractiveMultiselect.
+		WithDefaultText("events").
+		WithOptions([]string{"INSERT", "UPDATE", "DELETE", "TRUNCATE"}).
+		Show()
+	for _, event := range events {
+		o.Events = append(o.Events, OpCreateTriggerEventsElem(event))
+	}
+	forEach, _ := pterm.DefaultInteractiveSelect.
+		WithDefaultText("for_each").
+		WithOptions([]string{"ROW", "STATEMENT"}).
+		WithDefaultOption("ROW").
+		Show()
+	o.ForEach = OpCreateTriggerForEach(forEach)
+	o.When, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("when").Show()
+}
+
+func (o *OpDropTrigger) Create() {
+	o.Table, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("table").Show()
+	o.Name, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("name").Show()
+}
+
+func (o *OpCreateSequence) Create() {
+	o.Name, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("name").Show()
+	as, _ := pterm.DefaultInteractiveSelect.
+		WithDefaultText("as").
+		WithOptions([]string{"smallint", "integer", "bigint"}).
+		WithDefaultOption("bigint").
+		Show()
+	o.As = OpCreateSequenceAs(as)
+	o.StartWith = getOptionalIntOption("start_with")
+	o.Increment = getOptionalIntOption("increment")
+	o.MinValue = getOptionalIntOption("min_value")
+	o.MaxValue = getOptionalIntOption("max_value")
+	o.Cache = getOptionalIntOption("cache")
+	o.Cycle = getBooleanOptionForColumnAttr("cycle")
+	o.OwnedBy, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("owned_by").Show()
+}
+
+func (o *OpAlterSequence) Create() {
+	o.Name, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("name").Show()
+	o.Increment = getOptionalIntOption("increment")
+	o.MinValue = getOptionalIntOption("min_value")
+	o.MaxValue = getOptionalIntOption("max_value")
+	o.Cache = getOptionalIntOption("cache")
+	o.Restart = getOptionalIntOption("restart")
+}
+
+func (o *OpDropSequence) Create() {
+	o.Name, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("name").Show()
+}
+
+func (o *OpConvertToIdentity) Create() {
+	o.Table, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("table").Show()
+	o.Column, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("column").Show()
+	generated, _ := pterm.DefaultInteractiveSelect.
+		WithDefaultText("generated").
+		WithOptions([]string{"ALWAYS", "BY DEFAULT"}).
+		WithDefaultOption("BY DEFAULT").
+		Show()
+	o.Generated = OpConvertToIdentityGenerated(generated)
+}
+
+func (o *OpMoveTable) Create() {
+	o.Table, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("table").Show()
+	o.Schema, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("schema").Show()
+}
+
+func (o *OpGrant) Create() {
+	o.Table, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("table").Show()
+	o.Role, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("role").Show()
+	o.Privileges = getTablePrivileges()
+	o.WithGrantOption = getBooleanOptionForColumnAttr("with_grant_option")
+}
+
+func (o *OpRevoke) Create() {
+	o.Table, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("table").Show()
+	o.Role, _ = pterm.DefaultInteractiveTextInput.WithDefaultText("role").Show()
+	o.Privileges = getTablePrivileges()
+}
+
+func getTablePrivileges() []TablePrivilege {
+	privileges, _ := pterm.DefaultInteractiveMultiselect.
+		WithDefaultText("privileges").
+		WithOptions([]string{"ALL", "SELECT", "INSERT", "UPDATE", "DELETE", "TRUNCATE", "REFERENCES", "TRIGGER"}).
+		Show()
+	privs := make([]TablePrivilege, len(privileges))
+	for i, p := range privileges {
+		privs[i] = TablePrivilege(p)
+	}
+	return privs
+}
+
+func getFunctionVolatility() FunctionVolatility {
+	volatility, _ := pterm.DefaultInteractiveSelect.
+		WithDefaultText("volatility").
+		WithOptions([]string{"VOLATILE", "STABLE", "IMMUTABLE"}).
+		WithDefaultOption("VOLATILE").
+		Show()
+	return FunctionVolatility(volatility)
+}
+
 func getFkAction(name string) ForeignKeyAction {
 	action, _ := pterm.DefaultInteractiveSelect.
 		WithDefaultText(name).
@@ -313,3 +609,14 @@ func getBooleanOptionForColumnAttr(name string) bool {
 	boolVal, _ := strconv.ParseBool(val)
 	return boolVal
 }
+
+// getOptionalIntOption asks for an integer option, returning nil if no valid
+// integer is given
+func getOptionalIntOption(name string) *int {
+	val, _ := pterm.DefaultInteractiveTextInput.WithDefaultText(name).Show()
+	i, err := strconv.Atoi(val)
+	if err != nil {
+		return nil
+	}
+	return &i
+}
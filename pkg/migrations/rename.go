@@ -37,6 +37,15 @@ func NewRenameDuplicatedColumnAction(conn db.DB, table *schema.Table, column str
 
 func (a *renameDuplicatedColumnAction) ID() string { return a.id }
 
+// Renaming a duplicated column validates any duplicated check constraints on
+// it, which scans the table without blocking writes.
+func (a *renameDuplicatedColumnAction) Locks() []LockImpact {
+	return []LockImpact{
+		{Level: LockLevelShareUpdateExclusive, Relation: a.table.Name, ScansTable: true},
+		accessExclusiveLock(a.table.Name),
+	}
+}
+
 func (a *renameDuplicatedColumnAction) Execute(ctx context.Context) error {
 	const (
 		cRenameIndexSQL = `ALTER INDEX IF EXISTS %s RENAME TO %s`
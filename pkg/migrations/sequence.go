@@ -0,0 +1,181 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/lib/pq"
+
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+// defaultSequenceDataType is the data type of sequences created without an
+// explicit data type
+const defaultSequenceDataType = "bigint"
+
+// sequenceDataTypeRange returns the range of values of the sequence data type
+// `dataType`, or false if it is not a valid sequence data type.
+func sequenceDataTypeRange(dataType string) (int64, int64, bool) {
+	switch dataType {
+	case "smallint":
+		return math.MinInt16, math.MaxInt16, true
+	case "integer":
+		return math.MinInt32, math.MaxInt32, true
+	case "bigint":
+		return math.MinInt64, math.MaxInt64, true
+	}
+	return 0, 0, false
+}
+
+// sequenceOptions are the options of a CREATE SEQUENCE or ALTER SEQUENCE
+// statement. Nil options are left unset.
+type sequenceOptions struct {
+	start     *int
+	increment *int
+	minValue  *int
+	maxValue  *int
+	cache     *int
+	cycle     *bool
+	restart   *int
+}
+
+// isEmpty returns true if none of the options are set
+func (o sequenceOptions) isEmpty() bool {
+	return o.start == nil && o.increment == nil && o.minValue == nil && o.maxValue == nil &&
+		o.cache == nil && o.cycle == nil && o.restart == nil
+}
+
+// sql returns the options as a sequence options clause
+func (o sequenceOptions) sql() string {
+	var opts []string
+	if o.increment != nil {
+		opts = append(opts, fmt.Sprintf("INCREMENT BY %d", *o.increment))
+	}
+	if o.minValue != nil {
+		opts = append(opts, fmt.Sprintf("MINVALUE %d", *o.minValue))
+	}
+	if o.maxValue != nil {
+		opts = append(opts, fmt.Sprintf("MAXVALUE %d", *o.maxValue))
+	}
+	if o.start != nil {
+		opts = append(opts, fmt.Sprintf("START WITH %d", *o.start))
+	}
+	if o.restart != nil {
+		opts = append(opts, fmt.Sprintf("RESTART WITH %d", *o.restart))
+	}
+	if o.cache != nil {
+		opts = append(opts, fmt.Sprintf("CACHE %d", *o.cache))
+	}
+	if o.cycle != nil {
+		if *o.cycle {
+			opts = append(opts, "CYCLE")
+		} else {
+			opts = append(opts, "NO CYCLE")
+		}
+	}
+	return strings.Join(opts, " ")
+}
+
+// apply applies the options to `seq`, filling in the defaults postgres uses
+// for options that are not set on a new sequence.
+func (o sequenceOptions) apply(seq *schema.Sequence) {
+	if o.increment != nil {
+		seq.Increment = int64(*o.increment)
+	}
+	if o.minValue != nil {
+		seq.MinValue = int64(*o.minValue)
+	}
+	if o.maxValue != nil {
+		seq.MaxValue = int64(*o.maxValue)
+	}
+	if o.start != nil {
+		seq.Start = int64(*o.start)
+	}
+	if o.cache != nil {
+		seq.Cache = int64(*o.cache)
+	}
+	if o.cycle != nil {
+		seq.Cycle = *o.cycle
+	}
+}
+
+// validate returns an error if the options are not valid for `seq`, the
+// sequence with the options applied.
+func (o sequenceOptions) validate(seq *schema.Sequence) error {
+	if seq.Increment == 0 {
+		return InvalidSequenceOptionsError{Name: seq.Name, Reason: "increment must not be zero"}
+	}
+	if o.cache != nil && *o.cache < 1 {
+		return InvalidSequenceOptionsError{Name: seq.Name, Reason: "cache must be at least 1"}
+	}
+	if seq.MinValue >= seq.MaxValue {
+		return InvalidSequenceOptionsError{Name: seq.Name, Reason: "min_value must be less than max_value"}
+	}
+	if seq.Start < seq.MinValue || seq.Start > seq.MaxValue {
+		return InvalidSequenceOptionsError{Name: seq.Name, Reason: "start must be between min_value and max_value"}
+	}
+	if o.restart != nil && (int64(*o.restart) < seq.MinValue || int64(*o.restart) > seq.MaxValue) {
+		return InvalidSequenceOptionsError{Name: seq.Name, Reason: "restart must be between min_value and max_value"}
+	}
+	return nil
+}
+
+// parseSequenceOwner splits the owner of a sequence, given as `table.column`,
+// into the table and column names.
+func parseSequenceOwner(ownedBy string) (string, string, bool) {
+	table, column, ok := strings.Cut(ownedBy, ".")
+	if !ok || table == "" || column == "" || strings.Contains(column, ".") {
+		return "", "", false
+	}
+	return table, column, true
+}
+
+// nextvalDefaultRegex matches a column default that takes its value from a
+// sequence, as reported by postgres for serial columns, eg
+// nextval('orders_id_seq'::regclass)
+var nextvalDefaultRegex = regexp.MustCompile(`^nextval\('((?:[^']|'')*)'(?:::regclass)?\)$`)
+
+// defaultSequence returns the sequence used by the column default `def`, as
+// the contents of a regclass literal, or false if the default does not take
+// its value from a sequence.
+func defaultSequence(def *string) (string, bool) {
+	if def == nil {
+		return "", false
+	}
+	match := nextvalDefaultRegex.FindStringSubmatch(*def)
+	if match == nil {
+		return "", false
+	}
+	return match[1], true
+}
+
+// defaultSequenceName returns the unqualified name of the sequence used by the
+// column default `def`, or false if the default does not take its value from
+// a sequence.
+func defaultSequenceName(def *string) (string, bool) {
+	seq, ok := defaultSequence(def)
+	if !ok {
+		return "", false
+	}
+	tokens := expressionTokenRegex.FindAllString(strings.ReplaceAll(seq, "''", "'"), -1)
+	if len(tokens) == 0 {
+		return "", false
+	}
+	return identifierToken(tokens[len(tokens)-1])
+}
+
+// regclassLiteral returns the regclass literal for the contents `seq` returned
+// by defaultSequence
+func regclassLiteral(seq string) string {
+	return fmt.Sprintf("'%s'::regclass", seq)
+}
+
+// qualifiedSequenceOwner returns the quoted column owning a sequence, given as
+// `table.column`
+func qualifiedSequenceOwner(table, column string) string {
+	return pq.QuoteIdentifier(table) + "." + pq.QuoteIdentifier(column)
+}
@@ -0,0 +1,88 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultSequenceName(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		def    string
+		want   string
+		wantOk bool
+	}{
+		"serial column default": {
+			def:    "nextval('orders_id_seq'::regclass)",
+			want:   "orders_id_seq",
+			wantOk: true,
+		},
+		"qualified sequence": {
+			def:    "nextval('public.orders_id_seq'::regclass)",
+			want:   "orders_id_seq",
+			wantOk: true,
+		},
+		"quoted sequence": {
+			def:    `nextval('"Orders_Seq"'::regclass)`,
+			want:   "Orders_Seq",
+			wantOk: true,
+		},
+		"unquoted sequence names are case insensitive": {
+			def:    "nextval('Orders_Seq'::regclass)",
+			want:   "orders_seq",
+			wantOk: true,
+		},
+		"default not using a sequence": {
+			def: "'pending'::text",
+		},
+		"expression using a sequence": {
+			def: "nextval('orders_id_seq'::regclass) * 2",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, ok := defaultSequenceName(&tt.def)
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSequenceOptionsSQL(t *testing.T) {
+	t.Parallel()
+
+	increment, minValue, maxValue, start, cache, restart := -2, -100, -1, -10, 5, -50
+	cycle := true
+
+	assert.Equal(t, "", sequenceOptions{}.sql())
+	assert.Equal(t,
+		"INCREMENT BY -2 MINVALUE -100 MAXVALUE -1 START WITH -10 RESTART WITH -50 CACHE 5 CYCLE",
+		sequenceOptions{
+			start:     &start,
+			increment: &increment,
+			minValue:  &minValue,
+			maxValue:  &maxValue,
+			cache:     &cache,
+			cycle:     &cycle,
+			restart:   &restart,
+		}.sql())
+}
+
+func TestParseSequenceOwner(t *testing.T) {
+	t.Parallel()
+
+	table, column, ok := parseSequenceOwner("orders.id")
+	assert.True(t, ok)
+	assert.Equal(t, "orders", table)
+	assert.Equal(t, "id", column)
+
+	for _, ownedBy := range []string{"orders", "orders.", ".id", "public.orders.id"} {
+		_, _, ok := parseSequenceOwner(ownedBy)
+		assert.False(t, ok, ownedBy)
+	}
+}
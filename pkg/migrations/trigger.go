@@ -0,0 +1,47 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	pgq "github.com/xataio/pg_query_go/v6"
+
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+// triggerFromSchema returns an operation that creates `trigger` on `table`
+// as it is recorded in the schema.
+func triggerFromSchema(table string, trigger *schema.Trigger) *OpCreateTrigger {
+	events := make([]OpCreateTriggerEventsElem, len(trigger.Events))
+	for i, event := range trigger.Events {
+		events[i] = OpCreateTriggerEventsElem(event)
+	}
+
+	return &OpCreateTrigger{
+		Table:    table,
+		Name:     trigger.Name,
+		Function: trigger.Function,
+		Timing:   OpCreateTriggerTiming(trigger.Timing),
+		Events:   events,
+		ForEach:  OpCreateTriggerForEach(trigger.ForEach),
+		When:     triggerCondition(trigger.Definition),
+	}
+}
+
+// triggerCondition returns the WHEN condition of the CREATE TRIGGER statement
+// `definition`, or an empty string if the trigger has no condition.
+func triggerCondition(definition string) string {
+	tree, err := pgq.Parse(definition)
+	if err != nil || len(tree.GetStmts()) != 1 {
+		return ""
+	}
+
+	when := tree.GetStmts()[0].GetStmt().GetCreateTrigStmt().GetWhenClause()
+	if when == nil {
+		return ""
+	}
+	condition, err := pgq.DeparseExpr(when)
+	if err != nil {
+		return ""
+	}
+	return condition
+}
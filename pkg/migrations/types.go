@@ -181,6 +181,13 @@ type ForeignKeyReference struct {
 	Table string `json:"table"`
 }
 
+// Volatility of a function
+type FunctionVolatility string
+
+const FunctionVolatilityIMMUTABLE FunctionVolatility = "IMMUTABLE"
+const FunctionVolatilitySTABLE FunctionVolatility = "STABLE"
+const FunctionVolatilityVOLATILE FunctionVolatility = "VOLATILE"
+
 // Index field and its settings
 type IndexField struct {
 	// Collation for the index element
@@ -236,6 +243,34 @@ type OpAddColumn struct {
 	Up string `json:"up,omitempty"`
 }
 
+// Add enum value operation
+type OpAddEnumValue struct {
+	// Existing value after which the new value is added
+	After string `json:"after,omitempty"`
+
+	// Existing value before which the new value is added
+	Before string `json:"before,omitempty"`
+
+	// Name of the enum type
+	Enum string `json:"enum"`
+
+	// Value to add
+	Value string `json:"value"`
+}
+
+// Attach partition operation
+type OpAttachPartition struct {
+	// Partition bound of the partition, eg FOR VALUES FROM ('2024-01-01') TO
+	// ('2025-01-01')
+	Bound string `json:"bound"`
+
+	// Name of the table to attach as a partition
+	Name string `json:"name"`
+
+	// Name of the partitioned table
+	Table string `json:"table"`
+}
+
 // Alter column operation
 type OpAlterColumn struct {
 	// Add check constraint to the column
@@ -274,6 +309,65 @@ type OpAlterColumn struct {
 	Up string `json:"up"`
 }
 
+// Alter policy operation
+type OpAlterPolicy struct {
+	// Name of the policy
+	Name string `json:"name"`
+
+	// Roles the policy applies to
+	Roles []string `json:"roles,omitempty"`
+
+	// Name of the table
+	Table string `json:"table"`
+
+	// SQL expression that rows must satisfy to be visible
+	Using *string `json:"using,omitempty"`
+
+	// SQL expression that new rows must satisfy to be written
+	WithCheck *string `json:"with_check,omitempty"`
+}
+
+// Alter sequence operation
+type OpAlterSequence struct {
+	// Number of sequence values to preallocate
+	Cache *int `json:"cache,omitempty"`
+
+	// Whether the sequence wraps around when it reaches its limit
+	Cycle *bool `json:"cycle,omitempty"`
+
+	// Value added to the sequence for each new value
+	Increment *int `json:"increment,omitempty"`
+
+	// Maximum value of the sequence
+	MaxValue *int `json:"max_value,omitempty"`
+
+	// Minimum value of the sequence
+	MinValue *int `json:"min_value,omitempty"`
+
+	// Name of the sequence
+	Name string `json:"name"`
+
+	// Value the sequence restarts from
+	Restart *int `json:"restart,omitempty"`
+}
+
+// Convert serial column to identity column operation
+type OpConvertToIdentity struct {
+	// Name of the column
+	Column string `json:"column"`
+
+	// How values are generated for the identity column
+	Generated OpConvertToIdentityGenerated `json:"generated,omitempty"`
+
+	// Name of the table
+	Table string `json:"table"`
+}
+
+type OpConvertToIdentityGenerated string
+
+const OpConvertToIdentityGeneratedALWAYS OpConvertToIdentityGenerated = "ALWAYS"
+const OpConvertToIdentityGeneratedBYDEFAULT OpConvertToIdentityGenerated = "BY DEFAULT"
+
 // Add constraint to table operation
 type OpCreateConstraint struct {
 	// Check constraint expression
@@ -325,6 +419,36 @@ const OpCreateConstraintTypeForeignKey OpCreateConstraintType = "foreign_key"
 const OpCreateConstraintTypePrimaryKey OpCreateConstraintType = "primary_key"
 const OpCreateConstraintTypeUnique OpCreateConstraintType = "unique"
 
+// Create enum type operation
+type OpCreateEnum struct {
+	// Name of the enum type
+	Name string `json:"name"`
+
+	// Values of the enum, in sort order
+	Values []string `json:"values"`
+}
+
+// Create function operation
+type OpCreateFunction struct {
+	// Argument list of the function, eg `a integer, b text`
+	Arguments string `json:"arguments,omitempty"`
+
+	// Body of the function
+	Definition string `json:"definition"`
+
+	// Language the function is written in
+	Language string `json:"language,omitempty"`
+
+	// Name of the function
+	Name string `json:"name"`
+
+	// Return type of the function
+	Returns string `json:"returns"`
+
+	// Volatility of the function
+	Volatility FunctionVolatility `json:"volatility,omitempty"`
+}
+
 // Create index operation
 type OpCreateIndex struct {
 	// Names and settings of columns on which to define the index
@@ -358,6 +482,92 @@ const OpCreateIndexMethodGist OpCreateIndexMethod = "gist"
 const OpCreateIndexMethodHash OpCreateIndexMethod = "hash"
 const OpCreateIndexMethodSpgist OpCreateIndexMethod = "spgist"
 
+// Create partition operation
+type OpCreatePartition struct {
+	// Partition bound of the partition, eg FOR VALUES FROM ('2024-01-01') TO
+	// ('2025-01-01')
+	Bound string `json:"bound"`
+
+	// Name of the partition
+	Name string `json:"name"`
+
+	// Name of the partitioned table
+	Table string `json:"table"`
+}
+
+// Create policy operation
+type OpCreatePolicy struct {
+	// Whether the policy is permissive or restrictive
+	As OpCreatePolicyAs `json:"as,omitempty"`
+
+	// Command the policy applies to
+	Command OpCreatePolicyCommand `json:"command,omitempty"`
+
+	// Name of the policy
+	Name string `json:"name"`
+
+	// Roles the policy applies to
+	Roles []string `json:"roles,omitempty"`
+
+	// Name of the table
+	Table string `json:"table"`
+
+	// SQL expression that rows must satisfy to be visible
+	Using string `json:"using,omitempty"`
+
+	// SQL expression that new rows must satisfy to be written
+	WithCheck string `json:"with_check,omitempty"`
+}
+
+type OpCreatePolicyAs string
+
+const OpCreatePolicyAsPERMISSIVE OpCreatePolicyAs = "PERMISSIVE"
+const OpCreatePolicyAsRESTRICTIVE OpCreatePolicyAs = "RESTRICTIVE"
+
+type OpCreatePolicyCommand string
+
+const OpCreatePolicyCommandALL OpCreatePolicyCommand = "ALL"
+const OpCreatePolicyCommandDELETE OpCreatePolicyCommand = "DELETE"
+const OpCreatePolicyCommandINSERT OpCreatePolicyCommand = "INSERT"
+const OpCreatePolicyCommandSELECT OpCreatePolicyCommand = "SELECT"
+const OpCreatePolicyCommandUPDATE OpCreatePolicyCommand = "UPDATE"
+
+// Create sequence operation
+type OpCreateSequence struct {
+	// Data type of the sequence
+	As OpCreateSequenceAs `json:"as,omitempty"`
+
+	// Number of sequence values to preallocate
+	Cache *int `json:"cache,omitempty"`
+
+	// Whether the sequence wraps around when it reaches its limit
+	Cycle bool `json:"cycle,omitempty"`
+
+	// Value added to the sequence for each new value
+	Increment *int `json:"increment,omitempty"`
+
+	// Maximum value of the sequence
+	MaxValue *int `json:"max_value,omitempty"`
+
+	// Minimum value of the sequence
+	MinValue *int `json:"min_value,omitempty"`
+
+	// Name of the sequence
+	Name string `json:"name"`
+
+	// Column owning the sequence, as `table.column`
+	OwnedBy string `json:"owned_by,omitempty"`
+
+	// First value of the sequence
+	StartWith *int `json:"start_with,omitempty"`
+}
+
+type OpCreateSequenceAs string
+
+const OpCreateSequenceAsBigint OpCreateSequenceAs = "bigint"
+const OpCreateSequenceAsInteger OpCreateSequenceAs = "integer"
+const OpCreateSequenceAsSmallint OpCreateSequenceAs = "smallint"
+
 // Create table operation
 type OpCreateTable struct {
 	// Columns corresponds to the JSON schema field "columns".
@@ -373,6 +583,66 @@ type OpCreateTable struct {
 	Name string `json:"name"`
 }
 
+// Create trigger operation
+type OpCreateTrigger struct {
+	// Events the trigger fires on
+	Events []OpCreateTriggerEventsElem `json:"events"`
+
+	// Whether the trigger fires once for each row or once for each statement
+	ForEach OpCreateTriggerForEach `json:"for_each,omitempty"`
+
+	// Name of the function the trigger executes
+	Function string `json:"function"`
+
+	// Name of the trigger
+	Name string `json:"name"`
+
+	// Name of the table
+	Table string `json:"table"`
+
+	// When the trigger fires relative to the event
+	Timing OpCreateTriggerTiming `json:"timing"`
+
+	// SQL condition that must be satisfied for the trigger to fire
+	When string `json:"when,omitempty"`
+}
+
+type OpCreateTriggerEventsElem string
+
+const OpCreateTriggerEventsElemDELETE OpCreateTriggerEventsElem = "DELETE"
+const OpCreateTriggerEventsElemINSERT OpCreateTriggerEventsElem = "INSERT"
+const OpCreateTriggerEventsElemTRUNCATE OpCreateTriggerEventsElem = "TRUNCATE"
+const OpCreateTriggerEventsElemUPDATE OpCreateTriggerEventsElem = "UPDATE"
+
+type OpCreateTriggerForEach string
+
+const OpCreateTriggerForEachROW OpCreateTriggerForEach = "ROW"
+const OpCreateTriggerForEachSTATEMENT OpCreateTriggerForEach = "STATEMENT"
+
+type OpCreateTriggerTiming string
+
+const OpCreateTriggerTimingAFTER OpCreateTriggerTiming = "AFTER"
+const OpCreateTriggerTimingBEFORE OpCreateTriggerTiming = "BEFORE"
+const OpCreateTriggerTimingINSTEADOF OpCreateTriggerTiming = "INSTEAD OF"
+
+// Create view operation
+type OpCreateView struct {
+	// SELECT query defining the view
+	Definition string `json:"definition"`
+
+	// Name of the view
+	Name string `json:"name"`
+}
+
+// Detach partition operation
+type OpDetachPartition struct {
+	// Name of the partition
+	Name string `json:"name"`
+
+	// Name of the partitioned table
+	Table string `json:"table"`
+}
+
 // Drop column operation
 type OpDropColumn struct {
 	// Name of the column
@@ -400,6 +670,31 @@ type OpDropConstraint struct {
 	Up string `json:"up"`
 }
 
+// Drop enum type operation
+type OpDropEnum struct {
+	// Name of the enum type
+	Name string `json:"name"`
+}
+
+// Drop enum value operation
+type OpDropEnumValue struct {
+	// Name of the enum type
+	Enum string `json:"enum"`
+
+	// Value that rows holding the dropped value are migrated to. Rows are set to
+	// NULL if no replacement is given
+	Replacement *string `json:"replacement,omitempty"`
+
+	// Value to drop
+	Value string `json:"value"`
+}
+
+// Drop function operation
+type OpDropFunction struct {
+	// Name of the function
+	Name string `json:"name"`
+}
+
 // Drop index operation
 type OpDropIndex struct {
 	// Index name
@@ -421,12 +716,105 @@ type OpDropMultiColumnConstraint struct {
 	Up MultiColumnUpSQL `json:"up,omitempty"`
 }
 
+// Drop policy operation
+type OpDropPolicy struct {
+	// Name of the policy
+	Name string `json:"name"`
+
+	// Name of the table
+	Table string `json:"table"`
+}
+
+// Drop sequence operation
+type OpDropSequence struct {
+	// Name of the sequence
+	Name string `json:"name"`
+}
+
 // Drop table operation
 type OpDropTable struct {
 	// Name of the table
 	Name string `json:"name"`
 }
 
+// Drop trigger operation
+type OpDropTrigger struct {
+	// Name of the trigger
+	Name string `json:"name"`
+
+	// Name of the table
+	Table string `json:"table"`
+}
+
+// Drop view operation
+type OpDropView struct {
+	// Name of the view
+	Name string `json:"name"`
+}
+
+// Enable row level security operation
+type OpEnableRLS struct {
+	// Apply row level security to the owner of the table as well
+	Force bool `json:"force,omitempty"`
+
+	// Name of the table
+	Table string `json:"table"`
+}
+
+// Grant privileges operation
+type OpGrant struct {
+	// Privileges to grant
+	Privileges []TablePrivilege `json:"privileges"`
+
+	// Role to grant the privileges to, or PUBLIC
+	Role string `json:"role"`
+
+	// Name of the table
+	Table string `json:"table"`
+
+	// Allow the role to grant the privileges to other roles
+	WithGrantOption bool `json:"with_grant_option,omitempty"`
+}
+
+// Merge columns operation
+type OpMergeColumns struct {
+	// Column to create from the merged columns
+	Column Column `json:"column"`
+
+	// Names of the columns to merge
+	Columns []string `json:"columns"`
+
+	// SQL expressions for down migration of each merged column
+	Down MultiColumnDownSQL `json:"down"`
+
+	// Name of the table
+	Table string `json:"table"`
+
+	// SQL expression for up migration of the new column
+	Up string `json:"up"`
+}
+
+// Move table operation
+type OpMoveTable struct {
+	// Name of the schema to move the table to
+	Schema string `json:"schema"`
+
+	// Name of the table to move
+	Table string `json:"table"`
+}
+
+// Partition table operation
+type OpPartitionTable struct {
+	// Partition key of the partitioned table
+	PartitionBy PartitionBy `json:"partition_by"`
+
+	// Partitions to create for the partitioned table
+	Partitions []Partition `json:"partitions"`
+
+	// Name of the table to partition
+	Table string `json:"table"`
+}
+
 // Raw SQL operation
 type OpRawSQL struct {
 	// SQL expression for down migration
@@ -463,6 +851,18 @@ type OpRenameConstraint struct {
 	To string `json:"to"`
 }
 
+// Rename enum value operation
+type OpRenameEnumValue struct {
+	// Name of the enum type
+	Enum string `json:"enum"`
+
+	// Old value
+	From string `json:"from"`
+
+	// New value
+	To string `json:"to"`
+}
+
 // Rename table operation
 type OpRenameTable struct {
 	// Old name of the table
@@ -472,6 +872,30 @@ type OpRenameTable struct {
 	To string `json:"to"`
 }
 
+// Replace function operation
+type OpReplaceFunction struct {
+	// New body of the function
+	Definition string `json:"definition"`
+
+	// Language the function is written in
+	Language string `json:"language,omitempty"`
+
+	// Name of the function
+	Name string `json:"name"`
+
+	// Volatility of the function
+	Volatility FunctionVolatility `json:"volatility,omitempty"`
+}
+
+// Replace view operation
+type OpReplaceView struct {
+	// SELECT query defining the view
+	Definition string `json:"definition"`
+
+	// Name of the view
+	Name string `json:"name"`
+}
+
 // Set replica identity operation
 type OpSetReplicaIdentity struct {
 	// Replica identity to set
@@ -481,8 +905,67 @@ type OpSetReplicaIdentity struct {
 	Table string `json:"table"`
 }
 
+// Revoke privileges operation
+type OpRevoke struct {
+	// Privileges to revoke
+	Privileges []TablePrivilege `json:"privileges"`
+
+	// Role to revoke the privileges from, or PUBLIC
+	Role string `json:"role"`
+
+	// Name of the table
+	Table string `json:"table"`
+}
+
+// Split column operation
+type OpSplitColumn struct {
+	// Name of the column to split
+	Column string `json:"column"`
+
+	// Columns to create from the split column
+	Columns []Column `json:"columns"`
+
+	// SQL expression for down migration of the split column
+	Down string `json:"down"`
+
+	// Name of the table
+	Table string `json:"table"`
+
+	// SQL expressions for up migration of each new column
+	Up MultiColumnUpSQL `json:"up"`
+}
+
+// Partition definition
+type Partition struct {
+	// Partition bound, for example FOR VALUES FROM ('2024-01-01') TO
+	// ('2025-01-01'), or DEFAULT
+	Bound string `json:"bound"`
+
+	// Name of the partition
+	Name string `json:"name"`
+}
+
+// Partition key definition
+type PartitionBy struct {
+	// Columns of the partition key
+	Columns []string `json:"columns"`
+
+	// Partitioning strategy
+	Strategy PartitionByStrategy `json:"strategy"`
+}
+
+type PartitionByStrategy string
+
+const PartitionByStrategyHash PartitionByStrategy = "hash"
+const PartitionByStrategyList PartitionByStrategy = "list"
+const PartitionByStrategyRange PartitionByStrategy = "range"
+
 // PgRoll migration definition
 type PgRollMigration struct {
+	// Whether the migration is a baseline that recreates the schema left by all
+	// the migrations before it
+	Baseline *bool `json:"baseline,omitempty"`
+
 	// Name of the migration
 	Name *string `json:"name,omitempty"`
 
@@ -527,7 +1010,18 @@ type TableForeignKeyReference struct {
 	Table string `json:"table"`
 }
 
-// Unique constraint definition
+// Privilege on a table
+type TablePrivilege string
+
+const TablePrivilegeALL TablePrivilege = "ALL"
+const TablePrivilegeDELETE TablePrivilege = "DELETE"
+const TablePrivilegeINSERT TablePrivilege = "INSERT"
+const TablePrivilegeREFERENCES TablePrivilege = "REFERENCES"
+const TablePrivilegeSELECT TablePrivilege = "SELECT"
+const TablePrivilegeTRIGGER TablePrivilege = "TRIGGER"
+const TablePrivilegeTRUNCATE TablePrivilege = "TRUNCATE"
+const TablePrivilegeUPDATE TablePrivilege = "UPDATE"
+
 type UniqueConstraint struct {
 	// Name of unique constraint
 	Name string `json:"name"`
@@ -0,0 +1,46 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"encoding/json"
+
+	pgq "github.com/xataio/pg_query_go/v6"
+)
+
+// RelationNames returns the names of all relations referenced by the query
+// `sql`, or nil if the query can not be parsed.
+func RelationNames(sql string) []string {
+	tree, err := pgq.ParseToJSON(sql)
+	if err != nil {
+		return nil
+	}
+
+	var node any
+	if err := json.Unmarshal([]byte(tree), &node); err != nil {
+		return nil
+	}
+
+	var names []string
+	var walk func(any)
+	walk = func(node any) {
+		switch n := node.(type) {
+		case map[string]any:
+			if rv, ok := n["RangeVar"].(map[string]any); ok {
+				if name, ok := rv["relname"].(string); ok {
+					names = append(names, name)
+				}
+			}
+			for _, v := range n {
+				walk(v)
+			}
+		case []any:
+			for _, v := range n {
+				walk(v)
+			}
+		}
+	}
+	walk(node)
+
+	return names
+}
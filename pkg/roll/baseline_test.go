@@ -7,10 +7,14 @@ import (
 	"database/sql"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/xataio/pgroll/internal/testutils"
+	"github.com/xataio/pgroll/pkg/backfill"
+	"github.com/xataio/pgroll/pkg/migrations"
 	"github.com/xataio/pgroll/pkg/roll"
 	"github.com/xataio/pgroll/pkg/schema"
+	"github.com/xataio/pgroll/pkg/sql2pgroll"
 	"github.com/xataio/pgroll/pkg/state"
 )
 
@@ -58,6 +62,67 @@ func TestBaseline(t *testing.T) {
 			require.Equal(t, wantSchema, sc)
 		})
 	})
+
+	t.Run("operations generated from the baseline schema recreate it", func(t *testing.T) {
+		testutils.WithMigratorAndConnectionToContainer(t, func(r *roll.Roll, db *sql.DB) {
+			ctx := context.Background()
+
+			_, err := db.ExecContext(ctx, `
+				CREATE TYPE mood AS ENUM ('happy', 'sad');
+				CREATE SEQUENCE team_numbers START 1000;
+				CREATE SEQUENCE team_codes AS integer START 500;
+				CREATE TABLE users (
+					id serial PRIMARY KEY,
+					email text NOT NULL UNIQUE,
+					mood mood,
+					CONSTRAINT email_length CHECK (length(email) > 3)
+				);
+				CREATE TABLE teams (
+					id integer PRIMARY KEY,
+					owner_id integer REFERENCES users (id) ON DELETE CASCADE,
+					name text DEFAULT 'unnamed',
+					number bigint DEFAULT nextval('team_numbers'),
+					code integer DEFAULT nextval('team_codes')
+				);
+				ALTER SEQUENCE team_codes OWNED BY teams.code;
+				CREATE TABLE members (
+					id bigint GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+					team_id integer REFERENCES teams (id)
+				);
+				ALTER TABLE teams ADD COLUMN captain_id bigint REFERENCES members (id);
+				CREATE INDEX teams_name ON teams (name) WHERE name IS NOT NULL;
+				COMMENT ON TABLE teams IS 'teams of users';
+			`)
+			require.NoError(t, err)
+
+			err = r.CreateBaseline(ctx, "01_initial_version")
+			require.NoError(t, err)
+
+			want, err := r.State().SchemaAfterMigration(ctx, "public", "01_initial_version")
+			require.NoError(t, err)
+
+			ops, err := sql2pgroll.ConvertSchema(want)
+			require.NoError(t, err)
+
+			// Apply the generated baseline to an empty database
+			testutils.WithMigratorAndConnectionToContainer(t, func(fresh *roll.Roll, _ *sql.DB) {
+				baseline := &migrations.Migration{
+					Name:       "01_initial_version",
+					Baseline:   true,
+					Operations: ops,
+				}
+				require.NoError(t, fresh.Start(ctx, baseline, backfill.NewConfig()))
+				require.NoError(t, fresh.Complete(ctx))
+
+				got, err := fresh.State().ReadSchema(ctx, "public")
+				require.NoError(t, err)
+
+				clearOIDS(want)
+				clearOIDS(got)
+				assert.Equal(t, want, got)
+			})
+		})
+	})
 }
 
 func clearOIDS(s *schema.Schema) {
@@ -0,0 +1,174 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package roll
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// clientPollInterval is how often sessions using the previous version schema
+// are checked for while waiting for them to go away
+const clientPollInterval = time.Second
+
+// WaitForClients configures how Complete waits for sessions using the previous
+// version schema to go away before dropping it
+type WaitForClients struct {
+	// Timeout is how long to wait in total before aborting the completion
+	Timeout time.Duration
+
+	// GracePeriod is how long no session must have used the previous version
+	// schema before it is dropped
+	GracePeriod time.Duration
+
+	// CheckLocks also treats sessions holding locks on objects in the previous
+	// version schema as using it
+	CheckLocks bool
+}
+
+// Session describes a backend that is using a version schema
+type Session struct {
+	PID             int
+	User            string
+	ApplicationName string
+	ClientAddr      string
+	State           string
+	Query           string
+}
+
+func (s Session) String() string {
+	query := strings.Join(strings.Fields(s.Query), " ")
+	if len(query) > 80 {
+		query = query[:77] + "..."
+	}
+	return fmt.Sprintf("pid=%d user=%q application_name=%q client_addr=%q state=%q query=%q",
+		s.PID, s.User, s.ApplicationName, s.ClientAddr, s.State, query)
+}
+
+// ClientsConnectedError is returned by Complete when sessions are still using
+// the previous version schema once the wait timeout has expired
+type ClientsConnectedError struct {
+	Schema   string
+	Sessions []Session
+}
+
+func (e ClientsConnectedError) Error() string {
+	lines := make([]string, 0, len(e.Sessions))
+	for _, s := range e.Sessions {
+		lines = append(lines, "  "+s.String())
+	}
+	return fmt.Sprintf("%d session(s) are still using version schema %q:\n%s",
+		len(e.Sessions), e.Schema, strings.Join(lines, "\n"))
+}
+
+// ClientsRecentlyConnectedError is returned by Complete when the wait timeout
+// expires before the previous version schema has gone unused for the grace
+// period, even though no session is using it any more
+type ClientsRecentlyConnectedError struct {
+	Schema      string
+	UnusedFor   time.Duration
+	GracePeriod time.Duration
+}
+
+func (e ClientsRecentlyConnectedError) Error() string {
+	return fmt.Sprintf("version schema %q has only been unused for %s, less than the grace period of %s",
+		e.Schema, e.UnusedFor.Round(time.Second), e.GracePeriod)
+}
+
+// waitForClients waits until no session has used `versionSchema` for the
+// configured grace period, returning a ClientsConnectedError if sessions are
+// still using it when the timeout expires, or a ClientsRecentlyConnectedError
+// if the grace period has not yet elapsed. The grace period starts when the
+// wait starts, so the timeout must be longer than the grace period.
+//
+// A session is considered to be using the schema if its current or most recent
+// query mentions the schema as a whole identifier, for example in a `SET
+// search_path` statement or a qualified table name, or, if lock checking is
+// enabled, if it holds a lock on an object in the schema. Sessions that set
+// their search_path and have since run other queries are only found by
+// checking locks.
+func (m *Roll) waitForClients(ctx context.Context, versionSchema string) error {
+	cfg := m.clientWait
+
+	deadline := time.Now().Add(cfg.Timeout)
+	quietSince := time.Now()
+
+	for {
+		sessions, err := m.sessionsUsingSchema(ctx, versionSchema, cfg.CheckLocks)
+		if err != nil {
+			return fmt.Errorf("unable to check for sessions using version schema %q: %w", versionSchema, err)
+		}
+
+		now := time.Now()
+		if len(sessions) > 0 {
+			quietSince = now
+		} else if now.Sub(quietSince) >= cfg.GracePeriod {
+			return nil
+		}
+
+		if !now.Before(deadline) {
+			if len(sessions) == 0 {
+				return ClientsRecentlyConnectedError{
+					Schema:      versionSchema,
+					UnusedFor:   now.Sub(quietSince),
+					GracePeriod: cfg.GracePeriod,
+				}
+			}
+			return ClientsConnectedError{Schema: versionSchema, Sessions: sessions}
+		}
+
+		m.logger.Info("waiting for sessions to stop using version schema", "schema", versionSchema, "sessions", len(sessions))
+
+		if err := sleepCtx(ctx, min(clientPollInterval, time.Until(deadline))); err != nil {
+			return err
+		}
+	}
+}
+
+// sessionsUsingSchema returns the client backends, other than those of pgroll
+// itself, that are using `schemaName`
+func (m *Roll) sessionsUsingSchema(ctx context.Context, schemaName string, checkLocks bool) ([]Session, error) {
+	const query = `SELECT a.pid,
+			COALESCE(a.usename, ''),
+			COALESCE(a.application_name, ''),
+			COALESCE(host(a.client_addr), ''),
+			COALESCE(a.state, ''),
+			COALESCE(a.query, '')
+		FROM pg_stat_activity a
+		WHERE a.backend_type = 'client backend'
+			AND a.pid <> pg_backend_pid()
+			AND a.application_name <> $3
+			AND (
+				a.query ~ $4::text
+				OR ($2::boolean AND EXISTS (
+					SELECT 1 FROM pg_locks l
+					JOIN pg_class c ON c.oid = l.relation
+					JOIN pg_namespace n ON n.oid = c.relnamespace
+					WHERE l.pid = a.pid AND n.nspname::text = $1::text
+				))
+			)
+		ORDER BY a.pid`
+
+	// Match the schema name only where it is not part of a longer identifier
+	pattern := `(^|[^[:alnum:]_$])` + regexp.QuoteMeta(schemaName) + `($|[^[:alnum:]_$])`
+
+	rows, err := m.pgConn.QueryContext(ctx, query, schemaName, checkLocks, applicationName, pattern)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []Session
+	for rows.Next() {
+		var s Session
+		if err := rows.Scan(&s.PID, &s.User, &s.ApplicationName, &s.ClientAddr, &s.State, &s.Query); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+
+	return sessions, rows.Err()
+}
@@ -0,0 +1,197 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package roll_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xataio/pgroll/internal/testutils"
+	"github.com/xataio/pgroll/pkg/backfill"
+	"github.com/xataio/pgroll/pkg/migrations"
+	"github.com/xataio/pgroll/pkg/roll"
+)
+
+func TestCompleteAbortsWhileClientsUseThePreviousVersion(t *testing.T) {
+	t.Parallel()
+
+	opts := []roll.Option{roll.WithWaitForClients(roll.WaitForClients{
+		Timeout:     2 * time.Second,
+		GracePeriod: time.Second,
+	})}
+
+	testutils.WithMigratorInSchemaAndConnectionToContainerWithOptions(t, "public", opts, func(mig *roll.Roll, db *sql.DB) {
+		ctx := context.Background()
+
+		startTwoMigrations(t, mig)
+
+		// A client uses the previous version schema
+		conn := connectToVersion(t, db, "public_01_create_table")
+		defer conn.Close()
+
+		err := mig.Complete(ctx)
+		clientsErr := roll.ClientsConnectedError{}
+		require.ErrorAs(t, err, &clientsErr)
+		require.Len(t, clientsErr.Sessions, 1)
+		assert.Equal(t, "old-app", clientsErr.Sessions[0].ApplicationName)
+
+		// The migration is still in progress and the previous version still exists
+		status, err := mig.Status(ctx, "public")
+		require.NoError(t, err)
+		assert.Equal(t, roll.InProgressMigrationStatus, status.Status)
+		assert.True(t, schemaExists(t, db, "public_01_create_table"))
+	})
+}
+
+func TestCompleteWaitsForClientsToStopUsingThePreviousVersion(t *testing.T) {
+	t.Parallel()
+
+	opts := []roll.Option{roll.WithWaitForClients(roll.WaitForClients{
+		Timeout:     time.Minute,
+		GracePeriod: time.Second,
+	})}
+
+	testutils.WithMigratorInSchemaAndConnectionToContainerWithOptions(t, "public", opts, func(mig *roll.Roll, db *sql.DB) {
+		ctx := context.Background()
+
+		startTwoMigrations(t, mig)
+
+		// A client uses the previous version schema, then moves to the new one
+		conn := connectToVersion(t, db, "public_01_create_table")
+		defer conn.Close()
+		time.AfterFunc(2*time.Second, func() {
+			conn.ExecContext(ctx, "SET search_path TO public_02_add_column")
+		})
+
+		require.NoError(t, mig.Complete(ctx))
+		assert.False(t, schemaExists(t, db, "public_01_create_table"))
+	})
+}
+
+func TestCompleteAbortsWhenTheGracePeriodOutlastsTheTimeout(t *testing.T) {
+	t.Parallel()
+
+	opts := []roll.Option{roll.WithWaitForClients(roll.WaitForClients{
+		Timeout:     2 * time.Second,
+		GracePeriod: 5 * time.Second,
+	})}
+
+	testutils.WithMigratorInSchemaAndConnectionToContainerWithOptions(t, "public", opts, func(mig *roll.Roll, db *sql.DB) {
+		ctx := context.Background()
+
+		startTwoMigrations(t, mig)
+
+		// A client uses the previous version schema, then moves to the new one
+		// before the timeout expires
+		conn := connectToVersion(t, db, "public_01_create_table")
+		defer conn.Close()
+		time.AfterFunc(500*time.Millisecond, func() {
+			conn.ExecContext(ctx, "SET search_path TO public_02_add_column")
+		})
+
+		err := mig.Complete(ctx)
+		recentErr := roll.ClientsRecentlyConnectedError{}
+		require.ErrorAs(t, err, &recentErr)
+		assert.Equal(t, 5*time.Second, recentErr.GracePeriod)
+		assert.Less(t, recentErr.UnusedFor, recentErr.GracePeriod)
+
+		// The migration is still in progress and the previous version still exists
+		status, err := mig.Status(ctx, "public")
+		require.NoError(t, err)
+		assert.Equal(t, roll.InProgressMigrationStatus, status.Status)
+		assert.True(t, schemaExists(t, db, "public_01_create_table"))
+	})
+}
+
+func TestCompleteIgnoresClientsUsingSchemasWithTheSamePrefix(t *testing.T) {
+	t.Parallel()
+
+	opts := []roll.Option{roll.WithWaitForClients(roll.WaitForClients{
+		Timeout:     5 * time.Second,
+		GracePeriod: time.Second,
+	})}
+
+	testutils.WithMigratorInSchemaAndConnectionToContainerWithOptions(t, "public", opts, func(mig *roll.Roll, db *sql.DB) {
+		ctx := context.Background()
+
+		startTwoMigrations(t, mig)
+
+		// A client uses a schema whose name starts with the previous version
+		conn := connectToVersion(t, db, "public_01_create_table_other")
+		defer conn.Close()
+
+		require.NoError(t, mig.Complete(ctx))
+		assert.False(t, schemaExists(t, db, "public_01_create_table"))
+	})
+}
+
+func TestCompleteChecksLocksHeldOnThePreviousVersion(t *testing.T) {
+	t.Parallel()
+
+	opts := []roll.Option{roll.WithWaitForClients(roll.WaitForClients{
+		Timeout:     2 * time.Second,
+		GracePeriod: time.Second,
+		CheckLocks:  true,
+	})}
+
+	testutils.WithMigratorInSchemaAndConnectionToContainerWithOptions(t, "public", opts, func(mig *roll.Roll, db *sql.DB) {
+		ctx := context.Background()
+
+		startTwoMigrations(t, mig)
+
+		// A client reads from the previous version in a transaction and then runs
+		// a query that does not mention the schema
+		conn := connectToVersion(t, db, "public_01_create_table")
+		defer conn.Close()
+		tx, err := conn.BeginTx(ctx, nil)
+		require.NoError(t, err)
+		defer tx.Rollback()
+		_, err = tx.ExecContext(ctx, "SELECT * FROM table1")
+		require.NoError(t, err)
+		_, err = tx.ExecContext(ctx, "SELECT 1")
+		require.NoError(t, err)
+
+		err = mig.Complete(ctx)
+		clientsErr := roll.ClientsConnectedError{}
+		require.ErrorAs(t, err, &clientsErr)
+		require.Len(t, clientsErr.Sessions, 1)
+	})
+}
+
+func startTwoMigrations(t *testing.T, mig *roll.Roll) {
+	t.Helper()
+	ctx := context.Background()
+
+	require.NoError(t, mig.Start(ctx, &migrations.Migration{
+		Name:       "01_create_table",
+		Operations: migrations.Operations{createTableOp("table1")},
+	}, backfill.NewConfig()))
+	require.NoError(t, mig.Complete(ctx))
+
+	require.NoError(t, mig.Start(ctx, &migrations.Migration{
+		Name:       "02_add_column",
+		Operations: migrations.Operations{addColumnOp("table1")},
+	}, backfill.NewConfig()))
+}
+
+// connectToVersion returns a new session named `old-app` whose search_path is
+// set to the version schema `version`
+func connectToVersion(t *testing.T, db *sql.DB, version string) *sql.Conn {
+	t.Helper()
+	ctx := context.Background()
+
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+
+	_, err = conn.ExecContext(ctx, "SET application_name = 'old-app'")
+	require.NoError(t, err)
+	_, err = conn.ExecContext(ctx, "SET search_path TO "+version)
+	require.NoError(t, err)
+
+	return conn
+}
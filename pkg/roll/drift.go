@@ -0,0 +1,133 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package roll
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+
+	"github.com/xataio/pgroll/pkg/migrations"
+)
+
+// DriftedMigration is an applied migration whose local migration file has
+// changed since the migration was applied
+type DriftedMigration struct {
+	// The name of the migration
+	Name string `json:"name"`
+
+	// The checksums of the migration when it was applied and of the local
+	// migration file
+	AppliedChecksum string `json:"applied_checksum"`
+	LocalChecksum   string `json:"local_checksum"`
+
+	// A unified diff from the applied migration to the local migration file
+	Diff string `json:"diff"`
+}
+
+// DriftedMigrations returns the migrations applied to the schema since the
+// most recent baseline whose local migration file in `dir` has changed since
+// the migration was applied. Applied migrations that are missing from `dir`
+// are not reported; see `MissingMigrations`.
+func (m *Roll) DriftedMigrations(ctx context.Context, dir fs.FS) ([]DriftedMigration, error) {
+	history, err := m.State().SchemaHistory(ctx, m.Schema())
+	if err != nil {
+		return nil, fmt.Errorf("reading schema history: %w", err)
+	}
+
+	files, err := migrations.CollectFilesFromDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading migration files: %w", err)
+	}
+
+	localMigs := make(map[string]*migrations.RawMigration, len(files))
+	for _, file := range files {
+		mig, err := migrations.ReadRawMigration(dir, file)
+		if err != nil {
+			return nil, fmt.Errorf("reading migration file %q: %w", file, err)
+		}
+		localMigs[mig.Name] = mig
+	}
+
+	var drifted []DriftedMigration
+	for _, h := range history {
+		local, ok := localMigs[h.Migration.Name]
+		if !ok {
+			continue
+		}
+
+		applied, err := migrations.ParseMigration(&h.Migration)
+		if err != nil {
+			return nil, fmt.Errorf("parsing applied migration %q: %w", h.Migration.Name, err)
+		}
+
+		// Migrations applied before checksums were recorded are compared using
+		// the checksum of the migration as stored in the schema history
+		appliedChecksum := h.Checksum
+		if appliedChecksum == "" {
+			if appliedChecksum, err = applied.Checksum(); err != nil {
+				return nil, err
+			}
+		}
+
+		localMig, err := migrations.ParseMigration(local)
+		if err != nil {
+			return nil, fmt.Errorf("parsing migration file for %q: %w", local.Name, err)
+		}
+		localChecksum, err := localMig.Checksum()
+		if err != nil {
+			return nil, err
+		}
+
+		if localChecksum == appliedChecksum {
+			continue
+		}
+
+		diff, err := migrationDiff(applied, localMig)
+		if err != nil {
+			return nil, err
+		}
+
+		drifted = append(drifted, DriftedMigration{
+			Name:            local.Name,
+			AppliedChecksum: appliedChecksum,
+			LocalChecksum:   localChecksum,
+			Diff:            diff,
+		})
+	}
+
+	return drifted, nil
+}
+
+// migrationDiff returns a unified diff between the canonical representations
+// of the `applied` and `local` versions of a migration
+func migrationDiff(applied, local *migrations.Migration) (string, error) {
+	appliedYAML, err := applied.CanonicalYAML()
+	if err != nil {
+		return "", err
+	}
+	localYAML, err := local.CanonicalYAML()
+	if err != nil {
+		return "", err
+	}
+
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(appliedYAML),
+		B:        splitLines(localYAML),
+		FromFile: "applied/" + applied.Name,
+		ToFile:   "local/" + local.Name,
+		Context:  3,
+	})
+}
+
+// splitLines splits `s` into lines, keeping the line endings
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
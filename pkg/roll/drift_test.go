@@ -0,0 +1,97 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package roll_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xataio/pgroll/internal/testutils"
+	"github.com/xataio/pgroll/pkg/backfill"
+	"github.com/xataio/pgroll/pkg/migrations"
+	"github.com/xataio/pgroll/pkg/roll"
+)
+
+func TestDriftedMigrations(t *testing.T) {
+	t.Parallel()
+
+	applied := fstest.MapFS{
+		"01_migration_1.json": &fstest.MapFile{Data: exampleMigration(t, "01_migration_1")},
+		"02_migration_2.json": &fstest.MapFile{Data: exampleMigration(t, "02_migration_2")},
+	}
+
+	// applyAll applies all the migrations in `applied`
+	applyAll := func(t *testing.T, r *roll.Roll) {
+		ctx := context.Background()
+
+		for _, file := range []string{"01_migration_1.json", "02_migration_2.json"} {
+			migration, err := migrations.ReadMigration(applied, file)
+			require.NoError(t, err)
+			require.NoError(t, r.Start(ctx, migration, backfill.NewConfig()))
+			require.NoError(t, r.Complete(ctx))
+		}
+	}
+
+	t.Run("unchanged migrations have not drifted", func(t *testing.T) {
+		// The second migration is reformatted as YAML, and a third migration
+		// has not been applied yet
+		fs := fstest.MapFS{
+			"01_migration_1.json": applied["01_migration_1.json"],
+			"02_migration_2.yaml": &fstest.MapFile{Data: []byte("operations:\n  - sql:\n      up: SELECT 1\n")},
+			"03_migration_3.json": &fstest.MapFile{Data: exampleMigration(t, "03_migration_3")},
+		}
+
+		testutils.WithMigratorAndConnectionToContainer(t, func(r *roll.Roll, _ *sql.DB) {
+			applyAll(t, r)
+
+			drifted, err := r.DriftedMigrations(context.Background(), fs)
+			require.NoError(t, err)
+			assert.Empty(t, drifted)
+		})
+	})
+
+	t.Run("changed migrations have drifted", func(t *testing.T) {
+		fs := fstest.MapFS{
+			"01_migration_1.json": applied["01_migration_1.json"],
+			"02_migration_2.yaml": &fstest.MapFile{Data: []byte("operations:\n  - sql:\n      up: SELECT 2\n")},
+		}
+
+		testutils.WithMigratorAndConnectionToContainer(t, func(r *roll.Roll, _ *sql.DB) {
+			applyAll(t, r)
+
+			drifted, err := r.DriftedMigrations(context.Background(), fs)
+			require.NoError(t, err)
+			require.Len(t, drifted, 1)
+
+			assert.Equal(t, "02_migration_2", drifted[0].Name)
+			assert.NotEqual(t, drifted[0].AppliedChecksum, drifted[0].LocalChecksum)
+			assert.Equal(t, `--- applied/02_migration_2
++++ local/02_migration_2
+@@ -1,3 +1,3 @@
+ operations:
+ - sql:
+-    up: SELECT 1
++    up: SELECT 2
+`, drifted[0].Diff)
+		})
+	})
+
+	t.Run("migrations missing locally have not drifted", func(t *testing.T) {
+		fs := fstest.MapFS{
+			"02_migration_2.json": applied["02_migration_2.json"],
+		}
+
+		testutils.WithMigratorAndConnectionToContainer(t, func(r *roll.Roll, _ *sql.DB) {
+			applyAll(t, r)
+
+			drifted, err := r.DriftedMigrations(context.Background(), fs)
+			require.NoError(t, err)
+			assert.Empty(t, drifted)
+		})
+	})
+}
@@ -0,0 +1,42 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package roll
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/xataio/pgroll/pkg/state"
+)
+
+// recordEvent records `event` for the schema in the pgroll state schema. The
+// event is recorded even if `ctx` has been cancelled so that interrupted
+// phases are recorded too.
+func (m *Roll) recordEvent(ctx context.Context, event state.Event) error {
+	event.Schema = m.schema
+
+	if err := m.state.RecordEvent(context.WithoutCancel(ctx), &event); err != nil {
+		return fmt.Errorf("unable to record %s event for migration %q: %w", event.Type, event.Migration, err)
+	}
+	return nil
+}
+
+// recordPhase records the outcome of the migration `phase` that began at
+// `began` and failed with `err`, if not nil. A successful phase is recorded as
+// `event`; a failed phase is recorded as a failure event for the phase. The
+// returned error is `err` joined with any error recording the event.
+func (m *Roll) recordPhase(ctx context.Context, phase string, event state.Event, began time.Time, err error) error {
+	event.DurationMs = time.Since(began).Milliseconds()
+	if err != nil {
+		event.Phase = phase
+		event.Type = state.EventFailure
+		event.Error = err.Error()
+	}
+
+	if recordErr := m.recordEvent(ctx, event); recordErr != nil {
+		return errors.Join(err, recordErr)
+	}
+	return err
+}
@@ -7,12 +7,17 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/lib/pq"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/xataio/pgroll/pkg/backfill"
 	"github.com/xataio/pgroll/pkg/migrations"
 	"github.com/xataio/pgroll/pkg/schema"
+	"github.com/xataio/pgroll/pkg/state"
+	"github.com/xataio/pgroll/pkg/telemetry"
 )
 
 func (m *Roll) Validate(ctx context.Context, migration *migrations.Migration) error {
@@ -30,8 +35,51 @@ func (m *Roll) Validate(ctx context.Context, migration *migrations.Migration) er
 	return nil
 }
 
+// runPhase runs `f` while holding the advisory lock, recording a span and the
+// duration of the migration phase `phase`
+func (m *Roll) runPhase(ctx context.Context, phase string, f func(context.Context) error) (err error) {
+	ctx, span := telemetry.StartSpan(ctx, "pgroll."+phase, telemetry.SchemaKey.String(m.schema))
+	began := time.Now()
+	defer func() {
+		telemetry.RecordPhase(ctx, phase, time.Since(began), err)
+		telemetry.EndSpan(span, err)
+	}()
+
+	return m.withLock(ctx, func() error {
+		return f(ctx)
+	})
+}
+
+// executeOperation executes the actions for `phase` of a single operation,
+// recording a span for the operation
+func (m *Roll) executeOperation(ctx context.Context, phase string, op migrations.Operation, actions []migrations.DBAction) (err error) {
+	ctx, span := telemetry.StartSpan(ctx, "pgroll.operation."+phase,
+		telemetry.OperationKey.String(string(migrations.OperationName(op))))
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	return migrations.NewCoordinator(actions).Execute(ctx)
+}
+
 // Start will apply the required changes to enable supporting the new schema version
 func (m *Roll) Start(ctx context.Context, migration *migrations.Migration, cfg *backfill.Config) error {
+	return m.runPhase(ctx, "start", func(ctx context.Context) error {
+		return m.start(ctx, migration, cfg)
+	})
+}
+
+func (m *Roll) start(ctx context.Context, migration *migrations.Migration, cfg *backfill.Config) (err error) {
+	trace.SpanFromContext(ctx).SetAttributes(telemetry.MigrationKey.String(migration.Name))
+
+	// Record that the migration is starting before running any of it, so that
+	// a start that never finishes is visible in the history too
+	began := time.Now()
+	if err := m.recordEvent(ctx, state.Event{Migration: migration.Name, Type: state.EventStart}); err != nil {
+		return err
+	}
+	defer func() {
+		err = m.recordPhase(ctx, "start", state.Event{Migration: migration.Name, Type: state.EventStarted}, began, err)
+	}()
+
 	// Fail early if we have existing schema without migration history
 	hasExistingSchema, err := m.state.HasExistingSchemaWithoutHistory(ctx, m.schema)
 	if err != nil {
@@ -53,7 +101,7 @@ func (m *Roll) Start(ctx context.Context, migration *migrations.Migration, cfg *
 	}
 
 	// perform backfills for the tables that require it
-	return m.performBackfills(ctx, job, cfg)
+	return m.performBackfills(ctx, migration.Name, job, cfg)
 }
 
 // StartDDLOperations performs the DDL operations for the migration. This does
@@ -68,11 +116,32 @@ func (m *Roll) StartDDLOperations(ctx context.Context, migration *migrations.Mig
 		return nil, fmt.Errorf("a migration for schema %q is already in progress", m.schema)
 	}
 
+	// the other schemas changed by the migration must not have an active
+	// migration either
+	targetSchemas := migration.TargetSchemas(m.schema)
+	for _, target := range targetSchemas {
+		active, err := m.state.IsActiveMigrationPeriod(ctx, target)
+		if err != nil {
+			return nil, err
+		}
+		if active {
+			return nil, fmt.Errorf("a migration for schema %q is already in progress", target)
+		}
+	}
+
 	// create a new active migration (guaranteed to be unique by constraints)
 	if err = m.state.Start(ctx, m.schema, migration); err != nil {
 		return nil, fmt.Errorf("unable to start migration: %w", err)
 	}
 
+	// record the migration in the history of the other schemas it changes
+	for _, target := range targetSchemas {
+		if err := m.state.Start(ctx, target, migration); err != nil {
+			err = fmt.Errorf("unable to start migration in schema %q: %w", target, err)
+			return nil, errors.Join(err, m.RollbackWithReason(ctx, err.Error()))
+		}
+	}
+
 	// run any BeforeStartDDL hooks
 	if m.migrationHooks.BeforeStartDDL != nil {
 		if err := m.migrationHooks.BeforeStartDDL(m); err != nil {
@@ -109,9 +178,8 @@ func (m *Roll) StartDDLOperations(ctx context.Context, migration *migrations.Mig
 			continue
 		}
 
-		coordinator := migrations.NewCoordinator(startOp.Actions)
-		if err := coordinator.Execute(ctx); err != nil {
-			errRollback := m.Rollback(ctx)
+		if err := m.executeOperation(ctx, "start", op, startOp.Actions); err != nil {
+			errRollback := m.RollbackWithReason(ctx, err.Error())
 			if errRollback != nil {
 				return nil, errors.Join(
 					fmt.Errorf("unable to execute start operation of %q: %w", migration.Name, err),
@@ -141,11 +209,30 @@ func (m *Roll) StartDDLOperations(ctx context.Context, migration *migrations.Mig
 		if err := m.ensureViews(ctx, newSchema, migration); err != nil {
 			return nil, err
 		}
+		if err := m.ensureTargetViews(ctx, targetSchemas, migration); err != nil {
+			return nil, err
+		}
 	}
 
 	return job, nil
 }
 
+// ensureTargetViews creates the views for the new version in each of the
+// other schemas changed by the migration, reading their schemas from the
+// database after the migration's operations have been started.
+func (m *Roll) ensureTargetViews(ctx context.Context, targetSchemas []string, mig *migrations.Migration) error {
+	for _, target := range targetSchemas {
+		targetSchema, err := m.state.ReadSchema(ctx, target)
+		if err != nil {
+			return fmt.Errorf("unable to read schema %q: %w", target, err)
+		}
+		if err := m.forSchema(target).ensureViews(ctx, targetSchema, mig); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (m *Roll) ensureViews(ctx context.Context, schema *schema.Schema, mig *migrations.Migration) error {
 	versionSchema := VersionedSchemaName(m.schema, mig.VersionSchemaName())
 	_, err := m.pgConn.ExecContext(ctx, fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s", pq.QuoteIdentifier(versionSchema)))
@@ -153,6 +240,14 @@ func (m *Roll) ensureViews(ctx context.Context, schema *schema.Schema, mig *migr
 		return err
 	}
 
+	// copy the schema's privileges before creating any views, so that the
+	// schema's default privileges apply to them
+	if m.copyPrivileges {
+		if err := m.copySchemaPrivileges(ctx, versionSchema); err != nil {
+			return fmt.Errorf("unable to copy privileges to version schema: %w", err)
+		}
+	}
+
 	// create views in the new schema
 	for name, table := range schema.Tables {
 		if table.Deleted {
@@ -164,6 +259,31 @@ func (m *Roll) ensureViews(ctx context.Context, schema *schema.Schema, mig *migr
 		}
 	}
 
+	// copy the schema's functions into the new schema, so that they read from
+	// the views for tables created above
+	if m.versionedFunctions {
+		for _, fn := range versionedFunctions(schema.Functions) {
+			if err := m.ensureUserFunction(ctx, mig.VersionSchemaName(), fn); err != nil {
+				return fmt.Errorf("unable to create function %q in version schema: %w", fn.Name, err)
+			}
+		}
+	}
+
+	// make the schema's sequences available by name in the new schema
+	if hasSequences(schema) {
+		if err := m.ensureSequenceFunctions(ctx, mig.VersionSchemaName()); err != nil {
+			return fmt.Errorf("unable to create sequence functions in version schema: %w", err)
+		}
+	}
+
+	// recreate the schema's own views in the new schema, reading from the views
+	// for tables created above
+	for _, view := range orderViews(schema.Views) {
+		if err := m.ensureUserView(ctx, mig.VersionSchemaName(), view); err != nil {
+			return fmt.Errorf("unable to create view %q in version schema: %w", view.Name, err)
+		}
+	}
+
 	m.logger.LogSchemaCreation(mig.VersionSchemaName(), versionSchema)
 
 	return nil
@@ -171,24 +291,34 @@ func (m *Roll) ensureViews(ctx context.Context, schema *schema.Schema, mig *migr
 
 // Complete will update the database schema to match the current version
 func (m *Roll) Complete(ctx context.Context) error {
+	return m.runPhase(ctx, "complete", func(ctx context.Context) error {
+		return m.complete(ctx)
+	})
+}
+
+func (m *Roll) complete(ctx context.Context) (err error) {
 	// get current ongoing migration
 	migration, err := m.state.GetActiveMigration(ctx, m.schema)
 	if err != nil {
 		return fmt.Errorf("unable to get active migration: %w", err)
 	}
+	trace.SpanFromContext(ctx).SetAttributes(telemetry.MigrationKey.String(migration.Name))
+
+	began := time.Now()
+	defer func() {
+		err = m.recordPhase(ctx, "complete", state.Event{Migration: migration.Name, Type: state.EventComplete}, began, err)
+	}()
 
 	m.logger.LogMigrationComplete(migration)
 
-	// Drop the old version schema if there is one
-	prevVersion, err := m.state.PreviousVersion(ctx, m.schema)
-	if err != nil {
-		return fmt.Errorf("unable to get name of previous version: %w", err)
+	// Drop the old version schemas if there are any
+	targetSchemas := migration.TargetSchemas(m.schema)
+	if err := m.dropPreviousVersion(ctx); err != nil {
+		return err
 	}
-	if prevVersion != nil {
-		versionSchema := VersionedSchemaName(m.schema, *prevVersion)
-		_, err = m.pgConn.ExecContext(ctx, fmt.Sprintf("DROP SCHEMA IF EXISTS %s CASCADE", pq.QuoteIdentifier(versionSchema)))
-		if err != nil {
-			return fmt.Errorf("unable to drop previous version: %w", err)
+	for _, target := range targetSchemas {
+		if err := m.forSchema(target).dropPreviousVersion(ctx); err != nil {
+			return err
 		}
 	}
 
@@ -241,6 +371,9 @@ func (m *Roll) Complete(ctx context.Context) error {
 		if err != nil {
 			return err
 		}
+		if err := m.ensureTargetViews(ctx, targetSchemas, migration); err != nil {
+			return err
+		}
 	}
 
 	// mark as completed
@@ -248,6 +381,11 @@ func (m *Roll) Complete(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("unable to complete migration: %w", err)
 	}
+	for _, target := range targetSchemas {
+		if err := m.state.Complete(ctx, target, migration.Name); err != nil {
+			return fmt.Errorf("unable to complete migration in schema %q: %w", target, err)
+		}
+	}
 
 	m.logger.LogMigrationComplete(migration)
 
@@ -256,22 +394,43 @@ func (m *Roll) Complete(ctx context.Context) error {
 
 // Rollback will revert the changes made by the migration
 func (m *Roll) Rollback(ctx context.Context) error {
+	return m.RollbackWithReason(ctx, "")
+}
+
+// RollbackWithReason reverts the changes made by the migration, recording
+// `reason` as the reason for the rollback in the migration's events
+func (m *Roll) RollbackWithReason(ctx context.Context, reason string) error {
+	return m.runPhase(ctx, "rollback", func(ctx context.Context) error {
+		return m.rollback(ctx, reason)
+	})
+}
+
+func (m *Roll) rollback(ctx context.Context, reason string) (err error) {
 	// get current ongoing migration
 	migration, err := m.state.GetActiveMigration(ctx, m.schema)
 	if err != nil {
 		return fmt.Errorf("unable to get active migration: %w", err)
 	}
+	trace.SpanFromContext(ctx).SetAttributes(telemetry.MigrationKey.String(migration.Name))
+
+	began := time.Now()
+	defer func() {
+		err = m.recordPhase(ctx, "rollback", state.Event{Migration: migration.Name, Type: state.EventRollback, Reason: reason}, began, err)
+	}()
 
 	m.logger.LogMigrationRollback(migration)
 
-	// delete the schema and views for the new version
-	versionSchema := VersionedSchemaName(m.schema, migration.VersionSchemaName())
-	_, err = m.pgConn.ExecContext(ctx, fmt.Sprintf("DROP SCHEMA IF EXISTS %s CASCADE", pq.QuoteIdentifier(versionSchema)))
-	if err != nil {
-		return err
-	}
+	// delete the schemas and views for the new version
+	targetSchemas := migration.TargetSchemas(m.schema)
+	for _, schemaName := range append([]string{m.schema}, targetSchemas...) {
+		versionSchema := VersionedSchemaName(schemaName, migration.VersionSchemaName())
+		_, err = m.pgConn.ExecContext(ctx, fmt.Sprintf("DROP SCHEMA IF EXISTS %s CASCADE", pq.QuoteIdentifier(versionSchema)))
+		if err != nil {
+			return err
+		}
 
-	m.logger.LogSchemaDeletion(migration.Name, versionSchema)
+		m.logger.LogSchemaDeletion(migration.Name, versionSchema)
+	}
 
 	// get the name of the previous migration
 	previousMigration, err := m.state.PreviousMigration(ctx, m.schema)
@@ -281,6 +440,7 @@ func (m *Roll) Rollback(ctx context.Context) error {
 
 	// get the schema after the previous migration was applied
 	schema := schema.New()
+	schema.Name = m.schema
 	if previousMigration != nil {
 		schema, err = m.state.SchemaAfterMigration(ctx, m.schema, *previousMigration)
 		if err != nil {
@@ -299,8 +459,7 @@ func (m *Roll) Rollback(ctx context.Context) error {
 		if err != nil {
 			return fmt.Errorf("unable to collect actions for rollback operation: %w", err)
 		}
-		coordinator := migrations.NewCoordinator(actions)
-		if err := coordinator.Execute(ctx); err != nil {
+		if err := m.executeOperation(ctx, "rollback", migration.Operations[i], actions); err != nil {
 			return fmt.Errorf("unable to execute rollback operation: %w", err)
 		}
 	}
@@ -311,6 +470,21 @@ func (m *Roll) Rollback(ctx context.Context) error {
 		return fmt.Errorf("unable to rollback migration: %w", err)
 	}
 
+	// roll back the migration in the other schemas it changes. The migration
+	// may not have been recorded in all of them if it failed to start.
+	for _, target := range targetSchemas {
+		active, err := m.state.IsActiveMigrationPeriod(ctx, target)
+		if err != nil {
+			return err
+		}
+		if !active {
+			continue
+		}
+		if err := m.state.Rollback(ctx, target, migration.Name); err != nil {
+			return fmt.Errorf("unable to rollback migration in schema %q: %w", target, err)
+		}
+	}
+
 	m.logger.LogMigrationRollbackComplete(migration)
 
 	return nil
@@ -349,45 +523,190 @@ func (m *Roll) ensureView(ctx context.Context, version, name string, table *sche
 			pq.QuoteIdentifier(column),
 			defaultVal)
 	}
+
+	// Grant the privileges held on the table on the view as well
+	var grantPrivilegesOnView string
+	if m.copyPrivileges {
+		grantPrivilegesOnView = viewPrivilegesSQL(table, fmt.Sprintf("%s.%s",
+			pq.QuoteIdentifier(VersionedSchemaName(m.schema, version)),
+			pq.QuoteIdentifier(name)))
+	}
 	_, err := m.pgConn.ExecContext(ctx,
-		fmt.Sprintf("BEGIN; DROP VIEW IF EXISTS %s.%s; CREATE VIEW %s.%s %s AS SELECT %s FROM %s; %s COMMIT",
+		fmt.Sprintf("BEGIN; DROP VIEW IF EXISTS %s.%s; CREATE VIEW %s.%s %s AS SELECT %s FROM %s.%s; %s %s COMMIT",
 			pq.QuoteIdentifier(VersionedSchemaName(m.schema, version)),
 			pq.QuoteIdentifier(name),
 			pq.QuoteIdentifier(VersionedSchemaName(m.schema, version)),
 			pq.QuoteIdentifier(name),
 			withOptions,
 			strings.Join(columns, ","),
+			pq.QuoteIdentifier(m.schema),
 			pq.QuoteIdentifier(table.Name),
-			addDefaultsToView))
+			addDefaultsToView,
+			grantPrivilegesOnView))
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (m *Roll) performBackfills(ctx context.Context, job *backfill.Job, cfg *backfill.Config) error {
-	bf := backfill.New(m.pgConn, cfg)
+func (m *Roll) performBackfills(ctx context.Context, migrationName string, job *backfill.Job, cfg *backfill.Config) error {
+	checkpoints := m.state.BackfillCheckpoints(m.schema, migrationName)
+	bf := backfill.New(m.pgConn, cfg).WithCheckpointStore(checkpoints)
 
 	bf.CreateTriggers(ctx, job)
 
+	// Record every table that needs a backfill before starting so that all of
+	// them can be found if the backfill is interrupted.
 	for _, table := range job.Tables {
+		if err := checkpoints.SaveCheckpoint(ctx, table.Name, &backfill.Checkpoint{}); err != nil {
+			return fmt.Errorf("unable to save backfill checkpoint for table %q: %w", table.Name, err)
+		}
+	}
+
+	if err := m.backfillTables(ctx, bf, migrationName, job.Tables, cfg.Concurrency()); err != nil {
+		if m.resumableBackfills {
+			return fmt.Errorf("%w, the backfill can be resumed", err)
+		}
+
+		errRollback := m.RollbackWithReason(ctx, err.Error())
+
+		return errors.Join(err, errRollback)
+	}
+
+	return nil
+}
+
+// backfillTables backfills `tables` for migration `migrationName`. If
+// `concurrency` is greater than one the tables are backfilled in parallel,
+// otherwise they are backfilled one at a time. If the backfill of any table
+// fails, the remaining backfills are stopped.
+func (m *Roll) backfillTables(ctx context.Context, bf *backfill.Backfill, migrationName string, tables []*schema.Table, concurrency int) error {
+	backfillTable := func(ctx context.Context, table *schema.Table) error {
 		m.logger.LogBackfillStart(table.Name)
 
-		if err := bf.Start(ctx, table); err != nil {
-			errRollback := m.Rollback(ctx)
+		err := m.recordEvent(ctx, state.Event{Migration: migrationName, Type: state.EventBackfillStart, Table: table.Name})
+		if err != nil {
+			return err
+		}
 
+		began := time.Now()
+		err = bf.Start(ctx, table)
+		end := state.Event{
+			Migration:  migrationName,
+			Type:       state.EventBackfillEnd,
+			Table:      table.Name,
+			DurationMs: time.Since(began).Milliseconds(),
+		}
+		if err != nil {
+			end.Error = err.Error()
 			return errors.Join(
 				fmt.Errorf("unable to backfill table %q: %w", table.Name, err),
-				errRollback,
+				m.recordEvent(ctx, end),
 			)
 		}
+		if err := m.recordEvent(ctx, end); err != nil {
+			return err
+		}
 
 		m.logger.LogBackfillComplete(table.Name)
+		return nil
 	}
 
-	return nil
+	if concurrency <= 1 || len(tables) <= 1 {
+		for _, table := range tables {
+			if err := backfillTable(ctx, table); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	var wg sync.WaitGroup
+	for _, table := range tables {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := backfillTable(ctx, table); err != nil {
+				cancel(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return context.Cause(ctx)
+}
+
+// ResumeBackfill resumes the backfills of the active migration from their
+// last checkpoints. Backfills that have already completed are not run again.
+// The migration is not rolled back if a backfill fails.
+func (m *Roll) ResumeBackfill(ctx context.Context, cfg *backfill.Config) error {
+	return m.runPhase(ctx, "resume_backfill", func(ctx context.Context) error {
+		return m.resumeBackfill(ctx, cfg)
+	})
+}
+
+func (m *Roll) resumeBackfill(ctx context.Context, cfg *backfill.Config) error {
+	migration, err := m.state.GetActiveMigration(ctx, m.schema)
+	if err != nil {
+		return err
+	}
+
+	pending, err := m.state.PendingBackfills(ctx, m.schema, migration.Name)
+	if err != nil {
+		return fmt.Errorf("unable to read backfill progress: %w", err)
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	s, err := m.state.ReadSchema(ctx, m.schema)
+	if err != nil {
+		return fmt.Errorf("unable to read schema: %w", err)
+	}
+
+	// The backfill triggers were created when the migration was started, so
+	// only the backfills themselves need to be run.
+	tables := make([]*schema.Table, 0, len(pending))
+	for _, tableName := range pending {
+		table := s.GetTable(tableName)
+		if table == nil {
+			return fmt.Errorf("unable to resume backfill: table %q not found", tableName)
+		}
+		tables = append(tables, table)
+	}
+
+	bf := backfill.New(m.pgConn, cfg).WithCheckpointStore(m.state.BackfillCheckpoints(m.schema, migration.Name))
+
+	return m.backfillTables(ctx, bf, migration.Name, tables, cfg.Concurrency())
 }
 
 func VersionedSchemaName(schema string, version string) string {
 	return schema + "_" + version
 }
+
+// dropPreviousVersion drops the version schema of the previous migration, if
+// there is one, waiting for any clients using it first if configured to do so
+func (m *Roll) dropPreviousVersion(ctx context.Context) error {
+	prevVersion, err := m.state.PreviousVersion(ctx, m.schema)
+	if err != nil {
+		return fmt.Errorf("unable to get name of previous version: %w", err)
+	}
+	if prevVersion == nil {
+		return nil
+	}
+
+	versionSchema := VersionedSchemaName(m.schema, *prevVersion)
+	if m.clientWait != nil && !m.disableVersionSchemas {
+		if err := m.waitForClients(ctx, versionSchema); err != nil {
+			return err
+		}
+	}
+	_, err = m.pgConn.ExecContext(ctx, fmt.Sprintf("DROP SCHEMA IF EXISTS %s CASCADE", pq.QuoteIdentifier(versionSchema)))
+	if err != nil {
+		return fmt.Errorf("unable to drop previous version: %w", err)
+	}
+	return nil
+}
@@ -724,6 +724,63 @@ func TestCallbacksAreInvokedOnMigrationStart(t *testing.T) {
 	})
 }
 
+func TestBackfillCanBeResumedAfterFailure(t *testing.T) {
+	t.Parallel()
+
+	opts := []roll.Option{roll.WithResumableBackfills(true)}
+
+	testutils.WithMigratorInSchemaAndConnectionToContainerWithOptions(t, "public", opts, func(mig *roll.Roll, db *sql.DB) {
+		ctx := context.Background()
+
+		// Create a table with some data
+		_, err := db.ExecContext(ctx, "CREATE TABLE users (id SERIAL PRIMARY KEY, name text)")
+		require.NoError(t, err)
+		_, err = db.ExecContext(ctx, "INSERT INTO users (name) SELECT 'user' || n FROM generate_series(1, 100) n")
+		require.NoError(t, err)
+
+		// Cancel the backfill part way through
+		startCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		cfg := backfill.NewConfig(backfill.WithBatchSize(10))
+		cfg.AddCallback(func(n, total int64) {
+			if n >= 50 {
+				cancel()
+			}
+		})
+
+		err = mig.Start(startCtx, &migrations.Migration{
+			Name: "02_change_type",
+			Operations: migrations.Operations{
+				&migrations.OpAlterColumn{
+					Table:  "users",
+					Column: "name",
+					Type:   ptr("varchar(255)"),
+					Up:     "name",
+					Down:   "name",
+				},
+			},
+		}, cfg)
+		require.ErrorIs(t, err, context.Canceled)
+
+		// The migration is still in progress
+		status, err := mig.Status(ctx, cSchema)
+		require.NoError(t, err)
+		require.Equal(t, roll.InProgressMigrationStatus, status.Status)
+
+		// Resume the backfill and complete the migration
+		err = mig.ResumeBackfill(ctx, backfill.NewConfig(backfill.WithBatchSize(10)))
+		require.NoError(t, err)
+		err = mig.Complete(ctx)
+		require.NoError(t, err)
+
+		// All rows have been backfilled
+		var count int
+		err = db.QueryRowContext(ctx, "SELECT count(*) FROM users WHERE name IS NULL").Scan(&count)
+		require.NoError(t, err)
+		require.Zero(t, count)
+	})
+}
+
 func TestRollSchemaMethodReturnsCorrectSchema(t *testing.T) {
 	t.Parallel()
 
@@ -978,6 +1035,211 @@ func TestVersionSchemaCreationIsNotCapturedAsAnInferredMigration(t *testing.T) {
 	})
 }
 
+func TestMoveTableIsRecordedInTheHistoryOfBothSchemas(t *testing.T) {
+	t.Parallel()
+
+	testutils.WithMigratorAndConnectionToContainer(t, func(m *roll.Roll, db *sql.DB) {
+		ctx := context.Background()
+
+		// Create a table in the `public` schema
+		err := m.Start(ctx, &migrations.Migration{
+			Name:       "01_create_table",
+			Operations: migrations.Operations{createTableOp("table1")},
+		}, backfill.NewConfig())
+		require.NoError(t, err)
+		err = m.Complete(ctx)
+		require.NoError(t, err)
+
+		// Start a migration moving the table to the `billing` schema
+		err = m.Start(ctx, &migrations.Migration{
+			Name: "02_move_table",
+			Operations: migrations.Operations{
+				&migrations.OpMoveTable{Table: "table1", Schema: "billing"},
+			},
+		}, backfill.NewConfig())
+		require.NoError(t, err)
+
+		// The migration is active in both schemas and both have a new version schema
+		for _, schema := range []string{"public", "billing"} {
+			active, err := m.State().IsActiveMigrationPeriod(ctx, schema)
+			require.NoError(t, err)
+			assert.True(t, active, schema)
+			assert.True(t, schemaExists(t, db, roll.VersionedSchemaName(schema, "02_move_table")), schema)
+		}
+
+		// Rolling back removes the migration from both schemas
+		err = m.Rollback(ctx)
+		require.NoError(t, err)
+
+		hist, err := m.State().SchemaHistory(ctx, "billing")
+		require.NoError(t, err)
+		assert.Len(t, hist, 0)
+		assert.False(t, schemaExists(t, db, roll.VersionedSchemaName("billing", "02_move_table")))
+
+		// Apply the migration
+		err = m.Start(ctx, &migrations.Migration{
+			Name: "02_move_table",
+			Operations: migrations.Operations{
+				&migrations.OpMoveTable{Table: "table1", Schema: "billing"},
+			},
+		}, backfill.NewConfig())
+		require.NoError(t, err)
+		err = m.Complete(ctx)
+		require.NoError(t, err)
+
+		// The move is recorded in the history of both schemas
+		hist, err = m.State().SchemaHistory(ctx, "public")
+		require.NoError(t, err)
+		require.Len(t, hist, 2)
+		assert.Equal(t, "02_move_table", hist[1].Migration.Name)
+
+		hist, err = m.State().SchemaHistory(ctx, "billing")
+		require.NoError(t, err)
+		require.Len(t, hist, 1)
+		assert.Equal(t, "02_move_table", hist[0].Migration.Name)
+
+		// The resulting schemas reflect the move
+		publicSchema, err := m.State().SchemaAfterMigration(ctx, "public", "02_move_table")
+		require.NoError(t, err)
+		assert.Nil(t, publicSchema.GetTable("table1"))
+
+		billingSchema, err := m.State().SchemaAfterMigration(ctx, "billing", "02_move_table")
+		require.NoError(t, err)
+		assert.NotNil(t, billingSchema.GetTable("table1"))
+	})
+}
+
+func TestCopyPrivilegesOptionIsRespected(t *testing.T) {
+	t.Parallel()
+
+	opts := []roll.Option{roll.WithCopyPrivileges(true)}
+
+	testutils.WithMigratorInSchemaAndConnectionToContainerWithOptions(t, "public", opts, func(m *roll.Roll, db *sql.DB) {
+		ctx := context.Background()
+
+		// Set a default privilege on the schema for tables created by pgroll
+		_, err := db.ExecContext(ctx, "ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT SELECT ON TABLES TO pgroll")
+		require.NoError(t, err)
+
+		// Create a table and grant a privilege on it
+		err = m.Start(ctx, &migrations.Migration{
+			Name: "01_create_table",
+			Operations: migrations.Operations{
+				createTableOp("table1"),
+				&migrations.OpGrant{Table: "table1", Role: "pgroll", Privileges: []migrations.TablePrivilege{"INSERT"}},
+			},
+		}, backfill.NewConfig())
+		require.NoError(t, err)
+
+		versionSchema := roll.VersionedSchemaName("public", "01_create_table")
+		view := versionSchema + ".table1"
+
+		// The role has USAGE on the version schema as it has on the base schema
+		var hasUsage bool
+		err = db.QueryRowContext(ctx, "SELECT has_schema_privilege('pgroll', $1, 'USAGE')", versionSchema).Scan(&hasUsage)
+		require.NoError(t, err)
+		assert.True(t, hasUsage)
+
+		// The default privileges of the schema have been copied to the version schema
+		var hasDefaultPrivileges bool
+		err = db.QueryRowContext(ctx, `SELECT EXISTS (
+			SELECT 1 FROM pg_default_acl
+			WHERE defaclnamespace = $1::regnamespace
+		)`, versionSchema).Scan(&hasDefaultPrivileges)
+		require.NoError(t, err)
+		assert.True(t, hasDefaultPrivileges)
+
+		// The privileges on the table are granted on its view
+		for _, privilege := range []string{"SELECT", "INSERT"} {
+			var hasPrivilege bool
+			err = db.QueryRowContext(ctx, "SELECT has_table_privilege('pgroll', $1, $2)", view, privilege).Scan(&hasPrivilege)
+			require.NoError(t, err)
+			assert.True(t, hasPrivilege, privilege)
+		}
+	})
+}
+
+func TestMigrationEventsAreRecorded(t *testing.T) {
+	t.Parallel()
+
+	testutils.WithMigratorAndConnectionToContainer(t, func(m *roll.Roll, db *sql.DB) {
+		ctx := context.Background()
+
+		// Start and complete a migration to create a table
+		err := m.Start(ctx, &migrations.Migration{
+			Name:       "01_create_table",
+			Operations: migrations.Operations{createTableOp("table1")},
+		}, backfill.NewConfig())
+		require.NoError(t, err)
+		err = m.Complete(ctx)
+		require.NoError(t, err)
+
+		_, err = db.ExecContext(ctx, "INSERT INTO table1 (id, name) VALUES (1, 'alice')")
+		require.NoError(t, err)
+
+		// Start a migration that backfills the table and roll it back
+		err = m.Start(ctx, &migrations.Migration{
+			Name: "02_change_type",
+			Operations: migrations.Operations{
+				&migrations.OpAlterColumn{
+					Table:  "table1",
+					Column: "name",
+					Type:   ptr("text"),
+					Up:     "name",
+					Down:   "name",
+				},
+			},
+		}, backfill.NewConfig())
+		require.NoError(t, err)
+		err = m.RollbackWithReason(ctx, "wrong column")
+		require.NoError(t, err)
+
+		// Start a migration that fails and is rolled back automatically
+		err = m.Start(ctx, &migrations.Migration{
+			Name: "03_create_table",
+			Operations: migrations.Operations{
+				&migrations.OpCreateTable{
+					Name:    "table2",
+					Columns: []migrations.Column{{Name: "id", Type: "invalid"}},
+				},
+			},
+		}, backfill.NewConfig())
+		require.Error(t, err)
+
+		events, err := m.State().Events(ctx, "public", "")
+		require.NoError(t, err)
+
+		type event struct {
+			Migration string
+			Type      state.EventType
+			Phase     string
+			Table     string
+		}
+		got := make([]event, len(events))
+		for i, e := range events {
+			got[i] = event{Migration: e.Migration, Type: e.Type, Phase: e.Phase, Table: e.Table}
+		}
+		assert.Equal(t, []event{
+			{Migration: "01_create_table", Type: state.EventStart},
+			{Migration: "01_create_table", Type: state.EventStarted},
+			{Migration: "01_create_table", Type: state.EventComplete},
+			{Migration: "02_change_type", Type: state.EventStart},
+			{Migration: "02_change_type", Type: state.EventBackfillStart, Table: "table1"},
+			{Migration: "02_change_type", Type: state.EventBackfillEnd, Table: "table1"},
+			{Migration: "02_change_type", Type: state.EventStarted},
+			{Migration: "02_change_type", Type: state.EventRollback},
+			{Migration: "03_create_table", Type: state.EventStart},
+			{Migration: "03_create_table", Type: state.EventRollback},
+			{Migration: "03_create_table", Type: state.EventFailure, Phase: "start"},
+		}, got)
+
+		// The reason for each rollback and the error of the failure are recorded
+		assert.Equal(t, "wrong column", events[7].Reason)
+		assert.Contains(t, events[9].Reason, "invalid")
+		assert.Contains(t, events[10].Error, "invalid")
+	})
+}
+
 func addColumnOp(tableName string) *migrations.OpAddColumn {
 	return &migrations.OpAddColumn{
 		Table: tableName,
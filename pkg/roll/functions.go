@@ -0,0 +1,56 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package roll
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/lib/pq"
+
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+// ensureUserFunction creates a copy of the function `fn` in the version schema
+// for `version`. The copy runs with the version schema first on its search
+// path, so that the tables the function reads from resolve to the version
+// schema's views of those tables, with the column names of that version.
+func (m *Roll) ensureUserFunction(ctx context.Context, version string, fn *schema.Function) error {
+	versionSchema := VersionedSchemaName(m.schema, version)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "CREATE OR REPLACE FUNCTION %s.%s(%s) RETURNS %s LANGUAGE %s",
+		pq.QuoteIdentifier(versionSchema),
+		pq.QuoteIdentifier(fn.Name),
+		fn.Arguments,
+		fn.Returns,
+		pq.QuoteIdentifier(fn.Language))
+	if fn.Volatility != "" {
+		fmt.Fprintf(&sb, " %s", fn.Volatility)
+	}
+	fmt.Fprintf(&sb, " SET search_path = %s, %s AS %s",
+		pq.QuoteIdentifier(versionSchema),
+		pq.QuoteIdentifier(m.schema),
+		pq.QuoteLiteral(fn.Definition))
+
+	_, err := m.pgConn.ExecContext(ctx, sb.String())
+	return err
+}
+
+// versionedFunctions returns the non-deleted functions in `functions` that
+// are copied into version schemas, in name order. Trigger functions are not
+// copied; they are executed by triggers on the underlying tables.
+func versionedFunctions(functions map[string]*schema.Function) []*schema.Function {
+	var fns []*schema.Function
+	for _, name := range slices.Sorted(maps.Keys(functions)) {
+		fn := functions[name]
+		if fn.Deleted || fn.IsTriggerFunction() {
+			continue
+		}
+		fns = append(fns, fn)
+	}
+	return fns
+}
@@ -0,0 +1,184 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package roll
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// lockPollInterval is how often an advisory lock held by another session is
+// retried while waiting for it to be released
+const lockPollInterval = 250 * time.Millisecond
+
+// LockHeldError is returned when the advisory lock serializing pgroll
+// processes acting on a schema is held by another session for longer than the
+// configured wait timeout.
+type LockHeldError struct {
+	Schema          string
+	PID             int
+	ApplicationName string
+}
+
+func (e LockHeldError) Error() string {
+	if e.PID == 0 {
+		return fmt.Sprintf("another pgroll process is operating on schema %q", e.Schema)
+	}
+	return fmt.Sprintf("another pgroll process is operating on schema %q (application_name: %q, pid: %d)",
+		e.Schema, e.ApplicationName, e.PID)
+}
+
+// advisoryLock is a session level Postgres advisory lock held on a dedicated
+// connection, taken from a pool separate from the one migrations run on. It
+// is reentrant: nested calls to acquire only take the lock once and it is
+// released when the outermost caller releases it.
+type advisoryLock struct {
+	db          *sql.DB
+	key         string
+	schema      string
+	waitTimeout time.Duration
+
+	mu    sync.Mutex
+	conn  *sql.Conn
+	depth int
+}
+
+// Lock takes the advisory lock that serializes pgroll processes acting on the
+// schema, waiting for up to the lock wait timeout if it is held by another
+// session. The lock is keyed on the state schema and the target schema.
+//
+// Start, Complete, Rollback and ResumeBackfill take the lock themselves; Lock
+// can be used to hold it across several of those calls. Each successful call
+// must be paired with a call to Unlock.
+func (m *Roll) Lock(ctx context.Context) error {
+	return m.lock.acquire(ctx)
+}
+
+// Unlock releases the advisory lock taken by Lock
+func (m *Roll) Unlock(ctx context.Context) error {
+	return m.lock.release(ctx)
+}
+
+func (l *advisoryLock) acquire(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.depth > 0 {
+		l.depth++
+		return nil
+	}
+
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to acquire advisory lock: %w", err)
+	}
+
+	deadline := time.Now().Add(l.waitTimeout)
+	for {
+		var acquired bool
+		err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock(hashtextextended($1, 0))", l.key).Scan(&acquired)
+		if err != nil {
+			conn.Close()
+			return fmt.Errorf("unable to acquire advisory lock: %w", err)
+		}
+		if acquired {
+			break
+		}
+
+		if !time.Now().Before(deadline) {
+			holderErr := l.holder(ctx, conn)
+			conn.Close()
+			return holderErr
+		}
+
+		if err := sleepCtx(ctx, min(lockPollInterval, time.Until(deadline))); err != nil {
+			conn.Close()
+			return err
+		}
+	}
+
+	l.conn = conn
+	l.depth = 1
+	return nil
+}
+
+func (l *advisoryLock) release(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.depth == 0 {
+		return nil
+	}
+	l.depth--
+	if l.depth > 0 {
+		return nil
+	}
+
+	conn := l.conn
+	l.conn = nil
+
+	_, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock(hashtextextended($1, 0))", l.key)
+	return errors.Join(err, conn.Close())
+}
+
+// close releases the lock if it is still held, regardless of how many
+// callers have acquired it, and closes the lock's connection pool
+func (l *advisoryLock) close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.conn == nil {
+		return l.db.Close()
+	}
+
+	conn := l.conn
+	l.conn, l.depth = nil, 0
+
+	_, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock(hashtextextended($1, 0))", l.key)
+	return errors.Join(err, conn.Close(), l.db.Close())
+}
+
+// holder returns a LockHeldError describing the session holding the lock
+func (l *advisoryLock) holder(ctx context.Context, conn *sql.Conn) error {
+	// A bigint advisory lock key is split across the classid and objid columns
+	// of pg_locks
+	const query = `SELECT a.pid, COALESCE(a.application_name, '')
+		FROM pg_locks l JOIN pg_stat_activity a ON a.pid = l.pid,
+			(SELECT hashtextextended($1, 0) AS k) lock_key
+		WHERE l.locktype = 'advisory' AND l.granted AND l.objsubid = 1
+			AND l.classid = ((lock_key.k >> 32) & 4294967295)::oid
+			AND l.objid = (lock_key.k & 4294967295)::oid
+		LIMIT 1`
+
+	lockErr := LockHeldError{Schema: l.schema}
+	err := conn.QueryRowContext(ctx, query, l.key).Scan(&lockErr.PID, &lockErr.ApplicationName)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("unable to acquire advisory lock: %w", err)
+	}
+	return lockErr
+}
+
+// withLock runs `f` while holding the advisory lock
+func (m *Roll) withLock(ctx context.Context, f func() error) (err error) {
+	if err := m.lock.acquire(ctx); err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, m.lock.release(context.WithoutCancel(ctx)))
+	}()
+
+	return f()
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
@@ -0,0 +1,139 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package roll_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xataio/pgroll/internal/testutils"
+	"github.com/xataio/pgroll/pkg/backfill"
+	"github.com/xataio/pgroll/pkg/migrations"
+	"github.com/xataio/pgroll/pkg/roll"
+)
+
+func TestStartFailsWhileAnotherProcessHoldsTheLock(t *testing.T) {
+	t.Parallel()
+
+	testutils.WithMigratorInSchemaAndConnectionToContainer(t, "public", func(mig *roll.Roll, db *sql.DB) {
+		ctx := context.Background()
+
+		conn, pid := holdMigrationLock(t, db, "other-deploy")
+		defer conn.Close()
+
+		err := mig.Start(ctx, &migrations.Migration{
+			Name:       "01_create_table",
+			Operations: migrations.Operations{createTableOp("table1")},
+		}, backfill.NewConfig())
+
+		lockErr := roll.LockHeldError{}
+		require.ErrorAs(t, err, &lockErr)
+		assert.Equal(t, "other-deploy", lockErr.ApplicationName)
+		assert.Equal(t, pid, lockErr.PID)
+
+		// No migration was started
+		assert.False(t, tableExists(t, db, "public", "table1"))
+	})
+}
+
+func TestStartWaitsForTheLockToBeReleased(t *testing.T) {
+	t.Parallel()
+
+	opts := []roll.Option{roll.WithLockWaitTimeout(time.Minute)}
+
+	testutils.WithMigratorInSchemaAndConnectionToContainerWithOptions(t, "public", opts, func(mig *roll.Roll, db *sql.DB) {
+		ctx := context.Background()
+
+		conn, _ := holdMigrationLock(t, db, "other-deploy")
+		defer conn.Close()
+		time.AfterFunc(time.Second, func() {
+			conn.ExecContext(ctx, "SELECT pg_advisory_unlock(hashtextextended('pgroll.public', 0))")
+		})
+
+		err := mig.Start(ctx, &migrations.Migration{
+			Name:       "01_create_table",
+			Operations: migrations.Operations{createTableOp("table1")},
+		}, backfill.NewConfig())
+		require.NoError(t, err)
+		require.NoError(t, mig.Complete(ctx))
+
+		assert.True(t, tableExists(t, db, "public", "table1"))
+	})
+}
+
+func TestLockCanBeHeldAcrossOperations(t *testing.T) {
+	t.Parallel()
+
+	testutils.WithMigratorInSchemaAndConnectionToContainer(t, "public", func(mig *roll.Roll, db *sql.DB) {
+		ctx := context.Background()
+
+		require.NoError(t, mig.Lock(ctx))
+
+		err := mig.Start(ctx, &migrations.Migration{
+			Name:       "01_create_table",
+			Operations: migrations.Operations{createTableOp("table1")},
+		}, backfill.NewConfig())
+		require.NoError(t, err)
+		require.NoError(t, mig.Complete(ctx))
+
+		// The lock is still held after Start and Complete have returned
+		var acquired bool
+		err = db.QueryRowContext(ctx, "SELECT pg_try_advisory_lock(hashtextextended('pgroll.public', 0))").Scan(&acquired)
+		require.NoError(t, err)
+		assert.False(t, acquired)
+
+		require.NoError(t, mig.Unlock(ctx))
+	})
+}
+
+func TestSessionSettingsApplyWhileTheLockIsHeld(t *testing.T) {
+	t.Parallel()
+
+	opts := []roll.Option{roll.WithRole("pgroll"), roll.WithLockTimeoutMs(500)}
+
+	testutils.WithMigratorInSchemaAndConnectionToContainerWithOptions(t, "public", opts, func(mig *roll.Roll, db *sql.DB) {
+		ctx := context.Background()
+
+		require.NoError(t, mig.Lock(ctx))
+		defer mig.Unlock(ctx)
+
+		// Migrations run on connections other than the one holding the lock,
+		// which have the same session settings
+		rows, err := mig.PgConn().QueryContext(ctx, "SELECT current_user, current_setting('lock_timeout'), current_setting('pgroll.no_inferred_migrations')")
+		require.NoError(t, err)
+		defer rows.Close()
+
+		var role, lockTimeout, noInferredMigrations string
+		require.True(t, rows.Next())
+		require.NoError(t, rows.Scan(&role, &lockTimeout, &noInferredMigrations))
+
+		assert.Equal(t, "pgroll", role)
+		assert.Equal(t, "500ms", lockTimeout)
+		assert.Equal(t, "TRUE", noInferredMigrations)
+	})
+}
+
+// holdMigrationLock takes the advisory lock for the `public` schema on a new
+// session with the given application_name, returning the session and its PID
+func holdMigrationLock(t *testing.T, db *sql.DB, applicationName string) (*sql.Conn, int) {
+	t.Helper()
+	ctx := context.Background()
+
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+
+	_, err = conn.ExecContext(ctx, "SET application_name = '"+applicationName+"'")
+	require.NoError(t, err)
+	_, err = conn.ExecContext(ctx, "SELECT pg_advisory_lock(hashtextextended('pgroll.public', 0))")
+	require.NoError(t, err)
+
+	var pid int
+	require.NoError(t, conn.QueryRowContext(ctx, "SELECT pg_backend_pid()").Scan(&pid))
+
+	return conn, pid
+}
@@ -2,6 +2,8 @@
 
 package roll
 
+import "time"
+
 type options struct {
 	// lock timeout in milliseconds for pgroll DDL operations
 	lockTimeoutMs int
@@ -21,6 +23,21 @@ type options struct {
 	migrationHooks MigrationHooks
 
 	verbose bool
+
+	// whether to leave the migration in progress if a backfill fails
+	resumableBackfills bool
+
+	// whether to copy the schema's functions into version schemas
+	versionedFunctions bool
+
+	// whether to copy the schema's privileges onto version schemas
+	copyPrivileges bool
+
+	// how long to wait for the advisory lock held by another pgroll process
+	lockWaitTimeout time.Duration
+
+	// how to wait for sessions using the previous version schema on complete
+	waitForClients *WaitForClients
 }
 
 // MigrationHooks defines hooks that can be set to be called at various points
@@ -94,3 +111,52 @@ func WithLogging(enabled bool) Option {
 		}
 	}
 }
+
+// WithResumableBackfills controls whether a migration is rolled back when a
+// backfill fails. If set to true, the migration is left in progress so that
+// the backfill can be resumed from its last checkpoint with `ResumeBackfill`.
+func WithResumableBackfills(enabled bool) Option {
+	return func(o *options) {
+		o.resumableBackfills = enabled
+	}
+}
+
+// WithVersionedFunctions controls whether the functions in the schema are
+// copied into each version schema. The copies read from the views of the
+// version schema, so they see the column names of that version.
+func WithVersionedFunctions(enabled bool) Option {
+	return func(o *options) {
+		o.versionedFunctions = enabled
+	}
+}
+
+// WithCopyPrivileges controls whether the privileges of the schema are copied
+// onto each version schema. Roles with USAGE on the schema are granted USAGE on
+// the version schema, the schema's default privileges are copied to the
+// version schema and the privileges on each table are granted on its view.
+func WithCopyPrivileges(enabled bool) Option {
+	return func(o *options) {
+		o.copyPrivileges = enabled
+	}
+}
+
+// WithLockWaitTimeout sets how long to wait for another pgroll process acting
+// on the same schema to release its advisory lock before giving up. By default
+// the lock is not waited for.
+func WithLockWaitTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.lockWaitTimeout = timeout
+	}
+}
+
+// WithWaitForClients makes Complete wait until no session has used the
+// previous version schema for the configured grace period before dropping it.
+// If sessions are still using the schema when the timeout expires, Complete
+// aborts with a ClientsConnectedError listing them; if the grace period has not
+// passed since the last of them stopped, it aborts with a
+// ClientsRecentlyConnectedError.
+func WithWaitForClients(cfg WaitForClients) Option {
+	return func(o *options) {
+		o.waitForClients = &cfg
+	}
+}
@@ -0,0 +1,50 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package roll
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+func TestPhysicalSchema(t *testing.T) {
+	t.Parallel()
+
+	s := &schema.Schema{
+		Name: "public",
+		Tables: map[string]*schema.Table{
+			"users": {
+				Name: "_pgroll_new_users",
+				Columns: map[string]*schema.Column{
+					"name": {Name: "_pgroll_new_name", Type: "text"},
+					"age":  {Name: "age", Type: "integer", Deleted: true},
+				},
+				Indexes: map[string]*schema.Index{
+					"users_name_idx": {Name: "users_name_idx", Columns: []string{"_pgroll_new_name"}},
+				},
+			},
+			"orders": {Name: "orders", Deleted: true},
+		},
+	}
+
+	ps, err := physicalSchema(s)
+	require.NoError(t, err)
+
+	// Tables and columns are keyed by their physical names, and those deleted
+	// from the virtual schema are kept
+	users := ps.GetTable("_pgroll_new_users")
+	require.NotNil(t, users)
+	assert.NotNil(t, users.GetColumn("_pgroll_new_name"))
+	assert.NotNil(t, users.GetColumn("age"))
+	assert.NotNil(t, ps.GetTable("orders"))
+
+	// Changes to the copy do not affect the original schema
+	users.Indexes["users_name_idx"].Columns[0] = "name"
+	users.GetColumn("age").Type = "bigint"
+	assert.Equal(t, []string{"_pgroll_new_name"}, s.Tables["users"].Indexes["users_name_idx"].Columns)
+	assert.Equal(t, "integer", s.Tables["users"].Columns["age"].Type)
+}
@@ -0,0 +1,348 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package roll
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"github.com/xataio/pgroll/pkg/backfill"
+	"github.com/xataio/pgroll/pkg/db"
+	"github.com/xataio/pgroll/pkg/migrations"
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+type PlanPhase string
+
+const (
+	PlanPhaseStart    PlanPhase = "start"
+	PlanPhaseComplete PlanPhase = "complete"
+	PlanPhaseRollback PlanPhase = "rollback"
+)
+
+// IDs of plan steps that are performed by `Roll` itself rather than by a
+// `DBAction`.
+const (
+	PlanStepCreateBackfillTriggers = "create_backfill_triggers"
+	PlanStepCreateVersionSchema    = "create_version_schema"
+	PlanStepCreateTargetVersions   = "create_target_version_schemas"
+	PlanStepDropPreviousVersion    = "drop_previous_version_schema"
+	PlanStepDropVersionSchema      = "drop_version_schema"
+)
+
+// Plan describes the SQL statements that a migration would execute in each of
+// its phases.
+type Plan struct {
+	// The name of the migration
+	Migration string `json:"migration"`
+
+	// The statements run by each phase of the migration, in execution order
+	Phases []PlanPhaseSteps `json:"phases"`
+
+	// The tables that would be backfilled after the start phase
+	Backfills []string `json:"backfills,omitempty"`
+}
+
+// PlanPhaseSteps is the ordered list of steps run by a single migration phase.
+type PlanPhaseSteps struct {
+	Phase PlanPhase  `json:"phase"`
+	Steps []PlanStep `json:"steps"`
+}
+
+// PlanStep is a single step in a migration phase, usually corresponding to a
+// `DBAction` executed by the `Coordinator`.
+type PlanStep struct {
+	// The ID of the action that issues the statements
+	ID string `json:"id"`
+
+	// The SQL statements executed by the action
+	Statements []string `json:"statements"`
+
+	// The locks taken by the action while it runs
+	Locks []migrations.LockImpact `json:"locks,omitempty"`
+}
+
+// Plan returns the SQL statements that `migration` would execute when it is
+// started, completed or rolled back. No changes are made to the database; all
+// statements are recorded against a fake connection instead.
+//
+// Statements that depend on the state of the database after the start phase
+// (for example the complete phase of an isolated raw SQL operation) are
+// planned against the virtual schema, so may differ from the statements run by
+// `Start`, `Complete` and `Rollback`.
+func (m *Roll) Plan(ctx context.Context, migration *migrations.Migration) (*Plan, error) {
+	if err := m.Validate(ctx, migration); err != nil {
+		return nil, err
+	}
+
+	rec := &db.RecordingDB{}
+
+	// Use a copy of the Roll instance whose connection records statements
+	// instead of executing them.
+	planner := *m
+	planner.pgConn = rec
+	planner.logger = migrations.NewNoopLogger()
+
+	plan := &Plan{Migration: migration.Name}
+
+	start, backfills, err := planner.planStart(ctx, rec, migration)
+	if err != nil {
+		return nil, err
+	}
+	plan.Backfills = backfills
+
+	complete, err := planner.planComplete(ctx, rec, migration)
+	if err != nil {
+		return nil, err
+	}
+
+	rollback, err := planner.planRollback(ctx, rec, migration)
+	if err != nil {
+		return nil, err
+	}
+
+	plan.Phases = []PlanPhaseSteps{start, complete, rollback}
+
+	return plan, nil
+}
+
+func (m *Roll) planStart(ctx context.Context, rec *db.RecordingDB, migration *migrations.Migration) (PlanPhaseSteps, []string, error) {
+	phase := PlanPhaseSteps{Phase: PlanPhaseStart}
+
+	versionSchemaName := VersionedSchemaName(m.schema, migration.VersionSchemaName())
+
+	s, err := m.state.ReadSchema(ctx, m.schema)
+	if err != nil {
+		return phase, nil, fmt.Errorf("unable to read schema: %w", err)
+	}
+
+	job := backfill.NewJob(m.schema, versionSchemaName)
+	for _, op := range migration.Operations {
+		startOp, err := op.Start(ctx, m.logger, rec, s)
+		if err != nil {
+			return phase, nil, fmt.Errorf("unable to collect actions for start %q migration: %w", migration.Name, err)
+		}
+		if startOp == nil {
+			continue
+		}
+
+		steps, err := recordActions(ctx, rec, startOp.Actions)
+		if err != nil {
+			return phase, nil, err
+		}
+		phase.Steps = append(phase.Steps, steps...)
+
+		if startOp.BackfillTask != nil {
+			job.AddTask(startOp.BackfillTask)
+		}
+	}
+
+	// Record the backfill triggers that would be created before backfilling
+	if err := backfill.New(rec, backfill.NewConfig()).CreateTriggers(ctx, job); err != nil {
+		return phase, nil, fmt.Errorf("unable to plan backfill triggers: %w", err)
+	}
+	phase.Steps = appendStep(phase.Steps, rec, PlanStepCreateBackfillTriggers, triggerLocks(job)...)
+
+	// Record the version schema and the views that would be created in it
+	if !m.disableVersionSchemas {
+		if err := m.ensureViews(ctx, s, migration); err != nil {
+			return phase, nil, err
+		}
+		phase.Steps = appendStep(phase.Steps, rec, PlanStepCreateVersionSchema)
+
+		if err := m.planTargetViews(ctx, s, migration); err != nil {
+			return phase, nil, err
+		}
+		phase.Steps = appendStep(phase.Steps, rec, PlanStepCreateTargetVersions)
+	}
+
+	backfills := make([]string, 0, len(job.Tables))
+	for _, table := range job.Tables {
+		backfills = append(backfills, table.Name)
+	}
+
+	return phase, backfills, nil
+}
+
+// planTargetViews records the views that would be created in the version
+// schemas of the other schemas changed by `migration`. Tables moved by the
+// migration are only in those schemas once the start phase has run, so they
+// are added to the schemas read from the state.
+func (m *Roll) planTargetViews(ctx context.Context, s *schema.Schema, migration *migrations.Migration) error {
+	for _, target := range migration.TargetSchemas(m.schema) {
+		targetSchema, err := m.state.ReadSchema(ctx, target)
+		if err != nil {
+			return fmt.Errorf("unable to read schema %q: %w", target, err)
+		}
+
+		for _, op := range migration.Operations {
+			move, ok := op.(*migrations.OpMoveTable)
+			if !ok || move.Schema != target || s.Tables[move.Table] == nil {
+				continue
+			}
+			table := *s.Tables[move.Table]
+			table.Deleted = false
+			targetSchema.AddTable(move.Table, &table)
+		}
+
+		if err := m.forSchema(target).ensureViews(ctx, targetSchema, migration); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Roll) planComplete(ctx context.Context, rec *db.RecordingDB, migration *migrations.Migration) (PlanPhaseSteps, error) {
+	phase := PlanPhaseSteps{Phase: PlanPhaseComplete}
+
+	// Completing the migration drops the current latest version schema, which
+	// becomes the previous version once the migration has started.
+	prevVersion, err := m.state.LatestVersion(ctx, m.schema)
+	if err != nil {
+		return phase, fmt.Errorf("unable to get name of latest version: %w", err)
+	}
+	if prevVersion != nil {
+		versionSchema := VersionedSchemaName(m.schema, *prevVersion)
+		_, err := rec.ExecContext(ctx, fmt.Sprintf("DROP SCHEMA IF EXISTS %s CASCADE", pq.QuoteIdentifier(versionSchema)))
+		if err != nil {
+			return phase, err
+		}
+		phase.Steps = appendStep(phase.Steps, rec, PlanStepDropPreviousVersion)
+	}
+
+	// Approximate the schema that `Complete` would read from the database after
+	// the start phase has run.
+	s, err := m.state.ReadSchema(ctx, m.schema)
+	if err != nil {
+		return phase, fmt.Errorf("unable to read schema: %w", err)
+	}
+	if err := migration.UpdateVirtualSchema(ctx, s); err != nil {
+		return phase, fmt.Errorf("unable to replay changes to in-memory schema: %w", err)
+	}
+	s, err = physicalSchema(s)
+	if err != nil {
+		return phase, fmt.Errorf("unable to copy in-memory schema: %w", err)
+	}
+
+	var actions []migrations.DBAction
+	for _, op := range migration.Operations {
+		opActions, err := op.Complete(m.logger, rec, s)
+		if err != nil {
+			return phase, fmt.Errorf("unable to collect actions for complete operation: %w", err)
+		}
+		actions = append(actions, opActions...)
+	}
+
+	steps, err := recordActions(ctx, rec, actions)
+	if err != nil {
+		return phase, err
+	}
+	phase.Steps = append(phase.Steps, steps...)
+
+	return phase, nil
+}
+
+func (m *Roll) planRollback(ctx context.Context, rec *db.RecordingDB, migration *migrations.Migration) (PlanPhaseSteps, error) {
+	phase := PlanPhaseSteps{Phase: PlanPhaseRollback}
+
+	versionSchema := VersionedSchemaName(m.schema, migration.VersionSchemaName())
+	_, err := rec.ExecContext(ctx, fmt.Sprintf("DROP SCHEMA IF EXISTS %s CASCADE", pq.QuoteIdentifier(versionSchema)))
+	if err != nil {
+		return phase, err
+	}
+	phase.Steps = appendStep(phase.Steps, rec, PlanStepDropVersionSchema)
+
+	s, err := m.state.ReadSchema(ctx, m.schema)
+	if err != nil {
+		return phase, fmt.Errorf("unable to read schema: %w", err)
+	}
+	if err := migration.UpdateVirtualSchema(ctx, s); err != nil {
+		return phase, fmt.Errorf("unable to replay changes to in-memory schema: %w", err)
+	}
+
+	for i := len(migration.Operations) - 1; i >= 0; i-- {
+		actions, err := migration.Operations[i].Rollback(m.logger, rec, s)
+		if err != nil {
+			return phase, fmt.Errorf("unable to collect actions for rollback operation: %w", err)
+		}
+
+		steps, err := recordActions(ctx, rec, actions)
+		if err != nil {
+			return phase, err
+		}
+		phase.Steps = append(phase.Steps, steps...)
+	}
+
+	return phase, nil
+}
+
+// recordActions executes `actions` in coordinator order against the recording
+// connection and returns one step per action.
+func recordActions(ctx context.Context, rec *db.RecordingDB, actions []migrations.DBAction) ([]PlanStep, error) {
+	coordinator := migrations.NewCoordinator(actions)
+
+	var steps []PlanStep
+	for _, action := range coordinator.Actions() {
+		if err := action.Execute(ctx); err != nil {
+			return nil, fmt.Errorf("failed to plan action %s: %w", action.ID(), err)
+		}
+		steps = appendStep(steps, rec, action.ID(), action.Locks()...)
+	}
+	return steps, nil
+}
+
+// appendStep appends a step with the statements recorded so far to `steps`
+// and resets the recording. No step is appended if nothing was recorded.
+func appendStep(steps []PlanStep, rec *db.RecordingDB, id string, locks ...migrations.LockImpact) []PlanStep {
+	stmts := rec.Statements()
+	rec.Reset()
+	if len(stmts) == 0 {
+		return steps
+	}
+	return append(steps, PlanStep{ID: id, Statements: stmts, Locks: locks})
+}
+
+// triggerLocks returns the locks taken when creating the backfill triggers for
+// `job`.
+func triggerLocks(job *backfill.Job) []migrations.LockImpact {
+	locks := make([]migrations.LockImpact, 0, len(job.Tables))
+	for _, table := range job.Tables {
+		locks = append(locks, migrations.LockImpact{
+			Level:    migrations.LockLevelShareRowExclusive,
+			Relation: table.Name,
+		})
+	}
+	return locks
+}
+
+// physicalSchema returns a deep copy of the virtual schema `s` keyed by
+// physical table and column names, as it would be read from the database.
+// Objects deleted from the virtual schema still exist in the database until
+// the migration is completed, so they are not marked as deleted in the copy.
+func physicalSchema(s *schema.Schema) (*schema.Schema, error) {
+	// Deleted flags are not serialized, so a JSON round trip clears them
+	b, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	cp := schema.New()
+	if err := json.Unmarshal(b, cp); err != nil {
+		return nil, err
+	}
+
+	ps := *cp
+	ps.Tables = make(map[string]*schema.Table, len(cp.Tables))
+	for _, table := range cp.Tables {
+		columns := table.Columns
+		table.Columns = make(map[string]*schema.Column, len(columns))
+		for _, column := range columns {
+			table.Columns[column.Name] = column
+		}
+		ps.AddTable(table.Name, table)
+	}
+
+	return &ps, nil
+}
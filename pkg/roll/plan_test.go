@@ -0,0 +1,193 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package roll_test
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/xataio/pgroll/internal/testutils"
+	"github.com/xataio/pgroll/pkg/backfill"
+	"github.com/xataio/pgroll/pkg/migrations"
+	"github.com/xataio/pgroll/pkg/roll"
+)
+
+func TestPlan(t *testing.T) {
+	t.Parallel()
+
+	t.Run("plan does not modify the database", func(t *testing.T) {
+		testutils.WithMigratorAndConnectionToContainer(t, func(mig *roll.Roll, db *sql.DB) {
+			ctx := context.Background()
+
+			plan, err := mig.Plan(ctx, &migrations.Migration{
+				Name:       "01_create_table",
+				Operations: migrations.Operations{createTableOp("table1")},
+			})
+			require.NoError(t, err)
+
+			// The table and version schema were not created
+			require.False(t, schemaExists(t, db, roll.VersionedSchemaName(cSchema, "01_create_table")))
+			require.False(t, tableExists(t, db, cSchema, "table1"))
+
+			// No migration was recorded in pgroll's state
+			status, err := mig.Status(ctx, cSchema)
+			require.NoError(t, err)
+			require.Equal(t, "01_create_table", plan.Migration)
+			require.Empty(t, status.Version)
+		})
+	})
+
+	t.Run("plan lists statements for each phase in order", func(t *testing.T) {
+		testutils.WithMigratorAndConnectionToContainer(t, func(mig *roll.Roll, db *sql.DB) {
+			ctx := context.Background()
+
+			err := mig.Start(ctx, &migrations.Migration{
+				Name:       "01_create_table",
+				Operations: migrations.Operations{createTableOp("table1")},
+			}, backfill.NewConfig())
+			require.NoError(t, err)
+			err = mig.Complete(ctx)
+			require.NoError(t, err)
+
+			plan, err := mig.Plan(ctx, &migrations.Migration{
+				Name:       "02_add_column",
+				Operations: migrations.Operations{addColumnOp("table1")},
+			})
+			require.NoError(t, err)
+
+			require.Len(t, plan.Phases, 3)
+			require.Equal(t, roll.PlanPhaseStart, plan.Phases[0].Phase)
+			require.Equal(t, roll.PlanPhaseComplete, plan.Phases[1].Phase)
+			require.Equal(t, roll.PlanPhaseRollback, plan.Phases[2].Phase)
+
+			ids := func(phase roll.PlanPhaseSteps) []string {
+				var ids []string
+				for _, step := range phase.Steps {
+					require.NotEmpty(t, step.Statements)
+					ids = append(ids, step.ID)
+				}
+				return ids
+			}
+
+			require.Equal(t, []string{
+				"add_column_table1__pgroll_new_age",
+				roll.PlanStepCreateVersionSchema,
+			}, ids(plan.Phases[0]))
+			require.Contains(t, ids(plan.Phases[1]), roll.PlanStepDropPreviousVersion)
+			require.Contains(t, ids(plan.Phases[2]), roll.PlanStepDropVersionSchema)
+
+			// The migration has not been started
+			status, err := mig.Status(ctx, cSchema)
+			require.NoError(t, err)
+			require.Equal(t, "01_create_table", status.Version)
+		})
+	})
+
+	t.Run("plan lists the statements that copy privileges to the version schema", func(t *testing.T) {
+		opts := []roll.Option{roll.WithCopyPrivileges(true)}
+
+		testutils.WithMigratorInSchemaAndConnectionToContainerWithOptions(t, "public", opts, func(mig *roll.Roll, db *sql.DB) {
+			ctx := context.Background()
+
+			_, err := db.ExecContext(ctx, "ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT SELECT ON TABLES TO pgroll")
+			require.NoError(t, err)
+
+			plan, err := mig.Plan(ctx, &migrations.Migration{
+				Name:       "01_create_table",
+				Operations: migrations.Operations{createTableOp("table1")},
+			})
+			require.NoError(t, err)
+
+			var statements []string
+			for _, step := range plan.Phases[0].Steps {
+				if step.ID == roll.PlanStepCreateVersionSchema {
+					statements = step.Statements
+				}
+			}
+			require.Contains(t, strings.Join(statements, "\n"),
+				`IN SCHEMA "public_01_create_table" GRANT SELECT ON TABLES TO "pgroll"`)
+
+			// The version schema was not created
+			require.False(t, schemaExists(t, db, roll.VersionedSchemaName(cSchema, "01_create_table")))
+		})
+	})
+
+	t.Run("plan lists the statements that convert a serial column to an identity column", func(t *testing.T) {
+		testutils.WithMigratorAndConnectionToContainer(t, func(mig *roll.Roll, db *sql.DB) {
+			ctx := context.Background()
+
+			err := mig.Start(ctx, &migrations.Migration{
+				Name: "01_create_table",
+				Operations: migrations.Operations{
+					&migrations.OpCreateTable{
+						Name:    "table1",
+						Columns: []migrations.Column{{Name: "id", Type: "serial", Pk: true}},
+					},
+				},
+			}, backfill.NewConfig())
+			require.NoError(t, err)
+			err = mig.Complete(ctx)
+			require.NoError(t, err)
+
+			plan, err := mig.Plan(ctx, &migrations.Migration{
+				Name: "02_convert_to_identity",
+				Operations: migrations.Operations{
+					&migrations.OpConvertToIdentity{Table: "table1", Column: "id"},
+				},
+			})
+			require.NoError(t, err)
+
+			var statements []string
+			for _, step := range plan.Phases[1].Steps {
+				if step.ID == "convert_to_identity_table1_id" {
+					statements = step.Statements
+				}
+			}
+			require.Contains(t, statements, `ALTER TABLE "table1" ALTER COLUMN "id" ADD GENERATED BY DEFAULT AS IDENTITY`)
+
+			// The column has not been converted
+			status, err := mig.Status(ctx, cSchema)
+			require.NoError(t, err)
+			require.Equal(t, "01_create_table", status.Version)
+		})
+	})
+
+	t.Run("plan lists the views created in the schema a table is moved to", func(t *testing.T) {
+		testutils.WithMigratorInSchemaAndConnectionToContainer(t, "public", func(mig *roll.Roll, db *sql.DB) {
+			ctx := context.Background()
+
+			err := mig.Start(ctx, &migrations.Migration{
+				Name:       "01_create_table",
+				Operations: migrations.Operations{createTableOp("table1")},
+			}, backfill.NewConfig())
+			require.NoError(t, err)
+			err = mig.Complete(ctx)
+			require.NoError(t, err)
+
+			plan, err := mig.Plan(ctx, &migrations.Migration{
+				Name: "02_move_table",
+				Operations: migrations.Operations{
+					&migrations.OpMoveTable{Table: "table1", Schema: "archive"},
+				},
+			})
+			require.NoError(t, err)
+
+			var statements []string
+			for _, step := range plan.Phases[0].Steps {
+				if step.ID == roll.PlanStepCreateTargetVersions {
+					statements = step.Statements
+				}
+			}
+			require.Contains(t, statements, `CREATE SCHEMA IF NOT EXISTS "archive_02_move_table"`)
+			require.Contains(t, strings.Join(statements, "\n"), `"archive_02_move_table"."table1"`)
+
+			// The table has not been moved
+			require.False(t, schemaExists(t, db, "archive"))
+			require.True(t, tableExists(t, db, "public", "table1"))
+		})
+	})
+}
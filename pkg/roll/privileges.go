@@ -0,0 +1,157 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package roll
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+// defaultPrivilegeObjectTypes maps the object types of default privileges
+// that can be set on a schema to their keyword in ALTER DEFAULT PRIVILEGES
+var defaultPrivilegeObjectTypes = map[string]string{
+	"r": "TABLES",
+	"S": "SEQUENCES",
+	"f": "FUNCTIONS",
+	"T": "TYPES",
+}
+
+// defaultPrivilege is a default privilege set on a schema for objects created
+// by a role
+type defaultPrivilege struct {
+	role       string
+	objectType string
+	privilege  schema.Privilege
+}
+
+// copySchemaPrivileges grants the privileges held on the schema to the same
+// roles on `versionSchema` and copies the schema's default privileges to
+// `versionSchema`. It must be called before the views of the version schema
+// are created so that the default privileges apply to them.
+//
+// The privileges are read through the state connection, so that they are
+// still read when the statements are only being recorded by `Plan`.
+func (m *Roll) copySchemaPrivileges(ctx context.Context, versionSchema string) error {
+	privileges, err := m.schemaPrivileges(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to read privileges of schema %q: %w", m.schema, err)
+	}
+	defaults, err := m.schemaDefaultPrivileges(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to read default privileges of schema %q: %w", m.schema, err)
+	}
+
+	var stmts []string
+	for _, p := range privileges {
+		stmts = append(stmts, grantSQL(p, "SCHEMA "+pq.QuoteIdentifier(versionSchema)))
+	}
+	for _, d := range defaults {
+		stmts = append(stmts, d.sql(versionSchema))
+	}
+	if len(stmts) == 0 {
+		return nil
+	}
+
+	_, err = m.pgConn.ExecContext(ctx, strings.Join(stmts, "; "))
+	return err
+}
+
+// schemaPrivileges returns the privileges held on the schema by roles other
+// than its owner
+func (m *Roll) schemaPrivileges(ctx context.Context) ([]schema.Privilege, error) {
+	rows, err := m.state.PgConn().QueryContext(ctx, `
+		SELECT
+			CASE WHEN acl.grantee = 0 THEN 'PUBLIC' ELSE pg_get_userbyid(acl.grantee) END,
+			acl.privilege_type,
+			acl.is_grantable
+		FROM pg_namespace AS ns, aclexplode(ns.nspacl) AS acl
+		WHERE ns.nspname = $1
+			AND acl.grantee <> ns.nspowner
+		ORDER BY 1, 2`, m.schema)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var privileges []schema.Privilege
+	for rows.Next() {
+		var p schema.Privilege
+		if err := rows.Scan(&p.Grantee, &p.Privilege, &p.Grantable); err != nil {
+			return nil, err
+		}
+		privileges = append(privileges, p)
+	}
+
+	return privileges, rows.Err()
+}
+
+// schemaDefaultPrivileges returns the default privileges set on the schema
+func (m *Roll) schemaDefaultPrivileges(ctx context.Context) ([]defaultPrivilege, error) {
+	rows, err := m.state.PgConn().QueryContext(ctx, `
+		SELECT
+			pg_get_userbyid(d.defaclrole),
+			d.defaclobjtype,
+			CASE WHEN acl.grantee = 0 THEN 'PUBLIC' ELSE pg_get_userbyid(acl.grantee) END,
+			acl.privilege_type,
+			acl.is_grantable
+		FROM pg_default_acl AS d
+		INNER JOIN pg_namespace AS ns ON ns.oid = d.defaclnamespace,
+		aclexplode(d.defaclacl) AS acl
+		WHERE ns.nspname = $1
+		ORDER BY 1, 2, 3, 4`, m.schema)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var defaults []defaultPrivilege
+	for rows.Next() {
+		var d defaultPrivilege
+		if err := rows.Scan(&d.role, &d.objectType, &d.privilege.Grantee, &d.privilege.Privilege, &d.privilege.Grantable); err != nil {
+			return nil, err
+		}
+		if _, ok := defaultPrivilegeObjectTypes[d.objectType]; !ok {
+			continue
+		}
+		defaults = append(defaults, d)
+	}
+
+	return defaults, rows.Err()
+}
+
+// sql returns the statement that sets the default privilege on `versionSchema`
+func (d defaultPrivilege) sql(versionSchema string) string {
+	return fmt.Sprintf("ALTER DEFAULT PRIVILEGES FOR ROLE %s IN SCHEMA %s %s",
+		pq.QuoteIdentifier(d.role),
+		pq.QuoteIdentifier(versionSchema),
+		grantSQL(d.privilege, defaultPrivilegeObjectTypes[d.objectType]))
+}
+
+// viewPrivilegesSQL returns the statements that grant the privileges held on
+// `table` on the view `view` of the table
+func viewPrivilegesSQL(table *schema.Table, view string) string {
+	var sb strings.Builder
+	for _, p := range table.Privileges {
+		fmt.Fprintf(&sb, "%s; ", grantSQL(p, view))
+	}
+	return sb.String()
+}
+
+// grantSQL returns the statement that grants the privilege `p` on `object`
+func grantSQL(p schema.Privilege, object string) string {
+	grantee := "PUBLIC"
+	if p.Grantee != "PUBLIC" {
+		grantee = pq.QuoteIdentifier(p.Grantee)
+	}
+
+	stmt := fmt.Sprintf("GRANT %s ON %s TO %s", p.Privilege, object, grantee)
+	if p.Grantable {
+		stmt += " WITH GRANT OPTION"
+	}
+	return stmt
+}
@@ -0,0 +1,52 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package roll
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+func TestGrantSQL(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t,
+		`GRANT USAGE ON SCHEMA "public_01_create_table" TO "app"`,
+		grantSQL(schema.Privilege{Grantee: "app", Privilege: "USAGE"}, `SCHEMA "public_01_create_table"`))
+	assert.Equal(t,
+		`GRANT SELECT ON TABLES TO PUBLIC WITH GRANT OPTION`,
+		grantSQL(schema.Privilege{Grantee: "PUBLIC", Privilege: "SELECT", Grantable: true}, "TABLES"))
+}
+
+func TestDefaultPrivilegeSQL(t *testing.T) {
+	t.Parallel()
+
+	d := defaultPrivilege{
+		role:       "owner",
+		objectType: "S",
+		privilege:  schema.Privilege{Grantee: "app", Privilege: "USAGE"},
+	}
+
+	assert.Equal(t,
+		`ALTER DEFAULT PRIVILEGES FOR ROLE "owner" IN SCHEMA "public_01_create_table" GRANT USAGE ON SEQUENCES TO "app"`,
+		d.sql("public_01_create_table"))
+}
+
+func TestViewPrivilegesSQL(t *testing.T) {
+	t.Parallel()
+
+	table := &schema.Table{
+		Privileges: []schema.Privilege{
+			{Grantee: "app", Privilege: "SELECT"},
+			{Grantee: "app", Privilege: "INSERT"},
+		},
+	}
+
+	assert.Equal(t,
+		`GRANT SELECT ON "v1"."users" TO "app"; GRANT INSERT ON "v1"."users" TO "app"; `,
+		viewPrivilegesSQL(table, `"v1"."users"`))
+	assert.Equal(t, "", viewPrivilegesSQL(&schema.Table{}, `"v1"."users"`))
+}
@@ -0,0 +1,74 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package roll
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/xataio/pgroll/pkg/migrations"
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+var (
+	ErrMigrationNotComplete  = fmt.Errorf("migration is not complete; roll it back instead")
+	ErrBaselineNotRevertable = fmt.Errorf("baseline migrations can not be reverted")
+	ErrNotLatestMigration    = fmt.Errorf("only the latest migration can be reverted")
+)
+
+// RevertMigration returns a new migration named `revertName` that undoes the
+// completed migration `name`. The migration is not started; it can be run with
+// `Start` and `Complete` like any other migration.
+//
+// Only the latest migration can be reverted: the inverse of an older
+// migration is computed against the schema before that migration and so may
+// not apply to, or may lose data from, the schema changed by later migrations.
+//
+// The definitions of the columns, indexes and constraints that `name` dropped
+// or altered are restored from the schema recorded when its parent migration
+// completed. Migrations that can not be undone automatically, for example
+// because they dropped a table, are refused with a
+// migrations.OperationNotInvertibleError.
+func (m *Roll) RevertMigration(ctx context.Context, name, revertName string) (*migrations.Migration, error) {
+	record, err := m.state.GetMigration(ctx, m.schema, name)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get migration %q: %w", name, err)
+	}
+	if !record.Done {
+		return nil, fmt.Errorf("%w: %q", ErrMigrationNotComplete, name)
+	}
+	if record.Type == "baseline" {
+		return nil, fmt.Errorf("%w: %q", ErrBaselineNotRevertable, name)
+	}
+
+	latest, err := m.state.LatestMigration(ctx, m.schema)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get latest migration: %w", err)
+	}
+	if latest == nil || *latest != name {
+		return nil, fmt.Errorf("%w: %q is not the latest migration", ErrNotLatestMigration, name)
+	}
+
+	// The schema before the migration is the schema after its parent
+	var before *schema.Schema
+	if record.Parent != nil {
+		parent, err := m.state.GetMigration(ctx, m.schema, *record.Parent)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get migration %q: %w", *record.Parent, err)
+		}
+		before = &parent.ResultingSchema
+	}
+
+	ops, err := migrations.Invert(&record.Migration, before)
+	if err != nil {
+		return nil, fmt.Errorf("unable to revert migration %q: %w", name, err)
+	}
+	if len(ops) == 0 {
+		return nil, fmt.Errorf("unable to revert migration %q: %w", name, migrations.EmptyMigrationError{})
+	}
+
+	return &migrations.Migration{
+		Name:       revertName,
+		Operations: ops,
+	}, nil
+}
@@ -0,0 +1,86 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package roll_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xataio/pgroll/internal/testutils"
+	"github.com/xataio/pgroll/pkg/backfill"
+	"github.com/xataio/pgroll/pkg/migrations"
+	"github.com/xataio/pgroll/pkg/roll"
+)
+
+func TestRevertMigration(t *testing.T) {
+	t.Parallel()
+
+	testutils.WithMigratorInSchemaAndConnectionToContainer(t, "public", func(mig *roll.Roll, db *sql.DB) {
+		ctx := context.Background()
+
+		for _, m := range []*migrations.Migration{
+			{Name: "01_create_table", Operations: migrations.Operations{createTableOp("users")}},
+			{Name: "02_add_column", Operations: migrations.Operations{addColumnOp("users")}},
+		} {
+			require.NoError(t, mig.Start(ctx, m, backfill.NewConfig()))
+			require.NoError(t, mig.Complete(ctx))
+		}
+
+		// Migrations that are in progress can not be reverted
+		require.NoError(t, mig.Start(ctx, &migrations.Migration{
+			Name:       "03_create_table",
+			Operations: migrations.Operations{createTableOp("posts")},
+		}, backfill.NewConfig()))
+		_, err := mig.RevertMigration(ctx, "03_create_table", "revert_03_create_table")
+		require.ErrorIs(t, err, roll.ErrMigrationNotComplete)
+		require.NoError(t, mig.Rollback(ctx))
+
+		// Migrations other than the latest can not be reverted
+		_, err = mig.RevertMigration(ctx, "01_create_table", "03_revert_create_table")
+		require.ErrorIs(t, err, roll.ErrNotLatestMigration)
+
+		// Revert the migration that added the column
+		revert, err := mig.RevertMigration(ctx, "02_add_column", "03_revert_add_column")
+		require.NoError(t, err)
+		assert.Equal(t, migrations.Operations{
+			&migrations.OpDropColumn{Table: "users", Column: "age"},
+		}, revert.Operations)
+
+		require.NoError(t, mig.Start(ctx, revert, backfill.NewConfig()))
+		require.NoError(t, mig.Complete(ctx))
+
+		var exists bool
+		err = db.QueryRowContext(ctx, `SELECT EXISTS (
+			SELECT 1 FROM information_schema.columns
+			WHERE table_schema = 'public' AND table_name = 'users' AND column_name = 'age'
+		)`).Scan(&exists)
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+}
+
+func TestRevertMigrationRefusesIrreversibleOperations(t *testing.T) {
+	t.Parallel()
+
+	testutils.WithMigratorInSchemaAndConnectionToContainer(t, "public", func(mig *roll.Roll, db *sql.DB) {
+		ctx := context.Background()
+
+		for _, m := range []*migrations.Migration{
+			{Name: "01_create_table", Operations: migrations.Operations{createTableOp("users")}},
+			{Name: "02_drop_table", Operations: migrations.Operations{&migrations.OpDropTable{Name: "users"}}},
+		} {
+			require.NoError(t, mig.Start(ctx, m, backfill.NewConfig()))
+			require.NoError(t, mig.Complete(ctx))
+		}
+
+		_, err := mig.RevertMigration(ctx, "02_drop_table", "03_revert_drop_table")
+		require.ErrorAs(t, err, &migrations.OperationNotInvertibleError{})
+
+		// The table is still dropped
+		assert.False(t, tableExists(t, db, "public", "users"))
+	})
+}
@@ -28,6 +28,8 @@ const (
 var (
 	ErrMismatchedMigration          = fmt.Errorf("remote migration does not match local migration")
 	ErrExistingSchemaWithoutHistory = fmt.Errorf("schema has existing tables but no migration history - baseline required")
+	ErrMigrationDrift               = fmt.Errorf("applied migrations have changed since they were applied")
+	ErrSquashTargetNotFound         = fmt.Errorf("migration to squash up to has not been applied since the latest baseline")
 )
 
 type Roll struct {
@@ -45,6 +47,21 @@ type Roll struct {
 	state          *state.State
 	pgVersion      PGVersion
 	skipValidation bool
+
+	// leave the migration in progress if a backfill fails
+	resumableBackfills bool
+
+	// copy the schema's functions into version schemas
+	versionedFunctions bool
+
+	// copy the schema's privileges onto version schemas
+	copyPrivileges bool
+
+	// advisory lock serializing pgroll processes acting on the schema
+	lock *advisoryLock
+
+	// wait for sessions using the previous version schema on complete
+	clientWait *WaitForClients
 }
 
 // New creates a new Roll instance
@@ -54,7 +71,16 @@ func New(ctx context.Context, pgURL, schema string, state *state.State, opts ...
 		o(rollOpts)
 	}
 
-	conn, err := setupConn(ctx, pgURL, schema, *rollOpts)
+	dsn := connString(pgURL, schema, *rollOpts)
+	conn, err := setupConn(ctx, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	// The advisory lock is held on a connection of its own for as long as a
+	// migration phase runs, so it must not take a connection from the pool
+	// used to run the migration
+	lockConn, err := sql.Open("postgres", dsn)
 	if err != nil {
 		return nil, err
 	}
@@ -79,45 +105,57 @@ func New(ctx context.Context, pgURL, schema string, state *state.State, opts ...
 		disableVersionSchemas: rollOpts.disableVersionSchemas,
 		migrationHooks:        rollOpts.migrationHooks,
 		skipValidation:        rollOpts.skipValidation,
+		resumableBackfills:    rollOpts.resumableBackfills,
+		versionedFunctions:    rollOpts.versionedFunctions,
+		copyPrivileges:        rollOpts.copyPrivileges,
+		clientWait:            rollOpts.waitForClients,
+		lock: &advisoryLock{
+			db:          lockConn,
+			key:         state.Schema() + "." + schema,
+			schema:      schema,
+			waitTimeout: rollOpts.lockWaitTimeout,
+		},
 	}, nil
 }
 
-func setupConn(ctx context.Context, pgURL, schema string, options options) (*sql.DB, error) {
+// connString returns the connection string for the connections made by the
+// Roll instance. Session settings are passed as run-time parameters rather
+// than set with SET, so that every connection in the pool gets them, not only
+// the first.
+func connString(pgURL, schema string, options options) string {
 	dsn, err := pq.ParseURL(pgURL)
 	if err != nil {
 		dsn = pgURL
 	}
 
 	searchPath := append([]string{schema}, options.searchPath...)
-	dsn += fmt.Sprintf(" search_path=%s application_name=%s",
+	dsn += fmt.Sprintf(" search_path=%s application_name=%s pgroll.no_inferred_migrations=TRUE",
 		strings.Join(searchPath, ","), applicationName)
 
-	conn, err := sql.Open("postgres", dsn)
-	if err != nil {
-		return nil, err
+	if options.lockTimeoutMs > 0 {
+		dsn += fmt.Sprintf(" lock_timeout=%dms", options.lockTimeoutMs)
 	}
 
-	if err := conn.PingContext(ctx); err != nil {
-		return nil, err
+	if options.role != "" {
+		dsn += " role=" + quoteConnStringValue(options.role)
 	}
 
-	_, err = conn.ExecContext(ctx, "SET pgroll.no_inferred_migrations TO 'TRUE'")
-	if err != nil {
-		return nil, fmt.Errorf("unable to set pgroll.no_inferred_migrations to true: %w", err)
-	}
+	return dsn
+}
 
-	if options.lockTimeoutMs > 0 {
-		_, err = conn.ExecContext(ctx, fmt.Sprintf("SET lock_timeout to '%dms'", options.lockTimeoutMs))
-		if err != nil {
-			return nil, fmt.Errorf("unable to set lock_timeout: %w", err)
-		}
+// quoteConnStringValue quotes `value` for use in a key/value connection string
+func quoteConnStringValue(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+func setupConn(ctx context.Context, dsn string) (*sql.DB, error) {
+	conn, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
 	}
 
-	if options.role != "" {
-		_, err = conn.ExecContext(ctx, fmt.Sprintf("SET ROLE %s", options.role))
-		if err != nil {
-			return nil, fmt.Errorf("unable to set role to '%s': %w", options.role, err)
-		}
+	if err := conn.PingContext(ctx); err != nil {
+		return nil, err
 	}
 
 	return conn, nil
@@ -148,11 +186,25 @@ func (m *Roll) Schema() string {
 	return m.schema
 }
 
+// forSchema returns a copy of the Roll instance acting on `schema`. It is
+// used to update the version schemas of the other schemas changed by a
+// cross-schema migration. The copy shares the connection, state and advisory
+// lock of the original instance.
+func (m *Roll) forSchema(schema string) *Roll {
+	r := *m
+	r.schema = schema
+	return &r
+}
+
 func (m *Roll) UseVersionSchema() bool {
 	return !m.disableVersionSchemas
 }
 
 func (m *Roll) Close() error {
+	if err := m.lock.close(); err != nil {
+		return err
+	}
+
 	err := m.state.Close()
 	if err != nil {
 		return err
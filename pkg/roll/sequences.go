@@ -0,0 +1,67 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package roll
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+// sequenceFunctions are the functions that take the name of a sequence, along
+// with the types of their remaining arguments
+var sequenceFunctions = []struct {
+	name string
+	args []string
+}{
+	{name: "nextval"},
+	{name: "currval"},
+	{name: "setval", args: []string{"bigint"}},
+	{name: "setval", args: []string{"bigint", "boolean"}},
+}
+
+// ensureSequenceFunctions makes the sequences of the underlying schema
+// available by name from the version schema for `version`.
+//
+// Postgres has no way to make a sequence available under a second name, so
+// versions of `nextval`, `currval` and `setval` taking the name of the
+// sequence as `text` are created in the version schema instead. They look the
+// sequence up in the underlying schema. When called with a string literal, eg
+// `nextval('orders_id_seq')`, Postgres prefers them over the built-in
+// functions taking a `regclass`.
+func (m *Roll) ensureSequenceFunctions(ctx context.Context, version string) error {
+	versionSchema := VersionedSchemaName(m.schema, version)
+
+	for _, fn := range sequenceFunctions {
+		params := "text"
+		call := "$1::regclass"
+		for i, arg := range fn.args {
+			params += ", " + arg
+			call += fmt.Sprintf(", $%d", i+2)
+		}
+
+		_, err := m.pgConn.ExecContext(ctx, fmt.Sprintf("CREATE OR REPLACE FUNCTION %s.%s(%s) RETURNS bigint LANGUAGE sql VOLATILE STRICT SET search_path = %s AS %s",
+			pq.QuoteIdentifier(versionSchema),
+			fn.name,
+			params,
+			pq.QuoteIdentifier(m.schema),
+			pq.QuoteLiteral(fmt.Sprintf("SELECT pg_catalog.%s(%s)", fn.name, call))))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hasSequences returns whether `s` has any non-deleted sequences
+func hasSequences(s *schema.Schema) bool {
+	for name := range s.Sequences {
+		if s.GetSequence(name) != nil {
+			return true
+		}
+	}
+	return false
+}
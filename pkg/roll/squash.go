@@ -0,0 +1,264 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package roll
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/lib/pq"
+
+	"github.com/xataio/pgroll/pkg/migrations"
+	"github.com/xataio/pgroll/pkg/schema"
+	"github.com/xataio/pgroll/pkg/state"
+)
+
+// Squash returns a baseline migration called `name` whose operations recreate
+// the schema as it was after the migration `upTo` was applied. `upTo` must
+// have been applied since the most recent baseline and `name` must sort
+// between `upTo` and the migration that follows it, so that the baseline takes
+// the place of the migrations it supersedes.
+//
+// The baseline recreates the enums, sequences, tables, indexes and constraints
+// in the schema. Squash also returns a description of each object in the
+// schema that the baseline does not recreate, such as views and triggers; the
+// baseline is only a faithful copy of the schema if there are none.
+func (m *Roll) Squash(ctx context.Context, upTo, name string) (*migrations.Migration, []string, error) {
+	isActive, err := m.state.IsActiveMigrationPeriod(ctx, m.schema)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to check for active migrations: %w", err)
+	}
+	if isActive {
+		return nil, nil, fmt.Errorf("cannot squash migrations while a migration is in progress")
+	}
+
+	history, err := m.state.SchemaHistory(ctx, m.schema)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading schema history: %w", err)
+	}
+
+	idx := slices.IndexFunc(history, func(h state.HistoryEntry) bool {
+		return h.Migration.Name == upTo
+	})
+	if idx == -1 {
+		return nil, nil, fmt.Errorf("%w: %q", ErrSquashTargetNotFound, upTo)
+	}
+	if name <= upTo {
+		return nil, nil, fmt.Errorf("baseline name %q must sort after %q", name, upTo)
+	}
+	if idx+1 < len(history) && name >= history[idx+1].Migration.Name {
+		return nil, nil, fmt.Errorf("baseline name %q must sort before %q", name, history[idx+1].Migration.Name)
+	}
+
+	snapshot, err := m.state.SchemaAfterMigration(ctx, m.schema, upTo)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading schema after migration %q: %w", upTo, err)
+	}
+
+	m.logger.Info("Squashing migrations up to %q into baseline %q for schema %q", upTo, name, m.schema)
+
+	return &migrations.Migration{
+		Name:     name,
+		Baseline: true,
+		Operations: migrations.Operations{
+			&migrations.OpRawSQL{Up: snapshotSQL(snapshot)},
+		},
+	}, omittedFromSnapshot(snapshot), nil
+}
+
+// omittedFromSnapshot returns a description of each object in `s` that is not
+// recreated by snapshotSQL, in name order
+func omittedFromSnapshot(s *schema.Schema) []string {
+	var omitted []string
+
+	for _, name := range slices.Sorted(maps.Keys(s.Views)) {
+		if s.GetView(name) != nil {
+			omitted = append(omitted, fmt.Sprintf("view %q", name))
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(s.Functions)) {
+		if s.GetFunction(name) != nil {
+			omitted = append(omitted, fmt.Sprintf("function %q", name))
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(s.Tables)) {
+		t := s.GetTable(name)
+		if t == nil {
+			continue
+		}
+		if t.IsPartitioned() {
+			omitted = append(omitted, fmt.Sprintf("partitioning of table %q", name))
+		}
+		if t.RLSEnabled || t.RLSForced {
+			omitted = append(omitted, fmt.Sprintf("row level security on table %q", name))
+		}
+		for _, policy := range slices.Sorted(maps.Keys(t.Policies)) {
+			omitted = append(omitted, fmt.Sprintf("policy %q on table %q", policy, name))
+		}
+		for _, trigger := range slices.Sorted(maps.Keys(t.Triggers)) {
+			omitted = append(omitted, fmt.Sprintf("trigger %q on table %q", trigger, name))
+		}
+		if len(t.Privileges) > 0 {
+			omitted = append(omitted, fmt.Sprintf("privileges on table %q", name))
+		}
+	}
+
+	return omitted
+}
+
+// snapshotSQL returns the DDL that recreates the enums, sequences, tables,
+// indexes and constraints in `s`. Foreign keys are added once all tables have
+// been created, so tables can be created in any order.
+func snapshotSQL(s *schema.Schema) string {
+	var stmts []string
+
+	for _, name := range slices.Sorted(maps.Keys(s.Enums)) {
+		e := s.Enums[name]
+		values := make([]string, len(e.Values))
+		for i, v := range e.Values {
+			values[i] = pq.QuoteLiteral(v)
+		}
+		stmts = append(stmts, fmt.Sprintf("CREATE TYPE %s AS ENUM (%s)",
+			pq.QuoteIdentifier(e.Name), strings.Join(values, ", ")))
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(s.Sequences)) {
+		seq := s.Sequences[name]
+		cycle := "NO CYCLE"
+		if seq.Cycle {
+			cycle = "CYCLE"
+		}
+		stmts = append(stmts, fmt.Sprintf("CREATE SEQUENCE %s AS %s INCREMENT BY %d MINVALUE %d MAXVALUE %d START WITH %d CACHE %d %s",
+			pq.QuoteIdentifier(seq.Name), seq.DataType, seq.Increment, seq.MinValue, seq.MaxValue, seq.Start, seq.Cache, cycle))
+	}
+
+	tables := slices.Sorted(maps.Keys(s.Tables))
+	for _, name := range tables {
+		stmts = append(stmts, createTableSQL(s.Tables[name])...)
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(s.Sequences)) {
+		seq := s.Sequences[name]
+		table, column, ok := strings.Cut(seq.OwnedBy, ".")
+		if !ok {
+			continue
+		}
+		stmts = append(stmts, fmt.Sprintf("ALTER SEQUENCE %s OWNED BY %s.%s",
+			pq.QuoteIdentifier(seq.Name), pq.QuoteIdentifier(table), pq.QuoteIdentifier(column)))
+	}
+
+	for _, name := range tables {
+		indexes := s.Tables[name].StandaloneIndexes()
+		for _, idx := range slices.Sorted(maps.Keys(indexes)) {
+			stmts = append(stmts, indexes[idx].Definition)
+		}
+	}
+
+	for _, name := range tables {
+		t := s.Tables[name]
+		for _, fk := range slices.Sorted(maps.Keys(t.ForeignKeys)) {
+			stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ADD %s",
+				pq.QuoteIdentifier(t.Name), foreignKeySQL(t.ForeignKeys[fk])))
+		}
+	}
+
+	return strings.Join(stmts, ";\n") + ";\n"
+}
+
+// createTableSQL returns the statements that create table `t` with its
+// columns, primary key, unique, check and exclusion constraints and comments.
+// Primary key columns come first, followed by the remaining columns in name
+// order.
+func createTableSQL(t *schema.Table) []string {
+	names := slices.Clone(t.PrimaryKey)
+	for _, name := range slices.Sorted(maps.Keys(t.Columns)) {
+		if !slices.Contains(t.PrimaryKey, name) {
+			names = append(names, name)
+		}
+	}
+
+	var defs []string
+	for _, name := range names {
+		col := t.Columns[name]
+		def := pq.QuoteIdentifier(col.Name) + " " + col.Type
+		if col.Identity != "" {
+			def += fmt.Sprintf(" GENERATED %s AS IDENTITY", col.Identity)
+		}
+		if col.Default != nil {
+			def += " DEFAULT " + *col.Default
+		}
+		if !col.Nullable {
+			def += " NOT NULL"
+		}
+		defs = append(defs, def)
+	}
+
+	if len(t.PrimaryKey) > 0 {
+		defs = append(defs, fmt.Sprintf("PRIMARY KEY (%s)", quoteIdentifiers(t.PrimaryKey)))
+	}
+	for _, name := range slices.Sorted(maps.Keys(t.UniqueConstraints)) {
+		uc := t.UniqueConstraints[name]
+		defs = append(defs, fmt.Sprintf("CONSTRAINT %s UNIQUE (%s)",
+			pq.QuoteIdentifier(uc.Name), quoteIdentifiers(uc.Columns)))
+	}
+	for _, name := range slices.Sorted(maps.Keys(t.CheckConstraints)) {
+		cc := t.CheckConstraints[name]
+		defs = append(defs, fmt.Sprintf("CONSTRAINT %s %s", pq.QuoteIdentifier(cc.Name), cc.Definition))
+	}
+	for _, name := range slices.Sorted(maps.Keys(t.ExcludeConstraints)) {
+		xc := t.ExcludeConstraints[name]
+		defs = append(defs, fmt.Sprintf("CONSTRAINT %s %s", pq.QuoteIdentifier(xc.Name), xc.Definition))
+	}
+
+	stmts := []string{
+		fmt.Sprintf("CREATE TABLE %s (\n    %s\n)", pq.QuoteIdentifier(t.Name), strings.Join(defs, ",\n    ")),
+	}
+
+	if t.Comment != "" {
+		stmts = append(stmts, fmt.Sprintf("COMMENT ON TABLE %s IS %s",
+			pq.QuoteIdentifier(t.Name), pq.QuoteLiteral(t.Comment)))
+	}
+	for _, name := range names {
+		if col := t.Columns[name]; col.Comment != "" {
+			stmts = append(stmts, fmt.Sprintf("COMMENT ON COLUMN %s.%s IS %s",
+				pq.QuoteIdentifier(t.Name), pq.QuoteIdentifier(col.Name), pq.QuoteLiteral(col.Comment)))
+		}
+	}
+
+	return stmts
+}
+
+// foreignKeySQL returns the definition of foreign key constraint `fk`
+func foreignKeySQL(fk *schema.ForeignKey) string {
+	def := fmt.Sprintf("CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s (%s)",
+		pq.QuoteIdentifier(fk.Name),
+		quoteIdentifiers(fk.Columns),
+		pq.QuoteIdentifier(fk.ReferencedTable),
+		quoteIdentifiers(fk.ReferencedColumns))
+
+	if fk.MatchType != "" && fk.MatchType != "SIMPLE" {
+		def += " MATCH " + fk.MatchType
+	}
+	if fk.OnDelete != "" && fk.OnDelete != "NO ACTION" {
+		def += " ON DELETE " + fk.OnDelete
+		if len(fk.OnDeleteSetColumns) > 0 {
+			def += fmt.Sprintf(" (%s)", quoteIdentifiers(fk.OnDeleteSetColumns))
+		}
+	}
+	if fk.OnUpdate != "" && fk.OnUpdate != "NO ACTION" {
+		def += " ON UPDATE " + fk.OnUpdate
+	}
+	return def
+}
+
+func quoteIdentifiers(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = pq.QuoteIdentifier(name)
+	}
+	return strings.Join(quoted, ", ")
+}
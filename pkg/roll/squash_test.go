@@ -0,0 +1,149 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package roll_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xataio/pgroll/internal/testutils"
+	"github.com/xataio/pgroll/pkg/backfill"
+	"github.com/xataio/pgroll/pkg/migrations"
+	"github.com/xataio/pgroll/pkg/roll"
+)
+
+func TestSquash(t *testing.T) {
+	t.Parallel()
+
+	createTables := &migrations.Migration{
+		Name: "01_create_tables",
+		Operations: migrations.Operations{
+			&migrations.OpRawSQL{Up: `
+				CREATE TYPE mood AS ENUM ('happy', 'sad');
+				CREATE TABLE users (
+					id serial PRIMARY KEY,
+					email text NOT NULL UNIQUE,
+					mood mood,
+					CONSTRAINT email_length CHECK (length(email) > 3)
+				);
+				CREATE TABLE posts (
+					id bigint GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+					user_id integer REFERENCES users (id) ON DELETE CASCADE,
+					title text DEFAULT 'untitled'
+				);
+				CREATE INDEX posts_title ON posts (title) WHERE title IS NOT NULL;
+				COMMENT ON TABLE posts IS 'blog posts';
+			`},
+		},
+	}
+	addColumn := &migrations.Migration{
+		Name: "02_add_column",
+		Operations: migrations.Operations{
+			&migrations.OpRawSQL{Up: "ALTER TABLE posts ADD COLUMN body text"},
+		},
+	}
+
+	// apply applies the migrations `migs` in order
+	apply := func(t *testing.T, r *roll.Roll, migs ...*migrations.Migration) {
+		ctx := context.Background()
+
+		for _, mig := range migs {
+			require.NoError(t, r.Start(ctx, mig, backfill.NewConfig()))
+			require.NoError(t, r.Complete(ctx))
+		}
+	}
+
+	t.Run("the baseline recreates the schema after the squashed migrations", func(t *testing.T) {
+		testutils.WithMigratorAndConnectionToContainer(t, func(r *roll.Roll, _ *sql.DB) {
+			ctx := context.Background()
+			apply(t, r, createTables)
+
+			baseline, omitted, err := r.Squash(ctx, "01_create_tables", "01_create_tables_squashed")
+			require.NoError(t, err)
+
+			assert.Equal(t, "01_create_tables_squashed", baseline.Name)
+			assert.True(t, baseline.Baseline)
+			assert.Empty(t, omitted)
+
+			want, err := r.State().ReadSchema(ctx, "public")
+			require.NoError(t, err)
+
+			// Apply the baseline to an empty database
+			testutils.WithMigratorAndConnectionToContainer(t, func(fresh *roll.Roll, _ *sql.DB) {
+				apply(t, fresh, baseline)
+
+				got, err := fresh.State().ReadSchema(ctx, "public")
+				require.NoError(t, err)
+
+				clearOIDS(want)
+				clearOIDS(got)
+				assert.Equal(t, want, got)
+			})
+		})
+	})
+
+	t.Run("migrations applied after the squashed migrations are not included", func(t *testing.T) {
+		testutils.WithMigratorAndConnectionToContainer(t, func(r *roll.Roll, _ *sql.DB) {
+			ctx := context.Background()
+			apply(t, r, createTables, addColumn)
+
+			baseline, _, err := r.Squash(ctx, "01_create_tables", "01_create_tables_squashed")
+			require.NoError(t, err)
+
+			require.Len(t, baseline.Operations, 1)
+			up := baseline.Operations[0].(*migrations.OpRawSQL).Up
+			assert.Contains(t, up, `CREATE TABLE "posts"`)
+			assert.NotContains(t, up, `"body"`)
+		})
+	})
+
+	t.Run("objects the baseline does not recreate are reported", func(t *testing.T) {
+		testutils.WithMigratorAndConnectionToContainer(t, func(r *roll.Roll, _ *sql.DB) {
+			ctx := context.Background()
+			apply(t, r, createTables, &migrations.Migration{
+				Name: "02_create_view",
+				Operations: migrations.Operations{
+					&migrations.OpRawSQL{Up: `
+						CREATE VIEW post_titles AS SELECT title FROM posts;
+						ALTER TABLE posts ENABLE ROW LEVEL SECURITY;
+					`},
+				},
+			})
+
+			_, omitted, err := r.Squash(ctx, "02_create_view", "02_create_view_squashed")
+			require.NoError(t, err)
+
+			assert.Equal(t, []string{
+				`view "post_titles"`,
+				`row level security on table "posts"`,
+			}, omitted)
+		})
+	})
+
+	t.Run("migrations that have not been applied can not be squashed", func(t *testing.T) {
+		testutils.WithMigratorAndConnectionToContainer(t, func(r *roll.Roll, _ *sql.DB) {
+			ctx := context.Background()
+			apply(t, r, createTables)
+
+			_, _, err := r.Squash(ctx, "02_add_column", "02_add_column_squashed")
+			require.ErrorIs(t, err, roll.ErrSquashTargetNotFound)
+		})
+	})
+
+	t.Run("the baseline must sort between the squashed migrations and the following migration", func(t *testing.T) {
+		testutils.WithMigratorAndConnectionToContainer(t, func(r *roll.Roll, _ *sql.DB) {
+			ctx := context.Background()
+			apply(t, r, createTables, addColumn)
+
+			_, _, err := r.Squash(ctx, "01_create_tables", "00_baseline")
+			require.ErrorContains(t, err, `must sort after "01_create_tables"`)
+
+			_, _, err = r.Squash(ctx, "01_create_tables", "03_baseline")
+			require.ErrorContains(t, err, `must sort before "02_add_column"`)
+		})
+	})
+}
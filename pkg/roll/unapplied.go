@@ -9,12 +9,20 @@ import (
 	"sort"
 
 	"github.com/xataio/pgroll/pkg/migrations"
+	"github.com/xataio/pgroll/pkg/state"
 )
 
 // UnappliedMigrations returns the slice of unapplied migrations from `dir`
 // that have not yet been applied to the database. Applying each of the
 // returned migrations in order will bring the database up to date with `dir`.
 //
+// Local baseline migrations, written by `pgroll squash`, supersede the local
+// migrations that precede them. A database with no migration history is
+// brought up to date from the latest local baseline, skipping the migrations
+// before it, as is a database whose history starts with a local baseline.
+// Databases that applied the superseded migrations individually skip the local
+// baselines instead.
+//
 // If the local order of migrations does not match the order of migrations in
 // the schema history, an `ErrMismatchedMigration` error is returned.
 func (m *Roll) UnappliedMigrations(ctx context.Context, dir fs.FS) ([]*migrations.RawMigration, error) {
@@ -61,6 +69,7 @@ func (m *Roll) UnappliedMigrations(ctx context.Context, dir fs.FS) ([]*migration
 		}
 		migsAfterBaseline = append(migsAfterBaseline, migration)
 	}
+	migsAfterBaseline = withoutSupersededMigrations(migsAfterBaseline, history, baseline != nil)
 
 	// Find the index of the first local migration that has not been applied to
 	// the database and ensure that the order of migrations in the database
@@ -84,3 +93,34 @@ func (m *Roll) UnappliedMigrations(ctx context.Context, dir fs.FS) ([]*migration
 	// Return only the migrations that haven't been applied yet
 	return migsAfterBaseline[appliedCount:], nil
 }
+
+// withoutSupersededMigrations returns the local migrations `migs` that apply to
+// a database with schema history `history`, taking into account the local
+// baseline migrations in `migs`. `hasBaseline` is true if the database has a
+// baseline recorded in its history.
+func withoutSupersededMigrations(migs []*migrations.RawMigration, history []state.HistoryEntry, hasBaseline bool) []*migrations.RawMigration {
+	// Find the local baseline to apply the local migrations from, if any
+	start := -1
+	for i, mig := range migs {
+		if !mig.Baseline {
+			continue
+		}
+		switch {
+		case len(history) == 0 && !hasBaseline:
+			start = i
+		case len(history) > 0 && history[0].Migration.Name == mig.Name:
+			start = i
+		}
+	}
+
+	// Apply all migrations after the local baseline, or all migrations if there
+	// is none, skipping any other local baselines
+	result := make([]*migrations.RawMigration, 0, len(migs))
+	for i, mig := range migs {
+		if i < start || (mig.Baseline && i != start) {
+			continue
+		}
+		result = append(result, mig)
+	}
+	return result
+}
@@ -373,6 +373,79 @@ func TestUnappliedMigrationsWithBaselines(t *testing.T) {
 	})
 }
 
+func TestUnappliedMigrationsWithLocalBaselines(t *testing.T) {
+	t.Parallel()
+
+	fs := fstest.MapFS{
+		"01_migration_1.json":          &fstest.MapFile{Data: exampleMigration(t, "01_migration_1")},
+		"02_migration_2.json":          &fstest.MapFile{Data: exampleMigration(t, "02_migration_2")},
+		"02_migration_2_squashed.json": &fstest.MapFile{Data: exampleBaselineMigration(t, "02_migration_2_squashed")},
+		"03_migration_3.json":          &fstest.MapFile{Data: exampleMigration(t, "03_migration_3")},
+	}
+
+	// apply applies the migrations in `fs` with the given names
+	apply := func(t *testing.T, r *roll.Roll, names ...string) {
+		ctx := context.Background()
+
+		for _, name := range names {
+			migration, err := migrations.ReadMigration(fs, name+".json")
+			require.NoError(t, err)
+			require.NoError(t, r.Start(ctx, migration, backfill.NewConfig()))
+			require.NoError(t, r.Complete(ctx))
+		}
+	}
+
+	t.Run("a database with no history is migrated from the local baseline", func(t *testing.T) {
+		testutils.WithMigratorAndConnectionToContainer(t, func(roll *roll.Roll, _ *sql.DB) {
+			migs, err := roll.UnappliedMigrations(context.Background(), fs)
+			require.NoError(t, err)
+
+			// Assert that the migrations before the local baseline are superseded
+			require.Len(t, migs, 2)
+			require.Equal(t, "02_migration_2_squashed", migs[0].Name)
+			require.Equal(t, "03_migration_3", migs[1].Name)
+		})
+	})
+
+	t.Run("a database migrated from the local baseline ignores the migrations before it", func(t *testing.T) {
+		testutils.WithMigratorAndConnectionToContainer(t, func(roll *roll.Roll, _ *sql.DB) {
+			apply(t, roll, "02_migration_2_squashed")
+
+			migs, err := roll.UnappliedMigrations(context.Background(), fs)
+			require.NoError(t, err)
+
+			require.Len(t, migs, 1)
+			require.Equal(t, "03_migration_3", migs[0].Name)
+		})
+	})
+
+	t.Run("a database that applied the migrations before the local baseline skips the baseline", func(t *testing.T) {
+		testutils.WithMigratorAndConnectionToContainer(t, func(roll *roll.Roll, _ *sql.DB) {
+			apply(t, roll, "01_migration_1")
+
+			migs, err := roll.UnappliedMigrations(context.Background(), fs)
+			require.NoError(t, err)
+
+			require.Len(t, migs, 2)
+			require.Equal(t, "02_migration_2", migs[0].Name)
+			require.Equal(t, "03_migration_3", migs[1].Name)
+		})
+	})
+
+	t.Run("a database with the baseline recorded ignores the migrations before it", func(t *testing.T) {
+		testutils.WithMigratorAndConnectionToContainer(t, func(roll *roll.Roll, _ *sql.DB) {
+			apply(t, roll, "01_migration_1", "02_migration_2")
+			require.NoError(t, roll.CreateBaseline(context.Background(), "02_migration_2_squashed"))
+
+			migs, err := roll.UnappliedMigrations(context.Background(), fs)
+			require.NoError(t, err)
+
+			require.Len(t, migs, 1)
+			require.Equal(t, "03_migration_3", migs[0].Name)
+		})
+	})
+}
+
 func TestUnappliedMigrationsWithOldMigrationFormats(t *testing.T) {
 	t.Parallel()
 
@@ -452,6 +525,23 @@ func exampleMigration(t *testing.T, name string) []byte {
 	return bytes
 }
 
+func exampleBaselineMigration(t *testing.T, name string) []byte {
+	t.Helper()
+
+	mig := &migrations.Migration{
+		Name:     name,
+		Baseline: true,
+		Operations: migrations.Operations{
+			&migrations.OpRawSQL{Up: "SELECT 1"},
+		},
+	}
+
+	bytes, err := json.Marshal(mig)
+	require.NoError(t, err)
+
+	return bytes
+}
+
 func exampleMigrationWithVersionSchema(t *testing.T, name, versionSchema string) []byte {
 	t.Helper()
 
@@ -0,0 +1,87 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package roll
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/lib/pq"
+
+	"github.com/xataio/pgroll/pkg/migrations"
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+// ensureUserView creates the view `view` in the version schema for `version`.
+// The view is created with the version schema first on the search path, so
+// that the tables it reads from resolve to the version schema's views of those
+// tables rather than to the underlying tables.
+//
+// The statements are sent together so that they run in a single implicit
+// transaction; the search path change does not outlive it.
+func (m *Roll) ensureUserView(ctx context.Context, version string, view *schema.View) error {
+	versionSchema := VersionedSchemaName(m.schema, version)
+
+	_, err := m.pgConn.ExecContext(ctx,
+		fmt.Sprintf("SELECT set_config('search_path', %s || ',' || current_setting('search_path'), true); DROP VIEW IF EXISTS %s.%s; CREATE VIEW %s.%s AS %s",
+			pq.QuoteLiteral(pq.QuoteIdentifier(versionSchema)),
+			pq.QuoteIdentifier(versionSchema),
+			pq.QuoteIdentifier(view.Name),
+			pq.QuoteIdentifier(versionSchema),
+			pq.QuoteIdentifier(view.Name),
+			view.Definition))
+	return err
+}
+
+// orderViews returns the non-deleted views in `views` ordered so that each
+// view comes after the views it reads from.
+func orderViews(views map[string]*schema.View) []*schema.View {
+	names := make([]string, 0, len(views))
+	for name, view := range views {
+		if !view.Deleted {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	// Find the other views each view reads from
+	deps := make(map[string][]string, len(names))
+	for _, name := range names {
+		for _, rel := range migrations.RelationNames(views[name].Definition) {
+			if rel != name && slices.Contains(names, rel) {
+				deps[name] = append(deps[name], rel)
+			}
+		}
+	}
+
+	ordered := make([]*schema.View, 0, len(names))
+	done := make(map[string]bool, len(names))
+	for len(ordered) < len(names) {
+		progress := false
+		for _, name := range names {
+			if done[name] {
+				continue
+			}
+			if slices.ContainsFunc(deps[name], func(dep string) bool { return !done[dep] }) {
+				continue
+			}
+			ordered = append(ordered, views[name])
+			done[name] = true
+			progress = true
+		}
+
+		// A cycle can only come from names wrongly taken to be references to
+		// other views; add the remaining views in name order
+		if !progress {
+			for _, name := range names {
+				if !done[name] {
+					ordered = append(ordered, views[name])
+					done[name] = true
+				}
+			}
+		}
+	}
+
+	return ordered
+}
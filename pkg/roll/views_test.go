@@ -0,0 +1,70 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package roll
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+func TestOrderViews(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		views map[string]*schema.View
+		want  []string
+	}{
+		"no views": {
+			want: []string{},
+		},
+		"independent views are ordered by name": {
+			views: map[string]*schema.View{
+				"b": {Name: "b", Definition: "SELECT id FROM users"},
+				"a": {Name: "a", Definition: "SELECT id FROM users"},
+			},
+			want: []string{"a", "b"},
+		},
+		"views come after the views they read from": {
+			views: map[string]*schema.View{
+				"a": {Name: "a", Definition: "SELECT id FROM b JOIN c USING (id)"},
+				"b": {Name: "b", Definition: "SELECT id FROM c"},
+				"c": {Name: "c", Definition: "SELECT id FROM users"},
+			},
+			want: []string{"c", "b", "a"},
+		},
+		"views referenced in subqueries": {
+			views: map[string]*schema.View{
+				"a": {Name: "a", Definition: "SELECT id FROM users WHERE id IN (SELECT id FROM b)"},
+				"b": {Name: "b", Definition: "SELECT id FROM users"},
+			},
+			want: []string{"b", "a"},
+		},
+		"deleted views are skipped": {
+			views: map[string]*schema.View{
+				"a": {Name: "a", Definition: "SELECT id FROM users"},
+				"b": {Name: "b", Definition: "SELECT id FROM users", Deleted: true},
+			},
+			want: []string{"a"},
+		},
+		"unparseable definitions are ordered by name": {
+			views: map[string]*schema.View{
+				"b": {Name: "b", Definition: "SELECT FROM FROM"},
+				"a": {Name: "a", Definition: "SELECT id FROM b"},
+			},
+			want: []string{"b", "a"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := make([]string, 0, len(tt.views))
+			for _, v := range orderViews(tt.views) {
+				got = append(got, v.Name)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,224 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package schema
+
+import (
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// SchemaDiff describes the changes required to turn one schema into another.
+type SchemaDiff struct {
+	// Tables that exist only in the target schema
+	AddedTables []*Table
+
+	// Names of the tables that exist only in the source schema
+	DroppedTables []string
+
+	// Changes to tables that exist in both schemas
+	ChangedTables []*TableDiff
+}
+
+// TableDiff describes the changes made to a table that exists in both schemas.
+type TableDiff struct {
+	// Name of the table
+	Name string
+
+	// Columns that exist only in the target table
+	AddedColumns []*Column
+
+	// Columns that exist only in the source table
+	DroppedColumns []*Column
+
+	// Columns whose definition differs between the two tables
+	AlteredColumns []*ColumnDiff
+
+	// Indexes that exist only in the target table
+	AddedIndexes []*Index
+
+	// Names of the indexes that exist only in the source table
+	DroppedIndexes []string
+
+	// Indexes whose definition differs between the two tables, as defined in
+	// the target table
+	ChangedIndexes []*Index
+}
+
+// ColumnDiff describes the changes made to a column that exists in both
+// tables.
+type ColumnDiff struct {
+	From *Column
+	To   *Column
+}
+
+// TypeChanged returns true if the column type differs.
+func (d *ColumnDiff) TypeChanged() bool {
+	return d.From.Type != d.To.Type
+}
+
+// NullableChanged returns true if the column nullability differs.
+func (d *ColumnDiff) NullableChanged() bool {
+	return d.From.Nullable != d.To.Nullable
+}
+
+// DefaultChanged returns true if the column default differs. Defaults are
+// compared ignoring redundant casts and parentheses, as added by Postgres when
+// it stores the default.
+func (d *ColumnDiff) DefaultChanged() bool {
+	return !exprEqual(d.From.Default, d.To.Default)
+}
+
+// Empty returns true if the table has not changed.
+func (d *TableDiff) Empty() bool {
+	return len(d.AddedColumns) == 0 &&
+		len(d.DroppedColumns) == 0 &&
+		len(d.AlteredColumns) == 0 &&
+		len(d.AddedIndexes) == 0 &&
+		len(d.DroppedIndexes) == 0 &&
+		len(d.ChangedIndexes) == 0
+}
+
+// Empty returns true if the schemas are the same.
+func (d *SchemaDiff) Empty() bool {
+	return len(d.AddedTables) == 0 && len(d.DroppedTables) == 0 && len(d.ChangedTables) == 0
+}
+
+// Diff compares the schema `from` with the schema `to` and returns the changes
+// required to turn `from` into `to`.
+//
+// Tables and columns are matched by name, so a renamed table or column is
+// reported as dropped and added. Only columns and indexes are compared; changes
+// to constraints are not detected. The indexes that back primary key, unique
+// and exclusion constraints are ignored. All changes are returned in name
+// order.
+func Diff(from, to *Schema) *SchemaDiff {
+	diff := &SchemaDiff{}
+
+	for _, name := range sortedKeys(to.Tables) {
+		toTable := to.GetTable(name)
+		if toTable == nil {
+			continue
+		}
+
+		fromTable := from.GetTable(name)
+		if fromTable == nil {
+			diff.AddedTables = append(diff.AddedTables, toTable)
+			continue
+		}
+
+		if td := diffTable(name, fromTable, toTable); !td.Empty() {
+			diff.ChangedTables = append(diff.ChangedTables, td)
+		}
+	}
+
+	for _, name := range sortedKeys(from.Tables) {
+		if from.GetTable(name) != nil && to.GetTable(name) == nil {
+			diff.DroppedTables = append(diff.DroppedTables, name)
+		}
+	}
+
+	return diff
+}
+
+func diffTable(name string, from, to *Table) *TableDiff {
+	diff := &TableDiff{Name: name}
+
+	for _, colName := range sortedKeys(to.Columns) {
+		toCol := to.GetColumn(colName)
+		if toCol == nil {
+			continue
+		}
+
+		fromCol := from.GetColumn(colName)
+		if fromCol == nil {
+			diff.AddedColumns = append(diff.AddedColumns, toCol)
+			continue
+		}
+
+		cd := &ColumnDiff{From: fromCol, To: toCol}
+		if cd.TypeChanged() || cd.NullableChanged() || cd.DefaultChanged() {
+			diff.AlteredColumns = append(diff.AlteredColumns, cd)
+		}
+	}
+
+	for _, colName := range sortedKeys(from.Columns) {
+		if fromCol := from.GetColumn(colName); fromCol != nil && to.GetColumn(colName) == nil {
+			diff.DroppedColumns = append(diff.DroppedColumns, fromCol)
+		}
+	}
+
+	fromIndexes := from.StandaloneIndexes()
+	toIndexes := to.StandaloneIndexes()
+
+	for _, idxName := range sortedKeys(toIndexes) {
+		fromIdx, ok := fromIndexes[idxName]
+		switch {
+		case !ok:
+			diff.AddedIndexes = append(diff.AddedIndexes, toIndexes[idxName])
+		case !indexEqual(fromIdx, toIndexes[idxName]):
+			diff.ChangedIndexes = append(diff.ChangedIndexes, toIndexes[idxName])
+		}
+	}
+
+	for _, idxName := range sortedKeys(fromIndexes) {
+		if _, ok := toIndexes[idxName]; !ok {
+			diff.DroppedIndexes = append(diff.DroppedIndexes, idxName)
+		}
+	}
+
+	return diff
+}
+
+func indexEqual(a, b *Index) bool {
+	return a.Unique == b.Unique &&
+		indexMethod(a) == indexMethod(b) &&
+		slices.Equal(a.Columns, b.Columns) &&
+		exprEqual(a.Predicate, b.Predicate)
+}
+
+func indexMethod(idx *Index) string {
+	if idx.Method == "" {
+		return "btree"
+	}
+	return idx.Method
+}
+
+// typeCastRegex matches a cast to a built-in type, such as `::text` or
+// `::character varying(255)[]`.
+var typeCastRegex = regexp.MustCompile(`::[a-z_]+( [a-z_]+)*(\(\d+(,\s*\d+)?\))?(\[\])*`)
+
+// exprEqual returns true if the SQL expressions `a` and `b` are equal, ignoring
+// type casts, parentheses and whitespace outside of string literals.
+func exprEqual(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return normalizeExpr(*a) == normalizeExpr(*b)
+}
+
+// exprNoise replaces the parts of an expression that are ignored when
+// comparing expressions
+var exprNoise = strings.NewReplacer("(", "", ")", "", " ", "", "\n", "", "\t", "")
+
+func normalizeExpr(expr string) string {
+	var b strings.Builder
+	// Splitting on quotes puts the contents of string literals at odd indexes.
+	// An escaped quote ('') splits a literal in two with nothing in between.
+	for i, part := range strings.Split(expr, "'") {
+		if i > 0 {
+			b.WriteString("'")
+		}
+		if i%2 == 1 {
+			b.WriteString(part)
+			continue
+		}
+		b.WriteString(exprNoise.Replace(typeCastRegex.ReplaceAllString(part, "")))
+	}
+	return b.String()
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	return slices.Sorted(maps.Keys(m))
+}
@@ -8,6 +8,7 @@ import (
 	"errors"
 	"fmt"
 	"slices"
+	"strings"
 )
 
 // XXX we create a view of the schema with the minimum required for us to
@@ -28,6 +29,105 @@ type Schema struct {
 	Name string `json:"name"`
 	// Tables is a map of virtual table name -> table mapping
 	Tables map[string]*Table `json:"tables"`
+	// Enums is a map of enum type name -> enum type
+	Enums map[string]*Enum `json:"enums"`
+	// Views is a map of view name -> view
+	Views map[string]*View `json:"views"`
+	// Functions is a map of function name -> function
+	Functions map[string]*Function `json:"functions"`
+	// Sequences is a map of sequence name -> sequence
+	Sequences map[string]*Sequence `json:"sequences"`
+}
+
+// View represents a view in the schema
+type View struct {
+	// Name is the name of the view in postgres
+	Name string `json:"name"`
+
+	// Definition is the SELECT query defining the view. Table and column names
+	// in the query are those of the schema version, not of the underlying
+	// tables.
+	Definition string `json:"definition"`
+
+	// Whether or not the view has been deleted in the virtual schema
+	Deleted bool `json:"-"`
+}
+
+// Function represents a user-defined function in the schema. Overloaded
+// functions are not supported; functions are identified by name.
+type Function struct {
+	// Name is the name of the function in postgres
+	Name string `json:"name"`
+
+	// Arguments is the argument list of the function, eg `a integer, b text`
+	Arguments string `json:"arguments"`
+
+	// Returns is the return type of the function, eg `integer` or `trigger`
+	Returns string `json:"returns"`
+
+	// Language is the language the function is written in, eg `plpgsql`
+	Language string `json:"language"`
+
+	// Volatility is the volatility of the function: IMMUTABLE, STABLE or
+	// VOLATILE
+	Volatility string `json:"volatility"`
+
+	// Definition is the body of the function
+	Definition string `json:"definition"`
+
+	// Whether or not the function has been deleted in the virtual schema
+	Deleted bool `json:"-"`
+}
+
+// IsTriggerFunction returns true if the function is a trigger function
+func (f *Function) IsTriggerFunction() bool {
+	return f.Returns == "trigger"
+}
+
+// Sequence represents a sequence in the schema. Sequences backing identity
+// columns are not included; they are part of their column.
+type Sequence struct {
+	// Name is the name of the sequence in postgres
+	Name string `json:"name"`
+
+	// DataType is the data type of the sequence: smallint, integer or bigint
+	DataType string `json:"dataType"`
+
+	// Start is the start value of the sequence
+	Start int64 `json:"start"`
+
+	// Increment is the value added to the sequence for each new value
+	Increment int64 `json:"increment"`
+
+	// MinValue is the minimum value of the sequence
+	MinValue int64 `json:"minValue"`
+
+	// MaxValue is the maximum value of the sequence
+	MaxValue int64 `json:"maxValue"`
+
+	// Cache is the number of sequence values preallocated
+	Cache int64 `json:"cache"`
+
+	// Cycle is whether the sequence wraps around when it reaches its limit
+	Cycle bool `json:"cycle"`
+
+	// OwnedBy is the column owning the sequence, as `table.column`, if any
+	OwnedBy string `json:"ownedBy"`
+
+	// Whether or not the sequence has been deleted in the virtual schema
+	Deleted bool `json:"-"`
+}
+
+// Enum represents an enum type in the schema
+type Enum struct {
+	// Name is the name of the type in postgres
+	Name string `json:"name"`
+
+	// Values are the labels of the enum, in sort order
+	Values []string `json:"values"`
+
+	// Whether or not the enum has been deleted in the virtual schema
+	Deleted bool `json:"-"`
 }
 
 // Table represents a table in the schema
@@ -62,10 +162,111 @@ type Table struct {
 	// ExcludeConstraints is a map of all exclude constraints defined on the table
 	ExcludeConstraints map[string]*ExcludeConstraint `json:"excludeConstraints"`
 
+	// PartitionKey is the partition key of the table, or nil if the table is
+	// not partitioned
+	PartitionKey *PartitionKey `json:"partitionKey"`
+
+	// Partitions is a map of the partitions of a partitioned table
+	Partitions map[string]*Partition `json:"partitions"`
+
+	// Whether row-level security is enabled on the table
+	RLSEnabled bool `json:"rlsEnabled"`
+
+	// Whether row-level security is enforced for the owner of the table too
+	RLSForced bool `json:"rlsForced"`
+
+	// Policies is a map of the row-level security policies defined on the table
+	Policies map[string]*Policy `json:"policies"`
+
+	// Triggers is a map of the user-defined triggers on the table
+	Triggers map[string]*Trigger `json:"triggers"`
+
+	// Privileges are the privileges granted on the table to roles other than
+	// its owner
+	Privileges []Privilege `json:"privileges"`
+
 	// Whether or not the table has been deleted in the virtual schema
 	Deleted bool `json:"-"`
 }
 
+// PartitionKey represents the partition key of a partitioned table
+type PartitionKey struct {
+	// Strategy is the partitioning strategy: range, list or hash
+	Strategy string `json:"strategy"`
+
+	// The columns that make up the partition key
+	Columns []string `json:"columns"`
+}
+
+// Partition represents a partition of a partitioned table
+type Partition struct {
+	// Name is the name of the partition in postgres
+	Name string `json:"name"`
+
+	// Bound is the partition bound, eg FOR VALUES FROM (1) TO (10), or DEFAULT
+	Bound string `json:"bound"`
+}
+
+// Policy represents a row-level security policy on a table
+type Policy struct {
+	// Name is the name of the policy in postgres
+	Name string `json:"name"`
+
+	// Command is the command the policy applies to: ALL, SELECT, INSERT, UPDATE
+	// or DELETE
+	Command string `json:"command"`
+
+	// Permissive is true for permissive policies and false for restrictive
+	// policies
+	Permissive bool `json:"permissive"`
+
+	// The roles the policy applies to
+	Roles []string `json:"roles"`
+
+	// The USING expression of the policy
+	Using string `json:"using,omitempty"`
+
+	// The WITH CHECK expression of the policy
+	WithCheck string `json:"withCheck,omitempty"`
+
+	// The columns referenced by the policy expressions
+	Columns []string `json:"columns"`
+}
+
+// Trigger represents a user-defined trigger on a table
+type Trigger struct {
+	// Name is the name of the trigger in postgres
+	Name string `json:"name"`
+
+	// Function is the name of the function the trigger executes
+	Function string `json:"function"`
+
+	// Timing is when the trigger fires: BEFORE, AFTER or INSTEAD OF
+	Timing string `json:"timing"`
+
+	// Events are the events the trigger fires on: INSERT, UPDATE, DELETE or
+	// TRUNCATE
+	Events []string `json:"events"`
+
+	// ForEach is whether the trigger fires for each ROW or STATEMENT
+	ForEach string `json:"forEach"`
+
+	// Definition is the CREATE TRIGGER statement that defines the trigger
+	Definition string `json:"definition"`
+}
+
+// Privilege represents a privilege granted on a table
+type Privilege struct {
+	// Grantee is the role the privilege is granted to, or PUBLIC
+	Grantee string `json:"grantee"`
+
+	// Privilege is the type of the privilege, eg SELECT or INSERT
+	Privilege string `json:"privilege"`
+
+	// Grantable is true if the grantee may grant the privilege to other roles
+	Grantable bool `json:"grantable"`
+}
+
 // Column represents a column in a table
 type Column struct {
 	// Name is the actual name in postgres
@@ -89,6 +290,10 @@ type Column struct {
 
 	// Postgres type type, e.g enum, composite, range
 	PostgresType string `json:"postgresType"`
+
+	// Identity is how values are generated for an identity column: ALWAYS or
+	// BY DEFAULT. Empty if the column is not an identity column.
+	Identity string `json:"identity,omitempty"`
 }
 
 // Index represents an index on a table
@@ -235,6 +440,158 @@ func (s *Schema) UnRemoveTable(name string) {
 	}
 }
 
+// GetEnum returns an enum type by name
+func (s *Schema) GetEnum(name string) *Enum {
+	if s.Enums == nil {
+		return nil
+	}
+	e, ok := s.Enums[name]
+	if !ok || e.Deleted {
+		return nil
+	}
+	return e
+}
+
+// AddEnum adds an enum type to the schema
+func (s *Schema) AddEnum(name string, e *Enum) {
+	if s.Enums == nil {
+		s.Enums = make(map[string]*Enum)
+	}
+
+	s.Enums[name] = e
+}
+
+// RemoveEnum removes an enum type from the schema by marking it as deleted
+func (s *Schema) RemoveEnum(name string) {
+	if e, ok := s.Enums[name]; ok {
+		e.Deleted = true
+	}
+}
+
+// GetView returns a view by name
+func (s *Schema) GetView(name string) *View {
+	if s.Views == nil {
+		return nil
+	}
+	v, ok := s.Views[name]
+	if !ok || v.Deleted {
+		return nil
+	}
+	return v
+}
+
+// AddView adds a view to the schema
+func (s *Schema) AddView(name string, v *View) {
+	if s.Views == nil {
+		s.Views = make(map[string]*View)
+	}
+
+	s.Views[name] = v
+}
+
+// RemoveView removes a view from the schema by marking it as deleted
+func (s *Schema) RemoveView(name string) {
+	if v, ok := s.Views[name]; ok {
+		v.Deleted = true
+	}
+}
+
+// GetFunction returns a function by name
+func (s *Schema) GetFunction(name string) *Function {
+	if s.Functions == nil {
+		return nil
+	}
+	f, ok := s.Functions[name]
+	if !ok || f.Deleted {
+		return nil
+	}
+	return f
+}
+
+// AddFunction adds a function to the schema
+func (s *Schema) AddFunction(name string, f *Function) {
+	if s.Functions == nil {
+		s.Functions = make(map[string]*Function)
+	}
+
+	s.Functions[name] = f
+}
+
+// RemoveFunction removes a function from the schema by marking it as deleted
+func (s *Schema) RemoveFunction(name string) {
+	if f, ok := s.Functions[name]; ok {
+		f.Deleted = true
+	}
+}
+
+// GetSequence returns a sequence by name
+func (s *Schema) GetSequence(name string) *Sequence {
+	if s.Sequences == nil {
+		return nil
+	}
+	seq, ok := s.Sequences[name]
+	if !ok || seq.Deleted {
+		return nil
+	}
+	return seq
+}
+
+// AddSequence adds a sequence to the schema
+func (s *Schema) AddSequence(name string, seq *Sequence) {
+	if s.Sequences == nil {
+		s.Sequences = make(map[string]*Sequence)
+	}
+
+	s.Sequences[name] = seq
+}
+
+// RemoveSequence removes a sequence from the schema by marking it as deleted
+func (s *Schema) RemoveSequence(name string) {
+	if seq, ok := s.Sequences[name]; ok {
+		seq.Deleted = true
+	}
+}
+
+// EnumColumns returns the names of the columns in each table whose type is
+// the enum `name`
+func (s *Schema) EnumColumns(name string) map[string][]string {
+	columns := make(map[string][]string)
+	for tableName, table := range s.Tables {
+		if table.Deleted {
+			continue
+		}
+		for colName, col := range table.Columns {
+			if col.Deleted || col.PostgresType != "enum" {
+				continue
+			}
+			if unqualifiedTypeName(col.Type) == name {
+				columns[tableName] = append(columns[tableName], colName)
+			}
+		}
+	}
+	for _, cols := range columns {
+		slices.Sort(cols)
+	}
+	return columns
+}
+
+// unqualifiedTypeName strips any schema qualification and quoting from a type
+// name as formatted by postgres
+func unqualifiedTypeName(typ string) string {
+	if strings.HasSuffix(typ, `"`) {
+		// The type name is quoted and may itself contain dots
+		for i := len(typ) - 2; i >= 0; i-- {
+			if typ[i] == '"' && (i == 0 || typ[i-1] == '.') {
+				return strings.ReplaceAll(typ[i+1:len(typ)-1], `""`, `"`)
+			}
+		}
+	}
+	if i := strings.LastIndex(typ, "."); i >= 0 {
+		return typ[i+1:]
+	}
+	return typ
+}
+
 // GetColumn returns a column by name
 func (t *Table) GetColumn(name string) *Column {
 	if t.Columns == nil {
@@ -310,6 +667,90 @@ func (t *Table) GetPrimaryKey() (columns []*Column) {
 	return columns
 }
 
+// StandaloneIndexes returns the indexes on the table that do not back a
+// primary key, unique or exclusion constraint.
+func (t *Table) StandaloneIndexes() map[string]*Index {
+	indexes := make(map[string]*Index, len(t.Indexes))
+	for name, idx := range t.Indexes {
+		if _, ok := t.UniqueConstraints[name]; ok {
+			continue
+		}
+		if _, ok := t.ExcludeConstraints[name]; ok {
+			continue
+		}
+		if t.isPrimaryKeyIndex(idx) {
+			continue
+		}
+		indexes[name] = idx
+	}
+	return indexes
+}
+
+// PrimaryKeyIndex returns the index backing the primary key of the table, or
+// nil if the table has no primary key.
+func (t *Table) PrimaryKeyIndex() *Index {
+	for _, name := range sortedKeys(t.Indexes) {
+		if _, ok := t.UniqueConstraints[name]; ok {
+			continue
+		}
+		if idx := t.Indexes[name]; t.isPrimaryKeyIndex(idx) {
+			return idx
+		}
+	}
+	return nil
+}
+
+func (t *Table) isPrimaryKeyIndex(idx *Index) bool {
+	return idx.Unique && len(t.PrimaryKey) > 0 && slices.Equal(idx.Columns, t.PrimaryKey)
+}
+
+// IsPartitioned returns true if the table is a partitioned table
+func (t *Table) IsPartitioned() bool {
+	return t.PartitionKey != nil
+}
+
+// AddPartition adds a partition to the table
+func (t *Table) AddPartition(p *Partition) {
+	if t.Partitions == nil {
+		t.Partitions = make(map[string]*Partition)
+	}
+
+	t.Partitions[p.Name] = p
+}
+
+// RemovePartition removes a partition from the table
+func (t *Table) RemovePartition(name string) {
+	delete(t.Partitions, name)
+}
+
+// AddPolicy adds a row-level security policy to the table
+func (t *Table) AddPolicy(p *Policy) {
+	if t.Policies == nil {
+		t.Policies = make(map[string]*Policy)
+	}
+
+	t.Policies[p.Name] = p
+}
+
+// RemovePolicy removes a row-level security policy from the table
+func (t *Table) RemovePolicy(name string) {
+	delete(t.Policies, name)
+}
+
+// AddTrigger adds a trigger to the table
+func (t *Table) AddTrigger(tr *Trigger) {
+	if t.Triggers == nil {
+		t.Triggers = make(map[string]*Trigger)
+	}
+
+	t.Triggers[tr.Name] = tr
+}
+
+// RemoveTrigger removes a trigger from the table
+func (t *Table) RemoveTrigger(name string) {
+	delete(t.Triggers, name)
+}
+
 // AddColumn adds a column to the table
 func (t *Table) AddColumn(name string, c *Column) {
 	if t.Columns == nil {
@@ -0,0 +1,327 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package sql2pgroll
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/lib/pq"
+
+	"github.com/xataio/pgroll/pkg/migrations"
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+// ConvertSchema converts a schema snapshot to the pgroll operations that
+// recreate it in an empty schema.
+//
+// Enum types are created with `create_enum` operations and sequences that do
+// not back a serial column with `create_sequence` operations. Tables are
+// created in foreign key dependency order, with their constraints, so that
+// each table is created after the tables it references. Foreign keys that
+// form a cycle between tables are added with `create_constraint` operations
+// once all tables have been created. Sequences owned by a column that is not a
+// serial column are attached to it by a raw SQL operation run on completion.
+// Indexes that do not back a constraint are created last.
+func ConvertSchema(s *schema.Schema) (migrations.Operations, error) {
+	var ops migrations.Operations
+
+	for _, name := range sortedKeys(s.Enums) {
+		e := s.Enums[name]
+		ops = append(ops, &migrations.OpCreateEnum{Name: e.Name, Values: slices.Clone(e.Values)})
+	}
+
+	// Serial columns, which take their default from a sequence they own, create
+	// their own sequence. Other sequences are created before the tables whose
+	// defaults use them, and so can only be attached to the column that owns
+	// them once the tables exist.
+	serials := make(map[string]bool)
+	var owners []string
+	for _, name := range sortedKeys(s.Sequences) {
+		seq := s.Sequences[name]
+		if table, column, ok := strings.Cut(seq.OwnedBy, "."); ok {
+			if isSerialColumn(columnOf(s, table, column), seq) {
+				serials[seq.OwnedBy] = true
+				continue
+			}
+			owners = append(owners, fmt.Sprintf("ALTER SEQUENCE %s OWNED BY %s.%s;",
+				pq.QuoteIdentifier(seq.Name), pq.QuoteIdentifier(table), pq.QuoteIdentifier(column)))
+		}
+		ops = append(ops, createSequenceOp(seq))
+	}
+
+	var deferred []*migrations.OpCreateConstraint
+	for _, table := range tablesInDependencyOrder(s) {
+		op, fks, err := createTableWithConstraintsOp(table.table, table.created, serials)
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, op)
+		deferred = append(deferred, fks...)
+	}
+	for _, op := range deferred {
+		ops = append(ops, op)
+	}
+
+	// Raw SQL run on start must be the only operation in a migration
+	if len(owners) > 0 {
+		ops = append(ops, &migrations.OpRawSQL{Up: strings.Join(owners, "\n"), OnComplete: true})
+	}
+
+	for _, name := range sortedKeys(s.Tables) {
+		table := s.GetTable(name)
+		if table == nil {
+			continue
+		}
+		for _, idx := range sortedIndexes(table.StandaloneIndexes()) {
+			ops = append(ops, createIndexOp(table.Name, idx))
+		}
+	}
+
+	return ops, nil
+}
+
+// createSequenceOp returns the operation that creates `seq`. Options that
+// have their default value for an ascending sequence are omitted.
+func createSequenceOp(seq *schema.Sequence) *migrations.OpCreateSequence {
+	op := &migrations.OpCreateSequence{
+		Name:  seq.Name,
+		As:    migrations.OpCreateSequenceAs(seq.DataType),
+		Cycle: seq.Cycle,
+	}
+	if seq.Increment != 1 {
+		op.Increment = ptr(int(seq.Increment))
+	}
+	if seq.Increment < 0 || seq.MinValue != 1 {
+		op.MinValue = ptr(int(seq.MinValue))
+	}
+	if seq.Increment < 0 || seq.MaxValue != sequenceMaxValues[seq.DataType] {
+		op.MaxValue = ptr(int(seq.MaxValue))
+	}
+	if seq.Start != seq.MinValue {
+		op.StartWith = ptr(int(seq.Start))
+	}
+	if seq.Cache != 1 {
+		op.Cache = ptr(int(seq.Cache))
+	}
+	return op
+}
+
+// sequenceMaxValues are the default maximum values of ascending sequences of
+// each data type
+var sequenceMaxValues = map[string]int64{
+	"smallint": math.MaxInt16,
+	"integer":  math.MaxInt32,
+	"bigint":   math.MaxInt64,
+}
+
+func columnOf(s *schema.Schema, table, column string) *schema.Column {
+	if t := s.GetTable(table); t != nil {
+		return t.GetColumn(column)
+	}
+	return nil
+}
+
+// orderedTable is a table along with the names of the tables created before it
+type orderedTable struct {
+	table   *schema.Table
+	created map[string]bool
+}
+
+// tablesInDependencyOrder returns the tables in `s` ordered so that each table
+// comes after the tables its foreign keys reference. Ties are broken by table
+// name. When the remaining tables reference each other in a cycle, the first
+// of them by name is created next.
+func tablesInDependencyOrder(s *schema.Schema) []orderedTable {
+	remaining := make([]*schema.Table, 0, len(s.Tables))
+	for _, name := range sortedKeys(s.Tables) {
+		if table := s.GetTable(name); table != nil {
+			remaining = append(remaining, table)
+		}
+	}
+
+	created := make(map[string]bool, len(remaining))
+	ordered := make([]orderedTable, 0, len(remaining))
+
+	ready := func(t *schema.Table) bool {
+		for _, fk := range t.ForeignKeys {
+			if fk.ReferencedTable != t.Name && !created[fk.ReferencedTable] && s.GetTable(fk.ReferencedTable) != nil {
+				return false
+			}
+		}
+		return true
+	}
+
+	for len(remaining) > 0 {
+		next := slices.IndexFunc(remaining, ready)
+		if next == -1 {
+			next = 0
+		}
+
+		table := remaining[next]
+		ordered = append(ordered, orderedTable{table: table, created: maps.Clone(created)})
+		created[table.Name] = true
+		remaining = slices.Delete(remaining, next, next+1)
+	}
+
+	return ordered
+}
+
+// createTableWithConstraintsOp returns the operation that creates `table` with
+// its columns and constraints. Only the columns in `serials`, given as
+// `table.column`, are created as serial columns. Foreign keys that reference
+// tables other than `table` that are not in `created` are returned as
+// separate operations.
+func createTableWithConstraintsOp(table *schema.Table, created, serials map[string]bool) (*migrations.OpCreateTable, []*migrations.OpCreateConstraint, error) {
+	op := createTableOp(table)
+
+	// Unique constraints are created with their original names, including those
+	// on a single column
+	op.Constraints = nil
+	for i := range op.Columns {
+		col := table.GetColumn(op.Columns[i].Name)
+		if isSerial(op.Columns[i]) && !serials[table.Name+"."+col.Name] {
+			op.Columns[i].Type, op.Columns[i].Default = col.Type, col.Default
+		}
+
+		op.Columns[i].Unique = false
+		if col.Identity != "" {
+			op.Columns[i].Generated = &migrations.ColumnGenerated{
+				Identity: &migrations.ColumnGeneratedIdentity{
+					UserSpecifiedValues: migrations.ColumnGeneratedIdentityUserSpecifiedValues(col.Identity),
+				},
+			}
+		}
+	}
+	for _, name := range sortedKeys(table.UniqueConstraints) {
+		uc := table.UniqueConstraints[name]
+		op.Constraints = append(op.Constraints, migrations.Constraint{
+			Name:    uc.Name,
+			Type:    migrations.ConstraintTypeUnique,
+			Columns: uc.Columns,
+		})
+	}
+
+	for _, name := range sortedKeys(table.CheckConstraints) {
+		cc := table.CheckConstraints[name]
+		check, noInherit := strings.CutSuffix(cc.Definition, " NO INHERIT")
+		op.Constraints = append(op.Constraints, migrations.Constraint{
+			Name:      cc.Name,
+			Type:      migrations.ConstraintTypeCheck,
+			Check:     check,
+			NoInherit: noInherit,
+		})
+	}
+
+	for _, name := range sortedKeys(table.ExcludeConstraints) {
+		xc := table.ExcludeConstraints[name]
+		elements, ok := exclusionElements(xc.Definition)
+		if !ok {
+			return nil, nil, fmt.Errorf("unable to parse definition of exclusion constraint %q on table %q: %q",
+				xc.Name, table.Name, xc.Definition)
+		}
+		op.Constraints = append(op.Constraints, migrations.Constraint{
+			Name: xc.Name,
+			Type: migrations.ConstraintTypeExclude,
+			Exclude: &migrations.ConstraintExclude{
+				IndexMethod: xc.Method,
+				Elements:    elements,
+				Predicate:   xc.Predicate,
+			},
+		})
+	}
+
+	var deferred []*migrations.OpCreateConstraint
+	for _, name := range sortedKeys(table.ForeignKeys) {
+		fk := table.ForeignKeys[name]
+		ref := &migrations.TableForeignKeyReference{
+			Table:              fk.ReferencedTable,
+			Columns:            fk.ReferencedColumns,
+			OnDeleteSetColumns: fk.OnDeleteSetColumns,
+		}
+		if fk.MatchType != string(migrations.ForeignKeyMatchTypeSIMPLE) {
+			ref.MatchType = migrations.ForeignKeyMatchType(fk.MatchType)
+		}
+		if fk.OnDelete != string(migrations.ForeignKeyActionNOACTION) {
+			ref.OnDelete = migrations.ForeignKeyAction(fk.OnDelete)
+		}
+		if fk.OnUpdate != string(migrations.ForeignKeyActionNOACTION) {
+			ref.OnUpdate = migrations.ForeignKeyAction(fk.OnUpdate)
+		}
+
+		if fk.ReferencedTable == table.Name || created[fk.ReferencedTable] {
+			op.Constraints = append(op.Constraints, migrations.Constraint{
+				Name:       fk.Name,
+				Type:       migrations.ConstraintTypeForeignKey,
+				Columns:    fk.Columns,
+				References: ref,
+			})
+			continue
+		}
+
+		// The referenced table has not been created yet
+		up := make(migrations.MultiColumnUpSQL, len(fk.Columns))
+		down := make(migrations.MultiColumnDownSQL, len(fk.Columns))
+		for _, col := range fk.Columns {
+			up[col] = pq.QuoteIdentifier(col)
+			down[col] = pq.QuoteIdentifier(col)
+		}
+		deferred = append(deferred, &migrations.OpCreateConstraint{
+			Name:       fk.Name,
+			Table:      table.Name,
+			Type:       migrations.OpCreateConstraintTypeForeignKey,
+			Columns:    fk.Columns,
+			References: ref,
+			Up:         up,
+			Down:       down,
+		})
+	}
+
+	return op, deferred, nil
+}
+
+// exclusionElements returns the elements of an exclusion constraint from its
+// definition, for example `c WITH &&` from
+// `EXCLUDE USING gist (c WITH &&) WHERE (c IS NOT NULL)`
+func exclusionElements(definition string) (string, bool) {
+	start := strings.Index(definition, "(")
+	if start == -1 {
+		return "", false
+	}
+
+	depth := 0
+	for i := start; i < len(definition); i++ {
+		switch definition[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return definition[start+1 : i], true
+			}
+		}
+	}
+	return "", false
+}
+
+// isSerialColumn returns whether `col` takes its default from `seq` and `seq`
+// has the options of the sequence of a serial column, and so `col` can be
+// created as a serial column
+func isSerialColumn(col *schema.Column, seq *schema.Sequence) bool {
+	if col == nil || !isSerial(migrationColumn(col)) {
+		return false
+	}
+	if !strings.Contains(*col.Default, pq.QuoteLiteral(seq.Name)) &&
+		!strings.Contains(*col.Default, pq.QuoteLiteral(pq.QuoteIdentifier(seq.Name))) {
+		return false
+	}
+	return seq.DataType == col.Type && seq.Start == 1 && seq.Increment == 1 && seq.MinValue == 1 &&
+		seq.MaxValue == sequenceMaxValues[seq.DataType] && seq.Cache == 1 && !seq.Cycle
+}
+
+func isSerial(c migrations.Column) bool {
+	return c.Type == "serial" || c.Type == "bigserial" || c.Type == "smallserial"
+}
@@ -0,0 +1,301 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package sql2pgroll_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xataio/pgroll/pkg/migrations"
+	"github.com/xataio/pgroll/pkg/schema"
+	"github.com/xataio/pgroll/pkg/sql2pgroll"
+)
+
+func TestConvertSchema(t *testing.T) {
+	t.Parallel()
+
+	// The schema as read from the database by `read_schema`
+	s := &schema.Schema{
+		Name: "public",
+		Enums: map[string]*schema.Enum{
+			"mood": {Name: "mood", Values: []string{"happy", "sad"}},
+		},
+		Sequences: map[string]*schema.Sequence{
+			"users_id_seq": {
+				Name: "users_id_seq", DataType: "integer", Start: 1, Increment: 1,
+				MinValue: 1, MaxValue: 2147483647, Cache: 1, OwnedBy: "users.id",
+			},
+			"invoice_numbers": {
+				Name: "invoice_numbers", DataType: "bigint", Start: 1000, Increment: 1,
+				MinValue: 1, MaxValue: 9223372036854775807, Cache: 1,
+			},
+			// owned by a column, but with options a serial column does not have
+			"account_codes": {
+				Name: "account_codes", DataType: "bigint", Start: 500, Increment: 1,
+				MinValue: 1, MaxValue: 9223372036854775807, Cache: 1, OwnedBy: "accounts.code",
+			},
+		},
+		Tables: map[string]*schema.Table{
+			"users": {
+				Name:       "users",
+				Comment:    "registered users",
+				PrimaryKey: []string{"id"},
+				Columns: map[string]*schema.Column{
+					"id":    {Name: "id", Type: "integer", Default: ptr("nextval('users_id_seq'::regclass)")},
+					"email": {Name: "email", Type: "text", Unique: true},
+					"mood":  {Name: "mood", Type: "mood", Nullable: true},
+				},
+				Indexes: map[string]*schema.Index{
+					"users_pkey":      {Name: "users_pkey", Unique: true, Columns: []string{"id"}, Method: "btree"},
+					"users_email_key": {Name: "users_email_key", Unique: true, Columns: []string{"email"}, Method: "btree"},
+				},
+				UniqueConstraints: map[string]*schema.UniqueConstraint{
+					"users_email_key": {Name: "users_email_key", Columns: []string{"email"}},
+				},
+				CheckConstraints: map[string]*schema.CheckConstraint{
+					"email_length": {
+						Name:       "email_length",
+						Columns:    []string{"email"},
+						Definition: "CHECK ((length(email) > 3)) NO INHERIT",
+					},
+				},
+			},
+			"accounts": {
+				Name:       "accounts",
+				PrimaryKey: []string{"id"},
+				Columns: map[string]*schema.Column{
+					"id":       {Name: "id", Type: "bigint", Identity: "ALWAYS"},
+					"owner_id": {Name: "owner_id", Type: "integer", Nullable: true},
+					"name":     {Name: "name", Type: "text", Nullable: true},
+					"number":   {Name: "number", Type: "bigint", Default: ptr("nextval('invoice_numbers'::regclass)")},
+					"code":     {Name: "code", Type: "bigint", Default: ptr("nextval('account_codes'::regclass)")},
+				},
+				Indexes: map[string]*schema.Index{
+					"accounts_pkey":     {Name: "accounts_pkey", Unique: true, Columns: []string{"id"}, Method: "btree"},
+					"accounts_name_idx": {Name: "accounts_name_idx", Columns: []string{"name"}, Method: "btree", Predicate: ptr("name IS NOT NULL")},
+				},
+				ForeignKeys: map[string]*schema.ForeignKey{
+					"accounts_owner_id_fkey": {
+						Name:              "accounts_owner_id_fkey",
+						Columns:           []string{"owner_id"},
+						ReferencedTable:   "users",
+						ReferencedColumns: []string{"id"},
+						OnDelete:          "CASCADE",
+						OnUpdate:          "NO ACTION",
+						MatchType:         "SIMPLE",
+					},
+				},
+			},
+			// teams and members reference each other
+			"teams": {
+				Name:       "teams",
+				PrimaryKey: []string{"id"},
+				Columns: map[string]*schema.Column{
+					"id":         {Name: "id", Type: "integer"},
+					"captain_id": {Name: "captain_id", Type: "integer", Nullable: true},
+				},
+				Indexes: map[string]*schema.Index{
+					"teams_pkey": {Name: "teams_pkey", Unique: true, Columns: []string{"id"}, Method: "btree"},
+				},
+				ForeignKeys: map[string]*schema.ForeignKey{
+					"teams_captain_id_fkey": {
+						Name:              "teams_captain_id_fkey",
+						Columns:           []string{"captain_id"},
+						ReferencedTable:   "members",
+						ReferencedColumns: []string{"id"},
+						OnDelete:          "NO ACTION",
+						OnUpdate:          "NO ACTION",
+						MatchType:         "SIMPLE",
+					},
+				},
+			},
+			"members": {
+				Name:       "members",
+				PrimaryKey: []string{"id"},
+				Columns: map[string]*schema.Column{
+					"id":      {Name: "id", Type: "integer"},
+					"team_id": {Name: "team_id", Type: "integer", Nullable: true},
+				},
+				Indexes: map[string]*schema.Index{
+					"members_pkey": {Name: "members_pkey", Unique: true, Columns: []string{"id"}, Method: "btree"},
+				},
+				ForeignKeys: map[string]*schema.ForeignKey{
+					"members_team_id_fkey": {
+						Name:              "members_team_id_fkey",
+						Columns:           []string{"team_id"},
+						ReferencedTable:   "teams",
+						ReferencedColumns: []string{"id"},
+						OnDelete:          "SET NULL",
+						OnUpdate:          "NO ACTION",
+						MatchType:         "SIMPLE",
+					},
+				},
+			},
+		},
+	}
+
+	ops, err := sql2pgroll.ConvertSchema(s)
+	require.NoError(t, err)
+
+	// The operations can be run together in a single migration
+	mig := &migrations.Migration{Name: "01_baseline", Baseline: true, Operations: ops}
+	require.NoError(t, mig.Validate(context.Background(), schema.New()))
+
+	assert.Equal(t, migrations.Operations{
+		&migrations.OpCreateEnum{Name: "mood", Values: []string{"happy", "sad"}},
+		&migrations.OpCreateSequence{
+			Name:      "account_codes",
+			StartWith: ptr(500),
+			As:        migrations.OpCreateSequenceAsBigint,
+		},
+		&migrations.OpCreateSequence{
+			Name:      "invoice_numbers",
+			As:        migrations.OpCreateSequenceAsBigint,
+			StartWith: ptr(1000),
+		},
+		&migrations.OpCreateTable{
+			Name:    "users",
+			Comment: ptr("registered users"),
+			Columns: []migrations.Column{
+				{Name: "id", Type: "serial", Pk: true},
+				{Name: "email", Type: "text"},
+				{Name: "mood", Type: "mood", Nullable: true},
+			},
+			Constraints: []migrations.Constraint{
+				{Name: "users_email_key", Type: migrations.ConstraintTypeUnique, Columns: []string{"email"}},
+				{Name: "email_length", Type: migrations.ConstraintTypeCheck, Check: "CHECK ((length(email) > 3))", NoInherit: true},
+			},
+		},
+		&migrations.OpCreateTable{
+			Name: "accounts",
+			Columns: []migrations.Column{
+				{
+					Name: "id", Type: "bigint", Pk: true,
+					Generated: &migrations.ColumnGenerated{
+						Identity: &migrations.ColumnGeneratedIdentity{
+							UserSpecifiedValues: migrations.ColumnGeneratedIdentityUserSpecifiedValuesALWAYS,
+						},
+					},
+				},
+				{Name: "code", Type: "bigint", Default: ptr("nextval('account_codes'::regclass)")},
+				{Name: "name", Type: "text", Nullable: true},
+				{Name: "number", Type: "bigint", Default: ptr("nextval('invoice_numbers'::regclass)")},
+				{Name: "owner_id", Type: "integer", Nullable: true},
+			},
+			Constraints: []migrations.Constraint{
+				{
+					Name:    "accounts_owner_id_fkey",
+					Type:    migrations.ConstraintTypeForeignKey,
+					Columns: []string{"owner_id"},
+					References: &migrations.TableForeignKeyReference{
+						Table:    "users",
+						Columns:  []string{"id"},
+						OnDelete: migrations.ForeignKeyActionCASCADE,
+					},
+				},
+			},
+		},
+		&migrations.OpCreateTable{
+			Name: "members",
+			Columns: []migrations.Column{
+				{Name: "id", Type: "integer", Pk: true},
+				{Name: "team_id", Type: "integer", Nullable: true},
+			},
+		},
+		&migrations.OpCreateTable{
+			Name: "teams",
+			Columns: []migrations.Column{
+				{Name: "id", Type: "integer", Pk: true},
+				{Name: "captain_id", Type: "integer", Nullable: true},
+			},
+			Constraints: []migrations.Constraint{
+				{
+					Name:    "teams_captain_id_fkey",
+					Type:    migrations.ConstraintTypeForeignKey,
+					Columns: []string{"captain_id"},
+					References: &migrations.TableForeignKeyReference{
+						Table:   "members",
+						Columns: []string{"id"},
+					},
+				},
+			},
+		},
+		&migrations.OpCreateConstraint{
+			Name:    "members_team_id_fkey",
+			Table:   "members",
+			Type:    migrations.OpCreateConstraintTypeForeignKey,
+			Columns: []string{"team_id"},
+			References: &migrations.TableForeignKeyReference{
+				Table:    "teams",
+				Columns:  []string{"id"},
+				OnDelete: migrations.ForeignKeyActionSETNULL,
+			},
+			Up:   migrations.MultiColumnUpSQL{"team_id": `"team_id"`},
+			Down: migrations.MultiColumnDownSQL{"team_id": `"team_id"`},
+		},
+		&migrations.OpRawSQL{
+			Up:         `ALTER SEQUENCE "account_codes" OWNED BY "accounts"."code";`,
+			OnComplete: true,
+		},
+		&migrations.OpCreateIndex{
+			Name:      "accounts_name_idx",
+			Table:     "accounts",
+			Columns:   []migrations.IndexField{{Column: "name"}},
+			Predicate: "name IS NOT NULL",
+		},
+	}, ops)
+}
+
+func TestConvertSchemaExclusionConstraints(t *testing.T) {
+	t.Parallel()
+
+	s := &schema.Schema{
+		Name: "public",
+		Tables: map[string]*schema.Table{
+			"bookings": {
+				Name: "bookings",
+				Columns: map[string]*schema.Column{
+					"during": {Name: "during", Type: "tsrange", Nullable: true},
+				},
+				Indexes: map[string]*schema.Index{
+					"no_overlap": {Name: "no_overlap", Columns: []string{"during"}, Method: "gist"},
+				},
+				ExcludeConstraints: map[string]*schema.ExcludeConstraint{
+					"no_overlap": {
+						Name:       "no_overlap",
+						Columns:    []string{"during"},
+						Method:     "gist",
+						Predicate:  "(upper(during) > now())",
+						Definition: "EXCLUDE USING gist (during WITH &&) WHERE ((upper(during) > now()))",
+					},
+				},
+			},
+		},
+	}
+
+	ops, err := sql2pgroll.ConvertSchema(s)
+	require.NoError(t, err)
+
+	assert.Equal(t, migrations.Operations{
+		&migrations.OpCreateTable{
+			Name: "bookings",
+			Columns: []migrations.Column{
+				{Name: "during", Type: "tsrange", Nullable: true},
+			},
+			Constraints: []migrations.Constraint{
+				{
+					Name: "no_overlap",
+					Type: migrations.ConstraintTypeExclude,
+					Exclude: &migrations.ConstraintExclude{
+						IndexMethod: "gist",
+						Elements:    "during WITH &&",
+						Predicate:   "(upper(during) > now())",
+					},
+				},
+			},
+		},
+	}, ops)
+}
@@ -0,0 +1,235 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package sql2pgroll
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/oapi-codegen/nullable"
+
+	"github.com/xataio/pgroll/pkg/migrations"
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+// ConvertSchemaDiff converts a schema diff to the pgroll operations that apply
+// it. Where data has to be migrated between the old and new versions of a
+// column, the `up` and `down` expressions are set to PlaceHolderSQL and must
+// be filled in before the migration is run.
+//
+// Operations are ordered so that new tables are created first and tables are
+// dropped last. Indexes whose definition has changed can not be recreated
+// under the same name in a single migration, so return an error.
+func ConvertSchemaDiff(diff *schema.SchemaDiff) (migrations.Operations, error) {
+	var ops migrations.Operations
+
+	for _, table := range diff.AddedTables {
+		ops = append(ops, createTableOp(table))
+		for _, idx := range sortedIndexes(table.Indexes) {
+			ops = append(ops, createIndexOp(table.Name, idx))
+		}
+	}
+
+	for _, td := range diff.ChangedTables {
+		if len(td.ChangedIndexes) > 0 {
+			return nil, fmt.Errorf("definition of index %q on table %q has changed; give the new index a different name to replace it",
+				td.ChangedIndexes[0].Name, td.Name)
+		}
+
+		for _, idx := range td.DroppedIndexes {
+			ops = append(ops, &migrations.OpDropIndex{Name: idx})
+		}
+
+		for _, col := range td.AddedColumns {
+			ops = append(ops, addColumnOp(td.Name, col))
+		}
+
+		for _, cd := range td.AlteredColumns {
+			ops = append(ops, alterColumnOp(td.Name, cd))
+		}
+
+		for _, idx := range td.AddedIndexes {
+			ops = append(ops, createIndexOp(td.Name, idx))
+		}
+
+		for _, col := range td.DroppedColumns {
+			ops = append(ops, dropColumnOp(td.Name, col))
+		}
+	}
+
+	for _, table := range diff.DroppedTables {
+		ops = append(ops, &migrations.OpDropTable{Name: table})
+	}
+
+	return ops, nil
+}
+
+func createTableOp(table *schema.Table) *migrations.OpCreateTable {
+	op := &migrations.OpCreateTable{Name: table.Name}
+	if table.Comment != "" {
+		op.Comment = ptr(table.Comment)
+	}
+
+	// Primary key columns come first, followed by the remaining columns in name
+	// order
+	names := slices.Clone(table.PrimaryKey)
+	for _, name := range sortedColumnNames(table) {
+		if !slices.Contains(table.PrimaryKey, name) {
+			names = append(names, name)
+		}
+	}
+
+	for _, name := range names {
+		col := migrationColumn(table.GetColumn(name))
+		col.Pk = slices.Contains(table.PrimaryKey, name)
+		if col.Pk {
+			col.Unique = false
+			col.Nullable = false
+		}
+		op.Columns = append(op.Columns, col)
+	}
+
+	// Multi-column unique constraints can not be expressed on a column
+	for _, name := range sortedKeys(table.UniqueConstraints) {
+		uc := table.UniqueConstraints[name]
+		if len(uc.Columns) < 2 {
+			continue
+		}
+		op.Constraints = append(op.Constraints, migrations.Constraint{
+			Name:    uc.Name,
+			Type:    migrations.ConstraintTypeUnique,
+			Columns: uc.Columns,
+		})
+	}
+
+	return op
+}
+
+func addColumnOp(table string, col *schema.Column) *migrations.OpAddColumn {
+	op := &migrations.OpAddColumn{
+		Table:  table,
+		Column: migrationColumn(col),
+	}
+
+	// Existing rows need a value for a NOT NULL column without a default
+	if !col.Nullable && col.Default == nil {
+		op.Up = PlaceHolderSQL
+	}
+
+	return op
+}
+
+func alterColumnOp(table string, cd *schema.ColumnDiff) *migrations.OpAlterColumn {
+	op := &migrations.OpAlterColumn{
+		Table:  table,
+		Column: cd.To.Name,
+	}
+
+	if cd.TypeChanged() {
+		op.Type = ptr(cd.To.Type)
+	}
+	if cd.NullableChanged() {
+		op.Nullable = ptr(cd.To.Nullable)
+	}
+	if cd.DefaultChanged() {
+		if cd.To.Default == nil {
+			op.Default = nullable.NewNullNullable[string]()
+		} else {
+			op.Default = nullable.NewNullableWithValue(*cd.To.Default)
+		}
+	}
+
+	// Changing the type or nullability of a column duplicates and backfills it
+	if cd.TypeChanged() || cd.NullableChanged() {
+		op.Up = PlaceHolderSQL
+		op.Down = PlaceHolderSQL
+	}
+
+	return op
+}
+
+func dropColumnOp(table string, col *schema.Column) *migrations.OpDropColumn {
+	op := &migrations.OpDropColumn{
+		Table:  table,
+		Column: col.Name,
+	}
+
+	// Rows inserted into the new version need a value for the dropped column
+	// if it is NOT NULL without a default
+	if !col.Nullable && col.Default == nil {
+		op.Down = PlaceHolderSQL
+	}
+
+	return op
+}
+
+func createIndexOp(table string, idx *schema.Index) *migrations.OpCreateIndex {
+	op := &migrations.OpCreateIndex{
+		Name:   idx.Name,
+		Table:  table,
+		Unique: idx.Unique,
+	}
+	if idx.Method != "" && idx.Method != string(migrations.OpCreateIndexMethodBtree) {
+		op.Method = migrations.OpCreateIndexMethod(idx.Method)
+	}
+	if idx.Predicate != nil {
+		op.Predicate = *idx.Predicate
+	}
+	for _, col := range idx.Columns {
+		op.Columns = append(op.Columns, migrations.IndexField{Column: col})
+	}
+	return op
+}
+
+func migrationColumn(col *schema.Column) migrations.Column {
+	c := migrations.Column{
+		Name:     col.Name,
+		Type:     col.Type,
+		Nullable: col.Nullable,
+		Default:  col.Default,
+		Unique:   col.Unique,
+	}
+	if col.Comment != "" {
+		c.Comment = ptr(col.Comment)
+	}
+
+	// Serial columns are created with the serial type rather than an explicit
+	// sequence default
+	if col.Default != nil && strings.HasPrefix(*col.Default, "nextval(") {
+		switch col.Type {
+		case "integer":
+			c.Type, c.Default = "serial", nil
+		case "bigint":
+			c.Type, c.Default = "bigserial", nil
+		case "smallint":
+			c.Type, c.Default = "smallserial", nil
+		}
+	}
+
+	return c
+}
+
+func sortedColumnNames(table *schema.Table) []string {
+	names := make([]string, 0, len(table.Columns))
+	for name, col := range table.Columns {
+		if !col.Deleted {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+func sortedIndexes(indexes map[string]*schema.Index) []*schema.Index {
+	result := make([]*schema.Index, 0, len(indexes))
+	for _, name := range sortedKeys(indexes) {
+		result = append(result, indexes[name])
+	}
+	return result
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	return slices.Sorted(maps.Keys(m))
+}
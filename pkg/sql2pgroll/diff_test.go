@@ -0,0 +1,275 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package sql2pgroll_test
+
+import (
+	"testing"
+
+	"github.com/oapi-codegen/nullable"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xataio/pgroll/pkg/migrations"
+	"github.com/xataio/pgroll/pkg/schema"
+	"github.com/xataio/pgroll/pkg/sql2pgroll"
+)
+
+func TestParseSchemaNormalizesTypes(t *testing.T) {
+	t.Parallel()
+
+	s, err := sql2pgroll.ParseSchema("public", `
+		CREATE TABLE public.users (
+			id serial PRIMARY KEY,
+			name varchar(255) NOT NULL,
+			score float8,
+			created_at timestamp(3),
+			tags text[]
+		);
+	`)
+	require.NoError(t, err)
+
+	users := s.GetTable("users")
+	require.NotNil(t, users)
+	assert.Equal(t, []string{"id"}, users.PrimaryKey)
+	assert.Equal(t, "integer", users.GetColumn("id").Type)
+	assert.Equal(t, "nextval('users_id_seq'::regclass)", *users.GetColumn("id").Default)
+	assert.Equal(t, "varchar(255)", users.GetColumn("name").Type)
+	assert.False(t, users.GetColumn("name").Nullable)
+	assert.Equal(t, "double precision", users.GetColumn("score").Type)
+	assert.Equal(t, "timestamp(3) without time zone", users.GetColumn("created_at").Type)
+	assert.Equal(t, "text[]", users.GetColumn("tags").Type)
+}
+
+func TestParseSchemaRejectsUnsupportedStatements(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"other statements":   "CREATE VIEW v AS SELECT 1",
+		"check constraints":  "CREATE TABLE t (a int CHECK (a > 0))",
+		"foreign keys":       "CREATE TABLE t (a int REFERENCES other(id))",
+		"other schemas":      "CREATE TABLE other.t (a int)",
+		"index on new table": "CREATE INDEX idx ON missing (a)",
+	}
+
+	for name, sql := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := sql2pgroll.ParseSchema("public", sql)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestConvertSchemaDiff(t *testing.T) {
+	t.Parallel()
+
+	// The schema as read from the database by `read_schema`
+	current := &schema.Schema{
+		Name: "public",
+		Tables: map[string]*schema.Table{
+			"users": {
+				Name:       "users",
+				PrimaryKey: []string{"id"},
+				Columns: map[string]*schema.Column{
+					"id":       {Name: "id", Type: "integer", Default: ptr("nextval('users_id_seq'::regclass)"), Unique: true},
+					"name":     {Name: "name", Type: "text", Nullable: true},
+					"status":   {Name: "status", Type: "text", Nullable: true, Default: ptr("'active'::text")},
+					"age":      {Name: "age", Type: "integer", Nullable: true},
+					"nickname": {Name: "nickname", Type: "text"},
+				},
+				Indexes: map[string]*schema.Index{
+					"users_pkey":     {Name: "users_pkey", Unique: true, Columns: []string{"id"}, Method: "btree"},
+					"users_name_idx": {Name: "users_name_idx", Columns: []string{"name"}, Method: "btree"},
+				},
+			},
+			"legacy": {
+				Name:    "legacy",
+				Columns: map[string]*schema.Column{"id": {Name: "id", Type: "integer"}},
+			},
+		},
+	}
+
+	desired, err := sql2pgroll.ParseSchema("public", `
+		CREATE TABLE users (
+			id serial PRIMARY KEY,
+			name varchar(255) NOT NULL,
+			status text DEFAULT 'active',
+			age bigint,
+			email text NOT NULL
+		);
+		CREATE INDEX users_email_idx ON users (email);
+		CREATE TABLE posts (
+			id bigserial PRIMARY KEY,
+			title text
+		);
+	`)
+	require.NoError(t, err)
+
+	ops, err := sql2pgroll.ConvertSchemaDiff(schema.Diff(current, desired))
+	require.NoError(t, err)
+
+	assert.Equal(t, migrations.Operations{
+		&migrations.OpCreateTable{
+			Name: "posts",
+			Columns: []migrations.Column{
+				{Name: "id", Type: "bigserial", Pk: true},
+				{Name: "title", Type: "text", Nullable: true},
+			},
+		},
+		&migrations.OpDropIndex{Name: "users_name_idx"},
+		&migrations.OpAddColumn{
+			Table:  "users",
+			Column: migrations.Column{Name: "email", Type: "text"},
+			Up:     sql2pgroll.PlaceHolderSQL,
+		},
+		&migrations.OpAlterColumn{
+			Table:  "users",
+			Column: "age",
+			Type:   ptr("bigint"),
+			Up:     sql2pgroll.PlaceHolderSQL,
+			Down:   sql2pgroll.PlaceHolderSQL,
+		},
+		&migrations.OpAlterColumn{
+			Table:    "users",
+			Column:   "name",
+			Type:     ptr("varchar(255)"),
+			Nullable: ptr(false),
+			Up:       sql2pgroll.PlaceHolderSQL,
+			Down:     sql2pgroll.PlaceHolderSQL,
+		},
+		&migrations.OpCreateIndex{
+			Name:    "users_email_idx",
+			Table:   "users",
+			Columns: []migrations.IndexField{{Column: "email"}},
+		},
+		&migrations.OpDropColumn{
+			Table:  "users",
+			Column: "nickname",
+			Down:   sql2pgroll.PlaceHolderSQL,
+		},
+		&migrations.OpDropTable{Name: "legacy"},
+	}, ops)
+}
+
+func TestConvertSchemaDiffChangedDefault(t *testing.T) {
+	t.Parallel()
+
+	current := &schema.Schema{
+		Tables: map[string]*schema.Table{
+			"t": {
+				Name:    "t",
+				Columns: map[string]*schema.Column{"a": {Name: "a", Type: "integer", Nullable: true, Default: ptr("0")}},
+			},
+		},
+	}
+
+	desired, err := sql2pgroll.ParseSchema("public", "CREATE TABLE t (a int)")
+	require.NoError(t, err)
+
+	ops, err := sql2pgroll.ConvertSchemaDiff(schema.Diff(current, desired))
+	require.NoError(t, err)
+
+	assert.Equal(t, migrations.Operations{
+		&migrations.OpAlterColumn{
+			Table:   "t",
+			Column:  "a",
+			Default: nullable.NewNullNullable[string](),
+		},
+	}, ops)
+}
+
+func TestConvertSchemaDiffChangedStringDefault(t *testing.T) {
+	t.Parallel()
+
+	current := &schema.Schema{
+		Tables: map[string]*schema.Table{
+			"t": {
+				Name:    "t",
+				Columns: map[string]*schema.Column{"a": {Name: "a", Type: "text", Nullable: true, Default: ptr("'(a)b'::text")}},
+			},
+		},
+	}
+
+	// Spaces and parentheses in string literals are significant
+	desired, err := sql2pgroll.ParseSchema("public", "CREATE TABLE t (a text DEFAULT 'a b')")
+	require.NoError(t, err)
+
+	ops, err := sql2pgroll.ConvertSchemaDiff(schema.Diff(current, desired))
+	require.NoError(t, err)
+
+	assert.Equal(t, migrations.Operations{
+		&migrations.OpAlterColumn{
+			Table:   "t",
+			Column:  "a",
+			Default: nullable.NewNullableWithValue("'a b'"),
+		},
+	}, ops)
+
+	// Type casts, parentheses and spaces outside of string literals are not
+	desired, err = sql2pgroll.ParseSchema("public", "CREATE TABLE t (a text DEFAULT ('(a)b'))")
+	require.NoError(t, err)
+
+	ops, err = sql2pgroll.ConvertSchemaDiff(schema.Diff(current, desired))
+	require.NoError(t, err)
+	assert.Empty(t, ops)
+}
+
+func TestConvertSchemaDiffRejectsChangedIndexes(t *testing.T) {
+	t.Parallel()
+
+	current := &schema.Schema{
+		Tables: map[string]*schema.Table{
+			"t": {
+				Name:    "t",
+				Columns: map[string]*schema.Column{"a": {Name: "a", Type: "integer", Nullable: true}, "b": {Name: "b", Type: "integer", Nullable: true}},
+				Indexes: map[string]*schema.Index{"t_idx": {Name: "t_idx", Columns: []string{"a"}, Method: "btree"}},
+			},
+		},
+	}
+
+	desired, err := sql2pgroll.ParseSchema("public", "CREATE TABLE t (a int, b int); CREATE INDEX t_idx ON t (b);")
+	require.NoError(t, err)
+
+	_, err = sql2pgroll.ConvertSchemaDiff(schema.Diff(current, desired))
+	assert.Error(t, err)
+}
+
+func TestConvertSchemaDiffRejectsReorderedIndexColumns(t *testing.T) {
+	t.Parallel()
+
+	current := &schema.Schema{
+		Tables: map[string]*schema.Table{
+			"t": {
+				Name:    "t",
+				Columns: map[string]*schema.Column{"a": {Name: "a", Type: "integer", Nullable: true}, "b": {Name: "b", Type: "integer", Nullable: true}},
+				Indexes: map[string]*schema.Index{"t_idx": {Name: "t_idx", Columns: []string{"a", "b"}, Method: "btree"}},
+			},
+		},
+	}
+
+	desired, err := sql2pgroll.ParseSchema("public", "CREATE TABLE t (a int, b int); CREATE INDEX t_idx ON t (b, a);")
+	require.NoError(t, err)
+
+	diff := schema.Diff(current, desired)
+	require.Len(t, diff.ChangedTables, 1)
+	require.Len(t, diff.ChangedTables[0].ChangedIndexes, 1)
+	assert.Equal(t, []string{"b", "a"}, diff.ChangedTables[0].ChangedIndexes[0].Columns)
+
+	_, err = sql2pgroll.ConvertSchemaDiff(diff)
+	assert.Error(t, err)
+}
+
+func TestStandaloneIndexesKeepsUniqueIndexOnReorderedPrimaryKey(t *testing.T) {
+	t.Parallel()
+
+	table := &schema.Table{
+		Name:       "t",
+		PrimaryKey: []string{"a", "b"},
+		Indexes: map[string]*schema.Index{
+			"t_pkey": {Name: "t_pkey", Unique: true, Columns: []string{"a", "b"}, Method: "btree"},
+			"t_b_a":  {Name: "t_b_a", Unique: true, Columns: []string{"b", "a"}, Method: "btree"},
+		},
+	}
+
+	assert.Equal(t, map[string]*schema.Index{"t_b_a": table.Indexes["t_b_a"]}, table.StandaloneIndexes())
+	assert.Equal(t, table.Indexes["t_pkey"], table.PrimaryKeyIndex())
+}
@@ -0,0 +1,295 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package sql2pgroll
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/xataio/pgroll/pkg/migrations"
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+// UnsupportedSchemaStatementError is returned by ParseSchema when a statement
+// in a desired-state schema file can not be represented in a schema.Schema.
+type UnsupportedSchemaStatementError struct {
+	Statement string
+	Reason    string
+}
+
+func (e UnsupportedSchemaStatementError) Error() string {
+	return fmt.Sprintf("unsupported statement in schema file: %s: %q", e.Reason, e.Statement)
+}
+
+// ParseSchema parses a desired-state schema file made up of CREATE TABLE and
+// CREATE INDEX statements into a schema.Schema named `schemaName`.
+//
+// Column types are normalized to the form in which they are reported by
+// `read_schema`, so that the result can be compared with the live schema using
+// schema.Diff. Tables may be qualified with `schemaName` but not with any other
+// schema. Check and foreign key constraints, generated columns and any other
+// statement are not supported.
+func ParseSchema(schemaName, sql string) (*schema.Schema, error) {
+	ops, err := Convert(sql)
+	if err != nil {
+		return nil, err
+	}
+
+	s := schema.New()
+	s.Name = schemaName
+
+	// Indexes are added once all tables are known, so that tables can be
+	// defined in any order
+	var indexes []*migrations.OpCreateIndex
+
+	for _, op := range ops {
+		switch op := op.(type) {
+		case *migrations.OpCreateTable:
+			name, err := unqualifiedName(schemaName, op.Name)
+			if err != nil {
+				return nil, err
+			}
+			if s.GetTable(name) != nil {
+				return nil, fmt.Errorf("table %q is defined more than once", name)
+			}
+			table, err := tableFromCreateTable(name, op)
+			if err != nil {
+				return nil, err
+			}
+			s.AddTable(name, table)
+
+		case *migrations.OpCreateIndex:
+			indexes = append(indexes, op)
+
+		case *migrations.OpRawSQL:
+			return nil, UnsupportedSchemaStatementError{
+				Statement: op.Up,
+				Reason:    "only CREATE TABLE and CREATE INDEX statements are supported",
+			}
+
+		default:
+			return nil, fmt.Errorf("unsupported statement in schema file: %s", migrations.OperationName(op))
+		}
+	}
+
+	for _, op := range indexes {
+		tableName, err := unqualifiedName(schemaName, op.Table)
+		if err != nil {
+			return nil, err
+		}
+		table := s.GetTable(tableName)
+		if table == nil {
+			return nil, fmt.Errorf("index %q is defined on unknown table %q", op.Name, tableName)
+		}
+		table.Indexes[op.Name] = indexFromCreateIndex(op)
+	}
+
+	return s, nil
+}
+
+func tableFromCreateTable(name string, op *migrations.OpCreateTable) (*schema.Table, error) {
+	table := &schema.Table{
+		Name:              name,
+		Columns:           make(map[string]*schema.Column, len(op.Columns)),
+		Indexes:           make(map[string]*schema.Index),
+		ForeignKeys:       make(map[string]*schema.ForeignKey),
+		CheckConstraints:  make(map[string]*schema.CheckConstraint),
+		UniqueConstraints: make(map[string]*schema.UniqueConstraint),
+	}
+	if op.Comment != nil {
+		table.Comment = *op.Comment
+	}
+
+	for _, col := range op.Columns {
+		if col.Check != nil || col.References != nil || col.Generated != nil {
+			return nil, UnsupportedSchemaStatementError{
+				Statement: fmt.Sprintf("%s.%s", name, col.Name),
+				Reason:    "check constraints, foreign keys and generated columns are not supported",
+			}
+		}
+
+		column := &schema.Column{
+			Name:     col.Name,
+			Type:     normalizeType(col.Type),
+			Nullable: col.Nullable,
+			Default:  col.Default,
+			Unique:   col.Unique,
+		}
+		if col.Comment != nil {
+			column.Comment = *col.Comment
+		}
+
+		// Serial columns are integer columns defaulting to the next value of a
+		// sequence named after the table and column
+		if isSerialType(col.Type) {
+			column.Nullable = false
+			column.Default = ptr(fmt.Sprintf("nextval('%s_%s_seq'::regclass)", name, col.Name))
+		}
+
+		if col.Pk {
+			column.Nullable = false
+			table.PrimaryKey = append(table.PrimaryKey, col.Name)
+		}
+		if col.Unique {
+			ucName := fmt.Sprintf("%s_%s_key", name, col.Name)
+			table.UniqueConstraints[ucName] = &schema.UniqueConstraint{Name: ucName, Columns: []string{col.Name}}
+		}
+
+		table.AddColumn(col.Name, column)
+	}
+
+	for _, c := range op.Constraints {
+		switch c.Type {
+		case migrations.ConstraintTypePrimaryKey:
+			table.PrimaryKey = append(table.PrimaryKey, c.Columns...)
+			for _, col := range c.Columns {
+				if column := table.GetColumn(col); column != nil {
+					column.Nullable = false
+				}
+			}
+
+		case migrations.ConstraintTypeUnique:
+			ucName := c.Name
+			if ucName == "" {
+				ucName = fmt.Sprintf("%s_%s_key", name, strings.Join(c.Columns, "_"))
+			}
+			table.UniqueConstraints[ucName] = &schema.UniqueConstraint{Name: ucName, Columns: c.Columns}
+			if len(c.Columns) == 1 {
+				if column := table.GetColumn(c.Columns[0]); column != nil {
+					column.Unique = true
+				}
+			}
+
+		default:
+			return nil, UnsupportedSchemaStatementError{
+				Statement: fmt.Sprintf("%s.%s", name, c.Name),
+				Reason:    fmt.Sprintf("%s constraints are not supported", c.Type),
+			}
+		}
+	}
+
+	return table, nil
+}
+
+func indexFromCreateIndex(op *migrations.OpCreateIndex) *schema.Index {
+	columns := make([]string, 0, len(op.Columns))
+	for _, col := range op.Columns {
+		columns = append(columns, col.Column)
+	}
+
+	idx := &schema.Index{
+		Name:    op.Name,
+		Unique:  op.Unique,
+		Columns: columns,
+		Method:  string(op.Method),
+	}
+	if idx.Method == "" {
+		idx.Method = string(migrations.OpCreateIndexMethodBtree)
+	}
+	if op.Predicate != "" {
+		idx.Predicate = ptr(op.Predicate)
+	}
+	return idx
+}
+
+// unqualifiedName strips the schema qualifier from `name`, returning an error
+// if `name` is qualified with a schema other than `schemaName`.
+func unqualifiedName(schemaName, name string) (string, error) {
+	qualifier, table, found := strings.Cut(name, ".")
+	if !found {
+		return name, nil
+	}
+	if qualifier != schemaName {
+		return "", fmt.Errorf("table %q is not in schema %q", name, schemaName)
+	}
+	return table, nil
+}
+
+var typeModifierRegex = regexp.MustCompile(`^([^(\[]+?)\s*(\(([^)]*)\))?\s*((?:\[\d*\])*)$`)
+
+// typeAliases maps the names of built-in types to the names used by Postgres'
+// `format_type` function, as reported by `read_schema`.
+var typeAliases = map[string]string{
+	"int":                         "integer",
+	"int4":                        "integer",
+	"integer":                     "integer",
+	"serial":                      "integer",
+	"serial4":                     "integer",
+	"int8":                        "bigint",
+	"bigint":                      "bigint",
+	"bigserial":                   "bigint",
+	"serial8":                     "bigint",
+	"int2":                        "smallint",
+	"smallint":                    "smallint",
+	"smallserial":                 "smallint",
+	"serial2":                     "smallint",
+	"float8":                      "double precision",
+	"double precision":            "double precision",
+	"float4":                      "real",
+	"real":                        "real",
+	"bool":                        "boolean",
+	"boolean":                     "boolean",
+	"decimal":                     "numeric",
+	"numeric":                     "numeric",
+	"varchar":                     "varchar",
+	"character varying":           "varchar",
+	"char":                        "character",
+	"character":                   "character",
+	"bpchar":                      "character",
+	"timestamptz":                 "timestamptz",
+	"timestamp with time zone":    "timestamptz",
+	"timestamp":                   "timestamp without time zone",
+	"timestamp without time zone": "timestamp without time zone",
+	"timetz":                      "time with time zone",
+	"time with time zone":         "time with time zone",
+	"time":                        "time without time zone",
+	"time without time zone":      "time without time zone",
+}
+
+// normalizeType converts a type name as written in DDL to the form reported by
+// `read_schema`, for example `int4` to `integer` and `character varying(255)`
+// to `varchar(255)`. Types that are not built-in are returned unchanged.
+func normalizeType(typ string) string {
+	m := typeModifierRegex.FindStringSubmatch(typ)
+	if m == nil {
+		return typ
+	}
+	base, modifiers, arrayBounds := m[1], m[3], m[4]
+
+	name, ok := typeAliases[strings.ToLower(strings.TrimPrefix(base, "pg_catalog."))]
+	if !ok {
+		return typ
+	}
+
+	// format_type reports array types without bounds
+	arrays := strings.Repeat("[]", strings.Count(arrayBounds, "["))
+
+	if modifiers == "" {
+		if name == "character" {
+			modifiers = "1"
+		} else {
+			return name + arrays
+		}
+	}
+	modifiers = strings.ReplaceAll(modifiers, " ", "")
+
+	// Time types take their precision before the time zone
+	switch name {
+	case "timestamptz":
+		return fmt.Sprintf("timestamp(%s) with time zone%s", modifiers, arrays)
+	case "timestamp without time zone", "time with time zone", "time without time zone":
+		prefix, suffix, _ := strings.Cut(name, " ")
+		return fmt.Sprintf("%s(%s) %s%s", prefix, modifiers, suffix, arrays)
+	}
+
+	return fmt.Sprintf("%s(%s)%s", name, modifiers, arrays)
+}
+
+func isSerialType(typ string) bool {
+	switch strings.ToLower(typ) {
+	case "serial", "serial4", "bigserial", "serial8", "smallserial", "serial2":
+		return true
+	}
+	return false
+}
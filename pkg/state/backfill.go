@@ -0,0 +1,107 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package state
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"github.com/xataio/pgroll/pkg/backfill"
+)
+
+// BackfillCheckpoints stores the progress of the backfills run by a migration
+// in the pgroll state schema.
+type BackfillCheckpoints struct {
+	state     *State
+	schema    string
+	migration string
+}
+
+// BackfillCheckpoints returns a `backfill.CheckpointStore` for the backfills
+// run by migration `name` in `schema`.
+func (s *State) BackfillCheckpoints(schema, name string) *BackfillCheckpoints {
+	return &BackfillCheckpoints{
+		state:     s,
+		schema:    schema,
+		migration: name,
+	}
+}
+
+// LoadCheckpoint returns the checkpoint for `table`, or nil if there is none.
+func (c *BackfillCheckpoints) LoadCheckpoint(ctx context.Context, table string) (*backfill.Checkpoint, error) {
+	var cp backfill.Checkpoint
+	var lastValue pq.StringArray
+	var ranges []byte
+
+	err := c.state.pgConn.QueryRowContext(ctx,
+		fmt.Sprintf("SELECT last_value, ranges, rows_done, started_at, done FROM %s.backfill_progress WHERE schema=$1 AND migration=$2 AND table_name=$3",
+			pq.QuoteIdentifier(c.state.schema)),
+		c.schema, c.migration, table).Scan(&lastValue, &ranges, &cp.RowsDone, &cp.StartedAt, &cp.Done)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	cp.LastValue = lastValue
+
+	if ranges != nil {
+		if err := json.Unmarshal(ranges, &cp.Ranges); err != nil {
+			return nil, fmt.Errorf("unmarshal backfill ranges: %w", err)
+		}
+	}
+
+	return &cp, nil
+}
+
+// SaveCheckpoint stores the checkpoint for `table`. The start time of the
+// backfill is set when the first checkpoint for the table is saved.
+func (c *BackfillCheckpoints) SaveCheckpoint(ctx context.Context, table string, cp *backfill.Checkpoint) error {
+	var ranges []byte
+	if cp.Ranges != nil {
+		var err error
+		if ranges, err = json.Marshal(cp.Ranges); err != nil {
+			return fmt.Errorf("marshal backfill ranges: %w", err)
+		}
+	}
+
+	stmt := fmt.Sprintf(`INSERT INTO %s.backfill_progress (schema, migration, table_name, last_value, ranges, rows_done, done)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (schema, migration, table_name) DO UPDATE
+		SET last_value = EXCLUDED.last_value, ranges = EXCLUDED.ranges, rows_done = EXCLUDED.rows_done, done = EXCLUDED.done, updated_at = CURRENT_TIMESTAMP`,
+		pq.QuoteIdentifier(c.state.schema))
+
+	_, err := c.state.pgConn.ExecContext(ctx, stmt,
+		c.schema, c.migration, table, pq.StringArray(cp.LastValue), ranges, cp.RowsDone, cp.Done)
+	return err
+}
+
+// PendingBackfills returns the names of the tables whose backfill for
+// migration `name` in `schema` has not yet completed, in the order in which
+// the backfills were started.
+func (s *State) PendingBackfills(ctx context.Context, schema, name string) ([]string, error) {
+	rows, err := s.pgConn.QueryContext(ctx,
+		fmt.Sprintf("SELECT table_name FROM %s.backfill_progress WHERE schema=$1 AND migration=$2 AND done=false ORDER BY started_at, table_name",
+			pq.QuoteIdentifier(s.schema)),
+		schema, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var table string
+		if err := rows.Scan(&table); err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+
+	return tables, rows.Err()
+}
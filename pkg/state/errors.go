@@ -5,3 +5,7 @@ package state
 import "errors"
 
 var ErrNoActiveMigration = errors.New("no active migration")
+
+var ErrMigrationNotFound = errors.New("migration not found")
+
+var ErrNotInitialized = errors.New("pgroll state schema is not initialized")
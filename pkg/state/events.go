@@ -0,0 +1,121 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package state
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// EventType is the type of an event in the lifecycle of a migration
+type EventType string
+
+const (
+	EventStart         EventType = "start"
+	EventStarted       EventType = "started"
+	EventBackfillStart EventType = "backfill_start"
+	EventBackfillEnd   EventType = "backfill_end"
+	EventComplete      EventType = "complete"
+	EventRollback      EventType = "rollback"
+	EventFailure       EventType = "failure"
+)
+
+// Event is an entry in the append-only audit trail of the migrations applied
+// to a schema
+type Event struct {
+	ID        int64     `json:"id"`
+	Schema    string    `json:"schema"`
+	Migration string    `json:"migration"`
+	Type      EventType `json:"event"`
+
+	// The phase that failed, for failure events
+	Phase string `json:"phase,omitempty"`
+
+	// The table being backfilled, for backfill events
+	Table string `json:"table,omitempty"`
+
+	// How long the phase or backfill took, for all events except start and
+	// backfill_start
+	DurationMs int64 `json:"duration_ms,omitempty"`
+
+	// The error that caused a failure or a failed backfill
+	Error string `json:"error,omitempty"`
+
+	// Why the migration was rolled back, for rollback events
+	Reason string `json:"reason,omitempty"`
+
+	// The operating system user and host that ran pgroll, the Postgres role
+	// used by pgroll's state connection, and the version of pgroll
+	Operator      string `json:"operator"`
+	Hostname      string `json:"hostname"`
+	Role          string `json:"role"`
+	PgrollVersion string `json:"pgroll_version"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RecordEvent appends `event` to the events table. The operator, host and
+// pgroll version of the event are set from the State instance; its ID, role
+// and creation time are set by the database.
+func (s *State) RecordEvent(ctx context.Context, event *Event) error {
+	stmt := fmt.Sprintf(`INSERT INTO %s.events
+		(schema, migration, event, phase, table_name, duration_ms, error, reason, operator, hostname, pgroll_version)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), NULLIF($6, 0), NULLIF($7, ''), NULLIF($8, ''), NULLIF($9, ''), NULLIF($10, ''), $11)`,
+		pq.QuoteIdentifier(s.schema))
+
+	_, err := s.pgConn.ExecContext(ctx, stmt,
+		event.Schema, event.Migration, event.Type, event.Phase, event.Table, event.DurationMs,
+		event.Error, event.Reason, s.operator, s.hostname, s.pgrollVersion)
+	return err
+}
+
+// Events returns the events recorded for `schema` in the order in which they
+// happened. If `migration` is not empty, only the events of that migration are
+// returned.
+func (s *State) Events(ctx context.Context, schema, migration string) ([]Event, error) {
+	rows, err := s.pgConn.QueryContext(ctx,
+		fmt.Sprintf(`SELECT id, schema, migration, event, phase, table_name, duration_ms, error, reason,
+				operator, hostname, role, pgroll_version, created_at
+			FROM %s.events
+			WHERE schema = $1 AND ($2 = '' OR migration = $2)
+			ORDER BY id`,
+			pq.QuoteIdentifier(s.schema)), schema, migration)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []Event
+	for rows.Next() {
+		var e Event
+		var phase, table, errText, reason, operator, hostname, version sql.NullString
+		var duration sql.NullInt64
+
+		err := rows.Scan(&e.ID, &e.Schema, &e.Migration, &e.Type, &phase, &table, &duration, &errText, &reason,
+			&operator, &hostname, &e.Role, &version, &e.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("row scan: %w", err)
+		}
+
+		e.Phase = phase.String
+		e.Table = table.String
+		e.DurationMs = duration.Int64
+		e.Error = errText.String
+		e.Reason = reason.String
+		e.Operator = operator.String
+		e.Hostname = hostname.String
+		e.PgrollVersion = version.String
+
+		events = append(events, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating rows: %w", err)
+	}
+
+	return events, nil
+}
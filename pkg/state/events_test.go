@@ -0,0 +1,82 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package state_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xataio/pgroll/internal/testutils"
+	"github.com/xataio/pgroll/pkg/state"
+)
+
+func TestRecordedEventsAreReturnedInOrder(t *testing.T) {
+	t.Parallel()
+
+	testutils.WithStateAndConnectionToContainer(t, func(st *state.State, db *sql.DB) {
+		ctx := context.Background()
+
+		recorded := []state.Event{
+			{Schema: "public", Migration: "01_create_table", Type: state.EventStart, DurationMs: 10},
+			{Schema: "public", Migration: "02_add_column", Type: state.EventBackfillStart, Table: "users"},
+			{Schema: "public", Migration: "02_add_column", Type: state.EventBackfillEnd, Table: "users", DurationMs: 20},
+			{Schema: "public", Migration: "02_add_column", Type: state.EventFailure, Phase: "complete", Error: "boom"},
+			{Schema: "public", Migration: "02_add_column", Type: state.EventRollback, Reason: "bad column"},
+			{Schema: "other", Migration: "01_create_table", Type: state.EventStart},
+		}
+		for _, e := range recorded {
+			require.NoError(t, st.RecordEvent(ctx, &e))
+		}
+
+		events, err := st.Events(ctx, "public", "")
+		require.NoError(t, err)
+		require.Len(t, events, 5)
+
+		for i, e := range events {
+			assert.Equal(t, recorded[i].Migration, e.Migration)
+			assert.Equal(t, recorded[i].Type, e.Type)
+			assert.Equal(t, recorded[i].Phase, e.Phase)
+			assert.Equal(t, recorded[i].Table, e.Table)
+			assert.Equal(t, recorded[i].DurationMs, e.DurationMs)
+			assert.Equal(t, recorded[i].Error, e.Error)
+			assert.Equal(t, recorded[i].Reason, e.Reason)
+			assert.Equal(t, "development", e.PgrollVersion)
+			assert.NotEmpty(t, e.Role)
+			assert.False(t, e.CreatedAt.IsZero())
+		}
+
+		// Events can be filtered by migration
+		events, err = st.Events(ctx, "public", "01_create_table")
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, state.EventStart, events[0].Type)
+	})
+}
+
+func TestEventsAreAppendOnly(t *testing.T) {
+	t.Parallel()
+
+	testutils.WithStateAndConnectionToContainer(t, func(st *state.State, db *sql.DB) {
+		ctx := context.Background()
+
+		err := st.RecordEvent(ctx, &state.Event{Schema: "public", Migration: "01_create_table", Type: state.EventStart})
+		require.NoError(t, err)
+
+		for _, stmt := range []string{
+			"UPDATE pgroll.events SET error = 'changed'",
+			"DELETE FROM pgroll.events",
+			"TRUNCATE pgroll.events",
+		} {
+			_, err := db.ExecContext(ctx, stmt)
+			assert.ErrorContains(t, err, "append-only", stmt)
+		}
+
+		events, err := st.Events(ctx, "public", "")
+		require.NoError(t, err)
+		assert.Len(t, events, 1)
+	})
+}
@@ -20,6 +20,10 @@ import (
 type HistoryEntry struct {
 	Migration migrations.RawMigration
 	CreatedAt time.Time
+
+	// The checksum of the migration when it was applied; empty for inferred
+	// migrations and for migrations applied before checksums were recorded
+	Checksum string
 }
 
 // BaselineMigration represents a baseline migration record
@@ -33,7 +37,7 @@ type BaselineMigration struct {
 // recent baseline in ascending timestamp order
 func (s *State) SchemaHistory(ctx context.Context, schema string) ([]HistoryEntry, error) {
 	rows, err := s.pgConn.QueryContext(ctx,
-		fmt.Sprintf(`SELECT name, migration, created_at
+		fmt.Sprintf(`SELECT name, migration, created_at, checksum
 			FROM %[1]s.migrations
 			WHERE schema=$1
 			AND created_at > COALESCE(
@@ -53,8 +57,9 @@ func (s *State) SchemaHistory(ctx context.Context, schema string) ([]HistoryEntr
 	for rows.Next() {
 		var name, rawMigration string
 		var createdAt time.Time
+		var checksum sql.NullString
 
-		if err := rows.Scan(&name, &rawMigration, &createdAt); err != nil {
+		if err := rows.Scan(&name, &rawMigration, &createdAt, &checksum); err != nil {
 			return nil, fmt.Errorf("row scan: %w", err)
 		}
 
@@ -68,6 +73,7 @@ func (s *State) SchemaHistory(ctx context.Context, schema string) ([]HistoryEntr
 		entries = append(entries, HistoryEntry{
 			Migration: mig,
 			CreatedAt: createdAt,
+			Checksum:  checksum.String,
 		})
 	}
 
@@ -116,3 +122,47 @@ func (s *State) LatestBaseline(ctx context.Context, schemaName string) (*Baselin
 		SchemaSnapshot: schemaSnapshot,
 	}, nil
 }
+
+// MigrationRecord is a migration as recorded in the migrations table
+type MigrationRecord struct {
+	Migration migrations.Migration
+	Type      string
+	Parent    *string
+	Done      bool
+
+	// The schema after the migration was completed; empty if the migration has
+	// not been completed
+	ResultingSchema schema.Schema
+}
+
+// GetMigration returns the migration `name` applied to `schemaName`, or
+// ErrMigrationNotFound if there is no such migration
+func (s *State) GetMigration(ctx context.Context, schemaName, name string) (*MigrationRecord, error) {
+	query := fmt.Sprintf(`
+		SELECT migration, migration_type, parent, done, resulting_schema
+		FROM %s.migrations
+		WHERE schema = $1 AND name = $2`,
+		pq.QuoteIdentifier(s.schema))
+
+	var rawMigration, rawSchema []byte
+	record := MigrationRecord{}
+	err := s.pgConn.QueryRowContext(ctx, query, schemaName, name).
+		Scan(&rawMigration, &record.Type, &record.Parent, &record.Done, &rawSchema)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrMigrationNotFound
+		}
+		return nil, fmt.Errorf("failed to query migration: %w", err)
+	}
+
+	if err := json.Unmarshal(rawMigration, &record.Migration); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal migration: %w", err)
+	}
+	record.Migration.Name = name
+
+	if err := json.Unmarshal(rawSchema, &record.ResultingSchema); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal schema: %w", err)
+	}
+
+	return &record, nil
+}
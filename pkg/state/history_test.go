@@ -76,6 +76,13 @@ func TestSchemaHistoryReturnsFullSchemaHistory(t *testing.T) {
 		// Assert that the schema history is correct
 		assert.Equal(t, 2, len(res))
 		assert.Equal(t, migs, actualMigs)
+
+		// Assert that the checksum of each migration was recorded
+		for i := range res {
+			checksum, err := migs[i].Checksum()
+			require.NoError(t, err)
+			assert.Equal(t, checksum, res[i].Checksum)
+		}
 	})
 }
 
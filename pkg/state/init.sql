@@ -178,6 +178,29 @@ CREATE TABLE IF NOT EXISTS placeholder.pgroll_version (
     PRIMARY KEY (version)
 );
 
+-- Table to track the internal upgrades applied to the pgroll state schema
+CREATE TABLE IF NOT EXISTS placeholder.state_upgrades (
+    version integer NOT NULL,
+    name text NOT NULL,
+    pgroll_version text,
+    applied_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (version)
+);
+
+-- Table to track the progress of backfills so that they can be resumed
+CREATE TABLE IF NOT EXISTS placeholder.backfill_progress (
+    schema NAME NOT NULL,
+    migration text NOT NULL,
+    table_name text NOT NULL,
+    last_value text[],
+    rows_done bigint NOT NULL DEFAULT 0,
+    done boolean NOT NULL DEFAULT FALSE,
+    started_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (schema, migration, table_name),
+    FOREIGN KEY (schema, migration) REFERENCES placeholder.migrations (schema, name) ON DELETE CASCADE
+);
+
 -- Helper functions
 -- Are we in the middle of a migration?
 CREATE OR REPLACE FUNCTION placeholder.is_active_migration_period (schemaname name)
@@ -314,9 +337,13 @@ STABLE;
 CREATE OR REPLACE FUNCTION placeholder.read_schema (schemaname text)
     RETURNS jsonb
     LANGUAGE plpgsql
+    SET search_path = placeholder, pg_catalog, pg_temp
     AS $$
 DECLARE
     tables jsonb;
+    views jsonb;
+    functions jsonb;
+    sequences jsonb;
 BEGIN
     SELECT
         json_build_object('name', schemaname, 'tables', (
@@ -373,7 +400,12 @@ BEGIN
                                     'range'
                                 WHEN tp.typtype = 'm' THEN
                                     'multirange'
-                                END AS postgresType FROM pg_attribute AS attr
+                                END AS postgresType, CASE attr.attidentity
+                                WHEN 'a' THEN
+                                    'ALWAYS'
+                                WHEN 'd' THEN
+                                    'BY DEFAULT'
+                                END AS identity FROM pg_attribute AS attr
                                 INNER JOIN pg_type AS tp ON attr.atttypid = tp.oid
                                 LEFT JOIN pg_attrdef AS def ON attr.attrelid = def.adrelid
                                     AND attr.attnum = def.adnum
@@ -384,7 +416,7 @@ BEGIN
                             AND NOT attr.attisdropped
                             AND attr.attrelid = t.oid ORDER BY attr.attnum) c), 'primaryKey', (
                         SELECT
-                            json_agg(pg_attribute.attname) AS primary_key_columns
+                            json_agg(pg_attribute.attname ORDER BY array_position(pg_index.indkey::int2[], pg_attribute.attnum)) AS primary_key_columns
                         FROM pg_index, pg_attribute
                     WHERE
                         indrelid = t.oid
@@ -396,7 +428,7 @@ BEGIN
                             json_object_agg(ix_details.name, json_build_object('name', ix_details.name, 'unique', ix_details.indisunique, 'exclusion', ix_details.indisexclusion, 'columns', ix_details.columns, 'predicate', ix_details.predicate, 'method', ix_details.method, 'definition', ix_details.definition))
                     FROM (
                         SELECT
-                            replace(reverse(split_part(reverse(pi.indexrelid::regclass::text), '.', 1)), '"', '') AS name, pi.indisunique, pi.indisexclusion, array_agg(a.attname) AS columns, pg_get_expr(pi.indpred, t.oid) AS predicate, am.amname AS method, pg_get_indexdef(pi.indexrelid) AS definition
+                            replace(reverse(split_part(reverse(pi.indexrelid::regclass::text), '.', 1)), '"', '') AS name, pi.indisunique, pi.indisexclusion, array_agg(a.attname ORDER BY array_position(pi.indkey::int2[], a.attnum)) AS columns, pg_get_expr(pi.indpred, t.oid) AS predicate, am.amname AS method, pg_get_indexdef(pi.indexrelid) AS definition
                         FROM pg_index pi
                         JOIN pg_attribute a ON a.attrelid = pi.indrelid
                             AND a.attnum = ANY (pi.indkey)
@@ -478,17 +510,210 @@ BEGIN
                                         AND fk_constraint.contype = 'f' GROUP BY fk_constraint.conrelid, fk_constraint.conname, fk_constraint.confrelid, fk_cl.relname, fk_constraint.confkey, fk_constraint.confmatchtype, fk_constraint.confdeltype, fk_constraint.confupdtype) AS fk_info
                                     INNER JOIN pg_attribute ref_attr ON ref_attr.attrelid = fk_info.confrelid
                                         AND ref_attr.attnum = ANY (fk_info.confkey) -- join the columns of the referenced table
-                                GROUP BY fk_info.conname, fk_info.conrelid, fk_info.columns, fk_info.confrelid, fk_info.confmatchtype, fk_info.confdeltype, fk_info.confupdtype, fk_info.relname) AS fk_details)))), '{}'::json)
+                                GROUP BY fk_info.conname, fk_info.conrelid, fk_info.columns, fk_info.confrelid, fk_info.confmatchtype, fk_info.confdeltype, fk_info.confupdtype, fk_info.relname) AS fk_details), 'partitionKey', CASE WHEN pt.partrelid IS NOT NULL THEN
+                                json_build_object('strategy', CASE pt.partstrat
+                                    WHEN 'r' THEN
+                                        'range'
+                                    WHEN 'l' THEN
+                                        'list'
+                                    WHEN 'h' THEN
+                                        'hash'
+                                    END, 'columns', (
+                                        SELECT
+                                            json_agg(pk_attr.attname ORDER BY pk_key.ord)
+                                        FROM unnest(pt.partattrs::int2[])
+                                        WITH ORDINALITY AS pk_key (attnum, ord)
+                                        INNER JOIN pg_attribute AS pk_attr ON pk_attr.attrelid = t.oid
+                                            AND pk_attr.attnum = pk_key.attnum))
+                            END, 'partitions', (
+                                SELECT
+                                    json_object_agg(part.relname, json_build_object('name', part.relname, 'bound', pg_get_expr(part.relpartbound, part.oid)))
+                                FROM pg_inherits AS inh
+                                INNER JOIN pg_class AS part ON part.oid = inh.inhrelid
+                            WHERE
+                                inh.inhparent = t.oid
+                                AND part.relispartition), 'rlsEnabled', t.relrowsecurity, 'rlsForced', t.relforcerowsecurity, 'policies', (
+                                SELECT
+                                    json_object_agg(pol.polname, json_build_object('name', pol.polname, 'command', CASE pol.polcmd
+                                        WHEN 'r' THEN
+                                            'SELECT'
+                                        WHEN 'a' THEN
+                                            'INSERT'
+                                        WHEN 'w' THEN
+                                            'UPDATE'
+                                        WHEN 'd' THEN
+                                            'DELETE'
+                                        ELSE
+                                            'ALL'
+                                        END, 'permissive', pol.polpermissive, 'roles', (
+                                            SELECT
+                                                json_agg(
+                                                    CASE WHEN pol_role.oid = 0 THEN
+                                                        'public'
+                                                    ELSE
+                                                        pg_get_userbyid(pol_role.oid)
+                                                    END ORDER BY pol_role.ord)
+                                            FROM unnest(pol.polroles)
+                                            WITH ORDINALITY AS pol_role (oid, ord)), 'using', pg_get_expr(pol.polqual, pol.polrelid), 'withCheck', pg_get_expr(pol.polwithcheck, pol.polrelid), 'columns', (
+                                            SELECT
+                                                COALESCE(json_agg(DISTINCT pol_attr.attname ORDER BY pol_attr.attname), '[]'::json)
+                                            FROM pg_depend AS pol_dep
+                                            INNER JOIN pg_attribute AS pol_attr ON pol_attr.attrelid = pol_dep.refobjid
+                                                AND pol_attr.attnum = pol_dep.refobjsubid
+                                        WHERE
+                                            pol_dep.classid = 'pg_policy'::regclass
+                                            AND pol_dep.objid = pol.oid
+                                            AND pol_dep.refclassid = 'pg_class'::regclass
+                                            AND pol_dep.refobjid = t.oid
+                                            AND pol_dep.refobjsubid > 0)))
+                                FROM pg_policy AS pol
+                            WHERE
+                                pol.polrelid = t.oid), 'triggers', (
+                                SELECT
+                                    json_object_agg(tg.tgname, json_build_object('name', tg.tgname, 'function', tg_proc.proname, 'timing', CASE WHEN (tg.tgtype::integer & 2) > 0 THEN
+                                            'BEFORE'
+                                        WHEN (tg.tgtype::integer & 64) > 0 THEN
+                                            'INSTEAD OF'
+                                        ELSE
+                                            'AFTER'
+                                        END, 'events', (
+                                            SELECT
+                                                json_agg(tg_event.name ORDER BY tg_event.ord)
+                                            FROM (
+                                                VALUES (1, 'INSERT', 4), (2, 'UPDATE', 16), (3, 'DELETE', 8), (4, 'TRUNCATE', 32)) AS tg_event (ord, name, bit)
+                                        WHERE (tg.tgtype::integer & tg_event.bit) > 0), 'forEach', CASE WHEN (tg.tgtype::integer & 1) > 0 THEN
+                                            'ROW'
+                                        ELSE
+                                            'STATEMENT'
+                                        END, 'definition', pg_get_triggerdef(tg.oid)))
+                                FROM pg_trigger AS tg
+                                INNER JOIN pg_proc AS tg_proc ON tg_proc.oid = tg.tgfoid
+                            WHERE
+                                tg.tgrelid = t.oid
+                                AND NOT tg.tgisinternal
+                                AND tg.tgname NOT LIKE '\_pgroll%'), 'privileges', (
+                                SELECT
+                                    json_agg(json_build_object('grantee', acl.grantee, 'privilege', acl.privilege, 'grantable', acl.grantable) ORDER BY acl.grantee COLLATE "C", acl.privilege COLLATE "C")
+                                FROM (
+                                    SELECT
+                                        CASE WHEN tbl_acl.grantee = 0 THEN
+                                            'PUBLIC'
+                                        ELSE
+                                            pg_get_userbyid(tbl_acl.grantee)
+                                        END AS grantee, tbl_acl.privilege_type AS privilege, tbl_acl.is_grantable AS grantable
+                                    FROM aclexplode(t.relacl) AS tbl_acl
+                                WHERE
+                                    tbl_acl.grantee <> t.relowner) AS acl)))), '{}'::json)
             FROM pg_class AS t
             INNER JOIN pg_namespace AS ns ON t.relnamespace = ns.oid
             LEFT JOIN pg_description AS descr ON t.oid = descr.objoid
                 AND descr.objsubid = 0
+            LEFT JOIN pg_partitioned_table AS pt ON pt.partrelid = t.oid
             WHERE
                 ns.nspname = schemaname
                 AND t.relkind IN ('r', 'p') -- tables only (ignores views, materialized views & foreign tables)
-))
+                AND NOT t.relispartition -- partitions are part of their partitioned table
+), 'enums', (
+        SELECT
+            json_object_agg(tp.typname, json_build_object('name', tp.typname, 'values', (
+                    SELECT
+                        array_agg(e.enumlabel ORDER BY e.enumsortorder)
+                    FROM pg_enum AS e
+                WHERE
+                    e.enumtypid = tp.oid)))
+        FROM pg_type AS tp
+        INNER JOIN pg_namespace AS ns ON tp.typnamespace = ns.oid
+    WHERE
+        ns.nspname = schemaname
+        AND tp.typtype = 'e'))
     INTO
         tables;
+    -- Read view definitions with only the schema on the search path, so that
+    -- tables in the schema are not qualified with its name.
+    PERFORM
+        set_config('search_path', quote_ident(schemaname) || ', pg_catalog, pg_temp', TRUE);
+    SELECT
+        json_object_agg(v.relname, json_build_object('name', v.relname, 'definition', btrim(pg_get_viewdef(v.oid), E' \n;')))
+    INTO
+        views
+    FROM
+        pg_class AS v
+        INNER JOIN pg_namespace AS ns ON v.relnamespace = ns.oid
+    WHERE
+        ns.nspname = schemaname
+        AND v.relkind = 'v';
+    IF views IS NOT NULL THEN
+        tables := jsonb_set(tables, '{views}', views);
+    END IF;
+    -- Read user-defined functions, ignoring those belonging to extensions and
+    -- those created by pgroll.
+    SELECT
+        json_object_agg(p.proname, json_build_object('name', p.proname, 'arguments', pg_get_function_arguments(p.oid), 'returns', pg_get_function_result(p.oid), 'language', l.lanname, 'volatility', CASE p.provolatile
+                WHEN 'i' THEN
+                    'IMMUTABLE'
+                WHEN 's' THEN
+                    'STABLE'
+                ELSE
+                    'VOLATILE'
+                END, 'definition', p.prosrc))
+    INTO
+        functions
+    FROM
+        pg_proc AS p
+        INNER JOIN pg_namespace AS ns ON p.pronamespace = ns.oid
+        INNER JOIN pg_language AS l ON p.prolang = l.oid
+    WHERE
+        ns.nspname = schemaname
+        AND p.prokind = 'f'
+        AND p.proname NOT LIKE '\_pgroll%'
+        AND NOT EXISTS (
+            SELECT
+                1
+            FROM
+                pg_depend AS d
+            WHERE
+                d.classid = 'pg_proc'::regclass
+                AND d.objid = p.oid
+                AND d.deptype = 'e');
+    IF functions IS NOT NULL THEN
+        tables := jsonb_set(tables, '{functions}', functions);
+    END IF;
+    -- Read sequences, ignoring those backing identity columns, which are part
+    -- of their column.
+    SELECT
+        json_object_agg(c.relname, json_build_object('name', c.relname, 'dataType', format_type(seq.seqtypid, NULL), 'start', seq.seqstart, 'increment', seq.seqincrement, 'minValue', seq.seqmin, 'maxValue', seq.seqmax, 'cache', seq.seqcache, 'cycle', seq.seqcycle, 'ownedBy', (
+                    SELECT
+                        owner.relname || '.' || owner_attr.attname
+                    FROM pg_depend AS d
+                    INNER JOIN pg_class AS owner ON owner.oid = d.refobjid
+                    INNER JOIN pg_attribute AS owner_attr ON owner_attr.attrelid = d.refobjid
+                        AND owner_attr.attnum = d.refobjsubid
+                WHERE
+                    d.classid = 'pg_class'::regclass
+                    AND d.objid = c.oid
+                    AND d.refclassid = 'pg_class'::regclass
+                    AND d.deptype = 'a' LIMIT 1)))
+    INTO
+        sequences
+    FROM
+        pg_class AS c
+        INNER JOIN pg_namespace AS ns ON c.relnamespace = ns.oid
+        INNER JOIN pg_sequence AS seq ON seq.seqrelid = c.oid
+    WHERE
+        ns.nspname = schemaname
+        AND c.relkind = 'S'
+        AND NOT EXISTS (
+            SELECT
+                1
+            FROM
+                pg_depend AS d
+            WHERE
+                d.classid = 'pg_class'::regclass
+                AND d.objid = c.oid
+                AND d.deptype = 'i');
+    IF sequences IS NOT NULL THEN
+        tables := jsonb_set(tables, '{sequences}', sequences);
+    END IF;
     RETURN tables;
 END;
 $$;
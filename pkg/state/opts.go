@@ -11,3 +11,11 @@ func WithPgrollVersion(version string) StateOpt {
 		s.pgrollVersion = version
 	}
 }
+
+// WithOperator sets the name of the operator recorded in migration events,
+// overriding the operating system user running pgroll
+func WithOperator(operator string) StateOpt {
+	return func(s *State) {
+		s.operator = operator
+	}
+}
@@ -9,7 +9,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"strings"
+	"os"
+	"os/user"
 
 	"github.com/lib/pq"
 
@@ -28,6 +29,10 @@ type State struct {
 	pgConn        *sql.DB
 	pgrollVersion string
 	schema        string
+
+	// The operating system user and host recorded in migration events
+	operator string
+	hostname string
 }
 
 func New(ctx context.Context, pgURL, stateSchema string, opts ...StateOpt) (*State, error) {
@@ -56,13 +61,26 @@ func New(ctx context.Context, pgURL, stateSchema string, opts ...StateOpt) (*Sta
 		pgConn:        conn,
 		pgrollVersion: "development",
 		schema:        stateSchema,
+		operator:      currentOperator(),
 	}
+	st.hostname, _ = os.Hostname()
 
 	// Apply options to the State instance
 	for _, opt := range opts {
 		opt(st)
 	}
 
+	// Refuse to use a state schema that has had upgrades applied to it that
+	// this version of pgroll does not know about
+	stateVersion, err := st.StateVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if stateVersion > LatestStateVersion() {
+		return nil, fmt.Errorf("%w: binary state version: %d vs schema state version: %d",
+			ErrNewPgrollSchema, LatestStateVersion(), stateVersion)
+	}
+
 	// Check version compatibility between the pgroll version and the version of
 	// the pgroll state schema.
 	compat, err := st.VersionCompatibility(ctx)
@@ -90,47 +108,20 @@ func New(ctx context.Context, pgURL, stateSchema string, opts ...StateOpt) (*Sta
 	return st, nil
 }
 
-// Init initializes the required pg_roll schema to store the state
+// Init initializes the required pg_roll schema to store the state and applies
+// any pending upgrades to it
 func (s *State) Init(ctx context.Context) error {
-	tx, err := s.pgConn.Begin()
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	// Try to obtain an advisory lock.
-	// The key is an arbitrary number, used to distinguish the lock from other locks.
-	// The lock is automatically released when the transaction is committed or rolled back.
-	const key int64 = 0x2c03057fb9525b
-	_, err = tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", key)
-	if err != nil {
-		return err
-	}
-
-	// Perform pgroll state initialization
-	q := strings.ReplaceAll(sqlInit, "placeholder", pq.QuoteIdentifier(s.schema))
-	_, err = tx.ExecContext(ctx, q)
-	if err != nil {
-		return err
-	}
-
-	// Clear the pgroll_version table
-	_, err = tx.ExecContext(ctx, fmt.Sprintf("TRUNCATE TABLE %s.pgroll_version",
-		pq.QuoteIdentifier(s.schema)))
-	if err != nil {
-		return err
-	}
+	_, err := s.initialize(ctx)
+	return err
+}
 
-	// Insert the version of `pgroll` that is being initialized into the
-	// pgroll_version table
-	_, err = tx.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s.pgroll_version (version) VALUES ($1)",
-		pq.QuoteIdentifier(s.schema)),
-		s.pgrollVersion)
-	if err != nil {
-		return err
+// currentOperator returns the name of the operating system user running
+// pgroll, or an empty string if it can't be determined
+func currentOperator() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
 	}
-
-	return tx.Commit()
+	return os.Getenv("USER")
 }
 
 func (s *State) PgConn() *sql.DB {
@@ -238,12 +229,17 @@ func (s *State) Start(ctx context.Context, schemaname string, migration *migrati
 		return fmt.Errorf("unable to marshal migration: %w", err)
 	}
 
+	checksum, err := migration.Checksum()
+	if err != nil {
+		return fmt.Errorf("unable to compute migration checksum: %w", err)
+	}
+
 	// create a new migration object and return the previous known schema
 	// if there is no previous migration, read the schema from postgres
-	stmt := fmt.Sprintf(`INSERT INTO %[1]s.migrations (schema, name, parent, migration) VALUES ($1, $2, %[1]s.latest_migration($1), $3)`,
+	stmt := fmt.Sprintf(`INSERT INTO %[1]s.migrations (schema, name, parent, migration, checksum) VALUES ($1, $2, %[1]s.latest_migration($1), $3, $4)`,
 		pq.QuoteIdentifier(s.schema))
 
-	_, err = s.pgConn.ExecContext(ctx, stmt, schemaname, migration.Name, rawMigration)
+	_, err = s.pgConn.ExecContext(ctx, stmt, schemaname, migration.Name, rawMigration, checksum)
 	return err
 }
 
@@ -352,6 +348,11 @@ func (s *State) CreateBaseline(ctx context.Context, schemaName, baselineVersion
 		return fmt.Errorf("unable to marshal migration: %w", err)
 	}
 
+	checksum, err := emptyMigration.Checksum()
+	if err != nil {
+		return fmt.Errorf("unable to compute migration checksum: %w", err)
+	}
+
 	rawSchema, err := json.Marshal(schema)
 	if err != nil {
 		return fmt.Errorf("unable to marshal schema: %w", err)
@@ -360,11 +361,11 @@ func (s *State) CreateBaseline(ctx context.Context, schemaName, baselineVersion
 	// Insert a baseline migration record
 	stmt := fmt.Sprintf(`
 		INSERT INTO %[1]s.migrations 
-		(schema, name, migration, resulting_schema, done, parent, migration_type, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, TRUE,  %[1]s.latest_migration($1), 'baseline', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`,
+		(schema, name, migration, resulting_schema, done, parent, migration_type, checksum, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, TRUE,  %[1]s.latest_migration($1), 'baseline', $5, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`,
 		pq.QuoteIdentifier(s.schema))
 
-	_, err = s.pgConn.ExecContext(ctx, stmt, schemaName, baselineVersion, rawMigration, rawSchema)
+	_, err = s.pgConn.ExecContext(ctx, stmt, schemaName, baselineVersion, rawMigration, rawSchema, checksum)
 	if err != nil {
 		return fmt.Errorf("failed to insert baseline migration: %w", err)
 	}
@@ -1219,6 +1219,233 @@ func TestReadSchema(t *testing.T) {
 							},
 						},
 					},
+					Enums: map[string]*schema.Enum{
+						"review": {
+							Name:   "review",
+							Values: []string{"good", "bad", "ugly"},
+						},
+					},
+				},
+			},
+			{
+				name: "partitioned table",
+				createStmt: `
+					CREATE TABLE public.table1 (id int, created_at date) PARTITION BY RANGE (created_at);
+					CREATE TABLE public.table1_2024 PARTITION OF public.table1 FOR VALUES FROM ('2024-01-01') TO ('2025-01-01');
+					CREATE TABLE public.table1_default PARTITION OF public.table1 DEFAULT;`,
+				wantSchema: &schema.Schema{
+					Name: "public",
+					Tables: map[string]*schema.Table{
+						"table1": {
+							Name: "table1",
+							Columns: map[string]*schema.Column{
+								"id": {
+									Name:         "id",
+									Type:         "integer",
+									Nullable:     true,
+									PostgresType: "base",
+								},
+								"created_at": {
+									Name:         "created_at",
+									Type:         "date",
+									Nullable:     true,
+									PostgresType: "base",
+								},
+							},
+							PartitionKey: &schema.PartitionKey{
+								Strategy: "range",
+								Columns:  []string{"created_at"},
+							},
+							Partitions: map[string]*schema.Partition{
+								"table1_2024": {
+									Name:  "table1_2024",
+									Bound: "FOR VALUES FROM ('2024-01-01') TO ('2025-01-01')",
+								},
+								"table1_default": {
+									Name:  "table1_default",
+									Bound: "DEFAULT",
+								},
+							},
+						},
+					},
+				},
+			},
+			{
+				name: "row level security policies",
+				createStmt: `
+					CREATE TABLE public.table1 (id int, owner text);
+					ALTER TABLE public.table1 ENABLE ROW LEVEL SECURITY;
+					CREATE POLICY owner_update ON public.table1 AS RESTRICTIVE FOR UPDATE TO PUBLIC USING (owner = 'alice') WITH CHECK (id > 0);`,
+				wantSchema: &schema.Schema{
+					Name: "public",
+					Tables: map[string]*schema.Table{
+						"table1": {
+							Name: "table1",
+							Columns: map[string]*schema.Column{
+								"id": {
+									Name:         "id",
+									Type:         "integer",
+									Nullable:     true,
+									PostgresType: "base",
+								},
+								"owner": {
+									Name:         "owner",
+									Type:         "text",
+									Nullable:     true,
+									PostgresType: "base",
+								},
+							},
+							RLSEnabled: true,
+							Policies: map[string]*schema.Policy{
+								"owner_update": {
+									Name:       "owner_update",
+									Command:    "UPDATE",
+									Permissive: false,
+									Roles:      []string{"public"},
+									Using:      "(owner = 'alice'::text)",
+									WithCheck:  "(id > 0)",
+									Columns:    []string{"id", "owner"},
+								},
+							},
+						},
+					},
+				},
+			},
+			{
+				name: "functions and triggers",
+				createStmt: `
+					CREATE TABLE public.table1 (id int, name text);
+					CREATE FUNCTION public.touch() RETURNS trigger LANGUAGE plpgsql AS $$BEGIN RETURN NEW; END$$;
+					CREATE FUNCTION public.add(a integer, b integer) RETURNS integer LANGUAGE sql IMMUTABLE AS $$SELECT a + b$$;
+					CREATE TRIGGER table1_touch BEFORE INSERT OR UPDATE ON public.table1 FOR EACH ROW WHEN (NEW.name IS NOT NULL) EXECUTE FUNCTION public.touch();`,
+				wantSchema: &schema.Schema{
+					Name: "public",
+					Tables: map[string]*schema.Table{
+						"table1": {
+							Name: "table1",
+							Columns: map[string]*schema.Column{
+								"id": {
+									Name:         "id",
+									Type:         "integer",
+									Nullable:     true,
+									PostgresType: "base",
+								},
+								"name": {
+									Name:         "name",
+									Type:         "text",
+									Nullable:     true,
+									PostgresType: "base",
+								},
+							},
+							Triggers: map[string]*schema.Trigger{
+								"table1_touch": {
+									Name:       "table1_touch",
+									Function:   "touch",
+									Timing:     "BEFORE",
+									Events:     []string{"INSERT", "UPDATE"},
+									ForEach:    "ROW",
+									Definition: "CREATE TRIGGER table1_touch BEFORE INSERT OR UPDATE ON public.table1 FOR EACH ROW WHEN ((new.name IS NOT NULL)) EXECUTE FUNCTION public.touch()",
+								},
+							},
+						},
+					},
+					Functions: map[string]*schema.Function{
+						"touch": {
+							Name:       "touch",
+							Returns:    "trigger",
+							Language:   "plpgsql",
+							Volatility: "VOLATILE",
+							Definition: "BEGIN RETURN NEW; END",
+						},
+						"add": {
+							Name:       "add",
+							Arguments:  "a integer, b integer",
+							Returns:    "integer",
+							Language:   "sql",
+							Volatility: "IMMUTABLE",
+							Definition: "SELECT a + b",
+						},
+					},
+				},
+			},
+			{
+				name: "sequences and identity columns",
+				createStmt: `
+					CREATE TABLE public.table1 (id serial, code int GENERATED ALWAYS AS IDENTITY);
+					CREATE SEQUENCE public.counter AS integer START 10 INCREMENT 5 CACHE 2 CYCLE;`,
+				wantSchema: &schema.Schema{
+					Name: "public",
+					Tables: map[string]*schema.Table{
+						"table1": {
+							Name: "table1",
+							Columns: map[string]*schema.Column{
+								"id": {
+									Name:         "id",
+									Type:         "integer",
+									Default:      ptr("nextval('public.table1_id_seq'::regclass)"),
+									PostgresType: "base",
+								},
+								"code": {
+									Name:         "code",
+									Type:         "integer",
+									PostgresType: "base",
+									Identity:     "ALWAYS",
+								},
+							},
+						},
+					},
+					Sequences: map[string]*schema.Sequence{
+						"table1_id_seq": {
+							Name:      "table1_id_seq",
+							DataType:  "integer",
+							Start:     1,
+							Increment: 1,
+							MinValue:  1,
+							MaxValue:  2147483647,
+							Cache:     1,
+							OwnedBy:   "table1.id",
+						},
+						"counter": {
+							Name:      "counter",
+							DataType:  "integer",
+							Start:     10,
+							Increment: 5,
+							MinValue:  1,
+							MaxValue:  2147483647,
+							Cache:     2,
+							Cycle:     true,
+						},
+					},
+				},
+			},
+			{
+				name: "table privileges",
+				createStmt: `
+					CREATE TABLE public.table1 (id int);
+					GRANT SELECT ON public.table1 TO PUBLIC;
+					GRANT SELECT, INSERT ON public.table1 TO pgroll;
+					GRANT UPDATE ON public.table1 TO pgroll WITH GRANT OPTION;`,
+				wantSchema: &schema.Schema{
+					Name: "public",
+					Tables: map[string]*schema.Table{
+						"table1": {
+							Name: "table1",
+							Columns: map[string]*schema.Column{
+								"id": {
+									Name:         "id",
+									Type:         "integer",
+									Nullable:     true,
+									PostgresType: "base",
+								},
+							},
+							Privileges: []schema.Privilege{
+								{Grantee: "PUBLIC", Privilege: "SELECT"},
+								{Grantee: "pgroll", Privilege: "INSERT"},
+								{Grantee: "pgroll", Privilege: "SELECT"},
+								{Grantee: "pgroll", Privilege: "UPDATE", Grantable: true},
+							},
+						},
+					},
 				},
 			},
 			{
@@ -1273,6 +1500,12 @@ func TestReadSchema(t *testing.T) {
 							},
 						},
 					},
+					Enums: map[string]*schema.Enum{
+						"review": {
+							Name:   "review",
+							Values: []string{"good", "bad", "ugly"},
+						},
+					},
 				},
 			},
 		}
@@ -1377,6 +1610,77 @@ func TestPgrollSchemaVersionUpgrades(t *testing.T) {
 	}
 }
 
+func TestStateSchemaUpgrades(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	t.Run("initialization applies all upgrades", func(t *testing.T) {
+		testutils.WithStateAndConnectionToContainer(t, func(st *state.State, _ *sql.DB) {
+			version, err := st.StateVersion(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, state.LatestStateVersion(), version)
+
+			pending, err := st.PendingUpgrades(ctx)
+			require.NoError(t, err)
+			assert.Empty(t, pending)
+
+			// Upgrading an up to date state schema does nothing
+			applied, err := st.Upgrade(ctx)
+			require.NoError(t, err)
+			assert.Empty(t, applied)
+		})
+	})
+
+	t.Run("pending upgrades are applied", func(t *testing.T) {
+		testutils.WithStateAndConnectionToContainer(t, func(st *state.State, db *sql.DB) {
+			// Undo the most recent upgrade
+			_, err := db.ExecContext(ctx, "ALTER TABLE pgroll.migrations DROP COLUMN checksum")
+			require.NoError(t, err)
+			_, err = db.ExecContext(ctx, "DELETE FROM pgroll.state_upgrades WHERE version = $1", state.LatestStateVersion())
+			require.NoError(t, err)
+
+			pending, err := st.PendingUpgrades(ctx)
+			require.NoError(t, err)
+			require.Len(t, pending, 1)
+			assert.Equal(t, state.LatestStateVersion(), pending[0].Version)
+
+			applied, err := st.Upgrade(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, pending, applied)
+
+			version, err := st.StateVersion(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, state.LatestStateVersion(), version)
+
+			// The upgrade has been applied
+			_, err = db.ExecContext(ctx, "SELECT checksum FROM pgroll.migrations")
+			require.NoError(t, err)
+		})
+	})
+
+	t.Run("state schemas newer than the binary are rejected", func(t *testing.T) {
+		testutils.WithStateAtVersionAndConnectionToContainer(t, "development", func(st *state.State, connStr string, db *sql.DB) {
+			_, err := db.ExecContext(ctx, "INSERT INTO pgroll.state_upgrades (version, name) VALUES ($1, 'from_the_future')",
+				state.LatestStateVersion()+1)
+			require.NoError(t, err)
+
+			_, err = state.New(ctx, connStr, "pgroll")
+			require.ErrorIs(t, err, state.ErrNewPgrollSchema)
+
+			_, err = st.Upgrade(ctx)
+			require.ErrorIs(t, err, state.ErrNewPgrollSchema)
+		})
+	})
+
+	t.Run("uninitialized state schemas can't be upgraded", func(t *testing.T) {
+		testutils.WithUninitializedState(t, func(st *state.State) {
+			_, err := st.Upgrade(ctx)
+			require.ErrorIs(t, err, state.ErrNotInitialized)
+		})
+	})
+}
+
 func TestSchemaAfterMigration(t *testing.T) {
 	t.Parallel()
 
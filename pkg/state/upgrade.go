@@ -0,0 +1,236 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package state
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// upgradeFiles holds the internal upgrades of the pgroll state schema. Each
+// file is named `<version>_<name>.sql`, where versions start at 1 and have no
+// gaps. Upgrades are applied in version order, after `init.sql`, and each is
+// applied exactly once.
+//
+//go:embed upgrades/*.sql
+var upgradeFiles embed.FS
+
+// upgrades are the internal upgrades of the pgroll state schema known to this
+// version of pgroll, ordered by version
+var upgrades = mustReadUpgrades(upgradeFiles)
+
+// Upgrade is an internal upgrade of the pgroll state schema
+type Upgrade struct {
+	// The version of the state schema after the upgrade is applied
+	Version int
+
+	// The name of the upgrade
+	Name string
+
+	sql string
+}
+
+// LatestStateVersion returns the version of the pgroll state schema once all
+// upgrades known to this version of pgroll have been applied
+func LatestStateVersion() int {
+	if len(upgrades) == 0 {
+		return 0
+	}
+	return upgrades[len(upgrades)-1].Version
+}
+
+// StateVersion returns the version of the pgroll state schema, ie the version
+// of the most recent upgrade applied to it. A state schema with no upgrades
+// applied, or that is not initialized, is at version 0.
+func (s *State) StateVersion(ctx context.Context) (int, error) {
+	var exists bool
+	err := s.pgConn.QueryRowContext(ctx,
+		"SELECT to_regclass(format('%I.state_upgrades', $1::text)) IS NOT NULL",
+		s.schema).Scan(&exists)
+	if err != nil {
+		return 0, err
+	}
+	if !exists {
+		return 0, nil
+	}
+
+	return stateVersion(ctx, s.pgConn, s.schema)
+}
+
+// PendingUpgrades returns the upgrades that have not yet been applied to the
+// pgroll state schema, in the order in which they will be applied
+func (s *State) PendingUpgrades(ctx context.Context) ([]Upgrade, error) {
+	version, err := s.StateVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return pendingUpgrades(version), nil
+}
+
+// Upgrade applies all pending upgrades to an initialized pgroll state schema
+// in a single transaction and returns the upgrades that were applied.
+func (s *State) Upgrade(ctx context.Context) ([]Upgrade, error) {
+	ok, err := s.IsInitialized(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrNotInitialized
+	}
+
+	return s.initialize(ctx)
+}
+
+// initialize creates or updates the pgroll state schema and applies any
+// pending upgrades to it, returning the upgrades that were applied.
+func (s *State) initialize(ctx context.Context) ([]Upgrade, error) {
+	tx, err := s.pgConn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Try to obtain an advisory lock.
+	// The key is an arbitrary number, used to distinguish the lock from other locks.
+	// The lock is automatically released when the transaction is committed or rolled back.
+	const key int64 = 0x2c03057fb9525b
+	_, err = tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", key)
+	if err != nil {
+		return nil, err
+	}
+
+	// Perform pgroll state initialization
+	q := strings.ReplaceAll(sqlInit, "placeholder", pq.QuoteIdentifier(s.schema))
+	_, err = tx.ExecContext(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	// Apply the upgrades that have not yet been applied to the state schema.
+	// The version is read while holding the lock so that concurrent
+	// initializations do not apply the same upgrade twice.
+	version, err := stateVersion(ctx, tx, s.schema)
+	if err != nil {
+		return nil, err
+	}
+	if version > LatestStateVersion() {
+		return nil, fmt.Errorf("%w: binary state version: %d vs schema state version: %d",
+			ErrNewPgrollSchema, LatestStateVersion(), version)
+	}
+
+	pending := pendingUpgrades(version)
+	for _, u := range pending {
+		q := strings.ReplaceAll(u.sql, "placeholder", pq.QuoteIdentifier(s.schema))
+		if _, err := tx.ExecContext(ctx, q); err != nil {
+			return nil, fmt.Errorf("applying state upgrade %d (%s): %w", u.Version, u.Name, err)
+		}
+
+		_, err = tx.ExecContext(ctx,
+			fmt.Sprintf("INSERT INTO %s.state_upgrades (version, name, pgroll_version) VALUES ($1, $2, $3)",
+				pq.QuoteIdentifier(s.schema)),
+			u.Version, u.Name, s.pgrollVersion)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Clear the pgroll_version table
+	_, err = tx.ExecContext(ctx, fmt.Sprintf("TRUNCATE TABLE %s.pgroll_version",
+		pq.QuoteIdentifier(s.schema)))
+	if err != nil {
+		return nil, err
+	}
+
+	// Insert the version of `pgroll` that is being initialized into the
+	// pgroll_version table
+	_, err = tx.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s.pgroll_version (version) VALUES ($1)",
+		pq.QuoteIdentifier(s.schema)),
+		s.pgrollVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	return pending, tx.Commit()
+}
+
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// stateVersion returns the version of the most recent upgrade recorded in the
+// `state_upgrades` table of the state schema
+func stateVersion(ctx context.Context, conn queryRower, schema string) (int, error) {
+	var version int
+	err := conn.QueryRowContext(ctx,
+		fmt.Sprintf("SELECT COALESCE(MAX(version), 0) FROM %s.state_upgrades", pq.QuoteIdentifier(schema))).
+		Scan(&version)
+	return version, err
+}
+
+// pendingUpgrades returns the upgrades that come after state version `version`
+func pendingUpgrades(version int) []Upgrade {
+	var pending []Upgrade
+	for _, u := range upgrades {
+		if u.Version > version {
+			pending = append(pending, u)
+		}
+	}
+	return pending
+}
+
+// readUpgrades reads the upgrades in the `upgrades` directory of `fsys`,
+// ordered by version. Versions must start at 1 and have no gaps.
+func readUpgrades(fsys fs.FS) ([]Upgrade, error) {
+	files, err := fs.Glob(fsys, "upgrades/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Upgrade
+	for _, file := range files {
+		base := strings.TrimSuffix(path.Base(file), ".sql")
+		v, name, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("state upgrade %q is not named <version>_<name>.sql", file)
+		}
+		version, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("state upgrade %q has an invalid version: %w", file, err)
+		}
+
+		contents, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, Upgrade{Version: version, Name: name, sql: string(contents)})
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Version < result[j].Version })
+
+	for i, u := range result {
+		if u.Version != i+1 {
+			return nil, fmt.Errorf("state upgrade %q has version %d, expected %d", u.Name, u.Version, i+1)
+		}
+	}
+
+	return result, nil
+}
+
+func mustReadUpgrades(fsys fs.FS) []Upgrade {
+	u, err := readUpgrades(fsys)
+	if err != nil {
+		panic(err)
+	}
+	return u
+}
@@ -0,0 +1,62 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package state
+
+import (
+	"fmt"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadUpgrades(t *testing.T) {
+	t.Parallel()
+
+	t.Run("embedded upgrades are valid", func(t *testing.T) {
+		u, err := readUpgrades(upgradeFiles)
+		require.NoError(t, err)
+
+		require.NotEmpty(t, u)
+		assert.Equal(t, len(u), LatestStateVersion())
+	})
+
+	t.Run("upgrades are ordered by version", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"upgrades/0010_tenth.sql":  &fstest.MapFile{Data: []byte("SELECT 10")},
+			"upgrades/0002_second.sql": &fstest.MapFile{Data: []byte("SELECT 2")},
+			"upgrades/0001_first.sql":  &fstest.MapFile{Data: []byte("SELECT 1")},
+		}
+		for i := 3; i < 10; i++ {
+			fsys[fmt.Sprintf("upgrades/%04d_upgrade.sql", i)] = &fstest.MapFile{Data: []byte("SELECT 1")}
+		}
+
+		u, err := readUpgrades(fsys)
+		require.NoError(t, err)
+
+		require.Len(t, u, 10)
+		assert.Equal(t, Upgrade{Version: 1, Name: "first", sql: "SELECT 1"}, u[0])
+		assert.Equal(t, Upgrade{Version: 2, Name: "second", sql: "SELECT 2"}, u[1])
+		assert.Equal(t, Upgrade{Version: 10, Name: "tenth", sql: "SELECT 10"}, u[9])
+	})
+
+	t.Run("gaps in versions are rejected", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"upgrades/0001_first.sql": &fstest.MapFile{Data: []byte("SELECT 1")},
+			"upgrades/0003_third.sql": &fstest.MapFile{Data: []byte("SELECT 3")},
+		}
+
+		_, err := readUpgrades(fsys)
+		assert.ErrorContains(t, err, `state upgrade "third" has version 3, expected 2`)
+	})
+
+	t.Run("badly named upgrades are rejected", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"upgrades/first.sql": &fstest.MapFile{Data: []byte("SELECT 1")},
+		}
+
+		_, err := readUpgrades(fsys)
+		assert.ErrorContains(t, err, "is not named <version>_<name>.sql")
+	})
+}
@@ -0,0 +1,40 @@
+-- SPDX-License-Identifier: Apache-2.0
+
+-- Append-only audit trail of the events in the lifecycle of each migration.
+-- Events are kept after their migration is rolled back, so there is no foreign
+-- key to the migrations table.
+CREATE TABLE IF NOT EXISTS placeholder.events (
+    id bigint GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    schema NAME NOT NULL,
+    migration text NOT NULL,
+    event varchar(32) NOT NULL CONSTRAINT events_event_check CHECK (event IN ('start', 'started', 'backfill_start', 'backfill_end', 'complete', 'rollback', 'failure')),
+    phase text,
+    table_name text,
+    duration_ms bigint,
+    error text,
+    reason text,
+    operator text,
+    hostname text,
+    role text NOT NULL DEFAULT CURRENT_USER,
+    pgroll_version text,
+    created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS events_schema_migration ON placeholder.events (schema, migration);
+
+-- Reject any change to recorded events
+CREATE OR REPLACE FUNCTION placeholder.events_append_only ()
+    RETURNS TRIGGER
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+    RAISE EXCEPTION 'pgroll events are append-only: % is not allowed', tg_op;
+END;
+$$;
+
+DROP TRIGGER IF EXISTS events_append_only ON placeholder.events;
+
+CREATE TRIGGER events_append_only
+    BEFORE UPDATE OR DELETE OR TRUNCATE ON placeholder.events
+    FOR EACH STATEMENT
+    EXECUTE FUNCTION placeholder.events_append_only ();
@@ -0,0 +1,6 @@
+-- SPDX-License-Identifier: Apache-2.0
+
+-- Add a column to store the checksum of each migration applied by pgroll so
+-- that changes to already applied migration files can be detected
+ALTER TABLE placeholder.migrations
+    ADD COLUMN IF NOT EXISTS checksum text;
@@ -0,0 +1,6 @@
+-- SPDX-License-Identifier: Apache-2.0
+
+-- Add a column to store the progress of each key range of a table that is
+-- backfilled in parallel, so that parallel backfills can be resumed
+ALTER TABLE placeholder.backfill_progress
+    ADD COLUMN IF NOT EXISTS ranges jsonb;
@@ -0,0 +1,118 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Package telemetry records traces and metrics for the migration lifecycle
+// using the OpenTelemetry API.
+//
+// Spans and measurements are sent to the global OpenTelemetry tracer and meter
+// providers, so nothing is recorded unless the application embedding pgroll
+// installs providers with `otel.SetTracerProvider` and
+// `otel.SetMeterProvider`. The pgroll CLI does this when its telemetry flags
+// are set.
+package telemetry
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/xataio/pgroll"
+
+// Attribute keys used on spans and measurements
+const (
+	SchemaKey    = attribute.Key("pgroll.schema")
+	MigrationKey = attribute.Key("pgroll.migration")
+	OperationKey = attribute.Key("pgroll.operation")
+	ActionKey    = attribute.Key("pgroll.action")
+	TableKey     = attribute.Key("pgroll.table")
+	PhaseKey     = attribute.Key("pgroll.phase")
+)
+
+// StartSpan starts a span named `name` as a child of any span in `ctx`
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// EndSpan ends `span`, recording `err` on it if it is not nil
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+type instruments struct {
+	backfillRows          metric.Int64Counter
+	backfillBatchDuration metric.Float64Histogram
+	backfillRowsPerSecond metric.Float64Gauge
+	lockTimeoutRetries    metric.Int64Counter
+	phaseDuration         metric.Float64Histogram
+}
+
+var (
+	instrumentsOnce sync.Once
+	metrics         instruments
+)
+
+// meter returns the instruments used to record pgroll metrics, creating them
+// on first use. Instruments created from the global meter provider forward to
+// any provider installed later, so they only need to be created once.
+func meter() *instruments {
+	instrumentsOnce.Do(func() {
+		m := otel.Meter(instrumentationName)
+
+		// Errors are only returned for invalid instrument names and units, so
+		// are ignored: a failed instrument is a no-op instrument
+		metrics.backfillRows, _ = m.Int64Counter("pgroll.backfill.rows",
+			metric.WithDescription("Number of rows backfilled"),
+			metric.WithUnit("{row}"))
+		metrics.backfillBatchDuration, _ = m.Float64Histogram("pgroll.backfill.batch.duration",
+			metric.WithDescription("Time taken to backfill a batch of rows"),
+			metric.WithUnit("s"))
+		metrics.backfillRowsPerSecond, _ = m.Float64Gauge("pgroll.backfill.rows_per_second",
+			metric.WithDescription("Backfill throughput of the most recent batch"),
+			metric.WithUnit("{row}/s"))
+		metrics.lockTimeoutRetries, _ = m.Int64Counter("pgroll.db.lock_timeout.retries",
+			metric.WithDescription("Number of statements retried after exceeding lock_timeout"),
+			metric.WithUnit("{retry}"))
+		metrics.phaseDuration, _ = m.Float64Histogram("pgroll.migration.phase.duration",
+			metric.WithDescription("Time taken to start, complete or roll back a migration"),
+			metric.WithUnit("s"))
+	})
+	return &metrics
+}
+
+// RecordBackfillBatch records that a batch of `rows` rows of `table` was
+// backfilled in `elapsed`
+func RecordBackfillBatch(ctx context.Context, table string, rows int, elapsed time.Duration) {
+	m := meter()
+	attrs := metric.WithAttributes(TableKey.String(table))
+
+	m.backfillRows.Add(ctx, int64(rows), attrs)
+	m.backfillBatchDuration.Record(ctx, elapsed.Seconds(), attrs)
+	if elapsed > 0 {
+		m.backfillRowsPerSecond.Record(ctx, float64(rows)/elapsed.Seconds(), attrs)
+	}
+}
+
+// RecordLockTimeoutRetry records that a statement is being retried after
+// exceeding lock_timeout
+func RecordLockTimeoutRetry(ctx context.Context) {
+	meter().lockTimeoutRetries.Add(ctx, 1)
+}
+
+// RecordPhase records the time taken by a migration phase, such as start,
+// complete or rollback, and whether it succeeded
+func RecordPhase(ctx context.Context, phase string, elapsed time.Duration, err error) {
+	meter().phaseDuration.Record(ctx, elapsed.Seconds(), metric.WithAttributes(
+		PhaseKey.String(phase),
+		attribute.Bool("pgroll.success", err == nil),
+	))
+}
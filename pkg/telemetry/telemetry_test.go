@@ -0,0 +1,76 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package telemetry_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/xataio/pgroll/pkg/telemetry"
+)
+
+func TestTelemetry(t *testing.T) {
+	ctx := context.Background()
+
+	// Providers are global, so are installed once for all subtests
+	reader := sdkmetric.NewManualReader()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	spans := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)))
+
+	t.Run("backfill batches are recorded", func(t *testing.T) {
+		telemetry.RecordBackfillBatch(ctx, "users", 100, 500*time.Millisecond)
+		telemetry.RecordBackfillBatch(ctx, "users", 50, 250*time.Millisecond)
+
+		rm := metricdata.ResourceMetrics{}
+		require.NoError(t, reader.Collect(ctx, &rm))
+
+		rows := findMetric(t, rm, "pgroll.backfill.rows").Data.(metricdata.Sum[int64])
+		require.Len(t, rows.DataPoints, 1)
+		assert.Equal(t, int64(150), rows.DataPoints[0].Value)
+
+		rate := findMetric(t, rm, "pgroll.backfill.rows_per_second").Data.(metricdata.Gauge[float64])
+		require.Len(t, rate.DataPoints, 1)
+		assert.InDelta(t, 200.0, rate.DataPoints[0].Value, 0.001)
+
+		latency := findMetric(t, rm, "pgroll.backfill.batch.duration").Data.(metricdata.Histogram[float64])
+		require.Len(t, latency.DataPoints, 1)
+		assert.Equal(t, uint64(2), latency.DataPoints[0].Count)
+	})
+
+	t.Run("failed spans record the error", func(t *testing.T) {
+		_, span := telemetry.StartSpan(ctx, "pgroll.start", telemetry.MigrationKey.String("01_create_table"))
+		telemetry.EndSpan(span, errors.New("boom"))
+
+		ended := spans.Ended()
+		require.NotEmpty(t, ended)
+		last := ended[len(ended)-1]
+		assert.Equal(t, "pgroll.start", last.Name())
+		assert.Equal(t, codes.Error, last.Status().Code)
+		assert.Equal(t, "boom", last.Status().Description)
+	})
+}
+
+func findMetric(t *testing.T, rm metricdata.ResourceMetrics, name string) metricdata.Metrics {
+	t.Helper()
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name == name {
+				return m
+			}
+		}
+	}
+	t.Fatalf("metric %q not found", name)
+	return metricdata.Metrics{}
+}